@@ -0,0 +1,160 @@
+// Package cli implements the administration subcommands of the arc binary,
+// which talk to a running arc instance with the credentials read from the
+// env or a config file.
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const (
+	envURL         = "ARC_URL"
+	envUsername    = "ARC_USERNAME"
+	envPassword    = "ARC_PASSWORD"
+	envConfig      = "ARC_CONFIG"
+	defaultURL     = "http://localhost:8000"
+	defaultConfig  = ".arc"
+	exitOK         = 0
+	exitFailure    = 1
+	exitUsageError = 2
+)
+
+// command is a subcommand of the cli.
+type command struct {
+	usage string
+	run   func(c *client, args []string) error
+}
+
+var commands = map[string]command{
+	"health": {
+		usage: "health",
+		run:   health,
+	},
+	"user": {
+		usage: "user create|get|list|delete [flags] [username]",
+		run:   users,
+	},
+	"permission": {
+		usage: "permission create|get|list|delete [flags] [username]",
+		run:   permissions,
+	},
+	"reindex": {
+		usage: "reindex [-body file] <index> [destination_index]",
+		run:   reindex,
+	},
+	"analytics": {
+		usage: "analytics export [-index name] [-query json] [-out file]",
+		run:   analytics,
+	},
+}
+
+// IsCommand checks whether the argument names a subcommand.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run runs the subcommand named by the first argument and returns the exit
+// code. The config file, in KEY=VALUE format, is loaded with loadEnv.
+func Run(args []string, loadEnv func(string) error, stdout, stderr io.Writer) int {
+	if len(args) == 0 || !IsCommand(args[0]) {
+		printUsage(stderr)
+		return exitUsageError
+	}
+	cmd := commands[args[0]]
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	config := fs.String("config", configPath(), "Path to the config file with the ARC_URL, ARC_USERNAME and ARC_PASSWORD")
+	url := fs.String("url", "", "Address of the arc instance, overrides ARC_URL")
+	username := fs.String("username", "", "Username of the arc user, overrides ARC_USERNAME")
+	password := fs.String("password", "", "Password of the arc user, overrides ARC_PASSWORD")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: arc", cmd.usage)
+		fs.PrintDefaults()
+	}
+	// the subcommands parse their own flags, the common flags come first
+	rest, err := parseCommon(fs, args[1:])
+	if err != nil {
+		return exitUsageError
+	}
+
+	if err := loadEnv(*config); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintln(stderr, "arc: unable to load the config file", *config, ":", err)
+		return exitFailure
+	}
+	c := newClient(
+		firstOf(*url, os.Getenv(envURL), defaultURL),
+		firstOf(*username, os.Getenv(envUsername)),
+		firstOf(*password, os.Getenv(envPassword)),
+		stdout,
+	)
+	if err := cmd.run(c, rest); err != nil {
+		if err == flag.ErrHelp {
+			return exitUsageError
+		}
+		fmt.Fprintln(stderr, "arc:", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// parseCommon parses the common flags preceding the arguments of the
+// subcommand, the unknown flags are left to the subcommand.
+func parseCommon(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name := strings.TrimLeft(args[i], "-")
+		if !strings.HasPrefix(args[i], "-") || fs.Lookup(strings.SplitN(name, "=", 2)[0]) == nil {
+			rest = append(rest, args[i])
+			continue
+		}
+		common := []string{args[i]}
+		if !strings.Contains(name, "=") && i+1 < len(args) {
+			common = append(common, args[i+1])
+			i++
+		}
+		if err := fs.Parse(common); err != nil {
+			return nil, err
+		}
+	}
+	return rest, nil
+}
+
+func configPath() string {
+	if path := os.Getenv(envConfig); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return defaultConfig
+	}
+	return home + string(os.PathSeparator) + defaultConfig
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: arc <command> [-config file] [-url url] [-username name] [-password password] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, "  arc", commands[name].usage)
+	}
+}
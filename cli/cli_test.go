@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRun(t *testing.T) {
+	type request struct {
+		method, path, username string
+		body                   map[string]interface{}
+	}
+	var requests []request
+	scrolls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		username, _, _ := req.BasicAuth()
+		r := request{method: req.Method, path: req.URL.Path, username: username}
+		raw, _ := ioutil.ReadAll(req.Body)
+		json.Unmarshal(raw, &r.body)
+		requests = append(requests, r)
+
+		switch req.URL.Path {
+		case "/v1/_user/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"message":"user with \"username\"=\"missing\" not found"}}`))
+		case "/.analytics/_search":
+			w.Write([]byte(`{"_scroll_id":"s1","hits":{"hits":[{"_source":{"query":"a"}},{"_source":{"query": "b"}}]}}`))
+		case "/_search/scroll":
+			if req.Method == http.MethodPost {
+				scrolls++
+			}
+			w.Write([]byte(`{"_scroll_id":"s1","hits":{"hits":[]}}`))
+		default:
+			w.Write([]byte(`{"message":"ok"}`))
+		}
+	}))
+	defer server.Close()
+
+	noConfig := func(string) error { return os.ErrNotExist }
+	run := func(args ...string) (int, string, string) {
+		requests, scrolls = nil, 0
+		var stdout, stderr bytes.Buffer
+		code := Run(args, noConfig, &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+	os.Setenv(envURL, server.URL)
+	os.Setenv(envUsername, "admin")
+	defer os.Unsetenv(envURL)
+	defer os.Unsetenv(envUsername)
+
+	Convey("Users are created with the credentials of the env", t, func() {
+		code, stdout, _ := run("user", "create", "-admin", "-categories", "docs, search", "foo", "bar")
+		So(code, ShouldEqual, exitOK)
+		So(stdout, ShouldContainSubstring, `"message": "ok"`)
+		So(requests[0].method, ShouldEqual, http.MethodPost)
+		So(requests[0].path, ShouldEqual, "/v1/_user")
+		So(requests[0].username, ShouldEqual, "admin")
+		So(requests[0].body["username"], ShouldEqual, "foo")
+		So(requests[0].body["is_admin"], ShouldEqual, true)
+		So(requests[0].body["categories"], ShouldResemble, []interface{}{"docs", "search"})
+	})
+
+	Convey("The common flags override the env", t, func() {
+		code, _, _ := run("permission", "-username", "ops", "create", "-indices", "logs-*", "-ttl", "1h")
+		So(code, ShouldEqual, exitOK)
+		So(requests[0].username, ShouldEqual, "ops")
+		So(requests[0].path, ShouldEqual, "/v1/_permission")
+		So(requests[0].body["ttl"], ShouldEqual, 3.6e12)
+	})
+
+	Convey("The errors of arc are reported", t, func() {
+		code, _, stderr := run("user", "get", "missing")
+		So(code, ShouldEqual, exitFailure)
+		So(stderr, ShouldContainSubstring, `404 user with "username"="missing" not found`)
+	})
+
+	Convey("Reindexes are triggered into the destination index", t, func() {
+		code, _, _ := run("reindex", "products", "products_v2")
+		So(code, ShouldEqual, exitOK)
+		So(requests[0].path, ShouldEqual, "/v1/_reindex/products/products_v2")
+	})
+
+	Convey("Analytics are exported one record per line", t, func() {
+		code, stdout, _ := run("analytics", "export")
+		So(code, ShouldEqual, exitOK)
+		So(stdout, ShouldEqual, "{\"query\":\"a\"}\n{\"query\":\"b\"}\n")
+		So(scrolls, ShouldEqual, 1)
+		So(requests[len(requests)-1].method, ShouldEqual, http.MethodDelete)
+	})
+
+	Convey("Unknown commands print the usage", t, func() {
+		So(IsCommand("serve"), ShouldBeFalse)
+		code, _, stderr := run("serve")
+		So(code, ShouldEqual, exitUsageError)
+		So(stderr, ShouldContainSubstring, "usage: arc <command>")
+	})
+}
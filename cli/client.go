@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// apiVersion prefixes the paths of the arc apis.
+const apiVersion = "/v1"
+
+// client makes the requests to the arc instance.
+type client struct {
+	url      string
+	username string
+	password string
+	http     *http.Client
+	out      io.Writer
+}
+
+func newClient(url, username, password string, out io.Writer) *client {
+	return &client{
+		url:      strings.TrimRight(url, "/"),
+		username: username,
+		password: password,
+		http:     &http.Client{Timeout: time.Minute},
+		out:      out,
+	}
+}
+
+// do makes the request and returns the response body, the responses other
+// than 2xx are returned as errors carrying the error message of arc.
+func (c *client) do(method, path string, body interface{}) ([]byte, error) {
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(b)
+	default:
+		raw, err := json.Marshal(b)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req, err := http.NewRequest(method, c.url+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return raw, fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, errorMessage(raw))
+	}
+	return raw, nil
+}
+
+// print prints the json response indented.
+func (c *client) print(raw []byte) error {
+	var out bytes.Buffer
+	if err := json.Indent(&out, raw, "", "  "); err != nil {
+		_, err = c.out.Write(raw)
+		return err
+	}
+	out.WriteByte('\n')
+	_, err := out.WriteTo(c.out)
+	return err
+}
+
+// errorMessage extracts the message of the error responses of arc.
+func errorMessage(raw []byte) string {
+	var response struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(raw, &response); err == nil && response.Error.Message != "" {
+		return response.Error.Message
+	}
+	return strings.TrimSpace(string(raw))
+}
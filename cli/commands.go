@@ -0,0 +1,290 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// health prints the health of the components of arc, and fails if any of
+// them is unhealthy.
+func health(c *client, args []string) error {
+	raw, err := c.do(http.MethodGet, "/_health", nil)
+	if raw != nil {
+		if err := c.print(raw); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// users manages the users.
+func users(c *client, args []string) error {
+	action, args := subcommand(args)
+	switch action {
+	case "create":
+		fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+		admin := fs.Bool("admin", false, "Whether the user is an admin")
+		email := fs.String("email", "", "Email of the user")
+		categories := fs.String("categories", "", "Comma separated categories the user can access")
+		acls := fs.String("acls", "", "Comma separated acls the user can access")
+		ops := fs.String("ops", "", "Comma separated operations the user can perform")
+		indices := fs.String("indices", "", "Comma separated index patterns the user can access")
+		fs.Usage = usage(fs, "user create [flags] <username> <password>")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if fs.NArg() != 2 {
+			fs.Usage()
+			return flag.ErrHelp
+		}
+		body := map[string]interface{}{
+			"username": fs.Arg(0),
+			"password": fs.Arg(1),
+			"is_admin": *admin,
+		}
+		setString(body, "email", *email)
+		setList(body, "categories", *categories)
+		setList(body, "acls", *acls)
+		setList(body, "ops", *ops)
+		setList(body, "indices", *indices)
+		return c.printDo(http.MethodPost, apiVersion+"/_user", body)
+	case "get":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: arc user get <username>")
+		}
+		return c.printDo(http.MethodGet, apiVersion+"/_user/"+url.PathEscape(args[0]), nil)
+	case "list":
+		return c.printDo(http.MethodGet, apiVersion+"/_users", nil)
+	case "delete":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: arc user delete <username>")
+		}
+		return c.printDo(http.MethodDelete, apiVersion+"/_user/"+url.PathEscape(args[0]), nil)
+	default:
+		return fmt.Errorf("usage: arc user create|get|list|delete")
+	}
+}
+
+// permissions manages the permissions.
+func permissions(c *client, args []string) error {
+	action, args := subcommand(args)
+	switch action {
+	case "create":
+		fs := flag.NewFlagSet("permission create", flag.ContinueOnError)
+		owner := fs.String("owner", "", "Owner of the permission, defaults to the user creating it")
+		role := fs.String("role", "", "Role of the permission")
+		description := fs.String("description", "", "Description of the permission")
+		categories := fs.String("categories", "", "Comma separated categories the permission can access")
+		acls := fs.String("acls", "", "Comma separated acls the permission can access")
+		ops := fs.String("ops", "", "Comma separated operations the permission can perform")
+		indices := fs.String("indices", "", "Comma separated index patterns the permission can access")
+		sources := fs.String("sources", "", "Comma separated source ip ranges the permission can be used from")
+		referers := fs.String("referers", "", "Comma separated referers the permission can be used from")
+		ttl := fs.Duration("ttl", 0, "Time to live of the permission, e.g. 720h")
+		fs.Usage = usage(fs, "permission create [flags]")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		body := make(map[string]interface{})
+		setString(body, "owner", *owner)
+		setString(body, "role", *role)
+		setString(body, "description", *description)
+		setList(body, "categories", *categories)
+		setList(body, "acls", *acls)
+		setList(body, "ops", *ops)
+		setList(body, "indices", *indices)
+		setList(body, "sources", *sources)
+		setList(body, "referers", *referers)
+		if *ttl != 0 {
+			body["ttl"] = *ttl
+		}
+		return c.printDo(http.MethodPost, apiVersion+"/_permission", body)
+	case "get":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: arc permission get <username>")
+		}
+		return c.printDo(http.MethodGet, apiVersion+"/_permission/"+url.PathEscape(args[0]), nil)
+	case "list":
+		return c.printDo(http.MethodGet, apiVersion+"/_permissions", nil)
+	case "delete":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: arc permission delete <username>")
+		}
+		return c.printDo(http.MethodDelete, apiVersion+"/_permission/"+url.PathEscape(args[0]), nil)
+	default:
+		return fmt.Errorf("usage: arc permission create|get|list|delete")
+	}
+}
+
+// reindex reindexes the index in place, or into the destination index, with
+// the mappings, settings and types of the body file if any.
+func reindex(c *client, args []string) error {
+	fs := flag.NewFlagSet("reindex", flag.ContinueOnError)
+	bodyFile := fs.String("body", "", "Path to the json file with the mappings, settings and types")
+	fs.Usage = usage(fs, "reindex [-body file] <index> [destination_index]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	body := []byte("{}")
+	if *bodyFile != "" {
+		var err error
+		if body, err = ioutil.ReadFile(*bodyFile); err != nil {
+			return err
+		}
+	}
+	path := apiVersion + "/_reindex/" + url.PathEscape(fs.Arg(0))
+	if fs.NArg() == 2 {
+		path += "/" + url.PathEscape(fs.Arg(1))
+	}
+	return c.printDo(http.MethodPost, path, body)
+}
+
+// analytics exports the analytics records as newline delimited json by
+// scrolling through the analytics index.
+func analytics(c *client, args []string) error {
+	action, args := subcommand(args)
+	if action != "export" {
+		return fmt.Errorf("usage: arc analytics export [flags]")
+	}
+	fs := flag.NewFlagSet("analytics export", flag.ContinueOnError)
+	index := fs.String("index", firstOf(os.Getenv("ANALYTICS_ES_INDEX"), ".analytics"), "Index storing the analytics records")
+	query := fs.String("query", `{"match_all":{}}`, "Query filtering the exported records")
+	out := fs.String("out", "", "Path to the file the records are written to, defaults to stdout")
+	batch := fs.Int("batch", 1000, "Number of records fetched per request")
+	fs.Usage = usage(fs, "analytics export [flags]")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !json.Valid([]byte(*query)) {
+		return fmt.Errorf("invalid query: %s", *query)
+	}
+
+	w := c.out
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	writer := bufio.NewWriter(w)
+	defer writer.Flush()
+
+	search := map[string]interface{}{
+		"size":  *batch,
+		"query": json.RawMessage(*query),
+		"sort":  []string{"_doc"},
+	}
+	raw, err := c.do(http.MethodPost, "/"+url.PathEscape(*index)+"/_search?scroll=1m", search)
+	if err != nil {
+		return err
+	}
+	for {
+		scrollID, n, err := writeHits(writer, raw)
+		if err != nil || n == 0 || scrollID == "" {
+			if scrollID != "" {
+				c.do(http.MethodDelete, "/_search/scroll", map[string]interface{}{"scroll_id": scrollID})
+			}
+			return err
+		}
+		raw, err = c.do(http.MethodPost, "/_search/scroll", map[string]interface{}{
+			"scroll":    "1m",
+			"scroll_id": scrollID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// writeHits writes the sources of the hits of the search response, one per
+// line, and returns the scroll id along with the number of hits.
+func writeHits(w io.Writer, raw []byte) (string, int, error) {
+	var response struct {
+		ScrollID string `json:"_scroll_id"`
+		Hits     struct {
+			Hits []struct {
+				Source json.RawMessage `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return "", 0, err
+	}
+	for _, hit := range response.Hits.Hits {
+		if _, err := w.Write(append(compact(hit.Source), '\n')); err != nil {
+			return response.ScrollID, 0, err
+		}
+	}
+	return response.ScrollID, len(response.Hits.Hits), nil
+}
+
+func compact(raw json.RawMessage) []byte {
+	var out bytes.Buffer
+	if err := json.Compact(&out, raw); err != nil {
+		return raw
+	}
+	return out.Bytes()
+}
+
+// printDo makes the request and prints the response.
+func (c *client) printDo(method, path string, body interface{}) error {
+	raw, err := c.do(method, path, body)
+	if err != nil {
+		return err
+	}
+	return c.print(raw)
+}
+
+// subcommand splits the action off the arguments.
+func subcommand(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", nil
+	}
+	return args[0], args[1:]
+}
+
+func usage(fs *flag.FlagSet, synopsis string) func() {
+	fs.SetOutput(ioutil.Discard)
+	return func() {
+		fmt.Fprintln(os.Stderr, "usage: arc", synopsis)
+		fs.SetOutput(os.Stderr)
+		fs.PrintDefaults()
+		fs.SetOutput(ioutil.Discard)
+	}
+}
+
+func setString(body map[string]interface{}, key, value string) {
+	if value != "" {
+		body[key] = value
+	}
+}
+
+// setList sets the comma separated values as a list.
+func setList(body map[string]interface{}, key, value string) {
+	if value == "" {
+		return
+	}
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	body[key] = values
+}
@@ -15,10 +15,19 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/appbaseio/arc/cli"
 	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/deadline"
+	"github.com/appbaseio/arc/middleware/headers"
+	"github.com/appbaseio/arc/middleware/loadshed"
 	"github.com/appbaseio/arc/middleware/logger"
 	"github.com/appbaseio/arc/plugins"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/chaos"
+	"github.com/appbaseio/arc/util/health"
+	"github.com/appbaseio/arc/util/iplookup"
+	"github.com/appbaseio/arc/util/seed"
+	"github.com/appbaseio/arc/util/state"
 	"github.com/gorilla/mux"
 	"github.com/robfig/cron"
 	"github.com/rs/cors"
@@ -36,6 +45,11 @@ var (
 	port        int
 	pluginDir   string
 	https       bool
+	// flags bootstrapping a fresh deployment
+	rootUsername string
+	rootPassword string
+	seedFile     string
+	chaosMode    bool
 	// PlanRefreshInterval can be used to define the custom interval to refresh the plan
 	PlanRefreshInterval string
 	// Billing is a build time flag
@@ -46,6 +60,8 @@ var (
 	ClusterBilling string
 	// IgnoreBillingMiddleware ignores the billing middleware
 	IgnoreBillingMiddleware string
+	// Version is a build time flag
+	Version string
 
 	// Tier for testing
 	Tier string
@@ -63,9 +79,18 @@ func init() {
 	flag.IntVar(&port, "port", 8000, "Port number")
 	flag.StringVar(&pluginDir, "pluginDir", "build/plugins", "Directory containing the compiled plugins")
 	flag.BoolVar(&https, "https", false, "Starts a https server instead of a http server if true")
+	flag.StringVar(&rootUsername, "rootUsername", "", "Username of the root admin user, overrides the USERNAME env var")
+	flag.StringVar(&rootPassword, "rootPassword", "", "Password of the root admin user, overrides the PASSWORD env var")
+	flag.StringVar(&seedFile, "seed", "", "Path to the json file with the users and permissions created on the first run, overrides the SEED_FILE env var")
+	flag.BoolVar(&chaosMode, "chaos", false, "Injects the faults configured by the CHAOS_* env vars into the upstream calls, for resilience testing only")
 }
 
 func main() {
+	// the administration subcommands talk to a running instance
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:], LoadEnvFromFile, os.Stdout, os.Stderr))
+	}
+
 	flag.Parse()
 	log.SetReportCaller(true)
 	log.SetFormatter(&log.TextFormatter{
@@ -91,6 +116,15 @@ func main() {
 	if err := LoadEnvFromFile(envFile); err != nil {
 		log.Errorln(logTag, ": reading env file", envFile, ": ", err)
 	}
+	setEnvFromFlag("USERNAME", rootUsername)
+	setEnvFromFlag("PASSWORD", rootPassword)
+	setEnvFromFlag("SEED_FILE", seedFile)
+
+	if chaosMode {
+		if err := chaos.Enable(); err != nil {
+			log.Fatalln(logTag, ":", err)
+		}
+	}
 
 	router := mux.NewRouter().StrictSlash(true)
 
@@ -108,6 +142,7 @@ func main() {
 	util.Billing = Billing
 	util.HostedBilling = HostedBilling
 	util.ClusterBilling = ClusterBilling
+	util.Version = Version
 
 	if Billing == "true" {
 		log.Println("You're running Arc with billing module enabled.")
@@ -168,9 +203,19 @@ func main() {
 		util.SetFeatureSuggestions(true)
 	}
 
+	// fail fast on a malformed seed file, the plugins create its entries
+	if _, err := seed.Load(); err != nil {
+		log.Fatal(logTag, ": ", err)
+	}
+
 	// ES client instantiation
 	// ES v7 and v6 clients
 	util.NewClient()
+	// initialize the shared state, the geo enrichment providers and expose the health of the components
+	state.Instance()
+	iplookup.Instance()
+	router.HandleFunc("/_health", health.Handler()).Methods(http.MethodGet)
+
 	// map of specific plugins
 	sequencedPlugins := []string{"rules.so", "functions.so", "querytranslate.so", "analytics.so"}
 	sequencedPluginsByPath := make(map[string]string)
@@ -219,7 +264,12 @@ func main() {
 		AllowedHeaders: []string{"*"},
 	})
 	handler := c.Handler(router)
+	handler = deadline.Handler(handler)
+	handler = loadshed.Handler(handler)
+	handler = headers.Handler(handler)
 	handler = logger.Log(handler)
+	// the apis served over grpc go through the same middleware
+	plugins.SetServedHandler(handler)
 
 	// Listen and serve ...
 	addr := fmt.Sprintf("%s:%d", address, port)
@@ -293,6 +343,16 @@ func LoadEnvFromFile(envFile string) error {
 	return nil
 }
 
+// setEnvFromFlag sets the env var to the value of the flag, if the flag is set.
+func setEnvFromFlag(key, value string) {
+	if value == "" {
+		return
+	}
+	if err := os.Setenv(key, value); err != nil {
+		log.Errorln(logTag, ": unable to set", key, ":", err)
+	}
+}
+
 // ParseEnvFile parses the envFile for env variables in present in
 // KEY=VALUE format. It ignores the comment lines starting with "#".
 func ParseEnvFile(envFile io.Reader) (map[string]string, error) {
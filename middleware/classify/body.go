@@ -0,0 +1,20 @@
+package classify
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/util"
+)
+
+// Body returns a middleware that shares the request body with the handlers
+// down the chain, so that it's read only once.
+func Body() middleware.Middleware {
+	return body
+}
+
+func body(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		h(w, util.WithSharedBody(req))
+	}
+}
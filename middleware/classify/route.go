@@ -0,0 +1,35 @@
+package classify
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+)
+
+// Category returns a middleware that classifies the requests of a route into
+// the given category, so that each route can set the category it requires.
+func Category(c category.Category) middleware.Middleware {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			reqCategory := c
+			ctx := category.NewContext(req.Context(), &reqCategory)
+			req = req.WithContext(ctx)
+			h(w, req)
+		}
+	}
+}
+
+// ACL returns a middleware that classifies the requests of a route into the
+// given acl, so that each route can set the acl it requires.
+func ACL(a acl.ACL) middleware.Middleware {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			reqACL := a
+			ctx := acl.NewContext(req.Context(), &reqACL)
+			req = req.WithContext(ctx)
+			h(w, req)
+		}
+	}
+}
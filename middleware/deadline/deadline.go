@@ -0,0 +1,187 @@
+// Package deadline bounds the time arc spends serving each request. The
+// budget of a request is either the one its client asks for or the maximum
+// one, the request being served with a context carrying the deadline, which
+// the calls made to elasticsearch propagate. The request is answered with a
+// 504 as soon as the deadline passes, unless its response has started.
+package deadline
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	logTag = "[deadline]"
+	// Header is the header the clients set their budget with, e.g. "2s".
+	Header            = "X-Request-Timeout"
+	envRequestTimeout = "REQUEST_TIMEOUT"
+)
+
+var (
+	maxBudget time.Duration
+	once      sync.Once
+)
+
+// maxTimeout returns the maximum budget of the requests, zero if they aren't
+// bounded unless their clients ask for it.
+func maxTimeout() time.Duration {
+	once.Do(func() {
+		value := os.Getenv(envRequestTimeout)
+		if value == "" {
+			return
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			log.Errorln(logTag, ": invalid value for", envRequestTimeout, ":", value, ", the requests aren't bounded")
+			return
+		}
+		maxBudget = d
+	})
+	return maxBudget
+}
+
+// Handler serves the requests within their budget.
+func Handler(next http.Handler) http.Handler {
+	return handler(next, maxTimeout())
+}
+
+func handler(next http.Handler, limit time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		budget := limit
+		if value := req.Header.Get(Header); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				util.WriteBackErrorWithDetails(w, fmt.Sprintf("invalid value for %s: %s, must be a positive duration, e.g. 2s", Header, value), http.StatusBadRequest, []util.ErrorDetail{
+					{Type: "invalid_header", Reason: "must be a positive duration", Location: "header:" + Header},
+				})
+				return
+			}
+			if budget == 0 || d < budget {
+				budget = d
+			}
+		}
+		if budget == 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), budget)
+		defer cancel()
+		gw := &guardedWriter{ctx: ctx, w: w, header: w.Header().Clone()}
+		done := make(chan interface{}, 1)
+		go func() {
+			// the panics are raised again by the goroutine of the request
+			defer func() { done <- recover() }()
+			next.ServeHTTP(gw, req.WithContext(ctx))
+		}()
+		select {
+		case p := <-done:
+			if p != nil {
+				panic(p)
+			}
+			if ctx.Err() == context.DeadlineExceeded && gw.expire() {
+				exceeded(w, req, budget)
+			}
+		case <-ctx.Done():
+			if gw.expire() {
+				exceeded(w, req, budget)
+				return
+			}
+			// the response has started, it is left to complete
+			if p := <-done; p != nil {
+				panic(p)
+			}
+		}
+	})
+}
+
+func exceeded(w http.ResponseWriter, req *http.Request, budget time.Duration) {
+	log.Errorln(logTag, ":", req.Method, req.URL.Path, "exceeded its budget of", budget)
+	util.WriteBackErrorWithDetails(w, fmt.Sprintf("the request exceeded its timeout of %s", budget), http.StatusGatewayTimeout, []util.ErrorDetail{
+		{Type: "timeout_exceeded", Reason: fmt.Sprintf("the request wasn't served within %s", budget)},
+	})
+}
+
+// guardedWriter holds the response back from the client once the deadline
+// passed, unless the response had started. The responses the handlers write
+// after the deadline, usually the errors of the calls it cancelled, are
+// superseded by the 504.
+type guardedWriter struct {
+	ctx    context.Context
+	w      http.ResponseWriter
+	header http.Header
+
+	mu      sync.Mutex
+	started bool
+	expired bool
+}
+
+// expire prevents the response from starting, it reports whether it hadn't.
+func (g *guardedWriter) expire() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.started {
+		return false
+	}
+	g.expired = true
+	return true
+}
+
+// start starts the response unless the deadline passed.
+func (g *guardedWriter) start() bool {
+	if !g.started && g.ctx.Err() == context.DeadlineExceeded {
+		g.expired = true
+	}
+	if g.expired {
+		return false
+	}
+	if !g.started {
+		g.started = true
+		header := g.w.Header()
+		for key := range header {
+			delete(header, key)
+		}
+		for key, values := range g.header {
+			header[key] = values
+		}
+	}
+	return true
+}
+
+func (g *guardedWriter) Header() http.Header {
+	return g.header
+}
+
+func (g *guardedWriter) WriteHeader(code int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.start() {
+		g.w.WriteHeader(code)
+	}
+}
+
+func (g *guardedWriter) Write(p []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.start() {
+		return 0, http.ErrHandlerTimeout
+	}
+	return g.w.Write(p)
+}
+
+// Flush sends the buffered data to the client if supported by the underlying writer.
+func (g *guardedWriter) Flush() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if f, ok := g.w.(http.Flusher); ok && g.start() {
+		f.Flush()
+	}
+}
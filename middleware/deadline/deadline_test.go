@@ -0,0 +1,81 @@
+package deadline
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/util"
+)
+
+func TestHandler(t *testing.T) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-req.Context().Done():
+		case <-time.After(time.Second):
+		}
+		w.Header().Set("X-Slow", "true")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	Convey("The requests exceeding their budget are answered with a 504", t, func() {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/_search", nil)
+		handler(slow, 10*time.Millisecond).ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, http.StatusGatewayTimeout)
+		So(w.Header().Get("X-Slow"), ShouldBeEmpty)
+		var envelope util.ErrorEnvelope
+		So(json.Unmarshal(w.Body.Bytes(), &envelope), ShouldBeNil)
+		So(envelope.Error.Details[0].Type, ShouldEqual, "timeout_exceeded")
+	})
+
+	Convey("The clients can only shorten the budget", t, func() {
+		var remaining time.Duration
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			deadline, _ := req.Context().Deadline()
+			remaining = time.Until(deadline)
+		})
+		req := httptest.NewRequest(http.MethodGet, "/_search", nil)
+		req.Header.Set(Header, "2s")
+		handler(h, time.Minute).ServeHTTP(httptest.NewRecorder(), req)
+		So(remaining, ShouldBeLessThanOrEqualTo, 2*time.Second)
+
+		req.Header.Set(Header, "1h")
+		handler(h, time.Minute).ServeHTTP(httptest.NewRecorder(), req)
+		So(remaining, ShouldBeLessThanOrEqualTo, time.Minute)
+		So(remaining, ShouldBeGreaterThan, 2*time.Second)
+
+		w := httptest.NewRecorder()
+		req.Header.Set(Header, "soon")
+		handler(h, time.Minute).ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("The requests aren't bounded without a budget", t, func() {
+		var bounded bool
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			_, bounded = req.Context().Deadline()
+			w.WriteHeader(http.StatusCreated)
+		})
+		w := httptest.NewRecorder()
+		handler(h, 0).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		So(bounded, ShouldBeFalse)
+		So(w.Code, ShouldEqual, http.StatusCreated)
+	})
+
+	Convey("The responses started before the deadline complete", t, func() {
+		h := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			<-req.Context().Done()
+			w.Write([]byte("partial"))
+		})
+		w := httptest.NewRecorder()
+		handler(h, 10*time.Millisecond).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldEqual, "partial")
+	})
+}
@@ -0,0 +1,214 @@
+// Package feature lets the admin users toggle the features of the middleware
+// per request, in order to debug and compare their behavior without changing
+// the configuration. The features are toggled via the X-Arc-Feature header,
+// e.g. "X-Arc-Feature: cache=off, rerank=on", which must be signed with the
+// secret set via FEATURE_HEADER_SECRET, alike the webhook deliveries.
+package feature
+
+import (
+	"context"
+	"crypto/hmac"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/notify"
+)
+
+const (
+	envSecret = "FEATURE_HEADER_SECRET"
+	// maxSkew bounds the age of the signed headers, letting the replayed
+	// ones be rejected.
+	maxSkew = 5 * time.Minute
+
+	// Header carries the features toggled for the request.
+	Header = "X-Arc-Feature"
+	// SignatureHeader carries the signature of the Header value signed at
+	// TimestampHeader, as computed by notify.Sign.
+	SignatureHeader = "X-Arc-Feature-Signature"
+	// TimestampHeader carries the unix time at which the Header was signed.
+	TimestampHeader = "X-Arc-Feature-Timestamp"
+	// appliedHeader reports the features toggled in the response.
+	appliedHeader = "X-Arc-Feature-Applied"
+
+	// togglesKey is the key against which the toggled features are stored
+	// in the context.
+	togglesKey = contextKey("feature_toggles")
+)
+
+type contextKey string
+
+// Features that can be toggled.
+const (
+	// Cache serves the aggregations from the aggregations cache.
+	Cache = "cache"
+	// Rewrite injects the query defaults in the searches.
+	Rewrite = "rewrite"
+	// Rerank re-ranks the hits by their clicks, "on" opts the search in the
+	// re-ranking with the default weight.
+	Rerank = "rerank"
+)
+
+var features = map[string]bool{
+	Cache:   true,
+	Rewrite: true,
+	Rerank:  true,
+}
+
+// Enabled returns whether the feature is enabled for the request, def if it
+// wasn't toggled.
+func Enabled(ctx context.Context, name string, def bool) bool {
+	if enabled, ok := Toggled(ctx, name); ok {
+		return enabled
+	}
+	return def
+}
+
+// Toggled returns whether the feature was toggled for the request, and if so
+// whether it was turned on.
+func Toggled(ctx context.Context, name string) (enabled, ok bool) {
+	toggles, _ := ctx.Value(togglesKey).(map[string]bool)
+	enabled, ok = toggles[name]
+	return enabled, ok
+}
+
+// NewContext returns a new context carrying the toggled features.
+func NewContext(ctx context.Context, toggles map[string]bool) context.Context {
+	return context.WithValue(ctx, togglesKey, toggles)
+}
+
+// Toggle returns a middleware that stores the features toggled via the
+// X-Arc-Feature header in the context of the request. The header is only
+// honoured for the admin users and must be signed, it is stripped before the
+// request is forwarded to elasticsearch. It must run after the request is
+// authenticated.
+func Toggle() middleware.Middleware {
+	return toggle
+}
+
+func toggle(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		values := req.Header[Header]
+		if len(values) == 0 {
+			h(w, req)
+			return
+		}
+		value := strings.Join(values, ",")
+		signature := req.Header.Get(SignatureHeader)
+		timestamp := req.Header.Get(TimestampHeader)
+		req.Header.Del(Header)
+		req.Header.Del(SignatureHeader)
+		req.Header.Del(TimestampHeader)
+
+		ctx := req.Context()
+		if !isAdmin(ctx) {
+			msg := fmt.Sprintf(`only admin users are allowed to use the "%s" header`, Header)
+			util.WriteBackError(w, msg, http.StatusForbidden)
+			return
+		}
+		if err := verify(os.Getenv(envSecret), value, signature, timestamp, time.Now()); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		toggles, err := Parse(value)
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set(appliedHeader, Format(toggles))
+		req = req.WithContext(NewContext(ctx, toggles))
+		h(w, req)
+	}
+}
+
+func isAdmin(ctx context.Context) bool {
+	reqCredential, err := credential.FromContext(ctx)
+	if err != nil || reqCredential != credential.User {
+		return false
+	}
+	reqUser, err := user.FromContext(ctx)
+	return err == nil && reqUser.Admin()
+}
+
+// verify checks the signature of the header value signed at the timestamp.
+func verify(secret, value, signature, timestamp string, now time.Time) error {
+	if secret == "" {
+		return fmt.Errorf(`the "%s" header is disabled, %s isn't set`, Header, envSecret)
+	}
+	if signature == "" || timestamp == "" {
+		return fmt.Errorf(`the "%s" header must be signed via the "%s" and "%s" headers`, Header, SignatureHeader, TimestampHeader)
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf(`invalid "%s" value "%s", must be a unix time`, TimestampHeader, timestamp)
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > maxSkew || skew < -maxSkew {
+		return fmt.Errorf(`the "%s" header was signed more than %s away from now`, Header, maxSkew)
+	}
+	expected := notify.Sign(secret, ts, []byte(value))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return fmt.Errorf(`invalid "%s" signature`, Header)
+	}
+	return nil
+}
+
+// Parse parses the features toggled by the header value, a comma separated
+// list of name=on|off pairs.
+func Parse(value string) (map[string]bool, error) {
+	toggles := make(map[string]bool)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		name := strings.TrimSpace(parts[0])
+		if !features[name] {
+			return nil, fmt.Errorf(`unknown feature "%s" in the "%s" header, must be one of: %s`, name, Header, strings.Join(names(), ", "))
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf(`missing the value of feature "%s" in the "%s" header, must be either "on" or "off"`, name, Header)
+		}
+		switch strings.TrimSpace(parts[1]) {
+		case "on":
+			toggles[name] = true
+		case "off":
+			toggles[name] = false
+		default:
+			return nil, fmt.Errorf(`invalid value "%s" of feature "%s" in the "%s" header, must be either "on" or "off"`, strings.TrimSpace(parts[1]), name, Header)
+		}
+	}
+	return toggles, nil
+}
+
+// Format formats the toggled features as a header value, sorted by name.
+func Format(toggles map[string]bool) string {
+	pairs := make([]string, 0, len(toggles))
+	for name, enabled := range toggles {
+		state := "off"
+		if enabled {
+			state = "on"
+		}
+		pairs = append(pairs, name+"="+state)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+func names() []string {
+	var list []string
+	for name := range features {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
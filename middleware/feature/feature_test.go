@@ -0,0 +1,96 @@
+package feature
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util/notify"
+)
+
+func TestParse(t *testing.T) {
+	Convey("Features are toggled on and off", t, func() {
+		toggles, err := Parse("cache=off, rerank=on,")
+		So(err, ShouldBeNil)
+		So(toggles, ShouldResemble, map[string]bool{Cache: false, Rerank: true})
+		So(Format(toggles), ShouldEqual, "cache=off, rerank=on")
+	})
+
+	Convey("Unknown features and values are rejected", t, func() {
+		_, err := Parse("highlight=off")
+		So(err, ShouldNotBeNil)
+		_, err = Parse("cache")
+		So(err, ShouldNotBeNil)
+		_, err = Parse("cache=false")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	signature := notify.Sign("secret", now.Unix(), []byte("cache=off"))
+
+	Convey("Signed headers are verified", t, func() {
+		So(verify("secret", "cache=off", signature, "1600000000", now), ShouldBeNil)
+		So(verify("secret", "cache=on", signature, "1600000000", now), ShouldNotBeNil)
+		So(verify("other", "cache=off", signature, "1600000000", now), ShouldNotBeNil)
+		So(verify("secret", "cache=off", "", "", now), ShouldNotBeNil)
+	})
+
+	Convey("Stale and disabled headers are rejected", t, func() {
+		So(verify("secret", "cache=off", signature, "1600000000", now.Add(10*time.Minute)), ShouldNotBeNil)
+		So(verify("", "cache=off", signature, "1600000000", now), ShouldNotBeNil)
+	})
+}
+
+func TestToggle(t *testing.T) {
+	os.Setenv(envSecret, "secret")
+	defer os.Unsetenv(envSecret)
+
+	var cache bool
+	var forwarded string
+	h := toggle(func(w http.ResponseWriter, req *http.Request) {
+		cache = Enabled(req.Context(), Cache, true)
+		forwarded = req.Header.Get(Header)
+	})
+	request := func(admin bool) *http.Request {
+		isAdmin := admin
+		ctx := credential.NewContext(context.Background(), credential.User)
+		ctx = user.NewContext(ctx, &user.User{Username: "foo", IsAdmin: &isAdmin})
+		req := httptest.NewRequest(http.MethodPost, "/products/_search", nil).WithContext(ctx)
+		timestamp := time.Now().Unix()
+		req.Header.Set(Header, "cache=off")
+		req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(SignatureHeader, notify.Sign("secret", timestamp, []byte("cache=off")))
+		return req
+	}
+
+	Convey("Admin users toggle the features", t, func() {
+		w := httptest.NewRecorder()
+		h(w, request(true))
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(cache, ShouldBeFalse)
+		So(forwarded, ShouldBeEmpty)
+		So(w.Header().Get(appliedHeader), ShouldEqual, "cache=off")
+	})
+
+	Convey("The other credentials are forbidden", t, func() {
+		w := httptest.NewRecorder()
+		h(w, request(false))
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("Features default when not toggled", t, func() {
+		So(Enabled(context.Background(), Rewrite, true), ShouldBeTrue)
+		_, ok := Toggled(context.Background(), Rerank)
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,295 @@
+// Package headers injects the custom response headers configured per route,
+// e.g. security headers, cache control or tenant identifiers. The rules are
+// read from the file at RESPONSE_HEADERS_FILE and can be managed at runtime
+// by the plugins, which register them under their own source.
+package headers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	logTag  = "[headers]"
+	envFile = "RESPONSE_HEADERS_FILE"
+	// SourceFile is the source of the rules read from RESPONSE_HEADERS_FILE.
+	SourceFile = "file"
+)
+
+// protected headers are managed by the http server or describe the body,
+// they can't be injected.
+var protected = map[string]bool{
+	"Connection":        true,
+	"Content-Encoding":  true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// placeholders are substituted in the values of the headers.
+var placeholders = regexp.MustCompile(`\{(tenant|credential)\}`)
+
+// Rule sets the headers on the responses of the requests whose path matches
+// the regular expression, and whose method is one of the methods if any.
+// The values may use the {tenant} and {credential} placeholders, a header
+// whose value is empty once substituted isn't set.
+type Rule struct {
+	Name    string            `json:"name"`
+	Path    string            `json:"path"`
+	Methods []string          `json:"methods,omitempty"`
+	Headers map[string]string `json:"headers"`
+	Source  string            `json:"source,omitempty"`
+
+	path *regexp.Regexp
+}
+
+// Validate checks the rule and compiles its path.
+func (r *Rule) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf(`the rule has no "name"`)
+	}
+	if len(r.Headers) == 0 {
+		return fmt.Errorf(`rule "%s" has no "headers"`, r.Name)
+	}
+	path := r.Path
+	if path == "" {
+		path = ".*"
+	}
+	re, err := regexp.Compile(path)
+	if err != nil {
+		return fmt.Errorf(`invalid "path" of rule "%s": %v`, r.Name, err)
+	}
+	r.path = re
+	methods := make([]string, len(r.Methods))
+	for i, method := range r.Methods {
+		methods[i] = strings.ToUpper(method)
+	}
+	r.Methods = methods
+	headers := make(map[string]string, len(r.Headers))
+	for name, value := range r.Headers {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name == "" || strings.ContainsAny(name, " :\r\n") {
+			return fmt.Errorf(`invalid header "%s" in rule "%s"`, name, r.Name)
+		}
+		if protected[name] {
+			return fmt.Errorf(`header "%s" of rule "%s" can't be injected`, name, r.Name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf(`invalid value of header "%s" in rule "%s"`, name, r.Name)
+		}
+		headers[name] = value
+	}
+	r.Headers = headers
+	return nil
+}
+
+func (r *Rule) matches(req *http.Request) bool {
+	if len(r.Methods) > 0 {
+		found := false
+		for _, method := range r.Methods {
+			if method == req.Method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return r.path.MatchString(req.URL.Path)
+}
+
+// ParseRules reads the json array of rules.
+func ParseRules(raw []byte) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return nil, err
+		}
+		if names[rules[i].Name] {
+			return nil, fmt.Errorf(`duplicate rule "%s"`, rules[i].Name)
+		}
+		names[rules[i].Name] = true
+	}
+	return rules, nil
+}
+
+var (
+	mu      sync.RWMutex
+	sources = make(map[string][]Rule)
+	once    sync.Once
+)
+
+// Set replaces the rules of the source, which are applied in the given
+// order. The rules of the file are applied first and those of the other
+// sources after them, by source, so that they take precedence. The invalid
+// rules are skipped.
+func Set(source string, rules []Rule) {
+	set := make([]Rule, 0, len(rules))
+	for _, r := range rules {
+		r.Source = source
+		if err := r.Validate(); err != nil {
+			log.Errorln(logTag, ": skipping the invalid rule of", source, ":", err)
+			continue
+		}
+		set = append(set, r)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	sources[source] = set
+}
+
+// Rules returns the rules applied, in order.
+func Rules() []Rule {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(sources))
+	for source := range sources {
+		if source != SourceFile {
+			names = append(names, source)
+		}
+	}
+	sort.Strings(names)
+	rules := append([]Rule{}, sources[SourceFile]...)
+	for _, source := range names {
+		rules = append(rules, sources[source]...)
+	}
+	return rules
+}
+
+// loadFile reads the rules of RESPONSE_HEADERS_FILE, arc refuses to start
+// with an invalid file.
+func loadFile() {
+	path := os.Getenv(envFile)
+	if path == "" {
+		return
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatalln(logTag, ": unable to read", envFile, ":", err)
+	}
+	rules, err := ParseRules(raw)
+	if err != nil {
+		log.Fatalln(logTag, ": invalid", envFile, ":", err)
+	}
+	Set(SourceFile, rules)
+	log.Println(logTag, ": loaded", len(rules), "rules from", path)
+}
+
+type contextKey string
+
+const ctxKey = contextKey("headers")
+
+// Vars holds the values of the placeholders of the request, which are only
+// known once it is authenticated down the chain.
+type Vars struct {
+	mu     sync.Mutex
+	tenant string
+}
+
+// SetTenant sets the tenant the credential of the request is bound to.
+func (v *Vars) SetTenant(tenant string) {
+	v.mu.Lock()
+	v.tenant = tenant
+	v.mu.Unlock()
+}
+
+func (v *Vars) getTenant() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.tenant
+}
+
+// FromContext returns the placeholder values of the request, if it is
+// served by Handler.
+func FromContext(ctx context.Context) (*Vars, bool) {
+	vars, ok := ctx.Value(ctxKey).(*Vars)
+	return vars, ok
+}
+
+// Handler injects the headers of the rules matching the request into its
+// response, replacing the headers of the same name set by the handlers.
+func Handler(next http.Handler) http.Handler {
+	once.Do(loadFile)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var matched []Rule
+		for _, r := range Rules() {
+			if r.matches(req) {
+				matched = append(matched, r)
+			}
+		}
+		if len(matched) == 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+		vars := &Vars{}
+		req = req.WithContext(context.WithValue(req.Context(), ctxKey, vars))
+		next.ServeHTTP(&injectingWriter{ResponseWriter: w, req: req, vars: vars, rules: matched}, req)
+	})
+}
+
+// injectingWriter sets the headers of the rules once the response starts.
+type injectingWriter struct {
+	http.ResponseWriter
+	req         *http.Request
+	vars        *Vars
+	rules       []Rule
+	wroteHeader bool
+}
+
+func (i *injectingWriter) WriteHeader(code int) {
+	if !i.wroteHeader {
+		i.wroteHeader = true
+		i.inject()
+	}
+	i.ResponseWriter.WriteHeader(code)
+}
+
+func (i *injectingWriter) Write(p []byte) (int, error) {
+	if !i.wroteHeader {
+		i.WriteHeader(http.StatusOK)
+	}
+	return i.ResponseWriter.Write(p)
+}
+
+// Flush sends the buffered data to the client if supported by the underlying writer.
+func (i *injectingWriter) Flush() {
+	if f, ok := i.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (i *injectingWriter) inject() {
+	credential, _, _ := i.req.BasicAuth()
+	tenant := i.vars.getTenant()
+	header := i.ResponseWriter.Header()
+	for _, r := range i.rules {
+		for name, value := range r.Headers {
+			value = placeholders.ReplaceAllStringFunc(value, func(placeholder string) string {
+				if placeholder == "{tenant}" {
+					return tenant
+				}
+				return credential
+			})
+			if strings.TrimSpace(value) == "" {
+				continue
+			}
+			header.Set(name, value)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package headers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const fileRules = `[
+	{"name": "security", "headers": {"x-frame-options": "DENY", "Cache-Control": "no-store"}},
+	{"name": "search", "path": "^/[^/]+/_search$", "methods": ["get", "POST"], "headers": {"Cache-Control": "max-age=60", "X-Tenant": "{tenant}"}}
+]`
+
+func TestParseRules(t *testing.T) {
+	Convey("The rules are parsed", t, func() {
+		rules, err := ParseRules([]byte(fileRules))
+		So(err, ShouldBeNil)
+		So(rules, ShouldHaveLength, 2)
+		So(rules[0].Headers, ShouldContainKey, "X-Frame-Options")
+		So(rules[1].Methods, ShouldResemble, []string{"GET", "POST"})
+	})
+
+	Convey("The invalid rules are rejected", t, func() {
+		for _, raw := range []string{
+			`[{"headers": {"X-Foo": "bar"}}]`,
+			`[{"name": "foo"}]`,
+			`[{"name": "foo", "path": "(", "headers": {"X-Foo": "bar"}}]`,
+			`[{"name": "foo", "headers": {"Content-Length": "1"}}]`,
+			`[{"name": "foo", "headers": {"X-Foo": "bar\r\nX-Bar: baz"}}]`,
+			`[{"name": "foo", "headers": {"X-Foo": "bar"}}, {"name": "foo", "headers": {"X-Foo": "baz"}}]`,
+		} {
+			_, err := ParseRules([]byte(raw))
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestHandler(t *testing.T) {
+	rules, err := ParseRules([]byte(fileRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	Set(SourceFile, rules)
+	Set("api", []Rule{{Name: "nosniff", Path: "^/products/", Headers: map[string]string{"X-Content-Type-Options": "nosniff", "Cache-Control": "private"}}})
+	defer func() {
+		Set(SourceFile, nil)
+		Set("api", nil)
+	}()
+
+	serve := func(method, path string, h http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.SetBasicAuth("foo", "bar")
+		w := httptest.NewRecorder()
+		Handler(h).ServeHTTP(w, req)
+		return w
+	}
+	ok := func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Cache-Control", "public")
+		w.Write([]byte("{}"))
+	}
+
+	Convey("The headers of the matching rules are injected, the later rules taking precedence", t, func() {
+		w := serve(http.MethodGet, "/orders/_search", ok)
+		So(w.Header().Get("X-Frame-Options"), ShouldEqual, "DENY")
+		So(w.Header().Get("Cache-Control"), ShouldEqual, "max-age=60")
+
+		w = serve(http.MethodPost, "/products/_search", ok)
+		So(w.Header().Get("Cache-Control"), ShouldEqual, "private")
+		So(w.Header().Get("X-Content-Type-Options"), ShouldEqual, "nosniff")
+
+		w = serve(http.MethodDelete, "/orders/_search", ok)
+		So(w.Header().Get("Cache-Control"), ShouldEqual, "no-store")
+	})
+
+	Convey("The placeholders are substituted", t, func() {
+		w := serve(http.MethodGet, "/orders/_search", func(w http.ResponseWriter, req *http.Request) {
+			vars, ok := FromContext(req.Context())
+			So(ok, ShouldBeTrue)
+			vars.SetTenant("acme")
+			w.WriteHeader(http.StatusOK)
+		})
+		So(w.Header().Get("X-Tenant"), ShouldEqual, "acme")
+
+		w = serve(http.MethodGet, "/orders/_search", ok)
+		So(w.Header(), ShouldNotContainKey, "X-Tenant")
+	})
+}
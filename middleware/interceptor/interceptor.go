@@ -2,19 +2,28 @@ package interceptor
 
 import (
 	"net/http"
-	"net/url"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/errors"
 	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/balancer"
 )
 
 const (
-	logTag          = "[interceptor]"
-	envEsClusterURL = "ES_CLUSTER_URL"
+	logTag                 = "[interceptor]"
+	envEsClusterURL        = "ES_CLUSTER_URL"
+	envOpenSearchProxyAuth = "OPENSEARCH_PROXY_AUTH"
+	envOpenSearchUserHdr   = "OPENSEARCH_PROXY_USER_HEADER"
+	envOpenSearchRolesHdr  = "OPENSEARCH_PROXY_ROLES_HEADER"
+	defaultProxyUserHeader = "x-proxy-user"
+	defaultProxyRoleHeader = "x-proxy-roles"
 )
 
 // Redirect returns a middleware that redirects the es requests to upstream elasticsearch.
@@ -24,17 +33,15 @@ func Redirect() middleware.Middleware {
 
 func redirect(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		rawURL := os.Getenv("ES_CLUSTER_URL")
-		if rawURL == "" {
+		if os.Getenv(envEsClusterURL) == "" {
 			err := errors.NewEnvVarNotSetError(envEsClusterURL)
 			log.Errorln(logTag, ":", err)
 			return
 		}
-		esURL, err := url.Parse(rawURL)
-		if err != nil {
-			log.Errorln(logTag, ":error parsing", rawURL, "=", envEsClusterURL, ":", err)
-			return
-		}
+
+		// pick the upstream node, the handler releases it once the request is served
+		node := balancer.Instance().Next()
+		esURL := node.URL
 
 		r.URL.Scheme = esURL.Scheme
 		r.URL.Host = esURL.Host
@@ -42,11 +49,12 @@ func redirect(h http.HandlerFunc) http.HandlerFunc {
 
 		req, err := redirectRequest(r)
 		if err != nil {
+			node.Release()
 			log.Errorln(logTag, ":", err)
 			util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		req = req.WithContext(r.Context())
+		req = req.WithContext(balancer.NewContext(r.Context(), node))
 
 		// disable gzip compression
 		encoding := req.Header.Get("Accept-Encoding")
@@ -60,10 +68,71 @@ func redirect(h http.HandlerFunc) http.HandlerFunc {
 			req.Header.Set("Content-Type", "application/json")
 		}
 
+		if util.IsOpenSearch() {
+			setProxyAuthHeaders(req)
+		}
+
 		h(w, req)
 	}
 }
 
+// setProxyAuthHeaders forwards the identity of the arc credential to the
+// OpenSearch security plugin when it is configured for proxy authentication.
+// Any client supplied proxy headers are discarded to prevent impersonation.
+func setProxyAuthHeaders(req *http.Request) {
+	userHeader := os.Getenv(envOpenSearchUserHdr)
+	if userHeader == "" {
+		userHeader = defaultProxyUserHeader
+	}
+	rolesHeader := os.Getenv(envOpenSearchRolesHdr)
+	if rolesHeader == "" {
+		rolesHeader = defaultProxyRoleHeader
+	}
+	req.Header.Del(userHeader)
+	req.Header.Del(rolesHeader)
+
+	if os.Getenv(envOpenSearchProxyAuth) != "true" {
+		return
+	}
+
+	ctx := req.Context()
+	reqCredential, err := credential.FromContext(ctx)
+	if err != nil {
+		log.Errorln(logTag, ":", err)
+		return
+	}
+
+	var username string
+	var roles []string
+	switch reqCredential {
+	case credential.User:
+		reqUser, err := user.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			return
+		}
+		username = reqUser.Username
+		for _, c := range reqUser.Categories {
+			roles = append(roles, c.String())
+		}
+	case credential.Permission:
+		reqPermission, err := permission.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			return
+		}
+		username = reqPermission.Username
+		if reqPermission.Role != "" {
+			roles = append(roles, reqPermission.Role)
+		}
+	}
+
+	req.Header.Set(userHeader, username)
+	if len(roles) > 0 {
+		req.Header.Set(rolesHeader, strings.Join(roles, ","))
+	}
+}
+
 func redirectRequest(r *http.Request) (*http.Request, error) {
 	redirectRequest, err := http.NewRequest(r.Method, r.URL.String(), r.Body)
 	if err != nil {
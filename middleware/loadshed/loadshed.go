@@ -0,0 +1,291 @@
+// Package loadshed sheds the low priority traffic, e.g. the analytics reads
+// and the exports, with a 503 when arc is under pressure, so that the search
+// and index traffic doesn't degrade. The pressure is measured by the average
+// latency of the requests that aren't low priority over the last seconds and
+// by the number of requests in flight, which includes the requests queued
+// for a concurrency slot.
+package loadshed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/health"
+)
+
+const (
+	logTag              = "[loadshed]"
+	envLatencyThreshold = "LOADSHED_LATENCY_THRESHOLD"
+	envInflightLimit    = "LOADSHED_INFLIGHT_THRESHOLD"
+	envRules            = "LOADSHED_RULES"
+	// window is the number of seconds the latency is averaged over.
+	window = 10
+	// retryAfter is the number of seconds the shed clients are told to wait.
+	retryAfter = "5"
+)
+
+// Priority classes of the traffic.
+const (
+	// PriorityLow is shed as soon as arc is under pressure.
+	PriorityLow = "low"
+	// PriorityNormal is shed once the pressure is twice the thresholds.
+	PriorityNormal = "normal"
+	// PriorityHigh is never shed.
+	PriorityHigh = "high"
+)
+
+// defaultRules mark the analytics, the logs, the usage reports and the
+// scrolls, which the exports page through, as low priority.
+const defaultRules = `[
+	{"path": "^(/v1)?/_analytics/", "priority": "low"},
+	{"path": "^(/v1)?(/[^/]+)?/_logs$", "priority": "low"},
+	{"path": "^(/v1)?/_usage", "priority": "low"},
+	{"path": "/_search/scroll", "priority": "low"}
+]`
+
+// Rule sets the priority of the requests matching its path, a go regular
+// expression, its methods and its credential, those unset matching any.
+type Rule struct {
+	Path       string   `json:"path"`
+	Methods    []string `json:"methods"`
+	Credential string   `json:"credential"`
+	Priority   string   `json:"priority"`
+
+	path *regexp.Regexp
+}
+
+func (r *Rule) matches(req *http.Request) bool {
+	if r.path != nil && !r.path.MatchString(req.URL.Path) {
+		return false
+	}
+	if len(r.Methods) > 0 {
+		found := false
+		for _, method := range r.Methods {
+			if strings.EqualFold(method, req.Method) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if r.Credential != "" {
+		username, _, _ := req.BasicAuth()
+		return username == r.Credential
+	}
+	return true
+}
+
+func parseRules(raw string) ([]Rule, error) {
+	var rules []Rule
+	if err := json.Unmarshal([]byte(raw), &rules); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", envRules, err)
+	}
+	for i := range rules {
+		r := &rules[i]
+		switch r.Priority {
+		case PriorityLow, PriorityNormal, PriorityHigh:
+		default:
+			return nil, fmt.Errorf(`invalid priority "%s" of rule %d, expected "%s", "%s" or "%s"`, r.Priority, i, PriorityLow, PriorityNormal, PriorityHigh)
+		}
+		if r.Path != "" {
+			re, err := regexp.Compile(r.Path)
+			if err != nil {
+				return nil, fmt.Errorf("invalid path of rule %d: %v", i, err)
+			}
+			r.path = re
+		}
+	}
+	return rules, nil
+}
+
+// bucket sums the latencies observed within a second.
+type bucket struct {
+	second int64
+	total  time.Duration
+	count  int64
+}
+
+// shedder tracks the pressure and sheds the requests by priority.
+type shedder struct {
+	rules            []Rule
+	latencyThreshold time.Duration
+	inflightLimit    int64
+
+	inflight int64
+
+	mu      sync.Mutex
+	buckets [window]bucket
+}
+
+// observe records the latency of a request that isn't low priority.
+func (s *shedder) observe(now time.Time, latency time.Duration) {
+	second := now.Unix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b := &s.buckets[second%window]
+	if b.second != second {
+		*b = bucket{second: second}
+	}
+	b.total += latency
+	b.count++
+}
+
+// latency returns the average latency over the window, zero if no request
+// was observed.
+func (s *shedder) latency(now time.Time) time.Duration {
+	second := now.Unix()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total time.Duration
+	var count int64
+	for _, b := range s.buckets {
+		if b.count > 0 && second-b.second < window {
+			total += b.total
+			count += b.count
+		}
+	}
+	if count == 0 {
+		return 0
+	}
+	return total / time.Duration(count)
+}
+
+// pressure returns 0 if arc isn't under pressure, 1 once either of the
+// thresholds is exceeded and 2 once either is exceeded twice.
+func (s *shedder) pressure(now time.Time) int {
+	level := 0
+	if s.latencyThreshold > 0 {
+		if latency := s.latency(now); latency > 2*s.latencyThreshold {
+			level = 2
+		} else if latency > s.latencyThreshold {
+			level = 1
+		}
+	}
+	if s.inflightLimit > 0 {
+		if inflight := atomic.LoadInt64(&s.inflight); inflight > 2*s.inflightLimit {
+			level = 2
+		} else if inflight > s.inflightLimit && level < 1 {
+			level = 1
+		}
+	}
+	return level
+}
+
+// priority returns the priority of the first rule the request matches.
+func (s *shedder) priority(req *http.Request) string {
+	if req.URL.Path == "/_health" {
+		return PriorityHigh
+	}
+	for i := range s.rules {
+		if s.rules[i].matches(req) {
+			return s.rules[i].Priority
+		}
+	}
+	return PriorityNormal
+}
+
+func (s *shedder) sheds(priority string, level int) bool {
+	switch priority {
+	case PriorityLow:
+		return level >= 1
+	case PriorityNormal:
+		return level >= 2
+	default:
+		return false
+	}
+}
+
+func (s *shedder) handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		priority := s.priority(req)
+		start := time.Now()
+		if level := s.pressure(start); s.sheds(priority, level) {
+			w.Header().Set("Retry-After", retryAfter)
+			util.WriteBackErrorWithDetails(w, "arc is under pressure, try again later", http.StatusServiceUnavailable, []util.ErrorDetail{
+				{Type: "load_shed", Reason: fmt.Sprintf("%s priority requests are shed under pressure", priority)},
+			})
+			return
+		}
+
+		atomic.AddInt64(&s.inflight, 1)
+		defer atomic.AddInt64(&s.inflight, -1)
+		next.ServeHTTP(w, req)
+		if priority != PriorityLow {
+			s.observe(time.Now(), time.Since(start))
+		}
+	})
+}
+
+// health reports the service degraded while traffic is shed.
+func (s *shedder) health() error {
+	switch s.pressure(time.Now()) {
+	case 1:
+		return errors.New("shedding the low priority requests")
+	case 2:
+		return errors.New("shedding the low and normal priority requests")
+	}
+	return nil
+}
+
+// newShedder reads the thresholds and the rules from the env, it returns nil
+// if neither threshold is set.
+func newShedder() (*shedder, error) {
+	s := &shedder{}
+	if value := os.Getenv(envLatencyThreshold); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be a non-negative duration", envLatencyThreshold, value)
+		}
+		s.latencyThreshold = d
+	}
+	if value := os.Getenv(envInflightLimit); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be a non-negative integer", envInflightLimit, value)
+		}
+		s.inflightLimit = n
+	}
+	if s.latencyThreshold == 0 && s.inflightLimit == 0 {
+		return nil, nil
+	}
+	raw := os.Getenv(envRules)
+	if raw == "" {
+		raw = defaultRules
+	}
+	rules, err := parseRules(raw)
+	if err != nil {
+		return nil, err
+	}
+	s.rules = rules
+	return s, nil
+}
+
+// Handler sheds the requests by priority under pressure, the requests are
+// passed through as they are unless LOADSHED_LATENCY_THRESHOLD or
+// LOADSHED_INFLIGHT_THRESHOLD is set. Arc refuses to start with an invalid
+// configuration.
+func Handler(next http.Handler) http.Handler {
+	s, err := newShedder()
+	if err != nil {
+		log.Fatalln(logTag, ":", err)
+	}
+	if s == nil {
+		return next
+	}
+	health.Register("loadshed", s.health, false)
+	return s.handler(next)
+}
@@ -0,0 +1,106 @@
+package loadshed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRules(t *testing.T) {
+	rules, err := parseRules(defaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &shedder{rules: rules}
+	priority := func(method, path string) string {
+		return s.priority(httptest.NewRequest(method, path, nil))
+	}
+
+	Convey("The analytics, the logs, the usage and the scrolls are low priority", t, func() {
+		So(priority(http.MethodGet, "/_analytics/dashboard"), ShouldEqual, PriorityLow)
+		So(priority(http.MethodGet, "/v1/_analytics/records"), ShouldEqual, PriorityLow)
+		So(priority(http.MethodGet, "/products/_logs"), ShouldEqual, PriorityLow)
+		So(priority(http.MethodGet, "/_usage/credentials"), ShouldEqual, PriorityLow)
+		So(priority(http.MethodPost, "/_search/scroll"), ShouldEqual, PriorityLow)
+		So(priority(http.MethodPost, "/products/_search"), ShouldEqual, PriorityNormal)
+		So(priority(http.MethodGet, "/_health"), ShouldEqual, PriorityHigh)
+	})
+
+	Convey("The rules can match the methods and the credential", t, func() {
+		rules, err := parseRules(`[{"credential": "batch", "priority": "low"}, {"path": "_search$", "methods": ["post"], "priority": "high"}]`)
+		So(err, ShouldBeNil)
+		s := &shedder{rules: rules}
+		req := httptest.NewRequest(http.MethodPost, "/products/_search", nil)
+		So(s.priority(req), ShouldEqual, PriorityHigh)
+		req.SetBasicAuth("batch", "secret")
+		So(s.priority(req), ShouldEqual, PriorityLow)
+		So(s.priority(httptest.NewRequest(http.MethodGet, "/products/_search", nil)), ShouldEqual, PriorityNormal)
+	})
+
+	Convey("The invalid rules are rejected", t, func() {
+		_, err := parseRules(`[{"path": "_search$", "priority": "urgent"}]`)
+		So(err, ShouldNotBeNil)
+		_, err = parseRules(`[{"path": "(", "priority": "low"}]`)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestPressure(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	Convey("The pressure follows the average latency over the window", t, func() {
+		s := &shedder{latencyThreshold: 100 * time.Millisecond}
+		So(s.pressure(now), ShouldEqual, 0)
+		s.observe(now, 50*time.Millisecond)
+		s.observe(now, 250*time.Millisecond)
+		So(s.pressure(now), ShouldEqual, 1)
+		s.observe(now, 600*time.Millisecond)
+		So(s.pressure(now), ShouldEqual, 2)
+		So(s.pressure(now.Add(window*time.Second)), ShouldEqual, 0)
+	})
+
+	Convey("The pressure follows the requests in flight", t, func() {
+		s := &shedder{inflightLimit: 2}
+		atomic.StoreInt64(&s.inflight, 3)
+		So(s.pressure(now), ShouldEqual, 1)
+		atomic.StoreInt64(&s.inflight, 5)
+		So(s.pressure(now), ShouldEqual, 2)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	rules, err := parseRules(defaultRules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &shedder{rules: rules, latencyThreshold: 100 * time.Millisecond}
+	h := s.handler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	Convey("The traffic is served without pressure", t, func() {
+		So(serve("/_analytics/dashboard").Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("The low priority traffic is shed first", t, func() {
+		s.observe(time.Now(), 150*time.Millisecond)
+		shed := serve("/_analytics/dashboard")
+		So(shed.Code, ShouldEqual, http.StatusServiceUnavailable)
+		So(shed.Header().Get("Retry-After"), ShouldEqual, retryAfter)
+		So(serve("/products/_search").Code, ShouldEqual, http.StatusOK)
+
+		Convey("The normal priority traffic is shed under twice the pressure", func() {
+			s.observe(time.Now(), time.Second)
+			So(serve("/products/_search").Code, ShouldEqual, http.StatusServiceUnavailable)
+			So(serve("/_health").Code, ShouldEqual, http.StatusOK)
+			So(s.health(), ShouldNotBeNil)
+		})
+	})
+}
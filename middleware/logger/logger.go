@@ -2,26 +2,53 @@ package logger
 
 import (
 	"fmt"
-	log "github.com/sirupsen/logrus"
 	"net/http"
 	"strings"
 	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
 )
 
-const logTag = "[logger]"
+const (
+	logTag             = "[logger]"
+	maxRequestIDLength = 128
+)
 
 // Log logs and records time taken by each requests. As a side effect,
-// it trims the trailing slashes from the matched route.
+// it trims the trailing slashes from the matched route and tags the
+// response with the request id, which is generated unless the client
+// provides a valid one.
 func Log(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		start := time.Now()
 		req.URL.Path = trimTrailingSlashes(req.URL.Path)
+		requestID := req.Header.Get(util.RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = util.NewRequestID()
+			req.Header.Set(util.RequestIDHeader, requestID)
+		}
+		w.Header().Set(util.RequestIDHeader, requestID)
 		next.ServeHTTP(w, req)
-		log.Println(fmt.Sprintf("%s: finished %s, took %fs",
-			logTag, fmt.Sprintf("%s %s", req.Method, req.URL.Path), time.Since(start).Seconds()))
+		log.Println(fmt.Sprintf("%s: finished %s [%s], took %fs",
+			logTag, fmt.Sprintf("%s %s", req.Method, req.URL.Path), requestID, time.Since(start).Seconds()))
 	})
 }
 
+// validRequestID rejects the ids that could be used to forge log entries.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
 func trimTrailingSlashes(path string) string {
 	for path != "/" && strings.HasSuffix(path, "/") {
 		path = strings.TrimSuffix(path, "/")
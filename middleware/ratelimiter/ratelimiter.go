@@ -10,9 +10,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/middleware"
-	"github.com/appbaseio/arc/model/category"
-	"github.com/appbaseio/arc/model/credential"
-	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/reqcontext"
 	"github.com/appbaseio/arc/util"
 	"github.com/appbaseio/arc/util/iplookup"
 	"github.com/ulule/limiter"
@@ -59,35 +57,25 @@ func (rl *Ratelimiter) rateLimit(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
 
-		reqCredential, err := credential.FromContext(ctx)
+		reqCtx, err := reqcontext.FromContext(ctx)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+			util.WriteBackError(w, "An error occurred while validating rate limit", http.StatusInternalServerError)
+			return
+		}
+		if !reqCtx.Authenticated() {
+			util.WriteBackError(w, "the request isn't authenticated", http.StatusUnauthorized)
 			return
 		}
 
-		if reqCredential == credential.Permission {
+		if reqPermission := reqCtx.Permission; reqPermission != nil {
 			remoteIP := iplookup.FromRequest(req)
-			errMsg := "An error occurred while validating rate limit"
-			reqPermission, err := permission.FromContext(ctx)
-			if err != nil {
-				log.Errorln(logTag, ":", err)
-				util.WriteBackError(w, errMsg, http.StatusInternalServerError)
-				return
-			}
-
-			reqCategory, err := category.FromContext(ctx)
-			if err != nil {
-				log.Errorln(logTag, ":", err)
-				util.WriteBackError(w, errMsg, http.StatusInternalServerError)
-				return
-			}
+			reqCategory := reqCtx.Category
 
 			// limit on Categories per second
 			categoryLimit, err := reqPermission.GetLimitFor(*reqCategory)
 			if err != nil {
-				w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-				util.WriteBackError(w, err.Error(), http.StatusUnauthorized)
+				util.WriteBackError(w, err.Error(), http.StatusForbidden)
 				return
 			}
 
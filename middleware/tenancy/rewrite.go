@@ -0,0 +1,304 @@
+package tenancy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// indexVars are the route variables that hold index or alias names.
+var indexVars = []string{"index", "alias", "target", "new_index"}
+
+// indexScoped are the apis that span all the indices unless the indices are
+// specified, which are confined to the tenant's indices.
+var indexScoped = map[string]bool{
+	"_search":     true,
+	"_count":      true,
+	"_alias":      true,
+	"_mapping":    true,
+	"_settings":   true,
+	"_refresh":    true,
+	"_flush":      true,
+	"_stats":      true,
+	"_field_caps": true,
+	"_validate":   true,
+	"_segments":   true,
+	"_recovery":   true,
+	"_cache":      true,
+	"_forcemerge": true,
+}
+
+// unscoped are the apis that address the indices in the request body or
+// don't touch the indices at all.
+var unscoped = map[string]bool{
+	"/":                           true,
+	"/_bulk":                      true,
+	"/_msearch":                   true,
+	"/_msearch/template":          true,
+	"/_mget":                      true,
+	"/_search/scroll":             true,
+	"/_search/scroll/{scroll_id}": true,
+	"/_analyze":                   true,
+}
+
+// catScoped are the cat apis that can be filtered by index or alias name.
+var catScoped = map[string]bool{
+	"/_cat/indices":  true,
+	"/_cat/aliases":  true,
+	"/_cat/count":    true,
+	"/_cat/shards":   true,
+	"/_cat/segments": true,
+	"/_cat/recovery": true,
+}
+
+// rewritePath builds the path of the route template with the index and alias
+// names prefixed, the routes spanning all the indices are confined to the
+// tenant's indices.
+func rewritePath(template string, vars map[string]string, prefix string) (string, error) {
+	path := template
+	for key, value := range vars {
+		if isIndexVar(template, key) {
+			value = prefixIndices(value, prefix)
+		}
+		path = strings.Replace(path, "{"+key+"}", value, -1)
+	}
+	if hasIndexVar(template) || unscoped[template] {
+		return path, nil
+	}
+	if catScoped[template] {
+		return path + "/" + prefix + "*", nil
+	}
+	segment := strings.SplitN(strings.TrimPrefix(template, "/"), "/", 2)[0]
+	if indexScoped[segment] && !strings.HasPrefix(template, "/_search/scroll") {
+		return "/" + prefix + "*" + path, nil
+	}
+	return "", errClusterRoute
+}
+
+func isIndexVar(template, key string) bool {
+	for _, v := range indexVars {
+		if key == v {
+			return true
+		}
+	}
+	// the alias apis name the alias {name}
+	return key == "name" && (strings.Contains(template, "/_alias") || strings.HasPrefix(template, "/_cat/aliases"))
+}
+
+func hasIndexVar(template string) bool {
+	for _, v := range indexVars {
+		if strings.Contains(template, "{"+v+"}") {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyRewriter returns the function that prefixes the indices addressed in the
+// body of the api, if any.
+func bodyRewriter(template string) func(body []byte, prefix string) ([]byte, error) {
+	switch {
+	case strings.HasSuffix(template, "/_bulk"):
+		return rewriteBulk
+	case strings.HasSuffix(template, "/_msearch"), strings.HasSuffix(template, "/_msearch/template"):
+		defaultIndex := !hasIndexVar(template)
+		return func(body []byte, prefix string) ([]byte, error) {
+			return rewriteMsearch(body, prefix, defaultIndex)
+		}
+	case strings.HasSuffix(template, "/_mget"):
+		return rewriteMget
+	default:
+		return nil
+	}
+}
+
+// rewriteBulk prefixes the _index of the action lines, the source lines are
+// copied as is.
+func rewriteBulk(body []byte, prefix string) ([]byte, error) {
+	var out bytes.Buffer
+	scanner := newLineScanner(body)
+	expectSource := false
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Bytes()
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		if expectSource {
+			out.Write(raw)
+			out.WriteByte('\n')
+			expectSource = false
+			continue
+		}
+
+		var action map[string]map[string]interface{}
+		if err := json.Unmarshal(raw, &action); err != nil || len(action) != 1 {
+			return nil, fmt.Errorf("malformed bulk action on line %d", line)
+		}
+		for name, meta := range action {
+			if index, ok := meta["_index"].(string); ok {
+				meta["_index"] = prefix + index
+			}
+			expectSource = name != "delete"
+		}
+		if err := writeLine(&out, action); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), scanner.Err()
+}
+
+// rewriteMsearch prefixes the indices of the header lines, the headers without
+// indices are confined to the tenant's indices unless the path names them.
+func rewriteMsearch(body []byte, prefix string, defaultIndex bool) ([]byte, error) {
+	var out bytes.Buffer
+	scanner := newLineScanner(body)
+	header := true
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Bytes()
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		if !header {
+			out.Write(raw)
+			out.WriteByte('\n')
+			header = true
+			continue
+		}
+
+		var meta map[string]interface{}
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, fmt.Errorf("malformed msearch header on line %d", line)
+		}
+		switch index := meta["index"].(type) {
+		case string:
+			meta["index"] = prefixIndices(index, prefix)
+		case []interface{}:
+			for i, name := range index {
+				if s, ok := name.(string); ok {
+					index[i] = prefixIndices(s, prefix)
+				}
+			}
+		case nil:
+			if defaultIndex {
+				meta["index"] = prefix + "*"
+			}
+		}
+		if err := writeLine(&out, meta); err != nil {
+			return nil, err
+		}
+		header = false
+	}
+	return out.Bytes(), scanner.Err()
+}
+
+// rewriteMget prefixes the _index of the docs.
+func rewriteMget(body []byte, prefix string) ([]byte, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return body, nil
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("malformed mget body")
+	}
+	raw, ok := doc["docs"]
+	if !ok {
+		return body, nil
+	}
+	var docs []map[string]interface{}
+	if err := json.Unmarshal(raw, &docs); err != nil {
+		return nil, fmt.Errorf("malformed mget docs")
+	}
+	for _, d := range docs {
+		if index, ok := d["_index"].(string); ok {
+			d["_index"] = prefix + index
+		}
+	}
+	raw, err := json.Marshal(docs)
+	if err != nil {
+		return nil, err
+	}
+	doc["docs"] = raw
+	return json.Marshal(doc)
+}
+
+func newLineScanner(body []byte) *bufio.Scanner {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	// bulk sources can be large
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	return scanner
+}
+
+func writeLine(out *bytes.Buffer, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	out.Write(raw)
+	out.WriteByte('\n')
+	return nil
+}
+
+// stripResponse removes the tenant prefix from the index and alias names in
+// the json and the cat responses. The documents are left untouched.
+func stripResponse(body []byte, contentType, prefix string) []byte {
+	switch {
+	case strings.Contains(contentType, "json"):
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var v interface{}
+		if err := decoder.Decode(&v); err != nil {
+			return body
+		}
+		var out bytes.Buffer
+		encoder := json.NewEncoder(&out)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(strip(v, prefix, true)); err != nil {
+			return body
+		}
+		return out.Bytes()
+	case strings.HasPrefix(contentType, "text/plain"):
+		pattern := regexp.MustCompile(`(^|\s)` + regexp.QuoteMeta(prefix))
+		return pattern.ReplaceAll(body, []byte("$1"))
+	default:
+		return body
+	}
+}
+
+// strip walks the json value, the keys of the objects keyed by index or alias
+// names are stripped along with the values naming an index or alias.
+func strip(v interface{}, prefix string, keyedByIndex bool) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			switch key {
+			case "_source", "fields", "highlight", "inner_hits":
+				out[key] = value
+				continue
+			case "_index", "index", "alias":
+				if s, ok := value.(string); ok {
+					value = strings.TrimPrefix(s, prefix)
+				}
+			case "reason":
+				if s, ok := value.(string); ok {
+					value = strings.Replace(s, prefix, "", -1)
+				}
+			}
+			if keyedByIndex {
+				key = strings.TrimPrefix(key, prefix)
+			}
+			out[key] = strip(value, prefix, key == "aliases" || key == "indices")
+		}
+		return out
+	case []interface{}:
+		for i, value := range t {
+			t[i] = strip(value, prefix, false)
+		}
+		return t
+	default:
+		return v
+	}
+}
@@ -0,0 +1,143 @@
+package tenancy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPrefixIndices(t *testing.T) {
+	Convey("Index names and patterns are prefixed", t, func() {
+		So(prefixIndices("products", "acme_"), ShouldEqual, "acme_products")
+		So(prefixIndices("products,orders-*", "acme_"), ShouldEqual, "acme_products,acme_orders-*")
+		So(prefixIndices("_all", "acme_"), ShouldEqual, "acme_*")
+		So(prefixIndices("*,-orders", "acme_"), ShouldEqual, "acme_*,-acme_orders")
+	})
+}
+
+func TestRewritePath(t *testing.T) {
+	Convey("Index and alias variables are prefixed", t, func() {
+		path, err := rewritePath("/{index}/_doc/{id}", map[string]string{"index": "products", "id": "1"}, "acme_")
+		So(err, ShouldBeNil)
+		So(path, ShouldEqual, "/acme_products/_doc/1")
+
+		path, err = rewritePath("/{index}/_alias/{name}", map[string]string{"index": "products", "name": "current"}, "acme_")
+		So(err, ShouldBeNil)
+		So(path, ShouldEqual, "/acme_products/_alias/acme_current")
+	})
+
+	Convey("Apis spanning all the indices are confined to the tenant", t, func() {
+		path, err := rewritePath("/_search", nil, "acme_")
+		So(err, ShouldBeNil)
+		So(path, ShouldEqual, "/acme_*/_search")
+
+		path, err = rewritePath("/_cat/indices", nil, "acme_")
+		So(err, ShouldBeNil)
+		So(path, ShouldEqual, "/_cat/indices/acme_*")
+	})
+
+	Convey("Apis addressing the indices in the body are left as is", t, func() {
+		path, err := rewritePath("/_bulk", nil, "acme_")
+		So(err, ShouldBeNil)
+		So(path, ShouldEqual, "/_bulk")
+	})
+
+	Convey("Cluster level apis are rejected", t, func() {
+		_, err := rewritePath("/_cluster/settings", nil, "acme_")
+		So(err, ShouldEqual, errClusterRoute)
+
+		_, err = rewritePath("/_cat/nodes", nil, "acme_")
+		So(err, ShouldEqual, errClusterRoute)
+	})
+}
+
+func TestRewriteBody(t *testing.T) {
+	Convey("Bulk actions are prefixed while the sources are kept", t, func() {
+		body := "{\"index\":{\"_index\":\"products\",\"_id\":\"1\"}}\n{\"_index\":\"products\"}\n{\"delete\":{\"_index\":\"orders\",\"_id\":\"2\"}}\n"
+		out, err := bodyRewriter("/_bulk")([]byte(body), "acme_")
+		So(err, ShouldBeNil)
+		So(string(out), ShouldEqual, "{\"index\":{\"_id\":\"1\",\"_index\":\"acme_products\"}}\n{\"_index\":\"products\"}\n{\"delete\":{\"_id\":\"2\",\"_index\":\"acme_orders\"}}\n")
+	})
+
+	Convey("Malformed bulk actions are rejected", t, func() {
+		_, err := bodyRewriter("/_bulk")([]byte("{\"index\":\n"), "acme_")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Msearch headers are prefixed and confined to the tenant", t, func() {
+		body := "{\"index\":\"products\"}\n{\"query\":{}}\n{}\n{\"query\":{}}\n"
+		out, err := bodyRewriter("/_msearch")([]byte(body), "acme_")
+		So(err, ShouldBeNil)
+		So(string(out), ShouldEqual, "{\"index\":\"acme_products\"}\n{\"query\":{}}\n{\"index\":\"acme_*\"}\n{\"query\":{}}\n")
+
+		out, err = bodyRewriter("/{index}/_msearch")([]byte(body), "acme_")
+		So(err, ShouldBeNil)
+		So(string(out), ShouldEqual, "{\"index\":\"acme_products\"}\n{\"query\":{}}\n{}\n{\"query\":{}}\n")
+	})
+
+	Convey("Mget docs are prefixed", t, func() {
+		out, err := bodyRewriter("/_mget")([]byte(`{"docs":[{"_index":"products","_id":"1"}]}`), "acme_")
+		So(err, ShouldBeNil)
+		So(string(out), ShouldEqual, `{"docs":[{"_id":"1","_index":"acme_products"}]}`)
+	})
+
+	Convey("Other apis have no body rewriter", t, func() {
+		So(bodyRewriter("/{index}/_search"), ShouldBeNil)
+	})
+}
+
+func TestStripResponse(t *testing.T) {
+	Convey("Index names are stripped from json responses but the documents", t, func() {
+		body := `{"acme_products":{"aliases":{"acme_current":{}}},"hits":{"hits":[{"_index":"acme_products","_source":{"index":"acme_x"}}]}}`
+		out := stripResponse([]byte(body), "application/json; charset=UTF-8", "acme_")
+
+		var v map[string]interface{}
+		So(json.Unmarshal(out, &v), ShouldBeNil)
+		So(v, ShouldContainKey, "products")
+		So(v["products"].(map[string]interface{})["aliases"], ShouldContainKey, "current")
+		hit := v["hits"].(map[string]interface{})["hits"].([]interface{})[0].(map[string]interface{})
+		So(hit["_index"], ShouldEqual, "products")
+		So(hit["_source"].(map[string]interface{})["index"], ShouldEqual, "acme_x")
+	})
+
+	Convey("Index names are stripped from cat responses", t, func() {
+		body := "green open acme_products 1 1\ngreen open acme_orders 1 1\n"
+		out := stripResponse([]byte(body), "text/plain; charset=UTF-8", "acme_")
+		So(string(out), ShouldEqual, "green open products 1 1\ngreen open orders 1 1\n")
+	})
+
+	Convey("Malformed json responses are left as is", t, func() {
+		out := stripResponse([]byte(`{"acme_`), "application/json", "acme_")
+		So(string(out), ShouldEqual, `{"acme_`)
+	})
+}
+
+func TestBufferedWriter(t *testing.T) {
+	Convey("The responses within the limit are retained", t, func() {
+		w := httptest.NewRecorder()
+		buf := &bufferedWriter{w: w, code: http.StatusOK, limit: 8}
+		buf.WriteHeader(http.StatusCreated)
+		buf.Write([]byte("acme_"))
+		buf.Write([]byte("abc"))
+		So(buf.overflowed, ShouldBeFalse)
+		So(buf.body.String(), ShouldEqual, "acme_abc")
+		So(w.Body.Len(), ShouldEqual, 0)
+	})
+
+	Convey("The responses past the limit are discarded", t, func() {
+		w := httptest.NewRecorder()
+		buf := &bufferedWriter{w: w, code: http.StatusOK, limit: 8}
+		buf.WriteHeader(http.StatusCreated)
+		buf.Write([]byte("acme_"))
+		n, err := buf.Write([]byte("products"))
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, len("products"))
+		buf.Write([]byte("!"))
+		So(buf.overflowed, ShouldBeTrue)
+		So(buf.body.Len(), ShouldEqual, 0)
+		So(w.Body.Len(), ShouldEqual, 0)
+	})
+}
@@ -0,0 +1,166 @@
+package tenancy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+const logTag = "[tenancy]"
+
+// errClusterRoute is returned for the routes that can't be confined to the
+// indices of a tenant.
+var errClusterRoute = errors.New("cluster level routes aren't accessible to tenants")
+
+// Prefix returns the prefix of the indices and aliases owned by the tenant.
+func Prefix(tenant string) string {
+	return tenant + "_"
+}
+
+// Isolate returns a middleware that confines the requests made with the
+// credentials bound to a tenant to the tenant's indices. The tenants address
+// their indices and aliases by their friendly names, which are prefixed with
+// the tenant name in the request and stripped from the response.
+func Isolate() middleware.Middleware {
+	return isolate
+}
+
+func isolate(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		tenant, err := FromRequest(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, "an error occurred while resolving the tenant", http.StatusInternalServerError)
+			return
+		}
+		if tenant == "" {
+			h(w, req)
+			return
+		}
+		prefix := Prefix(tenant)
+
+		template, err := mux.CurrentRoute(req).GetPathTemplate()
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, "page not found", http.StatusNotFound)
+			return
+		}
+		path, err := rewritePath(template, mux.Vars(req), prefix)
+		if err == errClusterRoute {
+			util.WriteBackError(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		req.URL.Path = path
+		req.URL.RawPath = ""
+
+		if rewrite := bodyRewriter(template); rewrite != nil && req.Body != nil {
+			body, err := util.ReadRequestBody(req)
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+			body, err = rewrite(body, prefix)
+			if err != nil {
+				util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			util.SetRequestBody(req, body)
+		}
+
+		// buffer the response in order to strip the tenant prefix from it, the
+		// responses too large to be buffered are refused since they would
+		// disclose the prefixed names
+		buf := &bufferedWriter{w: w, code: http.StatusOK, limit: util.MaxResponseSize()}
+		h(buf, req)
+		if buf.overflowed {
+			log.Warnln(logTag, ": response for", req.URL.Path, "exceeds", buf.limit, "bytes, refusing it")
+			w.Header().Del("Content-Length")
+			w.Header().Del("Content-Encoding")
+			msg := fmt.Sprintf("response exceeds %d bytes and can't be confined to the tenant", buf.limit)
+			util.WriteBackError(w, msg, http.StatusBadGateway)
+			return
+		}
+
+		body := buf.body.Bytes()
+		if w.Header().Get("Content-Encoding") == "" {
+			body = stripResponse(body, w.Header().Get("Content-Type"), prefix)
+			w.Header().Del("Content-Length")
+		}
+		w.WriteHeader(buf.code)
+		w.Write(body)
+	}
+}
+
+// FromRequest returns the tenant the request credential is bound to, if any.
+func FromRequest(req *http.Request) (string, error) {
+	ctx := req.Context()
+	reqCredential, err := credential.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	if reqCredential != credential.Permission {
+		return "", nil
+	}
+	reqPermission, err := permission.FromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return reqPermission.Tenant, nil
+}
+
+// bufferedWriter retains the response written by the handler up to limit
+// bytes, zero for no limit. Past the limit, the response is discarded.
+type bufferedWriter struct {
+	w          http.ResponseWriter
+	code       int
+	limit      int64
+	body       bytes.Buffer
+	overflowed bool
+}
+
+func (b *bufferedWriter) Header() http.Header {
+	return b.w.Header()
+}
+
+func (b *bufferedWriter) WriteHeader(code int) {
+	b.code = code
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	if b.overflowed {
+		return len(p), nil
+	}
+	if b.limit <= 0 || int64(b.body.Len()+len(p)) <= b.limit {
+		return b.body.Write(p)
+	}
+	b.overflowed = true
+	b.body = bytes.Buffer{}
+	return len(p), nil
+}
+
+// prefixIndices prefixes each of the comma separated index names or patterns.
+func prefixIndices(indices, prefix string) string {
+	names := strings.Split(indices, ",")
+	for i, name := range names {
+		switch {
+		case name == "_all" || name == "*":
+			names[i] = prefix + "*"
+		case strings.HasPrefix(name, "-"):
+			names[i] = "-" + prefix + strings.TrimPrefix(name, "-")
+		case name != "":
+			names[i] = prefix + name
+		}
+	}
+	return strings.Join(names, ",")
+}
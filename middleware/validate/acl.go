@@ -16,6 +16,10 @@ import (
 )
 
 // ACL returns a middleware that validates the request acl against the credential acls.
+// The admin users can access every acl they aren't denied, and the credentials
+// granted the wildcard acl every acl of their categories. The requests that
+// aren't authenticated are rejected with 401, those the credential can't
+// access with 403.
 func ACL() middleware.Middleware {
 	return validateACL
 }
@@ -35,7 +39,7 @@ func validateACL(h http.HandlerFunc) http.HandlerFunc {
 		reqCredential, err := credential.FromContext(ctx)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, errMsg, http.StatusInternalServerError)
+			unauthenticated(w)
 			return
 		}
 
@@ -48,8 +52,7 @@ func validateACL(h http.HandlerFunc) http.HandlerFunc {
 
 		if !ok {
 			msg := fmt.Sprintf(`credentials cannot access "%s" acl`, reqACL.String())
-			w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-			util.WriteBackError(w, msg, http.StatusUnauthorized)
+			util.WriteBackError(w, msg, http.StatusForbidden)
 			return
 		}
 
@@ -64,6 +67,9 @@ func hasACL(ctx context.Context, c credential.Credential, acl *acl.ACL) (bool, e
 		if err != nil {
 			return false, err
 		}
+		if reqUser.Admin() {
+			return !reqUser.DeniesACL(*acl), nil
+		}
 		return reqUser.HasACL(*acl), nil
 	case credential.Permission:
 		reqPermission, err := permission.FromContext(ctx)
@@ -75,3 +81,10 @@ func hasACL(ctx context.Context, c credential.Credential, acl *acl.ACL) (bool, e
 		return false, fmt.Errorf("invalid credentials state reached")
 	}
 }
+
+// unauthenticated rejects the requests that weren't authenticated down the
+// chain, as opposed to those forbidden to the credential.
+func unauthenticated(w http.ResponseWriter) {
+	w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
+	util.WriteBackError(w, "the request isn't authenticated", http.StatusUnauthorized)
+}
@@ -0,0 +1,87 @@
+package validate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidateACL(t *testing.T) {
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	serve := func(ctx context.Context, a acl.ACL, c category.Category) int {
+		ctx = acl.NewContext(ctx, &a)
+		ctx = category.NewContext(ctx, &c)
+		req := httptest.NewRequest(http.MethodGet, "/products/_search", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		validateCategory(validateACL(ok))(w, req)
+		return w.Code
+	}
+	withUser := func(u *user.User) context.Context {
+		ctx := credential.NewContext(context.Background(), credential.User)
+		return user.NewContext(ctx, u)
+	}
+	withPermission := func(p *permission.Permission) context.Context {
+		ctx := credential.NewContext(context.Background(), credential.Permission)
+		return permission.NewContext(ctx, p)
+	}
+	admin, notAdmin := true, false
+
+	Convey("The requests that aren't authenticated are rejected with 401", t, func() {
+		So(serve(context.Background(), acl.Search, category.Search), ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("The acls the credential isn't granted are forbidden", t, func() {
+		p := &permission.Permission{Categories: []category.Category{category.Search}, ACLs: []acl.ACL{acl.Count}}
+		So(serve(withPermission(p), acl.Count, category.Search), ShouldEqual, http.StatusOK)
+		So(serve(withPermission(p), acl.Search, category.Search), ShouldEqual, http.StatusForbidden)
+		So(serve(withPermission(p), acl.Get, category.Docs), ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("The wildcard acl grants every acl of the categories", t, func() {
+		p := &permission.Permission{Categories: []category.Category{category.Search}, ACLs: []acl.ACL{acl.All}}
+		So(serve(withPermission(p), acl.Search, category.Search), ShouldEqual, http.StatusOK)
+		So(serve(withPermission(p), acl.Get, category.Docs), ShouldEqual, http.StatusForbidden)
+
+		p.DeniedCategories = []category.Category{category.Search}
+		So(serve(withPermission(p), acl.Search, category.Search), ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("The wildcard acl doesn't grant the acls of the other categories", t, func() {
+		p := &permission.Permission{Categories: []category.Category{category.Search}, ACLs: []acl.ACL{acl.All}}
+		So(p.HasACL(acl.Search), ShouldBeTrue)
+		So(p.HasACL(acl.Get), ShouldBeFalse)
+		So(p.HasACL(acl.Bulk), ShouldBeFalse)
+
+		a, c := acl.Get, category.Docs
+		ctx := acl.NewContext(withPermission(p), &a)
+		ctx = category.NewContext(ctx, &c)
+		req := httptest.NewRequest(http.MethodGet, "/products/_doc/1", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		validateACL(ok)(w, req)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+
+		u := &user.User{IsAdmin: &notAdmin, Categories: []category.Category{category.Docs}, ACLs: []acl.ACL{acl.All}}
+		So(u.HasACL(acl.Get), ShouldBeTrue)
+		So(u.HasACL(acl.Search), ShouldBeFalse)
+	})
+
+	Convey("The admin users bypass the categories and the acls they aren't denied", t, func() {
+		u := &user.User{IsAdmin: &admin}
+		So(serve(withUser(u), acl.Search, category.Search), ShouldEqual, http.StatusOK)
+		So(serve(withUser(u), acl.Cluster, category.Clusters), ShouldEqual, http.StatusOK)
+
+		u.DeniedCategories = []category.Category{category.Clusters}
+		So(serve(withUser(u), acl.Cluster, category.Clusters), ShouldEqual, http.StatusForbidden)
+		So(serve(withUser(u), acl.Search, category.Search), ShouldEqual, http.StatusOK)
+
+		So(serve(withUser(&user.User{IsAdmin: &notAdmin}), acl.Search, category.Search), ShouldEqual, http.StatusForbidden)
+	})
+}
@@ -0,0 +1,49 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util"
+)
+
+// Admin returns a middleware that only lets the admin users through, the
+// other credentials are told they aren't allowed to perform the action,
+// e.g. "manage the schemas".
+func Admin(action string) middleware.Middleware {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			ctx := req.Context()
+
+			reqCredential, err := credential.FromContext(ctx)
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackError(w, "an error occurred while validating user admin", http.StatusInternalServerError)
+				return
+			}
+			if reqCredential != credential.User {
+				util.WriteBackError(w, fmt.Sprintf("only admin users are allowed to %s", action), http.StatusForbidden)
+				return
+			}
+
+			reqUser, err := user.FromContext(ctx)
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackError(w, "an error occurred while validating user admin", http.StatusInternalServerError)
+				return
+			}
+			if !reqUser.Admin() {
+				msg := fmt.Sprintf(`user with "username"="%s" is not an admin`, reqUser.Username)
+				util.WriteBackError(w, msg, http.StatusForbidden)
+				return
+			}
+
+			h(w, req)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package validate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAdmin(t *testing.T) {
+	ok := func(w http.ResponseWriter, req *http.Request) {}
+	serve := func(ctx context.Context) int {
+		req := httptest.NewRequest(http.MethodGet, "/_schemas", nil).WithContext(ctx)
+		w := httptest.NewRecorder()
+		Admin("manage the schemas")(ok)(w, req)
+		return w.Code
+	}
+	withUser := func(u *user.User) context.Context {
+		ctx := credential.NewContext(context.Background(), credential.User)
+		return user.NewContext(ctx, u)
+	}
+	admin, notAdmin := true, false
+
+	Convey("The admin users are let through", t, func() {
+		So(serve(withUser(&user.User{IsAdmin: &admin})), ShouldEqual, http.StatusOK)
+	})
+
+	Convey("The other users are forbidden, including those stored without the admin flag", t, func() {
+		So(serve(withUser(&user.User{IsAdmin: &notAdmin})), ShouldEqual, http.StatusForbidden)
+		So(serve(withUser(&user.User{})), ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("The permissions are forbidden", t, func() {
+		ctx := credential.NewContext(context.Background(), credential.Permission)
+		So(serve(permission.NewContext(ctx, &permission.Permission{})), ShouldEqual, http.StatusForbidden)
+	})
+}
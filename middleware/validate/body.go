@@ -0,0 +1,153 @@
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/util"
+)
+
+// BodyFormat is the expected serialization of a request body.
+type BodyFormat int
+
+// Supported request body formats.
+const (
+	// JSON expects a single json document.
+	JSON BodyFormat = iota
+
+	// NDJSON expects newline delimited json documents, as accepted by
+	// the bulk and multi search apis.
+	NDJSON
+)
+
+// syntaxError locates a malformed json document in the request body.
+type syntaxError struct {
+	line   int
+	column int
+	reason string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.line, e.column, e.reason)
+}
+
+// JSONBody returns a middleware that validates the request body as a json document.
+func JSONBody() middleware.Middleware {
+	return Body(func(*http.Request) (BodyFormat, bool) { return JSON, true })
+}
+
+// NDJSONBody returns a middleware that validates the request body as newline delimited json.
+func NDJSONBody() middleware.Middleware {
+	return Body(func(*http.Request) (BodyFormat, bool) { return NDJSON, true })
+}
+
+// Body returns a middleware that validates the syntax of the request body in
+// the format resolved for the request, the body is not validated if the
+// resolver returns false. Empty bodies are always let through, malformed
+// bodies are rejected with http.StatusBadRequest along with their location.
+func Body(format func(*http.Request) (BodyFormat, bool)) middleware.Middleware {
+	return func(h http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, req *http.Request) {
+			f, ok := format(req)
+			if !ok || req.Body == nil || req.Body == http.NoBody {
+				h(w, req)
+				return
+			}
+
+			// the body is shared with the handlers down the chain, the ndjson
+			// ones are validated line by line
+			body, err := util.ReadRequestBody(req)
+			if err == nil {
+				switch f {
+				case NDJSON:
+					err = validateNDJSON(bytes.NewReader(body))
+				default:
+					err = validateJSON(body)
+				}
+			}
+
+			if e, ok := err.(*syntaxError); ok {
+				util.WriteBackErrorWithDetails(w, "malformed request body", http.StatusBadRequest, []util.ErrorDetail{
+					{
+						Type:     "json_parse_exception",
+						Reason:   e.reason,
+						Location: fmt.Sprintf("line %d, column %d", e.line, e.column),
+					},
+				})
+				return
+			}
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+
+			h(w, req)
+		}
+	}
+}
+
+// validateJSON validates the whole body as a single json document.
+func validateJSON(data []byte) error {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	var doc json.RawMessage
+	return locate(json.Unmarshal(data, &doc), data, 1)
+}
+
+// validateNDJSON validates one line at a time so that large bulk requests
+// needn't be decoded as a whole. Blank lines are ignored.
+func validateNDJSON(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		raw, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(raw)) > 0 {
+			var doc json.RawMessage
+			if e := json.Unmarshal(raw, &doc); e != nil {
+				return locate(e, raw, line)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// locate translates the json error into the position in the body, the data
+// starts at the given line.
+func locate(err error, data []byte, line int) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *json.SyntaxError:
+		l, column := position(data, e.Offset)
+		return &syntaxError{line + l - 1, column, e.Error()}
+	default:
+		return err
+	}
+}
+
+// position returns the line and column of the byte at the one-based offset.
+func position(data []byte, offset int64) (int, int) {
+	line, column := 1, 1
+	for i := int64(0); i < offset-1 && i < int64(len(data)); i++ {
+		if data[i] == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
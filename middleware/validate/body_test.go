@@ -0,0 +1,80 @@
+package validate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/util"
+)
+
+func serveBody(mw func(http.HandlerFunc) http.HandlerFunc, body string) (*httptest.ResponseRecorder, string) {
+	var forwarded string
+	h := mw(func(w http.ResponseWriter, req *http.Request) {
+		raw, _ := ioutil.ReadAll(req.Body)
+		forwarded = string(raw)
+		w.WriteHeader(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodPost, "/_search", strings.NewReader(body)))
+	return w, forwarded
+}
+
+func detail(w *httptest.ResponseRecorder) util.ErrorDetail {
+	var envelope util.ErrorEnvelope
+	json.Unmarshal(w.Body.Bytes(), &envelope)
+	if len(envelope.Error.Details) == 0 {
+		return util.ErrorDetail{}
+	}
+	return envelope.Error.Details[0]
+}
+
+func TestBody(t *testing.T) {
+	Convey("Valid json bodies are forwarded as is", t, func() {
+		body := `{"query": {"match_all": {}}}`
+		w, forwarded := serveBody(JSONBody(), body)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(forwarded, ShouldEqual, body)
+	})
+
+	Convey("Empty bodies are let through", t, func() {
+		w, _ := serveBody(JSONBody(), "")
+		So(w.Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("Malformed json is located", t, func() {
+		w, _ := serveBody(JSONBody(), "{\n  \"query\": {\n    \"match_all\" {}\n  }\n}")
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(detail(w).Location, ShouldEqual, "line 3, column 17")
+	})
+
+	Convey("Trailing data after the json document is rejected", t, func() {
+		w, _ := serveBody(JSONBody(), `{} {}`)
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(detail(w).Location, ShouldEqual, "line 1, column 4")
+	})
+
+	Convey("Valid ndjson bodies are forwarded as is", t, func() {
+		body := "{\"index\": {}}\n{\"field\": 1}\n\n"
+		w, forwarded := serveBody(NDJSONBody(), body)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(forwarded, ShouldEqual, body)
+	})
+
+	Convey("Malformed ndjson lines are located", t, func() {
+		w, _ := serveBody(NDJSONBody(), "{\"index\": {}}\n{\"field\": 1}\n{\"index\": {}}\n{\"field\": }\n")
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(detail(w).Location, ShouldEqual, "line 4, column 11")
+	})
+
+	Convey("Truncated ndjson lines are located", t, func() {
+		w, _ := serveBody(NDJSONBody(), "{\"index\": {}}\n{\"field\": 1")
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(detail(w).Location, ShouldEqual, "line 2, column 11")
+	})
+}
@@ -0,0 +1,241 @@
+package validate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/util"
+)
+
+// bodyIndex is an index named in the request body along with its location.
+type bodyIndex struct {
+	name     string
+	location string
+}
+
+// BodyIndices returns a middleware that validates the indices named in the
+// bodies of the bulk, multi search and multi get requests against the
+// credential indices, since those aren't reflected in the request path.
+// Malformed bodies are let through for the downstream to reject.
+func BodyIndices() middleware.Middleware {
+	return bodyIndices
+}
+
+func bodyIndices(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		reqACL, err := acl.FromContext(ctx)
+		if err != nil {
+			h(w, req)
+			return
+		}
+		extract := bodyIndexExtractor(*reqACL)
+		if extract == nil || req.Body == nil || req.Body == http.NoBody {
+			h(w, req)
+			return
+		}
+
+		errMsg := "an error occurred while validating indices"
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			log.Errorln(logTag, ": unable to read request body:", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		named, err := extract(body)
+		if err != nil || len(named) == 0 {
+			h(w, req)
+			return
+		}
+
+		reqCredential, err := credential.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			unauthenticated(w)
+			return
+		}
+		accessor, err := indexAccessorFromContext(ctx, reqCredential)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, errMsg, http.StatusInternalServerError)
+			return
+		}
+
+		var details []util.ErrorDetail
+		var denied []string
+		for _, i := range named {
+			ok, err := canAccessIndex(ctx, accessor, i.name)
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackError(w, errMsg, http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				denied = append(denied, i.name)
+				details = append(details, util.ErrorDetail{
+					Type:     "index_forbidden",
+					Reason:   fmt.Sprintf("credentials cannot access index %q", i.name),
+					Location: i.location,
+				})
+			}
+		}
+		if len(details) > 0 {
+			msg := fmt.Sprintf("credentials cannot access %v index/indices", denied)
+			util.WriteBackErrorWithDetails(w, msg, http.StatusForbidden, details)
+			return
+		}
+
+		h(w, req)
+	}
+}
+
+// BodyIndex is an index named in a request body along with its location.
+type BodyIndex struct {
+	Name     string
+	Location string
+}
+
+// IndicesInBody returns the indices named in the body of the bulk, multi
+// search and multi get requests, and none for the other acls.
+func IndicesInBody(a acl.ACL, body []byte) ([]BodyIndex, error) {
+	extract := bodyIndexExtractor(a)
+	if extract == nil {
+		return nil, nil
+	}
+	named, err := extract(body)
+	if err != nil {
+		return nil, err
+	}
+	indices := make([]BodyIndex, len(named))
+	for i, n := range named {
+		indices[i] = BodyIndex{Name: n.name, Location: n.location}
+	}
+	return indices, nil
+}
+
+// bodyIndexExtractor returns the function that extracts the indices named in
+// the body of the acl, if any.
+func bodyIndexExtractor(a acl.ACL) func([]byte) ([]bodyIndex, error) {
+	switch a {
+	case acl.Bulk:
+		return bulkIndices
+	case acl.Msearch:
+		return msearchIndices
+	case acl.Mget, acl.Mtermvectors:
+		return docsIndices
+	default:
+		return nil
+	}
+}
+
+// bulkIndices extracts the _index of the bulk action lines.
+func bulkIndices(body []byte) ([]bodyIndex, error) {
+	var named []bodyIndex
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	expectSource := false
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Bytes()
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		if expectSource {
+			expectSource = false
+			continue
+		}
+
+		var action map[string]struct {
+			Index string `json:"_index"`
+		}
+		if err := json.Unmarshal(raw, &action); err != nil || len(action) != 1 {
+			return nil, fmt.Errorf("malformed bulk action on line %d", line)
+		}
+		for name, meta := range action {
+			if meta.Index != "" {
+				named = append(named, bodyIndex{meta.Index, fmt.Sprintf("line %d", line)})
+			}
+			expectSource = name != "delete"
+		}
+	}
+	return named, scanner.Err()
+}
+
+// msearchIndices extracts the indices of the multi search header lines, which
+// may be given as a comma separated string or an array.
+func msearchIndices(body []byte) ([]bodyIndex, error) {
+	var named []bodyIndex
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	header := true
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Bytes()
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		if !header {
+			header = true
+			continue
+		}
+		header = false
+
+		var meta map[string]interface{}
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, fmt.Errorf("malformed msearch header on line %d", line)
+		}
+		var names []string
+		switch index := meta["index"].(type) {
+		case string:
+			names = strings.Split(index, ",")
+		case []interface{}:
+			for _, name := range index {
+				if s, ok := name.(string); ok {
+					names = append(names, strings.Split(s, ",")...)
+				}
+			}
+		}
+		var trimmed []string
+		for _, name := range names {
+			if name = strings.TrimSpace(name); name != "" {
+				trimmed = append(trimmed, name)
+			}
+		}
+		for _, name := range expressions(trimmed) {
+			named = append(named, bodyIndex{name, fmt.Sprintf("line %d", line)})
+		}
+	}
+	return named, scanner.Err()
+}
+
+// docsIndices extracts the _index of the docs of the multi get and the multi
+// term vectors bodies.
+func docsIndices(body []byte) ([]bodyIndex, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+	var doc struct {
+		Docs []struct {
+			Index string `json:"_index"`
+		} `json:"docs"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("malformed docs body")
+	}
+	var named []bodyIndex
+	for i, d := range doc.Docs {
+		if d.Index != "" {
+			named = append(named, bodyIndex{d.Index, fmt.Sprintf("docs[%d]", i)})
+		}
+	}
+	return named, nil
+}
@@ -0,0 +1,85 @@
+package validate
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+)
+
+func serveBodyIndices(a acl.ACL, p *permission.Permission, body string) (*httptest.ResponseRecorder, string) {
+	var forwarded string
+	h := BodyIndices()(func(w http.ResponseWriter, req *http.Request) {
+		raw, _ := ioutil.ReadAll(req.Body)
+		forwarded = string(raw)
+		w.WriteHeader(http.StatusOK)
+	})
+	req := httptest.NewRequest(http.MethodPost, "/_bulk", strings.NewReader(body))
+	ctx := acl.NewContext(req.Context(), &a)
+	ctx = credential.NewContext(ctx, credential.Permission)
+	ctx = permission.NewContext(ctx, p)
+	w := httptest.NewRecorder()
+	h(w, req.WithContext(ctx))
+	return w, forwarded
+}
+
+func TestBodyIndices(t *testing.T) {
+	aliasIndices = func(context.Context, string) ([]string, bool) { return nil, false }
+	indexAliases = func(context.Context, string) []string { return nil }
+	p := &permission.Permission{Indices: []string{"products*"}}
+
+	Convey("Bulk actions naming allowed indices are forwarded as is", t, func() {
+		body := "{\"index\":{\"_index\":\"products\"}}\n{\"name\":\"a\"}\n{\"delete\":{\"_index\":\"products_v2\",\"_id\":\"1\"}}\n"
+		w, forwarded := serveBodyIndices(acl.Bulk, p, body)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(forwarded, ShouldEqual, body)
+	})
+
+	Convey("Bulk actions naming other indices are rejected with their line", t, func() {
+		body := "{\"index\":{\"_index\":\"products\"}}\n{\"_index\":\"orders\"}\n{\"delete\":{\"_index\":\"orders\",\"_id\":\"1\"}}\n"
+		w, _ := serveBodyIndices(acl.Bulk, p, body)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(detail(w).Location, ShouldEqual, "line 3")
+		So(detail(w).Type, ShouldEqual, "index_forbidden")
+	})
+
+	Convey("Msearch headers are checked for comma separated and array indices", t, func() {
+		body := "{\"index\":\"products,products_v2\"}\n{}\n{}\n{}\n"
+		w, _ := serveBodyIndices(acl.Msearch, p, body)
+		So(w.Code, ShouldEqual, http.StatusOK)
+
+		body = "{\"index\":[\"products\"]}\n{}\n{\"index\":[\"products_v2\",\"orders\"]}\n{}\n"
+		w, _ = serveBodyIndices(acl.Msearch, p, body)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(detail(w).Location, ShouldEqual, "line 3")
+	})
+
+	Convey("Mget docs are checked by position", t, func() {
+		body := `{"docs":[{"_index":"products","_id":"1"},{"_index":"orders","_id":"2"}]}`
+		w, _ := serveBodyIndices(acl.Mget, p, body)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(detail(w).Location, ShouldEqual, "docs[1]")
+	})
+
+	Convey("Denied indices named in the body are rejected", t, func() {
+		p := &permission.Permission{Indices: []string{"*"}, DeniedIndices: []string{"secrets"}}
+		body := "{\"index\":{\"_index\":\"secrets\"}}\n{}\n"
+		w, _ := serveBodyIndices(acl.Bulk, p, body)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("Other apis are let through without reading the body", t, func() {
+		body := `{"query":{"match_all":{}}}`
+		w, forwarded := serveBodyIndices(acl.Search, p, body)
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(forwarded, ShouldEqual, body)
+	})
+}
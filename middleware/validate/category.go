@@ -16,6 +16,9 @@ import (
 )
 
 // Category returns a middleware that validates the request category against credential categories.
+// The admin users can access every category they aren't denied. The requests
+// that aren't authenticated are rejected with 401, those the credential can't
+// access with 403.
 func Category() middleware.Middleware {
 	return validateCategory
 }
@@ -35,7 +38,7 @@ func validateCategory(h http.HandlerFunc) http.HandlerFunc {
 		reqCredential, err := credential.FromContext(ctx)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, errMsg, http.StatusInternalServerError)
+			unauthenticated(w)
 			return
 		}
 
@@ -48,8 +51,7 @@ func validateCategory(h http.HandlerFunc) http.HandlerFunc {
 
 		if !ok {
 			msg := fmt.Sprintf(`credential can't access "%s" category`, reqCategory.String())
-			w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-			util.WriteBackError(w, msg, http.StatusUnauthorized)
+			util.WriteBackError(w, msg, http.StatusForbidden)
 			return
 		}
 
@@ -64,6 +66,9 @@ func hasCategory(ctx context.Context, c credential.Credential, cat *category.Cat
 		if err != nil {
 			return false, err
 		}
+		if reqUser.Admin() {
+			return !reqUser.DeniesCategory(*cat), nil
+		}
 		return reqUser.HasCategory(*cat), nil
 	case credential.Permission:
 		reqPermission, err := permission.FromContext(ctx)
@@ -3,6 +3,7 @@ package validate
 import (
 	"fmt"
 	"net/http"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/appbaseio/arc/model/credential"
 	"github.com/appbaseio/arc/model/permission"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/notify"
 )
 
 // PermissionExpiry returns a middleware that checks whether a permission is expired or not.
@@ -24,7 +26,7 @@ func validateExpiry(h http.HandlerFunc) http.HandlerFunc {
 		reqCredential, err := credential.FromContext(ctx)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+			unauthenticated(w)
 			return
 		}
 
@@ -44,6 +46,7 @@ func validateExpiry(h http.HandlerFunc) http.HandlerFunc {
 			}
 
 			if expired {
+				notifyExpired(req, reqPermission.Username)
 				msg := fmt.Sprintf("permission with username=%s is expired", reqPermission.Username)
 				w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
 				util.WriteBackError(w, msg, http.StatusUnauthorized)
@@ -54,3 +57,19 @@ func validateExpiry(h http.HandlerFunc) http.HandlerFunc {
 		h(w, req)
 	}
 }
+
+// notifiedExpiries holds the permissions whose expiry has been notified, the
+// webhooks are notified once per permission instead of on every request.
+var notifiedExpiries sync.Map
+
+func notifyExpired(req *http.Request, username string) {
+	if _, notified := notifiedExpiries.LoadOrStore(username, true); notified {
+		return
+	}
+	notify.Send(notify.Event{
+		Type:     notify.PermissionExpired,
+		Actor:    username,
+		Resource: username,
+		Message:  fmt.Sprintf("permission with username=%s is expired but still in use, requested %s", username, req.URL.Path),
+	})
+}
@@ -0,0 +1,60 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/family"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+// Family returns a middleware that validates the family of the elasticsearch
+// api against the families the permission is scoped to.
+func Family() middleware.Middleware {
+	return validateFamily
+}
+
+func validateFamily(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		errMsg := "an error occurred while validating request family"
+		reqCredential, err := credential.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			unauthenticated(w)
+			return
+		}
+		// the users aren't scoped to families
+		if reqCredential != credential.Permission {
+			h(w, req)
+			return
+		}
+
+		reqFamily, err := family.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, errMsg, http.StatusInternalServerError)
+			return
+		}
+		reqPermission, err := permission.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, errMsg, http.StatusInternalServerError)
+			return
+		}
+
+		if !reqPermission.HasFamily(*reqFamily) {
+			msg := fmt.Sprintf(`credentials cannot access "%s" apis`, reqFamily.String())
+			util.WriteBackError(w, msg, http.StatusForbidden)
+			return
+		}
+
+		h(w, req)
+	}
+}
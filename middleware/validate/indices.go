@@ -4,15 +4,20 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/tenancy"
 	"github.com/appbaseio/arc/model/credential"
 	"github.com/appbaseio/arc/model/index"
 	"github.com/appbaseio/arc/model/permission"
 	"github.com/appbaseio/arc/model/user"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/aliases"
+	"github.com/appbaseio/arc/util/indexwatch"
 )
 
 // Indices returns a middleware that validates the request indices against the credential indices.
@@ -35,7 +40,7 @@ func indices(h http.HandlerFunc) http.HandlerFunc {
 		reqCredential, err := credential.FromContext(ctx)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, errMsg, http.StatusInternalServerError)
+			unauthenticated(w)
 			return
 		}
 
@@ -48,13 +53,12 @@ func indices(h http.HandlerFunc) http.HandlerFunc {
 				return
 			}
 			if !ok {
-				w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-				util.WriteBackError(w, "credentials cannot access cluster level routes", http.StatusUnauthorized)
+				util.WriteBackError(w, "credentials cannot access cluster level routes", http.StatusForbidden)
 				return
 			}
 		} else {
 			// validate index level access
-			ok, err := allowedIndexAccess(ctx, reqCredential, reqIndices)
+			ok, err := allowedIndexAccess(ctx, reqCredential, expressions(reqIndices))
 			if err != nil {
 				log.Errorln(logTag, ":", err)
 				util.WriteBackError(w, errMsg, http.StatusInternalServerError)
@@ -62,8 +66,7 @@ func indices(h http.HandlerFunc) http.HandlerFunc {
 			}
 			if !ok {
 				msg := fmt.Sprintf("credentials cannot access %v index/indices", reqIndices)
-				w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-				util.WriteBackError(w, msg, http.StatusUnauthorized)
+				util.WriteBackError(w, msg, http.StatusForbidden)
 				return
 			}
 		}
@@ -92,20 +95,180 @@ func allowedClusterAccess(ctx context.Context, c credential.Credential) (bool, e
 }
 
 func allowedIndexAccess(ctx context.Context, c credential.Credential, indices []string) (bool, error) {
+	reqCredential, err := indexAccessorFromContext(ctx, c)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range indices {
+		if ok, err := canAccessIndex(ctx, reqCredential, name); !ok || err != nil {
+			return ok, err
+		}
+	}
+	return true, nil
+}
+
+// indexAccessorFromContext retrieves the user or the permission the request
+// is authenticated with.
+func indexAccessorFromContext(ctx context.Context, c credential.Credential) (indexAccessor, error) {
 	switch c {
 	case credential.User:
-		reqUser, err := user.FromContext(ctx)
+		return user.FromContext(ctx)
+	case credential.Permission:
+		return permission.FromContext(ctx)
+	default:
+		return nil, fmt.Errorf("illegal credential state reached")
+	}
+}
+
+// aliasIndices and indexAliases resolve the aliases of the cluster,
+// expandIndices resolves the index patterns.
+var (
+	aliasIndices  = aliases.Indices
+	indexAliases  = aliases.Of
+	expandIndices = expand
+)
+
+// indexAccessor is implemented by the credentials.
+type indexAccessor interface {
+	CanAccessIndex(name string) (bool, error)
+	DeniesIndex(name string) bool
+}
+
+// expressions drops the exclusions, e.g. -logs-secure in logs-*,-logs-secure,
+// from the comma separated index expressions since they can only narrow the
+// indices the expressions preceding them resolve to.
+func expressions(names []string) []string {
+	var included []string
+	wildcard := false
+	for _, name := range names {
+		if wildcard && strings.HasPrefix(name, "-") {
+			continue
+		}
+		wildcard = wildcard || strings.Contains(name, "*")
+		included = append(included, name)
+	}
+	return included
+}
+
+// canAccessIndex checks whether the credential can access the index, taking
+// the aliases into account. An alias can be accessed if the credential has
+// access to either the alias or all of its indices, unless one of them is
+// denied. An index can be accessed through the aliases pointing at it,
+// unless it's denied. The date math expressions are resolved as of now, and
+// the index patterns the credential doesn't cover are expanded against the
+// cluster, every index and alias they expand to must then be accessible.
+// The names of the tenants are resolved within the indices of the tenant.
+func canAccessIndex(ctx context.Context, c indexAccessor, name string) (bool, error) {
+	if index.IsDateMath(name) {
+		resolved, err := index.ResolveDateMath(name, time.Now())
 		if err != nil {
-			return false, err
+			log.Errorln(logTag, ":", err)
+			return false, nil
 		}
-		return reqUser.CanAccessIndices(indices...)
-	case credential.Permission:
-		reqPermission, err := permission.FromContext(ctx)
+		name = resolved
+	}
+	if name == "_all" {
+		name = "*"
+	}
+
+	ok, err := c.CanAccessIndex(name)
+	if err != nil {
+		return ok, err
+	}
+	if strings.Contains(name, "*") {
+		if ok || c.DeniesIndex(name) {
+			return ok, nil
+		}
+		prefix := tenantPrefix(c)
+		expanded, err := expandIndices(ctx, prefix+name)
 		if err != nil {
 			return false, err
 		}
-		return reqPermission.CanAccessIndices(indices...)
-	default:
-		return false, fmt.Errorf("illegal credential state reached")
+		expanded, _ = unprefixed(expanded, prefix)
+		if len(expanded) == 0 {
+			return false, nil
+		}
+		for _, concrete := range expanded {
+			if ok, err := canAccessIndex(ctx, c, concrete); !ok || err != nil {
+				return ok, err
+			}
+		}
+		return true, nil
+	}
+
+	prefix := tenantPrefix(c)
+	if concrete, isAlias := aliasIndices(ctx, prefix+name); isAlias {
+		concrete, within := unprefixed(concrete, prefix)
+		if !within {
+			return false, nil
+		}
+		all := true
+		for _, index := range concrete {
+			if c.DeniesIndex(index) {
+				return false, nil
+			}
+			if all {
+				if all, err = c.CanAccessIndex(index); err != nil {
+					return false, err
+				}
+			}
+		}
+		return ok || all, nil
+	}
+
+	if ok || c.DeniesIndex(name) {
+		return ok, nil
+	}
+	aliases, _ := unprefixed(indexAliases(ctx, prefix+name), prefix)
+	for _, alias := range aliases {
+		if ok, err := c.CanAccessIndex(alias); ok || err != nil {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// tenantPrefix returns the prefix of the indices of the tenant the credential
+// is bound to, if any.
+func tenantPrefix(c indexAccessor) string {
+	if p, ok := c.(*permission.Permission); ok && p.Tenant != "" {
+		return tenancy.Prefix(p.Tenant)
+	}
+	return ""
+}
+
+// unprefixed strips the prefix from the names, the names without the prefix
+// are dropped and reported as false.
+func unprefixed(names []string, prefix string) ([]string, bool) {
+	if prefix == "" {
+		return names, true
+	}
+	within := true
+	var stripped []string
+	for _, name := range names {
+		if !strings.HasPrefix(name, prefix) {
+			within = false
+			continue
+		}
+		stripped = append(stripped, strings.TrimPrefix(name, prefix))
+	}
+	return stripped, within
+}
+
+// expand resolves the index pattern into the indices and the aliases of the
+// cluster matching it. The indices are those of the last poll of the watcher
+// if the indices are watched.
+func expand(ctx context.Context, pattern string) ([]string, error) {
+	if watched, ok := indexwatch.Matching(pattern); ok {
+		return append(watched, aliases.Matching(ctx, pattern)...), nil
+	}
+	response, err := util.GetClient7().CatIndices().Index(pattern).Columns("index").Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var expanded []string
+	for _, row := range response {
+		expanded = append(expanded, row.Index)
 	}
+	return append(expanded, aliases.Matching(ctx, pattern)...), nil
 }
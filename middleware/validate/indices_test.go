@@ -0,0 +1,147 @@
+package validate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/appbaseio/arc/model/permission"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCanAccessIndex(t *testing.T) {
+	aliasIndices = func(ctx context.Context, alias string) ([]string, bool) {
+		indices, ok := map[string][]string{
+			"products": {"products_v3"},
+			"logs":     {"logs-app", "logs-secure"},
+		}[alias]
+		return indices, ok
+	}
+	indexAliases = func(ctx context.Context, index string) []string {
+		return map[string][]string{
+			"products_v3": {"products"},
+			"logs-app":    {"logs"},
+			"logs-secure": {"logs"},
+		}[index]
+	}
+	ctx := context.Background()
+
+	Convey("An index can be accessed through its aliases", t, func() {
+		p := &permission.Permission{Indices: []string{"products"}}
+		ok, err := canAccessIndex(ctx, p, "products_v3")
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		ok, _ = canAccessIndex(ctx, p, "products")
+		So(ok, ShouldBeTrue)
+		ok, _ = canAccessIndex(ctx, p, "orders")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("An alias can be accessed through all its indices", t, func() {
+		p := &permission.Permission{Indices: []string{"products_v*"}}
+		ok, _ := canAccessIndex(ctx, p, "products")
+		So(ok, ShouldBeTrue)
+
+		p = &permission.Permission{Indices: []string{"logs-app"}}
+		ok, _ = canAccessIndex(ctx, p, "logs")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("An alias pointing at a denied index can't be accessed", t, func() {
+		p := &permission.Permission{Indices: []string{"logs*"}, DeniedIndices: []string{"logs-secure"}}
+		ok, _ := canAccessIndex(ctx, p, "logs")
+		So(ok, ShouldBeFalse)
+		ok, _ = canAccessIndex(ctx, p, "logs-app")
+		So(ok, ShouldBeTrue)
+	})
+
+	Convey("A denied index can't be accessed through its aliases", t, func() {
+		p := &permission.Permission{Indices: []string{"logs"}, DeniedIndices: []string{"logs-secure"}}
+		ok, _ := canAccessIndex(ctx, p, "logs-secure")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Uncovered index patterns are expanded against the cluster", t, func() {
+		expandIndices = func(ctx context.Context, pattern string) ([]string, error) {
+			return map[string][]string{
+				"logs-*":  {"logs-app", "logs-secure", "logs"},
+				"logs-a*": {"logs-app"},
+			}[pattern], nil
+		}
+		p := &permission.Permission{Indices: []string{"logs-app", "products"}}
+		ok, _ := canAccessIndex(ctx, p, "logs-a*")
+		So(ok, ShouldBeTrue)
+		ok, _ = canAccessIndex(ctx, p, "logs-*")
+		So(ok, ShouldBeFalse)
+		ok, _ = canAccessIndex(ctx, p, "orders-*")
+		So(ok, ShouldBeFalse)
+
+		p = &permission.Permission{Indices: []string{"logs-*"}}
+		ok, _ = canAccessIndex(ctx, p, "logs-*")
+		So(ok, ShouldBeTrue)
+	})
+
+	Convey("Index names are matched as a whole", t, func() {
+		p := &permission.Permission{Indices: []string{"products*"}}
+		ok, _ := canAccessIndex(ctx, p, "oldproducts")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("Date math expressions are resolved before being authorized", t, func() {
+		today := time.Now().UTC().Format("2006.01.02")
+		p := &permission.Permission{Indices: []string{"logs-" + today}}
+		ok, _ := canAccessIndex(ctx, p, "<logs-{now/d}>")
+		So(ok, ShouldBeTrue)
+		ok, _ = canAccessIndex(ctx, p, "<logs-{now-1d/d}>")
+		So(ok, ShouldBeFalse)
+		ok, _ = canAccessIndex(ctx, p, "<logs-{now/d>")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("The names of the tenants are resolved within their indices", t, func() {
+		expandIndices = func(ctx context.Context, pattern string) ([]string, error) {
+			return map[string][]string{
+				"logs-*":      {"logs-app", "globex_logs-app", "acme_logs-app"},
+				"acme_logs-*": {"acme_logs-app"},
+				"acme_*":      {"acme_logs-app", "acme_orders"},
+			}[pattern], nil
+		}
+		aliasIndices = func(ctx context.Context, alias string) ([]string, bool) {
+			indices, ok := map[string][]string{
+				"logs":        {"logs-app"},
+				"acme_logs":   {"acme_logs-app"},
+				"acme_shared": {"acme_logs-app", "globex_logs-app"},
+			}[alias]
+			return indices, ok
+		}
+		indexAliases = func(ctx context.Context, index string) []string {
+			return map[string][]string{
+				"acme_logs-app": {"acme_logs", "acme_shared"},
+			}[index]
+		}
+		p := &permission.Permission{Tenant: "acme", Indices: []string{"logs-app"}}
+		ok, _ := canAccessIndex(ctx, p, "logs-*")
+		So(ok, ShouldBeTrue)
+		ok, _ = canAccessIndex(ctx, p, "*")
+		So(ok, ShouldBeFalse)
+		ok, _ = canAccessIndex(ctx, p, "logs")
+		So(ok, ShouldBeTrue)
+		ok, _ = canAccessIndex(ctx, p, "shared")
+		So(ok, ShouldBeFalse)
+
+		p = &permission.Permission{Tenant: "acme", Indices: []string{"logs-app", "orders"}}
+		ok, _ = canAccessIndex(ctx, p, "*")
+		So(ok, ShouldBeTrue)
+
+		p = &permission.Permission{Tenant: "acme", Indices: []string{"logs"}}
+		ok, _ = canAccessIndex(ctx, p, "logs-app")
+		So(ok, ShouldBeTrue)
+	})
+}
+
+func TestExpressions(t *testing.T) {
+	Convey("The exclusions following a wildcard are dropped", t, func() {
+		So(expressions([]string{"logs-*", "-logs-secure", "products"}), ShouldResemble, []string{"logs-*", "products"})
+		So(expressions([]string{"-logs", "logs-*"}), ShouldResemble, []string{"-logs", "logs-*"})
+	})
+}
@@ -35,7 +35,7 @@ func operation(h http.HandlerFunc) http.HandlerFunc {
 		reqCredential, err := credential.FromContext(ctx)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, errMsg, http.StatusInternalServerError)
+			unauthenticated(w)
 			return
 		}
 
@@ -48,8 +48,7 @@ func operation(h http.HandlerFunc) http.HandlerFunc {
 
 		if !ok {
 			msg := fmt.Sprintf(`credential cannot perform "%v" operation`, reqOp.String())
-			w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-			util.WriteBackError(w, msg, http.StatusUnauthorized)
+			util.WriteBackError(w, msg, http.StatusForbidden)
 			return
 		}
 
@@ -25,7 +25,7 @@ func referers(h http.HandlerFunc) http.HandlerFunc {
 		reqCredential, err := credential.FromContext(ctx)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+			unauthenticated(w)
 			return
 		}
 
@@ -59,8 +59,7 @@ func referers(h http.HandlerFunc) http.HandlerFunc {
 			}
 
 			if !validated {
-				w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-				util.WriteBackError(w, "permission doesn't have required referers", http.StatusUnauthorized)
+				util.WriteBackError(w, "permission doesn't have required referers", http.StatusForbidden)
 				return
 			}
 		}
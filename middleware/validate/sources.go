@@ -28,7 +28,7 @@ func sources(h http.HandlerFunc) http.HandlerFunc {
 		reqCredential, err := credential.FromContext(ctx)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+			unauthenticated(w)
 			return
 		}
 
@@ -36,8 +36,7 @@ func sources(h http.HandlerFunc) http.HandlerFunc {
 			reqIP := iplookup.FromRequest(req)
 			if reqIP == "" {
 				msg := fmt.Sprintf(`failed to recognize request ip: "%s"`, reqIP)
-				w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-				util.WriteBackError(w, msg, http.StatusUnauthorized)
+				util.WriteBackError(w, msg, http.StatusForbidden)
 				return
 			}
 			ip := net.ParseIP(reqIP)
@@ -71,8 +70,7 @@ func sources(h http.HandlerFunc) http.HandlerFunc {
 			if !validated {
 				msg := fmt.Sprintf(`permission with username %s doesn't have required sources. reqIP = %s, sources = %s`,
 					reqPermission.Username, reqIP, allowedSources)
-				w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-				util.WriteBackError(w, msg, http.StatusUnauthorized)
+				util.WriteBackError(w, msg, http.StatusForbidden)
 				return
 			}
 		}
@@ -0,0 +1,60 @@
+package validate
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/subcategory"
+	"github.com/appbaseio/arc/util"
+)
+
+// Subcategory returns a middleware that validates the sub-category of the
+// searches against the sub-categories the permission is scoped to. The
+// requests without a sub-category, i.e. outside the search category, are let
+// through.
+func Subcategory() middleware.Middleware {
+	return validateSubcategory
+}
+
+func validateSubcategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		reqSubcategory, err := subcategory.FromContext(ctx)
+		if err != nil {
+			h(w, req)
+			return
+		}
+		reqCredential, err := credential.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			unauthenticated(w)
+			return
+		}
+		// the users aren't scoped to sub-categories
+		if reqCredential != credential.Permission {
+			h(w, req)
+			return
+		}
+
+		reqPermission, err := permission.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, "an error occurred while validating request subcategory", http.StatusInternalServerError)
+			return
+		}
+
+		if !reqPermission.HasSubcategory(*reqSubcategory) {
+			msg := fmt.Sprintf(`credentials cannot make "%s" searches`, reqSubcategory.String())
+			util.WriteBackError(w, msg, http.StatusForbidden)
+			return
+		}
+
+		h(w, req)
+	}
+}
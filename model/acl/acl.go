@@ -11,6 +11,8 @@ type contextKey string
 // ctxKey is a key against which an acl.ACL is stored in the context.
 const ctxKey = contextKey("acl")
 
+//go:generate enumer -type=ACL -json -transform=snake
+
 // ACL is a type that represents an elasticsearch acl.
 type ACL int
 
@@ -70,6 +72,9 @@ const (
 	Tasks
 	Termvectors
 	Update
+	// All is the wildcard acl, the credentials granted it can access every
+	// acl of their categories.
+	All
 )
 
 // NewContext returns a new context with the given ACL.
@@ -90,7 +95,7 @@ func FromContext(ctx context.Context) (*ACL, error) {
 	return reqCategory, nil
 }
 
-// Contains checks if the given slice of category contains the given acl.
+// Contains checks if the given slice of acls contains the given acl.
 func Contains(acls []ACL, acl ACL) bool {
 	for _, a := range acls {
 		if a == acl {
@@ -7,18 +7,18 @@ import (
 	"fmt"
 )
 
-const _ACLName = "catbulkclustersearchremotecreatecountscriptsdeletedocsourcefield_capscloseanalyzeexistsgettemplateexplainindicesaliasaliasesdelete_by_querycacheindexmappingflushforcemergeupgradesettingsopenrecoverymappingsrolloverrefreshsegmentsshrinksplitshard_storesstatsingestvalidatemsearchmgetnodesmtermvectorsreindexupdate_by_queryrenderrank_evalsearch_shardssnapshottaskstermvectorsupdate"
+const _ACLName = "catbulkclustersearchremotecreatecountscriptsdeletedocsourcefield_capscloseanalyzeexistsgettemplateexplainindicesaliasaliasesdelete_by_querycacheindexmappingflushforcemergeupgradesettingsopenrecoverymappingsrolloverrefreshsegmentsshrinksplitshard_storesstatsingestvalidatemsearchmgetnodesmtermvectorsreindexupdate_by_queryrenderrank_evalsearch_shardssnapshottaskstermvectorsupdateall"
 
-var _CategoryIndex = [...]uint16{0, 3, 7, 14, 20, 26, 32, 37, 44, 50, 53, 59, 69, 74, 81, 87, 90, 98, 105, 112, 117, 124, 139, 144, 149, 156, 161, 171, 178, 186, 190, 198, 206, 214, 221, 229, 235, 240, 252, 257, 263, 271, 278, 282, 287, 299, 306, 321, 327, 336, 349, 357, 362, 373, 379}
+var _ACLIndex = [...]uint16{0, 3, 7, 14, 20, 26, 32, 37, 44, 50, 53, 59, 69, 74, 81, 87, 90, 98, 105, 112, 117, 124, 139, 144, 149, 156, 161, 171, 178, 186, 190, 198, 206, 214, 221, 229, 235, 240, 252, 257, 263, 271, 278, 282, 287, 299, 306, 321, 327, 336, 349, 357, 362, 373, 379, 382}
 
-func (a ACL) String() string {
-	if a < 0 || a >= ACL(len(_CategoryIndex)-1) {
-		return fmt.Sprintf("ACL(%d)", a)
+func (i ACL) String() string {
+	if i < 0 || i >= ACL(len(_ACLIndex)-1) {
+		return fmt.Sprintf("ACL(%d)", i)
 	}
-	return _ACLName[_CategoryIndex[a]:_CategoryIndex[a+1]]
+	return _ACLName[_ACLIndex[i]:_ACLIndex[i+1]]
 }
 
-var _ACLValues = []ACL{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47, 48, 49, 50, 51, 52, 53}
+var _ACLValues = []ACL{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39, 40, 41, 42, 43, 44, 45, 46, 47, 48, 49, 50, 51, 52, 53, 54}
 
 var _ACLNameToValueMap = map[string]ACL{
 	_ACLName[0:3]:     0,
@@ -75,45 +75,46 @@ var _ACLNameToValueMap = map[string]ACL{
 	_ACLName[357:362]: 51,
 	_ACLName[362:373]: 52,
 	_ACLName[373:379]: 53,
+	_ACLName[379:382]: 54,
 }
 
-// FromString retrieves an enum value from the enum constants string name.
+// ACLString retrieves an enum value from the enum constants string name.
 // Throws an error if the param is not part of the enum.
-func FromString(s string) (ACL, error) {
+func ACLString(s string) (ACL, error) {
 	if val, ok := _ACLNameToValueMap[s]; ok {
 		return val, nil
 	}
 	return 0, fmt.Errorf("%s does not belong to ACL values", s)
 }
 
-// Values returns all values of the enum.
-func Values() []ACL {
+// ACLValues returns all values of the enum
+func ACLValues() []ACL {
 	return _ACLValues
 }
 
-// IsAnACL returns "true" if the value is listed in the enum definition. "false" otherwise.
-func (a ACL) IsAnACL() bool {
+// IsAACL returns "true" if the value is listed in the enum definition. "false" otherwise
+func (i ACL) IsAACL() bool {
 	for _, v := range _ACLValues {
-		if a == v {
+		if i == v {
 			return true
 		}
 	}
 	return false
 }
 
-// MarshalJSON implements the json.Marshaler interface for ACL.
-func (a ACL) MarshalJSON() ([]byte, error) {
-	return json.Marshal(a.String())
+// MarshalJSON implements the json.Marshaler interface for ACL
+func (i ACL) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.String())
 }
 
-// UnmarshalJSON implements the json.Unmarshaler interface for ACL.
-func (a *ACL) UnmarshalJSON(data []byte) error {
+// UnmarshalJSON implements the json.Unmarshaler interface for ACL
+func (i *ACL) UnmarshalJSON(data []byte) error {
 	var s string
 	if err := json.Unmarshal(data, &s); err != nil {
 		return fmt.Errorf("ACL should be a string, got %s", data)
 	}
 
 	var err error
-	*a, err = FromString(s)
+	*i, err = ACLString(s)
 	return err
 }
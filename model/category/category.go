@@ -34,6 +34,8 @@ const (
 	Suggestions
 	Auth
 	Functions
+	Logs
+	Reindex
 )
 
 // String is an implementation of Stringer interface that returns the string representation of category.Categories.
@@ -54,6 +56,8 @@ func (c Category) String() string {
 		"suggestions",
 		"auth",
 		"functions",
+		"logs",
+		"reindex",
 	}[c]
 }
 
@@ -95,6 +99,10 @@ func (c *Category) UnmarshalJSON(bytes []byte) error {
 		*c = Auth
 	case Functions.String():
 		*c = Functions
+	case Logs.String():
+		*c = Logs
+	case Reindex.String():
+		*c = Reindex
 	default:
 		return fmt.Errorf("invalid category encountered: %v", category)
 	}
@@ -135,6 +143,10 @@ func (c Category) MarshalJSON() ([]byte, error) {
 		category = Auth.String()
 	case Functions:
 		category = Functions.String()
+	case Logs:
+		category = Logs.String()
+	case Reindex:
+		category = Reindex.String()
 	default:
 		return nil, fmt.Errorf("invalid category encountered: %v" + c.String())
 	}
@@ -152,6 +164,17 @@ func (c Category) IsFromES() bool {
 		c == Misc
 }
 
+// Covers checks whether the credentials granted the category can access the
+// given category. The user category keeps covering the logs and the reindex
+// categories, whose APIs it classified before they got their own, so that the
+// existing credentials don't lose access to them.
+func (c Category) Covers(other Category) bool {
+	if c == other {
+		return true
+	}
+	return c == User && (other == Logs || other == Reindex)
+}
+
 // HasACL checks whether the given acl is a value in the category categories.
 func (c Category) HasACL(a acl.ACL) bool {
 	return acl.Contains(c.ACLs(), a)
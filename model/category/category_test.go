@@ -0,0 +1,36 @@
+package category
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCovers(t *testing.T) {
+	Convey("The categories cover themselves", t, func() {
+		So(Logs.Covers(Logs), ShouldBeTrue)
+		So(Analytics.Covers(Analytics), ShouldBeTrue)
+		So(Logs.Covers(User), ShouldBeFalse)
+		So(Analytics.Covers(User), ShouldBeFalse)
+	})
+
+	Convey("The user category keeps covering the logs and the reindex", t, func() {
+		So(User.Covers(Logs), ShouldBeTrue)
+		So(User.Covers(Reindex), ShouldBeTrue)
+		So(User.Covers(Permission), ShouldBeFalse)
+		So(Permission.Covers(Logs), ShouldBeFalse)
+	})
+}
+
+func TestJSON(t *testing.T) {
+	Convey("The arc management categories are marshaled by name", t, func() {
+		raw, err := json.Marshal([]Category{Logs, Reindex})
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `["logs","reindex"]`)
+
+		var categories []Category
+		So(json.Unmarshal(raw, &categories), ShouldBeNil)
+		So(categories, ShouldResemble, []Category{Logs, Reindex})
+	})
+}
@@ -0,0 +1,88 @@
+package family
+
+import "strings"
+
+// apis maps the elasticsearch apis, named after their rest api spec, that
+// don't belong to the family of their namespace.
+var apis = map[string]Family{
+	// document apis
+	"bulk":               Docs,
+	"create":             Docs,
+	"delete":             Docs,
+	"delete_by_query":    Docs,
+	"exists":             Docs,
+	"exists_source":      Docs,
+	"get":                Docs,
+	"get_source":         Docs,
+	"index":              Docs,
+	"mget":               Docs,
+	"mtermvectors":       Docs,
+	"termvectors":        Docs,
+	"update":             Docs,
+	"update_by_query":    Docs,
+	"reindex":            Docs,
+	"reindex_rethrottle": Docs,
+
+	// search apis
+	"search":                 Search,
+	"msearch":                Search,
+	"search_template":        Search,
+	"msearch_template":       Search,
+	"render_search_template": Search,
+	"count":                  Search,
+	"explain":                Search,
+	"field_caps":             Search,
+	"rank_eval":              Search,
+	"scroll":                 Search,
+	"clear_scroll":           Search,
+	"search_shards":          Search,
+	"indices.validate_query": Search,
+
+	// script apis
+	"get_script":               Scripts,
+	"put_script":               Scripts,
+	"delete_script":            Scripts,
+	"scripts_painless_execute": Scripts,
+
+	// index apis with a family of their own
+	"indices.get_mapping":       Mappings,
+	"indices.put_mapping":       Mappings,
+	"indices.get_field_mapping": Mappings,
+	"indices.get_settings":      Settings,
+	"indices.put_settings":      Settings,
+	"indices.get_alias":         Aliases,
+	"indices.put_alias":         Aliases,
+	"indices.delete_alias":      Aliases,
+	"indices.exists_alias":      Aliases,
+	"indices.update_aliases":    Aliases,
+	"indices.get_template":      Templates,
+	"indices.put_template":      Templates,
+	"indices.delete_template":   Templates,
+	"indices.exists_template":   Templates,
+}
+
+// namespaces maps the namespaces of the apis to their families.
+var namespaces = map[string]Family{
+	"cat":      Cat,
+	"cluster":  Cluster,
+	"nodes":    Cluster,
+	"indices":  Indices,
+	"ingest":   Pipelines,
+	"tasks":    Tasks,
+	"snapshot": Snapshots,
+}
+
+// Of classifies the elasticsearch api by the name of its rest api spec, e.g.
+// "indices.put_mapping". The apis that don't belong to any of the families,
+// such as "ping" and "info", are classified as misc.
+func Of(api string) Family {
+	if f, ok := apis[api]; ok {
+		return f
+	}
+	if i := strings.Index(api, "."); i > 0 {
+		if f, ok := namespaces[api[:i]]; ok {
+			return f
+		}
+	}
+	return Misc
+}
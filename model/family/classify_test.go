@@ -0,0 +1,47 @@
+package family
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOf(t *testing.T) {
+	Convey("Apis are classified by their name", t, func() {
+		So(Of("cat.indices"), ShouldEqual, Cat)
+		So(Of("cluster.put_settings"), ShouldEqual, Cluster)
+		So(Of("nodes.stats"), ShouldEqual, Cluster)
+		So(Of("bulk"), ShouldEqual, Docs)
+		So(Of("msearch"), ShouldEqual, Search)
+		So(Of("indices.validate_query"), ShouldEqual, Search)
+		So(Of("indices.put_mapping"), ShouldEqual, Mappings)
+		So(Of("indices.get_settings"), ShouldEqual, Settings)
+		So(Of("indices.update_aliases"), ShouldEqual, Aliases)
+		So(Of("indices.put_template"), ShouldEqual, Templates)
+		So(Of("indices.create"), ShouldEqual, Indices)
+		So(Of("put_script"), ShouldEqual, Scripts)
+		So(Of("ingest.put_pipeline"), ShouldEqual, Pipelines)
+		So(Of("tasks.cancel"), ShouldEqual, Tasks)
+		So(Of("snapshot.restore"), ShouldEqual, Snapshots)
+	})
+
+	Convey("Unknown apis are classified as misc", t, func() {
+		So(Of("ping"), ShouldEqual, Misc)
+		So(Of(""), ShouldEqual, Misc)
+		So(Of("xpack.usage"), ShouldEqual, Misc)
+	})
+}
+
+func TestJSON(t *testing.T) {
+	Convey("Families are encoded by their names", t, func() {
+		raw, err := json.Marshal([]Family{Mappings, Pipelines})
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `["mappings","pipelines"]`)
+
+		var families []Family
+		So(json.Unmarshal(raw, &families), ShouldBeNil)
+		So(families, ShouldResemble, []Family{Mappings, Pipelines})
+		So(json.Unmarshal([]byte(`["mapping"]`), &families), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,121 @@
+package family
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/appbaseio/arc/errors"
+)
+
+type contextKey string
+
+// ctxKey is a key against which a family.Family is stored in the context.
+const ctxKey = contextKey("family")
+
+// Family represents a family of related elasticsearch apis, the permissions
+// can be scoped to a subset of the families.
+type Family int
+
+// Currently supported families.
+const (
+	Cat Family = iota
+	Cluster
+	Docs
+	Search
+	Mappings
+	Settings
+	Aliases
+	Templates
+	Indices
+	Scripts
+	Pipelines
+	Tasks
+	Snapshots
+	Misc
+)
+
+var names = [...]string{
+	"cat",
+	"cluster",
+	"docs",
+	"search",
+	"mappings",
+	"settings",
+	"aliases",
+	"templates",
+	"indices",
+	"scripts",
+	"pipelines",
+	"tasks",
+	"snapshots",
+	"misc",
+}
+
+// String is an implementation of Stringer interface that returns the string representation of family.Family.
+func (f Family) String() string {
+	if f < 0 || int(f) >= len(names) {
+		return fmt.Sprintf("Family(%d)", int(f))
+	}
+	return names[f]
+}
+
+// FromString returns the family with the given name.
+func FromString(name string) (Family, error) {
+	for i, n := range names {
+		if n == name {
+			return Family(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid family encountered: %v", name)
+}
+
+// UnmarshalJSON is an implementation of Unmarshaler interface for unmarshaling family.Family.
+func (f *Family) UnmarshalJSON(bytes []byte) error {
+	var name string
+	if err := json.Unmarshal(bytes, &name); err != nil {
+		return err
+	}
+	family, err := FromString(name)
+	if err != nil {
+		return err
+	}
+	*f = family
+	return nil
+}
+
+// MarshalJSON is the implementation of Marshaler interface for marshaling family.Family.
+func (f Family) MarshalJSON() ([]byte, error) {
+	if f < 0 || int(f) >= len(names) {
+		return nil, fmt.Errorf("invalid family encountered: %v", int(f))
+	}
+	return json.Marshal(f.String())
+}
+
+// Contains checks if the given slice of families contains the given family.
+func Contains(families []Family, family Family) bool {
+	for _, f := range families {
+		if f == family {
+			return true
+		}
+	}
+	return false
+}
+
+// NewContext returns a new context with the given family.
+func NewContext(ctx context.Context, f *Family) context.Context {
+	return context.WithValue(ctx, ctxKey, f)
+}
+
+// FromContext retrieves the family stored against the family.ctxKey from the context.
+func FromContext(ctx context.Context) (*Family, error) {
+	ctxFamily := ctx.Value(ctxKey)
+	if ctxFamily == nil {
+		return nil, errors.NewNotFoundInContextError("*family.Family")
+	}
+	reqFamily, ok := ctxFamily.(*Family)
+	if !ok {
+		return nil, errors.NewInvalidCastError("ctxFamily", "*family.Family")
+	}
+	return reqFamily, nil
+}
@@ -0,0 +1,215 @@
+package index
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDateFormat is the format of the date math expressions that don't
+// specify any.
+const defaultDateFormat = "yyyy.MM.dd"
+
+// dateLayouts translates the supported tokens of the java date formats.
+var dateLayouts = strings.NewReplacer(
+	"yyyy", "2006",
+	"uuuu", "2006",
+	"yy", "06",
+	"MM", "01",
+	"dd", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+)
+
+// IsDateMath checks whether the index name is a date math expression,
+// e.g. <logs-{now/d}>.
+func IsDateMath(name string) bool {
+	return strings.HasPrefix(name, "<") && strings.HasSuffix(name, ">")
+}
+
+// ResolveDateMath resolves the date math expression into the index name as of
+// now, e.g. <logs-{now/d}> resolves to logs-2020.03.01. The expressions take
+// the form <static{now[+-<n><unit>][/<unit>]{format|time_zone}}>, the format
+// and the time zone are optional.
+func ResolveDateMath(name string, now time.Time) (string, error) {
+	if !IsDateMath(name) {
+		return name, nil
+	}
+	expr := name[1 : len(name)-1]
+
+	var resolved strings.Builder
+	for len(expr) > 0 {
+		start := strings.IndexByte(expr, '{')
+		if start < 0 {
+			if strings.ContainsRune(expr, '}') {
+				return "", fmt.Errorf("invalid date math expression %q: unbalanced braces", name)
+			}
+			resolved.WriteString(expr)
+			break
+		}
+		resolved.WriteString(expr[:start])
+		end := closingBrace(expr, start)
+		if end < 0 {
+			return "", fmt.Errorf("invalid date math expression %q: unbalanced braces", name)
+		}
+		value, err := resolveDate(expr[start+1:end], now)
+		if err != nil {
+			return "", fmt.Errorf("invalid date math expression %q: %v", name, err)
+		}
+		resolved.WriteString(value)
+		expr = expr[end+1:]
+	}
+	return resolved.String(), nil
+}
+
+// closingBrace returns the position of the brace closing the one at start.
+func closingBrace(expr string, start int) int {
+	depth := 0
+	for i := start; i < len(expr); i++ {
+		switch expr[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// resolveDate resolves the date math and formats it, e.g. now-1d/d{yyyy.MM|UTC}.
+func resolveDate(expr string, now time.Time) (string, error) {
+	format, zone := defaultDateFormat, ""
+	if i := strings.IndexByte(expr, '{'); i >= 0 {
+		if !strings.HasSuffix(expr, "}") {
+			return "", fmt.Errorf("malformed date format")
+		}
+		format = expr[i+1 : len(expr)-1]
+		expr = expr[:i]
+		if j := strings.IndexByte(format, '|'); j >= 0 {
+			format, zone = format[:j], format[j+1:]
+		}
+		if format == "" {
+			format = defaultDateFormat
+		}
+	}
+
+	loc := time.UTC
+	if zone != "" {
+		var err error
+		if loc, err = location(zone); err != nil {
+			return "", err
+		}
+	}
+
+	if !strings.HasPrefix(expr, "now") {
+		return "", fmt.Errorf("date math must be anchored at now")
+	}
+	t := now.In(loc)
+	for ops := expr[len("now"):]; len(ops) > 0; {
+		op := ops[0]
+		ops = ops[1:]
+		n := 0
+		for n < len(ops) && ops[n] >= '0' && ops[n] <= '9' {
+			n++
+		}
+		if n == len(ops) {
+			return "", fmt.Errorf("missing time unit")
+		}
+		unit := ops[n]
+		switch op {
+		case '+', '-':
+			amount := 1
+			if n > 0 {
+				amount, _ = strconv.Atoi(ops[:n])
+			}
+			if op == '-' {
+				amount = -amount
+			}
+			var err error
+			if t, err = add(t, amount, unit); err != nil {
+				return "", err
+			}
+		case '/':
+			if n > 0 {
+				return "", fmt.Errorf("rounding doesn't take an amount")
+			}
+			var err error
+			if t, err = round(t, unit); err != nil {
+				return "", err
+			}
+		default:
+			return "", fmt.Errorf("unsupported operator %q", op)
+		}
+		ops = ops[n+1:]
+	}
+	return t.Format(dateLayouts.Replace(format)), nil
+}
+
+// location parses the time zone, either an offset such as +12:00 or a zone id.
+func location(zone string) (*time.Location, error) {
+	if zone[0] == '+' || zone[0] == '-' {
+		offset, err := time.Parse("-07:00", zone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time zone %q", zone)
+		}
+		_, seconds := offset.Zone()
+		return time.FixedZone(zone, seconds), nil
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %q", zone)
+	}
+	return loc, nil
+}
+
+func add(t time.Time, amount int, unit byte) (time.Time, error) {
+	switch unit {
+	case 'y':
+		return t.AddDate(amount, 0, 0), nil
+	case 'M':
+		return t.AddDate(0, amount, 0), nil
+	case 'w':
+		return t.AddDate(0, 0, 7*amount), nil
+	case 'd':
+		return t.AddDate(0, 0, amount), nil
+	case 'h', 'H':
+		return t.Add(time.Duration(amount) * time.Hour), nil
+	case 'm':
+		return t.Add(time.Duration(amount) * time.Minute), nil
+	case 's':
+		return t.Add(time.Duration(amount) * time.Second), nil
+	default:
+		return t, fmt.Errorf("unsupported time unit %q", unit)
+	}
+}
+
+func round(t time.Time, unit byte) (time.Time, error) {
+	y, mo, d := t.Date()
+	h, mi, s := t.Clock()
+	loc := t.Location()
+	switch unit {
+	case 'y':
+		return time.Date(y, 1, 1, 0, 0, 0, 0, loc), nil
+	case 'M':
+		return time.Date(y, mo, 1, 0, 0, 0, 0, loc), nil
+	case 'w':
+		// weeks start on monday
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, mo, d-offset, 0, 0, 0, 0, loc), nil
+	case 'd':
+		return time.Date(y, mo, d, 0, 0, 0, 0, loc), nil
+	case 'h', 'H':
+		return time.Date(y, mo, d, h, 0, 0, 0, loc), nil
+	case 'm':
+		return time.Date(y, mo, d, h, mi, 0, 0, loc), nil
+	case 's':
+		return time.Date(y, mo, d, h, mi, s, 0, loc), nil
+	default:
+		return t, fmt.Errorf("unsupported time unit %q", unit)
+	}
+}
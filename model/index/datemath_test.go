@@ -0,0 +1,44 @@
+package index
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResolveDateMath(t *testing.T) {
+	now := time.Date(2020, 3, 1, 22, 30, 15, 0, time.UTC)
+
+	Convey("The date math expressions resolve as of now", t, func() {
+		for expr, expected := range map[string]string{
+			"<logs-{now/d}>":                    "logs-2020.03.01",
+			"<logs-{now/M{yyyy.MM}}>":           "logs-2020.03",
+			"<logs-{now-1d/d}>":                 "logs-2020.02.29",
+			"<logs-{now+2M/M{yyyy.MM}}>":        "logs-2020.05",
+			"<logs-{now/d{yyyy.MM.dd|+12:00}}>": "logs-2020.03.02",
+			"<logs-{now/w{yyyy.MM.dd}}>":        "logs-2020.02.24",
+			"<logs-{now/h{yyyy.MM.dd.HH}}-app>": "logs-2020.03.01.22-app",
+			"<{now/y{yyyy}}-{now/M{MM}}>":       "2020-03",
+			"logs-2020.03.01":                   "logs-2020.03.01",
+		} {
+			resolved, err := ResolveDateMath(expr, now)
+			So(err, ShouldBeNil)
+			So(resolved, ShouldEqual, expected)
+		}
+	})
+
+	Convey("The malformed expressions are rejected", t, func() {
+		for _, expr := range []string{
+			"<logs-{now/d>",
+			"<logs-now/d}>",
+			"<logs-{2020/d}>",
+			"<logs-{now/x}>",
+			"<logs-{now-1}>",
+			"<logs-{now/d{yyyy|Mars/Olympus}}>",
+		} {
+			_, err := ResolveDateMath(expr, now)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
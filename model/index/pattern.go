@@ -0,0 +1,45 @@
+package index
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Overlaps checks whether the index, or any of the indices matching the
+// index pattern, matches one of the patterns. The wildcards of the index are
+// only expanded against the patterns themselves, hence an index pattern that
+// could match an index of the patterns counts as a match.
+func Overlaps(patterns []string, name string) bool {
+	if name == "_all" {
+		name = "*"
+	}
+	for _, pattern := range patterns {
+		if glob(pattern).MatchString(name) {
+			return true
+		}
+		if strings.Contains(name, "*") && glob(name).MatchString(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match checks whether the index, or index pattern, matches one of the
+// patterns. Unlike Overlaps, an index pattern only matches the patterns that
+// match every index it could expand to.
+func Match(patterns []string, name string) bool {
+	if name == "_all" {
+		name = "*"
+	}
+	for _, pattern := range patterns {
+		if glob(pattern).MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// glob compiles the index pattern into an anchored regexp.
+func glob(pattern string) *regexp.Regexp {
+	return regexp.MustCompile("^" + strings.Replace(regexp.QuoteMeta(pattern), `\*`, ".*", -1) + "$")
+}
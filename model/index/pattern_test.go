@@ -0,0 +1,43 @@
+package index
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOverlaps(t *testing.T) {
+	denied := []string{"logs-secure", "audit-*"}
+
+	Convey("The indices matching the patterns overlap", t, func() {
+		So(Overlaps(denied, "logs-secure"), ShouldBeTrue)
+		So(Overlaps(denied, "audit-2020"), ShouldBeTrue)
+		So(Overlaps(denied, "logs-app"), ShouldBeFalse)
+		So(Overlaps(denied, "catalogs-secure"), ShouldBeFalse)
+	})
+
+	Convey("The index patterns that could match the patterns overlap", t, func() {
+		So(Overlaps(denied, "logs-*"), ShouldBeTrue)
+		So(Overlaps(denied, "*"), ShouldBeTrue)
+		So(Overlaps(denied, "_all"), ShouldBeTrue)
+		So(Overlaps(denied, "logs-a*"), ShouldBeFalse)
+	})
+}
+
+func TestMatch(t *testing.T) {
+	patterns := []string{"products*", "logs-app"}
+
+	Convey("The patterns are matched against the whole name", t, func() {
+		So(Match(patterns, "products_v2"), ShouldBeTrue)
+		So(Match(patterns, "logs-app"), ShouldBeTrue)
+		So(Match(patterns, "oldproducts"), ShouldBeFalse)
+		So(Match(patterns, "logs-app-2020"), ShouldBeFalse)
+	})
+
+	Convey("Index patterns match the patterns covering them", t, func() {
+		So(Match(patterns, "products_*"), ShouldBeTrue)
+		So(Match(patterns, "logs-*"), ShouldBeFalse)
+		So(Match(patterns, "_all"), ShouldBeFalse)
+		So(Match([]string{"*"}, "_all"), ShouldBeTrue)
+	})
+}
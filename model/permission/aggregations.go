@@ -0,0 +1,121 @@
+package permission
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// AggregationPolicy is the allowlist of the aggregations of the searches.
+// The aggregations can leak the values of the documents and of the fields
+// the searches can't return, hence only the listed types of aggregations
+// are allowed, on the fields matching the listed patterns. An empty list
+// allows none.
+type AggregationPolicy struct {
+	Types  []string `json:"types"`
+	Fields []string `json:"fields"`
+}
+
+// aggregationFieldKeys are the keys of the aggregation params naming fields.
+var aggregationFieldKeys = map[string]bool{
+	"field":           true,
+	"fields":          true,
+	"docvalue_fields": true,
+}
+
+// InspectAggregations checks the aggregations of a search against the
+// aggregation policy of the permission, the fields must also be accessible
+// as per its include and exclude fields. The scripts of the aggregations are
+// rejected since they can read any field. It returns the violations along
+// with their location, prefixed by the location of the aggregations.
+func (p *Permission) InspectAggregations(aggs map[string]interface{}, location string) []util.ErrorDetail {
+	if p.Aggregations == nil {
+		return nil
+	}
+	i := &aggInspector{permission: p}
+	i.aggregations(aggs, location)
+	return i.violations
+}
+
+type aggInspector struct {
+	permission *Permission
+	violations []util.ErrorDetail
+}
+
+func (i *aggInspector) violate(reason, location string) {
+	i.violations = append(i.violations, util.ErrorDetail{
+		Type:     "aggregation_forbidden",
+		Reason:   reason,
+		Location: location,
+	})
+}
+
+func (i *aggInspector) aggregations(aggs map[string]interface{}, location string) {
+	policy := i.permission.Aggregations
+	for name, value := range aggs {
+		agg, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for kind, params := range agg {
+			path := location + "." + name + "." + kind
+			switch kind {
+			case "aggs", "aggregations":
+				if sub, ok := params.(map[string]interface{}); ok {
+					i.aggregations(sub, path)
+				}
+			case "meta":
+			default:
+				if !contains(policy.Types, kind) {
+					i.violate(fmt.Sprintf("aggregations of type %s aren't allowed", kind), path)
+					continue
+				}
+				i.params(kind, params, path)
+			}
+		}
+	}
+}
+
+// params checks the fields and the scripts the params of an aggregation
+// refer to, at any depth as the sources of the composite aggregations are.
+func (i *aggInspector) params(key string, value interface{}, location string) {
+	if key == "script" || key == "script_fields" || strings.HasSuffix(key, "_script") {
+		i.violate("scripts aren't allowed in aggregations", location)
+		return
+	}
+	switch v := value.(type) {
+	case string:
+		if aggregationFieldKeys[key] {
+			i.field(v, location)
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			i.params(k, item, location+"."+k)
+		}
+	case []interface{}:
+		for n, item := range v {
+			path := fmt.Sprintf("%s[%d]", location, n)
+			if field, ok := item.(string); ok && aggregationFieldKeys[key] {
+				i.field(field, path)
+				continue
+			}
+			i.params("", item, path)
+		}
+	}
+}
+
+func (i *aggInspector) field(field, location string) {
+	if !MatchesField(i.permission.Aggregations.Fields, field) || !i.permission.CanAccessField(field) {
+		i.violate(fmt.Sprintf("aggregations on field %s aren't allowed", field), location)
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -28,6 +28,8 @@ var (
 		category.Suggestions,
 		category.Auth,
 		category.Functions,
+		category.Logs,
+		category.Reindex,
 	}
 
 	defaultOps = []op.Operation{
@@ -57,6 +59,8 @@ var (
 		StreamsLimit:     10,
 		AuthLimit:        10,
 		FunctionsLimit:   10,
+		LogsLimit:        10,
+		ReindexLimit:     10,
 	}
 
 	defaultAdminLimits = Limits{
@@ -76,5 +80,7 @@ var (
 		StreamsLimit:     30,
 		AuthLimit:        30,
 		FunctionsLimit:   30,
+		LogsLimit:        30,
+		ReindexLimit:     30,
 	}
 )
@@ -4,16 +4,18 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"path"
 	"regexp"
 	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-
 	"github.com/appbaseio/arc/errors"
 	"github.com/appbaseio/arc/model/acl"
 	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/family"
+	"github.com/appbaseio/arc/model/index"
 	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/model/subcategory"
 	"github.com/appbaseio/arc/util"
 	"github.com/google/uuid"
 )
@@ -39,6 +41,7 @@ type Permission struct {
 	Role        string              `json:"role"`
 	Categories  []category.Category `json:"categories"`
 	ACLs        []acl.ACL           `json:"acls"`
+	Families    []family.Family     `json:"families,omitempty"`
 	Ops         []op.Operation      `json:"ops"`
 	Indices     []string            `json:"indices"`
 	Sources     []string            `json:"sources"`
@@ -50,6 +53,62 @@ type Permission struct {
 	Includes    []string            `json:"include_fields"`
 	Excludes    []string            `json:"exclude_fields"`
 	Expired     bool                `json:"expired"`
+	Tenant      string              `json:"tenant,omitempty"`
+	// SearchTimeout bounds the searches made with the permission, e.g. "5s".
+	SearchTimeout string `json:"search_timeout,omitempty"`
+	// DeniedIndices and DeniedCategories take precedence over the indices
+	// and categories the permission has access to.
+	DeniedIndices    []string            `json:"denied_indices,omitempty"`
+	DeniedCategories []category.Category `json:"denied_categories,omitempty"`
+	// Guardrails restricts the queries of the searches made with the permission.
+	Guardrails *Guardrails `json:"guardrails,omitempty"`
+	// Scripts controls the inline scripts of the requests made with the permission.
+	Scripts *ScriptPolicy `json:"scripts,omitempty"`
+	// Aggregations restricts the aggregations of the searches made with the permission.
+	Aggregations *AggregationPolicy `json:"aggregations,omitempty"`
+	// Subcategories scope the searches of the permission, e.g. to the
+	// suggestions.
+	Subcategories []subcategory.Subcategory `json:"subcategories,omitempty"`
+}
+
+// Script policy modes.
+const (
+	// ScriptsBlock rejects the inline scripts.
+	ScriptsBlock = "block"
+	// ScriptsAllowlist only allows the inline scripts listed by the policy.
+	ScriptsAllowlist = "allowlist"
+	// ScriptsAudit allows the inline scripts.
+	ScriptsAudit = "audit"
+)
+
+// ScriptPolicy controls the inline scripts of the requests, the scripts
+// allowed by the allowlist and the audit modes are recorded in the audit
+// trail. The stored scripts are always allowed.
+type ScriptPolicy struct {
+	Mode string `json:"mode"`
+	// Allowed are either the sources of the allowed scripts, compared
+	// regardless of whitespace, or their "sha256:<hex>" digests.
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+// Guardrails defines the restrictions on the queries of the searches, the
+// zero values don't restrict anything.
+type Guardrails struct {
+	// Rewrite clamps the size of the searches exceeding the size or the
+	// result window instead of rejecting them.
+	Rewrite bool `json:"rewrite,omitempty"`
+	// MaxSize bounds the number of hits of a search.
+	MaxSize int `json:"max_size,omitempty"`
+	// MaxResultWindow bounds the from + size of a search.
+	MaxResultWindow int `json:"max_result_window,omitempty"`
+	// MaxBuckets bounds the estimated number of buckets of the aggregations.
+	MaxBuckets int `json:"max_buckets,omitempty"`
+	// DenyLeadingWildcard rejects the wildcard terms starting with * or ?.
+	DenyLeadingWildcard bool `json:"deny_leading_wildcard,omitempty"`
+	// DenyRegexp rejects the regexp queries.
+	DenyRegexp bool `json:"deny_regexp,omitempty"`
+	// DenyScripts rejects the searches making use of scripts.
+	DenyScripts bool `json:"deny_scripts,omitempty"`
 }
 
 // Limits defines the rate limits for each category.
@@ -70,6 +129,8 @@ type Limits struct {
 	StreamsLimit     int64 `json:"streams_limit"`
 	AuthLimit        int64 `json:"auth_limit"`
 	FunctionsLimit   int64 `json:"functions_limit"`
+	LogsLimit        int64 `json:"logs_limit"`
+	ReindexLimit     int64 `json:"reindex_limit"`
 }
 
 // Options is a function type used to define a permission's properties.
@@ -122,6 +183,24 @@ func SetACLs(acls []acl.ACL) Options {
 	}
 }
 
+// SetSubcategories scopes the searches of the permission to the given
+// sub-categories, a permission without sub-categories can make all of them.
+func SetSubcategories(subcategories []subcategory.Subcategory) Options {
+	return func(p *Permission) error {
+		p.Subcategories = subcategories
+		return nil
+	}
+}
+
+// SetFamilies scopes the permission to the given families of elasticsearch
+// apis, a permission without families can access all of them.
+func SetFamilies(families []family.Family) Options {
+	return func(p *Permission) error {
+		p.Families = families
+		return nil
+	}
+}
+
 // SetOps sets the operations a permission can perform.
 func SetOps(ops []op.Operation) Options {
 	return func(p *Permission) error {
@@ -165,6 +244,85 @@ func SetSources(sources []string) Options {
 	}
 }
 
+// tenantPattern restricts the tenant names to the characters valid in an index name.
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// SetTenant binds the permission to the tenant, confining its requests to the
+// indices prefixed with the tenant name.
+func SetTenant(tenant string) Options {
+	return func(p *Permission) error {
+		if err := validateTenant(tenant); err != nil {
+			return err
+		}
+		p.Tenant = tenant
+		return nil
+	}
+}
+
+func validateTenant(tenant string) error {
+	if !tenantPattern.MatchString(tenant) {
+		return fmt.Errorf(`invalid tenant "%s", must consist of lowercase alphanumerics and hyphens`, tenant)
+	}
+	return nil
+}
+
+// SetSearchTimeout sets the timeout of the searches made with the permission.
+func SetSearchTimeout(timeout string) Options {
+	return func(p *Permission) error {
+		if err := validateSearchTimeout(timeout); err != nil {
+			return err
+		}
+		p.SearchTimeout = timeout
+		return nil
+	}
+}
+
+func validateSearchTimeout(timeout string) error {
+	d, err := time.ParseDuration(timeout)
+	if err != nil || d <= 0 {
+		return fmt.Errorf(`invalid search_timeout "%s", must be a positive duration such as "5s"`, timeout)
+	}
+	return nil
+}
+
+// GetSearchTimeout returns the timeout of the searches made with the
+// permission, zero if unset.
+func (p *Permission) GetSearchTimeout() time.Duration {
+	d, _ := time.ParseDuration(p.SearchTimeout)
+	return d
+}
+
+// SetDeniedIndices sets the indices or index patterns the permission is
+// denied access to, regardless of its indices.
+func SetDeniedIndices(indices []string) Options {
+	return func(p *Permission) error {
+		if err := validateIndexPatterns(indices); err != nil {
+			return err
+		}
+		p.DeniedIndices = indices
+		return nil
+	}
+}
+
+// SetDeniedCategories sets the categories the permission is denied access
+// to, along with their acls, regardless of its categories.
+func SetDeniedCategories(categories []category.Category) Options {
+	return func(p *Permission) error {
+		p.DeniedCategories = categories
+		return nil
+	}
+}
+
+func validateIndexPatterns(indices []string) error {
+	for _, pattern := range indices {
+		pattern = strings.Replace(pattern, "*", ".*", -1)
+		if _, err := regexp.Compile(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SetIncludes sets the includes fields
 func SetIncludes(includes []string) Options {
 	return func(p *Permission) error {
@@ -223,6 +381,72 @@ func SetLimits(limits *Limits) Options {
 	}
 }
 
+// SetGuardrails sets the restrictions on the queries of the searches made
+// with the permission.
+func SetGuardrails(guardrails *Guardrails) Options {
+	return func(p *Permission) error {
+		if err := validateGuardrails(guardrails); err != nil {
+			return err
+		}
+		p.Guardrails = guardrails
+		return nil
+	}
+}
+
+func validateGuardrails(g *Guardrails) error {
+	if g.MaxSize < 0 || g.MaxResultWindow < 0 || g.MaxBuckets < 0 {
+		return fmt.Errorf("invalid guardrails, the limits can't be negative")
+	}
+	return nil
+}
+
+// SetScripts sets the policy on the inline scripts of the requests made with
+// the permission.
+func SetScripts(policy *ScriptPolicy) Options {
+	return func(p *Permission) error {
+		if err := validateScripts(policy); err != nil {
+			return err
+		}
+		p.Scripts = policy
+		return nil
+	}
+}
+
+func validateScripts(policy *ScriptPolicy) error {
+	switch policy.Mode {
+	case ScriptsBlock, ScriptsAllowlist, ScriptsAudit:
+		return nil
+	default:
+		return fmt.Errorf(`invalid scripts mode "%s", must be one of "%s", "%s" or "%s"`, policy.Mode, ScriptsBlock, ScriptsAllowlist, ScriptsAudit)
+	}
+}
+
+// SetAggregations sets the aggregation types and fields the searches made
+// with the permission can request.
+func SetAggregations(policy *AggregationPolicy) Options {
+	return func(p *Permission) error {
+		if err := validateAggregations(policy); err != nil {
+			return err
+		}
+		p.Aggregations = policy
+		return nil
+	}
+}
+
+func validateAggregations(policy *AggregationPolicy) error {
+	for _, t := range policy.Types {
+		if strings.TrimSpace(t) == "" {
+			return fmt.Errorf("invalid aggregations, the types can't be empty strings")
+		}
+	}
+	for _, f := range policy.Fields {
+		if strings.TrimSpace(f) == "" {
+			return fmt.Errorf("invalid aggregations, the fields can't be empty strings")
+		}
+	}
+	return nil
+}
+
 // SetDescription sets the permission description.
 func SetDescription(description string) Options {
 	return func(p *Permission) error {
@@ -347,8 +571,11 @@ func (p *Permission) IsExpired() (bool, error) {
 
 // HasCategory checks whether the permission has access to the given category.
 func (p *Permission) HasCategory(category category.Category) bool {
+	if p.DeniesCategory(category) {
+		return false
+	}
 	for _, c := range p.Categories {
-		if c == category {
+		if c.Covers(category) {
 			return true
 		}
 	}
@@ -367,23 +594,54 @@ func (p *Permission) hasCategoryForACL(acl acl.ACL) bool {
 // ValidateACLs checks if the permission can possess the given set of categories.
 func (p *Permission) ValidateACLs(acls ...acl.ACL) error {
 	for _, a := range acls {
-		if !p.hasCategoryForACL(a) {
+		if a != acl.All && !p.hasCategoryForACL(a) {
 			return fmt.Errorf(`permission doesn't have category to access "%s" acl`, a)
 		}
 	}
 	return nil
 }
 
-// HasACL checks whether the permission has access to the given acl.
-func (p *Permission) HasACL(acl acl.ACL) bool {
-	for _, a := range p.ACLs {
-		if a == acl {
+// HasACL checks whether the permission has access to the given acl, the
+// wildcard acl granting the acls of the permission categories.
+func (p *Permission) HasACL(a acl.ACL) bool {
+	if p.DeniesACL(a) {
+		return false
+	}
+	return acl.Contains(p.ACLs, a) || acl.Contains(p.ACLs, acl.All) && p.hasCategoryForACL(a)
+}
+
+// DeniesCategory checks whether the permission is explicitly denied the given category.
+func (p *Permission) DeniesCategory(category category.Category) bool {
+	for _, c := range p.DeniedCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// DeniesACL checks whether the permission is denied a category of the given acl.
+func (p *Permission) DeniesACL(a acl.ACL) bool {
+	for _, c := range p.DeniedCategories {
+		if c.HasACL(a) {
 			return true
 		}
 	}
 	return false
 }
 
+// HasSubcategory checks whether the permission has access to the given
+// sub-category of the searches.
+func (p *Permission) HasSubcategory(s subcategory.Subcategory) bool {
+	return len(p.Subcategories) == 0 || subcategory.Contains(p.Subcategories, s)
+}
+
+// HasFamily checks whether the permission has access to the given family of
+// elasticsearch apis.
+func (p *Permission) HasFamily(f family.Family) bool {
+	return len(p.Families) == 0 || family.Contains(p.Families, f)
+}
+
 // CanDo checks whether the permission can perform a given operation.
 func (p *Permission) CanDo(op op.Operation) bool {
 	for _, o := range p.Ops {
@@ -395,7 +653,12 @@ func (p *Permission) CanDo(op op.Operation) bool {
 }
 
 // CanAccessCluster checks whether the user can access cluster level routes.
+// The cluster level routes span all the indices, hence the permissions
+// denied access to any index can't access them.
 func (p *Permission) CanAccessCluster() (bool, error) {
+	if len(p.DeniedIndices) > 0 {
+		return false, nil
+	}
 	for _, pattern := range p.Indices {
 		pattern = strings.Replace(pattern, "*", ".*", -1)
 		matched, err := regexp.MatchString(pattern, "*")
@@ -411,18 +674,16 @@ func (p *Permission) CanAccessCluster() (bool, error) {
 
 // CanAccessIndex checks whether the permission has access to given index or index pattern.
 func (p *Permission) CanAccessIndex(name string) (bool, error) {
-	for _, pattern := range p.Indices {
-		pattern = strings.Replace(pattern, "*", ".*", -1)
-		matched, err := regexp.MatchString(pattern, name)
-		if err != nil {
-			log.Errorln("invalid index regexp", pattern, "encontered: ", err)
-			return false, err
-		}
-		if matched {
-			return matched, nil
-		}
+	if index.Overlaps(p.DeniedIndices, name) {
+		return false, nil
 	}
-	return false, nil
+	return index.Match(p.Indices, name), nil
+}
+
+// DeniesIndex checks whether the permission is denied access to the index or
+// index pattern.
+func (p *Permission) DeniesIndex(name string) bool {
+	return index.Overlaps(p.DeniedIndices, name)
 }
 
 // CanAccessIndices checks whether the user has access to the given indices.
@@ -435,6 +696,34 @@ func (p *Permission) CanAccessIndices(indices ...string) (bool, error) {
 	return true, nil
 }
 
+// CanAccessField checks whether the field can be accessed as per the include
+// and exclude fields of the permission.
+func (p *Permission) CanAccessField(field string) bool {
+	if len(p.Includes) > 0 && !MatchesField(p.Includes, field) {
+		return false
+	}
+	return !MatchesField(p.Excludes, field)
+}
+
+// MatchesField checks whether any of the field patterns matches the field, a
+// pattern matching an object field matches its sub fields as the source
+// filters of elasticsearch do.
+func MatchesField(patterns []string, field string) bool {
+	for _, pattern := range patterns {
+		for prefix := field; ; {
+			if ok, _ := path.Match(pattern, prefix); ok {
+				return true
+			}
+			i := strings.LastIndex(prefix, ".")
+			if i < 0 {
+				break
+			}
+			prefix = prefix[:i]
+		}
+	}
+	return false
+}
+
 // GetLimitFor returns the rate limit for the given category in the permission.
 func (p *Permission) GetLimitFor(c category.Category) (int64, error) {
 	switch c {
@@ -468,6 +757,10 @@ func (p *Permission) GetLimitFor(c category.Category) (int64, error) {
 		return p.Limits.StreamsLimit, nil
 	case category.Functions:
 		return p.Limits.FunctionsLimit, nil
+	case category.Logs:
+		return p.Limits.LogsLimit, nil
+	case category.Reindex:
+		return p.Limits.ReindexLimit, nil
 	default:
 		return -1, fmt.Errorf(`we do not rate limit "%s" category`, c)
 	}
@@ -583,6 +876,12 @@ func (p *Permission) GetPatch(rolePatched bool) (map[string]interface{}, error)
 		if p.Limits.FunctionsLimit != 0 {
 			limits["functions_limit"] = p.Limits.FunctionsLimit
 		}
+		if p.Limits.LogsLimit != 0 {
+			limits["logs_limit"] = p.Limits.LogsLimit
+		}
+		if p.Limits.ReindexLimit != 0 {
+			limits["reindex_limit"] = p.Limits.ReindexLimit
+		}
 
 		patch["limits"] = limits
 	}
@@ -595,6 +894,51 @@ func (p *Permission) GetPatch(rolePatched bool) (map[string]interface{}, error)
 	if p.Excludes != nil {
 		patch["exclude_fields"] = p.Excludes
 	}
+	if p.Tenant != "" {
+		if err := validateTenant(p.Tenant); err != nil {
+			return nil, err
+		}
+		patch["tenant"] = p.Tenant
+	}
+	if p.SearchTimeout != "" {
+		if err := validateSearchTimeout(p.SearchTimeout); err != nil {
+			return nil, err
+		}
+		patch["search_timeout"] = p.SearchTimeout
+	}
+	if p.Families != nil {
+		patch["families"] = p.Families
+	}
+	if p.Subcategories != nil {
+		patch["subcategories"] = p.Subcategories
+	}
+	if p.Guardrails != nil {
+		if err := validateGuardrails(p.Guardrails); err != nil {
+			return nil, err
+		}
+		patch["guardrails"] = p.Guardrails
+	}
+	if p.Scripts != nil {
+		if err := validateScripts(p.Scripts); err != nil {
+			return nil, err
+		}
+		patch["scripts"] = p.Scripts
+	}
+	if p.Aggregations != nil {
+		if err := validateAggregations(p.Aggregations); err != nil {
+			return nil, err
+		}
+		patch["aggregations"] = p.Aggregations
+	}
+	if p.DeniedIndices != nil {
+		if err := validateIndexPatterns(p.DeniedIndices); err != nil {
+			return nil, err
+		}
+		patch["denied_indices"] = p.DeniedIndices
+	}
+	if p.DeniedCategories != nil {
+		patch["denied_categories"] = p.DeniedCategories
+	}
 
 	return patch, nil
 }
@@ -0,0 +1,87 @@
+// Package reqcontext gathers the values the middleware store in the request
+// context, each through the typed helpers of its package, so that the
+// handlers don't fetch and check them one by one.
+package reqcontext
+
+import (
+	"context"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+)
+
+// RequestContext carries the classification of a request and, once it is
+// authenticated, its credential.
+type RequestContext struct {
+	// Category and Op are set for every classified request.
+	Category *category.Category
+	Op       *op.Operation
+	// ACL is only set for the requests classified into an acl, e.g. those
+	// made to elasticsearch.
+	ACL *acl.ACL
+	// Indices is nil unless the indices of the request were classified.
+	Indices []string
+	// Credential is -1 unless the request is authenticated, in which case
+	// either User or Permission is set accordingly.
+	Credential credential.Credential
+	User       *user.User
+	Permission *permission.Permission
+}
+
+// FromContext gathers the values stored in the context. It returns an error
+// if the request wasn't classified, i.e. its category or op isn't set, or if
+// the credential of an authenticated request isn't stored along with it.
+func FromContext(ctx context.Context) (*RequestContext, error) {
+	var err error
+	r := &RequestContext{}
+	if r.Category, err = category.FromContext(ctx); err != nil {
+		return nil, err
+	}
+	if r.Op, err = op.FromContext(ctx); err != nil {
+		return nil, err
+	}
+	if reqACL, err := acl.FromContext(ctx); err == nil {
+		r.ACL = reqACL
+	}
+	if indices, err := index.FromContext(ctx); err == nil {
+		r.Indices = indices
+	}
+
+	r.Credential, err = credential.FromContext(ctx)
+	if err != nil {
+		r.Credential = -1
+		return r, nil
+	}
+	switch r.Credential {
+	case credential.User:
+		r.User, err = user.FromContext(ctx)
+	case credential.Permission:
+		r.Permission, err = permission.FromContext(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Authenticated checks whether the request is authenticated.
+func (r *RequestContext) Authenticated() bool {
+	return r.User != nil || r.Permission != nil
+}
+
+// Username returns the username of the credential of the request, empty if
+// it isn't authenticated.
+func (r *RequestContext) Username() string {
+	switch {
+	case r.User != nil:
+		return r.User.Username
+	case r.Permission != nil:
+		return r.Permission.Username
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package reqcontext
+
+import (
+	"context"
+	"testing"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/model/permission"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFromContext(t *testing.T) {
+	reqCategory, reqOp, reqACL := category.Search, op.Read, acl.Search
+	classified := category.NewContext(context.Background(), &reqCategory)
+	classified = op.NewContext(classified, &reqOp)
+
+	Convey("The requests that weren't classified are rejected", t, func() {
+		_, err := FromContext(context.Background())
+		So(err, ShouldNotBeNil)
+		_, err = FromContext(category.NewContext(context.Background(), &reqCategory))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("The values that aren't set are left empty", t, func() {
+		r, err := FromContext(classified)
+		So(err, ShouldBeNil)
+		So(*r.Category, ShouldEqual, category.Search)
+		So(*r.Op, ShouldEqual, op.Read)
+		So(r.ACL, ShouldBeNil)
+		So(r.Indices, ShouldBeNil)
+		So(r.Credential, ShouldEqual, -1)
+		So(r.Authenticated(), ShouldBeFalse)
+		So(r.Username(), ShouldBeEmpty)
+	})
+
+	Convey("The values of an authenticated request are gathered", t, func() {
+		ctx := acl.NewContext(classified, &reqACL)
+		ctx = index.NewContext(ctx, []string{"products"})
+		ctx = credential.NewContext(ctx, credential.Permission)
+
+		_, err := FromContext(ctx)
+		So(err, ShouldNotBeNil)
+
+		ctx = permission.NewContext(ctx, &permission.Permission{Username: "foo"})
+		r, err := FromContext(ctx)
+		So(err, ShouldBeNil)
+		So(*r.ACL, ShouldEqual, acl.Search)
+		So(r.Indices, ShouldResemble, []string{"products"})
+		So(r.Credential, ShouldEqual, credential.Permission)
+		So(r.User, ShouldBeNil)
+		So(r.Authenticated(), ShouldBeTrue)
+		So(r.Username(), ShouldEqual, "foo")
+	})
+}
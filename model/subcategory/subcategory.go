@@ -0,0 +1,104 @@
+package subcategory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/appbaseio/arc/errors"
+)
+
+type contextKey string
+
+// ctxKey is a key against which a subcategory.Subcategory is stored in the context.
+const ctxKey = contextKey("subcategory")
+
+// Subcategory distinguishes the kinds of requests of the search category, so
+// that e.g. the suggestions can be treated differently from the full searches.
+type Subcategory int
+
+// Currently supported sub-categories of the search category.
+const (
+	Search Subcategory = iota
+	Suggest
+	Count
+	Explain
+	FieldCaps
+)
+
+var names = [...]string{
+	"search",
+	"suggest",
+	"count",
+	"explain",
+	"field_caps",
+}
+
+// String is an implementation of Stringer interface that returns the string representation of subcategory.Subcategory.
+func (s Subcategory) String() string {
+	if s < 0 || int(s) >= len(names) {
+		return fmt.Sprintf("Subcategory(%d)", int(s))
+	}
+	return names[s]
+}
+
+// FromString returns the sub-category with the given name.
+func FromString(name string) (Subcategory, error) {
+	for i, n := range names {
+		if n == name {
+			return Subcategory(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid subcategory encountered: %v", name)
+}
+
+// UnmarshalJSON is an implementation of Unmarshaler interface for unmarshaling subcategory.Subcategory.
+func (s *Subcategory) UnmarshalJSON(bytes []byte) error {
+	var name string
+	if err := json.Unmarshal(bytes, &name); err != nil {
+		return err
+	}
+	subcategory, err := FromString(name)
+	if err != nil {
+		return err
+	}
+	*s = subcategory
+	return nil
+}
+
+// MarshalJSON is the implementation of Marshaler interface for marshaling subcategory.Subcategory.
+func (s Subcategory) MarshalJSON() ([]byte, error) {
+	if s < 0 || int(s) >= len(names) {
+		return nil, fmt.Errorf("invalid subcategory encountered: %v", int(s))
+	}
+	return json.Marshal(s.String())
+}
+
+// Contains checks if the given slice of sub-categories contains the given sub-category.
+func Contains(subcategories []Subcategory, subcategory Subcategory) bool {
+	for _, s := range subcategories {
+		if s == subcategory {
+			return true
+		}
+	}
+	return false
+}
+
+// NewContext returns a new context with the given sub-category.
+func NewContext(ctx context.Context, s *Subcategory) context.Context {
+	return context.WithValue(ctx, ctxKey, s)
+}
+
+// FromContext retrieves the sub-category stored against the subcategory.ctxKey
+// from the context, only the requests of the search category have one.
+func FromContext(ctx context.Context) (*Subcategory, error) {
+	ctxSubcategory := ctx.Value(ctxKey)
+	if ctxSubcategory == nil {
+		return nil, errors.NewNotFoundInContextError("*subcategory.Subcategory")
+	}
+	reqSubcategory, ok := ctxSubcategory.(*Subcategory)
+	if !ok {
+		return nil, errors.NewInvalidCastError("ctxSubcategory", "*subcategory.Subcategory")
+	}
+	return reqSubcategory, nil
+}
@@ -0,0 +1,22 @@
+package subcategory
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestJSON(t *testing.T) {
+	Convey("Subcategories are encoded by their names", t, func() {
+		raw, err := json.Marshal([]Subcategory{Suggest, FieldCaps})
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `["suggest","field_caps"]`)
+
+		var subcategories []Subcategory
+		So(json.Unmarshal(raw, &subcategories), ShouldBeNil)
+		So(subcategories, ShouldResemble, []Subcategory{Suggest, FieldCaps})
+
+		So(json.Unmarshal([]byte(`["autocomplete"]`), &subcategories), ShouldNotBeNil)
+	})
+}
@@ -28,6 +28,8 @@ var (
 		category.Suggestions,
 		category.Functions,
 		category.Auth,
+		category.Logs,
+		category.Reindex,
 	}
 
 	defaultOps = []op.Operation{
@@ -10,6 +10,7 @@ import (
 	"github.com/appbaseio/arc/errors"
 	"github.com/appbaseio/arc/model/acl"
 	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
 	"github.com/appbaseio/arc/model/op"
 )
 
@@ -37,6 +38,25 @@ type User struct {
 	Ops              []op.Operation      `json:"ops"`
 	Indices          []string            `json:"indices"`
 	CreatedAt        string              `json:"created_at"`
+	// DeniedIndices and DeniedCategories take precedence over the indices
+	// and categories the user has access to.
+	DeniedIndices    []string            `json:"denied_indices,omitempty"`
+	DeniedCategories []category.Category `json:"denied_categories,omitempty"`
+	// Disabled users can't authenticate, the deleted users are disabled
+	// until their deletion is purged after the retention window.
+	Disabled  bool   `json:"disabled,omitempty"`
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
+
+// IsDeleted checks whether the user is soft deleted.
+func (u *User) IsDeleted() bool {
+	return u.DeletedAt != ""
+}
+
+// Admin checks whether the user is an admin, the users stored without the
+// is_admin flag aren't.
+func (u *User) Admin() bool {
+	return u.IsAdmin != nil && *u.IsAdmin
 }
 
 // Options is a function type used to define a user's properties.
@@ -102,17 +122,45 @@ func SetIndices(indices []string) Options {
 		if indices == nil {
 			return errors.ErrNilIndices
 		}
-		for _, pattern := range indices {
-			pattern = strings.Replace(pattern, "*", ".*", -1)
-			if _, err := regexp.Compile(pattern); err != nil {
-				return err
-			}
+		if err := validateIndexPatterns(indices); err != nil {
+			return err
 		}
 		u.Indices = indices
 		return nil
 	}
 }
 
+// SetDeniedIndices sets the indices or index patterns the user is denied
+// access to, regardless of its indices.
+func SetDeniedIndices(indices []string) Options {
+	return func(u *User) error {
+		if err := validateIndexPatterns(indices); err != nil {
+			return err
+		}
+		u.DeniedIndices = indices
+		return nil
+	}
+}
+
+// SetDeniedCategories sets the categories the user is denied access to,
+// along with their acls, regardless of its categories.
+func SetDeniedCategories(categories []category.Category) Options {
+	return func(u *User) error {
+		u.DeniedCategories = categories
+		return nil
+	}
+}
+
+func validateIndexPatterns(indices []string) error {
+	for _, pattern := range indices {
+		pattern = strings.Replace(pattern, "*", ".*", -1)
+		if _, err := regexp.Compile(pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // New creates a new user by running the Options on it. It returns a default user
 // in case no Options are provided.
 func New(username, password string, opts ...Options) (*User, error) {
@@ -199,8 +247,11 @@ func FromContext(ctx context.Context) (*User, error) {
 
 // HasCategory checks whether the user has access to the given category.
 func (u *User) HasCategory(category category.Category) bool {
+	if u.DeniesCategory(category) {
+		return false
+	}
 	for _, c := range u.Categories {
-		if c == category {
+		if c.Covers(category) {
 			return true
 		}
 	}
@@ -219,17 +270,36 @@ func (u *User) hasCategoryForACL(acl acl.ACL) bool {
 // ValidateACLs checks if the user can possess the given set of acls.
 func (u *User) ValidateACLs(acls ...acl.ACL) error {
 	for _, a := range acls {
-		if !u.hasCategoryForACL(a) {
+		if a != acl.All && !u.hasCategoryForACL(a) {
 			return fmt.Errorf(`user doesn't have category to access "%s" acl`, a)
 		}
 	}
 	return nil
 }
 
-// HasACL checks whether the user has access to the given acl.
-func (u *User) HasACL(acl acl.ACL) bool {
-	for _, a := range u.ACLs {
-		if a == acl {
+// HasACL checks whether the user has access to the given acl, the wildcard
+// acl granting the acls of the user categories.
+func (u *User) HasACL(a acl.ACL) bool {
+	if u.DeniesACL(a) {
+		return false
+	}
+	return acl.Contains(u.ACLs, a) || acl.Contains(u.ACLs, acl.All) && u.hasCategoryForACL(a)
+}
+
+// DeniesCategory checks whether the user is explicitly denied the given category.
+func (u *User) DeniesCategory(category category.Category) bool {
+	for _, c := range u.DeniedCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// DeniesACL checks whether the user is denied a category of the given acl.
+func (u *User) DeniesACL(a acl.ACL) bool {
+	for _, c := range u.DeniedCategories {
+		if c.HasACL(a) {
 			return true
 		}
 	}
@@ -247,7 +317,12 @@ func (u *User) CanDo(op op.Operation) bool {
 }
 
 // CanAccessCluster checks whether the user can access cluster level routes.
+// The cluster level routes span all the indices, hence the users denied
+// access to any index can't access them.
 func (u *User) CanAccessCluster() (bool, error) {
+	if len(u.DeniedIndices) > 0 {
+		return false, nil
+	}
 	for _, pattern := range u.Indices {
 		pattern = strings.Replace(pattern, "*", ".*", -1)
 		matched, err := regexp.MatchString(pattern, "*")
@@ -263,17 +338,16 @@ func (u *User) CanAccessCluster() (bool, error) {
 
 // CanAccessIndex checks whether the user has access to the given index or index pattern.
 func (u *User) CanAccessIndex(name string) (bool, error) {
-	for _, pattern := range u.Indices {
-		pattern = strings.Replace(pattern, "*", ".*", -1)
-		matched, err := regexp.MatchString(pattern, name)
-		if err != nil {
-			return false, err
-		}
-		if matched {
-			return true, nil
-		}
+	if index.Overlaps(u.DeniedIndices, name) {
+		return false, nil
 	}
-	return false, nil
+	return index.Match(u.Indices, name), nil
+}
+
+// DeniesIndex checks whether the user is denied access to the index or
+// index pattern.
+func (u *User) DeniesIndex(name string) bool {
+	return index.Overlaps(u.DeniedIndices, name)
 }
 
 // CanAccessIndices checks whether the user has access to the given indices.
@@ -322,6 +396,15 @@ func (u *User) GetPatch() (map[string]interface{}, error) {
 	if u.CreatedAt != "" {
 		return nil, errors.NewUnsupportedPatchError("user", "created_at")
 	}
+	if u.DeniedIndices != nil {
+		if err := validateIndexPatterns(u.DeniedIndices); err != nil {
+			return nil, err
+		}
+		patch["denied_indices"] = u.DeniedIndices
+	}
+	if u.DeniedCategories != nil {
+		patch["denied_categories"] = u.DeniedCategories
+	}
 
 	return patch, nil
 }
@@ -0,0 +1,112 @@
+package aggcache
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag             = "[aggcache]"
+	envTTL             = "AGG_CACHE_TTL"
+	envStaleTTL        = "AGG_CACHE_STALE_TTL"
+	defaultStaleTTL    = time.Hour
+	envSize            = "AGG_CACHE_SIZE"
+	defaultSize        = 1000
+	envMaxBodySize     = "AGG_CACHE_MAX_BODY_SIZE"
+	defaultMaxBodySize = 1 << 20
+	// maxRevalidations bounds the revalidations in flight, the stale entries
+	// beyond it are revalidated by a later request.
+	maxRevalidations = 16
+)
+
+var (
+	singleton *aggCache
+	once      sync.Once
+)
+
+// aggCache caches the responses of the aggregation only searches, i.e. the
+// searches with a size of 0. The facet counts tolerate some staleness, so the
+// entries are served past their ttl while being revalidated in the background.
+type aggCache struct {
+	enabled       bool
+	maxBodySize   int
+	cache         *lruCache
+	revalidations chan struct{}
+	stats         stats
+}
+
+// Use only this function to fetch the instance of aggCache from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *aggCache {
+	once.Do(func() {
+		singleton = &aggCache{
+			maxBodySize:   defaultMaxBodySize,
+			revalidations: make(chan struct{}, maxRevalidations),
+		}
+	})
+	return singleton
+}
+
+func (a *aggCache) Name() string {
+	return logTag
+}
+
+func (a *aggCache) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	value := os.Getenv(envTTL)
+	if value == "" {
+		log.Println(logTag, ":", envTTL, "isn't set, aggregations won't be cached")
+		return nil
+	}
+	ttl, err := time.ParseDuration(value)
+	if err != nil || ttl <= 0 {
+		log.Errorln(logTag, ": invalid value for", envTTL, ":", value)
+		return nil
+	}
+	staleTTL := defaultStaleTTL
+	if value := os.Getenv(envStaleTTL); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			log.Errorln(logTag, ": invalid value for", envStaleTTL, ":", value)
+		} else {
+			staleTTL = d
+		}
+	}
+	size := defaultSize
+	if value := os.Getenv(envSize); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			log.Errorln(logTag, ": invalid value for", envSize, ":", value)
+		} else {
+			size = n
+		}
+	}
+	if value := os.Getenv(envMaxBodySize); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			log.Errorln(logTag, ": invalid value for", envMaxBodySize, ":", value)
+		} else {
+			a.maxBodySize = n
+		}
+	}
+
+	a.cache = newLRUCache(size, ttl, staleTTL)
+	a.enabled = true
+	return nil
+}
+
+func (a *aggCache) Routes() []plugins.Route {
+	return a.routes()
+}
+
+func (a *aggCache) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{a.serve}
+}
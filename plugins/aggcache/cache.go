@@ -0,0 +1,129 @@
+package aggcache
+
+import (
+	lists "container/list"
+	"sync"
+	"time"
+)
+
+// freshness of a cached response.
+type freshness int
+
+const (
+	// fresh entries are served as is.
+	fresh freshness = iota
+	// stale entries are served while being revalidated.
+	stale
+	// expired entries are treated as absent.
+	expired
+)
+
+// lruCache is a fixed size cache of responses that evicts the least recently
+// used entries first. Entries are fresh for the ttl, then stale for the
+// staleTTL, after which they are treated as absent.
+type lruCache struct {
+	sync.Mutex
+	size     int
+	ttl      time.Duration
+	staleTTL time.Duration
+	ll       *lists.List
+	items    map[string]*lists.Element
+}
+
+type cacheEntry struct {
+	key          string
+	response     response
+	storedAt     time.Time
+	revalidating bool
+}
+
+// response is a cached response.
+type response struct {
+	contentType string
+	body        []byte
+}
+
+func newLRUCache(size int, ttl, staleTTL time.Duration) *lruCache {
+	return &lruCache{
+		size:     size,
+		ttl:      ttl,
+		staleTTL: staleTTL,
+		ll:       lists.New(),
+		items:    make(map[string]*lists.Element),
+	}
+}
+
+// get returns the response cached against the key along with its freshness,
+// revalidate is set for the first caller to get the stale entry, which is
+// then expected to either add the revalidated response or to call release.
+func (c *lruCache) get(key string, now time.Time) (resp response, f freshness, revalidate bool) {
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return response{}, expired, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	age := now.Sub(entry.storedAt)
+	switch {
+	case age < c.ttl:
+		f = fresh
+	case age < c.ttl+c.staleTTL:
+		f = stale
+		if !entry.revalidating {
+			entry.revalidating = true
+			revalidate = true
+		}
+	default:
+		c.removeElement(elem)
+		return response{}, expired, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.response, f, revalidate
+}
+
+func (c *lruCache) add(key string, resp response, now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.items[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.response = resp
+		entry.storedAt = now
+		entry.revalidating = false
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, response: resp, storedAt: now})
+	if c.size > 0 && c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// release lets a later request revalidate the entry, if it still exists.
+func (c *lruCache) release(key string) {
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*cacheEntry).revalidating = false
+	}
+}
+
+func (c *lruCache) purge() int {
+	c.Lock()
+	defer c.Unlock()
+	n := c.ll.Len()
+	c.ll.Init()
+	c.items = make(map[string]*lists.Element)
+	return n
+}
+
+func (c *lruCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.ll.Len()
+}
+
+func (c *lruCache) removeElement(elem *lists.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*cacheEntry).key)
+}
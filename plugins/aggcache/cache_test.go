@@ -0,0 +1,107 @@
+package aggcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/acl"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+
+	Convey("Entries are fresh, then stale, then expired", t, func() {
+		c := newLRUCache(10, time.Minute, time.Hour)
+		c.add("k", response{body: []byte("{}")}, now)
+
+		_, f, revalidate := c.get("k", now.Add(30*time.Second))
+		So(f, ShouldEqual, fresh)
+		So(revalidate, ShouldBeFalse)
+
+		_, f, _ = c.get("k", now.Add(2*time.Hour))
+		So(f, ShouldEqual, expired)
+		So(c.len(), ShouldEqual, 0)
+	})
+
+	Convey("Only the first request to get a stale entry revalidates it", t, func() {
+		c := newLRUCache(10, time.Minute, time.Hour)
+		c.add("k", response{body: []byte("{}")}, now)
+
+		resp, f, revalidate := c.get("k", now.Add(2*time.Minute))
+		So(f, ShouldEqual, stale)
+		So(revalidate, ShouldBeTrue)
+		So(string(resp.body), ShouldEqual, "{}")
+
+		_, f, revalidate = c.get("k", now.Add(2*time.Minute))
+		So(f, ShouldEqual, stale)
+		So(revalidate, ShouldBeFalse)
+
+		c.release("k")
+		_, _, revalidate = c.get("k", now.Add(2*time.Minute))
+		So(revalidate, ShouldBeTrue)
+
+		c.add("k", response{body: []byte(`{"aggs":{}}`)}, now.Add(2*time.Minute))
+		resp, f, _ = c.get("k", now.Add(2*time.Minute))
+		So(f, ShouldEqual, fresh)
+		So(string(resp.body), ShouldEqual, `{"aggs":{}}`)
+	})
+
+	Convey("The least recently used entries are evicted", t, func() {
+		c := newLRUCache(2, time.Minute, 0)
+		c.add("a", response{}, now)
+		c.add("b", response{}, now)
+		c.get("a", now)
+		c.add("c", response{}, now)
+
+		_, f, _ := c.get("b", now)
+		So(f, ShouldEqual, expired)
+		_, f, _ = c.get("a", now)
+		So(f, ShouldEqual, fresh)
+	})
+}
+
+func TestCacheKey(t *testing.T) {
+	search := func(method, target, body string, reqACL acl.ACL) *http.Request {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		return req.WithContext(acl.NewContext(req.Context(), &reqACL))
+	}
+	aggs := `{"size": 0, "aggs": {"brands": {"terms": {"field": "brand"}}}}`
+
+	Convey("Aggregation only searches are cacheable", t, func() {
+		k1, ok := cacheKey(search(http.MethodPost, "/products/_search", aggs, acl.Search), []byte(aggs))
+		So(ok, ShouldBeTrue)
+
+		compact := `{"size":0,"aggs":{"brands":{"terms":{"field":"brand"}}}}`
+		k2, ok := cacheKey(search(http.MethodGet, "/products/_search", compact, acl.Search), []byte(compact))
+		So(ok, ShouldBeTrue)
+		So(k2, ShouldEqual, k1)
+
+		body := `{"aggs": {"brands": {"terms": {"field": "brand"}}}}`
+		_, ok = cacheKey(search(http.MethodPost, "/products/_search?size=0", body, acl.Search), []byte(body))
+		So(ok, ShouldBeTrue)
+
+		k3, _ := cacheKey(search(http.MethodPost, "/orders/_search", aggs, acl.Search), []byte(aggs))
+		So(k3, ShouldNotEqual, k1)
+	})
+
+	Convey("Other requests aren't cacheable", t, func() {
+		bodies := []string{
+			`{"size": 10, "aggs": {"brands": {"terms": {"field": "brand"}}}}`,
+			`{"aggs": {"brands": {"terms": {"field": "brand"}}}}`,
+			`{"size": 0}`,
+			`{"size": 0, "aggs": {}}`,
+			``,
+		}
+		for _, body := range bodies {
+			_, ok := cacheKey(search(http.MethodPost, "/products/_search", body, acl.Search), []byte(body))
+			So(ok, ShouldBeFalse)
+		}
+		_, ok := cacheKey(search(http.MethodPost, "/products/_count", aggs, acl.Count), []byte(aggs))
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,38 @@
+package aggcache
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// getStats reports the entries of the cache along with the outcomes of the
+// cacheable searches this arc instance has served since it started.
+func (a *aggCache) getStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := json.Marshal(a.report())
+		if err != nil {
+			msg := "error encoding the aggregations cache stats"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// purge empties the cache of this arc instance.
+func (a *aggCache) purge() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !a.enabled {
+			util.WriteBackError(w, "aggregations cache isn't enabled", http.StatusBadRequest)
+			return
+		}
+		n := a.cache.purge()
+		util.WriteBackMessage(w, fmt.Sprintf("purged %d cached aggregations", n), http.StatusOK)
+	}
+}
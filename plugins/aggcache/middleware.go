@@ -0,0 +1,242 @@
+package aggcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/feature"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	// cacheHeader reports whether the response was served from the cache.
+	cacheHeader = "X-Arc-Cache"
+	// revalidationTimeout bounds the revalidations, which outlive the client request.
+	revalidationTimeout = 30 * time.Second
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clustersCategory := category.Clusters
+		ctx := category.NewContext(req.Context(), &clustersCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusterACL := acl.Cluster
+		ctx := acl.NewContext(req.Context(), &clusterACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the cache spans all the
+// credentials.
+var isAdmin = validate.Admin("access the aggregations cache")
+
+// serve serves the aggregation only searches from the cache. The stale
+// responses are served while the first request to get them revalidates them
+// in the background, the misses are served as usual and cached once served.
+// A "Cache-Control: no-cache" request bypasses the cache but refreshes it,
+// whereas the "cache" feature toggled off by an admin bypasses it entirely.
+func (a *aggCache) serve(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !a.enabled || !feature.Enabled(req.Context(), feature.Cache, true) {
+			h(w, req)
+			return
+		}
+
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = util.ReadRequestBody(req)
+			if err != nil {
+				log.Errorln(logTag, ": unable to read request body:", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+		}
+		key, ok := cacheKey(req, body)
+		if !ok {
+			h(w, req)
+			return
+		}
+
+		if req.Header.Get("Cache-Control") != "no-cache" {
+			resp, f, revalidate := a.cache.get(key, time.Now())
+			switch f {
+			case fresh:
+				a.stats.add(fresh)
+				write(w, resp, "HIT")
+				return
+			case stale:
+				a.stats.add(stale)
+				write(w, resp, "STALE")
+				if revalidate {
+					a.revalidate(h, req, body, key)
+				}
+				return
+			}
+		}
+
+		a.stats.add(expired)
+		w.Header().Set(cacheHeader, "MISS")
+		tee := util.NewTeeResponseWriter(w, a.maxBodySize)
+		h(tee, req)
+		a.store(key, tee)
+	}
+}
+
+// revalidate serves the request again in the background to refresh the
+// stale entry, the request outlives the client request hence is detached
+// from its cancellation.
+func (a *aggCache) revalidate(h http.HandlerFunc, req *http.Request, body []byte, key string) {
+	select {
+	case a.revalidations <- struct{}{}:
+	default:
+		a.cache.release(key)
+		return
+	}
+	ctx, cancel := context.WithTimeout(detached{req.Context()}, revalidationTimeout)
+	revalidated := util.WithSharedBody(req.Clone(ctx))
+	util.SetRequestBody(revalidated, body)
+	go func() {
+		defer func() { <-a.revalidations }()
+		defer cancel()
+		tee := util.NewTeeResponseWriter(&discardWriter{header: http.Header{}}, a.maxBodySize)
+		h(tee, revalidated)
+		if !a.store(key, tee) {
+			a.cache.release(key)
+			log.Errorln(logTag, ": unable to revalidate the aggregations of", revalidated.URL.Path, ": response code", tee.Code())
+			return
+		}
+		a.stats.revalidated()
+	}()
+}
+
+// store caches the successful responses that fit the max body size.
+func (a *aggCache) store(key string, tee *util.TeeResponseWriter) bool {
+	if tee.Code() != http.StatusOK || tee.Truncated() {
+		return false
+	}
+	body := make([]byte, len(tee.Body()))
+	copy(body, tee.Body())
+	resp := response{contentType: tee.Header().Get("Content-Type"), body: body}
+	a.cache.add(key, resp, time.Now())
+	return true
+}
+
+func write(w http.ResponseWriter, resp response, status string) {
+	if resp.contentType != "" {
+		w.Header().Set("Content-Type", resp.contentType)
+	}
+	w.Header().Set("X-Origin", "ES")
+	w.Header().Set(cacheHeader, status)
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp.body)
+}
+
+// cacheKey returns the key of the aggregation only search, ok is false for
+// the requests that aren't. Searches differing only by the order of their
+// query params or the formatting of their body share the key.
+func cacheKey(req *http.Request, body []byte) (key string, ok bool) {
+	if req.Method != http.MethodGet && req.Method != http.MethodPost {
+		return "", false
+	}
+	reqACL, err := acl.FromContext(req.Context())
+	if err != nil || *reqACL != acl.Search {
+		return "", false
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return "", false
+	}
+	var search map[string]json.RawMessage
+	if err := json.Unmarshal(body, &search); err != nil {
+		return "", false
+	}
+	aggs, ok := search["aggs"]
+	if !ok {
+		aggs = search["aggregations"]
+	}
+	if aggs := strings.TrimSpace(string(aggs)); aggs == "" || aggs == "{}" || aggs == "null" {
+		return "", false
+	}
+	query := req.URL.Query()
+	if size, ok := search["size"]; ok {
+		if strings.TrimSpace(string(size)) != "0" {
+			return "", false
+		}
+	} else if query.Get("size") != "0" {
+		return "", false
+	}
+
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, body); err != nil {
+		return "", false
+	}
+	hash := sha256.New()
+	hash.Write([]byte(req.URL.Path + "?" + query.Encode() + "\n"))
+	hash.Write(compacted.Bytes())
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// detached carries the values of its parent without its cancellation.
+type detached struct {
+	parent context.Context
+}
+
+func (d detached) Deadline() (time.Time, bool)       { return time.Time{}, false }
+func (d detached) Done() <-chan struct{}             { return nil }
+func (d detached) Err() error                        { return nil }
+func (d detached) Value(key interface{}) interface{} { return d.parent.Value(key) }
+
+// discardWriter discards the response of the revalidations, which is
+// retained by the tee.
+type discardWriter struct {
+	header http.Header
+}
+
+func (d *discardWriter) Header() http.Header         { return d.header }
+func (d *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (d *discardWriter) WriteHeader(int)             {}
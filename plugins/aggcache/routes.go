@@ -0,0 +1,28 @@
+package aggcache
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (a *aggCache) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get aggregations cache stats",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_agg_cache",
+			HandlerFunc: middleware(isAdmin(a.getStats())),
+			Description: "Returns the number of cached aggregations along with the hits, stale hits and misses of the cache",
+		},
+		{
+			Name:        "Purge aggregations cache",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_agg_cache",
+			HandlerFunc: middleware(isAdmin(a.purge())),
+			Description: "Purges the cached aggregations",
+		},
+	}
+	return routes
+}
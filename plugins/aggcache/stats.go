@@ -0,0 +1,58 @@
+package aggcache
+
+import "sync"
+
+// stats counts the outcomes of the cacheable searches served by this arc
+// instance since it started.
+type stats struct {
+	sync.Mutex
+	hits          int64
+	staleHits     int64
+	misses        int64
+	revalidations int64
+}
+
+// statsReport is the snapshot of the stats.
+type statsReport struct {
+	Enabled       bool  `json:"enabled"`
+	Entries       int   `json:"entries"`
+	Hits          int64 `json:"hits"`
+	StaleHits     int64 `json:"stale_hits"`
+	Misses        int64 `json:"misses"`
+	Revalidations int64 `json:"revalidations"`
+}
+
+func (s *stats) add(f freshness) {
+	s.Lock()
+	defer s.Unlock()
+	switch f {
+	case fresh:
+		s.hits++
+	case stale:
+		s.staleHits++
+	default:
+		s.misses++
+	}
+}
+
+func (s *stats) revalidated() {
+	s.Lock()
+	defer s.Unlock()
+	s.revalidations++
+}
+
+func (a *aggCache) report() statsReport {
+	a.stats.Lock()
+	defer a.stats.Unlock()
+	r := statsReport{
+		Enabled:       a.enabled,
+		Hits:          a.stats.hits,
+		StaleHits:     a.stats.staleHits,
+		Misses:        a.stats.misses,
+		Revalidations: a.stats.revalidations,
+	}
+	if a.enabled {
+		r.Entries = a.cache.len()
+	}
+	return r
+}
@@ -0,0 +1,133 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron"
+)
+
+// Metric types an alert can be defined over.
+const (
+	// metricCount is the number of records matching the filter within the window.
+	metricCount = "count"
+
+	// metricRatio is the fraction of the records within the window that match the filter.
+	metricRatio = "ratio"
+)
+
+// compareWithPreviousDay evaluates the condition against the relative change
+// of the metric since the same window of the previous day.
+const compareWithPreviousDay = "previous_day"
+
+// metric is computed over the analytics records within the alert window.
+type metric struct {
+	Type   string          `json:"type"`
+	Filter json.RawMessage `json:"filter,omitempty"`
+}
+
+// condition triggers the alert when the metric compares with the value.
+type condition struct {
+	Operator string  `json:"operator"`
+	Value    float64 `json:"value"`
+}
+
+func (c condition) holds(v float64) bool {
+	switch c.Operator {
+	case "gt":
+		return v > c.Value
+	case "gte":
+		return v >= c.Value
+	case "lt":
+		return v < c.Value
+	case "lte":
+		return v <= c.Value
+	default:
+		return false
+	}
+}
+
+// alert is evaluated on the cron schedule, its condition is checked against
+// the metric computed over the records of the window preceding the evaluation.
+// The webhooks subscribed to the alert.triggered event and the emails are
+// notified when the alert starts triggering.
+type alert struct {
+	Name           string    `json:"name"`
+	Index          string    `json:"index,omitempty"`
+	TimestampField string    `json:"timestamp_field,omitempty"`
+	Metric         metric    `json:"metric"`
+	Window         string    `json:"window"`
+	Compare        string    `json:"compare,omitempty"`
+	Condition      condition `json:"condition"`
+	Cron           string    `json:"cron"`
+	Emails         []string  `json:"emails,omitempty"`
+	Creator        string    `json:"creator,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (a *alert) validate() error {
+	switch a.Metric.Type {
+	case metricCount:
+	case metricRatio:
+		if len(a.Metric.Filter) == 0 {
+			return fmt.Errorf(`"metric.filter" is required for the "%s" metric`, metricRatio)
+		}
+	default:
+		return fmt.Errorf(`"metric.type" must be one of "%s" or "%s"`, metricCount, metricRatio)
+	}
+	if len(a.Metric.Filter) > 0 && !json.Valid(a.Metric.Filter) {
+		return fmt.Errorf(`"metric.filter" must be a valid query`)
+	}
+	if _, err := a.window(); err != nil {
+		return err
+	}
+	if a.Compare != "" && a.Compare != compareWithPreviousDay {
+		return fmt.Errorf(`"compare" must be "%s" if provided`, compareWithPreviousDay)
+	}
+	switch a.Condition.Operator {
+	case "gt", "gte", "lt", "lte":
+	default:
+		return fmt.Errorf(`"condition.operator" must be one of "gt", "gte", "lt" or "lte"`)
+	}
+	if a.Cron == "" {
+		return fmt.Errorf(`"cron" is required`)
+	}
+	if _, err := cron.Parse(a.Cron); err != nil {
+		return fmt.Errorf(`invalid "cron" expression "%s": %v`, a.Cron, err)
+	}
+	return nil
+}
+
+func (a *alert) window() (time.Duration, error) {
+	window, err := time.ParseDuration(a.Window)
+	if err != nil || window <= 0 {
+		return 0, fmt.Errorf(`invalid "window" "%s", expected a positive duration such as "1h"`, a.Window)
+	}
+	return window, nil
+}
+
+// value returns the value the condition is checked against, which is the
+// relative change of the metric when compared with the previous day. The value
+// can't be computed if the previous day's metric is zero.
+func (a *alert) value(current, previous float64) (float64, bool) {
+	if a.Compare != compareWithPreviousDay {
+		return current, true
+	}
+	if previous == 0 {
+		return 0, false
+	}
+	return (current - previous) / previous, true
+}
+
+// evaluation is the outcome of an evaluation of the alert, recorded in the history.
+type evaluation struct {
+	Alert     string    `json:"alert"`
+	Timestamp time.Time `json:"timestamp"`
+	Current   float64   `json:"current"`
+	Previous  *float64  `json:"previous,omitempty"`
+	Value     float64   `json:"value"`
+	Triggered bool      `json:"triggered"`
+	Notified  bool      `json:"notified"`
+	Error     string    `json:"error,omitempty"`
+}
@@ -0,0 +1,93 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// fakeES serves the metrics of the current window and the day before.
+type fakeES struct {
+	alertService
+	current, previous float64
+	dayBefore         time.Time
+}
+
+func (f *fakeES) metric(ctx context.Context, a alert, from, to time.Time) (float64, error) {
+	if !to.After(f.dayBefore) {
+		return f.previous, nil
+	}
+	return f.current, nil
+}
+
+func validAlert() alert {
+	return alert{
+		Name:      "no-results",
+		Metric:    metric{Type: metricRatio, Filter: json.RawMessage(`{"term": {"total_hits": 0}}`)},
+		Window:    "1h",
+		Condition: condition{Operator: "gt", Value: 0.2},
+		Cron:      "0 */5 * * * *",
+	}
+}
+
+func TestValidate(t *testing.T) {
+	Convey("Valid alerts are accepted", t, func() {
+		a := validAlert()
+		So(a.validate(), ShouldBeNil)
+	})
+
+	Convey("Ratios require a filter", t, func() {
+		a := validAlert()
+		a.Metric.Filter = nil
+		So(a.validate(), ShouldNotBeNil)
+	})
+
+	Convey("Invalid windows, operators and cron expressions are rejected", t, func() {
+		a := validAlert()
+		a.Window = "an hour"
+		So(a.validate(), ShouldNotBeNil)
+
+		a = validAlert()
+		a.Condition.Operator = ">"
+		So(a.validate(), ShouldNotBeNil)
+
+		a = validAlert()
+		a.Cron = "every minute"
+		So(a.validate(), ShouldNotBeNil)
+	})
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Now()
+	dayBefore := now.AddDate(0, 0, -1)
+
+	Convey("The condition is checked against the metric", t, func() {
+		sc := newScheduler(&fakeES{current: 0.25, dayBefore: dayBefore}, newMailer())
+		e := sc.evaluate(context.Background(), validAlert(), now)
+		So(e.Error, ShouldBeEmpty)
+		So(e.Value, ShouldEqual, 0.25)
+		So(e.Triggered, ShouldBeTrue)
+	})
+
+	Convey("The relative change is checked when compared with the previous day", t, func() {
+		a := validAlert()
+		a.Metric = metric{Type: metricCount}
+		a.Compare = compareWithPreviousDay
+		a.Condition = condition{Operator: "lte", Value: -0.5}
+
+		sc := newScheduler(&fakeES{current: 40, previous: 100, dayBefore: dayBefore}, newMailer())
+		e := sc.evaluate(context.Background(), a, now)
+		So(*e.Previous, ShouldEqual, 100)
+		So(e.Value, ShouldAlmostEqual, -0.6)
+		So(e.Triggered, ShouldBeTrue)
+
+		Convey("The alert doesn't trigger without the previous day's records", func() {
+			sc := newScheduler(&fakeES{current: 40, dayBefore: dayBefore}, newMailer())
+			e := sc.evaluate(context.Background(), a, now)
+			So(e.Triggered, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,80 @@
+package alerts
+
+import (
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                = "[alerts]"
+	defaultAlertsEsIndex  = ".alerts"
+	envAlertsEsIndex      = "ALERTS_ES_INDEX"
+	defaultHistoryEsIndex = ".alerts_history"
+	envHistoryEsIndex     = "ALERTS_HISTORY_ES_INDEX"
+	defaultAnalyticsIndex = ".analytics"
+	envAnalyticsEsIndex   = "ANALYTICS_ES_INDEX"
+	settings              = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+)
+
+var (
+	singleton *alerts
+	once      sync.Once
+)
+
+type alerts struct {
+	es        alertService
+	scheduler *scheduler
+}
+
+// Use only this function to fetch the instance of alerts from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *alerts {
+	once.Do(func() { singleton = &alerts{} })
+	return singleton
+}
+
+func (a *alerts) Name() string {
+	return logTag
+}
+
+func (a *alerts) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	alertsIndex := os.Getenv(envAlertsEsIndex)
+	if alertsIndex == "" {
+		alertsIndex = defaultAlertsEsIndex
+	}
+	historyIndex := os.Getenv(envHistoryEsIndex)
+	if historyIndex == "" {
+		historyIndex = defaultHistoryEsIndex
+	}
+	analyticsIndex := os.Getenv(envAnalyticsEsIndex)
+	if analyticsIndex == "" {
+		analyticsIndex = defaultAnalyticsIndex
+	}
+
+	// initialize the dao
+	var err error
+	a.es, err = initPlugin(alertsIndex, historyIndex, analyticsIndex, settings)
+	if err != nil {
+		return err
+	}
+
+	// resume the evaluation of the persisted alerts
+	a.scheduler = newScheduler(a.es, newMailer())
+	return a.scheduler.load()
+}
+
+func (a *alerts) Routes() []plugins.Route {
+	return a.routes()
+}
+
+// Default empty middleware array function
+func (a *alerts) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
@@ -0,0 +1,223 @@
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+const (
+	defaultTimestampField = "timestamp"
+	historyMappings       = `
+	{
+	  "properties": {
+	    "alert": { "type": "keyword" },
+	    "timestamp": { "type": "date" },
+	    "triggered": { "type": "boolean" }
+	  }
+	}`
+)
+
+type elasticsearch struct {
+	alertsIndex    string
+	historyIndex   string
+	analyticsIndex string
+}
+
+func initPlugin(alertsIndex, historyIndex, analyticsIndex, config string) (*elasticsearch, error) {
+	ctx := context.Background()
+
+	es := &elasticsearch{alertsIndex, historyIndex, analyticsIndex}
+
+	// set number_of_replicas to (nodes-1)
+	nodes, err := util.GetTotalNodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := createIndex(ctx, alertsIndex, fmt.Sprintf(config, nodes, nodes-1)); err != nil {
+		return nil, err
+	}
+
+	var settings map[string]interface{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf(config, nodes, nodes-1)), &settings); err != nil {
+		return nil, err
+	}
+	if docType := util.DocType(); docType != "" {
+		settings["mappings"] = map[string]json.RawMessage{docType: json.RawMessage(historyMappings)}
+	} else {
+		settings["mappings"] = json.RawMessage(historyMappings)
+	}
+	body, err := json.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := createIndex(ctx, historyIndex, string(body)); err != nil {
+		return nil, err
+	}
+	return es, nil
+}
+
+func createIndex(ctx context.Context, indexName, body string) error {
+	exists, err := util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: error while checking if index already exists: %v", logTag, err)
+	}
+	if exists {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+		return nil
+	}
+
+	_, err = util.GetClient7().CreateIndex(indexName).
+		Body(body).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: error while creating index named %s: %v", logTag, indexName, err)
+	}
+
+	log.Println(logTag, ": successfully created index named", indexName)
+	return nil
+}
+
+func (es *elasticsearch) getAlerts(ctx context.Context) ([]alert, error) {
+	response, err := util.GetClient7().Search().
+		Index(es.alertsIndex).
+		Size(1000).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []alert{}
+	for _, hit := range response.Hits.Hits {
+		var a alert
+		if err := json.Unmarshal(hit.Source, &a); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal alert %s: %v", hit.Id, err)
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, nil
+}
+
+func (es *elasticsearch) getAlert(ctx context.Context, name string) (*alert, error) {
+	request := util.GetClient7().Get().
+		Index(es.alertsIndex).
+		Id(name).
+		FetchSource(true)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	response, err := request.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var a alert
+	if err := json.Unmarshal(response.Source, &a); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (es *elasticsearch) putAlert(ctx context.Context, a alert) error {
+	request := util.GetClient7().Index().
+		Refresh("wait_for").
+		Index(es.alertsIndex).
+		Id(a.Name).
+		BodyJson(a)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+func (es *elasticsearch) deleteAlert(ctx context.Context, name string) error {
+	request := util.GetClient7().Delete().
+		Refresh("wait_for").
+		Index(es.alertsIndex).
+		Id(name)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+// metric computes the metric of the alert over the records between from and to.
+func (es *elasticsearch) metric(ctx context.Context, a alert, from, to time.Time) (float64, error) {
+	indexName := a.Index
+	if indexName == "" {
+		indexName = es.analyticsIndex
+	}
+	field := a.TimestampField
+	if field == "" {
+		field = defaultTimestampField
+	}
+	window := es7.NewRangeQuery(field).Gte(from).Lt(to)
+	filters := []es7.Query{window}
+	if len(a.Metric.Filter) > 0 {
+		filters = append(filters, es7.NewRawStringQuery(string(a.Metric.Filter)))
+	}
+
+	matching, err := count(ctx, indexName, filters...)
+	if err != nil || a.Metric.Type != metricRatio {
+		return float64(matching), err
+	}
+	total, err := count(ctx, indexName, window)
+	if err != nil || total == 0 {
+		return 0, err
+	}
+	return float64(matching) / float64(total), nil
+}
+
+func count(ctx context.Context, indexName string, filters ...es7.Query) (int64, error) {
+	return util.GetClient7().Count(indexName).
+		Query(es7.NewBoolQuery().Filter(filters...)).
+		Do(ctx)
+}
+
+func (es *elasticsearch) recordEvaluation(ctx context.Context, e evaluation) error {
+	request := util.GetClient7().Index().
+		Index(es.historyIndex).
+		BodyJson(e)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+// getHistory returns the latest evaluations of the alert, optionally only
+// the ones that triggered.
+func (es *elasticsearch) getHistory(ctx context.Context, name string, triggered bool, size int) ([]evaluation, error) {
+	query := es7.NewBoolQuery().Filter(es7.NewTermQuery("alert", name))
+	if triggered {
+		query.Filter(es7.NewTermQuery("triggered", true))
+	}
+	response, err := util.GetClient7().Search().
+		Index(es.historyIndex).
+		Query(query).
+		Sort("timestamp", false).
+		Size(size).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history := []evaluation{}
+	for _, hit := range response.Hits.Hits {
+		var e evaluation
+		if err := json.Unmarshal(hit.Source, &e); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal evaluation %s: %v", hit.Id, err)
+		}
+		history = append(history, e)
+	}
+	return history, nil
+}
@@ -0,0 +1,187 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/audit"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultHistorySize = 100
+	maxHistorySize     = 1000
+)
+
+func (a *alerts) getAlerts() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		alerts, err := a.es.getAlerts(req.Context())
+		if err != nil {
+			msg := "an error occurred while fetching the alerts"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		raw, err := json.Marshal(alerts)
+		if err != nil {
+			msg := "an error occurred while fetching the alerts"
+			log.Errorln(logTag, ": unable to marshal alerts:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (a *alerts) getAlert() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		al, err := a.es.getAlert(req.Context(), name)
+		if err != nil {
+			msg := fmt.Sprintf(`alert with "name"="%s" not found`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		raw, err := json.Marshal(al)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching alert "%s"`, name)
+			log.Errorln(logTag, ": unable to marshal alert:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (a *alerts) putAlert() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+		creator, _, _ := req.BasicAuth()
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var al alert
+		if err := json.Unmarshal(body, &al); err != nil {
+			msg := "can't parse request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		al.Name = name
+		al.Creator = creator
+		al.CreatedAt = time.Now()
+		if err := al.validate(); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(al.Emails) > 0 && !a.scheduler.mailer.enabled() {
+			msg := fmt.Sprintf("%s and %s must be set in order to email alerts", envSMTPAddr, envSMTPFrom)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		err = a.es.putAlert(req.Context(), al)
+		a.audit(req, "put_alert", name, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while saving alert "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		if err := a.scheduler.add(al); err != nil {
+			msg := fmt.Sprintf(`an error occurred while scheduling alert "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		msg := fmt.Sprintf(`alert "%s" saved`, name)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+func (a *alerts) deleteAlert() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		err := a.es.deleteAlert(req.Context(), name)
+		a.audit(req, "delete_alert", name, err)
+		if err != nil {
+			msg := fmt.Sprintf(`alert with "name"="%s" not found`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		a.scheduler.remove(name)
+
+		msg := fmt.Sprintf(`alert "%s" deleted`, name)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+// getHistory returns the latest evaluations of the alert, the number of
+// evaluations is set by "size" and "triggered=true" only returns the
+// evaluations that triggered.
+func (a *alerts) getHistory() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+		params := req.URL.Query()
+
+		size := defaultHistorySize
+		if value := params.Get("size"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 || n > maxHistorySize {
+				msg := fmt.Sprintf(`invalid value "%s" for query param "size", expected a number between 1 and %d`, value, maxHistorySize)
+				util.WriteBackError(w, msg, http.StatusBadRequest)
+				return
+			}
+			size = n
+		}
+
+		history, err := a.es.getHistory(req.Context(), name, params.Get("triggered") == "true", size)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching the history of alert "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		raw, err := json.Marshal(history)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching the history of alert "%s"`, name)
+			log.Errorln(logTag, ": unable to marshal alert history:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// audit records the outcome of the alert operation performed by the request.
+func (a *alerts) audit(req *http.Request, action, resource string, err error) {
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusInternalServerError
+	}
+	event := audit.NewEvent(req, action, resource, status)
+	if err != nil {
+		event.Details = map[string]interface{}{"error": err.Error()}
+	}
+	audit.Record(req.Context(), event)
+}
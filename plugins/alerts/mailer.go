@@ -0,0 +1,51 @@
+package alerts
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+const (
+	envSMTPAddr     = "ALERTS_SMTP_ADDR"
+	envSMTPUsername = "ALERTS_SMTP_USERNAME"
+	envSMTPPassword = "ALERTS_SMTP_PASSWORD"
+	envSMTPFrom     = "ALERTS_SMTP_FROM"
+)
+
+// mailer emails the alerts via the smtp server configured via ALERTS_SMTP_ADDR.
+type mailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func newMailer() *mailer {
+	m := &mailer{
+		addr: os.Getenv(envSMTPAddr),
+		from: os.Getenv(envSMTPFrom),
+	}
+	if username := os.Getenv(envSMTPUsername); username != "" {
+		host, _, _ := net.SplitHostPort(m.addr)
+		m.auth = smtp.PlainAuth("", username, os.Getenv(envSMTPPassword), host)
+		if m.from == "" {
+			m.from = username
+		}
+	}
+	return m
+}
+
+func (m *mailer) enabled() bool {
+	return m.addr != "" && m.from != ""
+}
+
+func (m *mailer) send(to []string, subject, body string) error {
+	if !m.enabled() {
+		return fmt.Errorf("%s and %s must be set in order to email alerts", envSMTPAddr, envSMTPFrom)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s\r\n",
+		m.from, strings.Join(to, ", "), subject, body)
+	return smtp.SendMail(m.addr, m.auth, m.from, to, []byte(msg))
+}
@@ -0,0 +1,52 @@
+package alerts
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// Alerts are defined over the analytics, hence the credentials must be able
+// to access the analytics category.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyIndices,
+		logs.Recorder(),
+		classify.Op(),
+		auth.BasicAuth(),
+		validate.Operation(),
+		validate.Category(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		analyticsCategory := category.Analytics
+		ctx := category.NewContext(req.Context(), &analyticsCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyIndices(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := index.NewContext(req.Context(), []string{})
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
@@ -0,0 +1,49 @@
+package alerts
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (a *alerts) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get alerts",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_alerts",
+			HandlerFunc: middleware(a.getAlerts()),
+			Description: "Returns all the alerts",
+		},
+		{
+			Name:        "Get alert",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_alert/{name}",
+			HandlerFunc: middleware(a.getAlert()),
+			Description: "Returns the alert {name}",
+		},
+		{
+			Name:        "Create alert",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_alert/{name}",
+			HandlerFunc: middleware(a.putAlert()),
+			Description: "Creates or updates the alert {name}",
+		},
+		{
+			Name:        "Delete alert",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_alert/{name}",
+			HandlerFunc: middleware(a.deleteAlert()),
+			Description: "Deletes the alert {name}",
+		},
+		{
+			Name:        "Get alert history",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_alert/{name}/history",
+			HandlerFunc: middleware(a.getHistory()),
+			Description: "Returns the latest evaluations of the alert {name}",
+		},
+	}
+	return routes
+}
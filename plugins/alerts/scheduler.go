@@ -0,0 +1,166 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/notify"
+	"github.com/appbaseio/arc/util/state"
+)
+
+type scheduler struct {
+	mu     sync.Mutex
+	es     alertService
+	mailer *mailer
+	jobs   map[string]*cron.Cron
+}
+
+func newScheduler(es alertService, m *mailer) *scheduler {
+	return &scheduler{
+		es:     es,
+		mailer: m,
+		jobs:   make(map[string]*cron.Cron),
+	}
+}
+
+// load schedules all the persisted alerts.
+func (sc *scheduler) load() error {
+	alerts, err := sc.es.getAlerts(context.Background())
+	if err != nil {
+		return fmt.Errorf("%s: error while fetching alerts: %v", logTag, err)
+	}
+	for _, a := range alerts {
+		if err := sc.add(a); err != nil {
+			log.Errorln(logTag, ": unable to schedule", a.Name, ":", err)
+		}
+	}
+	return nil
+}
+
+// add schedules the alert, replacing the existing alert with the same name.
+func (sc *scheduler) add(a alert) error {
+	job := cron.New()
+	if err := job.AddFunc(a.Cron, func() { sc.run(a) }); err != nil {
+		return err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if existing, ok := sc.jobs[a.Name]; ok {
+		existing.Stop()
+	}
+	sc.jobs[a.Name] = job
+	job.Start()
+	return nil
+}
+
+func (sc *scheduler) remove(name string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if job, ok := sc.jobs[name]; ok {
+		job.Stop()
+		delete(sc.jobs, name)
+	}
+	if err := state.Instance().Delete(context.Background(), triggeredKey(name)); err != nil {
+		log.Errorln(logTag, ": unable to reset the state of", name, ":", err)
+	}
+}
+
+// run evaluates the alert and notifies when it starts triggering. Each of the
+// scheduled evaluations is run by a single arc instance.
+func (sc *scheduler) run(a alert) {
+	ctx := context.Background()
+	now := time.Now()
+
+	store := state.Instance()
+	lock := fmt.Sprintf("alerts:%s:run:%d", a.Name, now.Truncate(time.Minute).Unix())
+	if acquired, err := store.SetNX(ctx, lock, []byte{1}, 2*time.Minute); err != nil {
+		log.Errorln(logTag, ": unable to lock the evaluation of", a.Name, ":", err)
+	} else if !acquired {
+		return
+	}
+
+	e := sc.evaluate(ctx, a, now)
+	if e.Error == "" {
+		// only the transitions into the triggered state are notified
+		_, err := store.Get(ctx, triggeredKey(a.Name))
+		wasTriggered := err == nil
+		if e.Triggered && !wasTriggered {
+			sc.notify(a, e)
+			e.Notified = true
+			if err := store.Set(ctx, triggeredKey(a.Name), []byte{1}, 0); err != nil {
+				log.Errorln(logTag, ": unable to update the state of", a.Name, ":", err)
+			}
+		}
+		if !e.Triggered && wasTriggered {
+			if err := store.Delete(ctx, triggeredKey(a.Name)); err != nil {
+				log.Errorln(logTag, ": unable to update the state of", a.Name, ":", err)
+			}
+		}
+	}
+
+	if err := sc.es.recordEvaluation(ctx, e); err != nil {
+		log.Errorln(logTag, ": unable to record the evaluation of", a.Name, ":", err)
+	}
+}
+
+// evaluate computes the metric over the window preceding the given time and
+// checks the alert condition against it.
+func (sc *scheduler) evaluate(ctx context.Context, a alert, now time.Time) evaluation {
+	e := evaluation{Alert: a.Name, Timestamp: now}
+	window, err := a.window()
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+
+	e.Current, err = sc.es.metric(ctx, a, now.Add(-window), now)
+	if err != nil {
+		e.Error = err.Error()
+		return e
+	}
+	var previous float64
+	if a.Compare == compareWithPreviousDay {
+		dayBefore := now.AddDate(0, 0, -1)
+		previous, err = sc.es.metric(ctx, a, dayBefore.Add(-window), dayBefore)
+		if err != nil {
+			e.Error = err.Error()
+			return e
+		}
+		e.Previous = &previous
+	}
+
+	value, ok := a.value(e.Current, previous)
+	if !ok {
+		return e
+	}
+	e.Value = value
+	e.Triggered = a.Condition.holds(value)
+	return e
+}
+
+func (sc *scheduler) notify(a alert, e evaluation) {
+	msg := fmt.Sprintf(`alert "%s" triggered: %s %s %v over the last %s (value %v)`,
+		a.Name, a.Metric.Type, a.Condition.Operator, a.Condition.Value, a.Window, e.Value)
+	notify.Send(notify.Event{
+		Type:      notify.AlertTriggered,
+		Timestamp: e.Timestamp,
+		Resource:  a.Name,
+		Message:   msg,
+		Details:   e,
+	})
+	if len(a.Emails) > 0 {
+		if err := sc.mailer.send(a.Emails, "[arc] "+msg, msg); err != nil {
+			log.Errorln(logTag, ": unable to email alert", a.Name, ":", err)
+		}
+	}
+}
+
+func triggeredKey(name string) string {
+	return "alerts:" + name + ":triggered"
+}
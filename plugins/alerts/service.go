@@ -0,0 +1,16 @@
+package alerts
+
+import (
+	"context"
+	"time"
+)
+
+type alertService interface {
+	getAlerts(ctx context.Context) ([]alert, error)
+	getAlert(ctx context.Context, name string) (*alert, error)
+	putAlert(ctx context.Context, a alert) error
+	deleteAlert(ctx context.Context, name string) error
+	metric(ctx context.Context, a alert, from, to time.Time) (float64, error)
+	recordEvaluation(ctx context.Context, e evaluation) error
+	getHistory(ctx context.Context, name string, triggered bool, size int) ([]evaluation, error)
+}
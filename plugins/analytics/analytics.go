@@ -0,0 +1,174 @@
+package analytics
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                = "[analytics]"
+	envAnalyticsEsIndex   = "ANALYTICS_ES_INDEX"
+	defaultAnalyticsIndex = ".analytics"
+	envDashboardCacheTTL  = "ANALYTICS_DASHBOARD_CACHE_TTL"
+	defaultDashboardTTL   = time.Minute
+	envSessionWindow      = "ANALYTICS_SESSION_WINDOW"
+	defaultSessionWindow  = 30 * time.Minute
+	envTimestampField     = "ANALYTICS_TIMESTAMP_FIELD"
+	envTimestampFormat    = "ANALYTICS_TIMESTAMP_FORMAT"
+	envTimezone           = "ANALYTICS_TIMEZONE"
+	// legacyTimestampFormat is the format of the timestamps recorded by
+	// the previous releases, in the local time of the server.
+	legacyTimestampFormat = "2006/01/02 15:04:05"
+	envTimestampMapping   = "ANALYTICS_TIMESTAMP_MAPPING_FORMAT"
+	// defaultTimestampMapping is the date format of the timestamp field, it
+	// accepts the legacy timestamps so that they survive the migrations.
+	defaultTimestampMapping = "strict_date_optional_time||yyyy/MM/dd HH:mm:ss||epoch_millis"
+	// indexVersion is the version of the body of the analytics index, it
+	// must be bumped along with the mappings in order to migrate the index.
+	indexVersion = 3
+)
+
+var (
+	singleton *analytics
+	once      sync.Once
+)
+
+// recordFields are the fields of the analytics records.
+type recordFields struct {
+	query      string
+	hits       string
+	click      string
+	conversion string
+	country    string
+	took       string
+	timestamp  string
+	location   string
+	intent     string
+}
+
+// defaultFields are the fields of the records recorded by arc.
+var defaultFields = recordFields{
+	query:      "search_query",
+	hits:       "total_hits",
+	click:      "click",
+	conversion: "conversion",
+	country:    "country",
+	took:       "took",
+	timestamp:  "timestamp",
+	location:   "location",
+	intent:     "intent",
+}
+
+// analytics serves the analytics of the searches, aggregated from the
+// analytics records.
+type analytics struct {
+	es           analyticsService
+	dashboardTTL time.Duration
+	// sessionWindow is the time the searches are given to be clicked
+	// before they count as zero-click searches.
+	sessionWindow time.Duration
+	// timestampFormat is the layout of the timestamps of the records,
+	// formatted in the timezone location.
+	timestampFormat string
+	location        *time.Location
+	// export is nil unless the records are exported to an object store.
+	export *export
+}
+
+// Use only this function to fetch the instance of analytics from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *analytics {
+	once.Do(func() {
+		singleton = &analytics{
+			dashboardTTL:    defaultDashboardTTL,
+			sessionWindow:   defaultSessionWindow,
+			timestampFormat: time.RFC3339,
+			location:        time.UTC,
+		}
+	})
+	return singleton
+}
+
+func (a *analytics) Name() string {
+	return logTag
+}
+
+func (a *analytics) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	analyticsIndex := os.Getenv(envAnalyticsEsIndex)
+	if analyticsIndex == "" {
+		analyticsIndex = defaultAnalyticsIndex
+	}
+	if value := os.Getenv(envDashboardCacheTTL); value != "" {
+		ttl, err := time.ParseDuration(value)
+		if err != nil || ttl < 0 {
+			log.Errorln(logTag, ": invalid value for", envDashboardCacheTTL, ":", value)
+		} else {
+			a.dashboardTTL = ttl
+		}
+	}
+	if value := os.Getenv(envSessionWindow); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window < 0 {
+			log.Errorln(logTag, ": invalid value for", envSessionWindow, ":", value)
+		} else {
+			a.sessionWindow = window
+		}
+	}
+	if value := os.Getenv(envTimestampFormat); value != "" {
+		a.timestampFormat = value
+	}
+	if value := os.Getenv(envTimezone); value != "" {
+		location, err := time.LoadLocation(value)
+		if err != nil {
+			log.Errorln(logTag, ": invalid value for", envTimezone, ":", value)
+		} else {
+			a.location = location
+		}
+	}
+	fields := defaultFields
+	if value := os.Getenv(envTimestampField); value != "" {
+		fields.timestamp = value
+	}
+	a.es = &elasticsearch{analyticsIndex, fields, a.location}
+
+	timestampMapping := os.Getenv(envTimestampMapping)
+	if timestampMapping == "" {
+		timestampMapping = defaultTimestampMapping
+	}
+	if err := initIndex(analyticsIndex, fields, timestampMapping); err != nil {
+		return err
+	}
+
+	// export the records of each day to an object store
+	export, err := exportFromEnv(fields)
+	if err != nil {
+		return err
+	}
+	if export != nil {
+		a.export = export
+		go a.export.every(a.es)
+	}
+	return nil
+}
+
+// formatTimestamp formats the time of a record in the configured format and timezone.
+func (a *analytics) formatTimestamp(t time.Time) string {
+	return t.In(a.location).Format(a.timestampFormat)
+}
+
+func (a *analytics) Routes() []plugins.Route {
+	return a.routes()
+}
+
+// Default empty middleware array function
+func (a *analytics) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
@@ -0,0 +1,820 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	es7 "github.com/olivere/elastic/v7"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/migration"
+)
+
+type elasticsearch struct {
+	analyticsIndex string
+	fields         recordFields
+	// location is the timezone the date math of the ranges is resolved in.
+	location *time.Location
+}
+
+// initIndex creates the analytics index with its mappings, or migrates it if
+// its mappings changed.
+func initIndex(indexName string, fields recordFields, timestampFormat string) error {
+	body, err := indexBody(fields, timestampFormat, util.DocType())
+	if err != nil {
+		return err
+	}
+	created, err := migration.Ensure(context.Background(), migration.Index{
+		Name:    indexName,
+		Version: indexVersion,
+		Body:    body,
+	})
+	if err != nil {
+		return err
+	}
+	if created {
+		log.Println(logTag, ": successfully created index named", indexName)
+	} else {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+	}
+	return nil
+}
+
+// indexBody returns the mappings of the analytics records, the fields of the
+// records that aren't declared are still mapped dynamically.
+func indexBody(fields recordFields, timestampFormat, docType string) (string, error) {
+	mappings := map[string]interface{}{
+		"properties": map[string]interface{}{
+			fields.query: map[string]interface{}{
+				"type": "keyword",
+				"fields": map[string]interface{}{
+					"text":   map[string]interface{}{"type": "text"},
+					"tokens": map[string]interface{}{"type": "token_count", "analyzer": "standard"},
+				},
+			},
+			fields.hits:       map[string]interface{}{"type": "long"},
+			fields.click:      map[string]interface{}{"type": "boolean"},
+			fields.conversion: map[string]interface{}{"type": "boolean"},
+			fields.country:    map[string]interface{}{"type": "keyword"},
+			fields.took:       map[string]interface{}{"type": "float"},
+			fields.timestamp:  map[string]interface{}{"type": "date", "format": timestampFormat},
+			fields.location:   map[string]interface{}{"type": "geo_point"},
+			fields.intent:     map[string]interface{}{"type": "keyword"},
+		},
+	}
+	body := map[string]interface{}{"mappings": mappings}
+	if docType != "" {
+		body["mappings"] = map[string]interface{}{docType: mappings}
+	}
+	raw, err := json.Marshal(body)
+	return string(raw), err
+}
+
+// dashboardRange is the time range the dashboard spans along with the
+// number of entries of its lists, the bounds accept the es date math.
+type dashboardRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Size int    `json:"size"`
+}
+
+type bucket struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+type popularSearch struct {
+	bucket
+	Clicks           int64   `json:"clicks"`
+	ClickThroughRate float64 `json:"click_through_rate"`
+}
+
+type overview struct {
+	Searches         int64   `json:"searches"`
+	NoResults        int64   `json:"no_results"`
+	NoResultsRate    float64 `json:"no_results_rate"`
+	Clicks           int64   `json:"clicks"`
+	ClickThroughRate float64 `json:"click_through_rate"`
+	Conversions      int64   `json:"conversions"`
+	ConversionRate   float64 `json:"conversion_rate"`
+}
+
+// latency is the time taken by the searches in milliseconds, the values are
+// null in the absence of searches.
+type latency struct {
+	Avg *float64 `json:"avg"`
+	P50 *float64 `json:"p50"`
+	P95 *float64 `json:"p95"`
+	P99 *float64 `json:"p99"`
+}
+
+// intentSearches are the searches of an intent, along with their clicks.
+// The records only have an intent if the recorder that writes them stores the
+// X-Search-Intent header of the response, which nothing in arc does yet.
+type intentSearches struct {
+	bucket
+	Clicks           int64   `json:"clicks"`
+	ClickThroughRate float64 `json:"click_through_rate"`
+}
+
+type dashboard struct {
+	Range             dashboardRange   `json:"range"`
+	Overview          overview         `json:"overview"`
+	PopularSearches   []popularSearch  `json:"popular_searches"`
+	NoResultsSearches []bucket         `json:"no_results_searches"`
+	Geo               []bucket         `json:"geo"`
+	Intents           []intentSearches `json:"intents"`
+	Latency           latency          `json:"latency"`
+}
+
+type rawBucket struct {
+	Key      interface{} `json:"key"`
+	DocCount int64       `json:"doc_count"`
+}
+
+type filterAgg struct {
+	DocCount int64 `json:"doc_count"`
+}
+
+// dashboardResponse is the response of the dashboard search.
+type dashboardResponse struct {
+	Aggregations struct {
+		Searches    filterAgg `json:"searches"`
+		NoResults   filterAgg `json:"no_results"`
+		Clicks      filterAgg `json:"clicks"`
+		Conversions filterAgg `json:"conversions"`
+		Popular     struct {
+			Buckets []struct {
+				rawBucket
+				Clicks filterAgg `json:"clicks"`
+			} `json:"buckets"`
+		} `json:"popular_searches"`
+		NoResultsSearches struct {
+			Queries struct {
+				Buckets []rawBucket `json:"buckets"`
+			} `json:"queries"`
+		} `json:"no_results_searches"`
+		Geo struct {
+			Buckets []rawBucket `json:"buckets"`
+		} `json:"geo"`
+		Intents struct {
+			Buckets []struct {
+				rawBucket
+				Clicks filterAgg `json:"clicks"`
+			} `json:"buckets"`
+		} `json:"intents"`
+		AvgLatency struct {
+			Value *float64 `json:"value"`
+		} `json:"avg_latency"`
+		Latency struct {
+			Values map[string]*float64 `json:"values"`
+		} `json:"latency"`
+	} `json:"aggregations"`
+}
+
+// dashboard aggregates all the panels of the dashboard in a single search.
+// The search is made via a raw request since the response of the es6
+// searches can't be parsed by the es7 client.
+func (es *elasticsearch) dashboard(ctx context.Context, r dashboardRange) (*dashboard, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(es.analyticsIndex) + "/_search",
+		Body:   es.dashboardQuery(r),
+	})
+	if util.IsNotFound(err) {
+		// nothing was recorded yet
+		return newDashboard(r, &dashboardResponse{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result dashboardResponse
+	if err := json.Unmarshal(response.Body, &result); err != nil {
+		return nil, err
+	}
+	return newDashboard(r, &result), nil
+}
+
+func (es *elasticsearch) dashboardQuery(r dashboardRange) map[string]interface{} {
+	f := es.fields
+	term := func(field string, value interface{}) map[string]interface{} {
+		return map[string]interface{}{"term": map[string]interface{}{field: value}}
+	}
+	return map[string]interface{}{
+		"size":  0,
+		"query": es.rangeQuery(r),
+		"aggs": map[string]interface{}{
+			"searches":    map[string]interface{}{"filter": map[string]interface{}{"match_all": map[string]interface{}{}}},
+			"no_results":  map[string]interface{}{"filter": term(f.hits, 0)},
+			"clicks":      map[string]interface{}{"filter": term(f.click, true)},
+			"conversions": map[string]interface{}{"filter": term(f.conversion, true)},
+			"popular_searches": map[string]interface{}{
+				"terms": map[string]interface{}{"field": f.query, "size": r.Size},
+				"aggs": map[string]interface{}{
+					"clicks": map[string]interface{}{"filter": term(f.click, true)},
+				},
+			},
+			"no_results_searches": map[string]interface{}{
+				"filter": term(f.hits, 0),
+				"aggs": map[string]interface{}{
+					"queries": map[string]interface{}{
+						"terms": map[string]interface{}{"field": f.query, "size": r.Size},
+					},
+				},
+			},
+			"geo": map[string]interface{}{
+				"terms": map[string]interface{}{"field": f.country, "size": r.Size},
+			},
+			"intents": map[string]interface{}{
+				"terms": map[string]interface{}{"field": f.intent, "size": r.Size},
+				"aggs": map[string]interface{}{
+					"clicks": map[string]interface{}{"filter": term(f.click, true)},
+				},
+			},
+			"avg_latency": map[string]interface{}{
+				"avg": map[string]interface{}{"field": f.took},
+			},
+			"latency": map[string]interface{}{
+				"percentiles": map[string]interface{}{"field": f.took, "percents": []float64{50, 95, 99}},
+			},
+		},
+	}
+}
+
+// zeroClickSearch is a query whose searches got results but no click.
+type zeroClickSearch struct {
+	Key string `json:"key"`
+	// Searches is the number of searches of the query that got results.
+	Searches      int64   `json:"searches"`
+	ZeroClick     int64   `json:"zero_click"`
+	ZeroClickRate float64 `json:"zero_click_rate"`
+}
+
+type zeroClickSearches struct {
+	Range dashboardRange `json:"range"`
+	// Window is the time the searches are given to be clicked.
+	Window        string            `json:"window"`
+	Searches      int64             `json:"searches"`
+	ZeroClick     int64             `json:"zero_click"`
+	ZeroClickRate float64           `json:"zero_click_rate"`
+	Queries       []zeroClickSearch `json:"queries"`
+}
+
+// zeroClickResponse is the response of the zero-click searches search.
+type zeroClickResponse struct {
+	Hits struct {
+		Total util.TotalHits `json:"total"`
+	} `json:"hits"`
+	Aggregations struct {
+		ZeroClick filterAgg `json:"zero_click"`
+		Queries   struct {
+			Buckets []struct {
+				rawBucket
+				ZeroClick filterAgg `json:"zero_click"`
+			} `json:"buckets"`
+		} `json:"queries"`
+	} `json:"aggregations"`
+}
+
+// zeroClickSearches aggregates the searches that got results but no click
+// within the session window per query.
+func (es *elasticsearch) zeroClickSearches(ctx context.Context, r dashboardRange, window time.Duration) (*zeroClickSearches, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(es.analyticsIndex) + "/_search",
+		Body:   es.zeroClickQuery(r, window),
+	})
+	if util.IsNotFound(err) {
+		return newZeroClickSearches(r, window, &zeroClickResponse{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result zeroClickResponse
+	if err := json.Unmarshal(response.Body, &result); err != nil {
+		return nil, err
+	}
+	return newZeroClickSearches(r, window, &result), nil
+}
+
+// zeroClickQuery matches the searches of the range that got results and
+// that are older than the window, the searches within the window may still
+// be clicked. The queries are ranked by their number of zero-click searches.
+func (es *elasticsearch) zeroClickQuery(r dashboardRange, window time.Duration) map[string]interface{} {
+	f := es.fields
+	notClicked := map[string]interface{}{
+		"bool": map[string]interface{}{
+			"must_not": map[string]interface{}{"term": map[string]interface{}{f.click: true}},
+		},
+	}
+	return map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					es.rangeQuery(r),
+					map[string]interface{}{"range": map[string]interface{}{
+						f.timestamp: map[string]interface{}{"lte": fmt.Sprintf("now-%ds", int64(window.Seconds()))},
+					}},
+				},
+				"must_not": map[string]interface{}{"term": map[string]interface{}{f.hits: 0}},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"zero_click": map[string]interface{}{"filter": notClicked},
+			"queries": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": f.query,
+					"size":  r.Size,
+					"order": map[string]interface{}{"zero_click": "desc"},
+				},
+				"aggs": map[string]interface{}{
+					"zero_click": map[string]interface{}{"filter": notClicked},
+				},
+			},
+		},
+	}
+}
+
+func newZeroClickSearches(r dashboardRange, window time.Duration, result *zeroClickResponse) *zeroClickSearches {
+	aggs := result.Aggregations
+	z := &zeroClickSearches{
+		Range:     r,
+		Window:    window.String(),
+		Searches:  result.Hits.Total.Value,
+		ZeroClick: aggs.ZeroClick.DocCount,
+		Queries:   []zeroClickSearch{},
+	}
+	z.ZeroClickRate = rate(z.ZeroClick, z.Searches)
+	for _, b := range aggs.Queries.Buckets {
+		if b.ZeroClick.DocCount == 0 {
+			continue
+		}
+		z.Queries = append(z.Queries, zeroClickSearch{
+			Key:           key(b.Key),
+			Searches:      b.DocCount,
+			ZeroClick:     b.ZeroClick.DocCount,
+			ZeroClickRate: rate(b.ZeroClick.DocCount, b.DocCount),
+		})
+	}
+	return z
+}
+
+// queryLength is the outcome of the searches whose query has a number of
+// tokens.
+type queryLength struct {
+	Tokens           int64    `json:"tokens"`
+	Searches         int64    `json:"searches"`
+	AvgHits          *float64 `json:"avg_hits"`
+	NoResults        int64    `json:"no_results"`
+	NoResultsRate    float64  `json:"no_results_rate"`
+	Clicks           int64    `json:"clicks"`
+	ClickThroughRate float64  `json:"click_through_rate"`
+}
+
+type queryLengths struct {
+	Range   dashboardRange `json:"range"`
+	Lengths []queryLength  `json:"lengths"`
+}
+
+// queryLengthsResponse is the response of the query lengths search.
+type queryLengthsResponse struct {
+	Aggregations struct {
+		Lengths struct {
+			Buckets []struct {
+				Key       float64   `json:"key"`
+				DocCount  int64     `json:"doc_count"`
+				NoResults filterAgg `json:"no_results"`
+				Clicks    filterAgg `json:"clicks"`
+				AvgHits   struct {
+					Value *float64 `json:"value"`
+				} `json:"avg_hits"`
+			} `json:"buckets"`
+		} `json:"lengths"`
+	} `json:"aggregations"`
+}
+
+// queryLengths aggregates the hits and the clicks of the searches per number
+// of tokens of their query, which elasticsearch counts as the records are
+// indexed.
+func (es *elasticsearch) queryLengths(ctx context.Context, r dashboardRange) (*queryLengths, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(es.analyticsIndex) + "/_search",
+		Body:   es.queryLengthsQuery(r),
+	})
+	if util.IsNotFound(err) {
+		return newQueryLengths(r, &queryLengthsResponse{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result queryLengthsResponse
+	if err := json.Unmarshal(response.Body, &result); err != nil {
+		return nil, err
+	}
+	return newQueryLengths(r, &result), nil
+}
+
+func (es *elasticsearch) queryLengthsQuery(r dashboardRange) map[string]interface{} {
+	f := es.fields
+	term := func(field string, value interface{}) map[string]interface{} {
+		return map[string]interface{}{"term": map[string]interface{}{field: value}}
+	}
+	return map[string]interface{}{
+		"size":  0,
+		"query": es.rangeQuery(r),
+		"aggs": map[string]interface{}{
+			"lengths": map[string]interface{}{
+				"histogram": map[string]interface{}{
+					"field":         f.query + ".tokens",
+					"interval":      1,
+					"min_doc_count": 1,
+				},
+				"aggs": map[string]interface{}{
+					"avg_hits":   map[string]interface{}{"avg": map[string]interface{}{"field": f.hits}},
+					"no_results": map[string]interface{}{"filter": term(f.hits, 0)},
+					"clicks":     map[string]interface{}{"filter": term(f.click, true)},
+				},
+			},
+		},
+	}
+}
+
+// newQueryLengths lists the lengths, the shortest first, up to the size of
+// the range.
+func newQueryLengths(r dashboardRange, result *queryLengthsResponse) *queryLengths {
+	q := &queryLengths{Range: r, Lengths: []queryLength{}}
+	for _, b := range result.Aggregations.Lengths.Buckets {
+		if r.Size > 0 && len(q.Lengths) == r.Size {
+			break
+		}
+		q.Lengths = append(q.Lengths, queryLength{
+			Tokens:           int64(b.Key),
+			Searches:         b.DocCount,
+			AvgHits:          b.AvgHits.Value,
+			NoResults:        b.NoResults.DocCount,
+			NoResultsRate:    rate(b.NoResults.DocCount, b.DocCount),
+			Clicks:           b.Clicks.DocCount,
+			ClickThroughRate: rate(b.Clicks.DocCount, b.DocCount),
+		})
+	}
+	return q
+}
+
+// rangeQuery matches the records of the range, the date math such as now/d
+// is rounded in the configured timezone.
+func (es *elasticsearch) rangeQuery(r dashboardRange) map[string]interface{} {
+	bounds := map[string]interface{}{"gte": r.From, "lte": r.To}
+	if es.location != nil {
+		bounds["time_zone"] = es.location.String()
+	}
+	return map[string]interface{}{
+		"range": map[string]interface{}{es.fields.timestamp: bounds},
+	}
+}
+
+func newDashboard(r dashboardRange, result *dashboardResponse) *dashboard {
+	aggs := result.Aggregations
+	d := &dashboard{
+		Range: r,
+		Overview: overview{
+			Searches:    aggs.Searches.DocCount,
+			NoResults:   aggs.NoResults.DocCount,
+			Clicks:      aggs.Clicks.DocCount,
+			Conversions: aggs.Conversions.DocCount,
+		},
+		PopularSearches:   []popularSearch{},
+		NoResultsSearches: buckets(aggs.NoResultsSearches.Queries.Buckets),
+		Geo:               buckets(aggs.Geo.Buckets),
+		Intents:           []intentSearches{},
+		Latency: latency{
+			Avg: aggs.AvgLatency.Value,
+			P50: aggs.Latency.Values["50.0"],
+			P95: aggs.Latency.Values["95.0"],
+			P99: aggs.Latency.Values["99.0"],
+		},
+	}
+	o := &d.Overview
+	o.NoResultsRate = rate(o.NoResults, o.Searches)
+	o.ClickThroughRate = rate(o.Clicks, o.Searches)
+	o.ConversionRate = rate(o.Conversions, o.Searches)
+	for _, b := range aggs.Popular.Buckets {
+		d.PopularSearches = append(d.PopularSearches, popularSearch{
+			bucket:           bucket{key(b.Key), b.DocCount},
+			Clicks:           b.Clicks.DocCount,
+			ClickThroughRate: rate(b.Clicks.DocCount, b.DocCount),
+		})
+	}
+	for _, b := range aggs.Intents.Buckets {
+		d.Intents = append(d.Intents, intentSearches{
+			bucket:           bucket{key(b.Key), b.DocCount},
+			Clicks:           b.Clicks.DocCount,
+			ClickThroughRate: rate(b.Clicks.DocCount, b.DocCount),
+		})
+	}
+	return d
+}
+
+func buckets(raw []rawBucket) []bucket {
+	result := make([]bucket, 0, len(raw))
+	for _, b := range raw {
+		result = append(result, bucket{key(b.Key), b.DocCount})
+	}
+	return result
+}
+
+func rate(count, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
+
+// key returns the bucket key as a string, the numeric keys included.
+func key(k interface{}) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	raw, _ := json.Marshal(k)
+	return string(raw)
+}
+
+// record is an analytics record along with the epoch millis of its
+// timestamp, by which the records are sorted.
+type record struct {
+	ID     string          `json:"id"`
+	Millis int64           `json:"millis"`
+	Source json.RawMessage `json:"source"`
+}
+
+type records struct {
+	Records []record `json:"records"`
+}
+
+// records returns the records whose timestamp is at or after the epoch
+// millis since, the oldest first.
+func (es *elasticsearch) records(ctx context.Context, since int64, size int) (*records, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(es.analyticsIndex) + "/_search",
+		Body:   es.recordsQuery(since, size),
+	})
+	if util.IsNotFound(err) {
+		return &records{Records: []record{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID     string          `json:"_id"`
+				Source json.RawMessage `json:"_source"`
+				Sort   []json.Number   `json:"sort"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(response.Body, &result); err != nil {
+		return nil, err
+	}
+	r := &records{Records: make([]record, 0, len(result.Hits.Hits))}
+	for _, hit := range result.Hits.Hits {
+		var millis int64
+		if len(hit.Sort) > 0 {
+			millis, _ = hit.Sort[0].Int64()
+		}
+		r.Records = append(r.Records, record{ID: hit.ID, Millis: millis, Source: hit.Source})
+	}
+	return r, nil
+}
+
+func (es *elasticsearch) recordsQuery(since int64, size int) map[string]interface{} {
+	f := es.fields
+	return map[string]interface{}{
+		"size": size,
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				f.timestamp: map[string]interface{}{"gte": since, "format": "epoch_millis"},
+			},
+		},
+		"sort": []interface{}{
+			map[string]interface{}{f.timestamp: map[string]interface{}{"order": "asc"}},
+		},
+	}
+}
+
+// scrollRecords passes the records whose timestamp is in [from, to) to fn,
+// in batches of size sorted by timestamp. The scroll stops at the first error
+// returned by fn.
+func (es *elasticsearch) scrollRecords(ctx context.Context, from, to time.Time, size int, fn func([]record) error) error {
+	client := util.GetClient7()
+	f := es.fields
+	response, err := client.PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(es.analyticsIndex) + "/_search",
+		Params: url.Values{"scroll": []string{"1m"}},
+		Body: map[string]interface{}{
+			"size": size,
+			"query": map[string]interface{}{
+				"range": map[string]interface{}{
+					f.timestamp: map[string]interface{}{
+						"gte":    from.UnixNano() / int64(time.Millisecond),
+						"lt":     to.UnixNano() / int64(time.Millisecond),
+						"format": "epoch_millis",
+					},
+				},
+			},
+			"sort": []interface{}{
+				map[string]interface{}{f.timestamp: map[string]interface{}{"order": "asc"}},
+			},
+		},
+	})
+	if util.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for {
+		var page struct {
+			ScrollID string `json:"_scroll_id"`
+			Hits     struct {
+				Hits []struct {
+					ID     string          `json:"_id"`
+					Source json.RawMessage `json:"_source"`
+					Sort   []json.Number   `json:"sort"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := json.Unmarshal(response.Body, &page); err != nil {
+			return err
+		}
+		if len(page.Hits.Hits) == 0 {
+			if page.ScrollID != "" {
+				client.ClearScroll(page.ScrollID).Do(context.Background())
+			}
+			return nil
+		}
+
+		batch := make([]record, 0, len(page.Hits.Hits))
+		for _, hit := range page.Hits.Hits {
+			var millis int64
+			if len(hit.Sort) > 0 {
+				millis, _ = hit.Sort[0].Int64()
+			}
+			batch = append(batch, record{ID: hit.ID, Millis: millis, Source: hit.Source})
+		}
+		if err := fn(batch); err != nil {
+			client.ClearScroll(page.ScrollID).Do(context.Background())
+			return err
+		}
+
+		response, err = client.PerformRequest(ctx, es7.PerformRequestOptions{
+			Method: http.MethodPost,
+			Path:   "/_search/scroll",
+			Body:   map[string]interface{}{"scroll": "1m", "scroll_id": page.ScrollID},
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// backfill converts the timestamps of the existing records from the source
+// format and timezone to the configured ones.
+type backfill struct {
+	SourceFormat   string
+	SourceLocation *time.Location
+	Format         func(time.Time) string
+	DryRun         bool
+}
+
+type backfillResult struct {
+	// Converted is the number of records whose timestamp was converted.
+	Converted int64 `json:"converted"`
+	// Skipped is the number of records whose timestamp isn't in the source
+	// format, such as those already converted.
+	Skipped int64    `json:"skipped"`
+	Failed  int64    `json:"failed"`
+	Errors  []string `json:"errors,omitempty"`
+	DryRun  bool     `json:"dry_run"`
+}
+
+const (
+	backfillBatchSize = 500
+	maxBackfillErrors = 10
+)
+
+// backfillTimestamps scrolls through the records holding a timestamp and
+// rewrites the timestamps in the source format, batch by batch.
+func (es *elasticsearch) backfillTimestamps(ctx context.Context, b backfill) (*backfillResult, error) {
+	client := util.GetClient7()
+	result := &backfillResult{DryRun: b.DryRun}
+	response, err := client.PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(es.analyticsIndex) + "/_search",
+		Params: url.Values{"scroll": []string{"1m"}},
+		Body: map[string]interface{}{
+			"size":    backfillBatchSize,
+			"query":   map[string]interface{}{"exists": map[string]interface{}{"field": es.fields.timestamp}},
+			"sort":    []string{"_doc"},
+			"_source": []string{es.fields.timestamp},
+		},
+	})
+	if util.IsNotFound(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		var page struct {
+			ScrollID string `json:"_scroll_id"`
+			Hits     struct {
+				Hits []struct {
+					Index  string                     `json:"_index"`
+					ID     string                     `json:"_id"`
+					Source map[string]json.RawMessage `json:"_source"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := json.Unmarshal(response.Body, &page); err != nil {
+			return nil, err
+		}
+		if len(page.Hits.Hits) == 0 {
+			if page.ScrollID != "" {
+				client.ClearScroll(page.ScrollID).Do(context.Background())
+			}
+			return result, nil
+		}
+
+		bulk := client.Bulk()
+		for _, hit := range page.Hits.Hits {
+			var value string
+			if json.Unmarshal(hit.Source[es.fields.timestamp], &value) != nil {
+				result.Skipped++
+				continue
+			}
+			t, err := time.ParseInLocation(b.SourceFormat, value, b.SourceLocation)
+			if err != nil {
+				result.Skipped++
+				continue
+			}
+			converted := b.Format(t)
+			if converted == value {
+				result.Skipped++
+				continue
+			}
+			update := es7.NewBulkUpdateRequest().
+				Index(hit.Index).
+				Id(hit.ID).
+				Doc(map[string]interface{}{es.fields.timestamp: converted})
+			if docType := util.DocType(); docType != "" {
+				update.Type(docType)
+			}
+			bulk.Add(update)
+		}
+
+		if n := int64(bulk.NumberOfActions()); n > 0 {
+			if b.DryRun {
+				result.Converted += n
+			} else {
+				res, err := bulk.Do(ctx)
+				if err != nil {
+					client.ClearScroll(page.ScrollID).Do(context.Background())
+					return nil, err
+				}
+				failed := res.Failed()
+				result.Converted += n - int64(len(failed))
+				result.Failed += int64(len(failed))
+				for _, item := range failed {
+					if len(result.Errors) < maxBackfillErrors && item.Error != nil {
+						result.Errors = append(result.Errors, item.Id+": "+item.Error.Reason)
+					}
+				}
+			}
+		}
+
+		response, err = client.PerformRequest(ctx, es7.PerformRequestOptions{
+			Method: http.MethodPost,
+			Path:   "/_search/scroll",
+			Body:   map[string]interface{}{"scroll": "1m", "scroll_id": page.ScrollID},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type countingService struct {
+	calls    int
+	backfill backfill
+}
+
+func (s *countingService) dashboard(ctx context.Context, r dashboardRange) (*dashboard, error) {
+	s.calls++
+	return newDashboard(r, &dashboardResponse{}), nil
+}
+
+func (s *countingService) zeroClickSearches(ctx context.Context, r dashboardRange, window time.Duration) (*zeroClickSearches, error) {
+	s.calls++
+	return newZeroClickSearches(r, window, &zeroClickResponse{}), nil
+}
+
+func (s *countingService) queryLengths(ctx context.Context, r dashboardRange) (*queryLengths, error) {
+	s.calls++
+	return newQueryLengths(r, &queryLengthsResponse{}), nil
+}
+
+func (s *countingService) backfillTimestamps(ctx context.Context, b backfill) (*backfillResult, error) {
+	s.calls++
+	s.backfill = b
+	return &backfillResult{DryRun: b.DryRun}, nil
+}
+
+func (s *countingService) records(ctx context.Context, since int64, size int) (*records, error) {
+	s.calls++
+	return &records{Records: []record{}}, nil
+}
+
+func (s *countingService) scrollRecords(ctx context.Context, from, to time.Time, size int, fn func([]record) error) error {
+	s.calls++
+	return nil
+}
+
+func TestDashboard(t *testing.T) {
+	Convey("The panels are built from the aggregations", t, func() {
+		var result dashboardResponse
+		err := json.Unmarshal([]byte(`{"aggregations": {
+			"searches": {"doc_count": 200},
+			"no_results": {"doc_count": 20},
+			"clicks": {"doc_count": 50},
+			"conversions": {"doc_count": 10},
+			"popular_searches": {"buckets": [{"key": "shoes", "doc_count": 40, "clicks": {"doc_count": 10}}]},
+			"no_results_searches": {"doc_count": 20, "queries": {"buckets": [{"key": "sheos", "doc_count": 5}]}},
+			"geo": {"buckets": [{"key": "IN", "doc_count": 120}]},
+			"intents": {"buckets": [{"key": "support", "doc_count": 20, "clicks": {"doc_count": 5}}]},
+			"avg_latency": {"value": 12.5},
+			"latency": {"values": {"50.0": 10, "95.0": 30, "99.0": null}}
+		}}`), &result)
+		So(err, ShouldBeNil)
+
+		d := newDashboard(dashboardRange{From: "now-1d", To: "now", Size: 10}, &result)
+		So(d.Overview.Searches, ShouldEqual, 200)
+		So(d.Overview.NoResultsRate, ShouldEqual, 0.1)
+		So(d.Overview.ClickThroughRate, ShouldEqual, 0.25)
+		So(d.Overview.ConversionRate, ShouldEqual, 0.05)
+		So(d.PopularSearches, ShouldResemble, []popularSearch{{bucket{"shoes", 40}, 10, 0.25}})
+		So(d.NoResultsSearches, ShouldResemble, []bucket{{"sheos", 5}})
+		So(d.Geo, ShouldResemble, []bucket{{"IN", 120}})
+		So(d.Intents, ShouldResemble, []intentSearches{{bucket{"support", 20}, 5, 0.25}})
+		So(*d.Latency.Avg, ShouldEqual, 12.5)
+		So(*d.Latency.P95, ShouldEqual, 30)
+		So(d.Latency.P99, ShouldBeNil)
+	})
+
+	Convey("Without records the panels are empty", t, func() {
+		d := newDashboard(dashboardRange{}, &dashboardResponse{})
+		So(d.Overview.ClickThroughRate, ShouldEqual, 0)
+		raw, err := json.Marshal(d)
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldContainSubstring, `"popular_searches":[]`)
+		So(string(raw), ShouldContainSubstring, `"intents":[]`)
+	})
+
+	Convey("The dashboards are cached for the range", t, func() {
+		s := &countingService{}
+		a := &analytics{es: s, dashboardTTL: time.Minute}
+		get := func(target string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			a.getDashboard()(w, httptest.NewRequest(http.MethodGet, target, nil))
+			return w
+		}
+
+		So(get("/_analytics/dashboard?from=now-7d").Header().Get(cacheHeader), ShouldEqual, "MISS")
+		So(get("/_analytics/dashboard?from=now-7d").Header().Get(cacheHeader), ShouldEqual, "HIT")
+		So(s.calls, ShouldEqual, 1)
+		So(get("/_analytics/dashboard?from=now-1d").Header().Get(cacheHeader), ShouldEqual, "MISS")
+		So(s.calls, ShouldEqual, 2)
+		So(get("/_analytics/dashboard?size=1000").Code, ShouldEqual, http.StatusBadRequest)
+	})
+
+	Convey("The zero-click searches are ranked per query", t, func() {
+		var result zeroClickResponse
+		err := json.Unmarshal([]byte(`{
+			"hits": {"total": {"value": 100, "relation": "eq"}},
+			"aggregations": {
+				"zero_click": {"doc_count": 30},
+				"queries": {"buckets": [
+					{"key": "shoes", "doc_count": 40, "zero_click": {"doc_count": 20}},
+					{"key": "boots", "doc_count": 10, "zero_click": {"doc_count": 0}}
+				]}
+			}
+		}`), &result)
+		So(err, ShouldBeNil)
+
+		z := newZeroClickSearches(dashboardRange{Size: 10}, 30*time.Minute, &result)
+		So(z.Searches, ShouldEqual, 100)
+		So(z.ZeroClickRate, ShouldEqual, 0.3)
+		So(z.Window, ShouldEqual, "30m0s")
+		So(z.Queries, ShouldResemble, []zeroClickSearch{{"shoes", 40, 20, 0.5}})
+
+		// the total of es6 is a number
+		result = zeroClickResponse{}
+		So(json.Unmarshal([]byte(`{"hits": {"total": 42}}`), &result), ShouldBeNil)
+		So(newZeroClickSearches(dashboardRange{}, time.Minute, &result).Searches, ShouldEqual, 42)
+	})
+
+	Convey("The searches are broken down by the length of their query", t, func() {
+		var result queryLengthsResponse
+		err := json.Unmarshal([]byte(`{"aggregations": {"lengths": {"buckets": [
+			{"key": 1.0, "doc_count": 100, "avg_hits": {"value": 250.5}, "no_results": {"doc_count": 5}, "clicks": {"doc_count": 40}},
+			{"key": 2.0, "doc_count": 50, "avg_hits": {"value": 20}, "no_results": {"doc_count": 10}, "clicks": {"doc_count": 10}},
+			{"key": 5.0, "doc_count": 4, "avg_hits": {"value": 0}, "no_results": {"doc_count": 4}, "clicks": {"doc_count": 0}}
+		]}}}`), &result)
+		So(err, ShouldBeNil)
+
+		q := newQueryLengths(dashboardRange{Size: 2}, &result)
+		So(q.Lengths, ShouldHaveLength, 2)
+		So(q.Lengths[0].Tokens, ShouldEqual, 1)
+		So(*q.Lengths[0].AvgHits, ShouldEqual, 250.5)
+		So(q.Lengths[0].ClickThroughRate, ShouldEqual, 0.4)
+		So(q.Lengths[1].NoResultsRate, ShouldEqual, 0.2)
+
+		es := &elasticsearch{".analytics", defaultFields, time.UTC}
+		raw, err := json.Marshal(es.queryLengthsQuery(dashboardRange{From: "now-1d", To: "now", Size: 5}))
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldContainSubstring, `"field":"search_query.tokens"`)
+
+		s := &countingService{}
+		a := &analytics{es: s, dashboardTTL: time.Minute}
+		w := httptest.NewRecorder()
+		a.getQueryLengths()(w, httptest.NewRequest(http.MethodGet, "/_analytics/query_lengths", nil))
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"lengths":[]`)
+	})
+
+	Convey("The zero-click searches leave out the session window", t, func() {
+		es := &elasticsearch{".analytics", defaultFields, time.UTC}
+		raw, err := json.Marshal(es.zeroClickQuery(dashboardRange{From: "now-1d", To: "now", Size: 5}, time.Hour))
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldContainSubstring, `"lte":"now-3600s"`)
+		So(string(raw), ShouldContainSubstring, `"order":{"zero_click":"desc"}`)
+
+		s := &countingService{}
+		a := &analytics{es: s, sessionWindow: time.Minute}
+		w := httptest.NewRecorder()
+		a.getZeroClickSearches()(w, httptest.NewRequest(http.MethodGet, "/_analytics/zero_click_searches?window=1x", nil))
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		w = httptest.NewRecorder()
+		a.getZeroClickSearches()(w, httptest.NewRequest(http.MethodGet, "/_analytics/zero_click_searches?window=2h", nil))
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"window":"2h0m0s"`)
+	})
+
+	Convey("The ranges are resolved in the configured timezone", t, func() {
+		kolkata, err := time.LoadLocation("Asia/Kolkata")
+		So(err, ShouldBeNil)
+		es := &elasticsearch{".analytics", defaultFields, kolkata}
+		raw, err := json.Marshal(es.dashboardQuery(dashboardRange{From: "now/d", To: "now", Size: 5}))
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldContainSubstring, `"time_zone":"Asia/Kolkata"`)
+	})
+
+	Convey("The legacy timestamps are backfilled in the configured format", t, func() {
+		kolkata, err := time.LoadLocation("Asia/Kolkata")
+		So(err, ShouldBeNil)
+		s := &countingService{}
+		a := &analytics{es: s, timestampFormat: time.RFC3339, location: time.UTC}
+		backfill := func(target string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			a.backfillTimestamps()(w, httptest.NewRequest(http.MethodPost, target, nil))
+			return w
+		}
+
+		So(backfill("/_analytics/backfill_timestamps?source_timezone=Mars/Olympus").Code, ShouldEqual, http.StatusBadRequest)
+		So(backfill("/_analytics/backfill_timestamps?dry_run=maybe").Code, ShouldEqual, http.StatusBadRequest)
+		So(s.calls, ShouldEqual, 0)
+
+		w := backfill("/_analytics/backfill_timestamps?source_timezone=Asia/Kolkata&dry_run=true")
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"dry_run":true`)
+		b := s.backfill
+		So(b.SourceFormat, ShouldEqual, legacyTimestampFormat)
+		So(b.SourceLocation.String(), ShouldEqual, kolkata.String())
+		parsed, err := time.ParseInLocation(b.SourceFormat, "2019/03/01 10:30:00", b.SourceLocation)
+		So(err, ShouldBeNil)
+		So(b.Format(parsed), ShouldEqual, "2019-03-01T05:00:00Z")
+	})
+
+	Convey("The records are listed since the given millis, the oldest first", t, func() {
+		es := &elasticsearch{".analytics", defaultFields, time.UTC}
+		raw, err := json.Marshal(es.recordsQuery(1500000000000, 10))
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldContainSubstring, `"timestamp":{"format":"epoch_millis","gte":1500000000000}`)
+		So(string(raw), ShouldContainSubstring, `"sort":[{"timestamp":{"order":"asc"}}]`)
+
+		s := &countingService{}
+		a := &analytics{es: s}
+		list := func(target string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			a.getRecords()(w, httptest.NewRequest(http.MethodGet, target, nil))
+			return w
+		}
+		So(list("/_analytics/records?since=yesterday").Code, ShouldEqual, http.StatusBadRequest)
+		So(list("/_analytics/records?size=5000").Code, ShouldEqual, http.StatusBadRequest)
+		So(s.calls, ShouldEqual, 0)
+		w := list("/_analytics/records?since=1500000000000&size=5")
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldEqual, `{"records":[]}`)
+	})
+
+	Convey("The records are mapped explicitly", t, func() {
+		body, err := indexBody(defaultFields, defaultTimestampMapping, "")
+		So(err, ShouldBeNil)
+		var typeless struct {
+			Mappings struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"mappings"`
+		}
+		So(json.Unmarshal([]byte(body), &typeless), ShouldBeNil)
+		properties := typeless.Mappings.Properties
+		So(properties["search_query"]["type"], ShouldEqual, "keyword")
+		So(properties["search_query"]["fields"], ShouldContainKey, "tokens")
+		So(properties["location"]["type"], ShouldEqual, "geo_point")
+		So(properties["intent"]["type"], ShouldEqual, "keyword")
+		So(properties["timestamp"]["type"], ShouldEqual, "date")
+		So(properties["timestamp"]["format"], ShouldEqual, defaultTimestampMapping)
+
+		body, err = indexBody(defaultFields, defaultTimestampMapping, "_doc")
+		So(err, ShouldBeNil)
+		So(body, ShouldStartWith, `{"mappings":{"_doc":{"properties":`)
+	})
+}
@@ -0,0 +1,427 @@
+package analytics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/health"
+	"github.com/appbaseio/arc/util/objectstore"
+	"github.com/appbaseio/arc/util/parquet"
+	"github.com/appbaseio/arc/util/state"
+)
+
+const (
+	envExportURL          = "ANALYTICS_EXPORT_URL"
+	envExportFormat       = "ANALYTICS_EXPORT_FORMAT"
+	envExportInterval     = "ANALYTICS_EXPORT_INTERVAL"
+	envExportDelay        = "ANALYTICS_EXPORT_DELAY"
+	envExportSince        = "ANALYTICS_EXPORT_SINCE"
+	formatParquet         = "parquet"
+	formatNDJSON          = "ndjson"
+	defaultExportInterval = time.Hour
+	// defaultExportDelay is the time the records of a day are given to be
+	// recorded before the day is exported.
+	defaultExportDelay = 15 * time.Minute
+	// exportPartSize is the max number of records of the files of a day.
+	exportPartSize    = 50000
+	exportBatchSize   = 1000
+	exportDayLayout   = "2006-01-02"
+	exportLastDayKey  = "analytics:export:last_day"
+	exportLockKey     = "analytics:export:lock"
+	ndjsonContentType = "application/x-ndjson"
+)
+
+// archiver uploads the exported files.
+type archiver interface {
+	Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error
+}
+
+// export writes the records of each day to an object store once the day is
+// over, partitioned by day so that the lakehouse engines can prune them. The
+// last exported day is kept in the state store, so that the instances export
+// each day once and resume where they stopped after a restart.
+type export struct {
+	format   string
+	interval time.Duration
+	delay    time.Duration
+	// since is the first day exported, when no day was exported yet.
+	since   time.Time
+	archive archiver
+	state   state.Store
+	fields  recordFields
+
+	mu     sync.Mutex
+	status exportStatus
+}
+
+// exportStatus reports the runs of the export job.
+type exportStatus struct {
+	Enabled     bool   `json:"enabled"`
+	Destination string `json:"destination,omitempty"`
+	Format      string `json:"format,omitempty"`
+	Interval    string `json:"interval,omitempty"`
+	Running     bool   `json:"running"`
+	// LastDay is the last day exported by any of the instances.
+	LastDay string `json:"last_day,omitempty"`
+	// the outcome of the last run of this instance
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	LastSuccess  *time.Time `json:"last_success,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastExported int64      `json:"last_exported"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+	// the total since arc started
+	TotalExported int64 `json:"total_exported"`
+}
+
+// exportFromEnv returns the export configured by the env, nil if the records
+// aren't exported.
+func exportFromEnv(fields recordFields) (*export, error) {
+	value := os.Getenv(envExportURL)
+	if value == "" {
+		return nil, nil
+	}
+	store, err := objectstore.Open(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %v", envExportURL, err)
+	}
+
+	e := &export{
+		format:   formatParquet,
+		interval: defaultExportInterval,
+		delay:    defaultExportDelay,
+		archive:  store,
+		fields:   fields,
+	}
+	if value := os.Getenv(envExportFormat); value != "" {
+		if value != formatParquet && value != formatNDJSON {
+			return nil, fmt.Errorf(`invalid value for %s: %s, must be either "%s" or "%s"`, envExportFormat, value, formatParquet, formatNDJSON)
+		}
+		e.format = value
+	}
+	for env, d := range map[string]*time.Duration{
+		envExportInterval: &e.interval,
+		envExportDelay:    &e.delay,
+	} {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be a positive duration", env, value)
+		}
+		*d = parsed
+	}
+	if value := os.Getenv(envExportSince); value != "" {
+		since, err := time.Parse(exportDayLayout, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be a date such as 2006-01-02", envExportSince, value)
+		}
+		e.since = since
+	}
+	e.status = exportStatus{Enabled: true, Destination: store.String(), Format: e.format, Interval: e.interval.String()}
+	return e, nil
+}
+
+// every runs the export job every interval.
+func (e *export) every(es analyticsService) {
+	if e.state == nil {
+		e.state = state.Instance()
+	}
+	health.Register("analytics.export", e.health, false)
+	e.run(context.Background(), es, time.Now())
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	e.scheduleNext(time.Now())
+	for now := range ticker.C {
+		e.run(context.Background(), es, now)
+		e.scheduleNext(now)
+	}
+}
+
+func (e *export) scheduleNext(now time.Time) {
+	next := now.Add(e.interval)
+	e.mu.Lock()
+	e.status.NextRun = &next
+	e.mu.Unlock()
+}
+
+// run exports the days over since the last exported one. A single instance
+// exports at a time, the others skip their run.
+func (e *export) run(ctx context.Context, es analyticsService, now time.Time) {
+	e.mu.Lock()
+	if e.status.Running {
+		e.mu.Unlock()
+		return
+	}
+	e.status.Running = true
+	e.mu.Unlock()
+
+	var exported int64
+	acquired, err := e.state.SetNX(ctx, exportLockKey, []byte{1}, e.interval)
+	if err == nil && acquired {
+		exported, err = e.exportDays(ctx, es, now)
+		if err := e.state.Delete(ctx, exportLockKey); err != nil {
+			log.Errorln(logTag, ": unable to unlock the export :", err)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.status.Running = false
+	if err == nil && !acquired {
+		return
+	}
+	e.status.LastRun = &now
+	e.status.LastExported = exported
+	e.status.TotalExported += exported
+	if err != nil {
+		log.Errorln(logTag, ": unable to export the records :", err)
+		e.status.LastError = err.Error()
+		return
+	}
+	e.status.LastError = ""
+	e.status.LastSuccess = &now
+}
+
+// exportDays exports the days, one by one, from the one following the last
+// exported day to the last day over for at least the delay.
+func (e *export) exportDays(ctx context.Context, es analyticsService, now time.Time) (int64, error) {
+	day, err := e.nextDay(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+	var exported int64
+	for ; !day.AddDate(0, 0, 1).After(now.Add(-e.delay)); day = day.AddDate(0, 0, 1) {
+		n, err := e.exportDay(ctx, es, day)
+		exported += n
+		if err != nil {
+			return exported, fmt.Errorf("error exporting %s: %v", day.Format(exportDayLayout), err)
+		}
+		if err := e.state.Set(ctx, exportLastDayKey, []byte(day.Format(exportDayLayout)), 0); err != nil {
+			return exported, err
+		}
+		log.Println(logTag, ": exported", n, "records of", day.Format(exportDayLayout), "to", e.archive)
+	}
+	return exported, nil
+}
+
+// nextDay returns the day following the last exported one, the first day to
+// export if none was exported yet: either since or the day before now.
+func (e *export) nextDay(ctx context.Context, now time.Time) (time.Time, error) {
+	last, err := e.state.Get(ctx, exportLastDayKey)
+	if err == state.ErrNotFound {
+		if !e.since.IsZero() {
+			return e.since, nil
+		}
+		y, m, d := now.UTC().AddDate(0, 0, -1).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	day, err := time.Parse(exportDayLayout, string(last))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid last exported day %q: %v", last, err)
+	}
+	return day.AddDate(0, 0, 1), nil
+}
+
+// exportDay uploads the records of the day in parts of at most
+// exportPartSize records. The parts are named after the day and their
+// position, so that a day exported again replaces its files.
+func (e *export) exportDay(ctx context.Context, es analyticsService, day time.Time) (int64, error) {
+	var exported int64
+	var part []record
+	n := 0
+	flush := func() error {
+		key, body, contentType, contentEncoding, err := e.encode(day, n, part)
+		if err != nil {
+			return err
+		}
+		if err := e.archive.Put(ctx, key, body, contentType, contentEncoding); err != nil {
+			return err
+		}
+		exported += int64(len(part))
+		part = part[:0]
+		n++
+		return nil
+	}
+
+	err := es.scrollRecords(ctx, day, day.AddDate(0, 0, 1), exportBatchSize, func(batch []record) error {
+		for _, r := range batch {
+			part = append(part, r)
+			if len(part) == exportPartSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err == nil && len(part) > 0 {
+		err = flush()
+	}
+	return exported, err
+}
+
+// encode returns the key and the body of a part of a day, e.g.
+// "dt=2019-01-02/part-00000.parquet".
+func (e *export) encode(day time.Time, n int, records []record) (key string, body []byte, contentType, contentEncoding string, err error) {
+	key = fmt.Sprintf("dt=%s/part-%05d", day.Format(exportDayLayout), n)
+	if e.format == formatNDJSON {
+		body, err = ndjsonOf(records)
+		return key + ".ndjson.gz", body, ndjsonContentType, "gzip", err
+	}
+	body, err = e.parquetOf(records)
+	return key + ".parquet", body, parquet.ContentType, "", err
+}
+
+// ndjsonOf returns the gzipped ndjson of the records, as listed by the
+// records endpoint.
+func ndjsonOf(records []record) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(zw)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return nil, fmt.Errorf("invalid record %s: %v", r.ID, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parquetColumns returns the columns of the parquet files: the id and the
+// timestamp of the records, their known fields and their whole source, so
+// that the custom fields can be queried too.
+func (e *export) parquetColumns() []parquet.Column {
+	f := e.fields
+	return []parquet.Column{
+		{Name: "id", Type: parquet.String},
+		{Name: f.timestamp, Type: parquet.TimestampMillis},
+		{Name: f.query, Type: parquet.String},
+		{Name: f.hits, Type: parquet.Int64},
+		{Name: f.click, Type: parquet.Boolean},
+		{Name: f.conversion, Type: parquet.Boolean},
+		{Name: f.country, Type: parquet.String},
+		{Name: f.took, Type: parquet.Double},
+		{Name: f.location, Type: parquet.String},
+		{Name: f.intent, Type: parquet.String},
+		{Name: "source", Type: parquet.String},
+	}
+}
+
+func (e *export) parquetOf(records []record) ([]byte, error) {
+	columns := e.parquetColumns()
+	rows := make([][]interface{}, len(records))
+	for i, r := range records {
+		var source map[string]interface{}
+		dec := json.NewDecoder(bytes.NewReader(r.Source))
+		dec.UseNumber()
+		if err := dec.Decode(&source); err != nil {
+			return nil, fmt.Errorf("invalid record %s: %v", r.ID, err)
+		}
+		var compact bytes.Buffer
+		json.Compact(&compact, r.Source)
+
+		row := make([]interface{}, len(columns))
+		row[0], row[1], row[len(columns)-1] = r.ID, r.Millis, compact.String()
+		for j := 2; j < len(columns)-1; j++ {
+			row[j] = columnValue(columns[j].Type, source[columns[j].Name])
+		}
+		rows[i] = row
+	}
+
+	var buf bytes.Buffer
+	if err := parquet.Write(&buf, columns, rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// columnValue converts the value of a field to the type of its column, the
+// values that can't be converted are null. The fields of the other types,
+// such as the geo points, are written as json.
+func columnValue(t parquet.Type, v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch t {
+	case parquet.String:
+		if s, ok := v.(string); ok {
+			return s
+		}
+		raw, _ := json.Marshal(v)
+		return string(raw)
+	case parquet.Int64:
+		switch v := v.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				return n
+			}
+			if f, err := v.Float64(); err == nil {
+				return int64(f)
+			}
+		case string:
+			if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+				return n
+			}
+		}
+	case parquet.Double:
+		switch v := v.(type) {
+		case json.Number:
+			if f, err := v.Float64(); err == nil {
+				return f
+			}
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f
+			}
+		}
+	case parquet.Boolean:
+		switch v := v.(type) {
+		case bool:
+			return v
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b
+			}
+		}
+	}
+	return nil
+}
+
+// report returns the status of the export job.
+func (e *export) report(ctx context.Context) exportStatus {
+	e.mu.Lock()
+	status := e.status
+	e.mu.Unlock()
+	if e.state != nil {
+		if last, err := e.state.Get(ctx, exportLastDayKey); err == nil {
+			status.LastDay = string(last)
+		}
+	}
+	return status
+}
+
+// health reports the error of the last run of the export job.
+func (e *export) health() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.status.LastError != "" {
+		return fmt.Errorf("the last run of the analytics export failed: %s", e.status.LastError)
+	}
+	return nil
+}
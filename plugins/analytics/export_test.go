@@ -0,0 +1,222 @@
+package analytics
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/util/parquet"
+	"github.com/appbaseio/arc/util/state"
+)
+
+// memoryRecords keeps the records in memory, sorted by timestamp.
+type memoryRecords struct {
+	countingService
+	stored []record
+}
+
+func (m *memoryRecords) scrollRecords(ctx context.Context, from, to time.Time, size int, fn func([]record) error) error {
+	var batch []record
+	for _, r := range m.stored {
+		t := time.Unix(0, r.Millis*int64(time.Millisecond))
+		if t.Before(from) || !t.Before(to) {
+			continue
+		}
+		batch = append(batch, r)
+		if len(batch) == size {
+			if err := fn(batch); err != nil {
+				return err
+			}
+			batch = nil
+		}
+	}
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+	return nil
+}
+
+type memoryArchive struct {
+	objects map[string][]byte
+	fail    bool
+}
+
+func (m *memoryArchive) Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error {
+	if m.fail {
+		return errors.New("AccessDenied")
+	}
+	m.objects[key] = body
+	return nil
+}
+
+func TestExport(t *testing.T) {
+	newRecord := func(id, timestamp string) record {
+		ts, _ := time.Parse(time.RFC3339, timestamp)
+		source := fmt.Sprintf(`{"search_query": "shoes", "total_hits": 12, "click": "true", "took": 3.5, "location": {"lat": 1, "lon": 2}, "timestamp": %q}`, timestamp)
+		return record{ID: id, Millis: ts.UnixNano() / int64(time.Millisecond), Source: []byte(source)}
+	}
+	es := &memoryRecords{stored: []record{
+		newRecord("a", "2019-01-01T08:00:00Z"),
+		newRecord("b", "2019-01-01T12:00:00Z"),
+		newRecord("c", "2019-01-02T23:59:59Z"),
+		newRecord("d", "2019-01-03T00:10:00Z"),
+	}}
+	since, _ := time.Parse(exportDayLayout, "2019-01-01")
+	now, _ := time.Parse(time.RFC3339, "2019-01-03T00:30:00Z")
+	newExport := func(format string) (*export, *memoryArchive) {
+		archive := &memoryArchive{objects: map[string][]byte{}}
+		return &export{
+			format:   format,
+			interval: time.Hour,
+			delay:    15 * time.Minute,
+			since:    since,
+			archive:  archive,
+			state:    state.NewMemoryStore(),
+			fields:   defaultFields,
+			status:   exportStatus{Enabled: true},
+		}, archive
+	}
+	ctx := context.Background()
+
+	Convey("The days over are exported once, partitioned by day", t, func() {
+		e, archive := newExport(formatNDJSON)
+		e.run(ctx, es, now)
+		So(archive.objects, ShouldHaveLength, 2)
+		So(archive.objects, ShouldContainKey, "dt=2019-01-01/part-00000.ndjson.gz")
+		So(archive.objects, ShouldContainKey, "dt=2019-01-02/part-00000.ndjson.gz")
+
+		zr, err := gzip.NewReader(bytes.NewReader(archive.objects["dt=2019-01-01/part-00000.ndjson.gz"]))
+		So(err, ShouldBeNil)
+		body, _ := ioutil.ReadAll(zr)
+		lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+		So(lines, ShouldHaveLength, 2)
+		var r record
+		So(json.Unmarshal([]byte(lines[0]), &r), ShouldBeNil)
+		So(r.ID, ShouldEqual, "a")
+
+		status := e.report(ctx)
+		So(status.LastDay, ShouldEqual, "2019-01-02")
+		So(status.LastExported, ShouldEqual, 3)
+		So(status.LastError, ShouldBeEmpty)
+
+		// the exported days aren't exported again
+		archive.objects = map[string][]byte{}
+		e.run(ctx, es, now.Add(time.Hour))
+		So(archive.objects, ShouldBeEmpty)
+		So(e.report(ctx).TotalExported, ShouldEqual, 3)
+	})
+
+	Convey("Without a start day the export starts with the day before", t, func() {
+		e, archive := newExport(formatParquet)
+		e.since = time.Time{}
+		e.run(ctx, es, now)
+		So(archive.objects, ShouldHaveLength, 1)
+		file := archive.objects["dt=2019-01-02/part-00000.parquet"]
+		So(string(file[:4]), ShouldEqual, "PAR1")
+		So(string(file[len(file)-4:]), ShouldEqual, "PAR1")
+	})
+
+	Convey("A failed upload is exported again by the next run", t, func() {
+		e, archive := newExport(formatNDJSON)
+		archive.fail = true
+		e.run(ctx, es, now)
+		status := e.report(ctx)
+		So(status.LastError, ShouldContainSubstring, "AccessDenied")
+		So(status.LastDay, ShouldBeEmpty)
+		So(e.health(), ShouldNotBeNil)
+
+		archive.fail = false
+		e.run(ctx, es, now)
+		So(archive.objects, ShouldHaveLength, 2)
+		So(e.health(), ShouldBeNil)
+	})
+
+	Convey("The run is skipped while another instance exports", t, func() {
+		e, archive := newExport(formatNDJSON)
+		_, err := e.state.SetNX(ctx, exportLockKey, []byte{1}, time.Hour)
+		So(err, ShouldBeNil)
+		e.run(ctx, es, now)
+		So(archive.objects, ShouldBeEmpty)
+		So(e.report(ctx).LastRun, ShouldBeNil)
+	})
+}
+
+func TestParquetOf(t *testing.T) {
+	Convey("The fields are converted to the types of their columns", t, func() {
+		So(columnValue(parquet.Int64, json.Number("12")), ShouldEqual, int64(12))
+		So(columnValue(parquet.Int64, "12"), ShouldEqual, int64(12))
+		So(columnValue(parquet.Int64, "twelve"), ShouldBeNil)
+		So(columnValue(parquet.Double, json.Number("3.5")), ShouldEqual, 3.5)
+		So(columnValue(parquet.Boolean, "true"), ShouldEqual, true)
+		So(columnValue(parquet.Boolean, json.Number("1")), ShouldBeNil)
+		So(columnValue(parquet.String, map[string]interface{}{"lat": json.Number("1")}), ShouldEqual, `{"lat":1}`)
+		So(columnValue(parquet.String, nil), ShouldBeNil)
+	})
+
+	Convey("The invalid records aren't exported", t, func() {
+		e := &export{fields: defaultFields}
+		_, err := e.parquetOf([]record{{ID: "a", Source: []byte(`{`)}})
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestExportFromEnv(t *testing.T) {
+	envs := []string{envExportURL, envExportFormat, envExportInterval, envExportDelay, envExportSince,
+		"OBJECT_STORE_ACCESS_KEY_ID", "OBJECT_STORE_SECRET_ACCESS_KEY"}
+	reset := func() {
+		for _, env := range envs {
+			os.Unsetenv(env)
+		}
+		os.Setenv("OBJECT_STORE_ACCESS_KEY_ID", "key")
+		os.Setenv("OBJECT_STORE_SECRET_ACCESS_KEY", "secret")
+	}
+	defer func() {
+		for _, env := range envs {
+			os.Unsetenv(env)
+		}
+	}()
+
+	Convey("The records aren't exported without a url", t, func() {
+		reset()
+		e, err := exportFromEnv(defaultFields)
+		So(err, ShouldBeNil)
+		So(e, ShouldBeNil)
+	})
+
+	Convey("The defaults are used for the unset env vars", t, func() {
+		reset()
+		os.Setenv(envExportURL, "s3://lake/analytics")
+		e, err := exportFromEnv(defaultFields)
+		So(err, ShouldBeNil)
+		So(e.format, ShouldEqual, formatParquet)
+		So(e.interval, ShouldEqual, defaultExportInterval)
+		So(e.delay, ShouldEqual, defaultExportDelay)
+		So(e.since.IsZero(), ShouldBeTrue)
+	})
+
+	Convey("The invalid values are rejected", t, func() {
+		for env, value := range map[string]string{
+			envExportURL:      "ftp://lake",
+			envExportFormat:   "csv",
+			envExportInterval: "-1h",
+			envExportDelay:    "soon",
+			envExportSince:    "01/02/2019",
+		} {
+			reset()
+			os.Setenv(envExportURL, "gs://lake")
+			os.Setenv(env, value)
+			_, err := exportFromEnv(defaultFields)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
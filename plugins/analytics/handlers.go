@@ -0,0 +1,259 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/state"
+)
+
+const (
+	// cacheHeader reports whether the response was served from the cache.
+	cacheHeader = "X-Arc-Cache"
+	// dashboardKey prefixes the keys of the cached dashboards in the state.
+	dashboardKey    = "analytics:dashboard:"
+	zeroClickKey    = "analytics:zero_click:"
+	queryLengthsKey = "analytics:query_lengths:"
+	defaultFrom     = "now-30d"
+	defaultTo       = "now"
+	defaultListSize = 10
+	maxListSize     = 100
+	// defaultRecordsSize and maxRecordsSize bound the records listed at once.
+	defaultRecordsSize = 100
+	maxRecordsSize     = 1000
+)
+
+// getDashboard returns all the panels of the dashboard for the range of the
+// from and to query params. The dashboards are cached in the shared state
+// for a short while, so that the refreshes of the dashboards by the admins
+// of all the instances cost a single search.
+func (a *analytics) getDashboard() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r, err := parseRange(req)
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		key := fmt.Sprintf("%s%s|%s|%d", dashboardKey, r.From, r.To, r.Size)
+		a.cached(w, req, key, "analytics dashboard", func(ctx context.Context) (interface{}, error) {
+			return a.es.dashboard(ctx, r)
+		})
+	}
+}
+
+// getZeroClickSearches returns the queries whose searches got results but
+// no click within the session window, ranked by their number of zero-click
+// searches. The searches more recent than the window are left out since
+// they may still be clicked.
+func (a *analytics) getZeroClickSearches() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r, err := parseRange(req)
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		window := a.sessionWindow
+		if value := req.URL.Query().Get("window"); value != "" {
+			window, err = time.ParseDuration(value)
+			if err != nil || window < 0 {
+				util.WriteBackError(w, `"window" must be a positive duration, e.g. 30m`, http.StatusBadRequest)
+				return
+			}
+		}
+		key := fmt.Sprintf("%s%s|%s|%d|%s", zeroClickKey, r.From, r.To, r.Size, window)
+		a.cached(w, req, key, "zero-click searches", func(ctx context.Context) (interface{}, error) {
+			return a.es.zeroClickSearches(ctx, r, window)
+		})
+	}
+}
+
+// getQueryLengths returns the hits and the click-through rate of the
+// searches per number of tokens of their query.
+func (a *analytics) getQueryLengths() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r, err := parseRange(req)
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		key := fmt.Sprintf("%s%s|%s|%d", queryLengthsKey, r.From, r.To, r.Size)
+		a.cached(w, req, key, "query lengths", func(ctx context.Context) (interface{}, error) {
+			return a.es.queryLengths(ctx, r)
+		})
+	}
+}
+
+// getRecords lists the records recorded at or after the epoch millis of the
+// since query param, an hour ago by default, the oldest first. The clients
+// tailing the records pass the millis of the last record they got as since
+// and skip the ones they already got.
+func (a *analytics) getRecords() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		since := time.Now().Add(-time.Hour).UnixNano() / int64(time.Millisecond)
+		if value := query.Get("since"); value != "" {
+			millis, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || millis < 0 {
+				util.WriteBackError(w, `"since" must be the epoch millis of a time`, http.StatusBadRequest)
+				return
+			}
+			since = millis
+		}
+		size := defaultRecordsSize
+		if value := query.Get("size"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxRecordsSize {
+				util.WriteBackError(w, fmt.Sprintf(`"size" must be an integer between 1 and %d`, maxRecordsSize), http.StatusBadRequest)
+				return
+			}
+			size = n
+		}
+
+		r, err := a.es.records(req.Context(), since, size)
+		if err != nil {
+			msg := "error fetching the analytics records"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		raw, err := json.Marshal(r)
+		if err != nil {
+			msg := "error encoding the analytics records"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// backfillTimestamps converts the timestamps of the existing records from
+// the format and the timezone given by the source_format and the
+// source_timezone query params, the legacy ones by default, to the
+// configured ones. The records are only counted if dry_run is set.
+func (a *analytics) backfillTimestamps() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		b := backfill{
+			SourceFormat:   legacyTimestampFormat,
+			SourceLocation: time.Local,
+			Format:         a.formatTimestamp,
+		}
+		if value := query.Get("source_format"); value != "" {
+			b.SourceFormat = value
+		}
+		if value := query.Get("source_timezone"); value != "" {
+			location, err := time.LoadLocation(value)
+			if err != nil {
+				util.WriteBackError(w, fmt.Sprintf(`invalid value "%s" for query param "source_timezone"`, value), http.StatusBadRequest)
+				return
+			}
+			b.SourceLocation = location
+		}
+		if value := query.Get("dry_run"); value != "" {
+			dryRun, err := strconv.ParseBool(value)
+			if err != nil {
+				util.WriteBackError(w, fmt.Sprintf(`invalid value "%s" for query param "dry_run"`, value), http.StatusBadRequest)
+				return
+			}
+			b.DryRun = dryRun
+		}
+
+		result, err := a.es.backfillTimestamps(req.Context(), b)
+		if err != nil {
+			msg := "error backfilling the analytics timestamps"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		log.Println(logTag, ": converted the timestamps of", result.Converted, "analytics records, dry run:", result.DryRun)
+		raw, err := json.Marshal(result)
+		if err != nil {
+			util.WriteBackError(w, "error encoding the backfill result", http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// cached writes back the response cached against the key, or the response
+// aggregated by fetch which is then cached for the dashboard ttl.
+func (a *analytics) cached(w http.ResponseWriter, req *http.Request, key, what string,
+	fetch func(ctx context.Context) (interface{}, error)) {
+	ctx := req.Context()
+	if a.dashboardTTL > 0 {
+		if raw, err := state.Instance().Get(ctx, key); err == nil {
+			w.Header().Set(cacheHeader, "HIT")
+			util.WriteBackRaw(w, raw, http.StatusOK)
+			return
+		}
+	}
+
+	response, err := fetch(ctx)
+	if err != nil {
+		msg := fmt.Sprintf("error aggregating the %s", what)
+		log.Errorln(logTag, ":", msg, ":", err)
+		util.WriteBackError(w, msg, http.StatusInternalServerError)
+		return
+	}
+	raw, err := json.Marshal(response)
+	if err != nil {
+		msg := fmt.Sprintf("error encoding the %s", what)
+		log.Errorln(logTag, ":", msg, ":", err)
+		util.WriteBackError(w, msg, http.StatusInternalServerError)
+		return
+	}
+	if a.dashboardTTL > 0 {
+		if err := state.Instance().Set(ctx, key, raw, a.dashboardTTL); err != nil {
+			log.Errorln(logTag, ": unable to cache the", what, ":", err)
+		}
+	}
+	w.Header().Set(cacheHeader, "MISS")
+	util.WriteBackRaw(w, raw, http.StatusOK)
+}
+
+// parseRange parses the range of the dashboard from the query params.
+func parseRange(req *http.Request) (dashboardRange, error) {
+	query := req.URL.Query()
+	r := dashboardRange{From: defaultFrom, To: defaultTo, Size: defaultListSize}
+	if from := query.Get("from"); from != "" {
+		r.From = from
+	}
+	if to := query.Get("to"); to != "" {
+		r.To = to
+	}
+	if value := query.Get("size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxListSize {
+			return r, fmt.Errorf(`"size" must be an integer between 1 and %d`, maxListSize)
+		}
+		r.Size = size
+	}
+	return r, nil
+}
+
+// getExport reports the status of the export of the records.
+func (a *analytics) getExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		status := exportStatus{}
+		if a.export != nil {
+			status = a.export.report(req.Context())
+		}
+
+		raw, err := json.Marshal(status)
+		if err != nil {
+			msg := "error encoding the analytics export status"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
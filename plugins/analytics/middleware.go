@@ -0,0 +1,51 @@
+package analytics
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/plugins/usage"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The analytics span the searches of all the indices, hence the credentials
+// must be able to access the analytics category, which is only granted to the
+// admin users by default. The users granted it can read the analytics, e.g.
+// the ops users, without being able to manage the users or permissions.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classify.Category(category.Analytics),
+		classifyIndices,
+		logs.Recorder(),
+		classify.Op(),
+		usage.Recorder(),
+		auth.BasicAuth(),
+		validate.Operation(),
+		validate.Category(),
+	}
+}
+
+func classifyIndices(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := index.NewContext(req.Context(), []string{})
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through to the APIs rewriting the
+// analytics.
+var isAdmin = validate.Admin("rewrite the analytics")
@@ -0,0 +1,56 @@
+package analytics
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (a *analytics) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get analytics dashboard",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_analytics/dashboard",
+			HandlerFunc: middleware(a.getDashboard()),
+			Description: "Returns the panels of the analytics dashboard: the overview, the popular and the no results searches, the geo distribution, the intents and the latency",
+		},
+		{
+			Name:        "Get zero-click searches",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_analytics/zero_click_searches",
+			HandlerFunc: middleware(a.getZeroClickSearches()),
+			Description: "Returns the queries whose searches got results but no click within the session window",
+		},
+		{
+			Name:        "Get query lengths",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_analytics/query_lengths",
+			HandlerFunc: middleware(a.getQueryLengths()),
+			Description: "Returns the hits and the click-through rate of the searches per number of tokens of their query",
+		},
+		{
+			Name:        "Get analytics records",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_analytics/records",
+			HandlerFunc: middleware(a.getRecords()),
+			Description: "Returns the analytics records recorded since the epoch millis of ?since, the oldest first",
+		},
+		{
+			Name:        "Backfill analytics timestamps",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_analytics/backfill_timestamps",
+			HandlerFunc: middleware(isAdmin(a.backfillTimestamps())),
+			Description: "Converts the timestamps of the existing analytics records to the configured format and timezone",
+		},
+		{
+			Name:        "Get analytics export",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_analytics/export",
+			HandlerFunc: middleware(a.getExport()),
+			Description: "Returns the status of the export of the analytics records to an object store",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,15 @@
+package analytics
+
+import (
+	"context"
+	"time"
+)
+
+type analyticsService interface {
+	dashboard(ctx context.Context, r dashboardRange) (*dashboard, error)
+	zeroClickSearches(ctx context.Context, r dashboardRange, window time.Duration) (*zeroClickSearches, error)
+	queryLengths(ctx context.Context, r dashboardRange) (*queryLengths, error)
+	backfillTimestamps(ctx context.Context, b backfill) (*backfillResult, error)
+	records(ctx context.Context, since int64, size int) (*records, error)
+	scrollRecords(ctx context.Context, from, to time.Time, size int, fn func([]record) error) error
+}
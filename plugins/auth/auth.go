@@ -5,6 +5,7 @@ import (
 	"crypto/rsa"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"sync"
 
 	log "github.com/sirupsen/logrus"
@@ -13,6 +14,9 @@ import (
 	"github.com/appbaseio/arc/model/credential"
 	"github.com/appbaseio/arc/plugins"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/embedded"
+	"github.com/appbaseio/arc/util/state"
+	"github.com/appbaseio/arc/util/storage"
 	"github.com/dgrijalva/jwt-go"
 )
 
@@ -27,8 +31,11 @@ const (
 	defaultPublicKeyEsIndex   = ".publickey"
 	envJwtRsaPublicKeyLoc     = "JWT_RSA_PUBLIC_KEY_LOC"
 	envJwtRoleKey             = "JWT_ROLE_KEY"
+	envAuthFailureThreshold   = "AUTH_FAILURE_THRESHOLD"
+	defaultFailureThreshold   = 5
 	settings                  = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
 	publicKeyDocID            = "_public_key"
+	credentialsChannel        = "auth.credentials"
 )
 
 var (
@@ -43,6 +50,14 @@ type Auth struct {
 	jwtRsaPublicKey *rsa.PublicKey
 	jwtRoleKey      string
 	es              authService
+
+	// failureThreshold is the number of failed authentications of a client
+	// within a minute that are notified, zero disables the notification.
+	failureThreshold int64
+
+	// changes broadcasts the modified credentials to the other instances
+	// when the state isn't distributed, nil otherwise.
+	changes *changes
 }
 
 // Instance returns the singleton instance of the auth plugin. Instance
@@ -78,18 +93,62 @@ func (a *Auth) InitFunc() error {
 	if publicKeyIndex == "" {
 		publicKeyIndex = defaultPublicKeyEsIndex
 	}
-	var err error
-
+	a.failureThreshold = defaultFailureThreshold
+	if value := os.Getenv(envAuthFailureThreshold); value != "" {
+		threshold, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			log.Errorln(logTag, ": invalid value for", envAuthFailureThreshold, ":", err)
+		} else {
+			a.failureThreshold = threshold
+		}
+	}
 	// initialize the dao
-	a.es, err = initPlugin(userIndex, permissionIndex)
+	es, err := initPlugin(userIndex, permissionIndex)
+	if err != nil {
+		return err
+	}
+	a.es = es
+
+	// the credentials are read from the database of the storage backend
+	// if any, the embedded store is then of no use
+	docs, err := storage.Instance()
 	if err != nil {
 		return err
 	}
+	if docs != nil {
+		a.es = &documents{docs}
+	} else if store := embedded.Instance(); store != nil {
+		// serve the credentials from the embedded store while es is unreachable
+		f := newFallback(es, store)
+		go f.syncEvery()
+		a.es = f
+	}
+
+	// evict the credentials modified via the other arc instances
+	_, err = state.Instance().Subscribe(credentialsChannel, func(username []byte) {
+		a.removeCredentialFromCache(string(username))
+	})
+	if err != nil {
+		return err
+	}
+	if !state.Distributed() {
+		c, err := newChanges(context.Background())
+		switch {
+		case err != nil:
+			log.Errorln(logTag, ": unable to set up the credential changes, the caches of the other instances won't be invalidated:", err)
+		case c != nil:
+			a.changes = c
+			go c.pollEvery(a.removeCredentialFromCache)
+		}
+	}
 
 	// Create public key index
 	_, err = a.es.createIndex(publicKeyIndex, settings)
 	if err != nil {
-		return err
+		if !embedded.Enabled() || !util.IsUnreachable(err) {
+			return err
+		}
+		log.Warnln(logTag, ": elasticsearch is unreachable, starting with the embedded store:", err)
 	}
 
 	// Populate public key from ES
@@ -0,0 +1,198 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	es7 "github.com/olivere/elastic/v7"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/migration"
+)
+
+const (
+	envChangesEsIndex       = "AUTH_CHANGES_ES_INDEX"
+	defaultChangesEsIndex   = ".auth_changes"
+	envChangesPollInterval  = "AUTH_CHANGES_POLL_INTERVAL"
+	defaultChangesPollEvery = 5 * time.Second
+	// changesOverlap is the time the polls overlap by, so that the changes
+	// recorded by the instances with a lagging clock aren't missed.
+	changesOverlap = 30 * time.Second
+	// changesRetention is the time the changes are kept for.
+	changesRetention  = time.Hour
+	maxChangesPerPoll = 1000
+	changesVersion    = 1
+)
+
+// change records that a credential was modified.
+type change struct {
+	Username  string `json:"username"`
+	ChangedAt int64  `json:"changed_at"`
+}
+
+// changes is a feed of the credentials modified, recorded in elasticsearch
+// and polled by the arc instances to evict the modified credentials from
+// their caches. It is used when the state isn't shared via redis, which
+// otherwise broadcasts the changes.
+type changes struct {
+	index    string
+	interval time.Duration
+	// since is the time of the last poll in unix milliseconds.
+	since int64
+}
+
+// newChanges creates the index of the feed, the feed is disabled, i.e. nil,
+// if AUTH_CHANGES_POLL_INTERVAL is set to 0.
+func newChanges(ctx context.Context) (*changes, error) {
+	c := &changes{
+		index:    os.Getenv(envChangesEsIndex),
+		interval: defaultChangesPollEvery,
+		since:    time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	if c.index == "" {
+		c.index = defaultChangesEsIndex
+	}
+	if value := os.Getenv(envChangesPollInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			log.Errorln(logTag, ": invalid value for", envChangesPollInterval, ":", value)
+		} else {
+			c.interval = d
+		}
+	}
+	if c.interval == 0 {
+		return nil, nil
+	}
+
+	body, err := changesBody(util.DocType())
+	if err != nil {
+		return nil, err
+	}
+	_, err = migration.Ensure(ctx, migration.Index{
+		Name:    c.index,
+		Version: changesVersion,
+		Body:    body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func changesBody(docType string) (string, error) {
+	mappings := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"username":   map[string]interface{}{"type": "keyword"},
+			"changed_at": map[string]interface{}{"type": "date", "format": "epoch_millis"},
+		},
+	}
+	body := map[string]interface{}{"mappings": mappings}
+	if docType != "" {
+		body["mappings"] = map[string]interface{}{docType: mappings}
+	}
+	raw, err := json.Marshal(body)
+	return string(raw), err
+}
+
+// publish records the change of the credential.
+func (c *changes) publish(ctx context.Context, username string) error {
+	_, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(c.index) + "/_doc",
+		Body:   change{Username: username, ChangedAt: time.Now().UnixNano() / int64(time.Millisecond)},
+	})
+	return err
+}
+
+// poll returns the credentials changed since the last poll.
+func (c *changes) poll(ctx context.Context) ([]string, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(c.index) + "/_search",
+		Body:   changesQuery(c.since - int64(changesOverlap/time.Millisecond)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				Source change `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(response.Body, &result); err != nil {
+		return nil, err
+	}
+	c.since = now
+
+	seen := make(map[string]bool)
+	var usernames []string
+	for _, hit := range result.Hits.Hits {
+		if username := hit.Source.Username; !seen[username] {
+			seen[username] = true
+			usernames = append(usernames, username)
+		}
+	}
+	return usernames, nil
+}
+
+func changesQuery(since int64) map[string]interface{} {
+	return map[string]interface{}{
+		"size": maxChangesPerPoll,
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"changed_at": map[string]interface{}{"gte": since},
+			},
+		},
+		"sort": []interface{}{map[string]interface{}{"changed_at": "asc"}},
+	}
+}
+
+// prune deletes the changes older than the retention.
+func (c *changes) prune(ctx context.Context) error {
+	before := time.Now().Add(-changesRetention).UnixNano() / int64(time.Millisecond)
+	_, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(c.index) + "/_delete_by_query",
+		Body: map[string]interface{}{
+			"query": map[string]interface{}{
+				"range": map[string]interface{}{
+					"changed_at": map[string]interface{}{"lt": before},
+				},
+			},
+		},
+	})
+	return err
+}
+
+// pollEvery evicts the credentials changed via the other instances from
+// the cache, at every poll interval.
+func (c *changes) pollEvery(evict func(username string)) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	lastPrune := time.Now()
+	for range ticker.C {
+		ctx := context.Background()
+		usernames, err := c.poll(ctx)
+		if err != nil {
+			log.Errorln(logTag, ": unable to poll the credential changes:", err)
+			continue
+		}
+		for _, username := range usernames {
+			evict(username)
+		}
+		if time.Since(lastPrune) > changesRetention {
+			if err := c.prune(ctx); err != nil {
+				log.Errorln(logTag, ": unable to prune the credential changes:", err)
+			}
+			lastPrune = time.Now()
+		}
+	}
+}
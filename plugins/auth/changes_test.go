@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/category"
+)
+
+func TestChanges(t *testing.T) {
+	Convey("The credentials modified via the apis are invalidated", t, func() {
+		req := httptest.NewRequest(http.MethodPatch, "/v1/_user", nil)
+		req.SetBasicAuth("foo", "bar")
+		So(modifiedCredential(req, category.User), ShouldEqual, "foo")
+		So(modifiedCredential(req, category.Docs), ShouldBeEmpty)
+
+		req = mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/_permission/baz", nil), map[string]string{"username": "baz"})
+		req.SetBasicAuth("foo", "bar")
+		So(modifiedCredential(req, category.Permission), ShouldEqual, "baz")
+
+		req = httptest.NewRequest(http.MethodPost, "/_permission", nil)
+		req.SetBasicAuth("foo", "bar")
+		So(modifiedCredential(req, category.Permission), ShouldBeEmpty)
+
+		req = httptest.NewRequest(http.MethodPost, "/_reindex/products", nil)
+		req.SetBasicAuth("foo", "bar")
+		So(modifiedCredential(req, category.User), ShouldBeEmpty)
+	})
+
+	Convey("The changes are polled with an overlap", t, func() {
+		query := changesQuery(1000)
+		So(query["query"], ShouldResemble, map[string]interface{}{
+			"range": map[string]interface{}{
+				"changed_at": map[string]interface{}{"gte": int64(1000)},
+			},
+		})
+
+		body, err := changesBody("_doc")
+		So(err, ShouldBeNil)
+		So(body, ShouldContainSubstring, `{"mappings":{"_doc":{"properties":`)
+	})
+}
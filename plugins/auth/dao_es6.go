@@ -12,6 +12,7 @@ import (
 	"github.com/appbaseio/arc/model/permission"
 	"github.com/appbaseio/arc/model/user"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/migration"
 	es6 "gopkg.in/olivere/elastic.v6"
 )
 
@@ -51,7 +52,7 @@ func (es *elasticsearch) getCredentialEs6(ctx context.Context, username string)
 	// there should be either 0 or 1 hit
 	var obj credential.AuthCredential
 	for _, hit := range response.Hits.Hits {
-		if hit.Index == es.userIndex {
+		if migration.IsVersionOf(hit.Index, es.userIndex) {
 			var u user.User
 			if hit.Source != nil {
 				err := json.Unmarshal(*hit.Source, &u)
@@ -60,7 +61,7 @@ func (es *elasticsearch) getCredentialEs6(ctx context.Context, username string)
 				}
 				obj = &u
 			}
-		} else if hit.Index == es.permissionIndex {
+		} else if migration.IsVersionOf(hit.Index, es.permissionIndex) {
 			var p permission.Permission
 
 			// unmarshal into permission
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util/storage"
+)
+
+// documents reads the credentials from the database of the storage
+// backend, the users and the permissions plugins store them there.
+type documents struct {
+	docs *storage.Documents
+}
+
+// createIndex is a no-op, the table of the documents is created along with
+// the database.
+func (d *documents) createIndex(indexName, mapping string) (bool, error) {
+	return true, nil
+}
+
+func (d *documents) savePublicKey(ctx context.Context, indexName string, record publicKey) (interface{}, error) {
+	if err := d.docs.Put(ctx, storage.PublicKeys, publicKeyDocID, record); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *documents) getPublicKey(ctx context.Context) (publicKey, error) {
+	var record publicKey
+	raw, _, err := d.docs.Get(ctx, storage.PublicKeys, publicKeyDocID)
+	if err != nil {
+		return record, err
+	}
+	err = json.Unmarshal(raw, &record)
+	return record, err
+}
+
+// getCredential returns either the user or the permission of the username,
+// or nil if there's neither.
+func (d *documents) getCredential(ctx context.Context, username string) (credential.AuthCredential, error) {
+	u, err := d.getUser(ctx, username)
+	if err == nil {
+		return u, nil
+	}
+	if err != storage.ErrNotFound {
+		return nil, err
+	}
+	p, err := d.getPermission(ctx, username)
+	if err == storage.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (d *documents) putUser(ctx context.Context, u user.User) (bool, error) {
+	if err := d.docs.Put(ctx, storage.Users, u.Username, u); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *documents) getUser(ctx context.Context, username string) (*user.User, error) {
+	raw, err := d.getRawUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	var u user.User
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (d *documents) getRawUser(ctx context.Context, username string) ([]byte, error) {
+	raw, _, err := d.docs.Get(ctx, storage.Users, username)
+	return raw, err
+}
+
+func (d *documents) putPermission(ctx context.Context, p permission.Permission) (bool, error) {
+	if err := d.docs.Put(ctx, storage.Permissions, p.Username, p); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *documents) getPermission(ctx context.Context, username string) (*permission.Permission, error) {
+	raw, err := d.getRawPermission(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	var p permission.Permission
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (d *documents) getRawPermission(ctx context.Context, username string) ([]byte, error) {
+	raw, _, err := d.docs.Get(ctx, storage.Permissions, username)
+	return raw, err
+}
+
+func (d *documents) getRolePermission(ctx context.Context, role string) (*permission.Permission, error) {
+	docs, err := d.docs.List(ctx, storage.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	for _, raw := range docs {
+		var p permission.Permission
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, err
+		}
+		if p.Role == role {
+			return &p, nil
+		}
+	}
+	return nil, storage.ErrNotFound
+}
@@ -27,6 +27,8 @@ var adminCategories = []category.Category{
 	category.Suggestions,
 	category.Auth,
 	category.Functions,
+	category.Logs,
+	category.Reindex,
 }
 
 var adminOps = []op.Operation{
@@ -52,6 +54,8 @@ var defaultAdminLimits = permission.Limits{
 	StreamsLimit:     30,
 	AuthLimit:        30,
 	FunctionsLimit:   30,
+	LogsLimit:        30,
+	ReindexLimit:     30,
 }
 
 var createPermissionResponse = map[string]interface{}{
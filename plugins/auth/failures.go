@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/iplookup"
+	"github.com/appbaseio/arc/util/notify"
+	"github.com/appbaseio/arc/util/state"
+)
+
+// failureWindow is the window the failed authentications of a client are
+// counted within.
+const failureWindow = time.Minute
+
+// authFailed counts the failed authentication of the client, the webhooks are
+// notified once the failures within the window reach the threshold.
+func (a *Auth) authFailed(req *http.Request, username string) {
+	if a.failureThreshold <= 0 {
+		return
+	}
+	ip := iplookup.FromRequest(req)
+	key := fmt.Sprintf("auth:failures:%s", ip)
+	failures, err := state.Instance().Incr(req.Context(), key, 1, failureWindow)
+	if err != nil {
+		log.Errorln(logTag, ": unable to count the failed authentications of", ip, ":", err)
+		return
+	}
+	if failures != a.failureThreshold {
+		return
+	}
+	notify.Send(notify.Event{
+		Type:     notify.AuthFailures,
+		Actor:    username,
+		Resource: ip,
+		Message:  fmt.Sprintf("%d failed authentications from %s within %v, last with username=%s", failures, ip, failureWindow, username),
+		Details:  map[string]interface{}{"ip": ip, "username": username, "failures": failures},
+	})
+}
@@ -0,0 +1,297 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/embedded"
+)
+
+const (
+	envEmbeddedSyncInterval     = "EMBEDDED_STORE_SYNC_INTERVAL"
+	defaultEmbeddedSyncInterval = 30 * time.Second
+	usersBucket                 = "auth.users"
+	permissionsBucket           = "auth.permissions"
+	rolesBucket                 = "auth.roles"
+	pendingBucket               = "auth.pending"
+)
+
+// pendingWrite is a write that couldn't reach elasticsearch, it is replayed
+// once elasticsearch recovers.
+type pendingWrite struct {
+	Kind string          `json:"kind"`
+	Doc  json.RawMessage `json:"doc"`
+}
+
+// fallback serves the users and the permissions from the embedded store
+// while elasticsearch is unreachable. The credentials successfully fetched
+// from elasticsearch are written through to the store, the writes that
+// can't reach elasticsearch are kept in the store and synced back later.
+type fallback struct {
+	authService
+	store *embedded.Store
+
+	// stale is set once elasticsearch is found unreachable, the stored
+	// credentials are then refreshed once it recovers.
+	stale int32
+}
+
+func newFallback(es authService, store *embedded.Store) *fallback {
+	return &fallback{authService: es, store: store}
+}
+
+func (f *fallback) put(bucket, key string, value interface{}) {
+	raw, ok := value.([]byte)
+	if !ok {
+		var err error
+		if raw, err = json.Marshal(value); err != nil {
+			log.Errorln(logTag, ": unable to encode", bucket, key, "for the embedded store:", err)
+			return
+		}
+	}
+	if err := f.store.Put(bucket, key, raw); err != nil {
+		log.Errorln(logTag, ": unable to write", bucket, key, "to the embedded store:", err)
+	}
+}
+
+func (f *fallback) delete(bucket, key string) {
+	if err := f.store.Delete(bucket, key); err != nil {
+		log.Errorln(logTag, ": unable to delete", bucket, key, "from the embedded store:", err)
+	}
+}
+
+// forget removes the credential from the store, it is fetched again from
+// elasticsearch on its next use.
+func (f *fallback) forget(username string) {
+	f.delete(usersBucket, username)
+	f.delete(permissionsBucket, username)
+}
+
+func (f *fallback) getCredential(ctx context.Context, username string) (credential.AuthCredential, error) {
+	c, err := f.authService.getCredential(ctx, username)
+	if util.IsUnreachable(err) {
+		atomic.StoreInt32(&f.stale, 1)
+		if raw, ok := f.store.Get(usersBucket, username); ok {
+			var u user.User
+			if json.Unmarshal(raw, &u) == nil {
+				log.Warnln(logTag, ": elasticsearch is unreachable, serving user", username, "from the embedded store")
+				return &u, nil
+			}
+		}
+		if raw, ok := f.store.Get(permissionsBucket, username); ok {
+			var p permission.Permission
+			if json.Unmarshal(raw, &p) == nil {
+				log.Warnln(logTag, ": elasticsearch is unreachable, serving permission", username, "from the embedded store")
+				return &p, nil
+			}
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch obj := c.(type) {
+	case *user.User:
+		f.put(usersBucket, username, obj)
+	case *permission.Permission:
+		f.put(permissionsBucket, username, obj)
+	case nil:
+		f.forget(username)
+	}
+	return c, nil
+}
+
+func (f *fallback) getRaw(bucket, key string, raw []byte, err error) ([]byte, error) {
+	switch {
+	case err == nil:
+		f.put(bucket, key, raw)
+	case util.IsNotFound(err):
+		f.delete(bucket, key)
+	case util.IsUnreachable(err):
+		atomic.StoreInt32(&f.stale, 1)
+		if stored, ok := f.store.Get(bucket, key); ok {
+			log.Warnln(logTag, ": elasticsearch is unreachable, serving", bucket, key, "from the embedded store")
+			return stored, nil
+		}
+	}
+	return raw, err
+}
+
+func (f *fallback) getRawUser(ctx context.Context, username string) ([]byte, error) {
+	raw, err := f.authService.getRawUser(ctx, username)
+	return f.getRaw(usersBucket, username, raw, err)
+}
+
+func (f *fallback) getUser(ctx context.Context, username string) (*user.User, error) {
+	raw, err := f.getRawUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	var u user.User
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (f *fallback) getRawPermission(ctx context.Context, username string) ([]byte, error) {
+	raw, err := f.authService.getRawPermission(ctx, username)
+	return f.getRaw(permissionsBucket, username, raw, err)
+}
+
+func (f *fallback) getPermission(ctx context.Context, username string) (*permission.Permission, error) {
+	raw, err := f.getRawPermission(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	var p permission.Permission
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (f *fallback) getRolePermission(ctx context.Context, role string) (*permission.Permission, error) {
+	p, err := f.authService.getRolePermission(ctx, role)
+	switch {
+	case err == nil:
+		f.put(rolesBucket, role, p)
+	case util.IsUnreachable(err):
+		atomic.StoreInt32(&f.stale, 1)
+		if raw, ok := f.store.Get(rolesBucket, role); ok {
+			var stored permission.Permission
+			if json.Unmarshal(raw, &stored) == nil {
+				log.Warnln(logTag, ": elasticsearch is unreachable, serving role", role, "from the embedded store")
+				return &stored, nil
+			}
+		}
+	}
+	return p, err
+}
+
+func (f *fallback) putUser(ctx context.Context, u user.User) (bool, error) {
+	ok, err := f.authService.putUser(ctx, u)
+	if util.IsUnreachable(err) {
+		return f.queue("user", u.Username, usersBucket, u)
+	}
+	if err == nil {
+		f.put(usersBucket, u.Username, u)
+	}
+	return ok, err
+}
+
+func (f *fallback) putPermission(ctx context.Context, p permission.Permission) (bool, error) {
+	ok, err := f.authService.putPermission(ctx, p)
+	if util.IsUnreachable(err) {
+		return f.queue("permission", p.Username, permissionsBucket, p)
+	}
+	if err == nil {
+		f.put(permissionsBucket, p.Username, p)
+	}
+	return ok, err
+}
+
+// queue stores the document that couldn't be written to elasticsearch and
+// queues it to be synced back.
+func (f *fallback) queue(kind, username, bucket string, doc interface{}) (bool, error) {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return false, err
+	}
+	pending, err := json.Marshal(pendingWrite{Kind: kind, Doc: raw})
+	if err != nil {
+		return false, err
+	}
+	if err := f.store.Put(bucket, username, raw); err != nil {
+		return false, err
+	}
+	if err := f.store.Put(pendingBucket, kind+"/"+username, pending); err != nil {
+		return false, err
+	}
+	log.Warnln(logTag, ": elasticsearch is unreachable, the", kind, username, "will be synced once it recovers")
+	return true, nil
+}
+
+// sync replays the pending writes until elasticsearch is unreachable again,
+// and refreshes the stored credentials with their latest version if they
+// were served while elasticsearch was unreachable.
+func (f *fallback) sync(ctx context.Context) {
+	for _, key := range f.store.Keys(pendingBucket) {
+		raw, ok := f.store.Get(pendingBucket, key)
+		if !ok {
+			continue
+		}
+		var pending pendingWrite
+		if err := json.Unmarshal(raw, &pending); err != nil {
+			log.Errorln(logTag, ": dropping the malformed pending write", key, ":", err)
+			f.delete(pendingBucket, key)
+			continue
+		}
+		var err error
+		switch pending.Kind {
+		case "user":
+			var u user.User
+			if err = json.Unmarshal(pending.Doc, &u); err == nil {
+				_, err = f.authService.putUser(ctx, u)
+			}
+		case "permission":
+			var p permission.Permission
+			if err = json.Unmarshal(pending.Doc, &p); err == nil {
+				_, err = f.authService.putPermission(ctx, p)
+			}
+		}
+		if util.IsUnreachable(err) {
+			return
+		}
+		if err != nil {
+			log.Errorln(logTag, ": dropping the pending write", key, "rejected by elasticsearch:", err)
+		} else {
+			log.Println(logTag, ": synced the pending write", key, "to elasticsearch")
+		}
+		f.delete(pendingBucket, key)
+	}
+
+	if !atomic.CompareAndSwapInt32(&f.stale, 1, 0) {
+		return
+	}
+	for _, bucket := range []string{usersBucket, permissionsBucket} {
+		for _, username := range f.store.Keys(bucket) {
+			var err error
+			if bucket == usersBucket {
+				_, err = f.getRawUser(ctx, username)
+			} else {
+				_, err = f.getRawPermission(ctx, username)
+			}
+			if util.IsUnreachable(err) {
+				return
+			}
+		}
+	}
+}
+
+// syncEvery syncs the store with elasticsearch at the interval set via
+// EMBEDDED_STORE_SYNC_INTERVAL, 30s by default.
+func (f *fallback) syncEvery() {
+	interval := defaultEmbeddedSyncInterval
+	if value := os.Getenv(envEmbeddedSyncInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Errorln(logTag, ": invalid value for", envEmbeddedSyncInterval, ":", value)
+		} else {
+			interval = d
+		}
+	}
+	for range time.Tick(interval) {
+		f.sync(context.Background())
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	es7 "github.com/olivere/elastic/v7"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util/embedded"
+)
+
+// flakyES serves the users it holds unless it is down.
+type flakyES struct {
+	authService
+	down  bool
+	users map[string]user.User
+}
+
+func (es *flakyES) getCredential(ctx context.Context, username string) (credential.AuthCredential, error) {
+	if es.down {
+		return nil, es7.ErrNoClient
+	}
+	if u, ok := es.users[username]; ok {
+		return &u, nil
+	}
+	return nil, nil
+}
+
+func (es *flakyES) getRawUser(ctx context.Context, username string) ([]byte, error) {
+	if es.down {
+		return nil, es7.ErrNoClient
+	}
+	u, ok := es.users[username]
+	if !ok {
+		return nil, &es7.Error{Status: 404}
+	}
+	return json.Marshal(u)
+}
+
+func (es *flakyES) putUser(ctx context.Context, u user.User) (bool, error) {
+	if es.down {
+		return false, es7.ErrNoClient
+	}
+	es.users[u.Username] = u
+	return true, nil
+}
+
+func TestFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arc-auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	Convey("With an embedded store", t, func() {
+		store, err := embedded.Open(filepath.Join(dir, t.Name()+".json"))
+		So(err, ShouldBeNil)
+		es := &flakyES{users: map[string]user.User{"foo": {Username: "foo", Password: "bar"}}}
+		f := newFallback(es, store)
+
+		Convey("credentials fetched earlier are served while es is down", func() {
+			_, err := f.getCredential(ctx, "foo")
+			So(err, ShouldBeNil)
+
+			es.down = true
+			c, err := f.getCredential(ctx, "foo")
+			So(err, ShouldBeNil)
+			So(c.(*user.User).Password, ShouldEqual, "bar")
+
+			raw, err := f.getRawUser(ctx, "foo")
+			So(err, ShouldBeNil)
+			So(string(raw), ShouldContainSubstring, `"username":"foo"`)
+		})
+
+		Convey("unknown credentials still fail while es is down", func() {
+			es.down = true
+			_, err := f.getCredential(ctx, "baz")
+			So(err, ShouldEqual, es7.ErrNoClient)
+		})
+
+		Convey("credentials deleted from es are removed from the store", func() {
+			_, err := f.getCredential(ctx, "foo")
+			So(err, ShouldBeNil)
+			delete(es.users, "foo")
+			c, err := f.getCredential(ctx, "foo")
+			So(err, ShouldBeNil)
+			So(c, ShouldBeNil)
+
+			es.down = true
+			_, err = f.getCredential(ctx, "foo")
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("writes are synced back once es recovers", func() {
+			es.down = true
+			ok, err := f.putUser(ctx, user.User{Username: "qux"})
+			So(ok, ShouldBeTrue)
+			So(err, ShouldBeNil)
+			So(store.Keys(pendingBucket), ShouldResemble, []string{"user/qux"})
+
+			f.sync(ctx)
+			So(store.Keys(pendingBucket), ShouldHaveLength, 1)
+
+			es.down = false
+			f.sync(ctx)
+			So(store.Keys(pendingBucket), ShouldBeEmpty)
+			So(es.users, ShouldContainKey, "qux")
+		})
+	})
+}
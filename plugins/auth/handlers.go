@@ -6,7 +6,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"strings"
@@ -68,10 +67,10 @@ func (a *Auth) getPublicKey() http.HandlerFunc {
 
 func (a *Auth) setPublicKey() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		reqBody, err := ioutil.ReadAll(req.Body)
+		reqBody, err := util.ReadRequestBody(req)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, "Can't read request body", http.StatusBadRequest)
+			util.WriteBackBodyError(w, err)
 			return
 		}
 		defer req.Body.Close()
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/audit"
+)
+
+const (
+	// ImpersonateHeader names the user or permission whose credentials the
+	// request of an admin user is executed with.
+	ImpersonateHeader = "X-Arc-Impersonate"
+	// impersonatedByHeader names the admin user in the response, so that the
+	// request logs carry both the identities.
+	impersonatedByHeader = "X-Arc-Impersonated-By"
+	// impersonatorKey is the key against which the username of the admin
+	// user is stored in the context.
+	impersonatorKey = contextKey("impersonator")
+)
+
+type contextKey string
+
+// recordAudit records the impersonations in the audit trail.
+var recordAudit = audit.Record
+
+// Impersonator returns the username of the admin user impersonating the
+// credential of the request, if any.
+func Impersonator(ctx context.Context) (string, bool) {
+	username, ok := ctx.Value(impersonatorKey).(string)
+	return username, ok
+}
+
+// impersonate replaces the credential of the admin user in the context of
+// the request by the impersonated one, which is then authorized as if it
+// made the request. It returns false if the request can't impersonate, the
+// error being written back.
+func (a *Auth) impersonate(w http.ResponseWriter, req *http.Request, reqCategory *category.Category, target string) (*http.Request, bool) {
+	ctx := req.Context()
+
+	reqUser, err := user.FromContext(ctx)
+	if err != nil || !reqUser.Admin() {
+		util.WriteBackError(w, fmt.Sprintf(`only admin users are allowed to use the "%s" header`, ImpersonateHeader), http.StatusForbidden)
+		return nil, false
+	}
+	obj, err := a.getCredential(ctx, target)
+	if err != nil || obj == nil {
+		msg := fmt.Sprintf("No API credentials match with impersonated username: %s", target)
+		log.Errorln(logTag, ":", err)
+		util.WriteBackError(w, msg, http.StatusBadRequest)
+		return nil, false
+	}
+
+	var authenticated bool
+	var errorMsg string
+	switch impersonated := obj.(type) {
+	case *user.User:
+		authenticated = !impersonated.Disabled && (!reqCategory.IsFromES() || impersonated.Admin())
+		errorMsg = "only admin users are allowed to access elasticsearch"
+		if impersonated.Disabled {
+			errorMsg = fmt.Sprintf(`user with "username"="%s" is disabled`, target)
+		}
+		ctx = credential.NewContext(ctx, credential.User)
+		ctx = user.NewContext(ctx, impersonated)
+	case *permission.Permission:
+		authenticated = reqCategory.IsFromES()
+		errorMsg = "credential is only allowed to access elasticsearch"
+		ctx = credential.NewContext(ctx, credential.Permission)
+		ctx = permission.NewContext(ctx, impersonated)
+	default:
+		msg := fmt.Sprintf("No API credentials match with impersonated username: %s", target)
+		util.WriteBackError(w, msg, http.StatusBadRequest)
+		return nil, false
+	}
+	ctx = context.WithValue(ctx, impersonatorKey, reqUser.Username)
+	req = req.WithContext(ctx)
+	req.Header.Del(ImpersonateHeader)
+
+	log.Println(logTag, ":", reqUser.Username, "impersonating", target, "on", req.Method, req.URL.Path)
+	event := audit.NewEvent(req, "impersonate", target, http.StatusOK)
+	event.Actor = reqUser.Username
+	event.Details = map[string]interface{}{"method": req.Method, "path": req.URL.Path}
+	if !authenticated {
+		event.Status = http.StatusForbidden
+	}
+	go recordAudit(context.Background(), event)
+
+	if !authenticated {
+		util.WriteBackError(w, fmt.Sprintf("impersonated %s: %s", target, errorMsg), http.StatusForbidden)
+		return nil, false
+	}
+	w.Header().Set(impersonatedByHeader, reqUser.Username)
+	return req, true
+}
@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util/audit"
+)
+
+func TestImpersonate(t *testing.T) {
+	events := make(chan audit.Event, 1)
+	recordAudit = func(ctx context.Context, e audit.Event) { events <- e }
+	defer func() { recordAudit = audit.Record }()
+
+	admin, other := true, false
+	a := &Auth{credentialCache: map[string]credential.AuthCredential{
+		"support": &user.User{Username: "support", IsAdmin: &other},
+	}}
+	newRequest := func(u *user.User) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/_user", nil)
+		return req.WithContext(user.NewContext(req.Context(), u))
+	}
+	userCategory := category.User
+
+	Convey("The admin users impersonate the credentials", t, func() {
+		w := httptest.NewRecorder()
+		req, ok := a.impersonate(w, newRequest(&user.User{Username: "admin", IsAdmin: &admin}), &userCategory, "support")
+		So(ok, ShouldBeTrue)
+		reqUser, _ := user.FromContext(req.Context())
+		So(reqUser.Username, ShouldEqual, "support")
+		So(w.Header().Get(impersonatedByHeader), ShouldEqual, "admin")
+		e := <-events
+		So(e.Actor, ShouldEqual, "admin")
+		So(e.Status, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("The users without an admin flag can't impersonate", t, func() {
+		w := httptest.NewRecorder()
+		_, ok := a.impersonate(w, newRequest(&user.User{Username: "legacy"}), &userCategory, "support")
+		So(ok, ShouldBeFalse)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("The unknown credentials aren't impersonated", t, func() {
+		w := httptest.NewRecorder()
+		a.es = &flakyES{}
+		_, ok := a.impersonate(w, newRequest(&user.User{Username: "admin", IsAdmin: &admin}), &userCategory, "nobody")
+		So(ok, ShouldBeFalse)
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(w.Header().Get(impersonatedByHeader), ShouldBeEmpty)
+	})
+
+	Convey("The impersonated users without an admin flag can't access elasticsearch", t, func() {
+		a.credentialCache["legacy"] = &user.User{Username: "legacy"}
+		w := httptest.NewRecorder()
+		docs := category.Docs
+		_, ok := a.impersonate(w, newRequest(&user.User{Username: "admin", IsAdmin: &admin}), &docs, "legacy")
+		So(ok, ShouldBeFalse)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(w.Header().Get(impersonatedByHeader), ShouldBeEmpty)
+		So((<-events).Status, ShouldEqual, http.StatusForbidden)
+	})
+}
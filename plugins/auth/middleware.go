@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/appbaseio/arc/model/permission"
 	"github.com/appbaseio/arc/model/user"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/state"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/dgrijalva/jwt-go/request"
 	"github.com/gorilla/mux"
@@ -145,6 +147,7 @@ func (a *Auth) basicAuth(h http.HandlerFunc) http.HandlerFunc {
 			if err != nil || obj == nil {
 				msg := fmt.Sprintf("No API credentials match with provided username: %s", username)
 				log.Errorln(logTag, ":", err)
+				a.authFailed(req, username)
 				w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
 				util.WriteBackError(w, msg, http.StatusUnauthorized)
 				return
@@ -161,13 +164,20 @@ func (a *Auth) basicAuth(h http.HandlerFunc) http.HandlerFunc {
 			{
 				// if the request is made to elasticsearch using user credentials, then the user has to be an admin
 				reqUser := obj.(*user.User)
+				if reqUser.Disabled {
+					a.authFailed(req, username)
+					w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
+					util.WriteBackError(w, fmt.Sprintf(`user with "username"="%s" is disabled`, username), http.StatusUnauthorized)
+					return
+				}
 				if hasBasicAuth && bcrypt.CompareHashAndPassword([]byte(reqUser.Password), []byte(password)) != nil {
+					a.authFailed(req, username)
 					w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
 					util.WriteBackError(w, "invalid password", http.StatusUnauthorized)
 					return
 				}
 				if reqCategory.IsFromES() {
-					authenticated = *reqUser.IsAdmin
+					authenticated = reqUser.Admin()
 				} else {
 					authenticated = true
 				}
@@ -190,6 +200,7 @@ func (a *Auth) basicAuth(h http.HandlerFunc) http.HandlerFunc {
 			{
 				reqPermission := obj.(*permission.Permission)
 				if hasBasicAuth && reqPermission.Password != password {
+					a.authFailed(req, username)
 					w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
 					util.WriteBackError(w, "invalid password", http.StatusUnauthorized)
 					return
@@ -216,19 +227,46 @@ func (a *Auth) basicAuth(h http.HandlerFunc) http.HandlerFunc {
 		}
 
 		if !authenticated {
-			w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-			util.WriteBackError(w, errorMsg, http.StatusUnauthorized)
+			util.WriteBackError(w, errorMsg, http.StatusForbidden)
 			return
 		}
 
-		// remove user/permission from cache on write operation
-		if *reqOp == op.Write || *reqOp == op.Delete {
-			username := mux.Vars(req)["username"]
-			a.removeCredentialFromCache(username)
+		// admin users may execute the request with the credential of another
+		if target := req.Header.Get(ImpersonateHeader); target != "" {
+			var ok bool
+			if req, ok = a.impersonate(w, req, reqCategory, target); !ok {
+				return
+			}
+			ctx = req.Context()
 		}
 
 		h(w, req)
+
+		// remove user/permission from the caches on write operation, once
+		// written so that no instance caches the credential in between
+		if *reqOp == op.Write || *reqOp == op.Delete {
+			if target := modifiedCredential(req, *reqCategory); target != "" {
+				a.invalidateCredential(ctx, target)
+			}
+		}
+	}
+}
+
+// modifiedCredential returns the username of the credential modified by the
+// request to the user or permission apis, either the credential of the path
+// or the user itself.
+func modifiedCredential(req *http.Request, reqCategory category.Category) string {
+	if reqCategory != category.User && reqCategory != category.Permission {
+		return ""
+	}
+	if username := mux.Vars(req)["username"]; username != "" {
+		return username
 	}
+	if reqCategory == category.User && strings.HasSuffix(req.URL.Path, "/_user") {
+		username, _, _ := req.BasicAuth()
+		return username
+	}
+	return ""
 }
 
 func (a *Auth) getCredential(ctx context.Context, username string) (credential.AuthCredential, error) {
@@ -252,6 +290,28 @@ func (a *Auth) removeCredentialFromCache(username string) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	delete(a.credentialCache, username)
+	if f, ok := a.es.(*fallback); ok {
+		f.forget(username)
+	}
+}
+
+// invalidateCredential removes the credential from the cache of all the arc instances.
+func (a *Auth) invalidateCredential(ctx context.Context, username string) {
+	a.removeCredentialFromCache(username)
+	if err := state.Instance().Publish(ctx, credentialsChannel, []byte(username)); err != nil {
+		log.Errorln(logTag, ": unable to invalidate the cached credential", username, ":", err)
+	}
+	if a.changes != nil {
+		if err := a.changes.publish(ctx, username); err != nil {
+			log.Errorln(logTag, ": unable to record the change of credential", username, ":", err)
+		}
+	}
+}
+
+// InvalidateCredential removes the credential from the cache of all the arc
+// instances, for the credentials modified outside of the routes served by arc.
+func InvalidateCredential(ctx context.Context, username string) {
+	Instance().invalidateCredential(ctx, username)
 }
 
 func (a *Auth) cacheCredential(username string, c credential.AuthCredential) {
@@ -0,0 +1,107 @@
+package bulk
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag              = "[bulk]"
+	envSplit            = "BULK_SPLIT"
+	envChunkMaxBytes    = "BULK_CHUNK_MAX_BYTES"
+	defaultChunkMaxByte = 10 * 1024 * 1024
+	envChunkMaxDocs     = "BULK_CHUNK_MAX_DOCS"
+	defaultChunkMaxDocs = 5000
+	envMaxRetries       = "BULK_MAX_RETRIES"
+	defaultMaxRetries   = 3
+	envRetryBackoff     = "BULK_RETRY_BACKOFF"
+	defaultRetryBackoff = 500 * time.Millisecond
+)
+
+var (
+	singleton *bulk
+	once      sync.Once
+)
+
+// bulk splits the oversized bulk requests into chunks forwarded to
+// elasticsearch one after the other, retrying the chunks and the items
+// rejected by elasticsearch with 429, and merges the items of the chunks
+// into a single response.
+type bulk struct {
+	enabled      bool
+	maxBytes     int
+	maxDocs      int
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// Use only this function to fetch the instance of bulk from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *bulk {
+	once.Do(func() {
+		singleton = &bulk{
+			enabled:      true,
+			maxBytes:     defaultChunkMaxByte,
+			maxDocs:      defaultChunkMaxDocs,
+			maxRetries:   defaultMaxRetries,
+			retryBackoff: defaultRetryBackoff,
+		}
+	})
+	return singleton
+}
+
+func (b *bulk) Name() string {
+	return logTag
+}
+
+func (b *bulk) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	b.enabled = os.Getenv(envSplit) != "false"
+	var err error
+	if b.maxBytes, err = intFromEnv(envChunkMaxBytes, defaultChunkMaxByte, 1); err != nil {
+		return err
+	}
+	if b.maxDocs, err = intFromEnv(envChunkMaxDocs, defaultChunkMaxDocs, 1); err != nil {
+		return err
+	}
+	if b.maxRetries, err = intFromEnv(envMaxRetries, defaultMaxRetries, 0); err != nil {
+		return err
+	}
+	if value := os.Getenv(envRetryBackoff); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a non-negative duration", envRetryBackoff, value)
+		}
+		b.retryBackoff = d
+	}
+	return nil
+}
+
+func (b *bulk) Routes() []plugins.Route {
+	return []plugins.Route{}
+}
+
+func (b *bulk) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{b.split}
+}
+
+func intFromEnv(key string, defaultValue, min int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < min {
+		return 0, fmt.Errorf("invalid value for %s: %s, must be an integer of at least %d", key, value, min)
+	}
+	return n, nil
+}
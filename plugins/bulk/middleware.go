@@ -0,0 +1,176 @@
+package bulk
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/util"
+)
+
+// split forwards the oversized bulk requests to elasticsearch in chunks, one
+// after the other, and merges the items of their responses in the order of
+// the actions. A chunk failing with other than 429 stops the bulk, the
+// failure is written back while the preceding chunks remain applied.
+func (b *bulk) split(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		reqACL, err := acl.FromContext(req.Context())
+		if !b.enabled || err != nil || *reqACL != acl.Bulk || req.Body == nil {
+			h(w, req)
+			return
+		}
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		actions, err := parseActions(body)
+		if err != nil || !oversized(actions, b.maxBytes, b.maxDocs) {
+			// the malformed bodies are left for elasticsearch to report
+			h(w, req)
+			return
+		}
+
+		positions := make([]int, len(actions))
+		for i := range positions {
+			positions[i] = i
+		}
+		merged := bulkResponse{Items: make([]json.RawMessage, len(actions))}
+		var last *bufferedWriter
+		for _, chunk := range chunks(actions, positions, b.maxBytes, b.maxDocs) {
+			buf, ok := b.forwardChunk(h, req, actions, chunk, &merged)
+			if buf == nil {
+				util.WriteBackError(w, "the bulk request was cancelled", http.StatusGatewayTimeout)
+				return
+			}
+			if !ok {
+				log.Errorln(logTag, ": bulk chunk failed with status", buf.code)
+				buf.writeTo(w)
+				return
+			}
+			last = buf
+		}
+		for _, item := range merged.Items {
+			if failed(item) {
+				merged.Errors = true
+				break
+			}
+		}
+
+		raw, err := json.Marshal(merged)
+		if err != nil {
+			log.Errorln(logTag, ": unable to marshal bulk response:", err)
+			util.WriteBackError(w, "an error occurred while merging the bulk responses", http.StatusInternalServerError)
+			return
+		}
+		copyHeader(w.Header(), last.header)
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// forwardChunk forwards the actions of the chunk and sets their items in the
+// merged response. The chunk is retried as a whole if rejected with 429, and
+// so are its items rejected with 429, up to the max retries. It returns the
+// last response of the chunk and whether it succeeded, the response is nil
+// if the request was cancelled while backing off.
+func (b *bulk) forwardChunk(h http.HandlerFunc, req *http.Request, actions []action, chunk []int, merged *bulkResponse) (*bufferedWriter, bool) {
+	pending := chunk
+	for attempt := 0; ; attempt++ {
+		buf := forward(h, req, chunkBody(actions, pending))
+		retry := attempt < b.maxRetries
+		if buf.code == http.StatusTooManyRequests && retry {
+			if !b.backoff(req, attempt) {
+				return nil, false
+			}
+			continue
+		}
+		if buf.code != http.StatusOK {
+			return buf, false
+		}
+
+		var response bulkResponse
+		if err := json.Unmarshal(buf.body.Bytes(), &response); err != nil || len(response.Items) != len(pending) {
+			log.Errorln(logTag, ": unexpected bulk response:", err)
+			buf.code = http.StatusBadGateway
+			return buf, false
+		}
+		merged.Took += response.Took
+		var rejected []int
+		for i, item := range response.Items {
+			merged.Items[pending[i]] = item
+			if itemStatus(item) == http.StatusTooManyRequests {
+				rejected = append(rejected, pending[i])
+			}
+		}
+		if len(rejected) == 0 || !retry {
+			return buf, true
+		}
+		if !b.backoff(req, attempt) {
+			return nil, false
+		}
+		pending = rejected
+	}
+}
+
+// backoff waits exponentially longer before each retry, it returns false if
+// the request is cancelled meanwhile.
+func (b *bulk) backoff(req *http.Request, attempt int) bool {
+	timer := time.NewTimer(b.retryBackoff << uint(attempt))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
+
+// forward passes the chunk through the rest of the chain and buffers the
+// response. The response isn't compressed in order to be merged.
+func forward(h http.HandlerFunc, req *http.Request, body []byte) *bufferedWriter {
+	r := util.WithSharedBody(req.Clone(req.Context()))
+	util.SetRequestBody(r, body)
+	r.Header.Del("Accept-Encoding")
+	buf := &bufferedWriter{header: make(http.Header), code: http.StatusOK}
+	h(buf, r)
+	return buf
+}
+
+// bufferedWriter retains the response written by the handler.
+type bufferedWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (b *bufferedWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedWriter) WriteHeader(code int) {
+	b.code = code
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+func (b *bufferedWriter) writeTo(w http.ResponseWriter) {
+	copyHeader(w.Header(), b.header)
+	w.WriteHeader(b.code)
+	w.Write(b.body.Bytes())
+}
+
+func copyHeader(dst, src http.Header) {
+	for k, v := range src {
+		if k != "Content-Length" {
+			dst[k] = v
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package bulk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// action is a line of the bulk along with the source line following it, if
+// any, each terminated by a newline.
+type action []byte
+
+// parseActions splits the bulk body into its actions. The index, create and
+// update actions are followed by a source line, the delete actions aren't.
+func parseActions(body []byte) ([]action, error) {
+	var actions []action
+	var pending []byte
+	for line := 1; len(body) > 0; line++ {
+		n := bytes.IndexByte(body, '\n')
+		var raw []byte
+		if n < 0 {
+			raw, body = body, nil
+		} else {
+			raw, body = body[:n], body[n+1:]
+		}
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		if pending != nil {
+			actions = append(actions, append(append(pending, raw...), '\n'))
+			pending = nil
+			continue
+		}
+
+		var meta map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &meta); err != nil || len(meta) != 1 {
+			return nil, fmt.Errorf("malformed bulk action on line %d", line)
+		}
+		for op := range meta {
+			switch op {
+			case "index", "create", "update":
+				pending = append(append([]byte{}, raw...), '\n')
+			case "delete":
+				actions = append(actions, append(append([]byte{}, raw...), '\n'))
+			default:
+				return nil, fmt.Errorf(`unknown bulk action "%s" on line %d`, op, line)
+			}
+		}
+	}
+	if pending != nil {
+		return nil, fmt.Errorf("bulk action without source at the end of the body")
+	}
+	return actions, nil
+}
+
+// chunks groups the actions, given by their positions, into chunks of at
+// most maxDocs actions and maxBytes bytes. An action larger than maxBytes
+// makes up a chunk of its own.
+func chunks(actions []action, positions []int, maxBytes, maxDocs int) [][]int {
+	var chunks [][]int
+	var current []int
+	var size int
+	for _, i := range positions {
+		if len(current) > 0 && (len(current) == maxDocs || size+len(actions[i]) > maxBytes) {
+			chunks = append(chunks, current)
+			current, size = nil, 0
+		}
+		current = append(current, i)
+		size += len(actions[i])
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, current)
+	}
+	return chunks
+}
+
+// oversized checks whether the bulk must be split.
+func oversized(actions []action, maxBytes, maxDocs int) bool {
+	if len(actions) > maxDocs {
+		return true
+	}
+	var size int
+	for _, a := range actions {
+		size += len(a)
+	}
+	return size > maxBytes
+}
+
+// chunkBody concatenates the actions of the chunk.
+func chunkBody(actions []action, chunk []int) []byte {
+	var body bytes.Buffer
+	for _, i := range chunk {
+		body.Write(actions[i])
+	}
+	return body.Bytes()
+}
+
+// bulkResponse is the response of elasticsearch to a bulk request.
+type bulkResponse struct {
+	Took   int64             `json:"took"`
+	Errors bool              `json:"errors"`
+	Items  []json.RawMessage `json:"items"`
+}
+
+// itemStatus returns the status of the bulk item, which is keyed by its
+// action.
+func itemStatus(item json.RawMessage) int {
+	var result map[string]struct {
+		Status int `json:"status"`
+	}
+	if err := json.Unmarshal(item, &result); err != nil {
+		return 0
+	}
+	for _, r := range result {
+		return r.Status
+	}
+	return 0
+}
+
+// failed checks whether the bulk item failed.
+func failed(item json.RawMessage) bool {
+	status := itemStatus(item)
+	return status == 0 || status >= 300
+}
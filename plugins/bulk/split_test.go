@@ -0,0 +1,129 @@
+package bulk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/acl"
+)
+
+const body = `{"index": {"_index": "products", "_id": "1"}}
+{"title": "shoes"}
+{"delete": {"_index": "products", "_id": "2"}}
+
+{"update": {"_index": "products", "_id": "3"}}
+{"doc": {"title": "socks"}}
+{"create": {"_index": "products", "_id": "4"}}
+{"title": "hats"}
+`
+
+func TestParseActions(t *testing.T) {
+	Convey("The actions are split along with their sources", t, func() {
+		actions, err := parseActions([]byte(body))
+		So(err, ShouldBeNil)
+		So(actions, ShouldHaveLength, 4)
+		So(string(actions[1]), ShouldEqual, "{\"delete\": {\"_index\": \"products\", \"_id\": \"2\"}}\n")
+		So(string(actions[2]), ShouldEqual, "{\"update\": {\"_index\": \"products\", \"_id\": \"3\"}}\n{\"doc\": {\"title\": \"socks\"}}\n")
+	})
+
+	Convey("Malformed bulks are rejected", t, func() {
+		_, err := parseActions([]byte("{\"index\": {}}\n"))
+		So(err, ShouldNotBeNil)
+		_, err = parseActions([]byte("{\"upsert\": {}}\n{}\n"))
+		So(err, ShouldNotBeNil)
+		_, err = parseActions([]byte("not json\n"))
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestChunks(t *testing.T) {
+	actions := []action{make(action, 10), make(action, 10), make(action, 30), make(action, 5)}
+	positions := []int{0, 1, 2, 3}
+
+	Convey("The chunks are bounded by the docs count", t, func() {
+		So(chunks(actions, positions, 1000, 3), ShouldResemble, [][]int{{0, 1, 2}, {3}})
+	})
+
+	Convey("The chunks are bounded by the bytes, the larger actions make up their own", t, func() {
+		So(chunks(actions, positions, 20, 10), ShouldResemble, [][]int{{0, 1}, {2}, {3}})
+		So(oversized(actions, 55, 4), ShouldBeFalse)
+		So(oversized(actions, 54, 4), ShouldBeTrue)
+		So(oversized(actions, 55, 3), ShouldBeTrue)
+	})
+}
+
+func TestSplit(t *testing.T) {
+	b := &bulk{enabled: true, maxBytes: 1 << 20, maxDocs: 2, maxRetries: 2}
+
+	// es rejects the first attempt of each chunk, and the update items once
+	var requests []string
+	rejectedUpdate := false
+	es := func(w http.ResponseWriter, req *http.Request) {
+		raw, _ := ioutil.ReadAll(req.Body)
+		requests = append(requests, string(raw))
+		if len(requests)%2 == 1 && !strings.Contains(string(raw), "update") {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		actions, _ := parseActions(raw)
+		var items []string
+		for _, a := range actions {
+			var meta map[string]map[string]string
+			json.Unmarshal(a[:strings.IndexByte(string(a), '\n')], &meta)
+			for op, m := range meta {
+				status := 200
+				if op == "update" && !rejectedUpdate {
+					status, rejectedUpdate = 429, true
+				}
+				items = append(items, fmt.Sprintf(`{"%s": {"_id": "%s", "status": %d}}`, op, m["_id"], status))
+			}
+		}
+		w.Header().Set("X-Origin", "ES")
+		fmt.Fprintf(w, `{"took": 2, "errors": false, "items": [%s]}`, strings.Join(items, ","))
+	}
+	request := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/_bulk", strings.NewReader(body))
+		bulkACL := acl.Bulk
+		return req.WithContext(acl.NewContext(context.Background(), &bulkACL))
+	}
+
+	Convey("The chunks and their rejected items are retried and merged", t, func() {
+		w := httptest.NewRecorder()
+		b.split(es)(w, request())
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Header().Get("X-Origin"), ShouldEqual, "ES")
+
+		var merged bulkResponse
+		So(json.Unmarshal(w.Body.Bytes(), &merged), ShouldBeNil)
+		So(merged.Errors, ShouldBeFalse)
+		So(merged.Items, ShouldHaveLength, 4)
+		for i, item := range merged.Items {
+			So(string(item), ShouldContainSubstring, fmt.Sprintf(`"_id":"%d"`, i+1))
+			So(itemStatus(item), ShouldEqual, 200)
+		}
+		// the first chunk is retried, then the rejected update item of the second
+		So(requests, ShouldHaveLength, 4)
+		So(requests[0], ShouldEqual, requests[1])
+		So(requests[3], ShouldStartWith, `{"update"`)
+		So(strings.Count(requests[3], "\n"), ShouldEqual, 2)
+	})
+
+	Convey("The bulks within the limits are forwarded as is", t, func() {
+		requests = nil
+		b.maxDocs = 10
+		w := httptest.NewRecorder()
+		b.split(func(w http.ResponseWriter, req *http.Request) {
+			raw, _ := ioutil.ReadAll(req.Body)
+			requests = append(requests, string(raw))
+		})(w, request())
+		So(requests, ShouldResemble, []string{body})
+	})
+}
@@ -0,0 +1,131 @@
+package capture
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                 = "[capture]"
+	defaultSessionsEsIndex = ".capture_sessions"
+	envSessionsEsIndex     = "CAPTURE_SESSIONS_ES_INDEX"
+	defaultCapturesEsIndex = ".captures"
+	envCapturesEsIndex     = "CAPTURES_ES_INDEX"
+	envMaxBodySize         = "CAPTURE_MAX_BODY_SIZE"
+	defaultMaxBodySize     = 1 << 20
+	envRetention           = "CAPTURE_RETENTION"
+	defaultRetention       = 24 * time.Hour
+	refreshInterval        = 10 * time.Second
+	settings               = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+)
+
+var (
+	singleton *capture
+	once      sync.Once
+)
+
+// capture records the request/response pairs matching the filters of the
+// active capture sessions so that they can be inspected and replayed.
+type capture struct {
+	es          *elasticsearch
+	sessions    *registry
+	maxBodySize int
+	retention   time.Duration
+}
+
+// Use only this function to fetch the instance of capture from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *capture {
+	once.Do(func() {
+		singleton = &capture{
+			sessions:    newRegistry(),
+			maxBodySize: defaultMaxBodySize,
+			retention:   defaultRetention,
+		}
+	})
+	return singleton
+}
+
+func (c *capture) Name() string {
+	return logTag
+}
+
+func (c *capture) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	sessionsIndex := os.Getenv(envSessionsEsIndex)
+	if sessionsIndex == "" {
+		sessionsIndex = defaultSessionsEsIndex
+	}
+	capturesIndex := os.Getenv(envCapturesEsIndex)
+	if capturesIndex == "" {
+		capturesIndex = defaultCapturesEsIndex
+	}
+	if value := os.Getenv(envMaxBodySize); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			log.Errorln(logTag, ": invalid value for", envMaxBodySize, ":", err)
+		} else {
+			c.maxBodySize = size
+		}
+	}
+	if value := os.Getenv(envRetention); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Errorln(logTag, ": invalid value for", envRetention, ":", value)
+		} else {
+			c.retention = d
+		}
+	}
+
+	// initialize the dao
+	var err error
+	c.es, err = initPlugin(sessionsIndex, capturesIndex, settings)
+	if err != nil {
+		return err
+	}
+
+	// the sessions are created via any of the arc instances, each instance
+	// periodically picks up the active sessions
+	c.refresh()
+	go func() {
+		for range time.Tick(refreshInterval) {
+			c.refresh()
+		}
+	}()
+	return nil
+}
+
+func (c *capture) Routes() []plugins.Route {
+	return c.routes()
+}
+
+func (c *capture) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{c.capture}
+}
+
+// refresh loads the active sessions and purges the captures past their retention.
+func (c *capture) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), refreshInterval)
+	defer cancel()
+
+	now := time.Now()
+	sessions, err := c.es.getSessions(ctx)
+	if err != nil {
+		log.Errorln(logTag, ": unable to load the capture sessions :", err)
+		return
+	}
+	c.sessions.set(sessions)
+
+	if err := c.es.purge(ctx, now, c.retention); err != nil {
+		log.Errorln(logTag, ": unable to purge the expired captures :", err)
+	}
+}
@@ -0,0 +1,232 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// capturesMappings only indexes the fields the captures are looked up by,
+// the captured bodies are merely stored.
+const capturesMappings = `
+{
+  "dynamic": false,
+  "properties": {
+    "id": { "type": "keyword" },
+    "session": { "type": "keyword" },
+    "captured_at": { "type": "date" },
+    "expires_at": { "type": "date" }
+  }
+}`
+
+type elasticsearch struct {
+	sessionsIndex string
+	capturesIndex string
+}
+
+func initPlugin(sessionsIndex, capturesIndex, config string) (*elasticsearch, error) {
+	ctx := context.Background()
+
+	es := &elasticsearch{sessionsIndex, capturesIndex}
+
+	// set number_of_replicas to (nodes-1)
+	nodes, err := util.GetTotalNodes()
+	if err != nil {
+		return nil, err
+	}
+	settings := fmt.Sprintf(config, nodes, nodes-1)
+	if err := createIndex(ctx, sessionsIndex, settings); err != nil {
+		return nil, err
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(settings), &body); err != nil {
+		return nil, err
+	}
+	if docType := util.DocType(); docType != "" {
+		body["mappings"] = map[string]json.RawMessage{docType: json.RawMessage(capturesMappings)}
+	} else {
+		body["mappings"] = json.RawMessage(capturesMappings)
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	if err := createIndex(ctx, capturesIndex, string(raw)); err != nil {
+		return nil, err
+	}
+	return es, nil
+}
+
+func createIndex(ctx context.Context, indexName, body string) error {
+	exists, err := util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: error while checking if index already exists: %v", logTag, err)
+	}
+	if exists {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+		return nil
+	}
+
+	_, err = util.GetClient7().CreateIndex(indexName).
+		Body(body).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: error while creating index named %s: %v", logTag, indexName, err)
+	}
+
+	log.Println(logTag, ": successfully created index named", indexName)
+	return nil
+}
+
+func (es *elasticsearch) getSessions(ctx context.Context) ([]session, error) {
+	response, err := util.GetClient7().Search().
+		Index(es.sessionsIndex).
+		Sort("created_at", false).
+		Size(1000).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := []session{}
+	for _, hit := range response.Hits.Hits {
+		var s session
+		if err := json.Unmarshal(hit.Source, &s); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal capture session %s: %v", hit.Id, err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+func (es *elasticsearch) getSession(ctx context.Context, id string) (*session, error) {
+	request := util.GetClient7().Get().
+		Index(es.sessionsIndex).
+		Id(id).
+		FetchSource(true)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	response, err := request.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var s session
+	if err := json.Unmarshal(response.Source, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (es *elasticsearch) putSession(ctx context.Context, s session) error {
+	request := util.GetClient7().Index().
+		Refresh("wait_for").
+		Index(es.sessionsIndex).
+		Id(s.ID).
+		BodyJson(s)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+// deleteSession deletes the session along with its captures.
+func (es *elasticsearch) deleteSession(ctx context.Context, id string) error {
+	request := util.GetClient7().Delete().
+		Refresh("wait_for").
+		Index(es.sessionsIndex).
+		Id(id)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	if _, err := request.Do(ctx); err != nil {
+		return err
+	}
+	_, err := util.GetClient7().DeleteByQuery(es.capturesIndex).
+		Query(es7.NewTermQuery("session", id)).
+		ProceedOnVersionConflict().
+		Do(ctx)
+	return err
+}
+
+func (es *elasticsearch) indexRecord(ctx context.Context, r record) error {
+	request := util.GetClient7().Index().
+		Index(es.capturesIndex).
+		Id(r.Session + ":" + r.ID).
+		BodyJson(r)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+// getRecords returns the latest captures of the session.
+func (es *elasticsearch) getRecords(ctx context.Context, sessionID string, size int) ([]record, error) {
+	response, err := util.GetClient7().Search().
+		Index(es.capturesIndex).
+		Query(es7.NewTermQuery("session", sessionID)).
+		Sort("captured_at", false).
+		Size(size).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	records := []record{}
+	for _, hit := range response.Hits.Hits {
+		var r record
+		if err := json.Unmarshal(hit.Source, &r); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal capture %s: %v", hit.Id, err)
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func (es *elasticsearch) getRecord(ctx context.Context, sessionID, id string) (*record, error) {
+	request := util.GetClient7().Get().
+		Index(es.capturesIndex).
+		Id(sessionID + ":" + id).
+		FetchSource(true)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	response, err := request.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var r record
+	if err := json.Unmarshal(response.Source, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// purge deletes the captures past their retention along with the sessions
+// that expired before the retention.
+func (es *elasticsearch) purge(ctx context.Context, now time.Time, retention time.Duration) error {
+	_, err := util.GetClient7().DeleteByQuery(es.capturesIndex).
+		Query(es7.NewRangeQuery("expires_at").Lt(now)).
+		ProceedOnVersionConflict().
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = util.GetClient7().DeleteByQuery(es.sessionsIndex).
+		Query(es7.NewRangeQuery("expires_at").Lt(now.Add(-retention))).
+		ProceedOnVersionConflict().
+		Do(ctx)
+	return err
+}
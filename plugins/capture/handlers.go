@@ -0,0 +1,205 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/gorilla/mux"
+)
+
+const (
+	defaultCapturesSize = 100
+	maxCapturesSize     = 1000
+)
+
+func (c *capture) getSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		sessions, err := c.es.getSessions(req.Context())
+		if err != nil {
+			msg := "an error occurred while fetching the capture sessions"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		raw, err := json.Marshal(sessions)
+		if err != nil {
+			msg := "an error occurred while fetching the capture sessions"
+			log.Errorln(logTag, ": unable to marshal capture sessions:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (c *capture) postSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		creator, _, _ := req.BasicAuth()
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var s session
+		if err := json.Unmarshal(body, &s); err != nil {
+			msg := "can't parse request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		s.ID = util.NewRequestID()
+		s.Creator = creator
+		if err := s.init(time.Now()); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := c.es.putSession(req.Context(), s); err != nil {
+			msg := "an error occurred while saving the capture session"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		c.sessions.add(&s)
+
+		raw, err := json.Marshal(s)
+		if err != nil {
+			msg := "an error occurred while saving the capture session"
+			log.Errorln(logTag, ": unable to marshal capture session:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusCreated)
+	}
+}
+
+// getSession returns the session along with its latest captures, the number
+// of captures is set by "size".
+func (c *capture) getSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := mux.Vars(req)["id"]
+
+		size := defaultCapturesSize
+		if value := req.URL.Query().Get("size"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 || n > maxCapturesSize {
+				msg := fmt.Sprintf(`invalid value "%s" for query param "size", expected a number between 1 and %d`, value, maxCapturesSize)
+				util.WriteBackError(w, msg, http.StatusBadRequest)
+				return
+			}
+			size = n
+		}
+
+		s, err := c.es.getSession(req.Context(), id)
+		if err != nil {
+			msg := fmt.Sprintf(`capture session with "id"="%s" not found`, id)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		records, err := c.es.getRecords(req.Context(), id, size)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching the captures of session "%s"`, id)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		raw, err := json.Marshal(map[string]interface{}{
+			"session":  s,
+			"captures": records,
+		})
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching capture session "%s"`, id)
+			log.Errorln(logTag, ": unable to marshal capture session:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (c *capture) deleteSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := mux.Vars(req)["id"]
+
+		c.sessions.remove(id)
+		if err := c.es.deleteSession(req.Context(), id); err != nil {
+			msg := fmt.Sprintf(`capture session with "id"="%s" not found`, id)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		msg := fmt.Sprintf(`capture session "%s" deleted`, id)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+// replay re-sends the captured request and returns its response along with
+// the captured one.
+func (c *capture) replay() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		id, captureID := vars["id"], vars["capture"]
+
+		var opts replayOptions
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &opts); err != nil {
+				msg := "can't parse request body"
+				log.Errorln(logTag, ":", msg, ":", err)
+				util.WriteBackError(w, msg, http.StatusBadRequest)
+				return
+			}
+		}
+
+		r, err := c.es.getRecord(req.Context(), id, captureID)
+		if err != nil {
+			msg := fmt.Sprintf(`capture "%s" of session "%s" not found`, captureID, id)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		result, err := replay(req.Context(), r, opts)
+		if _, ok := err.(invalidReplayError); ok {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while replaying capture "%s"`, captureID)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackErrorWithDetails(w, msg, http.StatusBadGateway, []util.ErrorDetail{
+				{Type: "replay_exception", Reason: err.Error()},
+			})
+			return
+		}
+
+		raw, err := json.Marshal(result)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while replaying capture "%s"`, captureID)
+			log.Errorln(logTag, ": unable to marshal replay:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
@@ -0,0 +1,188 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/state"
+)
+
+// sensitiveHeaders aren't captured, the replayed requests are authenticated
+// with the credentials of the target cluster.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The captures span all the credentials and indices, hence require cluster
+// level access.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clustersCategory := category.Clusters
+		ctx := category.NewContext(req.Context(), &clustersCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusterACL := acl.Cluster
+		ctx := acl.NewContext(req.Context(), &clusterACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the captures hold the
+// requests of all the credentials.
+var isAdmin = validate.Admin("access the captures")
+
+type capturedRequest struct {
+	Method  string      `json:"method"`
+	URI     string      `json:"uri"`
+	Headers http.Header `json:"headers"`
+	Body    string      `json:"body"`
+}
+
+type capturedResponse struct {
+	Code      int         `json:"code"`
+	Headers   http.Header `json:"headers"`
+	Body      string      `json:"body"`
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+// record is a captured request/response pair.
+type record struct {
+	ID         string           `json:"id"`
+	Session    string           `json:"session"`
+	Credential string           `json:"credential,omitempty"`
+	Op         string           `json:"op"`
+	Request    capturedRequest  `json:"request"`
+	Response   capturedResponse `json:"response"`
+	TookMs     int64            `json:"took_ms"`
+	CapturedAt time.Time        `json:"captured_at"`
+	ExpiresAt  time.Time        `json:"expires_at"`
+}
+
+// capture records the requests matching the active sessions along with their
+// responses, the client is served as the response is written.
+func (c *capture) capture(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		username, _, _ := req.BasicAuth()
+		start := time.Now()
+		sessions := c.sessions.matching(req, username, start)
+		if len(sessions) == 0 {
+			h(w, req)
+			return
+		}
+
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = util.ReadRequestBody(req)
+			if err != nil {
+				log.Errorln(logTag, ": unable to read request body:", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+		}
+
+		rec := record{
+			ID:         w.Header().Get(util.RequestIDHeader),
+			Credential: username,
+			Request: capturedRequest{
+				Method:  req.Method,
+				URI:     req.URL.RequestURI(),
+				Headers: redact(req.Header),
+				Body:    string(body),
+			},
+			CapturedAt: start,
+		}
+		if rec.ID == "" {
+			rec.ID = util.NewRequestID()
+		}
+		if reqOp, err := op.FromContext(req.Context()); err == nil {
+			rec.Op = reqOp.String()
+		}
+
+		tee := util.NewTeeResponseWriter(w, c.maxBodySize)
+		h(tee, req)
+
+		rec.TookMs = time.Since(start).Nanoseconds() / int64(time.Millisecond)
+		rec.Response = capturedResponse{
+			Code:      tee.Code(),
+			Headers:   w.Header().Clone(),
+			Body:      string(tee.Body()),
+			Truncated: tee.Truncated(),
+		}
+		go c.record(sessions, rec)
+	}
+}
+
+// record stores the pair for each of the sessions it matches, within the
+// maximum captures of the session across the arc instances.
+func (c *capture) record(sessions []*session, rec record) {
+	ctx := context.Background()
+	for _, s := range sessions {
+		if !s.Filter.matchesStatus(rec.Response.Code) {
+			continue
+		}
+		key := fmt.Sprintf("capture:%s:count", s.ID)
+		n, err := state.Instance().Incr(ctx, key, 1, time.Until(s.ExpiresAt)+time.Minute)
+		if err != nil {
+			log.Errorln(logTag, ": unable to count the captures of session", s.ID, ":", err)
+			continue
+		}
+		if n > s.MaxCaptures {
+			continue
+		}
+		r := rec
+		r.Session = s.ID
+		r.ExpiresAt = s.ExpiresAt.Add(c.retention)
+		if err := c.es.indexRecord(ctx, r); err != nil {
+			log.Errorln(logTag, ": unable to store the capture", r.ID, "of session", s.ID, ":", err)
+		}
+	}
+}
+
+func redact(header http.Header) http.Header {
+	redacted := header.Clone()
+	for _, h := range sensitiveHeaders {
+		redacted.Del(h)
+	}
+	return redacted
+}
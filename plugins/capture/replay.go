@@ -0,0 +1,107 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/util"
+)
+
+const envEsURL = "ES_CLUSTER_URL"
+
+var errWriteReplay = errors.New(`the captured request modifies the data, set "allow_writes" in order to replay it`)
+
+// invalidReplayError is returned for the replays that can't be sent as requested.
+type invalidReplayError struct {
+	error
+}
+
+// replayOptions configures the replay of a capture. The capture is replayed
+// against the upstream cluster unless a target cluster url is provided.
+type replayOptions struct {
+	Target      string `json:"target,omitempty"`
+	AllowWrites bool   `json:"allow_writes,omitempty"`
+}
+
+type replayResult struct {
+	Target   string           `json:"target"`
+	Request  capturedRequest  `json:"request"`
+	Original capturedResponse `json:"original"`
+	Replayed capturedResponse `json:"replayed"`
+	TookMs   int64            `json:"took_ms"`
+}
+
+// replay re-sends the captured request to the target cluster. Only the read
+// requests are replayed unless the writes are explicitly allowed.
+func replay(ctx context.Context, r *record, opts replayOptions) (*replayResult, error) {
+	if r.Op != op.Read.String() && !opts.AllowWrites {
+		return nil, invalidReplayError{errWriteReplay}
+	}
+
+	target := opts.Target
+	if target == "" {
+		target = os.Getenv(envEsURL)
+	}
+	u, err := targetURL(target, r.Request.URI)
+	if err != nil {
+		return nil, invalidReplayError{err}
+	}
+
+	req, err := http.NewRequest(r.Request.Method, u.String(), strings.NewReader(r.Request.Body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType := r.Request.Headers.Get("Content-Type"); contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	start := time.Now()
+	response, err := util.HTTPClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// the credentials of the target aren't reported back
+	u.User = nil
+	return &replayResult{
+		Target:   u.String(),
+		Request:  r.Request,
+		Original: r.Response,
+		Replayed: capturedResponse{
+			Code:    response.StatusCode,
+			Headers: response.Header,
+			Body:    string(body),
+		},
+		TookMs: time.Since(start).Nanoseconds() / int64(time.Millisecond),
+	}, nil
+}
+
+// targetURL resolves the captured request uri against the target cluster url.
+func targetURL(target, uri string) (*url.URL, error) {
+	base, err := url.Parse(target)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf(`invalid "target" "%s", expected an http(s) url`, target)
+	}
+	ref, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return nil, fmt.Errorf(`invalid captured uri "%s": %v`, uri, err)
+	}
+	u := *base
+	u.Path = strings.TrimSuffix(base.Path, "/") + ref.Path
+	u.RawPath = ""
+	u.RawQuery = ref.RawQuery
+	return &u, nil
+}
@@ -0,0 +1,49 @@
+package capture
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (c *capture) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get capture sessions",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_captures",
+			HandlerFunc: middleware(isAdmin(c.getSessions())),
+			Description: "Returns the capture sessions",
+		},
+		{
+			Name:        "Create capture session",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_capture",
+			HandlerFunc: middleware(isAdmin(c.postSession())),
+			Description: "Starts capturing the requests matching the filter for a limited time",
+		},
+		{
+			Name:        "Get capture session",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_capture/{id}",
+			HandlerFunc: middleware(isAdmin(c.getSession())),
+			Description: "Returns the capture session {id} along with the latest captures",
+		},
+		{
+			Name:        "Delete capture session",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_capture/{id}",
+			HandlerFunc: middleware(isAdmin(c.deleteSession())),
+			Description: "Stops the capture session {id} and deletes its captures",
+		},
+		{
+			Name:        "Replay capture",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_replay/{id}/{capture}",
+			HandlerFunc: middleware(isAdmin(c.replay())),
+			Description: "Re-sends the captured request {capture} of session {id} to elasticsearch or the given cluster",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,166 @@
+package capture
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDuration    = 15 * time.Minute
+	maxDuration        = 24 * time.Hour
+	defaultMaxCaptures = 100
+	maxMaxCaptures     = 10000
+)
+
+// filter selects the requests captured by a session, the empty criteria match
+// all the requests.
+type filter struct {
+	Path       string   `json:"path,omitempty"`
+	Methods    []string `json:"methods,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+	Status     []int    `json:"status,omitempty"`
+	MinStatus  int      `json:"min_status,omitempty"`
+	path       *regexp.Regexp
+}
+
+// compile prepares the path pattern of the filter.
+func (f *filter) compile() error {
+	if f.Path == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(f.Path)
+	if err != nil {
+		return fmt.Errorf(`invalid "filter.path" pattern "%s": %v`, f.Path, err)
+	}
+	f.path = pattern
+	return nil
+}
+
+// matchesRequest checks the criteria known before the request is served.
+func (f *filter) matchesRequest(req *http.Request, credential string) bool {
+	if f.path != nil && !f.path.MatchString(req.URL.Path) {
+		return false
+	}
+	if f.Credential != "" && f.Credential != credential {
+		return false
+	}
+	if len(f.Methods) == 0 {
+		return true
+	}
+	for _, m := range f.Methods {
+		if strings.EqualFold(m, req.Method) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesStatus checks the status code of the response.
+func (f *filter) matchesStatus(code int) bool {
+	if f.MinStatus > 0 && code < f.MinStatus {
+		return false
+	}
+	if len(f.Status) == 0 {
+		return true
+	}
+	for _, s := range f.Status {
+		if s == code {
+			return true
+		}
+	}
+	return false
+}
+
+// session captures the requests matching its filter until it expires or the
+// maximum number of captures is reached.
+type session struct {
+	ID          string    `json:"id"`
+	Filter      filter    `json:"filter"`
+	Duration    string    `json:"duration,omitempty"`
+	MaxCaptures int64     `json:"max_captures"`
+	Creator     string    `json:"creator,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// init validates the session and sets its defaults and expiry.
+func (s *session) init(now time.Time) error {
+	duration := defaultDuration
+	if s.Duration != "" {
+		d, err := time.ParseDuration(s.Duration)
+		if err != nil || d <= 0 || d > maxDuration {
+			return fmt.Errorf(`invalid "duration" "%s", expected a positive duration of at most %v`, s.Duration, maxDuration)
+		}
+		duration = d
+	}
+	if s.MaxCaptures == 0 {
+		s.MaxCaptures = defaultMaxCaptures
+	}
+	if s.MaxCaptures < 0 || s.MaxCaptures > maxMaxCaptures {
+		return fmt.Errorf(`"max_captures" must be between 1 and %d`, maxMaxCaptures)
+	}
+	if err := s.Filter.compile(); err != nil {
+		return err
+	}
+	s.CreatedAt = now
+	s.ExpiresAt = now.Add(duration)
+	return nil
+}
+
+func (s *session) active(now time.Time) bool {
+	return now.Before(s.ExpiresAt)
+}
+
+// registry holds the sessions known to the arc instance.
+type registry struct {
+	mu       sync.RWMutex
+	sessions map[string]*session
+}
+
+func newRegistry() *registry {
+	return &registry{sessions: make(map[string]*session)}
+}
+
+// set replaces the known sessions with the loaded ones.
+func (r *registry) set(sessions []session) {
+	loaded := make(map[string]*session, len(sessions))
+	for i := range sessions {
+		s := &sessions[i]
+		if err := s.Filter.compile(); err != nil {
+			continue
+		}
+		loaded[s.ID] = s
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions = loaded
+}
+
+func (r *registry) add(s *session) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[s.ID] = s
+}
+
+func (r *registry) remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+}
+
+// matching returns the active sessions whose filter matches the request.
+func (r *registry) matching(req *http.Request, credential string, now time.Time) []*session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var matched []*session
+	for _, s := range r.sessions {
+		if s.active(now) && s.Filter.matchesRequest(req, credential) {
+			matched = append(matched, s)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,161 @@
+package clickrank
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                  = "[clickrank]"
+	defaultAnalyticsIndex   = ".analytics"
+	envAnalyticsEsIndex     = "ANALYTICS_ES_INDEX"
+	envQueryField           = "CLICK_RANK_QUERY_FIELD"
+	defaultQueryField       = "search_query"
+	envDocField             = "CLICK_RANK_DOC_FIELD"
+	defaultDocField         = "click_id"
+	envClickField           = "CLICK_RANK_CLICK_FIELD"
+	defaultClickField       = "click"
+	envConversionField      = "CLICK_RANK_CONVERSION_FIELD"
+	defaultConversionField  = "conversion"
+	envTimestampField       = "CLICK_RANK_TIMESTAMP_FIELD"
+	defaultTimestampField   = "timestamp"
+	envWindow               = "CLICK_RANK_WINDOW"
+	defaultWindow           = 30 * 24 * time.Hour
+	envRefreshInterval      = "CLICK_RANK_REFRESH_INTERVAL"
+	defaultRefreshInterval  = 10 * time.Minute
+	envWeight               = "CLICK_RANK_WEIGHT"
+	defaultWeight           = 1.0
+	envConversionWeight     = "CLICK_RANK_CONVERSION_WEIGHT"
+	defaultConversionWeight = 5.0
+	// hookName is the name of the response hook re-ranking the hits.
+	hookName = "click_rank"
+	// hookOrder runs the re-ranking before the hits are deduplicated or projected.
+	hookOrder = 10
+)
+
+var (
+	singleton *clickRank
+	once      sync.Once
+)
+
+// clickRank maintains the popularity of the documents clicked for each
+// query, aggregated from the analytics records, and re-ranks the hits of the
+// opted-in searches by boosting the popular ones.
+type clickRank struct {
+	es              *elasticsearch
+	scores          *scores
+	weight          float64
+	refreshInterval time.Duration
+}
+
+// Use only this function to fetch the instance of clickRank from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *clickRank {
+	once.Do(func() {
+		singleton = &clickRank{
+			scores:          newScores(),
+			weight:          defaultWeight,
+			refreshInterval: defaultRefreshInterval,
+		}
+	})
+	return singleton
+}
+
+func (c *clickRank) Name() string {
+	return logTag
+}
+
+func (c *clickRank) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	analyticsIndex := os.Getenv(envAnalyticsEsIndex)
+	if analyticsIndex == "" {
+		analyticsIndex = defaultAnalyticsIndex
+	}
+	fields := recordFields{
+		query:      fromEnv(envQueryField, defaultQueryField),
+		doc:        fromEnv(envDocField, defaultDocField),
+		click:      fromEnv(envClickField, defaultClickField),
+		conversion: fromEnv(envConversionField, defaultConversionField),
+		timestamp:  fromEnv(envTimestampField, defaultTimestampField),
+	}
+	window := durationFromEnv(envWindow, defaultWindow)
+	c.refreshInterval = durationFromEnv(envRefreshInterval, defaultRefreshInterval)
+	c.weight = floatFromEnv(envWeight, defaultWeight)
+	conversionWeight := floatFromEnv(envConversionWeight, defaultConversionWeight)
+	c.es = &elasticsearch{analyticsIndex, fields, window, conversionWeight}
+
+	plugins.RegisterResponseHook(hookName, hookOrder, c.rerank)
+
+	// the scores are aggregated by each arc instance
+	go func() {
+		c.refresh()
+		for range time.Tick(c.refreshInterval) {
+			c.refresh()
+		}
+	}()
+	return nil
+}
+
+func (c *clickRank) Routes() []plugins.Route {
+	return c.routes()
+}
+
+func (c *clickRank) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{c.optIn}
+}
+
+// refresh replaces the scores with the ones aggregated from the latest
+// analytics records.
+func (c *clickRank) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.refreshInterval)
+	defer cancel()
+
+	popularity, err := c.es.popularity(ctx)
+	if err != nil {
+		log.Errorln(logTag, ": unable to aggregate the clicks from the analytics:", err)
+		return
+	}
+	c.scores.set(popularity, time.Now())
+}
+
+func fromEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Errorln(logTag, ": invalid value for", key, ":", value)
+		return defaultValue
+	}
+	return d
+}
+
+func floatFromEnv(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		log.Errorln(logTag, ": invalid value for", key, ":", value)
+		return defaultValue
+	}
+	return f
+}
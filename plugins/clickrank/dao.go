@@ -0,0 +1,133 @@
+package clickrank
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+const (
+	// maxQueries bounds the queries whose clicks are aggregated, the most
+	// clicked queries first.
+	maxQueries = 10000
+	// maxDocs bounds the documents scored per query.
+	maxDocs = 100
+)
+
+// recordFields are the fields of the analytics records the clicks are
+// aggregated from.
+type recordFields struct {
+	query      string
+	doc        string
+	click      string
+	conversion string
+	timestamp  string
+}
+
+type elasticsearch struct {
+	analyticsIndex   string
+	fields           recordFields
+	window           time.Duration
+	conversionWeight float64
+}
+
+type bucket struct {
+	Key      interface{} `json:"key"`
+	DocCount int64       `json:"doc_count"`
+}
+
+// popularity aggregates the clicks of the window into the score of each
+// clicked document per query, the clicks that led to a conversion weigh more.
+// The aggregation is made via a raw request since the response of the es6
+// searches can't be parsed by the es7 client.
+func (es *elasticsearch) popularity(ctx context.Context) (map[string]map[string]float64, error) {
+	f := es.fields
+	body := map[string]interface{}{
+		"size": 0,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{"term": map[string]interface{}{f.click: true}},
+					map[string]interface{}{"range": map[string]interface{}{
+						f.timestamp: map[string]interface{}{"gte": time.Now().Add(-es.window).Format(time.RFC3339)},
+					}},
+				},
+			},
+		},
+		"aggs": map[string]interface{}{
+			"queries": map[string]interface{}{
+				"terms": map[string]interface{}{"field": f.query, "size": maxQueries},
+				"aggs": map[string]interface{}{
+					"docs": map[string]interface{}{
+						"terms": map[string]interface{}{"field": f.doc, "size": maxDocs},
+						"aggs": map[string]interface{}{
+							"conversions": map[string]interface{}{
+								"filter": map[string]interface{}{"term": map[string]interface{}{f.conversion: true}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(es.analyticsIndex) + "/_search",
+		Body:   body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Aggregations struct {
+			Queries struct {
+				Buckets []struct {
+					bucket
+					Docs struct {
+						Buckets []struct {
+							bucket
+							Conversions struct {
+								DocCount int64 `json:"doc_count"`
+							} `json:"conversions"`
+						} `json:"buckets"`
+					} `json:"docs"`
+				} `json:"buckets"`
+			} `json:"queries"`
+		} `json:"aggregations"`
+	}
+	if err := json.Unmarshal(response.Body, &result); err != nil {
+		return nil, err
+	}
+
+	popularity := make(map[string]map[string]float64)
+	for _, q := range result.Aggregations.Queries.Buckets {
+		query := normalize(key(q.Key))
+		if query == "" {
+			continue
+		}
+		docs, ok := popularity[query]
+		if !ok {
+			docs = make(map[string]float64)
+			popularity[query] = docs
+		}
+		for _, d := range q.Docs.Buckets {
+			docs[key(d.Key)] += float64(d.DocCount) + es.conversionWeight*float64(d.Conversions.DocCount)
+		}
+	}
+	return popularity, nil
+}
+
+// key returns the bucket key as a string, the numeric keys included.
+func key(k interface{}) string {
+	if s, ok := k.(string); ok {
+		return s
+	}
+	raw, _ := json.Marshal(k)
+	return string(raw)
+}
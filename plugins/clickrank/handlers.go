@@ -0,0 +1,63 @@
+package clickrank
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+type docPopularity struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+	Boost float64 `json:"boost"`
+}
+
+type popularityResponse struct {
+	Queries     int             `json:"queries"`
+	RefreshedAt *time.Time      `json:"refreshed_at,omitempty"`
+	Query       string          `json:"query,omitempty"`
+	Docs        []docPopularity `json:"docs,omitempty"`
+}
+
+// getPopularity reports the number of queries with clicks and, for the query
+// param, the popularity of the clicked documents along with the boost of
+// their scores, the most popular first.
+func (c *clickRank) getPopularity() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var response popularityResponse
+		var refreshedAt time.Time
+		response.Queries, refreshedAt = c.scores.stats()
+		if !refreshedAt.IsZero() {
+			response.RefreshedAt = &refreshedAt
+		}
+		if query := req.URL.Query().Get("query"); query != "" {
+			response.Query = normalize(query)
+			response.Docs = []docPopularity{}
+			if q := c.scores.forQuery(query); q != nil {
+				for id, score := range q.docs {
+					response.Docs = append(response.Docs, docPopularity{id, score, q.boost(id, c.weight)})
+				}
+				sort.Slice(response.Docs, func(i, j int) bool {
+					if response.Docs[i].Score == response.Docs[j].Score {
+						return response.Docs[i].ID < response.Docs[j].ID
+					}
+					return response.Docs[i].Score > response.Docs[j].Score
+				})
+			}
+		}
+
+		raw, err := json.Marshal(response)
+		if err != nil {
+			msg := "error encoding the click popularity"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
@@ -0,0 +1,212 @@
+package clickrank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/feature"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	// clickRankParam and clickRankHeader opt the search in the re-ranking,
+	// either by "true" or by the weight of the boost.
+	clickRankParam  = "click_rank"
+	clickRankHeader = "X-Arc-Click-Rank"
+	// queryHeader carries the query searched by the user, as it does for the
+	// analytics.
+	queryHeader = "X-Search-Query"
+	// rerankKey is the key against which the re-ranking of the request is
+	// stored in the context.
+	rerankKey = contextKey("rerank")
+)
+
+type contextKey string
+
+// rerank is the re-ranking of the hits of a search by their popularity for
+// the query.
+type rerank struct {
+	query  string
+	weight float64
+}
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The popularity is aggregated from the analytics, hence the credentials
+// must be able to access the analytics category.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyIndices,
+		logs.Recorder(),
+		classify.Op(),
+		auth.BasicAuth(),
+		validate.Operation(),
+		validate.Category(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		analyticsCategory := category.Analytics
+		ctx := category.NewContext(req.Context(), &analyticsCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyIndices(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := index.NewContext(req.Context(), []string{})
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the popularity spans the
+// searches of all the credentials.
+var isAdmin = validate.Admin("access the click popularity")
+
+// optIn flags the searches opted in the re-ranking by either the click_rank
+// query param or header, the param is removed from the request forwarded to
+// elasticsearch. The searches without the query header aren't re-ranked.
+// The "rerank" feature toggled by an admin overrides the opt-in.
+func (c *clickRank) optIn(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		value := query.Get(clickRankParam)
+		if _, ok := query[clickRankParam]; ok {
+			query.Del(clickRankParam)
+			req.URL.RawQuery = query.Encode()
+		}
+		if value == "" {
+			value = req.Header.Get(clickRankHeader)
+		}
+		req.Header.Del(clickRankHeader)
+		if enabled, ok := feature.Toggled(req.Context(), feature.Rerank); ok {
+			if !enabled {
+				value = ""
+			} else if value == "" || value == "false" {
+				value = "true"
+			}
+		}
+
+		searched := strings.TrimSpace(req.Header.Get(queryHeader))
+		if value == "" || value == "false" || searched == "" {
+			h(w, req)
+			return
+		}
+		reqACL, err := acl.FromContext(req.Context())
+		if err != nil || *reqACL != acl.Search {
+			h(w, req)
+			return
+		}
+
+		weight := c.weight
+		if value != "true" {
+			weight, err = strconv.ParseFloat(value, 64)
+			if err != nil || weight < 0 {
+				msg := fmt.Sprintf(`invalid "%s" value "%s", must be either "true" or a non-negative weight`, clickRankParam, value)
+				util.WriteBackError(w, msg, http.StatusBadRequest)
+				return
+			}
+		}
+		ctx := context.WithValue(req.Context(), rerankKey, &rerank{query: searched, weight: weight})
+		h(w, req.WithContext(ctx))
+	}
+}
+
+// rerank boosts the scores of the hits of the opted-in searches by the
+// popularity of the documents for the query, and sorts the hits by the
+// boosted scores. The hits are only re-ranked within the page, and not at
+// all if sorted by other than the score.
+func (c *clickRank) rerank(req *http.Request, resp *plugins.Response) error {
+	r, ok := req.Context().Value(rerankKey).(*rerank)
+	if !ok || resp.Code != http.StatusOK {
+		return nil
+	}
+	q := c.scores.forQuery(r.query)
+	if q == nil {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(resp.Body))
+	decoder.UseNumber()
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return err
+	}
+	hits, _ := body["hits"].(map[string]interface{})
+	items, _ := hits["hits"].([]interface{})
+	maxScore, boosted := boostHits(items, q, r.weight)
+	if !boosted {
+		return nil
+	}
+	hits["max_score"] = maxScore
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp.Body = raw
+	return nil
+}
+
+// boostHits boosts the scores of the hits and sorts them by the boosted
+// scores, the hits are left untouched if any of them isn't scored.
+func boostHits(items []interface{}, q *queryScores, weight float64) (float64, bool) {
+	scored := make([]float64, len(items))
+	for i, item := range items {
+		hit, ok := item.(map[string]interface{})
+		if !ok {
+			return 0, false
+		}
+		score, ok := hit["_score"].(json.Number)
+		if !ok {
+			return 0, false
+		}
+		f, err := score.Float64()
+		if err != nil {
+			return 0, false
+		}
+		id, _ := hit["_id"].(string)
+		scored[i] = f * q.boost(id, weight)
+	}
+	if len(items) == 0 {
+		return 0, false
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return scored[order[i]] > scored[order[j]] })
+	sorted := make([]interface{}, len(items))
+	for i, o := range order {
+		hit := items[o].(map[string]interface{})
+		hit["_score"] = scored[o]
+		sorted[i] = hit
+	}
+	copy(items, sorted)
+	return scored[order[0]], true
+}
@@ -0,0 +1,21 @@
+package clickrank
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (c *clickRank) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get click popularity",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_click_rank",
+			HandlerFunc: middleware(isAdmin(c.getPopularity())),
+			Description: "Returns the number of queries with clicks along with the popularity of the documents clicked for the query param",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,69 @@
+package clickrank
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// scores holds the popularity of the documents clicked for each query.
+type scores struct {
+	sync.RWMutex
+	byQuery     map[string]*queryScores
+	refreshedAt time.Time
+}
+
+// queryScores are the popularity of the documents clicked for a query along
+// with the highest of them, by which they are normalized.
+type queryScores struct {
+	docs map[string]float64
+	max  float64
+}
+
+func newScores() *scores {
+	return &scores{byQuery: make(map[string]*queryScores)}
+}
+
+// normalize folds the case and the spacing of the query.
+func normalize(query string) string {
+	return strings.ToLower(strings.Join(strings.Fields(query), " "))
+}
+
+func (s *scores) set(popularity map[string]map[string]float64, at time.Time) {
+	byQuery := make(map[string]*queryScores, len(popularity))
+	for query, docs := range popularity {
+		q := &queryScores{docs: docs}
+		for _, score := range docs {
+			if score > q.max {
+				q.max = score
+			}
+		}
+		if q.max > 0 {
+			byQuery[query] = q
+		}
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.byQuery = byQuery
+	s.refreshedAt = at
+}
+
+// forQuery returns the scores of the query, if any. The scores are replaced
+// on refresh rather than updated, hence can be read once returned.
+func (s *scores) forQuery(query string) *queryScores {
+	s.RLock()
+	defer s.RUnlock()
+	return s.byQuery[normalize(query)]
+}
+
+func (s *scores) stats() (int, time.Time) {
+	s.RLock()
+	defer s.RUnlock()
+	return len(s.byQuery), s.refreshedAt
+}
+
+// boost returns the factor by which the score of the document is boosted,
+// the most popular document of the query is boosted by 1 + weight.
+func (q *queryScores) boost(doc string, weight float64) float64 {
+	return 1 + weight*q.docs[doc]/q.max
+}
@@ -0,0 +1,76 @@
+package clickrank
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func decodeHits(raw string) []interface{} {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(raw)))
+	decoder.UseNumber()
+	var hits []interface{}
+	decoder.Decode(&hits)
+	return hits
+}
+
+func ids(hits []interface{}) []string {
+	var ids []string
+	for _, hit := range hits {
+		ids = append(ids, hit.(map[string]interface{})["_id"].(string))
+	}
+	return ids
+}
+
+func TestScores(t *testing.T) {
+	s := newScores()
+	s.set(map[string]map[string]float64{
+		"running shoes": {"a": 10, "b": 5},
+		"socks":         {},
+	}, time.Now())
+
+	Convey("The scores are looked up by the normalized query", t, func() {
+		q := s.forQuery("  Running   SHOES ")
+		So(q, ShouldNotBeNil)
+		So(q.max, ShouldEqual, 10)
+		So(q.boost("a", 1), ShouldEqual, 2)
+		So(q.boost("b", 1), ShouldEqual, 1.5)
+		So(q.boost("c", 1), ShouldEqual, 1)
+	})
+
+	Convey("Queries without clicks have no scores", t, func() {
+		So(s.forQuery("socks"), ShouldBeNil)
+		So(s.forQuery("hats"), ShouldBeNil)
+		n, _ := s.stats()
+		So(n, ShouldEqual, 1)
+	})
+}
+
+func TestBoostHits(t *testing.T) {
+	q := &queryScores{docs: map[string]float64{"c": 10, "b": 2}, max: 10}
+
+	Convey("The popular hits are boosted", t, func() {
+		hits := decodeHits(`[{"_id": "a", "_score": 3}, {"_id": "b", "_score": 2}, {"_id": "c", "_score": 1.6}]`)
+		maxScore, ok := boostHits(hits, q, 1)
+		So(ok, ShouldBeTrue)
+		So(ids(hits), ShouldResemble, []string{"c", "a", "b"})
+		So(maxScore, ShouldEqual, 3.2)
+	})
+
+	Convey("A zero weight keeps the ranking", t, func() {
+		hits := decodeHits(`[{"_id": "a", "_score": 3}, {"_id": "b", "_score": 2}, {"_id": "c", "_score": 1.6}]`)
+		_, ok := boostHits(hits, q, 0)
+		So(ok, ShouldBeTrue)
+		So(ids(hits), ShouldResemble, []string{"a", "b", "c"})
+	})
+
+	Convey("Hits sorted by other than the score aren't re-ranked", t, func() {
+		hits := decodeHits(`[{"_id": "a", "_score": null, "sort": [1]}, {"_id": "c", "_score": null, "sort": [2]}]`)
+		_, ok := boostHits(hits, q, 1)
+		So(ok, ShouldBeFalse)
+		So(ids(hits), ShouldResemble, []string{"a", "c"})
+	})
+}
@@ -0,0 +1,99 @@
+package concurrency
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag               = "[concurrency]"
+	envLimit             = "CONCURRENCY_LIMIT"
+	envCredentialLimit   = "CONCURRENCY_LIMIT_PER_CREDENTIAL"
+	envMaxWait           = "CONCURRENCY_MAX_WAIT"
+	defaultMaxWait       = time.Second
+	defaultCredentialKey = "anonymous"
+)
+
+var (
+	singleton *concurrency
+	once      sync.Once
+)
+
+// concurrency bounds the number of requests made to elasticsearch that are
+// in flight at once, both per credential and across all of them. The
+// requests beyond the limits are queued for a slot up to the max wait.
+type concurrency struct {
+	global      *limiter
+	credentials *limiter
+	maxWait     time.Duration
+}
+
+// Use only this function to fetch the instance of concurrency from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *concurrency {
+	once.Do(func() { singleton = &concurrency{maxWait: defaultMaxWait} })
+	return singleton
+}
+
+func (c *concurrency) Name() string {
+	return logTag
+}
+
+func (c *concurrency) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	limit, err := limitFromEnv(envLimit)
+	if err != nil {
+		return err
+	}
+	credentialLimit, err := limitFromEnv(envCredentialLimit)
+	if err != nil {
+		return err
+	}
+	if value := os.Getenv(envMaxWait); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a non-negative duration", envMaxWait, value)
+		}
+		c.maxWait = d
+	}
+
+	if limit > 0 {
+		c.global = newLimiter(limit)
+	}
+	if credentialLimit > 0 {
+		c.credentials = newLimiter(credentialLimit)
+	}
+	if c.global == nil && c.credentials == nil {
+		log.Println(logTag, ":", envLimit, "and", envCredentialLimit, "aren't set, concurrent requests won't be limited")
+	}
+	return nil
+}
+
+func (c *concurrency) Routes() []plugins.Route {
+	return []plugins.Route{}
+}
+
+func (c *concurrency) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{c.limit}
+}
+
+func limitFromEnv(key string) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid value for %s: %s, must be a non-negative integer", key, value)
+	}
+	return n, nil
+}
@@ -0,0 +1,74 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+)
+
+// limiter bounds the number of requests in flight per key. The semaphores
+// of the keys are dropped once none of their requests are in flight or
+// queued.
+type limiter struct {
+	sync.Mutex
+	limit      int
+	semaphores map[string]*semaphore
+}
+
+type semaphore struct {
+	slots chan struct{}
+	refs  int
+}
+
+func newLimiter(limit int) *limiter {
+	return &limiter{limit: limit, semaphores: make(map[string]*semaphore)}
+}
+
+// acquire waits for a slot of the key until the context is done, and returns
+// the function releasing the slot once acquired.
+func (l *limiter) acquire(ctx context.Context, key string) (func(), bool) {
+	l.Lock()
+	s, ok := l.semaphores[key]
+	if !ok {
+		s = &semaphore{slots: make(chan struct{}, l.limit)}
+		l.semaphores[key] = s
+	}
+	s.refs++
+	l.Unlock()
+
+	release := func() {
+		<-s.slots
+		l.unref(key, s)
+	}
+	// a free slot is taken even if the context is already done
+	select {
+	case s.slots <- struct{}{}:
+		return release, true
+	default:
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return release, true
+	case <-ctx.Done():
+		l.unref(key, s)
+		return nil, false
+	}
+}
+
+func (l *limiter) unref(key string, s *semaphore) {
+	l.Lock()
+	defer l.Unlock()
+	s.refs--
+	if s.refs == 0 {
+		delete(l.semaphores, key)
+	}
+}
+
+// inFlight returns the number of requests of the key in flight.
+func (l *limiter) inFlight(key string) int {
+	l.Lock()
+	defer l.Unlock()
+	if s, ok := l.semaphores[key]; ok {
+		return len(s.slots)
+	}
+	return 0
+}
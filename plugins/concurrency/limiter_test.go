@@ -0,0 +1,78 @@
+package concurrency
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLimiter(t *testing.T) {
+	Convey("The requests beyond the limit wait for a slot", t, func() {
+		l := newLimiter(2)
+		release1, ok := l.acquire(context.Background(), "foo")
+		So(ok, ShouldBeTrue)
+		_, ok = l.acquire(context.Background(), "foo")
+		So(ok, ShouldBeTrue)
+		So(l.inFlight("foo"), ShouldEqual, 2)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, ok = l.acquire(ctx, "foo")
+		So(ok, ShouldBeFalse)
+
+		_, ok = l.acquire(ctx, "bar")
+		So(ok, ShouldBeTrue)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			release1()
+		}()
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_, ok = l.acquire(ctx, "foo")
+		So(ok, ShouldBeTrue)
+	})
+
+	Convey("The idle keys are dropped", t, func() {
+		l := newLimiter(1)
+		release, ok := l.acquire(context.Background(), "foo")
+		So(ok, ShouldBeTrue)
+		release()
+		So(l.semaphores, ShouldBeEmpty)
+	})
+}
+
+func TestLimit(t *testing.T) {
+	c := &concurrency{credentials: newLimiter(1), maxWait: 10 * time.Millisecond}
+	entered, done := make(chan struct{}), make(chan struct{})
+	handler := c.limit(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/slow/_search" {
+			close(entered)
+			<-done
+		}
+	})
+	request := func(path, username string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.SetBasicAuth(username, "secret")
+		return req
+	}
+
+	Convey("A credential can't exceed its in-flight requests", t, func() {
+		go handler(httptest.NewRecorder(), request("/slow/_search", "foo"))
+		<-entered
+
+		w := httptest.NewRecorder()
+		handler(w, request("/products/_search", "foo"))
+		So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+		So(w.Header().Get("Retry-After"), ShouldEqual, "1")
+
+		w = httptest.NewRecorder()
+		handler(w, request("/products/_search", "bar"))
+		So(w.Code, ShouldEqual, http.StatusOK)
+		close(done)
+	})
+}
@@ -0,0 +1,62 @@
+package concurrency
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// globalKey is the key of the global limit.
+const globalKey = "*"
+
+// limit holds the request until a slot is available for its credential and
+// then globally, and rejects it if none is within the max wait. The slot of
+// the credential is acquired first, so that the requests queued by a single
+// credential don't hold the global slots.
+func (c *concurrency) limit(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if c.global == nil && c.credentials == nil {
+			h(w, req)
+			return
+		}
+		ctx, cancel := context.WithTimeout(req.Context(), c.maxWait)
+		defer cancel()
+
+		if c.credentials != nil {
+			release, ok := c.credentials.acquire(ctx, credentialKey(req))
+			if !ok {
+				c.reject(w, "Too many concurrent requests for the credential")
+				return
+			}
+			defer release()
+		}
+		if c.global != nil {
+			release, ok := c.global.acquire(ctx, globalKey)
+			if !ok {
+				c.reject(w, "Too many concurrent requests")
+				return
+			}
+			defer release()
+		}
+		h(w, req)
+	}
+}
+
+func (c *concurrency) reject(w http.ResponseWriter, msg string) {
+	retryAfter := int64(c.maxWait.Seconds())
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	util.WriteBackError(w, msg, http.StatusTooManyRequests)
+}
+
+// credentialKey returns the username of the request credential.
+func credentialKey(req *http.Request) string {
+	if username, _, ok := req.BasicAuth(); ok && username != "" {
+		return username
+	}
+	return defaultCredentialKey
+}
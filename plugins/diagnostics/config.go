@@ -0,0 +1,47 @@
+package diagnostics
+
+import (
+	"net/url"
+	"strings"
+)
+
+const redacted = "[redacted]"
+
+// sensitive are the fragments of the names of the env vars whose values are
+// redacted.
+var sensitive = []string{"PASSWORD", "SECRET", "TOKEN", "KEY", "CREDENTIAL", "DSN"}
+
+// config returns the env of arc with the secrets redacted, the credentials
+// of the urls included.
+func config(environ []string) map[string]string {
+	env := make(map[string]string, len(environ))
+	for _, kv := range environ {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		env[parts[0]] = redact(parts[0], parts[1])
+	}
+	return env
+}
+
+func redact(name, value string) string {
+	if value == "" {
+		return value
+	}
+	upper := strings.ToUpper(name)
+	for _, fragment := range sensitive {
+		if strings.Contains(upper, fragment) {
+			return redacted
+		}
+	}
+	// the urls, possibly comma separated, may carry credentials
+	urls := strings.Split(value, ",")
+	for i, raw := range urls {
+		if u, err := url.Parse(strings.TrimSpace(raw)); err == nil && u.User != nil {
+			u.User = url.User("redacted")
+			urls[i] = u.String()
+		}
+	}
+	return strings.Join(urls, ",")
+}
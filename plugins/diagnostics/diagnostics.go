@@ -0,0 +1,50 @@
+package diagnostics
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const logTag = "[diagnostics]"
+
+var (
+	singleton *diagnostics
+	once      sync.Once
+)
+
+// diagnostics exposes the profiles of the go runtime and bundles the state
+// of arc into a single archive for the support cases.
+type diagnostics struct {
+	errors *recentErrors
+}
+
+// Use only this function to fetch the instance of diagnostics from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *diagnostics {
+	once.Do(func() { singleton = &diagnostics{errors: newRecentErrors()} })
+	return singleton
+}
+
+func (d *diagnostics) Name() string {
+	return logTag
+}
+
+func (d *diagnostics) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+	// the errors logged from now on are kept for the bundles
+	log.AddHook(d.errors)
+	return nil
+}
+
+func (d *diagnostics) Routes() []plugins.Route {
+	return d.routes()
+}
+
+// Default empty middleware array function
+func (d *diagnostics) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
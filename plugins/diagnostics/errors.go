@@ -0,0 +1,55 @@
+package diagnostics
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxErrors is the number of recent errors kept for the diagnostics.
+const maxErrors = 200
+
+// loggedError is an error logged by arc.
+type loggedError struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Message string    `json:"message"`
+}
+
+// recentErrors is a logrus hook keeping the last errors logged.
+type recentErrors struct {
+	mu     sync.Mutex
+	errors []loggedError
+	next   int
+}
+
+func newRecentErrors() *recentErrors {
+	return &recentErrors{errors: make([]loggedError, 0, maxErrors)}
+}
+
+func (r *recentErrors) Levels() []log.Level {
+	return []log.Level{log.PanicLevel, log.FatalLevel, log.ErrorLevel}
+}
+
+func (r *recentErrors) Fire(entry *log.Entry) error {
+	e := loggedError{Time: entry.Time, Level: entry.Level.String(), Message: entry.Message}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errors) < maxErrors {
+		r.errors = append(r.errors, e)
+		return nil
+	}
+	r.errors[r.next] = e
+	r.next = (r.next + 1) % maxErrors
+	return nil
+}
+
+// all returns the recent errors, the oldest first.
+func (r *recentErrors) all() []loggedError {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	all := make([]loggedError, 0, len(r.errors))
+	all = append(all, r.errors[r.next:]...)
+	return append(all, r.errors[:r.next]...)
+}
@@ -0,0 +1,197 @@
+package diagnostics
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	httppprof "net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/health"
+	"github.com/appbaseio/arc/util/iplookup"
+	"github.com/appbaseio/arc/util/notify"
+	"github.com/gorilla/mux"
+)
+
+// maxProfileSeconds bounds the duration of the cpu profiles and the traces.
+const maxProfileSeconds = 60
+
+// profile is a profile of the go runtime available via /_debug/pprof/{profile}.
+type profile struct {
+	Name  string `json:"name"`
+	Count int    `json:"count,omitempty"`
+}
+
+// getProfiles lists the profiles, the cpu profile and the execution trace
+// are taken over "seconds" when requested.
+func (d *diagnostics) getProfiles() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		profiles := []profile{{Name: "profile"}, {Name: "trace"}, {Name: "cmdline"}, {Name: "symbol"}}
+		for _, p := range pprof.Profiles() {
+			profiles = append(profiles, profile{Name: p.Name(), Count: p.Count()})
+		}
+		sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+
+		raw, err := json.Marshal(profiles)
+		if err != nil {
+			msg := "error encoding the profiles"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (d *diagnostics) getProfile() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["profile"]
+		if value := req.URL.Query().Get("seconds"); value != "" {
+			seconds, err := strconv.Atoi(value)
+			if err != nil || seconds <= 0 || seconds > maxProfileSeconds {
+				msg := fmt.Sprintf(`invalid value "%s" for query param "seconds", expected a number between 1 and %d`, value, maxProfileSeconds)
+				util.WriteBackError(w, msg, http.StatusBadRequest)
+				return
+			}
+		}
+
+		switch name {
+		case "profile":
+			httppprof.Profile(w, req)
+		case "trace":
+			httppprof.Trace(w, req)
+		case "cmdline":
+			httppprof.Cmdline(w, req)
+		case "symbol":
+			httppprof.Symbol(w, req)
+		default:
+			if pprof.Lookup(name) == nil {
+				msg := fmt.Sprintf(`profile "%s" not found`, name)
+				util.WriteBackError(w, msg, http.StatusNotFound)
+				return
+			}
+			httppprof.Handler(name).ServeHTTP(w, req)
+		}
+	}
+}
+
+// queue is the number of items waiting in one of the background queues of
+// arc, out of its capacity.
+type queue struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+}
+
+// runtimeStats summarizes the state of the go runtime.
+type runtimeStats struct {
+	GoVersion  string `json:"go_version"`
+	CPUs       int    `json:"cpus"`
+	Goroutines int    `json:"goroutines"`
+	HeapAlloc  uint64 `json:"heap_alloc_bytes"`
+	HeapInuse  uint64 `json:"heap_inuse_bytes"`
+	Sys        uint64 `json:"sys_bytes"`
+	NumGC      uint32 `json:"num_gc"`
+}
+
+// getDiagnostics returns a gzipped tar archive of the goroutine dump, the
+// heap profile, the config with the secrets redacted, the queue depths, the
+// health of the components, the status of the plugins and the recent errors.
+func (d *diagnostics) getDiagnostics() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		now := time.Now().UTC()
+		archive, err := d.bundle(now)
+		if err != nil {
+			msg := "error bundling the diagnostics"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		filename := fmt.Sprintf("arc-diagnostics-%s.tar.gz", now.Format("20060102T150405Z"))
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.Header().Set("Content-Length", strconv.Itoa(len(archive)))
+		w.WriteHeader(http.StatusOK)
+		w.Write(archive)
+	}
+}
+
+func (d *diagnostics) bundle(now time.Time) ([]byte, error) {
+	var goroutines, heap bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&goroutines, 2); err != nil {
+		return nil, err
+	}
+	if err := pprof.Lookup("heap").WriteTo(&heap, 0); err != nil {
+		return nil, err
+	}
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	stats := runtimeStats{
+		GoVersion:  runtime.Version(),
+		CPUs:       runtime.NumCPU(),
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  mem.HeapAlloc,
+		HeapInuse:  mem.HeapInuse,
+		Sys:        mem.Sys,
+		NumGC:      mem.NumGC,
+	}
+	queues := make(map[string]queue)
+	length, capacity := notify.Queued()
+	queues["notifications"] = queue{length, capacity}
+	length, capacity = iplookup.Instance().Queued()
+	queues["iplookup"] = queue{length, capacity}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"goroutines.txt", goroutines.Bytes()},
+		{"heap.pprof", heap.Bytes()},
+		{"runtime.json", stats},
+		{"config.json", config(os.Environ())},
+		{"queues.json", queues},
+		{"health.json", health.Run()},
+		{"plugins.json", plugins.Statuses()},
+		{"errors.json", d.errors.all()},
+	}
+
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+	for _, f := range files {
+		content, ok := f.content.([]byte)
+		if !ok {
+			raw, err := json.MarshalIndent(f.content, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("unable to encode %s: %v", f.name, err)
+			}
+			content = raw
+		}
+		header := &tar.Header{Name: f.name, Mode: 0644, Size: int64(len(content)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return archive.Bytes(), nil
+}
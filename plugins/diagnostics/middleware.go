@@ -0,0 +1,59 @@
+package diagnostics
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The diagnostics span the whole of arc, hence require cluster level access.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clustersCategory := category.Clusters
+		ctx := category.NewContext(req.Context(), &clustersCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusterACL := acl.Cluster
+		ctx := acl.NewContext(req.Context(), &clusterACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the profiles and the
+// diagnostics expose the internals of arc.
+var isAdmin = validate.Admin("access the diagnostics")
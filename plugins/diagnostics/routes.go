@@ -0,0 +1,35 @@
+package diagnostics
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (d *diagnostics) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get profiles",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_debug/pprof",
+			HandlerFunc: middleware(isAdmin(d.getProfiles())),
+			Description: "Returns the profiles of the go runtime available",
+		},
+		{
+			Name:        "Get profile",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_debug/pprof/{profile}",
+			HandlerFunc: middleware(isAdmin(d.getProfile())),
+			Description: "Returns the profile {profile} of the go runtime in the pprof format",
+		},
+		{
+			Name:        "Get diagnostics",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_diagnostics",
+			HandlerFunc: middleware(isAdmin(d.getDiagnostics())),
+			Description: "Returns an archive of the goroutines, heap profile, redacted config, queues, health and recent errors of arc",
+		},
+	}
+	return routes
+}
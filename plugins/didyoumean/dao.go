@@ -0,0 +1,119 @@
+package didyoumean
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	es7 "github.com/olivere/elastic/v7"
+
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	phraseSuggester = "phrase"
+	termSuggester   = "term"
+	// suggestionName is the name of the suggestion in the suggest request.
+	suggestionName = "did_you_mean"
+)
+
+// suggestion is a correction of the query.
+type suggestion struct {
+	Text        string  `json:"text"`
+	Highlighted string  `json:"highlighted,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+type suggestOption struct {
+	Text        string  `json:"text"`
+	Highlighted string  `json:"highlighted"`
+	Score       float64 `json:"score"`
+}
+
+type suggestEntry struct {
+	Text    string          `json:"text"`
+	Offset  int             `json:"offset"`
+	Length  int             `json:"length"`
+	Options []suggestOption `json:"options"`
+}
+
+// suggest runs the suggester over the text against the indices. The
+// request is made raw since the suggest responses of es6 can't be parsed by
+// the es7 client.
+func (d *didYouMean) suggest(ctx context.Context, indices string, t target, text string) ([]suggestion, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(indices) + "/_search",
+		Body:   suggestBody(d.suggester, t.field, text, d.size),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Suggest map[string][]suggestEntry `json:"suggest"`
+	}
+	if err := json.Unmarshal(response.Body, &result); err != nil {
+		return nil, err
+	}
+	entries := result.Suggest[suggestionName]
+	if d.suggester == termSuggester {
+		return correctTerms(text, entries), nil
+	}
+	var suggestions []suggestion
+	for _, entry := range entries {
+		for _, option := range entry.Options {
+			suggestions = append(suggestions, suggestion(option))
+		}
+	}
+	return suggestions, nil
+}
+
+func suggestBody(suggester, field, text string, size int) map[string]interface{} {
+	options := map[string]interface{}{
+		"field": field,
+		"size":  size,
+	}
+	if suggester == phraseSuggester {
+		options["direct_generator"] = []interface{}{
+			map[string]interface{}{"field": field, "suggest_mode": "always"},
+		}
+		options["highlight"] = map[string]interface{}{"pre_tag": "<em>", "post_tag": "</em>"}
+	} else {
+		options["suggest_mode"] = "popular"
+	}
+	return map[string]interface{}{
+		"size": 0,
+		"suggest": map[string]interface{}{
+			"text":         text,
+			suggestionName: map[string]interface{}{suggester: options},
+		},
+	}
+}
+
+// correctTerms builds the correction of the text out of the best option of
+// each of its terms, the score being the mean of the scores of the options.
+// There's no correction if none of the terms has an option. The offsets of
+// the terms are in characters.
+func correctTerms(text string, entries []suggestEntry) []suggestion {
+	chars := []rune(text)
+	var b strings.Builder
+	var score float64
+	var corrected, last int
+	for _, entry := range entries {
+		if len(entry.Options) == 0 || entry.Offset < last || entry.Offset+entry.Length > len(chars) {
+			continue
+		}
+		b.WriteString(string(chars[last:entry.Offset]))
+		b.WriteString(entry.Options[0].Text)
+		last = entry.Offset + entry.Length
+		score += entry.Options[0].Score
+		corrected++
+	}
+	if corrected == 0 {
+		return nil
+	}
+	b.WriteString(string(chars[last:]))
+	return []suggestion{{Text: b.String(), Score: score / float64(corrected)}}
+}
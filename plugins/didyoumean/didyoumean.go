@@ -0,0 +1,100 @@
+package didyoumean
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag           = "[didyoumean]"
+	envIndices       = "DID_YOU_MEAN_INDICES"
+	envMaxHits       = "DID_YOU_MEAN_MAX_HITS"
+	envSize          = "DID_YOU_MEAN_SIZE"
+	defaultSize      = 3
+	envSuggester     = "DID_YOU_MEAN_SUGGESTER"
+	defaultSuggester = phraseSuggester
+	// hookName is the name of the response hook attaching the suggestions.
+	hookName = "did_you_mean"
+	// hookOrder runs the suggestions once the hits are final.
+	hookOrder = 40
+)
+
+var (
+	singleton *didYouMean
+	once      sync.Once
+)
+
+// didYouMean suggests corrections of the queries of the searches returning
+// no or few hits, via the suggesters of elasticsearch. The suggestions are
+// only made for the indices it is enabled for.
+type didYouMean struct {
+	targets   []target
+	maxHits   int64
+	size      int
+	suggester string
+}
+
+// Use only this function to fetch the instance of didYouMean from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *didYouMean {
+	once.Do(func() {
+		singleton = &didYouMean{size: defaultSize, suggester: defaultSuggester}
+	})
+	return singleton
+}
+
+func (d *didYouMean) Name() string {
+	return logTag
+}
+
+func (d *didYouMean) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	targets, err := parseTargets(os.Getenv(envIndices))
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %v", envIndices, err)
+	}
+	d.targets = targets
+	if value := os.Getenv(envMaxHits); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a non-negative integer", envMaxHits, value)
+		}
+		d.maxHits = n
+	}
+	if value := os.Getenv(envSize); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a positive integer", envSize, value)
+		}
+		d.size = n
+	}
+	if value := os.Getenv(envSuggester); value != "" {
+		if value != phraseSuggester && value != termSuggester {
+			return fmt.Errorf(`invalid value for %s: %s, must be either "%s" or "%s"`, envSuggester, value, phraseSuggester, termSuggester)
+		}
+		d.suggester = value
+	}
+
+	if len(d.targets) == 0 {
+		log.Println(logTag, ":", envIndices, "isn't set, no suggestions will be made")
+		return nil
+	}
+	plugins.RegisterResponseHook(hookName, hookOrder, d.attach)
+	return nil
+}
+
+func (d *didYouMean) Routes() []plugins.Route {
+	return []plugins.Route{}
+}
+
+func (d *didYouMean) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{d.mark}
+}
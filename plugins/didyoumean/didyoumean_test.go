@@ -0,0 +1,71 @@
+package didyoumean
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTargets(t *testing.T) {
+	Convey("The targets are parsed", t, func() {
+		targets, err := parseTargets("products:title, blog-*:title.trigram,")
+		So(err, ShouldBeNil)
+		So(targets, ShouldResemble, []target{
+			{pattern: "products", field: "title"},
+			{pattern: "blog-*", field: "title.trigram"},
+		})
+
+		_, err = parseTargets("products")
+		So(err, ShouldNotBeNil)
+		_, err = parseTargets("products:")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("All the indices must belong to the target", t, func() {
+		targets, _ := parseTargets("products:title,blog-*:body")
+		tg, ok := targetOf(targets, []string{"blog-2020", "blog-2021"})
+		So(ok, ShouldBeTrue)
+		So(tg.field, ShouldEqual, "body")
+
+		_, ok = targetOf(targets, []string{"products", "blog-2020"})
+		So(ok, ShouldBeFalse)
+		_, ok = targetOf(targets, []string{"blog-2020", "orders"})
+		So(ok, ShouldBeFalse)
+	})
+}
+
+func TestTotalHits(t *testing.T) {
+	Convey("The total hits of both the versions are read", t, func() {
+		total, ok := totalHits([]byte(`{"hits": {"total": {"value": 2, "relation": "eq"}, "hits": []}}`))
+		So(ok, ShouldBeTrue)
+		So(total, ShouldEqual, 2)
+
+		total, ok = totalHits([]byte(`{"hits": {"total": 0, "hits": []}}`))
+		So(ok, ShouldBeTrue)
+		So(total, ShouldEqual, 0)
+	})
+
+	Convey("The returned hits are counted if the total isn't tracked", t, func() {
+		total, ok := totalHits([]byte(`{"hits": {"hits": [{"_id": "1"}]}}`))
+		So(ok, ShouldBeTrue)
+		So(total, ShouldEqual, 1)
+
+		_, ok = totalHits([]byte(`{"error": "boom"}`))
+		So(ok, ShouldBeFalse)
+	})
+}
+
+func TestCorrectTerms(t *testing.T) {
+	Convey("The terms are replaced by their best option", t, func() {
+		entries := []suggestEntry{
+			{Text: "blu", Offset: 0, Length: 3, Options: []suggestOption{{Text: "blue", Score: 0.75}, {Text: "blur", Score: 0.5}}},
+			{Text: "jeans", Offset: 4, Length: 5},
+			{Text: "slimm", Offset: 10, Length: 5, Options: []suggestOption{{Text: "slim", Score: 0.25}}},
+		}
+		So(correctTerms("blu jeans slimm", entries), ShouldResemble, []suggestion{{Text: "blue jeans slim", Score: 0.5}})
+	})
+
+	Convey("There's no correction without options", t, func() {
+		So(correctTerms("jeans", []suggestEntry{{Text: "jeans", Offset: 0, Length: 5}}), ShouldBeNil)
+	})
+}
@@ -0,0 +1,117 @@
+package didyoumean
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	// queryHeader carries the query searched by the user, as it does for the
+	// analytics.
+	queryHeader = "X-Search-Query"
+	// responseKey is the key of the response the suggestions are attached
+	// under, along with the other additions of arc.
+	responseKey = "_arc"
+	// markKey is the key against which the suggestion request is stored in
+	// the context.
+	markKey = contextKey("did_you_mean")
+)
+
+type contextKey string
+
+// mark holds what the suggestions of the search are made from.
+type mark struct {
+	indices string
+	target  target
+	query   string
+}
+
+// mark flags the searches made to the indices the suggestions are enabled
+// for, the searches without the query header aren't flagged.
+func (d *didYouMean) mark(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searched := strings.TrimSpace(req.Header.Get(queryHeader))
+		reqACL, err := acl.FromContext(req.Context())
+		if searched == "" || err != nil || *reqACL != acl.Search {
+			h(w, req)
+			return
+		}
+		segment := pathIndices(req.URL.Path)
+		t, ok := targetOf(d.targets, strings.Split(segment, ","))
+		if segment == "" || !ok {
+			h(w, req)
+			return
+		}
+		ctx := context.WithValue(req.Context(), markKey, &mark{indices: segment, target: t, query: searched})
+		h(w, req.WithContext(ctx))
+	}
+}
+
+// pathIndices returns the comma separated indices named by the path.
+func pathIndices(path string) string {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	if strings.HasPrefix(segment, "_") {
+		return ""
+	}
+	return segment
+}
+
+// attach attaches the suggestions to the responses of the flagged searches
+// with no more hits than DID_YOU_MEAN_MAX_HITS, under "_arc.did_you_mean".
+func (d *didYouMean) attach(req *http.Request, resp *plugins.Response) error {
+	m, ok := req.Context().Value(markKey).(*mark)
+	if !ok || resp.Code != http.StatusOK {
+		return nil
+	}
+
+	if total, ok := totalHits(resp.Body); !ok || total > d.maxHits {
+		return nil
+	}
+	suggestions, err := d.suggest(req.Context(), m.indices, m.target, m.query)
+	if err != nil || len(suggestions) == 0 {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(resp.Body))
+	decoder.UseNumber()
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return err
+	}
+	additions, _ := body[responseKey].(map[string]interface{})
+	if additions == nil {
+		additions = make(map[string]interface{})
+		body[responseKey] = additions
+	}
+	additions["did_you_mean"] = suggestions
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp.Body = raw
+	return nil
+}
+
+// totalHits returns the total hits of the search response, or the number of
+// hits returned if the total isn't tracked.
+func totalHits(raw []byte) (int64, bool) {
+	if total, err := util.ParseTotalHits(raw); err == nil {
+		return total.Value, true
+	}
+	var response struct {
+		Hits *struct {
+			Hits []json.RawMessage `json:"hits"`
+		} `json:"hits"`
+	}
+	if json.Unmarshal(raw, &response) != nil || response.Hits == nil {
+		return 0, false
+	}
+	return int64(len(response.Hits.Hits)), true
+}
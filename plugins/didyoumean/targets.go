@@ -0,0 +1,56 @@
+package didyoumean
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/appbaseio/arc/model/index"
+)
+
+// target is an index, or index pattern, the suggestions are enabled for,
+// along with the field the suggestions are made from.
+type target struct {
+	pattern string
+	field   string
+}
+
+// parseTargets parses the comma separated list of pattern:field pairs, e.g.
+// "products:title,blog-*:title.trigram".
+func parseTargets(value string) ([]target, error) {
+	var targets []target
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+			return nil, fmt.Errorf(`"%s" must be of the form "<index>:<field>"`, pair)
+		}
+		targets = append(targets, target{
+			pattern: strings.TrimSpace(parts[0]),
+			field:   strings.TrimSpace(parts[1]),
+		})
+	}
+	return targets, nil
+}
+
+// targetOf returns the target all the indices belong to, if any.
+func targetOf(targets []target, indices []string) (target, bool) {
+	if len(indices) == 0 {
+		return target{}, false
+	}
+	for _, t := range targets {
+		all := true
+		for _, name := range indices {
+			if !index.Match([]string{t.pattern}, name) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return t, true
+		}
+	}
+	return target{}, false
+}
@@ -0,0 +1,79 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+// enforceAggregations applies the aggregation policy of the permission to
+// the aggregations of the searches, the searches requesting aggregation
+// types or fields the policy doesn't allow are rejected along with the
+// location of each violation.
+func enforceAggregations(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		reqACL, err := acl.FromContext(ctx)
+		if err != nil || (*reqACL != acl.Search && *reqACL != acl.Msearch) || req.Body == nil {
+			h(w, req)
+			return
+		}
+		reqPermission, err := permission.FromContext(ctx)
+		if err != nil || reqPermission.Aggregations == nil {
+			h(w, req)
+			return
+		}
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var violations []util.ErrorDetail
+		inspect := func(search []byte, prefix string) error {
+			s, err := decodeSearch(search)
+			if err != nil {
+				return err
+			}
+			for _, key := range []string{"aggs", "aggregations"} {
+				if aggs, ok := s[key].(map[string]interface{}); ok {
+					violations = append(violations, reqPermission.InspectAggregations(aggs, prefix+key)...)
+				}
+			}
+			return nil
+		}
+		if *reqACL == acl.Msearch {
+			searchLine := false
+			for i, line := range bytes.Split(body, []byte("\n")) {
+				if len(bytes.TrimSpace(line)) == 0 {
+					continue
+				}
+				if searchLine {
+					if err = inspect(line, fmt.Sprintf("line %d: ", i+1)); err != nil {
+						break
+					}
+				}
+				searchLine = !searchLine
+			}
+		} else {
+			err = inspect(body, "")
+		}
+		if err != nil {
+			util.WriteBackError(w, "can't parse request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(violations) > 0 {
+			util.WriteBackErrorWithDetails(w, "aggregations aren't allowed for the credentials", http.StatusForbidden, violations)
+			return
+		}
+		h(w, req)
+	}
+}
@@ -0,0 +1,79 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+func TestEnforceAggregations(t *testing.T) {
+	var served bool
+	handler := enforceAggregations(func(w http.ResponseWriter, req *http.Request) {
+		served = true
+	})
+	serve := func(body string, reqACL acl.ACL, p *permission.Permission) *httptest.ResponseRecorder {
+		served = false
+		req := httptest.NewRequest(http.MethodPost, "/products/_search", strings.NewReader(body))
+		ctx := acl.NewContext(req.Context(), &reqACL)
+		ctx = permission.NewContext(ctx, p)
+		w := httptest.NewRecorder()
+		handler(w, req.WithContext(ctx))
+		return w
+	}
+	details := func(w *httptest.ResponseRecorder) []util.ErrorDetail {
+		var envelope util.ErrorEnvelope
+		json.Unmarshal(w.Body.Bytes(), &envelope)
+		return envelope.Error.Details
+	}
+	policy := func() *permission.Permission {
+		return &permission.Permission{Aggregations: &permission.AggregationPolicy{
+			Types:  []string{"terms", "avg", "composite"},
+			Fields: []string{"category", "price"},
+		}}
+	}
+
+	Convey("The allowed aggregations are forwarded", t, func() {
+		body := `{"aggs":{"categories":{"terms":{"field":"category.keyword"},"aggs":{"price":{"avg":{"field":"price"}}}}}}`
+		serve(body, acl.Search, policy())
+		So(served, ShouldBeTrue)
+
+		serve(body, acl.Search, &permission.Permission{})
+		So(served, ShouldBeTrue)
+	})
+
+	Convey("The types and fields that aren't allowed are rejected", t, func() {
+		w := serve(`{"aggs":{"emails":{"terms":{"field":"email"}},"hits":{"top_hits":{}}}}`, acl.Search, policy())
+		So(served, ShouldBeFalse)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(details(w), ShouldHaveLength, 2)
+
+		w = serve(`{"aggregations":{"pairs":{"composite":{"sources":[{"c":{"terms":{"field":"category"}}},{"e":{"terms":{"field":"email"}}}]}}}}`, acl.Search, policy())
+		So(details(w), ShouldHaveLength, 1)
+		So(details(w)[0].Location, ShouldEqual, "aggregations.pairs.composite.sources[1].e.terms.field")
+
+		w = serve(`{"aggs":{"total":{"avg":{"script":"doc['salary'].value"}}}}`, acl.Search, policy())
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("The excluded fields can't be aggregated on", t, func() {
+		p := policy()
+		p.Excludes = []string{"price"}
+		w := serve(`{"aggs":{"price":{"avg":{"field":"price"}}}}`, acl.Search, p)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("The searches of the msearch are located", t, func() {
+		body := "{}\n{\"aggs\":{\"c\":{\"terms\":{\"field\":\"category\"}}}}\n{}\n{\"aggs\":{\"e\":{\"terms\":{\"field\":\"email\"}}}}\n"
+		w := serve(body, acl.Msearch, policy())
+		So(details(w), ShouldHaveLength, 1)
+		So(details(w)[0].Location, ShouldEqual, "line 4: aggs.e.terms.field")
+	})
+}
@@ -0,0 +1,247 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	envDedupMaxPerGroup = "ES_DEDUP_MAX_PER_GROUP"
+	// dedupParam and dedupHeader name the field by which the hits are deduplicated.
+	dedupParam  = "dedup"
+	dedupHeader = "X-Arc-Dedup"
+	// dedupSizeParam and dedupSizeHeader set the max hits per group.
+	dedupSizeParam  = "dedup_size"
+	dedupSizeHeader = "X-Arc-Dedup-Size"
+	// dedupKey is the key against which the dedup of the request is stored in
+	// the context.
+	dedupKey = contextKey("dedup")
+	// dedupHook is the name of the response hook deduplicating the hits.
+	dedupHook = "dedup"
+)
+
+// dedupDefaults configures the deduplication of the hits.
+var dedupDefaults = struct {
+	maxPerGroup int
+}{maxPerGroup: 1}
+
+// dedup keeps at most max hits per value of the field. The single hit per
+// group dedups are collapsed by elasticsearch, the others are deduplicated
+// once the response is served.
+type dedup struct {
+	field     string
+	max       int
+	collapsed bool
+}
+
+// collapsible checks whether the search can be collapsed by elasticsearch,
+// which keeps a single hit per group and doesn't support scrolling,
+// rescoring and search after.
+func (d *dedup) collapsible(query map[string][]string, search map[string]json.RawMessage) bool {
+	if d.max != 1 {
+		return false
+	}
+	if _, ok := query["scroll"]; ok {
+		return false
+	}
+	for _, key := range []string{"collapse", "rescore", "search_after"} {
+		if _, ok := search[key]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// dedupHits deduplicates the hits of the searches by the field named by
+// either the dedup query param or header. The query params are removed from
+// the request forwarded to elasticsearch.
+func dedupHits(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		field := query.Get(dedupParam)
+		if field == "" {
+			field = req.Header.Get(dedupHeader)
+		}
+		size := query.Get(dedupSizeParam)
+		if size == "" {
+			size = req.Header.Get(dedupSizeHeader)
+		}
+		_, hasParam := query[dedupParam]
+		_, hasSizeParam := query[dedupSizeParam]
+		if hasParam || hasSizeParam {
+			query.Del(dedupParam)
+			query.Del(dedupSizeParam)
+			req.URL.RawQuery = query.Encode()
+		}
+		req.Header.Del(dedupHeader)
+		req.Header.Del(dedupSizeHeader)
+
+		field = strings.TrimSpace(field)
+		if field == "" {
+			h(w, req)
+			return
+		}
+		ctx := req.Context()
+		reqACL, err := acl.FromContext(ctx)
+		if err != nil || *reqACL != acl.Search {
+			h(w, req)
+			return
+		}
+
+		// the values of the field are returned along with the collapsed hits
+		if reqPermission, err := permission.FromContext(ctx); err == nil && !reqPermission.CanAccessField(field) {
+			msg := fmt.Sprintf(`can't dedup by field "%s" which isn't accessible`, field)
+			util.WriteBackError(w, msg, http.StatusForbidden)
+			return
+		}
+
+		d := &dedup{field: field, max: dedupDefaults.maxPerGroup}
+		if size != "" {
+			d.max, err = strconv.Atoi(size)
+			if err != nil || d.max <= 0 {
+				msg := fmt.Sprintf(`invalid dedup size "%s", must be a positive integer`, size)
+				util.WriteBackError(w, msg, http.StatusBadRequest)
+				return
+			}
+		}
+
+		var body []byte
+		if req.Body != nil {
+			body, err = util.ReadRequestBody(req)
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+		}
+		search := make(map[string]json.RawMessage)
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err := json.Unmarshal(body, &search); err != nil {
+				util.WriteBackError(w, "can't parse request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		if d.collapsible(query, search) {
+			body, err = setSearchField(body, "collapse", map[string]interface{}{"field": d.field})
+			if err != nil {
+				util.WriteBackError(w, "can't parse request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			util.SetRequestBody(req, body)
+			if req.Method == http.MethodGet {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			d.collapsed = true
+		}
+
+		ctx = context.WithValue(ctx, dedupKey, d)
+		h(w, req.WithContext(ctx))
+	}
+}
+
+// dedupResponse drops the hits beyond the max hits per group of the dedup of
+// the request, unless the search was collapsed by elasticsearch. The hits
+// lacking the field are kept.
+func dedupResponse(req *http.Request, resp *plugins.Response) error {
+	d, ok := req.Context().Value(dedupKey).(*dedup)
+	if !ok || d.collapsed || resp.Code != http.StatusOK {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(resp.Body))
+	decoder.UseNumber()
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return err
+	}
+	hits, _ := body["hits"].(map[string]interface{})
+	items, _ := hits["hits"].([]interface{})
+	if len(items) == 0 {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	kept := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		group, ok := d.group(item)
+		if ok {
+			if counts[group] == d.max {
+				continue
+			}
+			counts[group]++
+		}
+		kept = append(kept, item)
+	}
+	if len(kept) == len(items) {
+		return nil
+	}
+	hits["hits"] = kept
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp.Body = raw
+	return nil
+}
+
+// group returns the value of the dedup field of the hit, looked up in the
+// source and then in the fields of the hit. The keyword sub field of a text
+// field is looked up as the text field in the source.
+func (d *dedup) group(item interface{}) (string, bool) {
+	hit, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	var value interface{}
+	if source, ok := hit["_source"].(map[string]interface{}); ok {
+		value = lookup(source, d.field)
+		if value == nil && strings.HasSuffix(d.field, ".keyword") {
+			value = lookup(source, strings.TrimSuffix(d.field, ".keyword"))
+		}
+	}
+	if value == nil {
+		if fields, ok := hit["fields"].(map[string]interface{}); ok {
+			if values, ok := fields[d.field].([]interface{}); ok && len(values) > 0 {
+				value = values[0]
+			}
+		}
+	}
+	if value == nil {
+		return "", false
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	return string(raw), true
+}
+
+// lookup returns the value of the dotted field of the source, if any.
+func lookup(source map[string]interface{}, field string) interface{} {
+	if value, ok := source[field]; ok {
+		return value
+	}
+	// the object fields may be flattened at any level, e.g. "a.b": {"c": 1}
+	for i := 0; i < len(field); i++ {
+		if field[i] != '.' {
+			continue
+		}
+		if object, ok := source[field[:i]].(map[string]interface{}); ok {
+			if value := lookup(object, field[i+1:]); value != nil {
+				return value
+			}
+		}
+	}
+	return nil
+}
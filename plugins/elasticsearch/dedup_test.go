@@ -0,0 +1,69 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func TestCollapsible(t *testing.T) {
+	Convey("Single hit per group dedups are collapsed by elasticsearch", t, func() {
+		d := &dedup{field: "group", max: 1}
+		So(d.collapsible(nil, map[string]json.RawMessage{"query": nil}), ShouldBeTrue)
+		So(d.collapsible(map[string][]string{"scroll": {"1m"}}, nil), ShouldBeFalse)
+		So(d.collapsible(nil, map[string]json.RawMessage{"rescore": nil}), ShouldBeFalse)
+		So(d.collapsible(nil, map[string]json.RawMessage{"collapse": nil}), ShouldBeFalse)
+		So((&dedup{field: "group", max: 2}).collapsible(nil, nil), ShouldBeFalse)
+	})
+}
+
+func TestDedupResponse(t *testing.T) {
+	search := func(d *dedup) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/products/_search", nil)
+		return req.WithContext(context.WithValue(req.Context(), dedupKey, d))
+	}
+	body := `{"hits": {"total": 5, "hits": [
+		{"_id": "1", "_source": {"product": {"group": "a"}}},
+		{"_id": "2", "_source": {"product": {"group": "a"}}},
+		{"_id": "3", "_source": {"product.group": "b"}},
+		{"_id": "4", "_source": {"product": {"group": "a"}}},
+		{"_id": "5", "_source": {}}
+	]}}`
+	ids := func(resp *plugins.Response) []string {
+		var r struct {
+			Hits struct {
+				Hits []struct {
+					ID string `json:"_id"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		So(json.Unmarshal(resp.Body, &r), ShouldBeNil)
+		var ids []string
+		for _, hit := range r.Hits.Hits {
+			ids = append(ids, hit.ID)
+		}
+		return ids
+	}
+
+	Convey("The hits beyond the max per group are dropped", t, func() {
+		resp := &plugins.Response{Code: http.StatusOK, Body: []byte(body)}
+		So(dedupResponse(search(&dedup{field: "product.group", max: 2}), resp), ShouldBeNil)
+		So(ids(resp), ShouldResemble, []string{"1", "2", "3", "5"})
+
+		resp = &plugins.Response{Code: http.StatusOK, Body: []byte(body)}
+		So(dedupResponse(search(&dedup{field: "product.group.keyword", max: 1}), resp), ShouldBeNil)
+		So(ids(resp), ShouldResemble, []string{"1", "3", "5"})
+	})
+
+	Convey("Collapsed searches are left untouched", t, func() {
+		resp := &plugins.Response{Code: http.StatusOK, Body: []byte(body)}
+		So(dedupResponse(search(&dedup{field: "product.group", max: 1, collapsed: true}), resp), ShouldBeNil)
+		So(string(resp.Body), ShouldEqual, body)
+	})
+}
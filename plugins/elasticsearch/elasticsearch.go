@@ -1,17 +1,36 @@
 package elasticsearch
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/middleware"
 	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util/indexwatch"
 )
 
-const logTag = "[elasticsearch]"
+const (
+	logTag              = "[elasticsearch]"
+	envValidateBody     = "ES_VALIDATE_BODY"
+	envValidateBodySkip = "ES_VALIDATE_BODY_SKIP"
+)
 
 var (
 	singleton *elasticsearch
 	once      sync.Once
+
+	// bodyValidation configures the validation of the request bodies, the
+	// apis are identified by the name of their spec, e.g. bulk or indices.create.
+	bodyValidation struct {
+		enabled bool
+		skip    map[string]bool
+	}
 )
 
 type elasticsearch struct {
@@ -28,6 +47,39 @@ func (es *elasticsearch) Name() string {
 }
 
 func (es *elasticsearch) InitFunc(mw []middleware.Middleware) error {
+	bodyValidation.enabled = os.Getenv(envValidateBody) != "false"
+	bodyValidation.skip = make(map[string]bool)
+	for _, name := range strings.Split(os.Getenv(envValidateBodySkip), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			bodyValidation.skip[name] = true
+		}
+	}
+	strictRoutes.enabled = os.Getenv(envStrictRoutes) == "true"
+	allowed, err := parseAllowedPaths(os.Getenv(envAllowedPaths))
+	if err != nil {
+		return err
+	}
+	strictRoutes.allowed = allowed
+	if strictRoutes.enabled {
+		log.Println(logTag, ": strict mode enabled, requests for unrecognized apis will be rejected")
+	}
+	if value := os.Getenv(envDedupMaxPerGroup); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a positive integer", envDedupMaxPerGroup, value)
+		}
+		dedupDefaults.maxPerGroup = n
+	}
+	if value := os.Getenv(envSearchTimeout); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a positive duration", envSearchTimeout, value)
+		}
+		searchTimeout = d
+	}
+	plugins.RegisterResponseHook(dedupHook, 20, dedupResponse)
+	plugins.RegisterResponseHook(projectionHook, 30, enforceProjection)
+	indexwatch.Start()
 	return es.preprocess(mw)
 }
 
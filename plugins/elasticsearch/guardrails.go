@@ -0,0 +1,404 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+// defaultSize is the number of hits elasticsearch returns by default.
+const defaultSize = 10
+
+// scriptKeys are the keys of the search body that make use of scripts.
+var scriptKeys = map[string]bool{
+	"script":          true,
+	"script_fields":   true,
+	"scripted_metric": true,
+	"_script":         true,
+}
+
+// bucketSizes are the default sizes of the bucket aggregations that take one.
+var bucketSizes = map[string]int{
+	"terms":             10,
+	"multi_terms":       10,
+	"significant_terms": 10,
+	"significant_text":  10,
+	"composite":         10,
+	"geotile_grid":      10000,
+	"geohash_grid":      10000,
+}
+
+// guard inspects the searches against the guardrails of a permission.
+type guard struct {
+	*permission.Guardrails
+	violations []util.ErrorDetail
+}
+
+func (g *guard) violate(reason, location string) {
+	g.violations = append(g.violations, util.ErrorDetail{
+		Type:     "query_guardrail",
+		Reason:   reason,
+		Location: location,
+	})
+}
+
+// guardQueries enforces the guardrails of the permission on the searches.
+// The searches exceeding the size or the result window are clamped if the
+// guardrails rewrite them, the other violations are rejected along with
+// their location in the body.
+func guardQueries(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		reqACL, err := acl.FromContext(ctx)
+		if err != nil || (*reqACL != acl.Search && *reqACL != acl.Msearch) {
+			h(w, req)
+			return
+		}
+		reqPermission, err := permission.FromContext(ctx)
+		if err != nil || reqPermission.Guardrails == nil {
+			h(w, req)
+			return
+		}
+		g := &guard{Guardrails: reqPermission.Guardrails}
+
+		var body []byte
+		if req.Body != nil {
+			body, err = util.ReadRequestBody(req)
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+		}
+
+		query := req.URL.Query()
+		if *reqACL == acl.Msearch {
+			body, err = g.msearch(body)
+		} else {
+			body, err = g.search(body, query)
+			req.URL.RawQuery = query.Encode()
+		}
+		if err != nil {
+			util.WriteBackError(w, "can't parse request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(g.violations) > 0 {
+			util.WriteBackErrorWithDetails(w, "query violates the guardrails of the credentials", http.StatusBadRequest, g.violations)
+			return
+		}
+		util.SetRequestBody(req, body)
+
+		h(w, req)
+	}
+}
+
+// search inspects the search body along with the query params, which take
+// precedence over the body.
+func (g *guard) search(body []byte, query map[string][]string) ([]byte, error) {
+	search, err := decodeSearch(body)
+	if err != nil {
+		return nil, err
+	}
+	p := queryParams(query)
+
+	if q := p.get("q"); q != "" {
+		g.inspectQueryString(q, "q")
+	}
+	modified := g.inspectPagination(search, p, "")
+	g.inspect(search, "")
+	if !modified {
+		return body, nil
+	}
+	for key, value := range p.set {
+		query[key] = []string{value}
+	}
+	return json.Marshal(search)
+}
+
+// msearch inspects the searches of the msearch body, whose lines alternate
+// between headers and searches.
+func (g *guard) msearch(body []byte) ([]byte, error) {
+	lines := bytes.Split(body, []byte("\n"))
+	var modified bytes.Buffer
+	searchLine := false
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if searchLine {
+			search, err := decodeSearch(line)
+			if err != nil {
+				return nil, err
+			}
+			prefix := fmt.Sprintf("line %d: ", i+1)
+			if g.inspectPagination(search, queryParams(nil), prefix) {
+				if line, err = json.Marshal(search); err != nil {
+					return nil, err
+				}
+			}
+			g.inspect(search, prefix)
+		}
+		modified.Write(line)
+		modified.WriteByte('\n')
+		searchLine = !searchLine
+	}
+	return modified.Bytes(), nil
+}
+
+func decodeSearch(body []byte) (map[string]interface{}, error) {
+	search := make(map[string]interface{})
+	if len(bytes.TrimSpace(body)) == 0 {
+		return search, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+// params are the query params of a search, the rewritten ones are set.
+type params struct {
+	values map[string][]string
+	set    map[string]string
+}
+
+func queryParams(values map[string][]string) *params {
+	return &params{values: values, set: make(map[string]string)}
+}
+
+func (p *params) get(key string) string {
+	if len(p.values[key]) == 0 {
+		return ""
+	}
+	return p.values[key][0]
+}
+
+// intValue returns the value of the query param if set, else of the search
+// body, else the fallback.
+func (p *params) intValue(search map[string]interface{}, key string, fallback int) (int, bool) {
+	if value := p.get(key); value != "" {
+		n, err := strconv.Atoi(value)
+		return n, err == nil
+	}
+	if value, ok := search[key]; ok {
+		n, ok := toInt(value)
+		return n, ok
+	}
+	return fallback, true
+}
+
+// inspectPagination checks the size and the result window of the search,
+// clamping the size if the guardrails rewrite the searches. It reports
+// whether the search was modified.
+func (g *guard) inspectPagination(search map[string]interface{}, p *params, prefix string) bool {
+	size, ok := p.intValue(search, "size", defaultSize)
+	if !ok {
+		return false
+	}
+	from, ok := p.intValue(search, "from", 0)
+	if !ok {
+		return false
+	}
+
+	limit := size
+	if g.MaxSize > 0 && limit > g.MaxSize {
+		limit = g.MaxSize
+	}
+	if g.MaxResultWindow > 0 && from+limit > g.MaxResultWindow {
+		if from >= g.MaxResultWindow {
+			g.violate(fmt.Sprintf("from %d exceeds the result window of %d", from, g.MaxResultWindow), prefix+"from")
+			return false
+		}
+		limit = g.MaxResultWindow - from
+	}
+	if limit == size {
+		return false
+	}
+	if !g.Rewrite {
+		if g.MaxSize > 0 && size > g.MaxSize {
+			g.violate(fmt.Sprintf("size %d exceeds the max size of %d", size, g.MaxSize), prefix+"size")
+		} else {
+			g.violate(fmt.Sprintf("from %d and size %d exceed the result window of %d", from, size, g.MaxResultWindow), prefix+"size")
+		}
+		return false
+	}
+	search["size"] = limit
+	if p.get("size") != "" {
+		p.set["size"] = strconv.Itoa(limit)
+	}
+	return true
+}
+
+// inspect walks the search body for the scripts, the regexp and the leading
+// wildcard queries, and estimates the buckets of the aggregations.
+func (g *guard) inspect(search map[string]interface{}, prefix string) {
+	for key, value := range search {
+		switch key {
+		case "_source", "fields", "docvalue_fields", "stored_fields":
+			continue
+		case "aggs", "aggregations":
+			if aggs, ok := value.(map[string]interface{}); ok && g.MaxBuckets > 0 {
+				if buckets := estimateBuckets(aggs); buckets > g.MaxBuckets {
+					g.violate(fmt.Sprintf("aggregations are estimated to return %d buckets, exceeding the max of %d", buckets, g.MaxBuckets), prefix+key)
+				}
+			}
+		}
+		g.walk(key, value, prefix+key)
+	}
+}
+
+func (g *guard) walk(key string, value interface{}, path string) {
+	switch {
+	case g.DenyScripts && scriptKeys[key]:
+		g.violate("scripts aren't allowed", path)
+		return
+	case g.DenyRegexp && key == "regexp":
+		g.violate("regexp queries aren't allowed", path)
+		return
+	case key == "wildcard":
+		if g.DenyLeadingWildcard && hasLeadingWildcard(value) {
+			g.violate("wildcard terms can't start with a wildcard", path)
+		}
+		return
+	case key == "query_string" || key == "simple_query_string":
+		if q, ok := value.(map[string]interface{}); ok {
+			if s, ok := q["query"].(string); ok {
+				g.inspectQueryString(s, path+".query")
+			}
+		}
+	}
+
+	switch t := value.(type) {
+	case map[string]interface{}:
+		for k, v := range t {
+			g.walk(k, v, path+"."+k)
+		}
+	case []interface{}:
+		for i, v := range t {
+			g.walk("", v, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// inspectQueryString checks the terms of the query string syntax.
+func (g *guard) inspectQueryString(q, path string) {
+	for _, term := range strings.Fields(q) {
+		if i := strings.IndexByte(term, ':'); i >= 0 {
+			term = term[i+1:]
+		}
+		term = strings.TrimLeft(term, "(+-!")
+		switch {
+		case g.DenyLeadingWildcard && (strings.HasPrefix(term, "*") || strings.HasPrefix(term, "?")):
+			g.violate(fmt.Sprintf("term %q starts with a wildcard", term), path)
+			return
+		case g.DenyRegexp && strings.HasPrefix(term, "/"):
+			g.violate(fmt.Sprintf("term %q is a regular expression", term), path)
+			return
+		}
+	}
+}
+
+// hasLeadingWildcard checks the value of the wildcard query, either
+// {"field": "*term"} or {"field": {"value": "*term"}}.
+func hasLeadingWildcard(value interface{}) bool {
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for _, field := range fields {
+		var term string
+		switch t := field.(type) {
+		case string:
+			term = t
+		case map[string]interface{}:
+			if v, ok := t["value"].(string); ok {
+				term = v
+			} else if v, ok := t["wildcard"].(string); ok {
+				term = v
+			}
+		}
+		if strings.HasPrefix(term, "*") || strings.HasPrefix(term, "?") {
+			return true
+		}
+	}
+	return false
+}
+
+// estimateBuckets estimates the number of buckets the aggregations return,
+// each bucket of an aggregation carries the buckets of its sub aggregations.
+// The buckets of the aggregations whose size isn't known upfront, such as
+// the histograms, are counted once.
+func estimateBuckets(aggs map[string]interface{}) int {
+	total := 0
+	for _, value := range aggs {
+		agg, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		buckets, sub := 1, 0
+		for kind, body := range agg {
+			switch kind {
+			case "aggs", "aggregations":
+				if subAggs, ok := body.(map[string]interface{}); ok {
+					sub = estimateBuckets(subAggs)
+				}
+			case "meta":
+			default:
+				buckets = bucketCount(kind, body)
+			}
+		}
+		total += buckets * (1 + sub)
+	}
+	return total
+}
+
+func bucketCount(kind string, body interface{}) int {
+	params, _ := body.(map[string]interface{})
+	if size, ok := bucketSizes[kind]; ok {
+		if n, ok := toInt(params["size"]); ok {
+			size = n
+		}
+		return size
+	}
+	switch kind {
+	case "range", "date_range", "ip_range":
+		if ranges, ok := params["ranges"].([]interface{}); ok {
+			return len(ranges)
+		}
+	case "filters":
+		switch filters := params["filters"].(type) {
+		case map[string]interface{}:
+			return len(filters)
+		case []interface{}:
+			return len(filters)
+		}
+	}
+	return 1
+}
+
+func toInt(value interface{}) (int, bool) {
+	switch t := value.(type) {
+	case json.Number:
+		n, err := t.Int64()
+		return int(n), err == nil
+	case float64:
+		return int(t), true
+	case int:
+		return t, true
+	case string:
+		n, err := strconv.Atoi(t)
+		return n, err == nil
+	}
+	return 0, false
+}
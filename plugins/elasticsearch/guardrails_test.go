@@ -0,0 +1,119 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+func TestGuardQueries(t *testing.T) {
+	var served *http.Request
+	var forwarded string
+	handler := guardQueries(func(w http.ResponseWriter, req *http.Request) {
+		served = req
+		raw, _ := ioutil.ReadAll(req.Body)
+		forwarded = string(raw)
+	})
+	serve := func(target, body string, reqACL acl.ACL, g *permission.Guardrails) *httptest.ResponseRecorder {
+		served, forwarded = nil, ""
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		ctx := acl.NewContext(req.Context(), &reqACL)
+		ctx = permission.NewContext(ctx, &permission.Permission{Guardrails: g})
+		w := httptest.NewRecorder()
+		handler(w, req.WithContext(ctx))
+		return w
+	}
+	details := func(w *httptest.ResponseRecorder) []util.ErrorDetail {
+		var envelope util.ErrorEnvelope
+		json.Unmarshal(w.Body.Bytes(), &envelope)
+		return envelope.Error.Details
+	}
+
+	Convey("The searches within the guardrails are forwarded as is", t, func() {
+		g := &permission.Guardrails{MaxSize: 50, DenyScripts: true, DenyRegexp: true, DenyLeadingWildcard: true}
+		body := `{"size":20,"query":{"wildcard":{"name":"shoe*"}}}`
+		serve("/products/_search", body, acl.Search, g)
+		So(forwarded, ShouldEqual, body)
+	})
+
+	Convey("The searches exceeding the size are rejected unless rewritten", t, func() {
+		g := &permission.Guardrails{MaxSize: 50}
+		w := serve("/products/_search", `{"size":100}`, acl.Search, g)
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(details(w)[0].Location, ShouldEqual, "size")
+		So(served, ShouldBeNil)
+
+		g.Rewrite = true
+		serve("/products/_search?size=100", `{"size":100}`, acl.Search, g)
+		So(served.URL.Query().Get("size"), ShouldEqual, "50")
+		So(forwarded, ShouldEqual, `{"size":50}`)
+	})
+
+	Convey("Deep pagination beyond the result window is rejected", t, func() {
+		g := &permission.Guardrails{MaxResultWindow: 1000, Rewrite: true}
+		serve("/products/_search", `{"from":995,"size":10}`, acl.Search, g)
+		So(forwarded, ShouldEqual, `{"from":995,"size":5}`)
+
+		w := serve("/products/_search?from=1000", ``, acl.Search, g)
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(details(w)[0].Location, ShouldEqual, "from")
+	})
+
+	Convey("Scripts, regexp and leading wildcards are located in the body", t, func() {
+		g := &permission.Guardrails{DenyScripts: true, DenyRegexp: true, DenyLeadingWildcard: true}
+		w := serve("/products/_search", `{"query":{"bool":{"must":[{"match":{"name":"a"}},{"wildcard":{"name":{"value":"*shoe"}}}]}}}`, acl.Search, g)
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(details(w)[0].Location, ShouldEqual, "query.bool.must[1].wildcard")
+
+		w = serve("/products/_search", `{"query":{"function_score":{"script_score":{"script":{"source":"1"}}}}}`, acl.Search, g)
+		So(details(w)[0].Location, ShouldEqual, "query.function_score.script_score.script")
+
+		w = serve("/products/_search", `{"query":{"regexp":{"name":"sh.*"}}}`, acl.Search, g)
+		So(details(w)[0].Location, ShouldEqual, "query.regexp")
+
+		w = serve("/products/_search?q=name:*shoe", ``, acl.Search, g)
+		So(details(w)[0].Location, ShouldEqual, "q")
+
+		w = serve("/products/_search", `{"query":{"query_string":{"query":"name:/sh.*/"}}}`, acl.Search, g)
+		So(details(w)[0].Location, ShouldEqual, "query.query_string.query")
+	})
+
+	Convey("The aggregations estimated to return too many buckets are rejected", t, func() {
+		g := &permission.Guardrails{MaxBuckets: 1000}
+		body := `{"aggs":{"brands":{"terms":{"field":"brand","size":100},"aggs":{"colors":{"terms":{"field":"color"}}}}}}`
+		w := serve("/products/_search", body, acl.Search, g)
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(details(w)[0].Location, ShouldEqual, "aggs")
+
+		body = `{"aggs":{"brands":{"terms":{"field":"brand","size":50},"aggs":{"colors":{"terms":{"field":"color"}}}}}}`
+		serve("/products/_search", body, acl.Search, g)
+		So(forwarded, ShouldEqual, body)
+	})
+
+	Convey("The searches of the msearch are inspected one by one", t, func() {
+		g := &permission.Guardrails{MaxSize: 50, DenyScripts: true}
+		w := serve("/_msearch", "{}\n{\"size\":10}\n{}\n{\"script_fields\":{}}\n", acl.Msearch, g)
+		So(w.Code, ShouldEqual, http.StatusBadRequest)
+		So(details(w)[0].Location, ShouldEqual, "line 4: script_fields")
+
+		g.Rewrite = true
+		serve("/_msearch", "{}\n{\"size\":100}\n", acl.Msearch, g)
+		So(forwarded, ShouldEqual, "{}\n{\"size\":50}\n")
+	})
+
+	Convey("Other apis and permissions without guardrails are let through", t, func() {
+		serve("/products/_doc/1", `{"script":{}}`, acl.Index, &permission.Guardrails{DenyScripts: true})
+		So(forwarded, ShouldEqual, `{"script":{}}`)
+		serve("/products/_search", `{"size":10000}`, acl.Search, nil)
+		So(forwarded, ShouldEqual, `{"size":10000}`)
+	})
+}
@@ -1,15 +1,20 @@
 package elasticsearch
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/model/acl"
-	"github.com/appbaseio/arc/model/category"
-	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/model/reqcontext"
+	"github.com/appbaseio/arc/plugins"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/balancer"
+	"github.com/appbaseio/arc/util/chaos"
 	"github.com/hashicorp/go-retryablehttp"
 )
 
@@ -17,29 +22,29 @@ func (es *elasticsearch) handler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
-		reqCategory, err := category.FromContext(ctx)
+		reqCtx, err := reqcontext.FromContext(ctx)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, "error classifying request acl", http.StatusInternalServerError)
+			util.WriteBackError(w, "error classifying the request", http.StatusInternalServerError)
 			return
 		}
-
-		reqACL, err := acl.FromContext(ctx)
-		if err != nil {
-			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, "error classifying request category", http.StatusInternalServerError)
+		reqACL := reqCtx.ACL
+		if reqACL == nil {
+			log.Errorln(logTag, ": *acl.ACL not found in request context")
+			util.WriteBackError(w, "error classifying request acl", http.StatusInternalServerError)
 			return
 		}
+		log.Println(logTag, ": category=", *reqCtx.Category, ", acl=", *reqACL, ", op=", *reqCtx.Op)
 
-		reqOp, err := op.FromContext(ctx)
-		if err != nil {
-			log.Errorln(logTag, ":", err)
-			util.WriteBackError(w, "error classifying request op", http.StatusInternalServerError)
-			return
+		// Release the upstream node picked by the interceptor once we are done
+		node, err := balancer.FromContext(ctx)
+		if err == nil {
+			defer node.Release()
 		}
-		log.Println(logTag, ": category=", *reqCategory, ", acl=", *reqACL, ", op=", *reqOp)
+
 		// Forward the request to elasticsearch
 		client := retryablehttp.NewClient()
+		client.HTTPClient.Transport = chaos.Transport(client.HTTPClient.Transport)
 		loggerT := log.New()
 		wrappedLoggerDebug := &util.WrapKitLoggerDebug{*loggerT}
 		client.Logger = wrappedLoggerDebug
@@ -51,7 +56,19 @@ func (es *elasticsearch) handler() http.HandlerFunc {
 		}
 		response, err := client.Do(request)
 
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			log.Errorln(logTag, ": request for", r.URL.Path, "exceeded its deadline")
+			util.WriteBackError(w, "the request exceeded its timeout", http.StatusGatewayTimeout)
+			return
+		}
+		if err != nil && ctx.Err() == context.Canceled {
+			log.Errorln(logTag, ": request for", r.URL.Path, "was canceled by the client")
+			return
+		}
 		if err != nil {
+			if node != nil {
+				node.Fail(ctx, err)
+			}
 			log.Errorln(logTag, ": error fetching response for", r.URL.Path, err)
 			util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -67,6 +84,25 @@ func (es *elasticsearch) handler() http.HandlerFunc {
 		}
 		w.Header().Set("X-Origin", "ES")
 
+		if postProcess(reqACL, response) {
+			body, err := util.ReadBody(response.Body, util.MaxResponseSize())
+			if errors.Is(err, util.ErrBodyTooLarge) {
+				log.Errorln(logTag, ": response for", r.URL.Path, "exceeds", util.MaxResponseSize(), "bytes")
+				util.WriteBackError(w, fmt.Sprintf("upstream response exceeds %d bytes", util.MaxResponseSize()), http.StatusBadGateway)
+				return
+			}
+			if err != nil {
+				log.Errorln(logTag, ": error reading response for", r.URL.Path, err)
+				util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			resp := &plugins.Response{Code: response.StatusCode, Header: w.Header(), Body: body}
+			plugins.RunResponseHooks(r, resp)
+			w.WriteHeader(resp.Code)
+			w.Write(resp.Body)
+			return
+		}
+
 		// Copy the status code
 		w.WriteHeader(response.StatusCode)
 
@@ -74,3 +110,15 @@ func (es *elasticsearch) handler() http.HandlerFunc {
 		io.Copy(w, response.Body)
 	}
 }
+
+// postProcess checks whether the response must be passed through the response
+// hooks, which only apply to the uncompressed search responses.
+func postProcess(reqACL *acl.ACL, response *http.Response) bool {
+	if *reqACL != acl.Search && *reqACL != acl.Msearch {
+		return false
+	}
+	if encoding := response.Header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return false
+	}
+	return plugins.HasResponseHooks()
+}
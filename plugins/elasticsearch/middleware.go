@@ -12,11 +12,14 @@ import (
 
 	"github.com/appbaseio/arc/middleware"
 	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/feature"
 	"github.com/appbaseio/arc/middleware/interceptor"
 	"github.com/appbaseio/arc/middleware/ratelimiter"
+	"github.com/appbaseio/arc/middleware/tenancy"
 	"github.com/appbaseio/arc/middleware/validate"
 	"github.com/appbaseio/arc/model/acl"
 	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/family"
 	"github.com/appbaseio/arc/model/op"
 	"github.com/appbaseio/arc/model/permission"
 	"github.com/appbaseio/arc/plugins/auth"
@@ -35,38 +38,50 @@ func (c *chain) Wrap(mw []middleware.Middleware, h http.HandlerFunc) http.Handle
 
 func list() []middleware.Middleware {
 	return []middleware.Middleware{
+		validateRoute,
+		classify.Body(),
 		classifyCategory,
 		classifyACL,
 		classifyOp,
+		classifyFamily,
 		classify.Indices(),
 		logs.Recorder(),
 		auth.BasicAuth(),
+		feature.Toggle(),
 		ratelimiter.Limit(),
 		validate.Sources(),
 		validate.Referers(),
 		validate.Indices(),
+		validate.BodyIndices(),
 		validate.Category(),
 		validate.ACL(),
+		validate.Family(),
+		classifySubcategory,
+		validate.Subcategory(),
 		validate.Operation(),
 		validate.PermissionExpiry(),
+		validate.Body(bodyFormat),
 		// TODO: move transform request logic to querytranslate plugin
 		transformRequest,
+		projectFields,
+		dedupHits,
+		enforceTimeout,
+		guardQueries,
+		enforceScripts,
+		enforceAggregations,
+		tenancy.Isolate(),
 	}
 }
 
 func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		route := mux.CurrentRoute(req)
-
-		template, err := route.GetPathTemplate()
+		reqAPI, err := routeSpec(req)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
 			util.WriteBackError(w, "page not found", http.StatusNotFound)
 			return
 		}
-		key := fmt.Sprintf("%s:%s", req.Method, template)
-		routeSpec := routeSpecs[key]
-		routeCategory := routeSpec.category
+		routeCategory := reqAPI.category
 
 		// classify streams explicitly
 		stream := req.Header.Get("X-Request-Category")
@@ -84,17 +99,13 @@ func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
 
 func classifyACL(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		currentRoute := mux.CurrentRoute(req)
-
-		template, err := currentRoute.GetPathTemplate()
+		reqAPI, err := routeSpec(req)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
 			util.WriteBackError(w, "page not found", http.StatusNotFound)
 			return
 		}
-		key := fmt.Sprintf("%s:%s", req.Method, template)
-		routeSpec := routeSpecs[key]
-		routeACL := routeSpec.acl
+		routeACL := reqAPI.acl
 
 		ctx := acl.NewContext(req.Context(), &routeACL)
 		req = req.WithContext(ctx)
@@ -105,17 +116,13 @@ func classifyACL(h http.HandlerFunc) http.HandlerFunc {
 
 func classifyOp(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		route := mux.CurrentRoute(req)
-
-		template, err := route.GetPathTemplate()
+		reqAPI, err := routeSpec(req)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
 			util.WriteBackError(w, "page not found", http.StatusNotFound)
 			return
 		}
-		key := fmt.Sprintf("%s:%s", req.Method, template)
-		routeSpec := routeSpecs[key]
-		routeOp := routeSpec.op
+		routeOp := reqAPI.op
 
 		ctx := op.NewContext(req.Context(), &routeOp)
 		req = req.WithContext(ctx)
@@ -124,6 +131,45 @@ func classifyOp(h http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// classifyFamily classifies the api of the matched route into its family, the
+// routes without an api spec are classified as misc.
+func classifyFamily(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		reqAPI, err := routeSpec(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, "page not found", http.StatusNotFound)
+			return
+		}
+		routeFamily := family.Of(reqAPI.name)
+
+		ctx := family.NewContext(req.Context(), &routeFamily)
+		req = req.WithContext(ctx)
+
+		h(w, req)
+	}
+}
+
+// bodyFormat resolves the format of the request body from the api spec of the
+// matched route, the apis serialized as bulk expect newline delimited json.
+func bodyFormat(req *http.Request) (validate.BodyFormat, bool) {
+	if !bodyValidation.enabled {
+		return validate.JSON, false
+	}
+	template, err := mux.CurrentRoute(req).GetPathTemplate()
+	if err != nil {
+		return validate.JSON, false
+	}
+	routeSpec, ok := routeSpecs[fmt.Sprintf("%s:%s", req.Method, template)]
+	if !ok || routeSpec.spec == nil || routeSpec.spec.Body.Description == "" || bodyValidation.skip[routeSpec.name] {
+		return validate.JSON, false
+	}
+	if routeSpec.spec.Body.Serialize == "bulk" {
+		return validate.NDJSON, true
+	}
+	return validate.JSON, true
+}
+
 func transformRequest(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx := req.Context()
@@ -159,10 +205,10 @@ func transformRequest(h http.HandlerFunc) http.HandlerFunc {
 			if shouldApplyFilters {
 				if isMsearch {
 					// Handle the _msearch requests
-					body, err := ioutil.ReadAll(req.Body)
+					body, err := util.ReadRequestBody(req)
 					if err != nil {
 						log.Errorln(logTag, ":", err)
-						util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+						util.WriteBackBodyError(w, err)
 						return
 					}
 					var reqBodyString = string(body)
@@ -191,12 +237,12 @@ func transformRequest(h http.HandlerFunc) http.HandlerFunc {
 						modifiedBodyString += "\n"
 					}
 					modifiedBody := []byte(modifiedBodyString)
-					req.Body = ioutil.NopCloser(bytes.NewReader(modifiedBody))
+					util.SetRequestBody(req, modifiedBody)
 				} else {
-					body, err := ioutil.ReadAll(req.Body)
+					body, err := util.ReadRequestBody(req)
 					if err != nil {
 						log.Errorln(logTag, ":", err)
-						util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+						util.WriteBackBodyError(w, err)
 						return
 					}
 					d := json.NewDecoder(ioutil.NopCloser(bytes.NewReader(body)))
@@ -204,7 +250,7 @@ func transformRequest(h http.HandlerFunc) http.HandlerFunc {
 					d.Decode(&reqBody)
 					reqBody["_source"] = sources
 					modifiedBody, _ := json.Marshal(reqBody)
-					req.Body = ioutil.NopCloser(bytes.NewReader(modifiedBody))
+					util.SetRequestBody(req, modifiedBody)
 				}
 			}
 		}
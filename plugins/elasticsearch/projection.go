@@ -0,0 +1,258 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	// fieldsParam is the query param listing the fields of the hits to return.
+	fieldsParam = "fields"
+	// projectionKey is the key against which the projection of the request is
+	// stored in the context.
+	projectionKey = contextKey("projection")
+	// projectionHook is the name of the response hook enforcing the projections.
+	projectionHook = "projection"
+)
+
+// projection are the fields of the hits requested by the client, intersected
+// with the fields the credential can access.
+type projection struct {
+	includes []string
+	excludes []string
+}
+
+// newProjection intersects the requested fields with the fields the
+// permission can access. A requested object field that the permission only
+// partially includes is narrowed down to the included sub fields.
+func newProjection(fields []string, p *permission.Permission) *projection {
+	includes := []string{}
+	for _, field := range fields {
+		if len(p.Includes) == 0 || permission.MatchesField(p.Includes, field) {
+			includes = append(includes, field)
+			continue
+		}
+		for _, include := range p.Includes {
+			if strings.HasPrefix(include, field+".") {
+				includes = append(includes, include)
+			}
+		}
+	}
+	return &projection{includes: includes, excludes: p.Excludes}
+}
+
+// source returns the source filter of the projection. Nothing is included
+// if none of the requested fields can be accessed.
+func (p *projection) source() interface{} {
+	if len(p.includes) == 0 {
+		return false
+	}
+	source := map[string]interface{}{"includes": p.includes}
+	if len(p.excludes) > 0 {
+		source["excludes"] = p.excludes
+	}
+	return source
+}
+
+// filter drops the fields of the source that aren't part of the projection,
+// the sub fields of an included object field are dropped if excluded.
+func (p *projection) filter(source map[string]interface{}, prefix string, included bool) {
+	for key, value := range source {
+		field := prefix + key
+		if permission.MatchesField(p.excludes, field) {
+			delete(source, key)
+			continue
+		}
+		in := included || permission.MatchesField(p.includes, field)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			p.filter(v, field+".", in)
+			if !in && len(v) == 0 {
+				delete(source, key)
+			}
+		case []interface{}:
+			kept := v[:0]
+			for _, item := range v {
+				object, ok := item.(map[string]interface{})
+				if !ok {
+					if in {
+						kept = append(kept, item)
+					}
+					continue
+				}
+				p.filter(object, field+".", in)
+				if in || len(object) > 0 {
+					kept = append(kept, object)
+				}
+			}
+			if !in && len(kept) == 0 {
+				delete(source, key)
+			} else {
+				source[key] = kept
+			}
+		default:
+			if !in {
+				delete(source, key)
+			}
+		}
+	}
+}
+
+// projectFields converts the fields query param of the searches into source
+// filters, the param is then removed from the request forwarded to
+// elasticsearch. The projection is stored in the context for the response
+// hook to enforce it on the hits.
+func projectFields(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if _, ok := query[fieldsParam]; !ok {
+			h(w, req)
+			return
+		}
+		ctx := req.Context()
+		reqACL, err := acl.FromContext(ctx)
+		if err != nil || (*reqACL != acl.Search && *reqACL != acl.Msearch) {
+			h(w, req)
+			return
+		}
+
+		var fields []string
+		for _, field := range strings.Split(query.Get(fieldsParam), ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				fields = append(fields, field)
+			}
+		}
+		query.Del(fieldsParam)
+		req.URL.RawQuery = query.Encode()
+		if len(fields) == 0 {
+			h(w, req)
+			return
+		}
+
+		reqPermission, err := permission.FromContext(ctx)
+		if err != nil {
+			// users can access all the fields
+			reqPermission = &permission.Permission{}
+		}
+		p := newProjection(fields, reqPermission)
+
+		var body []byte
+		if req.Body != nil {
+			body, err = util.ReadRequestBody(req)
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+		}
+		if *reqACL == acl.Msearch {
+			body, err = setMsearchSource(body, p.source())
+		} else {
+			body, err = setSearchField(body, "_source", p.source())
+		}
+		if err != nil {
+			util.WriteBackError(w, "can't parse request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		util.SetRequestBody(req, body)
+		if req.Method == http.MethodGet && len(body) > 0 {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		ctx = context.WithValue(ctx, projectionKey, p)
+		h(w, req.WithContext(ctx))
+	}
+}
+
+// setSearchField sets the field of the search body.
+func setSearchField(body []byte, key string, value interface{}) ([]byte, error) {
+	search := make(map[string]json.RawMessage)
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &search); err != nil {
+			return nil, err
+		}
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	search[key] = raw
+	return json.Marshal(search)
+}
+
+// setMsearchSource sets the source filter of each search of the msearch
+// body, whose lines alternate between headers and searches.
+func setMsearchSource(body []byte, source interface{}) ([]byte, error) {
+	lines := bytes.Split(body, []byte("\n"))
+	var modified bytes.Buffer
+	searchLine := false
+	for _, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if searchLine {
+			var err error
+			if line, err = setSearchField(line, "_source", source); err != nil {
+				return nil, err
+			}
+		}
+		modified.Write(line)
+		modified.WriteByte('\n')
+		searchLine = !searchLine
+	}
+	return modified.Bytes(), nil
+}
+
+// enforceProjection drops the fields of the hits that aren't part of the
+// projection of the request, should elasticsearch return them regardless.
+func enforceProjection(req *http.Request, resp *plugins.Response) error {
+	p, ok := req.Context().Value(projectionKey).(*projection)
+	if !ok || resp.Code != http.StatusOK {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(resp.Body))
+	decoder.UseNumber()
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return err
+	}
+	if responses, ok := body["responses"].([]interface{}); ok {
+		for _, r := range responses {
+			if r, ok := r.(map[string]interface{}); ok {
+				p.filterHits(r)
+			}
+		}
+	} else {
+		p.filterHits(body)
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp.Body = raw
+	return nil
+}
+
+func (p *projection) filterHits(response map[string]interface{}) {
+	hits, _ := response["hits"].(map[string]interface{})
+	items, _ := hits["hits"].([]interface{})
+	for _, item := range items {
+		hit, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if source, ok := hit["_source"].(map[string]interface{}); ok {
+			p.filter(source, "", false)
+		}
+	}
+}
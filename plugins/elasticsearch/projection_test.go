@@ -0,0 +1,84 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/plugins"
+)
+
+func TestNewProjection(t *testing.T) {
+	Convey("The requested fields are intersected with the accessible fields", t, func() {
+		p := newProjection([]string{"title", "price", "meta"}, &permission.Permission{
+			Includes: []string{"title", "meta.tags", "meta.brand"},
+			Excludes: []string{"meta.brand"},
+		})
+		So(p.includes, ShouldResemble, []string{"title", "meta.tags", "meta.brand"})
+		So(p.source(), ShouldResemble, map[string]interface{}{
+			"includes": []string{"title", "meta.tags", "meta.brand"},
+			"excludes": []string{"meta.brand"},
+		})
+	})
+
+	Convey("Credentials without include fields get the requested fields", t, func() {
+		p := newProjection([]string{"title", "price"}, &permission.Permission{Includes: []string{"*"}})
+		So(p.includes, ShouldResemble, []string{"title", "price"})
+		p = newProjection([]string{"title"}, &permission.Permission{})
+		So(p.includes, ShouldResemble, []string{"title"})
+	})
+
+	Convey("Nothing is returned if none of the requested fields can be accessed", t, func() {
+		p := newProjection([]string{"price"}, &permission.Permission{Includes: []string{"title"}})
+		So(p.source(), ShouldEqual, false)
+	})
+}
+
+func TestSetMsearchSource(t *testing.T) {
+	Convey("The source filter is set on each search of the msearch", t, func() {
+		body := "{\"index\":\"products\"}\n{\"query\":{\"match_all\":{}}}\n{}\n{}\n"
+		modified, err := setMsearchSource([]byte(body), map[string]interface{}{"includes": []string{"title"}})
+		So(err, ShouldBeNil)
+		So(string(modified), ShouldEqual, "{\"index\":\"products\"}\n"+
+			"{\"_source\":{\"includes\":[\"title\"]},\"query\":{\"match_all\":{}}}\n{}\n"+
+			"{\"_source\":{\"includes\":[\"title\"]}}\n")
+	})
+}
+
+func TestEnforceProjection(t *testing.T) {
+	p := &projection{includes: []string{"title", "meta.tags", "variants.sku"}, excludes: []string{"meta.tags.internal"}}
+	req := httptest.NewRequest(http.MethodGet, "/products/_search", nil)
+	req = req.WithContext(context.WithValue(req.Context(), projectionKey, p))
+
+	Convey("The fields of the hits outside the projection are dropped", t, func() {
+		resp := &plugins.Response{Code: http.StatusOK, Body: []byte(`{"hits": {"hits": [{"_id": "1", "_source": {
+			"title": "shoes", "price": 10,
+			"meta": {"tags": {"color": "red", "internal": "x"}, "brand": "nike"},
+			"variants": [{"sku": "a", "stock": 1}, {"stock": 2}]
+		}}]}}`)}
+		So(enforceProjection(req, resp), ShouldBeNil)
+		So(string(resp.Body), ShouldEqual, `{"hits":{"hits":[{"_id":"1","_source":{`+
+			`"meta":{"tags":{"color":"red"}},"title":"shoes","variants":[{"sku":"a"}]}}]}}`)
+	})
+
+	Convey("The hits of each msearch response are projected", t, func() {
+		resp := &plugins.Response{Code: http.StatusOK, Body: []byte(`{"responses": [{"hits": {"hits": [{"_source": {"title": "shoes", "price": 10}}]}}]}`)}
+		So(enforceProjection(req, resp), ShouldBeNil)
+		var body struct {
+			Responses []json.RawMessage `json:"responses"`
+		}
+		So(json.Unmarshal(resp.Body, &body), ShouldBeNil)
+		So(string(body.Responses[0]), ShouldEqual, `{"hits":{"hits":[{"_source":{"title":"shoes"}}]}}`)
+	})
+
+	Convey("Requests without projection are left untouched", t, func() {
+		resp := &plugins.Response{Code: http.StatusOK, Body: []byte(`{"hits": {}}`)}
+		So(enforceProjection(httptest.NewRequest(http.MethodGet, "/products/_search", nil), resp), ShouldBeNil)
+		So(string(resp.Body), ShouldEqual, `{"hits": {}}`)
+	})
+}
@@ -110,6 +110,17 @@ func (es *elasticsearch) preprocess(mw []middleware.Middleware) error {
 		Description: "You know, for search",
 	}
 	routes = append(routes, indexRoute)
+
+	// the allowed paths that don't match any of the apis are served last
+	if strictRoutes.enabled && len(strictRoutes.allowed) > 0 {
+		routes = append(routes, plugins.Route{
+			Name:        "unrecognized",
+			Methods:     []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete},
+			Path:        unrecognizedPath,
+			HandlerFunc: middlewareFunction(mw, es.handler()),
+			Description: "Serves the allowed paths of the apis unknown to arc",
+		})
+	}
 	return nil
 }
 
@@ -197,7 +208,7 @@ func decodeACL(specName string, spec *spec) (*acl.ACL, error) {
 	for _, pathToken := range pathTokens {
 		if strings.HasPrefix(pathToken, "_") {
 			pathToken = strings.TrimPrefix(pathToken, "_")
-			c, err := acl.FromString(pathToken)
+			c, err := acl.ACLString(pathToken)
 			if err != nil {
 				return nil, err
 			}
@@ -206,7 +217,7 @@ func decodeACL(specName string, spec *spec) (*acl.ACL, error) {
 	}
 
 	aclString := strings.Split(specName, ".")[0]
-	a, err := acl.FromString(aclString)
+	a, err := acl.ACLString(aclString)
 	if err != nil {
 		defaultACL := acl.Get
 		return &defaultACL, err
@@ -0,0 +1,274 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/audit"
+)
+
+// scriptACLs are the apis whose bodies may carry inline scripts.
+var scriptACLs = map[acl.ACL]bool{
+	acl.Search:        true,
+	acl.Msearch:       true,
+	acl.Count:         true,
+	acl.Explain:       true,
+	acl.Update:        true,
+	acl.UpdateByQuery: true,
+	acl.DeleteByQuery: true,
+	acl.Bulk:          true,
+	acl.Reindex:       true,
+	acl.Scripts:       true,
+}
+
+// scriptSkipKeys hold documents or script params rather than requests.
+var scriptSkipKeys = map[string]bool{
+	"_source":   true,
+	"doc":       true,
+	"upsert":    true,
+	"document":  true,
+	"documents": true,
+	"params":    true,
+}
+
+// recordAudit records the allowed scripts in the audit trail.
+var recordAudit = audit.Record
+
+// inlineScript is an inline script of the request body along with its location.
+type inlineScript struct {
+	Location string `json:"location"`
+	Lang     string `json:"lang,omitempty"`
+	Source   string `json:"source"`
+}
+
+// enforceScripts applies the script policy of the permission to the inline
+// scripts of the request body. The scripts are rejected in block mode, or
+// unless allowed in allowlist mode, the allowed scripts are recorded in the
+// audit trail.
+func enforceScripts(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		reqACL, err := acl.FromContext(ctx)
+		if err != nil || !scriptACLs[*reqACL] || req.Body == nil {
+			h(w, req)
+			return
+		}
+		reqPermission, err := permission.FromContext(ctx)
+		if err != nil || reqPermission.Scripts == nil {
+			h(w, req)
+			return
+		}
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var scripts []inlineScript
+		switch *reqACL {
+		case acl.Bulk:
+			scripts, err = bulkScripts(body)
+		case acl.Msearch:
+			scripts, err = msearchScripts(body)
+		default:
+			scripts, err = bodyScripts(body, "")
+		}
+		if err != nil {
+			util.WriteBackError(w, "can't parse request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(scripts) == 0 {
+			h(w, req)
+			return
+		}
+
+		policy := reqPermission.Scripts
+		var details []util.ErrorDetail
+		for _, script := range scripts {
+			switch {
+			case policy.Mode == permission.ScriptsBlock:
+				details = append(details, util.ErrorDetail{
+					Type:     "script_forbidden",
+					Reason:   "inline scripts aren't allowed",
+					Location: script.Location,
+				})
+			case policy.Mode == permission.ScriptsAllowlist && !allowedScript(policy.Allowed, script.Source):
+				details = append(details, util.ErrorDetail{
+					Type:     "script_forbidden",
+					Reason:   "inline script isn't allowlisted, its digest is " + scriptDigest(script.Source),
+					Location: script.Location,
+				})
+			}
+		}
+		if len(details) > 0 {
+			util.WriteBackErrorWithDetails(w, "request carries forbidden scripts", http.StatusForbidden, details)
+			return
+		}
+
+		event := audit.NewEvent(req, "script", req.URL.Path, http.StatusOK)
+		event.Details = map[string]interface{}{"method": req.Method, "scripts": scripts}
+		go recordAudit(context.Background(), event)
+
+		h(w, req)
+	}
+}
+
+// allowedScript checks whether the script source, or its digest, is allowed.
+func allowedScript(allowed []string, source string) bool {
+	normalized := normalizeScript(source)
+	digest := scriptDigest(source)
+	for _, a := range allowed {
+		if a == digest || normalizeScript(a) == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeScript collapses the whitespace of the script source.
+func normalizeScript(source string) string {
+	return strings.Join(strings.Fields(source), " ")
+}
+
+// scriptDigest returns the "sha256:<hex>" digest of the normalized source.
+func scriptDigest(source string) string {
+	sum := sha256.Sum256([]byte(normalizeScript(source)))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// bodyScripts extracts the inline scripts of the json body.
+func bodyScripts(body []byte, prefix string) ([]inlineScript, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, err
+	}
+	var scripts []inlineScript
+	collectScripts(v, "", prefix, &scripts)
+	return scripts, nil
+}
+
+// bulkScripts extracts the inline scripts of the update actions of the bulk
+// body, the sources of the other actions are documents.
+func bulkScripts(body []byte) ([]inlineScript, error) {
+	var scripts []inlineScript
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), len(body)+1)
+	expectSource, update := false, false
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Bytes()
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		if expectSource {
+			expectSource = false
+			if update {
+				found, err := bodyScripts(raw, fmt.Sprintf("line %d: ", line))
+				if err != nil {
+					return nil, err
+				}
+				scripts = append(scripts, found...)
+			}
+			continue
+		}
+		var action map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &action); err != nil || len(action) != 1 {
+			return nil, fmt.Errorf("malformed bulk action on line %d", line)
+		}
+		for name := range action {
+			expectSource = name != "delete"
+			update = name == "update"
+		}
+	}
+	return scripts, scanner.Err()
+}
+
+// msearchScripts extracts the inline scripts of the searches of the msearch
+// body, whose lines alternate between headers and searches.
+func msearchScripts(body []byte) ([]inlineScript, error) {
+	var scripts []inlineScript
+	searchLine := false
+	for i, line := range bytes.Split(body, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if searchLine {
+			found, err := bodyScripts(line, fmt.Sprintf("line %d: ", i+1))
+			if err != nil {
+				return nil, err
+			}
+			scripts = append(scripts, found...)
+		}
+		searchLine = !searchLine
+	}
+	return scripts, nil
+}
+
+// isScriptKey checks whether the key holds a script, such as the script of a
+// query or the map_script of a scripted_metric aggregation.
+func isScriptKey(key string) bool {
+	return key == "script" || (strings.HasSuffix(key, "_script") && key != "_script")
+}
+
+func collectScripts(v interface{}, path, prefix string, scripts *[]inlineScript) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for key, value := range t {
+			if scriptSkipKeys[key] {
+				continue
+			}
+			location := key
+			if path != "" {
+				location = path + "." + key
+			}
+			if isScriptKey(key) {
+				if script, ok := inline(value); ok {
+					script.Location = prefix + location
+					*scripts = append(*scripts, script)
+					continue
+				}
+			}
+			collectScripts(value, location, prefix, scripts)
+		}
+	case []interface{}:
+		for i, value := range t {
+			collectScripts(value, fmt.Sprintf("%s[%d]", path, i), prefix, scripts)
+		}
+	}
+}
+
+// inline returns the inline script, given either as its source or as an
+// object with a source, the stored scripts referenced by id aren't inline.
+func inline(v interface{}) (inlineScript, bool) {
+	switch t := v.(type) {
+	case string:
+		return inlineScript{Source: t}, true
+	case map[string]interface{}:
+		source, ok := t["source"].(string)
+		if !ok {
+			source, ok = t["inline"].(string)
+		}
+		if !ok {
+			return inlineScript{}, false
+		}
+		lang, _ := t["lang"].(string)
+		return inlineScript{Lang: lang, Source: source}, true
+	}
+	return inlineScript{}, false
+}
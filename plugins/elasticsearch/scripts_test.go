@@ -0,0 +1,96 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/audit"
+)
+
+func TestEnforceScripts(t *testing.T) {
+	recorded := make(chan audit.Event, 1)
+	recordAudit = func(ctx context.Context, e audit.Event) { recorded <- e }
+	defer func() { recordAudit = audit.Record }()
+
+	var forwarded string
+	handler := enforceScripts(func(w http.ResponseWriter, req *http.Request) {
+		raw, _ := ioutil.ReadAll(req.Body)
+		forwarded = string(raw)
+	})
+	serve := func(body string, reqACL acl.ACL, policy *permission.ScriptPolicy) *httptest.ResponseRecorder {
+		forwarded = ""
+		req := httptest.NewRequest(http.MethodPost, "/products/_update/1", strings.NewReader(body))
+		ctx := acl.NewContext(req.Context(), &reqACL)
+		ctx = permission.NewContext(ctx, &permission.Permission{Scripts: policy})
+		w := httptest.NewRecorder()
+		handler(w, req.WithContext(ctx))
+		return w
+	}
+	details := func(w *httptest.ResponseRecorder) []util.ErrorDetail {
+		var envelope util.ErrorEnvelope
+		json.Unmarshal(w.Body.Bytes(), &envelope)
+		return envelope.Error.Details
+	}
+	update := `{"script":{"source":"ctx._source.count += params.n","params":{"n":1}},"upsert":{"script":"not a script"}}`
+
+	Convey("Inline scripts are rejected in block mode", t, func() {
+		w := serve(update, acl.Update, &permission.ScriptPolicy{Mode: permission.ScriptsBlock})
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(details(w), ShouldHaveLength, 1)
+		So(details(w)[0].Location, ShouldEqual, "script")
+		So(forwarded, ShouldBeEmpty)
+	})
+
+	Convey("Stored scripts and the documents are let through", t, func() {
+		body := `{"script":{"id":"increment"},"upsert":{"script":"not a script"}}`
+		serve(body, acl.Update, &permission.ScriptPolicy{Mode: permission.ScriptsBlock})
+		So(forwarded, ShouldEqual, body)
+	})
+
+	Convey("Allowlisted scripts are allowed and audited", t, func() {
+		policy := &permission.ScriptPolicy{Mode: permission.ScriptsAllowlist, Allowed: []string{"ctx._source.count  +=  params.n"}}
+		serve(update, acl.Update, policy)
+		So(forwarded, ShouldEqual, update)
+		e := <-recorded
+		So(e.Action, ShouldEqual, "script")
+		scripts := e.Details.(map[string]interface{})["scripts"].([]inlineScript)
+		So(scripts[0].Source, ShouldEqual, "ctx._source.count += params.n")
+
+		policy.Allowed = []string{scriptDigest("ctx._source.count += params.n")}
+		serve(update, acl.Update, policy)
+		So(forwarded, ShouldEqual, update)
+		<-recorded
+
+		policy.Allowed = []string{"ctx._source.count = 0"}
+		w := serve(update, acl.Update, policy)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("The scripts of the searches and the bulk updates are located", t, func() {
+		policy := &permission.ScriptPolicy{Mode: permission.ScriptsBlock}
+		search := `{"aggs":{"total":{"scripted_metric":{"map_script":"state.x = 1","params":{"script":"x"}}}},"sort":{"_script":{"type":"number","script":{"source":"1"}}}}`
+		w := serve(search, acl.Search, policy)
+		So(details(w), ShouldHaveLength, 2)
+
+		bulk := "{\"index\":{\"_index\":\"products\"}}\n{\"script\":\"a document\"}\n{\"update\":{\"_id\":\"1\"}}\n{\"script\":\"ctx.op = 'delete'\"}\n"
+		w = serve(bulk, acl.Bulk, policy)
+		So(details(w), ShouldHaveLength, 1)
+		So(details(w)[0].Location, ShouldEqual, "line 4: script")
+	})
+
+	Convey("Audit mode allows the scripts", t, func() {
+		serve(update, acl.Update, &permission.ScriptPolicy{Mode: permission.ScriptsAudit})
+		So(forwarded, ShouldEqual, update)
+		<-recorded
+	})
+}
@@ -0,0 +1,139 @@
+package elasticsearch
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	envStrictRoutes = "ES_STRICT_ROUTES"
+	envAllowedPaths = "ES_ALLOWED_PATHS"
+	// unrecognizedPath is the path of the route serving the allowed paths
+	// that don't match any of the known apis.
+	unrecognizedPath = "/{path:.*}"
+)
+
+// unrecognizedKey is the key against which the unrecognized requests are
+// flagged in the context.
+const unrecognizedKey = contextKey("unrecognized")
+
+type contextKey string
+
+// strictRoutes configures the deny-by-default mode, in which the requests
+// that don't address one of the known apis are rejected unless allowed.
+var strictRoutes struct {
+	enabled bool
+	allowed []*regexp.Regexp
+}
+
+// endpointVars are the route variables whose values are never an api
+// endpoint, a value starting with an underscore addresses an api unknown to
+// arc that happens to match a route with variables.
+var endpointVars = map[string]map[string]bool{
+	"index":     {"_all": true},
+	"alias":     {"_all": true},
+	"target":    {},
+	"new_index": {},
+	"type":      {"_doc": true},
+}
+
+// parseAllowedPaths compiles the comma separated path patterns, "*" matches
+// any sequence of characters.
+func parseAllowedPaths(raw string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, p := range strings.Split(raw, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.HasPrefix(p, "/") {
+			return nil, fmt.Errorf(`invalid path pattern "%s" in %s, must begin with "/"`, p, envAllowedPaths)
+		}
+		pattern := "^" + strings.Replace(regexp.QuoteMeta(p), `\*`, ".*", -1) + "$"
+		patterns = append(patterns, regexp.MustCompile(pattern))
+	}
+	return patterns, nil
+}
+
+func isAllowedPath(path string) bool {
+	for _, p := range strictRoutes.allowed {
+		if p.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// recognized checks whether the matched route addresses a known api.
+func recognized(template string, vars map[string]string) bool {
+	if template == unrecognizedPath {
+		return false
+	}
+	for name, value := range vars {
+		reserved, ok := endpointVars[name]
+		if !ok {
+			continue
+		}
+		for _, v := range strings.Split(value, ",") {
+			if strings.HasPrefix(v, "_") && !reserved[v] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validateRoute rejects the requests for the apis unknown to arc in strict
+// mode, unless the path is allowed. The allowed requests are classified as
+// misc apis.
+func validateRoute(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if !strictRoutes.enabled {
+			h(w, req)
+			return
+		}
+		template, err := mux.CurrentRoute(req).GetPathTemplate()
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, "page not found", http.StatusNotFound)
+			return
+		}
+		if recognized(template, mux.Vars(req)) {
+			h(w, req)
+			return
+		}
+		if !isAllowedPath(req.URL.Path) {
+			msg := fmt.Sprintf(`unrecognized api "%s %s" isn't allowed`, req.Method, req.URL.Path)
+			util.WriteBackError(w, msg, http.StatusForbidden)
+			return
+		}
+		ctx := context.WithValue(req.Context(), unrecognizedKey, true)
+		h(w, req.WithContext(ctx))
+	}
+}
+
+// routeSpec returns the api of the route matched by the request.
+func routeSpec(req *http.Request) (api, error) {
+	template, err := mux.CurrentRoute(req).GetPathTemplate()
+	if err != nil {
+		return api{}, err
+	}
+	if unrecognized, _ := req.Context().Value(unrecognizedKey).(bool); unrecognized {
+		return api{
+			category: category.Misc,
+			acl:      acl.Get,
+			op:       decodeOp(&spec{Methods: []string{req.Method}}),
+		}, nil
+	}
+	return routeSpecs[fmt.Sprintf("%s:%s", req.Method, template)], nil
+}
@@ -0,0 +1,39 @@
+package elasticsearch
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecognized(t *testing.T) {
+	Convey("Routes addressing known apis are recognized", t, func() {
+		So(recognized("/{index}/_search", map[string]string{"index": "products,orders"}), ShouldBeTrue)
+		So(recognized("/{index}/_search", map[string]string{"index": "_all"}), ShouldBeTrue)
+		So(recognized("/{index}/{type}/{id}", map[string]string{"index": "products", "type": "_doc", "id": "_1"}), ShouldBeTrue)
+		So(recognized("/_nodes/{node_id}/stats", map[string]string{"node_id": "_local"}), ShouldBeTrue)
+	})
+
+	Convey("Unknown apis matching routes with variables aren't recognized", t, func() {
+		So(recognized("/{index}/{type}/{id}", map[string]string{"index": "_security", "type": "user", "id": "foo"}), ShouldBeFalse)
+		So(recognized("/{index}/{type}", map[string]string{"index": "products", "type": "_ilm"}), ShouldBeFalse)
+		So(recognized("/{index}", map[string]string{"index": "products,_xpack"}), ShouldBeFalse)
+		So(recognized(unrecognizedPath, map[string]string{"path": "_license"}), ShouldBeFalse)
+	})
+}
+
+func TestParseAllowedPaths(t *testing.T) {
+	Convey("Allowed paths are matched as wildcard patterns", t, func() {
+		patterns, err := parseAllowedPaths("/_license, /*/_ilm/*")
+		So(err, ShouldBeNil)
+		So(patterns, ShouldHaveLength, 2)
+		So(patterns[0].MatchString("/_license"), ShouldBeTrue)
+		So(patterns[0].MatchString("/_license/basic_status"), ShouldBeFalse)
+		So(patterns[1].MatchString("/products/_ilm/explain"), ShouldBeTrue)
+	})
+
+	Convey("Relative path patterns are rejected", t, func() {
+		_, err := parseAllowedPaths("_license")
+		So(err, ShouldNotBeNil)
+	})
+}
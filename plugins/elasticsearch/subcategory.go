@@ -0,0 +1,101 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/subcategory"
+	"github.com/appbaseio/arc/util"
+)
+
+// subcategoryHeader carries the sub-category of the search in the response,
+// where the request logs and the analytics recorders pick it up.
+const subcategoryHeader = "X-Search-Subcategory"
+
+// searchClauses make a search body with suggestions more than a suggestion.
+var searchClauses = []string{"query", "aggs", "aggregations", "post_filter", "knn", "collapse", "rescore"}
+
+// classifySubcategory classifies the requests of the search category into
+// their sub-category. The searches whose bodies only ask for suggestions, the
+// source filtering and the paging aside, are suggestions, as are the multi
+// searches made of suggestions only.
+func classifySubcategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		reqCategory, err := category.FromContext(ctx)
+		if err != nil || *reqCategory != category.Search {
+			h(w, req)
+			return
+		}
+		reqACL, err := acl.FromContext(ctx)
+		if err != nil {
+			h(w, req)
+			return
+		}
+
+		s := subcategory.Search
+		switch *reqACL {
+		case acl.Count:
+			s = subcategory.Count
+		case acl.Explain:
+			s = subcategory.Explain
+		case acl.FieldCaps:
+			s = subcategory.FieldCaps
+		case acl.Search, acl.Msearch:
+			body, err := util.ReadRequestBody(req)
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+			if suggestsOnly(*reqACL, body) {
+				s = subcategory.Suggest
+			}
+		}
+
+		w.Header().Set(subcategoryHeader, s.String())
+		req = req.WithContext(subcategory.NewContext(ctx, &s))
+		h(w, req)
+	}
+}
+
+// suggestsOnly checks whether the search, or every search of the multi
+// search, only asks for suggestions.
+func suggestsOnly(a acl.ACL, body []byte) bool {
+	if a != acl.Msearch {
+		search, err := decodeSearch(body)
+		return err == nil && isSuggestion(search)
+	}
+	searches := 0
+	searchLine := false
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if searchLine {
+			search, err := decodeSearch(line)
+			if err != nil || !isSuggestion(search) {
+				return false
+			}
+			searches++
+		}
+		searchLine = !searchLine
+	}
+	return searches > 0
+}
+
+func isSuggestion(search map[string]interface{}) bool {
+	if _, ok := search["suggest"]; !ok {
+		return false
+	}
+	for _, clause := range searchClauses {
+		if _, ok := search[clause]; ok {
+			return false
+		}
+	}
+	return true
+}
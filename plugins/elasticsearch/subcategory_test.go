@@ -0,0 +1,93 @@
+package elasticsearch
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/subcategory"
+)
+
+func TestClassifySubcategory(t *testing.T) {
+	var classified *subcategory.Subcategory
+	var forwarded string
+	handler := classifySubcategory(func(w http.ResponseWriter, req *http.Request) {
+		classified, _ = subcategory.FromContext(req.Context())
+		raw, _ := ioutil.ReadAll(req.Body)
+		forwarded = string(raw)
+	})
+	serve := func(body string, reqCategory category.Category, reqACL acl.ACL) *httptest.ResponseRecorder {
+		classified, forwarded = nil, ""
+		req := httptest.NewRequest(http.MethodPost, "/products/_search", strings.NewReader(body))
+		ctx := category.NewContext(req.Context(), &reqCategory)
+		ctx = acl.NewContext(ctx, &reqACL)
+		w := httptest.NewRecorder()
+		handler(w, req.WithContext(ctx))
+		return w
+	}
+
+	Convey("The searches asking only for suggestions are suggestions", t, func() {
+		body := `{"suggest": {"title": {"prefix": "iph", "completion": {"field": "suggest"}}}, "_source": ["title"], "size": 0}`
+		w := serve(body, category.Search, acl.Search)
+		So(*classified, ShouldEqual, subcategory.Suggest)
+		So(w.Header().Get(subcategoryHeader), ShouldEqual, "suggest")
+		So(forwarded, ShouldEqual, body)
+
+		serve(`{"query": {"match": {"title": "iphone"}}, "suggest": {"text": "iphone"}}`, category.Search, acl.Search)
+		So(*classified, ShouldEqual, subcategory.Search)
+		serve(`{"query": {"match_all": {}}}`, category.Search, acl.Search)
+		So(*classified, ShouldEqual, subcategory.Search)
+		serve(``, category.Search, acl.Search)
+		So(*classified, ShouldEqual, subcategory.Search)
+	})
+
+	Convey("The multi searches made of suggestions only are suggestions", t, func() {
+		serve("{}\n{\"suggest\": {\"text\": \"iph\"}}\n{\"index\": \"orders\"}\n{\"suggest\": {\"text\": \"sam\"}}\n", category.Search, acl.Msearch)
+		So(*classified, ShouldEqual, subcategory.Suggest)
+		serve("{}\n{\"suggest\": {\"text\": \"iph\"}}\n{}\n{\"query\": {\"match_all\": {}}}\n", category.Search, acl.Msearch)
+		So(*classified, ShouldEqual, subcategory.Search)
+	})
+
+	Convey("The other searches are classified by their acl", t, func() {
+		serve(`{}`, category.Search, acl.Count)
+		So(*classified, ShouldEqual, subcategory.Count)
+		serve(`{}`, category.Search, acl.Explain)
+		So(*classified, ShouldEqual, subcategory.Explain)
+		serve(``, category.Search, acl.FieldCaps)
+		So(*classified, ShouldEqual, subcategory.FieldCaps)
+		w := serve(`{}`, category.Docs, acl.Get)
+		So(classified, ShouldBeNil)
+		So(w.Header().Get(subcategoryHeader), ShouldBeEmpty)
+	})
+}
+
+func TestValidateSubcategory(t *testing.T) {
+	handler := classifySubcategory(validate.Subcategory()(func(w http.ResponseWriter, req *http.Request) {}))
+	serve := func(body string, p *permission.Permission) int {
+		reqCategory, reqACL := category.Search, acl.Search
+		req := httptest.NewRequest(http.MethodPost, "/products/_search", strings.NewReader(body))
+		ctx := category.NewContext(req.Context(), &reqCategory)
+		ctx = acl.NewContext(ctx, &reqACL)
+		ctx = credential.NewContext(ctx, credential.Permission)
+		ctx = permission.NewContext(ctx, p)
+		w := httptest.NewRecorder()
+		handler(w, req.WithContext(ctx))
+		return w.Code
+	}
+
+	Convey("The permissions scoped to the suggestions can't make full searches", t, func() {
+		p := &permission.Permission{Subcategories: []subcategory.Subcategory{subcategory.Suggest}}
+		So(serve(`{"suggest": {"text": "iph"}}`, p), ShouldEqual, http.StatusOK)
+		So(serve(`{"query": {"match_all": {}}}`, p), ShouldEqual, http.StatusForbidden)
+		So(serve(`{"query": {"match_all": {}}}`, &permission.Permission{}), ShouldEqual, http.StatusOK)
+	})
+}
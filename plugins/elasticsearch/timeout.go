@@ -0,0 +1,177 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	envSearchTimeout = "ES_SEARCH_TIMEOUT"
+	// deadlineGrace lets elasticsearch return the partial results of the
+	// timed out searches before the request is cancelled.
+	deadlineGrace = time.Second
+)
+
+// searchTimeout bounds the searches made with the credentials that don't
+// set their own timeout.
+var searchTimeout time.Duration
+
+// esTimePattern matches the elasticsearch time values.
+var esTimePattern = regexp.MustCompile(`^([0-9]+)(nanos|micros|ms|s|m|h|d)$`)
+
+var esTimeUnits = map[string]time.Duration{
+	"nanos":  time.Nanosecond,
+	"micros": time.Microsecond,
+	"ms":     time.Millisecond,
+	"s":      time.Second,
+	"m":      time.Minute,
+	"h":      time.Hour,
+	"d":      24 * time.Hour,
+}
+
+// parseESTime parses the elasticsearch time value.
+func parseESTime(value string) (time.Duration, bool) {
+	matches := esTimePattern.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(matches[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(n) * esTimeUnits[matches[2]], true
+}
+
+// formatESTime formats the duration as an elasticsearch time value.
+func formatESTime(d time.Duration) string {
+	ms := int64(d / time.Millisecond)
+	if ms < 1 {
+		ms = 1
+	}
+	return fmt.Sprintf("%dms", ms)
+}
+
+// exceeds checks whether the elasticsearch time value is unset, invalid or
+// exceeds the timeout.
+func exceeds(value string, timeout time.Duration) bool {
+	d, ok := parseESTime(value)
+	return !ok || d > timeout
+}
+
+// enforceTimeout bounds the searches by either the timeout of the permission
+// or the global one, and by the remaining budget of the request if it has a
+// deadline. The timeout of elasticsearch is set on the searches that don't
+// set a shorter one, and the request is cancelled if it outlives the
+// timeout, which elasticsearch doesn't enforce on every phase of the search.
+func enforceTimeout(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		reqACL, err := acl.FromContext(ctx)
+		if err != nil || (*reqACL != acl.Search && *reqACL != acl.Msearch) {
+			h(w, req)
+			return
+		}
+		timeout := searchTimeout
+		if reqPermission, err := permission.FromContext(ctx); err == nil {
+			if t := reqPermission.GetSearchTimeout(); t > 0 {
+				timeout = t
+			}
+		}
+		if budget, ok := remainingBudget(ctx); ok && (timeout <= 0 || budget < timeout) {
+			timeout = budget
+		}
+		if timeout <= 0 {
+			h(w, req)
+			return
+		}
+
+		if *reqACL == acl.Msearch {
+			if req.Body != nil {
+				body, err := util.ReadRequestBody(req)
+				if err != nil {
+					log.Errorln(logTag, ":", err)
+					util.WriteBackBodyError(w, err)
+					return
+				}
+				body, err = setMsearchTimeout(body, timeout)
+				if err != nil {
+					util.WriteBackError(w, "can't parse request body: "+err.Error(), http.StatusBadRequest)
+					return
+				}
+				util.SetRequestBody(req, body)
+			}
+		} else {
+			// the timeout param overrides the one of the body
+			query := req.URL.Query()
+			if exceeds(query.Get("timeout"), timeout) {
+				query.Set("timeout", formatESTime(timeout))
+				req.URL.RawQuery = query.Encode()
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout+deadlineGrace)
+		defer cancel()
+		h(w, req.WithContext(ctx))
+	}
+}
+
+// remainingBudget returns the time left to the deadline of the request, if
+// any, less the grace elasticsearch is given to return the partial results.
+func remainingBudget(ctx context.Context) (time.Duration, bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0, false
+	}
+	remaining := time.Until(deadline)
+	if remaining > 2*deadlineGrace {
+		return remaining - deadlineGrace, true
+	}
+	// the short budgets are split between the search and the grace
+	if remaining > 0 {
+		return remaining / 2, true
+	}
+	return time.Millisecond, true
+}
+
+// setMsearchTimeout sets the timeout of the searches of the multi search
+// that don't set a shorter one.
+func setMsearchTimeout(body []byte, timeout time.Duration) ([]byte, error) {
+	lines := bytes.Split(body, []byte("\n"))
+	var modified bytes.Buffer
+	searchLine := false
+	for _, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if searchLine {
+			var search struct {
+				Timeout string `json:"timeout"`
+			}
+			if err := json.Unmarshal(line, &search); err != nil {
+				return nil, err
+			}
+			if exceeds(search.Timeout, timeout) {
+				var err error
+				if line, err = setSearchField(line, "timeout", formatESTime(timeout)); err != nil {
+					return nil, err
+				}
+			}
+		}
+		modified.Write(line)
+		modified.WriteByte('\n')
+		searchLine = !searchLine
+	}
+	return modified.Bytes(), nil
+}
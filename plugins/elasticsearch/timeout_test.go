@@ -0,0 +1,97 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/permission"
+)
+
+func TestParseESTime(t *testing.T) {
+	Convey("The elasticsearch time values are parsed", t, func() {
+		d, ok := parseESTime("1500ms")
+		So(ok, ShouldBeTrue)
+		So(d, ShouldEqual, 1500*time.Millisecond)
+		d, ok = parseESTime("2d")
+		So(ok, ShouldBeTrue)
+		So(d, ShouldEqual, 48*time.Hour)
+		_, ok = parseESTime("-1")
+		So(ok, ShouldBeFalse)
+		_, ok = parseESTime("5 s")
+		So(ok, ShouldBeFalse)
+		So(formatESTime(2*time.Second), ShouldEqual, "2000ms")
+	})
+}
+
+func TestEnforceTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { searchTimeout = timeout }(searchTimeout)
+	searchTimeout = 5 * time.Second
+
+	request := func(method, target, body string, reqACL acl.ACL, p *permission.Permission) *http.Request {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		ctx := acl.NewContext(req.Context(), &reqACL)
+		if p != nil {
+			ctx = permission.NewContext(ctx, p)
+		}
+		return req.WithContext(ctx)
+	}
+	var served *http.Request
+	var deadline time.Time
+	handler := enforceTimeout(func(w http.ResponseWriter, req *http.Request) {
+		served = req
+		deadline, _ = req.Context().Deadline()
+	})
+
+	Convey("The searches get the global timeout unless they set a shorter one", t, func() {
+		handler(httptest.NewRecorder(), request(http.MethodGet, "/products/_search?timeout=1m", "", acl.Search, nil))
+		So(served.URL.Query().Get("timeout"), ShouldEqual, "5000ms")
+		So(time.Until(deadline), ShouldBeBetween, 5*time.Second, 6*time.Second)
+
+		handler(httptest.NewRecorder(), request(http.MethodGet, "/products/_search?timeout=1s", "", acl.Search, nil))
+		So(served.URL.Query().Get("timeout"), ShouldEqual, "1s")
+	})
+
+	Convey("The timeout of the permission overrides the global one", t, func() {
+		p := &permission.Permission{SearchTimeout: "30s"}
+		handler(httptest.NewRecorder(), request(http.MethodGet, "/products/_search", "", acl.Search, p))
+		So(served.URL.Query().Get("timeout"), ShouldEqual, "30000ms")
+		So(time.Until(deadline), ShouldBeGreaterThan, 30*time.Second)
+	})
+
+	Convey("The searches of the multi searches get the timeout", t, func() {
+		body := "{}\n{\"timeout\": \"10s\"}\n{}\n{\"timeout\": \"1s\"}\n"
+		handler(httptest.NewRecorder(), request(http.MethodPost, "/_msearch", body, acl.Msearch, nil))
+		raw := make([]byte, served.ContentLength)
+		served.Body.Read(raw)
+		So(string(raw), ShouldEqual, "{}\n{\"timeout\":\"5000ms\"}\n{}\n{\"timeout\": \"1s\"}\n")
+	})
+
+	Convey("The searches are bounded by the remaining budget of the request", t, func() {
+		req := request(http.MethodGet, "/products/_search", "", acl.Search, nil)
+		ctx, cancel := context.WithTimeout(req.Context(), 3*time.Second)
+		defer cancel()
+		handler(httptest.NewRecorder(), req.WithContext(ctx))
+		timeout, _ := parseESTime(served.URL.Query().Get("timeout"))
+		So(timeout, ShouldBeBetween, time.Second, 2*time.Second+time.Millisecond)
+		So(time.Until(deadline), ShouldBeLessThanOrEqualTo, 3*time.Second)
+
+		ctx, cancel = context.WithTimeout(req.Context(), time.Second)
+		defer cancel()
+		handler(httptest.NewRecorder(), req.WithContext(ctx))
+		timeout, _ = parseESTime(served.URL.Query().Get("timeout"))
+		So(timeout, ShouldBeLessThanOrEqualTo, 500*time.Millisecond)
+	})
+
+	Convey("The other requests aren't bounded", t, func() {
+		deadline = time.Time{}
+		handler(httptest.NewRecorder(), request(http.MethodGet, "/products/_doc/1", "", acl.Get, nil))
+		So(deadline.IsZero(), ShouldBeTrue)
+	})
+}
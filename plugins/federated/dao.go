@@ -0,0 +1,54 @@
+package federated
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	es7 "github.com/olivere/elastic/v7"
+
+	"github.com/appbaseio/arc/util"
+)
+
+type elasticsearch struct{}
+
+// searchResponse is the part of the search responses the merge relies on.
+type searchResponse struct {
+	Took int64 `json:"took"`
+	Hits struct {
+		Total *util.TotalHits          `json:"total"`
+		Hits  []map[string]interface{} `json:"hits"`
+	} `json:"hits"`
+}
+
+// search searches the indices with the query. The request is made raw so
+// that the hits are merged as returned by either of the versions.
+func (es *elasticsearch) search(ctx context.Context, indices string, body map[string]interface{}, params url.Values) (*searchResponse, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(indices) + "/_search",
+		Params: params,
+		Body:   body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(response.Body))
+	decoder.UseNumber()
+	var result searchResponse
+	if err := decoder.Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// total returns the total hits, or the number of hits returned if the total
+// isn't tracked.
+func (r *searchResponse) total() int64 {
+	if r.Hits.Total != nil {
+		return r.Hits.Total.Value
+	}
+	return int64(len(r.Hits.Hits))
+}
@@ -0,0 +1,67 @@
+package federated
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag            = "[federated]"
+	envMaxIndices     = "FEDERATED_SEARCH_MAX_INDICES"
+	defaultMaxIndices = 10
+)
+
+var (
+	singleton *federated
+	once      sync.Once
+)
+
+// federated searches several indices with the same query in parallel and
+// merges their hits into a single list, either by their normalized scores
+// or by interleaving them, weighted per index.
+type federated struct {
+	es         *elasticsearch
+	maxIndices int
+}
+
+// Use only this function to fetch the instance of federated from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *federated {
+	once.Do(func() {
+		singleton = &federated{es: &elasticsearch{}, maxIndices: defaultMaxIndices}
+	})
+	return singleton
+}
+
+func (f *federated) Name() string {
+	return logTag
+}
+
+func (f *federated) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	if value := os.Getenv(envMaxIndices); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a positive integer", envMaxIndices, value)
+		}
+		f.maxIndices = n
+	}
+	return nil
+}
+
+func (f *federated) Routes() []plugins.Route {
+	return f.routes()
+}
+
+// Default empty middleware array function
+func (f *federated) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
@@ -0,0 +1,117 @@
+package federated
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+func (f *federated) search() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r, ok := req.Context().Value(requestKey).(*searchRequest)
+		if !ok {
+			util.WriteBackError(w, "can't parse request body", http.StatusBadRequest)
+			return
+		}
+		start := time.Now()
+		results := f.fanOut(req, r)
+
+		indices := make(map[string]interface{})
+		var succeeded []result
+		var total int64
+		for _, res := range results {
+			if res.err != nil {
+				log.Errorln(logTag, ": an error occurred while searching", res.target.Index, ":", res.err)
+				indices[res.target.Index] = map[string]interface{}{"error": res.err.Error()}
+				continue
+			}
+			indices[res.target.Index] = map[string]interface{}{"total": res.total, "took": res.took}
+			succeeded = append(succeeded, res)
+			total += res.total
+		}
+		if len(succeeded) == 0 {
+			util.WriteBackESError(w, "an error occurred while searching the indices", results[0].err)
+			return
+		}
+
+		var hits []map[string]interface{}
+		if r.Merge == mergeInterleave {
+			hits = interleave(succeeded, r.From, *r.Size)
+		} else {
+			hits = mergeByScore(succeeded, r.Normalization, r.From, *r.Size)
+		}
+		raw, err := json.Marshal(map[string]interface{}{
+			"took": time.Since(start).Milliseconds(),
+			"hits": map[string]interface{}{
+				"total": total,
+				"hits":  hits,
+			},
+			"indices": indices,
+		})
+		if err != nil {
+			log.Errorln(logTag, ": unable to marshal the merged hits:", err)
+			util.WriteBackError(w, "an error occurred while merging the hits", http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// fanOut searches the targets of the request in parallel, each for enough
+// hits to fill the requested page on its own.
+func (f *federated) fanOut(req *http.Request, r *searchRequest) []result {
+	body := map[string]interface{}{"from": 0, "size": r.From + *r.Size}
+	if len(r.Query) > 0 {
+		body["query"] = r.Query
+	}
+	params := sourceFilters(req)
+
+	results := make([]result, len(r.Indices))
+	var wg sync.WaitGroup
+	for i, t := range r.Indices {
+		wg.Add(1)
+		go func(i int, t target) {
+			defer wg.Done()
+			results[i].target = t
+			response, err := f.es.search(req.Context(), t.Index, body, params)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			results[i].hits = response.Hits.Hits
+			results[i].total = response.total()
+			results[i].took = response.Took
+		}(i, t)
+	}
+	wg.Wait()
+	return results
+}
+
+// sourceFilters returns the query params applying the source filters of the
+// permission, if any.
+func sourceFilters(req *http.Request) url.Values {
+	params := url.Values{}
+	reqPermission, err := permission.FromContext(req.Context())
+	if err != nil {
+		return params
+	}
+	includes, excludes := "_source_includes", "_source_excludes"
+	if util.GetVersion() == 6 {
+		includes, excludes = "_source_include", "_source_exclude"
+	}
+	if len(reqPermission.Includes) > 0 && !(len(reqPermission.Includes) == 1 && reqPermission.Includes[0] == "*") {
+		params.Set(includes, strings.Join(reqPermission.Includes, ","))
+	}
+	if len(reqPermission.Excludes) > 0 {
+		params.Set(excludes, strings.Join(reqPermission.Excludes, ","))
+	}
+	return params
+}
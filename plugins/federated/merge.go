@@ -0,0 +1,115 @@
+package federated
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// result is the outcome of the search of a target.
+type result struct {
+	target target
+	hits   []map[string]interface{}
+	total  int64
+	took   int64
+	err    error
+}
+
+// score returns the score of the hit, zero if it isn't scored.
+func score(hit map[string]interface{}) float64 {
+	n, ok := hit["_score"].(json.Number)
+	if !ok {
+		return 0
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// normalize returns the scores of the hits scaled to be comparable across
+// the indices: "max" divides them by the max score, "min_max" scales them
+// between 0 and 1.
+func normalize(hits []map[string]interface{}, mode string) []float64 {
+	scores := make([]float64, len(hits))
+	min, max := math.Inf(1), math.Inf(-1)
+	for i, hit := range hits {
+		scores[i] = score(hit)
+		min, max = math.Min(min, scores[i]), math.Max(max, scores[i])
+	}
+	for i := range scores {
+		switch mode {
+		case normalizeMax:
+			if max > 0 {
+				scores[i] /= max
+			}
+		case normalizeMinMax:
+			if max > min {
+				scores[i] = (scores[i] - min) / (max - min)
+			} else {
+				scores[i] = 1
+			}
+		}
+	}
+	return scores
+}
+
+// federatedHit annotates the hit with the weight and the original score.
+func federatedHit(hit map[string]interface{}, t target) map[string]interface{} {
+	hit["_federated"] = map[string]interface{}{
+		"index":  t.Index,
+		"weight": t.Weight,
+		"score":  hit["_score"],
+	}
+	return hit
+}
+
+// mergeByScore merges the hits by their normalized scores multiplied by the
+// weight of their index, the ties are broken by the order of the indices.
+func mergeByScore(results []result, normalization string, from, size int) []map[string]interface{} {
+	type scored struct {
+		hit   map[string]interface{}
+		score float64
+	}
+	var all []scored
+	for _, r := range results {
+		scores := normalize(r.hits, normalization)
+		for i, hit := range r.hits {
+			all = append(all, scored{federatedHit(hit, r.target), scores[i] * r.target.Weight})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].score > all[j].score })
+
+	hits := []map[string]interface{}{}
+	for i := from; i < len(all) && i < from+size; i++ {
+		all[i].hit["_score"] = all[i].score
+		hits = append(hits, all[i].hit)
+	}
+	return hits
+}
+
+// interleave merges the hits by taking turns across the indices in
+// proportion to their weight, the hits keep the order of their index.
+func interleave(results []result, from, size int) []map[string]interface{} {
+	taken := make([]int, len(results))
+	hits := []map[string]interface{}{}
+	for n := 0; n < from+size; n++ {
+		next := -1
+		for i, r := range results {
+			if taken[i] >= len(r.hits) {
+				continue
+			}
+			// the index the furthest behind its share goes next
+			if next < 0 || float64(taken[i])/r.target.Weight < float64(taken[next])/results[next].target.Weight {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		hit := results[next].hits[taken[next]]
+		taken[next]++
+		if n >= from {
+			hits = append(hits, federatedHit(hit, results[next].target))
+		}
+	}
+	return hits
+}
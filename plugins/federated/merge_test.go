@@ -0,0 +1,102 @@
+package federated
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func hitsOf(index string, scores ...string) []map[string]interface{} {
+	var hits []map[string]interface{}
+	for i, s := range scores {
+		hits = append(hits, map[string]interface{}{
+			"_index": index,
+			"_id":    index + string(rune('a'+i)),
+			"_score": json.Number(s),
+		})
+	}
+	return hits
+}
+
+func ids(hits []map[string]interface{}) []string {
+	var ids []string
+	for _, hit := range hits {
+		ids = append(ids, hit["_id"].(string))
+	}
+	return ids
+}
+
+func TestValidate(t *testing.T) {
+	Convey("The defaults are set", t, func() {
+		r := searchRequest{Indices: []target{{Index: "products"}, {Index: "blogs, articles", Weight: 2}}}
+		So(r.validate(10), ShouldBeNil)
+		So(*r.Size, ShouldEqual, defaultSize)
+		So(r.Merge, ShouldEqual, mergeScore)
+		So(r.Normalization, ShouldEqual, normalizeMax)
+		So(r.Indices[0].Weight, ShouldEqual, 1)
+		So(r.indices(), ShouldResemble, []string{"products", "blogs", "articles"})
+	})
+
+	Convey("Invalid requests are rejected", t, func() {
+		So((&searchRequest{}).validate(10), ShouldNotBeNil)
+		So((&searchRequest{Indices: []target{{Index: "a"}, {Index: "b"}}}).validate(1), ShouldNotBeNil)
+		So((&searchRequest{Indices: []target{{Index: "a", Weight: -1}}}).validate(10), ShouldNotBeNil)
+		So((&searchRequest{Indices: []target{{Index: "a"}}, From: maxWindow}).validate(10), ShouldNotBeNil)
+		So((&searchRequest{Indices: []target{{Index: "a"}}, Merge: "random"}).validate(10), ShouldNotBeNil)
+		So((&searchRequest{Indices: []target{{Index: "a"}}, Normalization: "z"}).validate(10), ShouldNotBeNil)
+	})
+}
+
+func TestMergeByScore(t *testing.T) {
+	results := func() []result {
+		return []result{
+			{target: target{Index: "products", Weight: 1}, hits: hitsOf("products", "10", "5")},
+			{target: target{Index: "blogs", Weight: 1}, hits: hitsOf("blogs", "2", "1.5")},
+		}
+	}
+
+	Convey("The raw scores are compared without normalization", t, func() {
+		So(ids(mergeByScore(results(), normalizeNone, 0, 10)), ShouldResemble, []string{"productsa", "productsb", "blogsa", "blogsb"})
+	})
+
+	Convey("The scores are normalized per index", t, func() {
+		hits := mergeByScore(results(), normalizeMax, 0, 10)
+		So(ids(hits), ShouldResemble, []string{"productsa", "blogsa", "blogsb", "productsb"})
+		So(hits[2]["_score"], ShouldEqual, 0.75)
+		So(hits[2]["_federated"].(map[string]interface{})["score"], ShouldEqual, json.Number("1.5"))
+
+		So(ids(mergeByScore(results(), normalizeMinMax, 0, 10)), ShouldResemble, []string{"productsa", "blogsa", "productsb", "blogsb"})
+	})
+
+	Convey("The weights scale the scores and the page is applied after the merge", t, func() {
+		rs := results()
+		rs[1].target.Weight = 3
+		So(ids(mergeByScore(rs, normalizeMax, 1, 2)), ShouldResemble, []string{"blogsb", "productsa"})
+	})
+}
+
+func TestInterleave(t *testing.T) {
+	Convey("The indices take turns in proportion to their weight", t, func() {
+		rs := []result{
+			{target: target{Index: "products", Weight: 2}, hits: hitsOf("products", "1", "1", "1", "1")},
+			{target: target{Index: "blogs", Weight: 1}, hits: hitsOf("blogs", "1")},
+		}
+		So(ids(interleave(rs, 0, 10)), ShouldResemble, []string{"productsa", "blogsa", "productsb", "productsc", "productsd"})
+		So(ids(interleave(rs, 2, 2)), ShouldResemble, []string{"productsb", "productsc"})
+	})
+}
+
+func TestTotal(t *testing.T) {
+	Convey("The total hits of both the versions are read", t, func() {
+		for raw, total := range map[string]int64{
+			`{"hits": {"total": 12, "hits": []}}`:                             12,
+			`{"hits": {"total": {"value": 7, "relation": "eq"}, "hits": []}}`: 7,
+			`{"hits": {"hits": [{"_id": "a"}, {"_id": "b"}]}}`:                2,
+		} {
+			var response searchResponse
+			So(json.Unmarshal([]byte(raw), &response), ShouldBeNil)
+			So(response.total(), ShouldEqual, total)
+		}
+	})
+}
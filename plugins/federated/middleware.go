@@ -0,0 +1,110 @@
+package federated
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/tenancy"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+// requestKey is the key against which the parsed federated search request
+// is stored in the context.
+const requestKey = contextKey("federated_request")
+
+type contextKey string
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The federated searches are searches of all the indices they list, hence
+// are validated as the searches made to elasticsearch are.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classifyOp,
+		classifyIndices,
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Sources(),
+		validate.Referers(),
+		validate.Indices(),
+		validate.Category(),
+		validate.ACL(),
+		validate.Operation(),
+		validate.PermissionExpiry(),
+		tenancy.Isolate(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searchCategory := category.Search
+		ctx := category.NewContext(req.Context(), &searchCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searchACL := acl.Search
+		ctx := acl.NewContext(req.Context(), &searchACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyOp(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		readOp := op.Read
+		ctx := op.NewContext(req.Context(), &readOp)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// classifyIndices identifies the indices listed by the request body, the
+// validated request is stored in the context.
+func classifyIndices(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var r searchRequest
+		if err := json.Unmarshal(body, &r); err != nil {
+			util.WriteBackError(w, "can't parse request body", http.StatusBadRequest)
+			return
+		}
+		if err := r.validate(Instance().maxIndices); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := index.NewContext(req.Context(), r.indices())
+		ctx = context.WithValue(ctx, requestKey, &r)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
@@ -0,0 +1,97 @@
+package federated
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultSize = 10
+	// maxWindow bounds the hits fetched from each index, as the result
+	// window of the indices does.
+	maxWindow = 10000
+
+	mergeScore      = "score"
+	mergeInterleave = "interleave"
+
+	normalizeNone   = "none"
+	normalizeMax    = "max"
+	normalizeMinMax = "min_max"
+)
+
+// target is an index, or a comma separated list of indices, searched along
+// with the weight of its hits.
+type target struct {
+	Index  string  `json:"index"`
+	Weight float64 `json:"weight"`
+}
+
+// searchRequest is the body of the federated searches.
+type searchRequest struct {
+	Query         json.RawMessage `json:"query"`
+	Indices       []target        `json:"indices"`
+	From          int             `json:"from"`
+	Size          *int            `json:"size"`
+	Merge         string          `json:"merge"`
+	Normalization string          `json:"normalization"`
+}
+
+// validate checks the request and sets its defaults.
+func (r *searchRequest) validate(maxIndices int) error {
+	if len(r.Indices) == 0 {
+		return errors.New(`"indices" must list at least one index`)
+	}
+	if len(r.Indices) > maxIndices {
+		return fmt.Errorf(`"indices" can't list more than %d indices`, maxIndices)
+	}
+	for i := range r.Indices {
+		t := &r.Indices[i]
+		t.Index = strings.TrimSpace(t.Index)
+		if t.Index == "" {
+			return fmt.Errorf(`"indices[%d].index" is required`, i)
+		}
+		if t.Weight < 0 {
+			return fmt.Errorf(`"indices[%d].weight" must be positive`, i)
+		}
+		if t.Weight == 0 {
+			t.Weight = 1
+		}
+	}
+	if r.Size == nil {
+		size := defaultSize
+		r.Size = &size
+	}
+	if r.From < 0 || *r.Size < 0 || r.From+*r.Size > maxWindow {
+		return fmt.Errorf(`"from" and "size" must be non-negative and add up to at most %d`, maxWindow)
+	}
+	switch r.Merge {
+	case "":
+		r.Merge = mergeScore
+	case mergeScore, mergeInterleave:
+	default:
+		return fmt.Errorf(`"merge" must be either "%s" or "%s"`, mergeScore, mergeInterleave)
+	}
+	switch r.Normalization {
+	case "":
+		r.Normalization = normalizeMax
+	case normalizeNone, normalizeMax, normalizeMinMax:
+	default:
+		return fmt.Errorf(`"normalization" must be one of "%s", "%s" or "%s"`, normalizeNone, normalizeMax, normalizeMinMax)
+	}
+	return nil
+}
+
+// indices returns all the indices searched.
+func (r *searchRequest) indices() []string {
+	var indices []string
+	for _, t := range r.Indices {
+		for _, name := range strings.Split(t.Index, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				indices = append(indices, name)
+			}
+		}
+	}
+	return indices
+}
@@ -0,0 +1,21 @@
+package federated
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (f *federated) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Federated search",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_federated_search",
+			HandlerFunc: middleware(f.search()),
+			Description: "Searches the indices of the body with its query in parallel and merges their hits",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,108 @@
+package graphqlapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util"
+)
+
+// requestKey is the key against which the graphql request is stored in the
+// context of the execution.
+const requestKey = contextKey("request")
+
+type contextKey string
+
+// handler returns the router the fields are resolved through.
+var handler = plugins.Handler
+
+// errDecode is reported for the responses that couldn't be decoded.
+var errDecode = errors.New("unable to decode the response")
+
+// apiError is the error response of the api a field is resolved through,
+// its code is reported among the extensions of the graphql error.
+type apiError struct {
+	code    int
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func (e *apiError) Extensions() map[string]interface{} {
+	return map[string]interface{}{
+		"code":   e.code,
+		"status": http.StatusText(e.code),
+	}
+}
+
+// call resolves a field through the http api at the path, the request being
+// made with the headers of the graphql request so that the credentials are
+// authorized for each of the fields, and decodes the json response into out.
+func call(ctx context.Context, path string, query url.Values, out interface{}) error {
+	h := handler()
+	origin, ok := ctx.Value(requestKey).(*http.Request)
+	if h == nil || !ok {
+		return &apiError{http.StatusServiceUnavailable, "arc isn't ready to serve requests yet"}
+	}
+
+	target := plugins.APIVersion + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return &apiError{http.StatusBadRequest, err.Error()}
+	}
+	req = req.WithContext(ctx)
+	for key, values := range origin.Header {
+		if key == "Content-Type" || key == "Content-Length" {
+			continue
+		}
+		req.Header[key] = values
+	}
+	req.RemoteAddr = origin.RemoteAddr
+
+	w := &bufferedWriter{header: make(http.Header), code: http.StatusOK}
+	h.ServeHTTP(w, req)
+	if w.code >= http.StatusBadRequest {
+		msg := http.StatusText(w.code)
+		var envelope util.ErrorEnvelope
+		if err := json.Unmarshal(w.body.Bytes(), &envelope); err == nil && envelope.Error.Message != "" {
+			msg = envelope.Error.Message
+		}
+		return &apiError{w.code, msg}
+	}
+	if err := json.Unmarshal(w.body.Bytes(), out); err != nil {
+		log.Errorln(logTag, ": unable to decode the response of", path, ":", err)
+		return errDecode
+	}
+	return nil
+}
+
+// bufferedWriter retains the response written by the handler.
+type bufferedWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (b *bufferedWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedWriter) WriteHeader(code int) {
+	b.code = code
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
@@ -0,0 +1,63 @@
+package graphqlapi
+
+import (
+	"sync"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag = "[graphql]"
+	// maxDepth is the maximum depth of the selections of a query.
+	maxDepth = 10
+)
+
+var (
+	singleton *graphQL
+	once      sync.Once
+)
+
+// graphQL serves the users, permissions, analytics and logs as a graph. The
+// fields are resolved through the equivalent http apis with the credentials
+// of the request, hence each of them is authorized as the http request
+// would be.
+type graphQL struct {
+	schema *graphql.Schema
+}
+
+// Use only this function to fetch the instance of graphQL from within this
+// package to avoid creating stateless duplicates of the plugin.
+func Instance() *graphQL {
+	once.Do(func() {
+		singleton = &graphQL{}
+	})
+	return singleton
+}
+
+func (g *graphQL) Name() string {
+	return logTag
+}
+
+func (g *graphQL) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	s, err := graphql.ParseSchema(schema, &resolver{}, graphql.UseFieldResolvers(), graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return err
+	}
+	g.schema = s
+	return nil
+}
+
+func (g *graphQL) Routes() []plugins.Route {
+	return g.routes()
+}
+
+// Default empty middleware array function
+func (g *graphQL) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
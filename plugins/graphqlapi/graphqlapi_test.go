@@ -0,0 +1,113 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// api stubs the http apis the fields are resolved through.
+func api() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Basic Zm9vOmJhcg==" {
+			util.WriteBackError(w, "invalid credentials", http.StatusUnauthorized)
+			return
+		}
+		switch req.URL.Path {
+		case "/v1/_users":
+			w.Write([]byte(`[
+				{"username": "foo", "is_admin": true, "acls": ["search"], "created_at": "2020"},
+				{"username": "bar", "acls": ["search", "analytics"]},
+				{"username": "baz", "acls": ["analytics"], "disabled": true}
+			]`))
+		case "/v1/_permission/abc":
+			w.Write([]byte(`{"username": "abc", "owner": "foo", "ttl": 86400000000000, "include_fields": ["title"]}`))
+		case "/v1/products/_logs":
+			if req.URL.Query().Get("from") != "1" || req.URL.Query().Get("filter") != "error" {
+				util.WriteBackError(w, "unexpected query", http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"logs": [{"indices": ["products"], "category": "search", "response": {"code": 500}}], "total": 3}`))
+		case "/v1/_analytics/dashboard":
+			w.Write([]byte(`{"range": {"from": "now-1d", "to": "now", "size": 5}, "overview": {"searches": 10}, "latency": {"avg": 1.5}}`))
+		case "/v1/_analytics/zero_click_searches":
+			w.Write([]byte(`{"window": "` + req.URL.Query().Get("window") + `", "searches": 4, "queries": [{"key": "shoes", "zero_click": 2}]}`))
+		default:
+			util.WriteBackError(w, "not found", http.StatusNotFound)
+		}
+	})
+}
+
+func execute(g *graphQL, query, auth string) map[string]interface{} {
+	body, _ := json.Marshal(params{Query: query})
+	req := httptest.NewRequest(http.MethodPost, "/_graphql", strings.NewReader(string(body)))
+	req.Header.Set("Authorization", auth)
+	w := httptest.NewRecorder()
+	g.query()(w, req)
+	So(w.Code, ShouldEqual, http.StatusOK)
+	var response map[string]interface{}
+	So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+	return response
+}
+
+func TestGraphQL(t *testing.T) {
+	defer func(h func() http.Handler) { handler = h }(handler)
+	handler = func() http.Handler { return api() }
+	g := &graphQL{}
+
+	Convey("The schema matches the resolvers", t, func() {
+		So(g.InitFunc(), ShouldBeNil)
+	})
+
+	Convey("The users are filtered and paginated", t, func() {
+		response := execute(g, `{ users(filter: {acl: "search"}, first: 1) { totalCount nodes { username isAdmin } pageInfo { hasNextPage endCursor } } }`, "Basic Zm9vOmJhcg==")
+		users := response["data"].(map[string]interface{})["users"].(map[string]interface{})
+		So(users["totalCount"], ShouldEqual, 2)
+		So(users["nodes"], ShouldResemble, []interface{}{map[string]interface{}{"username": "foo", "isAdmin": true}})
+		info := users["pageInfo"].(map[string]interface{})
+		So(info["hasNextPage"], ShouldBeTrue)
+
+		response = execute(g, `{ users(filter: {acl: "search"}, after: "`+info["endCursor"].(string)+`") { nodes { username } pageInfo { hasNextPage } } }`, "Basic Zm9vOmJhcg==")
+		users = response["data"].(map[string]interface{})["users"].(map[string]interface{})
+		So(users["nodes"], ShouldResemble, []interface{}{map[string]interface{}{"username": "bar"}})
+		So(users["pageInfo"].(map[string]interface{})["hasNextPage"], ShouldBeFalse)
+	})
+
+	Convey("The fields are converted", t, func() {
+		response := execute(g, `{ permission(username: "abc") { owner ttl includeFields } }`, "Basic Zm9vOmJhcg==")
+		So(response["data"], ShouldResemble, map[string]interface{}{
+			"permission": map[string]interface{}{"owner": "foo", "ttl": "24h0m0s", "includeFields": []interface{}{"title"}},
+		})
+	})
+
+	Convey("The logs are paginated by the api", t, func() {
+		response := execute(g, `{ logs(filter: {kind: ERROR, index: "products"}, first: 1, after: "`+encodeCursor(1)+`") { totalCount nodes { category response { code } } pageInfo { hasNextPage } } }`, "Basic Zm9vOmJhcg==")
+		logs := response["data"].(map[string]interface{})["logs"].(map[string]interface{})
+		So(logs["totalCount"], ShouldEqual, 3)
+		So(logs["pageInfo"], ShouldResemble, map[string]interface{}{"hasNextPage": true})
+	})
+
+	Convey("The zero-click searches are resolved over the range of the dashboard", t, func() {
+		response := execute(g, `{ analytics(from: "now-1d") { overview { searches } latency { avg p99 } zeroClickSearches(window: "1h") { window queries { key zeroClick } } } }`, "Basic Zm9vOmJhcg==")
+		analytics := response["data"].(map[string]interface{})["analytics"].(map[string]interface{})
+		So(analytics["latency"], ShouldResemble, map[string]interface{}{"avg": 1.5, "p99": nil})
+		So(analytics["zeroClickSearches"], ShouldResemble, map[string]interface{}{
+			"window":  "1h",
+			"queries": []interface{}{map[string]interface{}{"key": "shoes", "zeroClick": float64(2)}},
+		})
+	})
+
+	Convey("The errors of the apis are reported with their code", t, func() {
+		response := execute(g, `{ permission(username: "abc") { owner } }`, "Basic d3Jvbmc=")
+		errs := response["errors"].([]interface{})
+		So(errs, ShouldHaveLength, 1)
+		So(errs[0].(map[string]interface{})["message"], ShouldEqual, "invalid credentials")
+		So(errs[0].(map[string]interface{})["extensions"], ShouldResemble, map[string]interface{}{"code": float64(401), "status": "Unauthorized"})
+	})
+}
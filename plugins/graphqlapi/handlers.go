@@ -0,0 +1,56 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// params are the parameters of a graphql request.
+type params struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// query executes the graphql query either of the query params of a GET
+// request or of the json body of a POST request.
+func (g *graphQL) query() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var p params
+		if req.Method == http.MethodGet {
+			values := req.URL.Query()
+			p.Query = values.Get("query")
+			p.OperationName = values.Get("operationName")
+			if variables := values.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &p.Variables); err != nil {
+					util.WriteBackError(w, fmt.Sprintf(`"variables" must be a json object: %v`, err), http.StatusBadRequest)
+					return
+				}
+			}
+		} else if err := json.NewDecoder(req.Body).Decode(&p); err != nil {
+			util.WriteBackError(w, fmt.Sprintf("can't parse the request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		if p.Query == "" {
+			util.WriteBackError(w, `"query" is required`, http.StatusBadRequest)
+			return
+		}
+
+		ctx := context.WithValue(req.Context(), requestKey, req)
+		response := g.schema.Exec(ctx, p.Query, p.OperationName, p.Variables)
+		raw, err := json.Marshal(response)
+		if err != nil {
+			msg := "error encoding the graphql response"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
@@ -0,0 +1,91 @@
+package graphqlapi
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	cursorPrefix    = "offset:"
+)
+
+var errCursor = errors.New(`"after" must be the end cursor of a page`)
+
+// pageArgs are the arguments the lists are paginated with.
+type pageArgs struct {
+	First *int32
+	After *string
+}
+
+type pageInfo struct {
+	HasNextPage bool
+	EndCursor   *string
+}
+
+// bounds returns the offset and the size of the page.
+func (a pageArgs) bounds() (offset, size int, err error) {
+	size = defaultPageSize
+	if a.First != nil {
+		size = int(*a.First)
+		if size < 0 || size > maxPageSize {
+			return 0, 0, fmt.Errorf(`"first" must be between 0 and %d`, maxPageSize)
+		}
+	}
+	if a.After != nil {
+		offset, err = decodeCursor(*a.After)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return offset, size, nil
+}
+
+// info returns the page info of the page of the returned items, the list
+// having total items.
+func (a pageArgs) info(offset, returned, total int) pageInfo {
+	end := offset + returned
+	info := pageInfo{HasNextPage: end < total}
+	if returned > 0 {
+		cursor := encodeCursor(end)
+		info.EndCursor = &cursor
+	}
+	return info
+}
+
+// slice returns the bounds of the page within a list of total items.
+func (a pageArgs) slice(total int) (start, end int, err error) {
+	offset, size, err := a.bounds()
+	if err != nil {
+		return 0, 0, err
+	}
+	if offset > total {
+		offset = total
+	}
+	end = offset + size
+	if end > total {
+		end = total
+	}
+	return offset, end, nil
+}
+
+// encodeCursor returns the opaque cursor of the page ending before offset.
+func encodeCursor(offset int) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, errCursor
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, errCursor
+	}
+	return offset, nil
+}
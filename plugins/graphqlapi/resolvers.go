@@ -0,0 +1,380 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// resolver is the root of the graph, the fields are resolved through the
+// equivalent http apis.
+type resolver struct{}
+
+type user struct {
+	Username         string   `json:"username"`
+	Email            string   `json:"email"`
+	Admin            *bool    `json:"is_admin"`
+	Categories       []string `json:"categories"`
+	ACLs             []string `json:"acls"`
+	Ops              []string `json:"ops"`
+	Indices          []string `json:"indices"`
+	DeniedIndices    []string `json:"denied_indices"`
+	DeniedCategories []string `json:"denied_categories"`
+	Disabled         bool     `json:"disabled"`
+	CreatedAt        string   `json:"created_at"`
+	Deleted          string   `json:"deleted_at"`
+}
+
+func (u *user) IsAdmin() bool {
+	return u.Admin != nil && *u.Admin
+}
+
+func (u *user) DeletedAt() *string {
+	return optional(u.Deleted)
+}
+
+type userFilter struct {
+	Username *string
+	IsAdmin  *bool
+	Disabled *bool
+	Category *string
+	ACL      *string
+	Index    *string
+}
+
+func (f *userFilter) matches(u *user) bool {
+	if f == nil {
+		return true
+	}
+	return (f.Username == nil || *f.Username == u.Username) &&
+		(f.IsAdmin == nil || *f.IsAdmin == u.IsAdmin()) &&
+		(f.Disabled == nil || *f.Disabled == u.Disabled) &&
+		contains(u.Categories, f.Category) &&
+		contains(u.ACLs, f.ACL) &&
+		contains(u.Indices, f.Index)
+}
+
+type userConnection struct {
+	TotalCount int32
+	Nodes      []*user
+	PageInfo   pageInfo
+}
+
+func (r *resolver) User(ctx context.Context, args struct{ Username *string }) (*user, error) {
+	path := "/_user"
+	if args.Username != nil {
+		path += "/" + url.PathEscape(*args.Username)
+	}
+	var u user
+	if err := call(ctx, path, nil, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *resolver) Users(ctx context.Context, args struct {
+	Filter  *userFilter
+	Deleted bool
+	pageArgs
+}) (*userConnection, error) {
+	var all []*user
+	if err := call(ctx, "/_users", url.Values{"deleted": {strconv.FormatBool(args.Deleted)}}, &all); err != nil {
+		return nil, err
+	}
+	var users []*user
+	for _, u := range all {
+		if args.Filter.matches(u) {
+			users = append(users, u)
+		}
+	}
+	start, end, err := args.slice(len(users))
+	if err != nil {
+		return nil, err
+	}
+	return &userConnection{
+		TotalCount: int32(len(users)),
+		Nodes:      users[start:end],
+		PageInfo:   args.info(start, end-start, len(users)),
+	}, nil
+}
+
+type permission struct {
+	Username      string        `json:"username"`
+	Owner         string        `json:"owner"`
+	Creator       string        `json:"creator"`
+	Role          string        `json:"role"`
+	Description   string        `json:"description"`
+	Categories    []string      `json:"categories"`
+	ACLs          []string      `json:"acls"`
+	Ops           []string      `json:"ops"`
+	Indices       []string      `json:"indices"`
+	Sources       []string      `json:"sources"`
+	Referers      []string      `json:"referers"`
+	IncludeFields []string      `json:"include_fields"`
+	ExcludeFields []string      `json:"exclude_fields"`
+	Tenant        string        `json:"tenant"`
+	CreatedAt     string        `json:"created_at"`
+	Lifetime      time.Duration `json:"ttl"`
+	Expired       bool          `json:"expired"`
+}
+
+func (p *permission) TTL() *string {
+	if p.Lifetime <= 0 {
+		return nil
+	}
+	return optional(p.Lifetime.String())
+}
+
+type permissionFilter struct {
+	Owner    *string
+	Role     *string
+	Tenant   *string
+	Category *string
+	ACL      *string
+	Index    *string
+	Expired  *bool
+}
+
+func (f *permissionFilter) matches(p *permission) bool {
+	if f == nil {
+		return true
+	}
+	return (f.Owner == nil || *f.Owner == p.Owner) &&
+		(f.Role == nil || *f.Role == p.Role) &&
+		(f.Tenant == nil || *f.Tenant == p.Tenant) &&
+		(f.Expired == nil || *f.Expired == p.Expired) &&
+		contains(p.Categories, f.Category) &&
+		contains(p.ACLs, f.ACL) &&
+		contains(p.Indices, f.Index)
+}
+
+type permissionConnection struct {
+	TotalCount int32
+	Nodes      []*permission
+	PageInfo   pageInfo
+}
+
+func (r *resolver) Permission(ctx context.Context, args struct{ Username string }) (*permission, error) {
+	var p permission
+	if err := call(ctx, "/_permission/"+url.PathEscape(args.Username), nil, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *resolver) Permissions(ctx context.Context, args struct {
+	Filter *permissionFilter
+	pageArgs
+}) (*permissionConnection, error) {
+	var all []*permission
+	if err := call(ctx, "/_permissions", nil, &all); err != nil {
+		return nil, err
+	}
+	var permissions []*permission
+	for _, p := range all {
+		if args.Filter.matches(p) {
+			permissions = append(permissions, p)
+		}
+	}
+	start, end, err := args.slice(len(permissions))
+	if err != nil {
+		return nil, err
+	}
+	return &permissionConnection{
+		TotalCount: int32(len(permissions)),
+		Nodes:      permissions[start:end],
+		PageInfo:   args.info(start, end-start, len(permissions)),
+	}, nil
+}
+
+type dashboardRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Size int32  `json:"size"`
+}
+
+type bucket struct {
+	Key   string `json:"key"`
+	Count int32  `json:"count"`
+}
+
+type overview struct {
+	Searches         int32   `json:"searches"`
+	NoResults        int32   `json:"no_results"`
+	NoResultsRate    float64 `json:"no_results_rate"`
+	Clicks           int32   `json:"clicks"`
+	ClickThroughRate float64 `json:"click_through_rate"`
+	Conversions      int32   `json:"conversions"`
+	ConversionRate   float64 `json:"conversion_rate"`
+}
+
+type popularSearch struct {
+	Key              string  `json:"key"`
+	Count            int32   `json:"count"`
+	Clicks           int32   `json:"clicks"`
+	ClickThroughRate float64 `json:"click_through_rate"`
+}
+
+type latency struct {
+	Avg *float64 `json:"avg"`
+	P50 *float64 `json:"p50"`
+	P95 *float64 `json:"p95"`
+	P99 *float64 `json:"p99"`
+}
+
+type zeroClickSearch struct {
+	Key           string  `json:"key"`
+	Searches      int32   `json:"searches"`
+	ZeroClick     int32   `json:"zero_click"`
+	ZeroClickRate float64 `json:"zero_click_rate"`
+}
+
+type zeroClickSearches struct {
+	Window        string            `json:"window"`
+	Searches      int32             `json:"searches"`
+	ZeroClick     int32             `json:"zero_click"`
+	ZeroClickRate float64           `json:"zero_click_rate"`
+	Queries       []zeroClickSearch `json:"queries"`
+}
+
+// analytics is the analytics dashboard, the zero-click searches are
+// resolved only if queried.
+type analytics struct {
+	Range             dashboardRange  `json:"range"`
+	Overview          overview        `json:"overview"`
+	PopularSearches   []popularSearch `json:"popular_searches"`
+	NoResultsSearches []bucket        `json:"no_results_searches"`
+	Geo               []bucket        `json:"geo"`
+	Latency           latency         `json:"latency"`
+
+	query url.Values
+}
+
+func (a *analytics) ZeroClickSearches(ctx context.Context, args struct{ Window *string }) (*zeroClickSearches, error) {
+	query := url.Values{}
+	for key, values := range a.query {
+		query[key] = values
+	}
+	if args.Window != nil {
+		query.Set("window", *args.Window)
+	}
+	var z zeroClickSearches
+	if err := call(ctx, "/_analytics/zero_click_searches", query, &z); err != nil {
+		return nil, err
+	}
+	return &z, nil
+}
+
+func (r *resolver) Analytics(ctx context.Context, args struct {
+	From *string
+	To   *string
+	Size *int32
+}) (*analytics, error) {
+	query := url.Values{}
+	if args.From != nil {
+		query.Set("from", *args.From)
+	}
+	if args.To != nil {
+		query.Set("to", *args.To)
+	}
+	if args.Size != nil {
+		query.Set("size", strconv.Itoa(int(*args.Size)))
+	}
+	a := &analytics{query: query}
+	if err := call(ctx, "/_analytics/dashboard", query, a); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+type logRequest struct {
+	URI    string `json:"uri"`
+	Method string `json:"method"`
+	Body   string `json:"body"`
+}
+
+type logResponse struct {
+	Code   int32  `json:"code"`
+	Status string `json:"status"`
+	Body   string `json:"body"`
+}
+
+type logEntry struct {
+	Indices   []string    `json:"indices"`
+	Category  string      `json:"category"`
+	Kind      string      `json:"event"`
+	Request   logRequest  `json:"request"`
+	Response  logResponse `json:"response"`
+	Timestamp string      `json:"timestamp"`
+}
+
+func (l *logEntry) Event() *string {
+	return optional(l.Kind)
+}
+
+type logFilter struct {
+	Kind  *string
+	Index *string
+}
+
+type logConnection struct {
+	TotalCount int32
+	Nodes      []*logEntry
+	PageInfo   pageInfo
+}
+
+func (r *resolver) Logs(ctx context.Context, args struct {
+	Filter *logFilter
+	pageArgs
+}) (*logConnection, error) {
+	offset, size, err := args.bounds()
+	if err != nil {
+		return nil, err
+	}
+	path := "/_logs"
+	query := url.Values{"from": {strconv.Itoa(offset)}, "size": {strconv.Itoa(size)}}
+	if f := args.Filter; f != nil {
+		if f.Index != nil {
+			path = "/" + url.PathEscape(*f.Index) + path
+		}
+		if f.Kind != nil {
+			query.Set("filter", strings.ToLower(*f.Kind))
+		}
+	}
+	var logs struct {
+		Logs  []*logEntry `json:"logs"`
+		Total json.Number `json:"total"`
+	}
+	if err := call(ctx, path, query, &logs); err != nil {
+		return nil, err
+	}
+	total, _ := logs.Total.Int64()
+	return &logConnection{
+		TotalCount: int32(total),
+		Nodes:      logs.Logs,
+		PageInfo:   args.info(offset, len(logs.Logs), int(total)),
+	}, nil
+}
+
+// contains reports whether the values contain the value, if any.
+func contains(values []string, value *string) bool {
+	if value == nil {
+		return true
+	}
+	for _, v := range values {
+		if v == *value {
+			return true
+		}
+	}
+	return false
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,19 @@
+package graphqlapi
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (g *graphQL) routes() []plugins.Route {
+	return []plugins.Route{
+		{
+			Name:        "GraphQL",
+			Methods:     []string{http.MethodGet, http.MethodPost},
+			Path:        "/_graphql",
+			HandlerFunc: g.query(),
+			Description: "Queries the users, permissions, analytics and logs as a graph",
+		},
+	}
+}
@@ -0,0 +1,192 @@
+package graphqlapi
+
+// schema is the graph of the management data. The lists are paginated with
+// the opaque cursors of their page info.
+const schema = `
+schema {
+	query: Query
+}
+
+type Query {
+	# The user of the credentials, or the one named if any.
+	user(username: String): User
+	users(filter: UserFilter, deleted: Boolean = false, first: Int, after: String): UserConnection!
+	permission(username: String!): Permission
+	permissions(filter: PermissionFilter, first: Int, after: String): PermissionConnection!
+	# The bounds accept the es date math, e.g. "now-7d".
+	analytics(from: String, to: String, size: Int): Analytics!
+	logs(filter: LogFilter, first: Int, after: String): LogConnection!
+}
+
+type PageInfo {
+	hasNextPage: Boolean!
+	endCursor: String
+}
+
+input UserFilter {
+	username: String
+	isAdmin: Boolean
+	disabled: Boolean
+	category: String
+	acl: String
+	index: String
+}
+
+type User {
+	username: String!
+	email: String!
+	isAdmin: Boolean!
+	categories: [String!]!
+	acls: [String!]!
+	ops: [String!]!
+	indices: [String!]!
+	deniedIndices: [String!]!
+	deniedCategories: [String!]!
+	disabled: Boolean!
+	createdAt: String!
+	deletedAt: String
+}
+
+type UserConnection {
+	totalCount: Int!
+	nodes: [User!]!
+	pageInfo: PageInfo!
+}
+
+input PermissionFilter {
+	owner: String
+	role: String
+	tenant: String
+	category: String
+	acl: String
+	index: String
+	expired: Boolean
+}
+
+type Permission {
+	username: String!
+	owner: String!
+	creator: String!
+	role: String!
+	description: String!
+	categories: [String!]!
+	acls: [String!]!
+	ops: [String!]!
+	indices: [String!]!
+	sources: [String!]!
+	referers: [String!]!
+	includeFields: [String!]!
+	excludeFields: [String!]!
+	tenant: String!
+	createdAt: String!
+	# The time to live of the permission, e.g. "24h0m0s", none if it doesn't expire.
+	ttl: String
+	expired: Boolean!
+}
+
+type PermissionConnection {
+	totalCount: Int!
+	nodes: [Permission!]!
+	pageInfo: PageInfo!
+}
+
+type Range {
+	from: String!
+	to: String!
+	size: Int!
+}
+
+type Bucket {
+	key: String!
+	count: Int!
+}
+
+type Overview {
+	searches: Int!
+	noResults: Int!
+	noResultsRate: Float!
+	clicks: Int!
+	clickThroughRate: Float!
+	conversions: Int!
+	conversionRate: Float!
+}
+
+type PopularSearch {
+	key: String!
+	count: Int!
+	clicks: Int!
+	clickThroughRate: Float!
+}
+
+type Latency {
+	avg: Float
+	p50: Float
+	p95: Float
+	p99: Float
+}
+
+type ZeroClickSearch {
+	key: String!
+	searches: Int!
+	zeroClick: Int!
+	zeroClickRate: Float!
+}
+
+type ZeroClickSearches {
+	window: String!
+	searches: Int!
+	zeroClick: Int!
+	zeroClickRate: Float!
+	queries: [ZeroClickSearch!]!
+}
+
+type Analytics {
+	range: Range!
+	overview: Overview!
+	popularSearches: [PopularSearch!]!
+	noResultsSearches: [Bucket!]!
+	geo: [Bucket!]!
+	latency: Latency!
+	# The window defaults to the session window of the analytics, e.g. "30m".
+	zeroClickSearches(window: String): ZeroClickSearches!
+}
+
+enum LogKind {
+	SEARCH
+	DELETE
+	SUCCESS
+	ERROR
+}
+
+input LogFilter {
+	kind: LogKind
+	index: String
+}
+
+type LogRequest {
+	uri: String!
+	method: String!
+	body: String!
+}
+
+type LogResponse {
+	code: Int!
+	status: String!
+	body: String!
+}
+
+type Log {
+	indices: [String!]!
+	category: String!
+	event: String
+	request: LogRequest!
+	response: LogResponse!
+	timestamp: String!
+}
+
+type LogConnection {
+	totalCount: Int!
+	nodes: [Log!]!
+	pageInfo: PageInfo!
+}
+`
@@ -0,0 +1,318 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/appbaseio/arc/plugins/grpcapi/arcpb"
+)
+
+const (
+	// tailPageSize is the number of records polled at once.
+	tailPageSize = 500
+	// tailLag is how late the records may become searchable after their
+	// timestamp, the records are polled again for that long.
+	tailLag = 5 * time.Second
+)
+
+type analyticsServer struct {
+	tailInterval time.Duration
+}
+
+type rangeBody struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Size int32  `json:"size"`
+}
+
+type bucket struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// dashboard is the dashboard as returned by the http api.
+type dashboard struct {
+	Range    rangeBody `json:"range"`
+	Overview struct {
+		Searches         int64   `json:"searches"`
+		NoResults        int64   `json:"no_results"`
+		NoResultsRate    float64 `json:"no_results_rate"`
+		Clicks           int64   `json:"clicks"`
+		ClickThroughRate float64 `json:"click_through_rate"`
+		Conversions      int64   `json:"conversions"`
+		ConversionRate   float64 `json:"conversion_rate"`
+	} `json:"overview"`
+	PopularSearches []struct {
+		Key              string  `json:"key"`
+		Count            int64   `json:"count"`
+		Clicks           int64   `json:"clicks"`
+		ClickThroughRate float64 `json:"click_through_rate"`
+	} `json:"popular_searches"`
+	NoResultsSearches []bucket `json:"no_results_searches"`
+	Geo               []bucket `json:"geo"`
+	Latency           struct {
+		Avg *float64 `json:"avg"`
+		P50 *float64 `json:"p50"`
+		P95 *float64 `json:"p95"`
+		P99 *float64 `json:"p99"`
+	} `json:"latency"`
+}
+
+// zeroClickSearches are the zero-click searches as returned by the http api.
+type zeroClickSearches struct {
+	Range         rangeBody `json:"range"`
+	Window        string    `json:"window"`
+	Searches      int64     `json:"searches"`
+	ZeroClick     int64     `json:"zero_click"`
+	ZeroClickRate float64   `json:"zero_click_rate"`
+	Queries       []struct {
+		Key           string  `json:"key"`
+		Searches      int64   `json:"searches"`
+		ZeroClick     int64   `json:"zero_click"`
+		ZeroClickRate float64 `json:"zero_click_rate"`
+	} `json:"queries"`
+}
+
+// record is an analytics record as returned by the http api.
+type record struct {
+	ID     string          `json:"id"`
+	Millis int64           `json:"millis"`
+	Source json.RawMessage `json:"source"`
+}
+
+func (s *analyticsServer) GetDashboard(ctx context.Context, req *arcpb.RangeRequest) (*arcpb.Dashboard, error) {
+	var d dashboard
+	if err := call(ctx, http.MethodGet, "/_analytics/dashboard", rangeQuery(req), &d); err != nil {
+		return nil, err
+	}
+	o := d.Overview
+	response := &arcpb.Dashboard{
+		Range: rangeMessage(d.Range),
+		Overview: &arcpb.Overview{
+			Searches:         o.Searches,
+			NoResults:        o.NoResults,
+			NoResultsRate:    o.NoResultsRate,
+			Clicks:           o.Clicks,
+			ClickThroughRate: o.ClickThroughRate,
+			Conversions:      o.Conversions,
+			ConversionRate:   o.ConversionRate,
+		},
+		NoResultsSearches: bucketMessages(d.NoResultsSearches),
+		Geo:               bucketMessages(d.Geo),
+		Latency: &arcpb.Latency{
+			Recorded: d.Latency.Avg != nil,
+			Avg:      value(d.Latency.Avg),
+			P50:      value(d.Latency.P50),
+			P95:      value(d.Latency.P95),
+			P99:      value(d.Latency.P99),
+		},
+	}
+	for _, p := range d.PopularSearches {
+		response.PopularSearches = append(response.PopularSearches, &arcpb.PopularSearch{
+			Key:              p.Key,
+			Count:            p.Count,
+			Clicks:           p.Clicks,
+			ClickThroughRate: p.ClickThroughRate,
+		})
+	}
+	return response, nil
+}
+
+func (s *analyticsServer) GetZeroClickSearches(ctx context.Context, req *arcpb.ZeroClickSearchesRequest) (*arcpb.ZeroClickSearches, error) {
+	query := rangeQuery(req.Range)
+	if req.Window != "" {
+		query.Set("window", req.Window)
+	}
+	var z zeroClickSearches
+	if err := call(ctx, http.MethodGet, "/_analytics/zero_click_searches", query, &z); err != nil {
+		return nil, err
+	}
+	response := &arcpb.ZeroClickSearches{
+		Range:         rangeMessage(z.Range),
+		Window:        z.Window,
+		Searches:      z.Searches,
+		ZeroClick:     z.ZeroClick,
+		ZeroClickRate: z.ZeroClickRate,
+	}
+	for _, q := range z.Queries {
+		response.Queries = append(response.Queries, &arcpb.ZeroClickSearch{
+			Key:           q.Key,
+			Searches:      q.Searches,
+			ZeroClick:     q.ZeroClick,
+			ZeroClickRate: q.ZeroClickRate,
+		})
+	}
+	return response, nil
+}
+
+// TailRecords polls the records recorded since the last one streamed. Each
+// poll is dispatched as an http request, so the credentials revoked in the
+// meantime end the stream.
+func (s *analyticsServer) TailRecords(req *arcpb.TailRecordsRequest, stream arcpb.Analytics_TailRecordsServer) error {
+	ctx := stream.Context()
+	since := req.Since
+	if since <= 0 {
+		since = time.Now().UnixNano() / int64(time.Millisecond)
+	}
+	t := newTail(since)
+	ticker := time.NewTicker(s.tailInterval)
+	defer ticker.Stop()
+	for {
+		query := url.Values{
+			"since": {strconv.FormatInt(t.since(), 10)},
+			"size":  {strconv.Itoa(tailPageSize)},
+		}
+		var page struct {
+			Records []record `json:"records"`
+		}
+		if err := call(ctx, http.MethodGet, "/_analytics/records", query, &page); err != nil {
+			return err
+		}
+		full := len(page.Records) == tailPageSize
+		fresh := t.fresh(page.Records, full)
+		for _, r := range fresh {
+			if err := stream.Send(recordMessage(r)); err != nil {
+				return err
+			}
+		}
+		if full && len(fresh) > 0 {
+			// the rest of the records are polled right away
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// tail keeps track of the records streamed. The records are polled again
+// for the lag after their timestamp so that the ones that became searchable
+// late are streamed too, the ones already streamed are skipped.
+type tail struct {
+	// cursor is the millis of the latest record streamed.
+	cursor int64
+	// floor is the millis the records are polled from at the earliest.
+	floor int64
+	// seen are the millis of the records streamed within the lag by id.
+	seen map[string]int64
+}
+
+func newTail(since int64) *tail {
+	return &tail{cursor: since, floor: since, seen: make(map[string]int64)}
+}
+
+func (t *tail) since() int64 {
+	since := t.cursor - int64(tailLag/time.Millisecond)
+	if since < t.floor {
+		return t.floor
+	}
+	return since
+}
+
+// fresh returns the records that weren't streamed yet. The records of a
+// full page are polled from the last one on, even within the lag.
+func (t *tail) fresh(records []record, full bool) []record {
+	var fresh []record
+	for _, r := range records {
+		if _, ok := t.seen[r.ID]; ok {
+			continue
+		}
+		t.seen[r.ID] = r.Millis
+		if r.Millis > t.cursor {
+			t.cursor = r.Millis
+		}
+		fresh = append(fresh, r)
+	}
+	if full && len(records) > 0 && records[len(records)-1].Millis > t.floor {
+		t.floor = records[len(records)-1].Millis
+	}
+	since := t.since()
+	for id, millis := range t.seen {
+		if millis < since {
+			delete(t.seen, id)
+		}
+	}
+	return fresh
+}
+
+func rangeQuery(req *arcpb.RangeRequest) url.Values {
+	query := url.Values{}
+	if req == nil {
+		return query
+	}
+	if req.From != "" {
+		query.Set("from", req.From)
+	}
+	if req.To != "" {
+		query.Set("to", req.To)
+	}
+	if req.Size > 0 {
+		query.Set("size", strconv.Itoa(int(req.Size)))
+	}
+	return query
+}
+
+func rangeMessage(r rangeBody) *arcpb.Range {
+	return &arcpb.Range{From: r.From, To: r.To, Size: r.Size}
+}
+
+func bucketMessages(buckets []bucket) []*arcpb.Bucket {
+	var messages []*arcpb.Bucket
+	for _, b := range buckets {
+		messages = append(messages, &arcpb.Bucket{Key: b.Key, Count: b.Count})
+	}
+	return messages
+}
+
+// recordMessage converts the record, the fields recorded by arc are read
+// from its source regardless of their json type.
+func recordMessage(r record) *arcpb.Record {
+	message := &arcpb.Record{Id: r.ID, Millis: r.Millis, Source: r.Source}
+	var source map[string]interface{}
+	if err := json.Unmarshal(r.Source, &source); err != nil {
+		return message
+	}
+	message.SearchQuery, _ = source["search_query"].(string)
+	message.Country, _ = source["country"].(string)
+	message.TotalHits = toInt64(source["total_hits"])
+	message.Took = toInt64(source["took"])
+	message.Click = toBool(source["click"])
+	message.Conversion = toBool(source["conversion"])
+	return message
+}
+
+// value returns the value of the latency, zero if none was recorded.
+func value(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func toInt64(value interface{}) int64 {
+	switch v := value.(type) {
+	case float64:
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseFloat(v, 64)
+		return int64(n)
+	}
+	return 0
+}
+
+func toBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package grpcapi
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTail(t *testing.T) {
+	Convey("The records are polled again within the lag", t, func() {
+		tl := newTail(1000)
+		So(tl.since(), ShouldEqual, 1000)
+
+		fresh := tl.fresh([]record{{ID: "a", Millis: 9000}, {ID: "b", Millis: 10000}}, false)
+		So(fresh, ShouldHaveLength, 2)
+		So(tl.since(), ShouldEqual, 5000)
+
+		fresh = tl.fresh([]record{{ID: "a", Millis: 9000}, {ID: "c", Millis: 8000}, {ID: "b", Millis: 10000}}, false)
+		So(fresh, ShouldResemble, []record{{ID: "c", Millis: 8000}})
+	})
+
+	Convey("The records out of the lag are forgotten", t, func() {
+		tl := newTail(1000)
+		tl.fresh([]record{{ID: "a", Millis: 2000}}, false)
+		tl.fresh([]record{{ID: "b", Millis: 20000}}, false)
+		So(tl.seen, ShouldResemble, map[string]int64{"b": 20000})
+	})
+
+	Convey("The full pages are polled from their last record", t, func() {
+		tl := newTail(1000)
+		tl.fresh([]record{{ID: "a", Millis: 2000}, {ID: "b", Millis: 3000}}, true)
+		So(tl.since(), ShouldEqual, 3000)
+	})
+}
+
+func TestRecordMessage(t *testing.T) {
+	Convey("The fields are read regardless of their type", t, func() {
+		m := recordMessage(record{ID: "a", Millis: 1, Source: []byte(`{"search_query": "jeans", "total_hits": "12", "took": 3, "click": "true", "conversion": false}`)})
+		So(m.SearchQuery, ShouldEqual, "jeans")
+		So(m.TotalHits, ShouldEqual, 12)
+		So(m.Took, ShouldEqual, 3)
+		So(m.Click, ShouldBeTrue)
+		So(m.Conversion, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,1913 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: arc.proto
+
+// The management apis of arc over grpc. The calls are authenticated by the
+// "authorization" metadata as the http requests are by the Authorization
+// header, e.g. "Basic <base64 of username:password>", and authorized as the
+// equivalent http requests, which are named by the comments of the rpcs.
+
+package arcpb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type GetUserRequest struct {
+	Username             string   `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetUserRequest) Reset()         { *m = GetUserRequest{} }
+func (m *GetUserRequest) String() string { return proto.CompactTextString(m) }
+func (*GetUserRequest) ProtoMessage()    {}
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{0}
+}
+
+func (m *GetUserRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetUserRequest.Unmarshal(m, b)
+}
+func (m *GetUserRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetUserRequest.Marshal(b, m, deterministic)
+}
+func (m *GetUserRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetUserRequest.Merge(m, src)
+}
+func (m *GetUserRequest) XXX_Size() int {
+	return xxx_messageInfo_GetUserRequest.Size(m)
+}
+func (m *GetUserRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetUserRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetUserRequest proto.InternalMessageInfo
+
+func (m *GetUserRequest) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+type ListUsersRequest struct {
+	Deleted              bool     `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListUsersRequest) Reset()         { *m = ListUsersRequest{} }
+func (m *ListUsersRequest) String() string { return proto.CompactTextString(m) }
+func (*ListUsersRequest) ProtoMessage()    {}
+func (*ListUsersRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{1}
+}
+
+func (m *ListUsersRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListUsersRequest.Unmarshal(m, b)
+}
+func (m *ListUsersRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListUsersRequest.Marshal(b, m, deterministic)
+}
+func (m *ListUsersRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListUsersRequest.Merge(m, src)
+}
+func (m *ListUsersRequest) XXX_Size() int {
+	return xxx_messageInfo_ListUsersRequest.Size(m)
+}
+func (m *ListUsersRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListUsersRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListUsersRequest proto.InternalMessageInfo
+
+func (m *ListUsersRequest) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
+type ListUsersResponse struct {
+	Users                []*User  `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListUsersResponse) Reset()         { *m = ListUsersResponse{} }
+func (m *ListUsersResponse) String() string { return proto.CompactTextString(m) }
+func (*ListUsersResponse) ProtoMessage()    {}
+func (*ListUsersResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{2}
+}
+
+func (m *ListUsersResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListUsersResponse.Unmarshal(m, b)
+}
+func (m *ListUsersResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListUsersResponse.Marshal(b, m, deterministic)
+}
+func (m *ListUsersResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListUsersResponse.Merge(m, src)
+}
+func (m *ListUsersResponse) XXX_Size() int {
+	return xxx_messageInfo_ListUsersResponse.Size(m)
+}
+func (m *ListUsersResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListUsersResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListUsersResponse proto.InternalMessageInfo
+
+func (m *ListUsersResponse) GetUsers() []*User {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
+type User struct {
+	Username         string   `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	IsAdmin          bool     `protobuf:"varint,2,opt,name=is_admin,json=isAdmin,proto3" json:"is_admin,omitempty"`
+	Email            string   `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Categories       []string `protobuf:"bytes,4,rep,name=categories,proto3" json:"categories,omitempty"`
+	Acls             []string `protobuf:"bytes,5,rep,name=acls,proto3" json:"acls,omitempty"`
+	Ops              []string `protobuf:"bytes,6,rep,name=ops,proto3" json:"ops,omitempty"`
+	Indices          []string `protobuf:"bytes,7,rep,name=indices,proto3" json:"indices,omitempty"`
+	DeniedIndices    []string `protobuf:"bytes,8,rep,name=denied_indices,json=deniedIndices,proto3" json:"denied_indices,omitempty"`
+	DeniedCategories []string `protobuf:"bytes,9,rep,name=denied_categories,json=deniedCategories,proto3" json:"denied_categories,omitempty"`
+	Disabled         bool     `protobuf:"varint,10,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	CreatedAt        string   `protobuf:"bytes,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	DeletedAt        string   `protobuf:"bytes,12,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	// raw is the user as returned by the http api, in json.
+	Raw                  []byte   `protobuf:"bytes,15,opt,name=raw,proto3" json:"raw,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *User) Reset()         { *m = User{} }
+func (m *User) String() string { return proto.CompactTextString(m) }
+func (*User) ProtoMessage()    {}
+func (*User) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{3}
+}
+
+func (m *User) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_User.Unmarshal(m, b)
+}
+func (m *User) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_User.Marshal(b, m, deterministic)
+}
+func (m *User) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_User.Merge(m, src)
+}
+func (m *User) XXX_Size() int {
+	return xxx_messageInfo_User.Size(m)
+}
+func (m *User) XXX_DiscardUnknown() {
+	xxx_messageInfo_User.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_User proto.InternalMessageInfo
+
+func (m *User) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *User) GetIsAdmin() bool {
+	if m != nil {
+		return m.IsAdmin
+	}
+	return false
+}
+
+func (m *User) GetEmail() string {
+	if m != nil {
+		return m.Email
+	}
+	return ""
+}
+
+func (m *User) GetCategories() []string {
+	if m != nil {
+		return m.Categories
+	}
+	return nil
+}
+
+func (m *User) GetAcls() []string {
+	if m != nil {
+		return m.Acls
+	}
+	return nil
+}
+
+func (m *User) GetOps() []string {
+	if m != nil {
+		return m.Ops
+	}
+	return nil
+}
+
+func (m *User) GetIndices() []string {
+	if m != nil {
+		return m.Indices
+	}
+	return nil
+}
+
+func (m *User) GetDeniedIndices() []string {
+	if m != nil {
+		return m.DeniedIndices
+	}
+	return nil
+}
+
+func (m *User) GetDeniedCategories() []string {
+	if m != nil {
+		return m.DeniedCategories
+	}
+	return nil
+}
+
+func (m *User) GetDisabled() bool {
+	if m != nil {
+		return m.Disabled
+	}
+	return false
+}
+
+func (m *User) GetCreatedAt() string {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return ""
+}
+
+func (m *User) GetDeletedAt() string {
+	if m != nil {
+		return m.DeletedAt
+	}
+	return ""
+}
+
+func (m *User) GetRaw() []byte {
+	if m != nil {
+		return m.Raw
+	}
+	return nil
+}
+
+type GetPermissionRequest struct {
+	Username             string   `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPermissionRequest) Reset()         { *m = GetPermissionRequest{} }
+func (m *GetPermissionRequest) String() string { return proto.CompactTextString(m) }
+func (*GetPermissionRequest) ProtoMessage()    {}
+func (*GetPermissionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{4}
+}
+
+func (m *GetPermissionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPermissionRequest.Unmarshal(m, b)
+}
+func (m *GetPermissionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPermissionRequest.Marshal(b, m, deterministic)
+}
+func (m *GetPermissionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPermissionRequest.Merge(m, src)
+}
+func (m *GetPermissionRequest) XXX_Size() int {
+	return xxx_messageInfo_GetPermissionRequest.Size(m)
+}
+func (m *GetPermissionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPermissionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPermissionRequest proto.InternalMessageInfo
+
+func (m *GetPermissionRequest) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+type ListPermissionsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListPermissionsRequest) Reset()         { *m = ListPermissionsRequest{} }
+func (m *ListPermissionsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListPermissionsRequest) ProtoMessage()    {}
+func (*ListPermissionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{5}
+}
+
+func (m *ListPermissionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPermissionsRequest.Unmarshal(m, b)
+}
+func (m *ListPermissionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPermissionsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListPermissionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPermissionsRequest.Merge(m, src)
+}
+func (m *ListPermissionsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListPermissionsRequest.Size(m)
+}
+func (m *ListPermissionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPermissionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPermissionsRequest proto.InternalMessageInfo
+
+type ListPermissionsResponse struct {
+	Permissions          []*Permission `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ListPermissionsResponse) Reset()         { *m = ListPermissionsResponse{} }
+func (m *ListPermissionsResponse) String() string { return proto.CompactTextString(m) }
+func (*ListPermissionsResponse) ProtoMessage()    {}
+func (*ListPermissionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{6}
+}
+
+func (m *ListPermissionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListPermissionsResponse.Unmarshal(m, b)
+}
+func (m *ListPermissionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListPermissionsResponse.Marshal(b, m, deterministic)
+}
+func (m *ListPermissionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListPermissionsResponse.Merge(m, src)
+}
+func (m *ListPermissionsResponse) XXX_Size() int {
+	return xxx_messageInfo_ListPermissionsResponse.Size(m)
+}
+func (m *ListPermissionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListPermissionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListPermissionsResponse proto.InternalMessageInfo
+
+func (m *ListPermissionsResponse) GetPermissions() []*Permission {
+	if m != nil {
+		return m.Permissions
+	}
+	return nil
+}
+
+type Permission struct {
+	Username      string   `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Owner         string   `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	Creator       string   `protobuf:"bytes,3,opt,name=creator,proto3" json:"creator,omitempty"`
+	Role          string   `protobuf:"bytes,4,opt,name=role,proto3" json:"role,omitempty"`
+	Description   string   `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	Categories    []string `protobuf:"bytes,6,rep,name=categories,proto3" json:"categories,omitempty"`
+	Acls          []string `protobuf:"bytes,7,rep,name=acls,proto3" json:"acls,omitempty"`
+	Ops           []string `protobuf:"bytes,8,rep,name=ops,proto3" json:"ops,omitempty"`
+	Indices       []string `protobuf:"bytes,9,rep,name=indices,proto3" json:"indices,omitempty"`
+	Sources       []string `protobuf:"bytes,10,rep,name=sources,proto3" json:"sources,omitempty"`
+	Referers      []string `protobuf:"bytes,11,rep,name=referers,proto3" json:"referers,omitempty"`
+	IncludeFields []string `protobuf:"bytes,12,rep,name=include_fields,json=includeFields,proto3" json:"include_fields,omitempty"`
+	ExcludeFields []string `protobuf:"bytes,13,rep,name=exclude_fields,json=excludeFields,proto3" json:"exclude_fields,omitempty"`
+	Tenant        string   `protobuf:"bytes,14,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	CreatedAt     string   `protobuf:"bytes,15,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// ttl is the time to live of the permission in nanoseconds.
+	Ttl     int64 `protobuf:"varint,16,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	Expired bool  `protobuf:"varint,17,opt,name=expired,proto3" json:"expired,omitempty"`
+	// raw is the permission as returned by the http api, in json.
+	Raw                  []byte   `protobuf:"bytes,31,opt,name=raw,proto3" json:"raw,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Permission) Reset()         { *m = Permission{} }
+func (m *Permission) String() string { return proto.CompactTextString(m) }
+func (*Permission) ProtoMessage()    {}
+func (*Permission) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{7}
+}
+
+func (m *Permission) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Permission.Unmarshal(m, b)
+}
+func (m *Permission) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Permission.Marshal(b, m, deterministic)
+}
+func (m *Permission) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Permission.Merge(m, src)
+}
+func (m *Permission) XXX_Size() int {
+	return xxx_messageInfo_Permission.Size(m)
+}
+func (m *Permission) XXX_DiscardUnknown() {
+	xxx_messageInfo_Permission.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Permission proto.InternalMessageInfo
+
+func (m *Permission) GetUsername() string {
+	if m != nil {
+		return m.Username
+	}
+	return ""
+}
+
+func (m *Permission) GetOwner() string {
+	if m != nil {
+		return m.Owner
+	}
+	return ""
+}
+
+func (m *Permission) GetCreator() string {
+	if m != nil {
+		return m.Creator
+	}
+	return ""
+}
+
+func (m *Permission) GetRole() string {
+	if m != nil {
+		return m.Role
+	}
+	return ""
+}
+
+func (m *Permission) GetDescription() string {
+	if m != nil {
+		return m.Description
+	}
+	return ""
+}
+
+func (m *Permission) GetCategories() []string {
+	if m != nil {
+		return m.Categories
+	}
+	return nil
+}
+
+func (m *Permission) GetAcls() []string {
+	if m != nil {
+		return m.Acls
+	}
+	return nil
+}
+
+func (m *Permission) GetOps() []string {
+	if m != nil {
+		return m.Ops
+	}
+	return nil
+}
+
+func (m *Permission) GetIndices() []string {
+	if m != nil {
+		return m.Indices
+	}
+	return nil
+}
+
+func (m *Permission) GetSources() []string {
+	if m != nil {
+		return m.Sources
+	}
+	return nil
+}
+
+func (m *Permission) GetReferers() []string {
+	if m != nil {
+		return m.Referers
+	}
+	return nil
+}
+
+func (m *Permission) GetIncludeFields() []string {
+	if m != nil {
+		return m.IncludeFields
+	}
+	return nil
+}
+
+func (m *Permission) GetExcludeFields() []string {
+	if m != nil {
+		return m.ExcludeFields
+	}
+	return nil
+}
+
+func (m *Permission) GetTenant() string {
+	if m != nil {
+		return m.Tenant
+	}
+	return ""
+}
+
+func (m *Permission) GetCreatedAt() string {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return ""
+}
+
+func (m *Permission) GetTtl() int64 {
+	if m != nil {
+		return m.Ttl
+	}
+	return 0
+}
+
+func (m *Permission) GetExpired() bool {
+	if m != nil {
+		return m.Expired
+	}
+	return false
+}
+
+func (m *Permission) GetRaw() []byte {
+	if m != nil {
+		return m.Raw
+	}
+	return nil
+}
+
+type RangeRequest struct {
+	// from and to are dates or date math, "now-30d" and "now" by default.
+	From string `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To   string `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	// size is the number of entries of the lists, 10 by default.
+	Size                 int32    `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RangeRequest) Reset()         { *m = RangeRequest{} }
+func (m *RangeRequest) String() string { return proto.CompactTextString(m) }
+func (*RangeRequest) ProtoMessage()    {}
+func (*RangeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{8}
+}
+
+func (m *RangeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RangeRequest.Unmarshal(m, b)
+}
+func (m *RangeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RangeRequest.Marshal(b, m, deterministic)
+}
+func (m *RangeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RangeRequest.Merge(m, src)
+}
+func (m *RangeRequest) XXX_Size() int {
+	return xxx_messageInfo_RangeRequest.Size(m)
+}
+func (m *RangeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RangeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RangeRequest proto.InternalMessageInfo
+
+func (m *RangeRequest) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *RangeRequest) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *RangeRequest) GetSize() int32 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type ZeroClickSearchesRequest struct {
+	Range *RangeRequest `protobuf:"bytes,1,opt,name=range,proto3" json:"range,omitempty"`
+	// window is the time the searches are given to be clicked, e.g. "30m".
+	Window               string   `protobuf:"bytes,2,opt,name=window,proto3" json:"window,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ZeroClickSearchesRequest) Reset()         { *m = ZeroClickSearchesRequest{} }
+func (m *ZeroClickSearchesRequest) String() string { return proto.CompactTextString(m) }
+func (*ZeroClickSearchesRequest) ProtoMessage()    {}
+func (*ZeroClickSearchesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{9}
+}
+
+func (m *ZeroClickSearchesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ZeroClickSearchesRequest.Unmarshal(m, b)
+}
+func (m *ZeroClickSearchesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ZeroClickSearchesRequest.Marshal(b, m, deterministic)
+}
+func (m *ZeroClickSearchesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ZeroClickSearchesRequest.Merge(m, src)
+}
+func (m *ZeroClickSearchesRequest) XXX_Size() int {
+	return xxx_messageInfo_ZeroClickSearchesRequest.Size(m)
+}
+func (m *ZeroClickSearchesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ZeroClickSearchesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ZeroClickSearchesRequest proto.InternalMessageInfo
+
+func (m *ZeroClickSearchesRequest) GetRange() *RangeRequest {
+	if m != nil {
+		return m.Range
+	}
+	return nil
+}
+
+func (m *ZeroClickSearchesRequest) GetWindow() string {
+	if m != nil {
+		return m.Window
+	}
+	return ""
+}
+
+type Range struct {
+	From                 string   `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To                   string   `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Size                 int32    `protobuf:"varint,3,opt,name=size,proto3" json:"size,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Range) Reset()         { *m = Range{} }
+func (m *Range) String() string { return proto.CompactTextString(m) }
+func (*Range) ProtoMessage()    {}
+func (*Range) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{10}
+}
+
+func (m *Range) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Range.Unmarshal(m, b)
+}
+func (m *Range) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Range.Marshal(b, m, deterministic)
+}
+func (m *Range) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Range.Merge(m, src)
+}
+func (m *Range) XXX_Size() int {
+	return xxx_messageInfo_Range.Size(m)
+}
+func (m *Range) XXX_DiscardUnknown() {
+	xxx_messageInfo_Range.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Range proto.InternalMessageInfo
+
+func (m *Range) GetFrom() string {
+	if m != nil {
+		return m.From
+	}
+	return ""
+}
+
+func (m *Range) GetTo() string {
+	if m != nil {
+		return m.To
+	}
+	return ""
+}
+
+func (m *Range) GetSize() int32 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type Bucket struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Count                int64    `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Bucket) Reset()         { *m = Bucket{} }
+func (m *Bucket) String() string { return proto.CompactTextString(m) }
+func (*Bucket) ProtoMessage()    {}
+func (*Bucket) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{11}
+}
+
+func (m *Bucket) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Bucket.Unmarshal(m, b)
+}
+func (m *Bucket) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Bucket.Marshal(b, m, deterministic)
+}
+func (m *Bucket) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Bucket.Merge(m, src)
+}
+func (m *Bucket) XXX_Size() int {
+	return xxx_messageInfo_Bucket.Size(m)
+}
+func (m *Bucket) XXX_DiscardUnknown() {
+	xxx_messageInfo_Bucket.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Bucket proto.InternalMessageInfo
+
+func (m *Bucket) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *Bucket) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type PopularSearch struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Count                int64    `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	Clicks               int64    `protobuf:"varint,3,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	ClickThroughRate     float64  `protobuf:"fixed64,4,opt,name=click_through_rate,json=clickThroughRate,proto3" json:"click_through_rate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PopularSearch) Reset()         { *m = PopularSearch{} }
+func (m *PopularSearch) String() string { return proto.CompactTextString(m) }
+func (*PopularSearch) ProtoMessage()    {}
+func (*PopularSearch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{12}
+}
+
+func (m *PopularSearch) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PopularSearch.Unmarshal(m, b)
+}
+func (m *PopularSearch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PopularSearch.Marshal(b, m, deterministic)
+}
+func (m *PopularSearch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PopularSearch.Merge(m, src)
+}
+func (m *PopularSearch) XXX_Size() int {
+	return xxx_messageInfo_PopularSearch.Size(m)
+}
+func (m *PopularSearch) XXX_DiscardUnknown() {
+	xxx_messageInfo_PopularSearch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PopularSearch proto.InternalMessageInfo
+
+func (m *PopularSearch) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *PopularSearch) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *PopularSearch) GetClicks() int64 {
+	if m != nil {
+		return m.Clicks
+	}
+	return 0
+}
+
+func (m *PopularSearch) GetClickThroughRate() float64 {
+	if m != nil {
+		return m.ClickThroughRate
+	}
+	return 0
+}
+
+type Overview struct {
+	Searches             int64    `protobuf:"varint,1,opt,name=searches,proto3" json:"searches,omitempty"`
+	NoResults            int64    `protobuf:"varint,2,opt,name=no_results,json=noResults,proto3" json:"no_results,omitempty"`
+	NoResultsRate        float64  `protobuf:"fixed64,3,opt,name=no_results_rate,json=noResultsRate,proto3" json:"no_results_rate,omitempty"`
+	Clicks               int64    `protobuf:"varint,4,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	ClickThroughRate     float64  `protobuf:"fixed64,5,opt,name=click_through_rate,json=clickThroughRate,proto3" json:"click_through_rate,omitempty"`
+	Conversions          int64    `protobuf:"varint,6,opt,name=conversions,proto3" json:"conversions,omitempty"`
+	ConversionRate       float64  `protobuf:"fixed64,7,opt,name=conversion_rate,json=conversionRate,proto3" json:"conversion_rate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Overview) Reset()         { *m = Overview{} }
+func (m *Overview) String() string { return proto.CompactTextString(m) }
+func (*Overview) ProtoMessage()    {}
+func (*Overview) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{13}
+}
+
+func (m *Overview) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Overview.Unmarshal(m, b)
+}
+func (m *Overview) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Overview.Marshal(b, m, deterministic)
+}
+func (m *Overview) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Overview.Merge(m, src)
+}
+func (m *Overview) XXX_Size() int {
+	return xxx_messageInfo_Overview.Size(m)
+}
+func (m *Overview) XXX_DiscardUnknown() {
+	xxx_messageInfo_Overview.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Overview proto.InternalMessageInfo
+
+func (m *Overview) GetSearches() int64 {
+	if m != nil {
+		return m.Searches
+	}
+	return 0
+}
+
+func (m *Overview) GetNoResults() int64 {
+	if m != nil {
+		return m.NoResults
+	}
+	return 0
+}
+
+func (m *Overview) GetNoResultsRate() float64 {
+	if m != nil {
+		return m.NoResultsRate
+	}
+	return 0
+}
+
+func (m *Overview) GetClicks() int64 {
+	if m != nil {
+		return m.Clicks
+	}
+	return 0
+}
+
+func (m *Overview) GetClickThroughRate() float64 {
+	if m != nil {
+		return m.ClickThroughRate
+	}
+	return 0
+}
+
+func (m *Overview) GetConversions() int64 {
+	if m != nil {
+		return m.Conversions
+	}
+	return 0
+}
+
+func (m *Overview) GetConversionRate() float64 {
+	if m != nil {
+		return m.ConversionRate
+	}
+	return 0
+}
+
+// Latency is the time taken by the searches in milliseconds, the values are
+// absent in the absence of searches.
+type Latency struct {
+	Recorded             bool     `protobuf:"varint,1,opt,name=recorded,proto3" json:"recorded,omitempty"`
+	Avg                  float64  `protobuf:"fixed64,2,opt,name=avg,proto3" json:"avg,omitempty"`
+	P50                  float64  `protobuf:"fixed64,3,opt,name=p50,proto3" json:"p50,omitempty"`
+	P95                  float64  `protobuf:"fixed64,4,opt,name=p95,proto3" json:"p95,omitempty"`
+	P99                  float64  `protobuf:"fixed64,5,opt,name=p99,proto3" json:"p99,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Latency) Reset()         { *m = Latency{} }
+func (m *Latency) String() string { return proto.CompactTextString(m) }
+func (*Latency) ProtoMessage()    {}
+func (*Latency) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{14}
+}
+
+func (m *Latency) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Latency.Unmarshal(m, b)
+}
+func (m *Latency) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Latency.Marshal(b, m, deterministic)
+}
+func (m *Latency) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Latency.Merge(m, src)
+}
+func (m *Latency) XXX_Size() int {
+	return xxx_messageInfo_Latency.Size(m)
+}
+func (m *Latency) XXX_DiscardUnknown() {
+	xxx_messageInfo_Latency.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Latency proto.InternalMessageInfo
+
+func (m *Latency) GetRecorded() bool {
+	if m != nil {
+		return m.Recorded
+	}
+	return false
+}
+
+func (m *Latency) GetAvg() float64 {
+	if m != nil {
+		return m.Avg
+	}
+	return 0
+}
+
+func (m *Latency) GetP50() float64 {
+	if m != nil {
+		return m.P50
+	}
+	return 0
+}
+
+func (m *Latency) GetP95() float64 {
+	if m != nil {
+		return m.P95
+	}
+	return 0
+}
+
+func (m *Latency) GetP99() float64 {
+	if m != nil {
+		return m.P99
+	}
+	return 0
+}
+
+type Dashboard struct {
+	Range                *Range           `protobuf:"bytes,1,opt,name=range,proto3" json:"range,omitempty"`
+	Overview             *Overview        `protobuf:"bytes,2,opt,name=overview,proto3" json:"overview,omitempty"`
+	PopularSearches      []*PopularSearch `protobuf:"bytes,3,rep,name=popular_searches,json=popularSearches,proto3" json:"popular_searches,omitempty"`
+	NoResultsSearches    []*Bucket        `protobuf:"bytes,4,rep,name=no_results_searches,json=noResultsSearches,proto3" json:"no_results_searches,omitempty"`
+	Geo                  []*Bucket        `protobuf:"bytes,5,rep,name=geo,proto3" json:"geo,omitempty"`
+	Latency              *Latency         `protobuf:"bytes,6,opt,name=latency,proto3" json:"latency,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *Dashboard) Reset()         { *m = Dashboard{} }
+func (m *Dashboard) String() string { return proto.CompactTextString(m) }
+func (*Dashboard) ProtoMessage()    {}
+func (*Dashboard) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{15}
+}
+
+func (m *Dashboard) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Dashboard.Unmarshal(m, b)
+}
+func (m *Dashboard) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Dashboard.Marshal(b, m, deterministic)
+}
+func (m *Dashboard) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Dashboard.Merge(m, src)
+}
+func (m *Dashboard) XXX_Size() int {
+	return xxx_messageInfo_Dashboard.Size(m)
+}
+func (m *Dashboard) XXX_DiscardUnknown() {
+	xxx_messageInfo_Dashboard.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Dashboard proto.InternalMessageInfo
+
+func (m *Dashboard) GetRange() *Range {
+	if m != nil {
+		return m.Range
+	}
+	return nil
+}
+
+func (m *Dashboard) GetOverview() *Overview {
+	if m != nil {
+		return m.Overview
+	}
+	return nil
+}
+
+func (m *Dashboard) GetPopularSearches() []*PopularSearch {
+	if m != nil {
+		return m.PopularSearches
+	}
+	return nil
+}
+
+func (m *Dashboard) GetNoResultsSearches() []*Bucket {
+	if m != nil {
+		return m.NoResultsSearches
+	}
+	return nil
+}
+
+func (m *Dashboard) GetGeo() []*Bucket {
+	if m != nil {
+		return m.Geo
+	}
+	return nil
+}
+
+func (m *Dashboard) GetLatency() *Latency {
+	if m != nil {
+		return m.Latency
+	}
+	return nil
+}
+
+type ZeroClickSearch struct {
+	Key                  string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Searches             int64    `protobuf:"varint,2,opt,name=searches,proto3" json:"searches,omitempty"`
+	ZeroClick            int64    `protobuf:"varint,3,opt,name=zero_click,json=zeroClick,proto3" json:"zero_click,omitempty"`
+	ZeroClickRate        float64  `protobuf:"fixed64,4,opt,name=zero_click_rate,json=zeroClickRate,proto3" json:"zero_click_rate,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ZeroClickSearch) Reset()         { *m = ZeroClickSearch{} }
+func (m *ZeroClickSearch) String() string { return proto.CompactTextString(m) }
+func (*ZeroClickSearch) ProtoMessage()    {}
+func (*ZeroClickSearch) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{16}
+}
+
+func (m *ZeroClickSearch) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ZeroClickSearch.Unmarshal(m, b)
+}
+func (m *ZeroClickSearch) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ZeroClickSearch.Marshal(b, m, deterministic)
+}
+func (m *ZeroClickSearch) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ZeroClickSearch.Merge(m, src)
+}
+func (m *ZeroClickSearch) XXX_Size() int {
+	return xxx_messageInfo_ZeroClickSearch.Size(m)
+}
+func (m *ZeroClickSearch) XXX_DiscardUnknown() {
+	xxx_messageInfo_ZeroClickSearch.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ZeroClickSearch proto.InternalMessageInfo
+
+func (m *ZeroClickSearch) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ZeroClickSearch) GetSearches() int64 {
+	if m != nil {
+		return m.Searches
+	}
+	return 0
+}
+
+func (m *ZeroClickSearch) GetZeroClick() int64 {
+	if m != nil {
+		return m.ZeroClick
+	}
+	return 0
+}
+
+func (m *ZeroClickSearch) GetZeroClickRate() float64 {
+	if m != nil {
+		return m.ZeroClickRate
+	}
+	return 0
+}
+
+type ZeroClickSearches struct {
+	Range                *Range             `protobuf:"bytes,1,opt,name=range,proto3" json:"range,omitempty"`
+	Window               string             `protobuf:"bytes,2,opt,name=window,proto3" json:"window,omitempty"`
+	Searches             int64              `protobuf:"varint,3,opt,name=searches,proto3" json:"searches,omitempty"`
+	ZeroClick            int64              `protobuf:"varint,4,opt,name=zero_click,json=zeroClick,proto3" json:"zero_click,omitempty"`
+	ZeroClickRate        float64            `protobuf:"fixed64,5,opt,name=zero_click_rate,json=zeroClickRate,proto3" json:"zero_click_rate,omitempty"`
+	Queries              []*ZeroClickSearch `protobuf:"bytes,6,rep,name=queries,proto3" json:"queries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ZeroClickSearches) Reset()         { *m = ZeroClickSearches{} }
+func (m *ZeroClickSearches) String() string { return proto.CompactTextString(m) }
+func (*ZeroClickSearches) ProtoMessage()    {}
+func (*ZeroClickSearches) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{17}
+}
+
+func (m *ZeroClickSearches) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ZeroClickSearches.Unmarshal(m, b)
+}
+func (m *ZeroClickSearches) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ZeroClickSearches.Marshal(b, m, deterministic)
+}
+func (m *ZeroClickSearches) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ZeroClickSearches.Merge(m, src)
+}
+func (m *ZeroClickSearches) XXX_Size() int {
+	return xxx_messageInfo_ZeroClickSearches.Size(m)
+}
+func (m *ZeroClickSearches) XXX_DiscardUnknown() {
+	xxx_messageInfo_ZeroClickSearches.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ZeroClickSearches proto.InternalMessageInfo
+
+func (m *ZeroClickSearches) GetRange() *Range {
+	if m != nil {
+		return m.Range
+	}
+	return nil
+}
+
+func (m *ZeroClickSearches) GetWindow() string {
+	if m != nil {
+		return m.Window
+	}
+	return ""
+}
+
+func (m *ZeroClickSearches) GetSearches() int64 {
+	if m != nil {
+		return m.Searches
+	}
+	return 0
+}
+
+func (m *ZeroClickSearches) GetZeroClick() int64 {
+	if m != nil {
+		return m.ZeroClick
+	}
+	return 0
+}
+
+func (m *ZeroClickSearches) GetZeroClickRate() float64 {
+	if m != nil {
+		return m.ZeroClickRate
+	}
+	return 0
+}
+
+func (m *ZeroClickSearches) GetQueries() []*ZeroClickSearch {
+	if m != nil {
+		return m.Queries
+	}
+	return nil
+}
+
+type TailRecordsRequest struct {
+	// since is the epoch millis of the time the records are streamed from,
+	// the time of the call by default.
+	Since                int64    `protobuf:"varint,1,opt,name=since,proto3" json:"since,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TailRecordsRequest) Reset()         { *m = TailRecordsRequest{} }
+func (m *TailRecordsRequest) String() string { return proto.CompactTextString(m) }
+func (*TailRecordsRequest) ProtoMessage()    {}
+func (*TailRecordsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{18}
+}
+
+func (m *TailRecordsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TailRecordsRequest.Unmarshal(m, b)
+}
+func (m *TailRecordsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TailRecordsRequest.Marshal(b, m, deterministic)
+}
+func (m *TailRecordsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TailRecordsRequest.Merge(m, src)
+}
+func (m *TailRecordsRequest) XXX_Size() int {
+	return xxx_messageInfo_TailRecordsRequest.Size(m)
+}
+func (m *TailRecordsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TailRecordsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TailRecordsRequest proto.InternalMessageInfo
+
+func (m *TailRecordsRequest) GetSince() int64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+type Record struct {
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// millis is the epoch millis of the timestamp of the record.
+	Millis      int64  `protobuf:"varint,2,opt,name=millis,proto3" json:"millis,omitempty"`
+	SearchQuery string `protobuf:"bytes,3,opt,name=search_query,json=searchQuery,proto3" json:"search_query,omitempty"`
+	TotalHits   int64  `protobuf:"varint,4,opt,name=total_hits,json=totalHits,proto3" json:"total_hits,omitempty"`
+	Click       bool   `protobuf:"varint,5,opt,name=click,proto3" json:"click,omitempty"`
+	Conversion  bool   `protobuf:"varint,6,opt,name=conversion,proto3" json:"conversion,omitempty"`
+	Country     string `protobuf:"bytes,7,opt,name=country,proto3" json:"country,omitempty"`
+	Took        int64  `protobuf:"varint,8,opt,name=took,proto3" json:"took,omitempty"`
+	// source is the record as stored, in json.
+	Source               []byte   `protobuf:"bytes,15,opt,name=source,proto3" json:"source,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Record) Reset()         { *m = Record{} }
+func (m *Record) String() string { return proto.CompactTextString(m) }
+func (*Record) ProtoMessage()    {}
+func (*Record) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f961f24d85691f1e, []int{19}
+}
+
+func (m *Record) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Record.Unmarshal(m, b)
+}
+func (m *Record) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Record.Marshal(b, m, deterministic)
+}
+func (m *Record) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Record.Merge(m, src)
+}
+func (m *Record) XXX_Size() int {
+	return xxx_messageInfo_Record.Size(m)
+}
+func (m *Record) XXX_DiscardUnknown() {
+	xxx_messageInfo_Record.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Record proto.InternalMessageInfo
+
+func (m *Record) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *Record) GetMillis() int64 {
+	if m != nil {
+		return m.Millis
+	}
+	return 0
+}
+
+func (m *Record) GetSearchQuery() string {
+	if m != nil {
+		return m.SearchQuery
+	}
+	return ""
+}
+
+func (m *Record) GetTotalHits() int64 {
+	if m != nil {
+		return m.TotalHits
+	}
+	return 0
+}
+
+func (m *Record) GetClick() bool {
+	if m != nil {
+		return m.Click
+	}
+	return false
+}
+
+func (m *Record) GetConversion() bool {
+	if m != nil {
+		return m.Conversion
+	}
+	return false
+}
+
+func (m *Record) GetCountry() string {
+	if m != nil {
+		return m.Country
+	}
+	return ""
+}
+
+func (m *Record) GetTook() int64 {
+	if m != nil {
+		return m.Took
+	}
+	return 0
+}
+
+func (m *Record) GetSource() []byte {
+	if m != nil {
+		return m.Source
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*GetUserRequest)(nil), "arc.v1.GetUserRequest")
+	proto.RegisterType((*ListUsersRequest)(nil), "arc.v1.ListUsersRequest")
+	proto.RegisterType((*ListUsersResponse)(nil), "arc.v1.ListUsersResponse")
+	proto.RegisterType((*User)(nil), "arc.v1.User")
+	proto.RegisterType((*GetPermissionRequest)(nil), "arc.v1.GetPermissionRequest")
+	proto.RegisterType((*ListPermissionsRequest)(nil), "arc.v1.ListPermissionsRequest")
+	proto.RegisterType((*ListPermissionsResponse)(nil), "arc.v1.ListPermissionsResponse")
+	proto.RegisterType((*Permission)(nil), "arc.v1.Permission")
+	proto.RegisterType((*RangeRequest)(nil), "arc.v1.RangeRequest")
+	proto.RegisterType((*ZeroClickSearchesRequest)(nil), "arc.v1.ZeroClickSearchesRequest")
+	proto.RegisterType((*Range)(nil), "arc.v1.Range")
+	proto.RegisterType((*Bucket)(nil), "arc.v1.Bucket")
+	proto.RegisterType((*PopularSearch)(nil), "arc.v1.PopularSearch")
+	proto.RegisterType((*Overview)(nil), "arc.v1.Overview")
+	proto.RegisterType((*Latency)(nil), "arc.v1.Latency")
+	proto.RegisterType((*Dashboard)(nil), "arc.v1.Dashboard")
+	proto.RegisterType((*ZeroClickSearch)(nil), "arc.v1.ZeroClickSearch")
+	proto.RegisterType((*ZeroClickSearches)(nil), "arc.v1.ZeroClickSearches")
+	proto.RegisterType((*TailRecordsRequest)(nil), "arc.v1.TailRecordsRequest")
+	proto.RegisterType((*Record)(nil), "arc.v1.Record")
+}
+
+func init() {
+	proto.RegisterFile("arc.proto", fileDescriptor_f961f24d85691f1e)
+}
+
+var fileDescriptor_f961f24d85691f1e = []byte{
+	// 1364 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x57, 0x4d, 0x6f, 0xdb, 0x46,
+	0x13, 0x06, 0x4d, 0x51, 0x1f, 0x23, 0xc9, 0x92, 0xf7, 0xf5, 0x9b, 0x30, 0x42, 0x9b, 0xa8, 0x2c,
+	0xda, 0xba, 0xa9, 0x61, 0x27, 0x6a, 0xd2, 0x20, 0x08, 0xd0, 0xc6, 0x49, 0x91, 0xb4, 0x40, 0x80,
+	0xa4, 0x4c, 0x7a, 0xc9, 0xa1, 0x02, 0x4d, 0x6e, 0xe4, 0x85, 0x29, 0x2e, 0xb3, 0xbb, 0xb2, 0x63,
+	0x03, 0xbd, 0xf6, 0x97, 0xf4, 0xda, 0xbf, 0xd3, 0x9f, 0xd0, 0xde, 0x7a, 0xe9, 0xbd, 0x28, 0x76,
+	0x76, 0x49, 0x51, 0x1f, 0x0e, 0x8c, 0x5e, 0x84, 0xf9, 0x78, 0xb8, 0x3b, 0xb3, 0xf3, 0xec, 0xec,
+	0x08, 0x5a, 0x91, 0x88, 0xf7, 0x72, 0xc1, 0x15, 0x27, 0x75, 0x2d, 0x9e, 0xdc, 0x0e, 0x76, 0x61,
+	0xf3, 0x29, 0x55, 0x3f, 0x4a, 0x2a, 0x42, 0xfa, 0x76, 0x46, 0xa5, 0x22, 0x03, 0x68, 0xce, 0x24,
+	0x15, 0x59, 0x34, 0xa5, 0xbe, 0x33, 0x74, 0x76, 0x5a, 0x61, 0xa9, 0x07, 0xbb, 0xd0, 0x7f, 0xc6,
+	0x24, 0xc2, 0x65, 0x81, 0xf7, 0xa1, 0x91, 0xd0, 0x94, 0x2a, 0x9a, 0x20, 0xbc, 0x19, 0x16, 0x6a,
+	0x70, 0x0f, 0xb6, 0x2a, 0x68, 0x99, 0xf3, 0x4c, 0x52, 0x12, 0x80, 0xa7, 0x97, 0x93, 0xbe, 0x33,
+	0x74, 0x77, 0xda, 0xa3, 0xce, 0x9e, 0x09, 0x64, 0x0f, 0x43, 0x30, 0xae, 0xe0, 0xef, 0x0d, 0xa8,
+	0x69, 0xfd, 0x7d, 0xb1, 0x90, 0x6b, 0xd0, 0x64, 0x72, 0x1c, 0x25, 0x53, 0x96, 0xf9, 0x1b, 0x66,
+	0x63, 0x26, 0x0f, 0xb4, 0x4a, 0xb6, 0xc1, 0xa3, 0xd3, 0x88, 0xa5, 0xbe, 0x8b, 0xdf, 0x18, 0x85,
+	0x5c, 0x07, 0x88, 0x23, 0x45, 0x27, 0x5c, 0x30, 0x2a, 0xfd, 0xda, 0xd0, 0xdd, 0x69, 0x85, 0x15,
+	0x0b, 0x21, 0x50, 0x8b, 0xe2, 0x54, 0xfa, 0x1e, 0x7a, 0x50, 0x26, 0x7d, 0x70, 0x79, 0x2e, 0xfd,
+	0x3a, 0x9a, 0xb4, 0xa8, 0xd3, 0x65, 0x59, 0xc2, 0x62, 0x2a, 0xfd, 0x06, 0x5a, 0x0b, 0x95, 0x7c,
+	0x02, 0x9b, 0x09, 0xcd, 0x18, 0x4d, 0xc6, 0x05, 0xa0, 0x89, 0x80, 0xae, 0xb1, 0x7e, 0x6f, 0x61,
+	0x5f, 0xc0, 0x96, 0x85, 0x55, 0xa2, 0x69, 0x21, 0xb2, 0x6f, 0x1c, 0x8f, 0xe7, 0x31, 0x0d, 0xa0,
+	0x99, 0x30, 0x19, 0x1d, 0xa6, 0x34, 0xf1, 0x01, 0x93, 0x2c, 0x75, 0xf2, 0x21, 0x40, 0x2c, 0x68,
+	0xa4, 0x68, 0x32, 0x8e, 0x94, 0xdf, 0xc6, 0x54, 0x5b, 0xd6, 0x72, 0xa0, 0xb4, 0xdb, 0x16, 0x42,
+	0xbb, 0x3b, 0xc6, 0x6d, 0x2d, 0x07, 0x4a, 0x67, 0x26, 0xa2, 0x53, 0xbf, 0x37, 0x74, 0x76, 0x3a,
+	0xa1, 0x16, 0x83, 0x11, 0x6c, 0x3f, 0xa5, 0xea, 0x05, 0x15, 0x53, 0x26, 0x25, 0xe3, 0xd9, 0x65,
+	0x08, 0xe1, 0xc3, 0x15, 0x5d, 0xe2, 0xf9, 0x47, 0x05, 0x2d, 0x82, 0xe7, 0x70, 0x75, 0xc5, 0x63,
+	0x29, 0x70, 0x07, 0xda, 0xf9, 0xdc, 0x6c, 0x89, 0x40, 0x0a, 0x22, 0x54, 0x02, 0xa8, 0xc2, 0x82,
+	0x3f, 0x5d, 0x80, 0xb9, 0xef, 0xbd, 0xd4, 0xd8, 0x06, 0x8f, 0x9f, 0x66, 0x54, 0x20, 0x2f, 0x5a,
+	0xa1, 0x51, 0x74, 0xe5, 0xf0, 0x74, 0xb8, 0xb0, 0xbc, 0x28, 0x54, 0x5d, 0x79, 0xc1, 0x53, 0xea,
+	0xd7, 0xd0, 0x8c, 0x32, 0x19, 0x42, 0x3b, 0xa1, 0x32, 0x16, 0x2c, 0x57, 0x8c, 0x67, 0xbe, 0x87,
+	0xae, 0xaa, 0x69, 0x89, 0x4f, 0xf5, 0x0b, 0xf9, 0xd4, 0x58, 0xe5, 0x53, 0x73, 0x2d, 0x9f, 0x5a,
+	0x8b, 0x7c, 0xf2, 0xa1, 0x21, 0xf9, 0x4c, 0x68, 0x0f, 0x18, 0x8f, 0x55, 0x75, 0xee, 0x82, 0xbe,
+	0xa1, 0x42, 0x5f, 0xa3, 0x36, 0xba, 0x4a, 0x5d, 0xb3, 0x90, 0x65, 0x71, 0x3a, 0x4b, 0xe8, 0xf8,
+	0x0d, 0xa3, 0x69, 0x22, 0xfd, 0x8e, 0x61, 0xa1, 0xb5, 0x3e, 0x41, 0xa3, 0x86, 0xd1, 0x77, 0x0b,
+	0xb0, 0xae, 0x81, 0xd1, 0x77, 0x55, 0xd8, 0x15, 0xa8, 0x2b, 0x9a, 0x45, 0x99, 0xf2, 0x37, 0xf1,
+	0x00, 0xac, 0xb6, 0xc4, 0xbd, 0xde, 0x32, 0xf7, 0xfa, 0xe0, 0x2a, 0x95, 0xfa, 0xfd, 0xa1, 0xb3,
+	0xe3, 0x86, 0x5a, 0xd4, 0xc9, 0xd0, 0x77, 0x39, 0x13, 0x34, 0xf1, 0xb7, 0xcc, 0x65, 0xb5, 0x6a,
+	0x41, 0xc4, 0x1b, 0x73, 0x22, 0x3e, 0x81, 0x4e, 0x18, 0x65, 0x13, 0x5a, 0x10, 0x90, 0x40, 0xed,
+	0x8d, 0xe0, 0x53, 0x5b, 0x66, 0x94, 0xc9, 0x26, 0x6c, 0x28, 0x6e, 0xeb, 0xbb, 0xa1, 0xb8, 0xc6,
+	0x48, 0x76, 0x4e, 0xb1, 0xb2, 0x5e, 0x88, 0x72, 0xf0, 0x13, 0xf8, 0xaf, 0xa9, 0xe0, 0x8f, 0x53,
+	0x16, 0x1f, 0xbf, 0xa4, 0x91, 0x88, 0x8f, 0x68, 0xd9, 0xb5, 0x6e, 0x82, 0x27, 0xf4, 0x1e, 0xb8,
+	0x68, 0x7b, 0xb4, 0x5d, 0xb0, 0xaf, 0xba, 0x71, 0x68, 0x20, 0xfa, 0x10, 0x4e, 0x59, 0x96, 0xf0,
+	0x53, 0xbb, 0x9f, 0xd5, 0x82, 0x6f, 0xc0, 0x43, 0xf8, 0x7f, 0x0e, 0xf0, 0x16, 0xd4, 0x1f, 0xcd,
+	0xe2, 0x63, 0x8a, 0x07, 0x76, 0x4c, 0xcf, 0xec, 0x02, 0x5a, 0xd4, 0x1c, 0x8e, 0xf9, 0x2c, 0x53,
+	0xb8, 0x84, 0x1b, 0x1a, 0x25, 0xf8, 0x19, 0xba, 0x2f, 0x78, 0x3e, 0x4b, 0x23, 0x61, 0x12, 0xba,
+	0xec, 0x87, 0x3a, 0x87, 0x58, 0x9f, 0x83, 0xc4, 0x00, 0xdc, 0xd0, 0x6a, 0x64, 0x17, 0x08, 0x4a,
+	0x63, 0x75, 0x24, 0xf8, 0x6c, 0x72, 0x34, 0x16, 0x91, 0x32, 0x17, 0xc1, 0x09, 0xfb, 0xe8, 0x79,
+	0x65, 0x1c, 0x61, 0xa4, 0x68, 0xf0, 0x8f, 0x03, 0xcd, 0xe7, 0x27, 0x54, 0x9c, 0x30, 0x7a, 0xaa,
+	0x59, 0x28, 0xed, 0xa9, 0xe2, 0xfe, 0x6e, 0x58, 0xea, 0x9a, 0x1f, 0x19, 0x1f, 0x0b, 0x2a, 0x67,
+	0xa9, 0x92, 0x36, 0x92, 0x56, 0xc6, 0x43, 0x63, 0x20, 0x9f, 0x42, 0x6f, 0xee, 0x36, 0x5b, 0xba,
+	0xb8, 0x65, 0xb7, 0xc4, 0xe8, 0xfd, 0x2a, 0x51, 0xd7, 0x2e, 0x11, 0xb5, 0xb7, 0x3e, 0x6a, 0x7d,
+	0x95, 0x63, 0x9e, 0x9d, 0x50, 0x61, 0xfa, 0x4d, 0x1d, 0x97, 0xaa, 0x9a, 0xc8, 0x67, 0xd0, 0x9b,
+	0xab, 0x66, 0xb1, 0x06, 0x2e, 0xb6, 0x39, 0x37, 0xe3, 0x01, 0x4c, 0xa1, 0xf1, 0x2c, 0x52, 0x34,
+	0x8b, 0xcf, 0xcc, 0x25, 0x8c, 0xb9, 0x48, 0xca, 0x87, 0xaf, 0xd4, 0x75, 0x55, 0xa2, 0x93, 0x09,
+	0xe6, 0xed, 0x84, 0x5a, 0xd4, 0x96, 0xfc, 0xee, 0x2d, 0x9b, 0xa5, 0x16, 0xd1, 0x72, 0xff, 0xae,
+	0x3d, 0x6a, 0x2d, 0x1a, 0xcb, 0x7d, 0x9b, 0x86, 0x16, 0x83, 0xdf, 0x36, 0xa0, 0xf5, 0x6d, 0x24,
+	0x8f, 0x0e, 0x79, 0x24, 0x12, 0xf2, 0xf1, 0x22, 0x67, 0xbb, 0x8b, 0x9c, 0x35, 0x3e, 0xb2, 0x0b,
+	0x4d, 0x6e, 0x2b, 0x84, 0xfb, 0xb7, 0x47, 0xfd, 0x02, 0x57, 0x54, 0x2e, 0x2c, 0x11, 0xe4, 0x21,
+	0xf4, 0x73, 0xc3, 0xa7, 0x71, 0x59, 0x4b, 0x17, 0xfb, 0xf1, 0xff, 0xcb, 0x7e, 0x5c, 0xe5, 0x5b,
+	0xd8, 0xcb, 0xab, 0x2a, 0x95, 0xe4, 0x6b, 0xf8, 0x5f, 0xa5, 0x94, 0xe5, 0x22, 0x35, 0x5c, 0x64,
+	0xb3, 0x58, 0xc4, 0xd0, 0x3c, 0xdc, 0x2a, 0xcb, 0x5b, 0x7e, 0x3f, 0x04, 0x77, 0x42, 0xb9, 0xef,
+	0xad, 0xc5, 0x6b, 0x17, 0xf9, 0x1c, 0x1a, 0xa9, 0x39, 0x73, 0x2c, 0x5d, 0x7b, 0xd4, 0x2b, 0x50,
+	0xb6, 0x14, 0x61, 0xe1, 0x0f, 0x7e, 0x71, 0xa0, 0xb7, 0x74, 0xe5, 0xd7, 0xdc, 0x90, 0x2a, 0x71,
+	0x37, 0x56, 0x89, 0x7b, 0x4e, 0x05, 0x1f, 0x23, 0x89, 0xec, 0x5d, 0x69, 0x9d, 0x17, 0x4b, 0x6a,
+	0xe2, 0xce, 0xdd, 0xd5, 0xbb, 0xd2, 0x2d, 0x31, 0xc8, 0x93, 0x3f, 0x1c, 0xd8, 0x5a, 0xe9, 0x3d,
+	0x97, 0x2b, 0xe0, 0x05, 0xdd, 0x66, 0x21, 0x6a, 0xf7, 0xbd, 0x51, 0xd7, 0x2e, 0x11, 0xb5, 0xb7,
+	0x26, 0x6a, 0x72, 0x1b, 0x1a, 0x6f, 0x67, 0xb4, 0x7c, 0xce, 0xda, 0xa3, 0xab, 0x45, 0x84, 0x4b,
+	0xb9, 0x84, 0x05, 0x2e, 0xb8, 0x09, 0xe4, 0x55, 0xc4, 0xd2, 0x10, 0x99, 0x5f, 0x76, 0xd7, 0x6d,
+	0xf0, 0x24, 0xcb, 0x62, 0x6a, 0xfb, 0x82, 0x51, 0x82, 0xbf, 0x1c, 0xa8, 0x1b, 0xa0, 0xee, 0x8e,
+	0x2c, 0xb1, 0x35, 0xd9, 0x60, 0x89, 0x4e, 0x7a, 0xca, 0xd2, 0x94, 0x15, 0x05, 0xb1, 0x1a, 0xf9,
+	0x08, 0x3a, 0x26, 0xc9, 0xb1, 0xde, 0xf0, 0xcc, 0x3e, 0xdc, 0x6d, 0x63, 0xfb, 0x41, 0x9b, 0x74,
+	0xee, 0x8a, 0xab, 0x28, 0x1d, 0x1f, 0x31, 0x55, 0xf4, 0x89, 0x16, 0x5a, 0xbe, 0x63, 0x4a, 0x62,
+	0x3b, 0xc4, 0x53, 0xf1, 0xf0, 0x8e, 0x1a, 0x05, 0xdf, 0xee, 0xf2, 0x66, 0x23, 0xad, 0x9a, 0x61,
+	0xc5, 0x82, 0xb3, 0x82, 0xee, 0x9b, 0xe2, 0xcc, 0x6f, 0xd8, 0x59, 0xc1, 0xa8, 0xba, 0x8f, 0x2b,
+	0xce, 0x8f, 0xfd, 0x26, 0x6e, 0x84, 0xb2, 0x8e, 0xde, 0x3c, 0xcd, 0x76, 0x9c, 0xb2, 0xda, 0xe8,
+	0x1c, 0x3c, 0x1c, 0x7e, 0xc9, 0x3e, 0x34, 0xec, 0x94, 0x4d, 0xae, 0x14, 0x47, 0xba, 0x38, 0x76,
+	0x0f, 0x16, 0x06, 0x61, 0xf2, 0x10, 0x5a, 0xe5, 0xe8, 0x4c, 0xfc, 0x92, 0xef, 0x4b, 0xb3, 0xf7,
+	0xe0, 0xda, 0x1a, 0x8f, 0x19, 0xb2, 0x46, 0xbf, 0x3a, 0xd0, 0xae, 0x0c, 0x5f, 0xe4, 0x00, 0xba,
+	0x0b, 0xd3, 0x1d, 0xf9, 0xa0, 0x12, 0xc8, 0xca, 0xd0, 0x37, 0x58, 0x33, 0x8e, 0x91, 0x10, 0x7a,
+	0x4b, 0x23, 0x1d, 0xb9, 0x5e, 0x0d, 0x60, 0x75, 0x0a, 0x1c, 0xdc, 0xb8, 0xd0, 0x6f, 0xc3, 0xfc,
+	0xdd, 0x81, 0xd6, 0x41, 0x16, 0xa5, 0x67, 0x8a, 0xc5, 0x92, 0xdc, 0x83, 0xce, 0x53, 0xaa, 0xe6,
+	0x1d, 0x6f, 0xed, 0xb3, 0x3c, 0xd8, 0x2a, 0xac, 0x73, 0xe0, 0x4b, 0x9c, 0x5d, 0x57, 0x6f, 0xdc,
+	0xf0, 0x02, 0x02, 0xd3, 0xd5, 0x23, 0x5c, 0xfd, 0xf8, 0x01, 0xb4, 0x2b, 0xdc, 0x26, 0x83, 0x02,
+	0xb9, 0x4a, 0xf8, 0x41, 0xd9, 0xb8, 0x8c, 0xfd, 0x96, 0xf3, 0xe8, 0xab, 0xd7, 0x77, 0x26, 0x4c,
+	0x1d, 0xcd, 0x0e, 0xf7, 0x62, 0x3e, 0xdd, 0x8f, 0xf2, 0xfc, 0x30, 0x92, 0x94, 0xf1, 0xfd, 0x48,
+	0xc4, 0xfb, 0x79, 0x3a, 0x9b, 0xb0, 0x4c, 0xee, 0x4f, 0x44, 0x1e, 0x47, 0x39, 0xd3, 0xb6, 0xfc,
+	0xf0, 0x01, 0xfe, 0x1e, 0xd6, 0xf1, 0xff, 0xd9, 0x97, 0xff, 0x0e, 0x00, 0xec, 0x9a, 0x1f, 0xc7,
+	0xac, 0x0d, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// UsersClient is the client API for Users service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type UsersClient interface {
+	// GetUser returns the user with the username, which requires an admin
+	// user, or the authenticated user if the username is empty.
+	// GET /_user/{username} or GET /_user
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error)
+	// ListUsers returns all the users, or the soft deleted ones.
+	// GET /_users
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
+}
+
+type usersClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewUsersClient(cc grpc.ClientConnInterface) UsersClient {
+	return &usersClient{cc}
+}
+
+func (c *usersClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/arc.v1.Users/GetUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *usersClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error) {
+	out := new(ListUsersResponse)
+	err := c.cc.Invoke(ctx, "/arc.v1.Users/ListUsers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UsersServer is the server API for Users service.
+type UsersServer interface {
+	// GetUser returns the user with the username, which requires an admin
+	// user, or the authenticated user if the username is empty.
+	// GET /_user/{username} or GET /_user
+	GetUser(context.Context, *GetUserRequest) (*User, error)
+	// ListUsers returns all the users, or the soft deleted ones.
+	// GET /_users
+	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+}
+
+// UnimplementedUsersServer can be embedded to have forward compatible implementations.
+type UnimplementedUsersServer struct {
+}
+
+func (*UnimplementedUsersServer) GetUser(ctx context.Context, req *GetUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (*UnimplementedUsersServer) ListUsers(ctx context.Context, req *ListUsersRequest) (*ListUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+
+func RegisterUsersServer(s *grpc.Server, srv UsersServer) {
+	s.RegisterService(&_Users_serviceDesc, srv)
+}
+
+func _Users_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/arc.v1.Users/GetUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Users_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UsersServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/arc.v1.Users/ListUsers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UsersServer).ListUsers(ctx, req.(*ListUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Users_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "arc.v1.Users",
+	HandlerType: (*UsersServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUser",
+			Handler:    _Users_GetUser_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _Users_ListUsers_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "arc.proto",
+}
+
+// PermissionsClient is the client API for Permissions service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type PermissionsClient interface {
+	// GetPermission returns the permission with the username.
+	// GET /_permission/{username}
+	GetPermission(ctx context.Context, in *GetPermissionRequest, opts ...grpc.CallOption) (*Permission, error)
+	// ListPermissions returns the permissions owned by the authenticated user.
+	// GET /_permissions
+	ListPermissions(ctx context.Context, in *ListPermissionsRequest, opts ...grpc.CallOption) (*ListPermissionsResponse, error)
+}
+
+type permissionsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPermissionsClient(cc grpc.ClientConnInterface) PermissionsClient {
+	return &permissionsClient{cc}
+}
+
+func (c *permissionsClient) GetPermission(ctx context.Context, in *GetPermissionRequest, opts ...grpc.CallOption) (*Permission, error) {
+	out := new(Permission)
+	err := c.cc.Invoke(ctx, "/arc.v1.Permissions/GetPermission", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *permissionsClient) ListPermissions(ctx context.Context, in *ListPermissionsRequest, opts ...grpc.CallOption) (*ListPermissionsResponse, error) {
+	out := new(ListPermissionsResponse)
+	err := c.cc.Invoke(ctx, "/arc.v1.Permissions/ListPermissions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PermissionsServer is the server API for Permissions service.
+type PermissionsServer interface {
+	// GetPermission returns the permission with the username.
+	// GET /_permission/{username}
+	GetPermission(context.Context, *GetPermissionRequest) (*Permission, error)
+	// ListPermissions returns the permissions owned by the authenticated user.
+	// GET /_permissions
+	ListPermissions(context.Context, *ListPermissionsRequest) (*ListPermissionsResponse, error)
+}
+
+// UnimplementedPermissionsServer can be embedded to have forward compatible implementations.
+type UnimplementedPermissionsServer struct {
+}
+
+func (*UnimplementedPermissionsServer) GetPermission(ctx context.Context, req *GetPermissionRequest) (*Permission, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPermission not implemented")
+}
+func (*UnimplementedPermissionsServer) ListPermissions(ctx context.Context, req *ListPermissionsRequest) (*ListPermissionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPermissions not implemented")
+}
+
+func RegisterPermissionsServer(s *grpc.Server, srv PermissionsServer) {
+	s.RegisterService(&_Permissions_serviceDesc, srv)
+}
+
+func _Permissions_GetPermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPermissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionsServer).GetPermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/arc.v1.Permissions/GetPermission",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionsServer).GetPermission(ctx, req.(*GetPermissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Permissions_ListPermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPermissionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionsServer).ListPermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/arc.v1.Permissions/ListPermissions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionsServer).ListPermissions(ctx, req.(*ListPermissionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Permissions_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "arc.v1.Permissions",
+	HandlerType: (*PermissionsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetPermission",
+			Handler:    _Permissions_GetPermission_Handler,
+		},
+		{
+			MethodName: "ListPermissions",
+			Handler:    _Permissions_ListPermissions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "arc.proto",
+}
+
+// AnalyticsClient is the client API for Analytics service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type AnalyticsClient interface {
+	// GetDashboard returns the panels of the analytics dashboard.
+	// GET /_analytics/dashboard
+	GetDashboard(ctx context.Context, in *RangeRequest, opts ...grpc.CallOption) (*Dashboard, error)
+	// GetZeroClickSearches returns the queries whose searches got results but
+	// no click within the session window.
+	// GET /_analytics/zero_click_searches
+	GetZeroClickSearches(ctx context.Context, in *ZeroClickSearchesRequest, opts ...grpc.CallOption) (*ZeroClickSearches, error)
+	// TailRecords streams the analytics records as they are recorded, starting
+	// from the ones recorded since the given time.
+	// GET /_analytics/records, polled
+	TailRecords(ctx context.Context, in *TailRecordsRequest, opts ...grpc.CallOption) (Analytics_TailRecordsClient, error)
+}
+
+type analyticsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAnalyticsClient(cc grpc.ClientConnInterface) AnalyticsClient {
+	return &analyticsClient{cc}
+}
+
+func (c *analyticsClient) GetDashboard(ctx context.Context, in *RangeRequest, opts ...grpc.CallOption) (*Dashboard, error) {
+	out := new(Dashboard)
+	err := c.cc.Invoke(ctx, "/arc.v1.Analytics/GetDashboard", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsClient) GetZeroClickSearches(ctx context.Context, in *ZeroClickSearchesRequest, opts ...grpc.CallOption) (*ZeroClickSearches, error) {
+	out := new(ZeroClickSearches)
+	err := c.cc.Invoke(ctx, "/arc.v1.Analytics/GetZeroClickSearches", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *analyticsClient) TailRecords(ctx context.Context, in *TailRecordsRequest, opts ...grpc.CallOption) (Analytics_TailRecordsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Analytics_serviceDesc.Streams[0], "/arc.v1.Analytics/TailRecords", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &analyticsTailRecordsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Analytics_TailRecordsClient interface {
+	Recv() (*Record, error)
+	grpc.ClientStream
+}
+
+type analyticsTailRecordsClient struct {
+	grpc.ClientStream
+}
+
+func (x *analyticsTailRecordsClient) Recv() (*Record, error) {
+	m := new(Record)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AnalyticsServer is the server API for Analytics service.
+type AnalyticsServer interface {
+	// GetDashboard returns the panels of the analytics dashboard.
+	// GET /_analytics/dashboard
+	GetDashboard(context.Context, *RangeRequest) (*Dashboard, error)
+	// GetZeroClickSearches returns the queries whose searches got results but
+	// no click within the session window.
+	// GET /_analytics/zero_click_searches
+	GetZeroClickSearches(context.Context, *ZeroClickSearchesRequest) (*ZeroClickSearches, error)
+	// TailRecords streams the analytics records as they are recorded, starting
+	// from the ones recorded since the given time.
+	// GET /_analytics/records, polled
+	TailRecords(*TailRecordsRequest, Analytics_TailRecordsServer) error
+}
+
+// UnimplementedAnalyticsServer can be embedded to have forward compatible implementations.
+type UnimplementedAnalyticsServer struct {
+}
+
+func (*UnimplementedAnalyticsServer) GetDashboard(ctx context.Context, req *RangeRequest) (*Dashboard, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDashboard not implemented")
+}
+func (*UnimplementedAnalyticsServer) GetZeroClickSearches(ctx context.Context, req *ZeroClickSearchesRequest) (*ZeroClickSearches, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetZeroClickSearches not implemented")
+}
+func (*UnimplementedAnalyticsServer) TailRecords(req *TailRecordsRequest, srv Analytics_TailRecordsServer) error {
+	return status.Errorf(codes.Unimplemented, "method TailRecords not implemented")
+}
+
+func RegisterAnalyticsServer(s *grpc.Server, srv AnalyticsServer) {
+	s.RegisterService(&_Analytics_serviceDesc, srv)
+}
+
+func _Analytics_GetDashboard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RangeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServer).GetDashboard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/arc.v1.Analytics/GetDashboard",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServer).GetDashboard(ctx, req.(*RangeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Analytics_GetZeroClickSearches_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ZeroClickSearchesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AnalyticsServer).GetZeroClickSearches(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/arc.v1.Analytics/GetZeroClickSearches",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AnalyticsServer).GetZeroClickSearches(ctx, req.(*ZeroClickSearchesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Analytics_TailRecords_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(TailRecordsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AnalyticsServer).TailRecords(m, &analyticsTailRecordsServer{stream})
+}
+
+type Analytics_TailRecordsServer interface {
+	Send(*Record) error
+	grpc.ServerStream
+}
+
+type analyticsTailRecordsServer struct {
+	grpc.ServerStream
+}
+
+func (x *analyticsTailRecordsServer) Send(m *Record) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Analytics_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "arc.v1.Analytics",
+	HandlerType: (*AnalyticsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDashboard",
+			Handler:    _Analytics_GetDashboard_Handler,
+		},
+		{
+			MethodName: "GetZeroClickSearches",
+			Handler:    _Analytics_GetZeroClickSearches_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "TailRecords",
+			Handler:       _Analytics_TailRecords_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "arc.proto",
+}
@@ -0,0 +1,9 @@
+// Package arcpb holds the protobuf definitions of the management apis of
+// arc served over grpc, along with the generated clients and servers.
+//
+// The code is generated by protoc-gen-go v1.3.5, the version of
+// github.com/golang/protobuf required by go.mod, which must be bumped along.
+package arcpb
+
+//go:generate go install github.com/golang/protobuf/protoc-gen-go@v1.3.5
+//go:generate protoc --go_out=plugins=grpc,paths=source_relative:. arc.proto
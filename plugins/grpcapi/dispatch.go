@@ -0,0 +1,128 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util"
+)
+
+// handler returns the handler the calls are dispatched through, the one of
+// the http requests, so that the calls go through the same middleware.
+var handler = plugins.ServedHandler
+
+// call dispatches the call as the http request made to the path, the
+// metadata of the call being the headers of the request, and decodes the
+// json response into out. The error responses are converted to the status
+// of the equivalent code.
+func call(ctx context.Context, method, path string, query url.Values, out interface{}) error {
+	h := handler()
+	if h == nil {
+		return status.Error(codes.Unavailable, "arc isn't ready to serve requests yet")
+	}
+
+	target := plugins.APIVersion + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, nil)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	req = req.WithContext(ctx)
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for key, values := range md {
+			// the pseudo headers and the ones of the transport aren't forwarded
+			if strings.HasPrefix(key, ":") || strings.HasPrefix(key, "grpc-") || key == "content-type" || key == "te" {
+				continue
+			}
+			req.Header[http.CanonicalHeaderKey(key)] = values
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		req.RemoteAddr = p.Addr.String()
+	}
+
+	w := &bufferedWriter{header: make(http.Header), code: http.StatusOK}
+	h.ServeHTTP(w, req)
+	if w.code >= http.StatusBadRequest {
+		return statusError(w.code, w.body.Bytes())
+	}
+	if err := json.Unmarshal(w.body.Bytes(), out); err != nil {
+		return decodeError(err)
+	}
+	return nil
+}
+
+// decodeError reports the response that couldn't be decoded.
+func decodeError(err error) error {
+	log.Errorln(logTag, ": unable to decode the response:", err)
+	return status.Error(codes.Internal, "unable to decode the response")
+}
+
+// statusError converts the error response to the status of the equivalent
+// code, with the message of the error envelope.
+func statusError(code int, body []byte) error {
+	msg := http.StatusText(code)
+	var envelope util.ErrorEnvelope
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Message != "" {
+		msg = envelope.Error.Message
+	}
+	return status.Error(grpcCode(code), msg)
+}
+
+func grpcCode(code int) codes.Code {
+	switch code {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+// bufferedWriter retains the response written by the handler.
+type bufferedWriter struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (b *bufferedWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedWriter) WriteHeader(code int) {
+	b.code = code
+}
+
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
@@ -0,0 +1,62 @@
+package grpcapi
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/appbaseio/arc/util"
+)
+
+func TestCall(t *testing.T) {
+	defer func(h func() http.Handler) { handler = h }(handler)
+
+	Convey("The call is dispatched as an http request", t, func() {
+		var got *http.Request
+		handler = func() http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				got = req
+				w.Write([]byte(`{"username": "foo"}`))
+			})
+		}
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+			"authorization", "Basic Zm9vOmJhcg==",
+			"content-type", "application/grpc",
+			"grpc-timeout", "1S",
+		))
+		var out struct {
+			Username string `json:"username"`
+		}
+		So(call(ctx, http.MethodGet, "/_users", url.Values{"deleted": {"true"}}, &out), ShouldBeNil)
+		So(out.Username, ShouldEqual, "foo")
+		So(got.URL.Path, ShouldEqual, "/v1/_users")
+		So(got.URL.Query().Get("deleted"), ShouldEqual, "true")
+		So(got.Header.Get("Authorization"), ShouldEqual, "Basic Zm9vOmJhcg==")
+		So(got.Header.Get("Content-Type"), ShouldBeEmpty)
+		So(got.Header.Get("Grpc-Timeout"), ShouldBeEmpty)
+	})
+
+	Convey("The error responses are converted to statuses", t, func() {
+		handler = func() http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				util.WriteBackError(w, "credentials cannot access the api", http.StatusForbidden)
+			})
+		}
+		err := call(context.Background(), http.MethodGet, "/_users", nil, &struct{}{})
+		s, _ := status.FromError(err)
+		So(s.Code(), ShouldEqual, codes.PermissionDenied)
+		So(s.Message(), ShouldEqual, "credentials cannot access the api")
+	})
+
+	Convey("The calls are unavailable until the router is ready", t, func() {
+		handler = func() http.Handler { return nil }
+		err := call(context.Background(), http.MethodGet, "/_users", nil, &struct{}{})
+		So(status.Code(err), ShouldEqual, codes.Unavailable)
+	})
+}
@@ -0,0 +1,107 @@
+package grpcapi
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/plugins/grpcapi/arcpb"
+)
+
+const (
+	logTag              = "[grpcapi]"
+	envAddress          = "GRPC_ADDRESS"
+	envTLSCert          = "GRPC_TLS_CERT"
+	envTLSKey           = "GRPC_TLS_KEY"
+	envTailInterval     = "GRPC_TAIL_INTERVAL"
+	defaultTailInterval = time.Second
+)
+
+var (
+	singleton *grpcAPI
+	once      sync.Once
+)
+
+// grpcAPI serves the users, permissions and analytics read apis over grpc.
+// The calls are dispatched as the equivalent http requests through the
+// handler of the http requests, hence are authenticated, authorized and
+// served exactly as the http requests are.
+type grpcAPI struct {
+	server *grpc.Server
+	// tailInterval is the interval at which the tailed analytics records
+	// are polled.
+	tailInterval time.Duration
+}
+
+// Use only this function to fetch the instance of grpcAPI from within this
+// package to avoid creating stateless duplicates of the plugin.
+func Instance() *grpcAPI {
+	once.Do(func() {
+		singleton = &grpcAPI{tailInterval: defaultTailInterval}
+	})
+	return singleton
+}
+
+func (g *grpcAPI) Name() string {
+	return logTag
+}
+
+func (g *grpcAPI) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	address := os.Getenv(envAddress)
+	if address == "" {
+		log.Println(logTag, ":", envAddress, "isn't set, the grpc apis are disabled")
+		return nil
+	}
+	if value := os.Getenv(envTailInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a positive duration", envTailInterval, value)
+		}
+		g.tailInterval = d
+	}
+
+	var opts []grpc.ServerOption
+	cert, key := os.Getenv(envTLSCert), os.Getenv(envTLSKey)
+	if cert != "" || key != "" {
+		creds, err := credentials.NewServerTLSFromFile(cert, key)
+		if err != nil {
+			return fmt.Errorf("%s: unable to load the tls certificate: %v", logTag, err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("%s: unable to listen on %s: %v", logTag, address, err)
+	}
+
+	g.server = grpc.NewServer(opts...)
+	arcpb.RegisterUsersServer(g.server, &usersServer{})
+	arcpb.RegisterPermissionsServer(g.server, &permissionsServer{})
+	arcpb.RegisterAnalyticsServer(g.server, &analyticsServer{tailInterval: g.tailInterval})
+	go func() {
+		log.Println(logTag, ": listening on", address)
+		if err := g.server.Serve(listener); err != nil {
+			log.Errorln(logTag, ": the grpc server stopped:", err)
+		}
+	}()
+	return nil
+}
+
+func (g *grpcAPI) Routes() []plugins.Route {
+	return []plugins.Route{}
+}
+
+// Default empty middleware array function
+func (g *grpcAPI) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
@@ -0,0 +1,84 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/appbaseio/arc/plugins/grpcapi/arcpb"
+)
+
+type permissionsServer struct{}
+
+// permission is the permission as returned by the http api.
+type permission struct {
+	Username    string   `json:"username"`
+	Owner       string   `json:"owner"`
+	Creator     string   `json:"creator"`
+	Role        string   `json:"role"`
+	Description string   `json:"description"`
+	Categories  []string `json:"categories"`
+	ACLs        []string `json:"acls"`
+	Ops         []string `json:"ops"`
+	Indices     []string `json:"indices"`
+	Sources     []string `json:"sources"`
+	Referers    []string `json:"referers"`
+	Includes    []string `json:"include_fields"`
+	Excludes    []string `json:"exclude_fields"`
+	Tenant      string   `json:"tenant"`
+	CreatedAt   string   `json:"created_at"`
+	TTL         int64    `json:"ttl"`
+	Expired     bool     `json:"expired"`
+}
+
+func (s *permissionsServer) GetPermission(ctx context.Context, req *arcpb.GetPermissionRequest) (*arcpb.Permission, error) {
+	var raw json.RawMessage
+	if err := call(ctx, http.MethodGet, "/_permission/"+url.PathEscape(req.Username), nil, &raw); err != nil {
+		return nil, err
+	}
+	return permissionMessage(raw)
+}
+
+func (s *permissionsServer) ListPermissions(ctx context.Context, req *arcpb.ListPermissionsRequest) (*arcpb.ListPermissionsResponse, error) {
+	var raws []json.RawMessage
+	if err := call(ctx, http.MethodGet, "/_permissions", nil, &raws); err != nil {
+		return nil, err
+	}
+	response := &arcpb.ListPermissionsResponse{}
+	for _, raw := range raws {
+		p, err := permissionMessage(raw)
+		if err != nil {
+			return nil, err
+		}
+		response.Permissions = append(response.Permissions, p)
+	}
+	return response, nil
+}
+
+func permissionMessage(raw json.RawMessage) (*arcpb.Permission, error) {
+	var p permission
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, decodeError(err)
+	}
+	return &arcpb.Permission{
+		Username:      p.Username,
+		Owner:         p.Owner,
+		Creator:       p.Creator,
+		Role:          p.Role,
+		Description:   p.Description,
+		Categories:    p.Categories,
+		Acls:          p.ACLs,
+		Ops:           p.Ops,
+		Indices:       p.Indices,
+		Sources:       p.Sources,
+		Referers:      p.Referers,
+		IncludeFields: p.Includes,
+		ExcludeFields: p.Excludes,
+		Tenant:        p.Tenant,
+		CreatedAt:     p.CreatedAt,
+		Ttl:           p.TTL,
+		Expired:       p.Expired,
+		Raw:           raw,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/appbaseio/arc/plugins/grpcapi/arcpb"
+)
+
+type usersServer struct{}
+
+// user is the user as returned by the http api.
+type user struct {
+	Username         string   `json:"username"`
+	IsAdmin          *bool    `json:"is_admin"`
+	Email            string   `json:"email"`
+	Categories       []string `json:"categories"`
+	ACLs             []string `json:"acls"`
+	Ops              []string `json:"ops"`
+	Indices          []string `json:"indices"`
+	DeniedIndices    []string `json:"denied_indices"`
+	DeniedCategories []string `json:"denied_categories"`
+	Disabled         bool     `json:"disabled"`
+	CreatedAt        string   `json:"created_at"`
+	DeletedAt        string   `json:"deleted_at"`
+}
+
+func (s *usersServer) GetUser(ctx context.Context, req *arcpb.GetUserRequest) (*arcpb.User, error) {
+	path := "/_user"
+	if req.Username != "" {
+		path += "/" + url.PathEscape(req.Username)
+	}
+	var raw json.RawMessage
+	if err := call(ctx, http.MethodGet, path, nil, &raw); err != nil {
+		return nil, err
+	}
+	return userMessage(raw)
+}
+
+func (s *usersServer) ListUsers(ctx context.Context, req *arcpb.ListUsersRequest) (*arcpb.ListUsersResponse, error) {
+	query := url.Values{"deleted": {strconv.FormatBool(req.Deleted)}}
+	var raws []json.RawMessage
+	if err := call(ctx, http.MethodGet, "/_users", query, &raws); err != nil {
+		return nil, err
+	}
+	response := &arcpb.ListUsersResponse{}
+	for _, raw := range raws {
+		u, err := userMessage(raw)
+		if err != nil {
+			return nil, err
+		}
+		response.Users = append(response.Users, u)
+	}
+	return response, nil
+}
+
+func userMessage(raw json.RawMessage) (*arcpb.User, error) {
+	var u user
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return nil, decodeError(err)
+	}
+	return &arcpb.User{
+		Username:         u.Username,
+		IsAdmin:          u.IsAdmin != nil && *u.IsAdmin,
+		Email:            u.Email,
+		Categories:       u.Categories,
+		Acls:             u.ACLs,
+		Ops:              u.Ops,
+		Indices:          u.Indices,
+		DeniedIndices:    u.DeniedIndices,
+		DeniedCategories: u.DeniedCategories,
+		Disabled:         u.Disabled,
+		CreatedAt:        u.CreatedAt,
+		DeletedAt:        u.DeletedAt,
+		Raw:              raw,
+	}, nil
+}
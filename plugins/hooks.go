@@ -0,0 +1,119 @@
+package plugins
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Response is the upstream response passed through the response hooks. The
+// hooks may modify it in place, the result is written back to the client.
+type Response struct {
+	Code   int
+	Header http.Header
+	Body   []byte
+}
+
+// ResponseHook post-processes the response served for the request. A failing
+// hook must leave the response untouched, the error is logged and the rest of
+// the pipeline is run regardless.
+type ResponseHook func(req *http.Request, resp *Response) error
+
+// HookStats reports the usage and timing of a response hook.
+type HookStats struct {
+	Name          string        `json:"name"`
+	Order         int           `json:"order"`
+	Calls         int64         `json:"calls"`
+	Errors        int64         `json:"errors"`
+	TotalDuration time.Duration `json:"total_duration_ns"`
+	LastDuration  time.Duration `json:"last_duration_ns"`
+}
+
+type registeredHook struct {
+	hook  ResponseHook
+	seq   int
+	stats HookStats
+}
+
+var (
+	hooksMu sync.RWMutex
+	hooks   []*registeredHook
+)
+
+// RegisterResponseHook adds the hook to the pipeline that post-processes the
+// search responses. The hooks are run in the ascending order, the hooks with
+// the same order run in the order of registration. Registering a hook with an
+// existing name replaces it.
+func RegisterResponseHook(name string, order int, hook ResponseHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	seq := len(hooks)
+	for i, h := range hooks {
+		if h.stats.Name == name {
+			seq = h.seq
+			hooks = append(hooks[:i], hooks[i+1:]...)
+			break
+		}
+	}
+	hooks = append(hooks, &registeredHook{
+		hook:  hook,
+		seq:   seq,
+		stats: HookStats{Name: name, Order: order},
+	})
+	sort.SliceStable(hooks, func(i, j int) bool {
+		if hooks[i].stats.Order == hooks[j].stats.Order {
+			return hooks[i].seq < hooks[j].seq
+		}
+		return hooks[i].stats.Order < hooks[j].stats.Order
+	})
+}
+
+// HasResponseHooks reports whether any response hook is registered, in which
+// case the response must be buffered to be post-processed.
+func HasResponseHooks() bool {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	return len(hooks) > 0
+}
+
+// RunResponseHooks passes the response through the registered hooks in order.
+func RunResponseHooks(req *http.Request, resp *Response) {
+	hooksMu.RLock()
+	pipeline := make([]*registeredHook, len(hooks))
+	copy(pipeline, hooks)
+	hooksMu.RUnlock()
+
+	for _, h := range pipeline {
+		start := time.Now()
+		err := h.hook(req, resp)
+		took := time.Since(start)
+
+		hooksMu.Lock()
+		h.stats.Calls++
+		h.stats.TotalDuration += took
+		h.stats.LastDuration = took
+		if err != nil {
+			h.stats.Errors++
+		}
+		hooksMu.Unlock()
+
+		if err != nil {
+			log.Errorln(logTag, ": response hook", h.stats.Name, "failed:", err)
+		}
+	}
+}
+
+// ResponseHookStats returns the stats of the registered hooks in the order they are run.
+func ResponseHookStats() []HookStats {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+	stats := make([]HookStats, len(hooks))
+	for i, h := range hooks {
+		stats[i] = h.stats
+	}
+	return stats
+}
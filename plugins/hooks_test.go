@@ -0,0 +1,49 @@
+package plugins
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResponseHooks(t *testing.T) {
+	Convey("Hooks are run in order", t, func() {
+		hooks = nil
+		var calls []string
+		hook := func(name string, err error) ResponseHook {
+			return func(req *http.Request, resp *Response) error {
+				calls = append(calls, name)
+				if err == nil {
+					resp.Body = append(resp.Body, name...)
+				}
+				return err
+			}
+		}
+		RegisterResponseHook("late", 10, hook("late", nil))
+		RegisterResponseHook("first", 0, hook("first", nil))
+		RegisterResponseHook("failing", 0, hook("failing", errors.New("boom")))
+		RegisterResponseHook("second", 0, hook("second", nil))
+		So(HasResponseHooks(), ShouldBeTrue)
+
+		resp := &Response{Code: http.StatusOK, Header: http.Header{}}
+		RunResponseHooks(httptest.NewRequest(http.MethodGet, "/_search", nil), resp)
+		So(calls, ShouldResemble, []string{"first", "failing", "second", "late"})
+		So(string(resp.Body), ShouldEqual, "firstsecondlate")
+
+		stats := ResponseHookStats()
+		So(len(stats), ShouldEqual, 4)
+		So(stats[1].Name, ShouldEqual, "failing")
+		So(stats[1].Calls, ShouldEqual, 1)
+		So(stats[1].Errors, ShouldEqual, 1)
+
+		Convey("Registering an existing name replaces the hook in place", func() {
+			calls = nil
+			RegisterResponseHook("first", 0, hook("replaced", nil))
+			RunResponseHooks(httptest.NewRequest(http.MethodGet, "/_search", nil), resp)
+			So(calls, ShouldResemble, []string{"replaced", "failing", "second", "late"})
+		})
+	})
+}
@@ -0,0 +1,81 @@
+package indices
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// elasticsearch forwards the index management requests to the upstream
+// cluster. The index apis share the same shape across es6 and es7, hence
+// the raw requests are performed with the es7 client for both versions.
+type elasticsearch struct{}
+
+func (es *elasticsearch) perform(ctx context.Context, method, path string, params url.Values, body []byte) ([]byte, error) {
+	options := es7.PerformRequestOptions{
+		Method: method,
+		Path:   path,
+		Params: params,
+	}
+	if body != nil {
+		options.Body = string(body)
+	}
+	response, err := util.GetClient7().PerformRequest(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func (es *elasticsearch) getIndices(ctx context.Context) ([]byte, error) {
+	params := url.Values{}
+	params.Set("format", "json")
+	return es.perform(ctx, http.MethodGet, "/_cat/indices", params, nil)
+}
+
+func (es *elasticsearch) getIndex(ctx context.Context, name string) ([]byte, error) {
+	return es.perform(ctx, http.MethodGet, "/"+url.PathEscape(name), nil, nil)
+}
+
+func (es *elasticsearch) createIndex(ctx context.Context, name string, body []byte) ([]byte, error) {
+	return es.perform(ctx, http.MethodPut, "/"+url.PathEscape(name), nil, body)
+}
+
+func (es *elasticsearch) deleteIndex(ctx context.Context, name string) ([]byte, error) {
+	return es.perform(ctx, http.MethodDelete, "/"+url.PathEscape(name), nil, nil)
+}
+
+func (es *elasticsearch) getSettings(ctx context.Context, name string) ([]byte, error) {
+	return es.perform(ctx, http.MethodGet, "/"+url.PathEscape(name)+"/_settings", nil, nil)
+}
+
+func (es *elasticsearch) putSettings(ctx context.Context, name string, body []byte) ([]byte, error) {
+	return es.perform(ctx, http.MethodPut, "/"+url.PathEscape(name)+"/_settings", nil, body)
+}
+
+func (es *elasticsearch) getMapping(ctx context.Context, name string) ([]byte, error) {
+	return es.perform(ctx, http.MethodGet, "/"+url.PathEscape(name)+"/_mapping", nil, nil)
+}
+
+func (es *elasticsearch) putMapping(ctx context.Context, name string, body []byte) ([]byte, error) {
+	path := "/" + url.PathEscape(name) + "/_mapping"
+	if !util.IsTypeless() {
+		path += "/" + util.DocType()
+	}
+	return es.perform(ctx, http.MethodPut, path, nil, body)
+}
+
+func (es *elasticsearch) getAliases(ctx context.Context, name string) ([]byte, error) {
+	return es.perform(ctx, http.MethodGet, "/"+url.PathEscape(name)+"/_alias", nil, nil)
+}
+
+func (es *elasticsearch) putAlias(ctx context.Context, name, alias string, body []byte) ([]byte, error) {
+	return es.perform(ctx, http.MethodPut, "/"+url.PathEscape(name)+"/_alias/"+url.PathEscape(alias), nil, body)
+}
+
+func (es *elasticsearch) deleteAlias(ctx context.Context, name, alias string) ([]byte, error) {
+	return es.perform(ctx, http.MethodDelete, "/"+url.PathEscape(name)+"/_alias/"+url.PathEscape(alias), nil, nil)
+}
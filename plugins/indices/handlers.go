@@ -0,0 +1,156 @@
+package indices
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/gorilla/mux"
+)
+
+func (i *indices) getIndices() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := i.es.getIndices(req.Context())
+		if err != nil {
+			msg := "an error occurred while fetching the indices"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (i *indices) getIndex() http.HandlerFunc {
+	return i.read(i.es.getIndex, "index")
+}
+
+func (i *indices) getSettings() http.HandlerFunc {
+	return i.read(i.es.getSettings, "settings")
+}
+
+func (i *indices) getMapping() http.HandlerFunc {
+	return i.read(i.es.getMapping, "mapping")
+}
+
+func (i *indices) getAliases() http.HandlerFunc {
+	return i.read(i.es.getAliases, "aliases")
+}
+
+func (i *indices) createIndex() http.HandlerFunc {
+	return i.write(i.es.createIndex, "creating", true)
+}
+
+func (i *indices) putSettings() http.HandlerFunc {
+	return i.write(i.es.putSettings, "updating the settings of", false)
+}
+
+func (i *indices) putMapping() http.HandlerFunc {
+	return i.write(i.es.putMapping, "updating the mapping of", false)
+}
+
+func (i *indices) deleteIndex() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["index"]
+		raw, err := i.es.deleteIndex(req.Context(), name)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while deleting index "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (i *indices) putAlias() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		name, alias := vars["index"], vars["alias"]
+
+		body, ok := readBody(w, req, true)
+		if !ok {
+			return
+		}
+
+		raw, err := i.es.putAlias(req.Context(), name, alias, body)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while adding alias "%s" to index "%s"`, alias, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (i *indices) deleteAlias() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		name, alias := vars["index"], vars["alias"]
+
+		raw, err := i.es.deleteAlias(req.Context(), name, alias)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while removing alias "%s" from index "%s"`, alias, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// read returns a handler that fetches the given resource of the {index}.
+func (i *indices) read(fetch func(context.Context, string) ([]byte, error), resource string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["index"]
+		raw, err := fetch(req.Context(), name)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching %s of index "%s"`, resource, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// write returns a handler that forwards the request body to the given update of the {index}.
+func (i *indices) write(update func(context.Context, string, []byte) ([]byte, error), action string, optionalBody bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["index"]
+
+		body, ok := readBody(w, req, optionalBody)
+		if !ok {
+			return
+		}
+
+		raw, err := update(req.Context(), name, body)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while %s index "%s"`, action, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// readBody reads the request body, writing back the error if it can't be
+// read or is required but empty.
+func readBody(w http.ResponseWriter, req *http.Request, optional bool) ([]byte, bool) {
+	body, err := util.ReadRequestBody(req)
+	if err != nil {
+		log.Errorln(logTag, ": can't read request body :", err)
+		util.WriteBackBodyError(w, err)
+		return nil, false
+	}
+	if len(body) == 0 && !optional {
+		util.WriteBackError(w, "request body is required", http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
@@ -0,0 +1,47 @@
+package indices
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const logTag = "[indices]"
+
+var (
+	singleton *indices
+	once      sync.Once
+)
+
+type indices struct {
+	es indicesService
+}
+
+// Use only this function to fetch the instance of indices from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *indices {
+	once.Do(func() { singleton = &indices{} })
+	return singleton
+}
+
+func (i *indices) Name() string {
+	return logTag
+}
+
+func (i *indices) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+	i.es = &elasticsearch{}
+	return nil
+}
+
+func (i *indices) Routes() []plugins.Route {
+	return i.routes()
+}
+
+// Default empty middleware array function
+func (i *indices) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
@@ -0,0 +1,61 @@
+package indices
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+)
+
+// chain wraps the handlers with the middleware required to validate the
+// request against the credential's indices category and the given acl.
+type chain struct {
+	middleware.Fifo
+	acl acl.ACL
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list(c.acl)...)
+}
+
+func list(a acl.ACL) []middleware.Middleware {
+	return []middleware.Middleware{
+		classify.Category(category.Indices),
+		classify.ACL(a),
+		classify.Op(),
+		classify.Indices(),
+		classifyAlias,
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+// classifyAlias adds the {alias} of the alias routes to the request indices,
+// the credential must then be able to access the alias as well as the index
+// it's added to or removed from.
+func classifyAlias(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		alias, ok := mux.Vars(req)["alias"]
+		if !ok {
+			h(w, req)
+			return
+		}
+		reqIndices, _ := index.FromContext(req.Context())
+		indices := append(append([]string{}, reqIndices...), alias)
+		ctx := index.NewContext(req.Context(), indices)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
@@ -0,0 +1,35 @@
+package indices
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/model/index"
+)
+
+func TestClassifyAlias(t *testing.T) {
+	indicesOf := func(path string) []string {
+		var indices []string
+		h := classify.Indices()(classifyAlias(func(w http.ResponseWriter, req *http.Request) {
+			indices, _ = index.FromContext(req.Context())
+		}))
+		router := mux.NewRouter()
+		router.HandleFunc("/_index/{index}", h)
+		router.HandleFunc("/_index/{index}/alias/{alias}", h)
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, path, nil))
+		return indices
+	}
+
+	Convey("The alias is validated along with the index", t, func() {
+		So(indicesOf("/_index/products/alias/secure"), ShouldResemble, []string{"products", "secure"})
+	})
+
+	Convey("The routes without an alias are left as is", t, func() {
+		So(indicesOf("/_index/products"), ShouldResemble, []string{"products"})
+	})
+}
@@ -0,0 +1,94 @@
+package indices
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (i *indices) routes() []plugins.Route {
+	middleware := func(a acl.ACL) func(http.HandlerFunc) http.HandlerFunc {
+		return (&chain{acl: a}).Wrap
+	}
+	routes := []plugins.Route{
+		{
+			Name:        "Get indices",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_indices",
+			HandlerFunc: middleware(acl.Indices)(i.getIndices()),
+			Description: "Returns the indices present in the cluster",
+		},
+		{
+			Name:        "Get index",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_index/{index}",
+			HandlerFunc: middleware(acl.Indices)(i.getIndex()),
+			Description: "Returns the settings, mappings and aliases of the {index}",
+		},
+		{
+			Name:        "Create index",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_index/{index}",
+			HandlerFunc: middleware(acl.Indices)(i.createIndex()),
+			Description: "Creates the {index} with optionally provided settings, mappings and aliases",
+		},
+		{
+			Name:        "Delete index",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_index/{index}",
+			HandlerFunc: middleware(acl.Indices)(i.deleteIndex()),
+			Description: "Deletes the {index}",
+		},
+		{
+			Name:        "Get index settings",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_index/{index}/settings",
+			HandlerFunc: middleware(acl.Settings)(i.getSettings()),
+			Description: "Returns the settings of the {index}",
+		},
+		{
+			Name:        "Update index settings",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_index/{index}/settings",
+			HandlerFunc: middleware(acl.Settings)(i.putSettings()),
+			Description: "Updates the dynamic settings of the {index}",
+		},
+		{
+			Name:        "Get index mapping",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_index/{index}/mapping",
+			HandlerFunc: middleware(acl.Mapping)(i.getMapping()),
+			Description: "Returns the mapping of the {index}",
+		},
+		{
+			Name:        "Update index mapping",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_index/{index}/mapping",
+			HandlerFunc: middleware(acl.Mapping)(i.putMapping()),
+			Description: "Adds new fields to the mapping of the {index}",
+		},
+		{
+			Name:        "Get index aliases",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_index/{index}/aliases",
+			HandlerFunc: middleware(acl.Aliases)(i.getAliases()),
+			Description: "Returns the aliases of the {index}",
+		},
+		{
+			Name:        "Create index alias",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_index/{index}/alias/{alias}",
+			HandlerFunc: middleware(acl.Alias)(i.putAlias()),
+			Description: "Adds the {alias} to the {index}",
+		},
+		{
+			Name:        "Delete index alias",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_index/{index}/alias/{alias}",
+			HandlerFunc: middleware(acl.Alias)(i.deleteAlias()),
+			Description: "Removes the {alias} from the {index}",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,17 @@
+package indices
+
+import "context"
+
+type indicesService interface {
+	getIndices(ctx context.Context) ([]byte, error)
+	getIndex(ctx context.Context, name string) ([]byte, error)
+	createIndex(ctx context.Context, name string, body []byte) ([]byte, error)
+	deleteIndex(ctx context.Context, name string) ([]byte, error)
+	getSettings(ctx context.Context, name string) ([]byte, error)
+	putSettings(ctx context.Context, name string, body []byte) ([]byte, error)
+	getMapping(ctx context.Context, name string) ([]byte, error)
+	putMapping(ctx context.Context, name string, body []byte) ([]byte, error)
+	getAliases(ctx context.Context, name string) ([]byte, error)
+	putAlias(ctx context.Context, name, alias string, body []byte) ([]byte, error)
+	deleteAlias(ctx context.Context, name, alias string) ([]byte, error)
+}
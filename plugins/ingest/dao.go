@@ -0,0 +1,87 @@
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// document is the document converted from the record of the upload.
+type document struct {
+	record int
+	id     string
+	source map[string]interface{}
+}
+
+// bulkIndex indexes the documents into the index and returns the reason of
+// the failure of each document that failed.
+func bulkIndex(ctx context.Context, index string, docs []document, refresh string) (map[int]string, error) {
+	var body bytes.Buffer
+	for _, doc := range docs {
+		meta := make(map[string]string)
+		if doc.id != "" {
+			meta["_id"] = doc.id
+		}
+		if docType := util.DocType(); docType != "" {
+			meta["_type"] = docType
+		}
+		action, err := json.Marshal(map[string]interface{}{"index": meta})
+		if err != nil {
+			return nil, err
+		}
+		source, err := json.Marshal(doc.source)
+		if err != nil {
+			return nil, err
+		}
+		body.Write(action)
+		body.WriteByte('\n')
+		body.Write(source)
+		body.WriteByte('\n')
+	}
+
+	params := url.Values{}
+	if refresh != "" {
+		params.Set("refresh", refresh)
+	}
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method:      http.MethodPost,
+		Path:        "/" + url.PathEscape(index) + "/_bulk",
+		Params:      params,
+		Body:        body.String(),
+		ContentType: "application/x-ndjson",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var bulk struct {
+		Items []map[string]struct {
+			Status int `json:"status"`
+			Error  struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(response.Body, &bulk); err != nil {
+		return nil, err
+	}
+	if len(bulk.Items) != len(docs) {
+		return nil, fmt.Errorf("expected %d bulk items, got %d", len(docs), len(bulk.Items))
+	}
+	failures := make(map[int]string)
+	for i, item := range bulk.Items {
+		for _, result := range item {
+			if result.Status >= 300 {
+				failures[docs[i].record] = fmt.Sprintf("%s: %s", result.Error.Type, result.Error.Reason)
+			}
+		}
+	}
+	return failures, nil
+}
@@ -0,0 +1,178 @@
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// maxReportedFailures bounds the failures reported for an upload.
+const maxReportedFailures = 100
+
+// report sums up the indexing of the records of an upload.
+type report struct {
+	Index    string    `json:"index"`
+	Total    int       `json:"total"`
+	Indexed  int       `json:"indexed"`
+	Failed   int       `json:"failed"`
+	Failures []failure `json:"failures"`
+}
+
+// failure is the reason the record, numbered from 1, wasn't indexed.
+type failure struct {
+	Record int    `json:"record"`
+	Reason string `json:"reason"`
+}
+
+func (r *report) fail(record int, reason string) {
+	r.Failed++
+	if len(r.Failures) < maxReportedFailures {
+		r.Failures = append(r.Failures, failure{Record: record, Reason: reason})
+	}
+}
+
+// ingest reads the records of the body, either csv or ndjson, converts them
+// into documents by the rules and indexes them in batches. The records that
+// can't be read or converted are reported along with the ones elasticsearch
+// fails to index, the others are indexed regardless.
+func (i *ingest) ingest() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		// the path names the index of the tenant, if any
+		indexName := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+		query := req.URL.Query()
+
+		var r rules
+		if raw := query.Get("rules"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &r); err != nil {
+				util.WriteBackError(w, `can't parse "rules": `+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := r.validate(); err != nil {
+				util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		body := http.MaxBytesReader(w, req.Body, i.maxBodySize)
+		var reader recordReader
+		switch format := uploadFormat(req); format {
+		case formatCSV:
+			delimiter := ','
+			if d := query.Get("delimiter"); d != "" {
+				if utf8.RuneCountInString(d) != 1 {
+					util.WriteBackError(w, `"delimiter" must be a single character`, http.StatusBadRequest)
+					return
+				}
+				delimiter, _ = utf8.DecodeRuneInString(d)
+			}
+			csvRecords, err := newCSVReader(body, delimiter)
+			if err != nil {
+				util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			reader = csvRecords
+		case formatNDJSON:
+			reader = newNDJSONReader(body)
+		default:
+			msg := fmt.Sprintf(`invalid "format" "%s", must be either "%s" or "%s"`, format, formatCSV, formatNDJSON)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		rep := report{Index: indexName, Failures: []failure{}}
+		batch := make([]document, 0, i.batchSize)
+		flush := func() error {
+			if len(batch) == 0 {
+				return nil
+			}
+			failures, err := bulkIndex(req.Context(), indexName, batch, query.Get("refresh"))
+			if err != nil {
+				return err
+			}
+			records := make([]int, 0, len(failures))
+			for record := range failures {
+				records = append(records, record)
+			}
+			sort.Ints(records)
+			for _, record := range records {
+				rep.fail(record, failures[record])
+			}
+			rep.Indexed += len(batch) - len(failures)
+			batch = batch[:0]
+			return nil
+		}
+
+		for {
+			record, err := reader.next()
+			if err == io.EOF {
+				break
+			}
+			if e, ok := err.(recordError); ok {
+				rep.Total++
+				rep.fail(rep.Total, e.Error())
+				continue
+			}
+			if err != nil {
+				msg := fmt.Sprintf("unable to read the records: %v, %d records were indexed", err, rep.Indexed)
+				log.Errorln(logTag, ":", msg)
+				code := http.StatusBadRequest
+				if err.Error() == "http: request body too large" {
+					code = http.StatusRequestEntityTooLarge
+				}
+				util.WriteBackError(w, msg, code)
+				return
+			}
+			rep.Total++
+
+			source, id, err := r.apply(record)
+			if err != nil {
+				rep.fail(rep.Total, err.Error())
+				continue
+			}
+			batch = append(batch, document{record: rep.Total, id: id, source: source})
+			if len(batch) == i.batchSize {
+				if err := flush(); err != nil {
+					msg := fmt.Sprintf("an error occurred while indexing the records, %d records were indexed", rep.Indexed)
+					log.Errorln(logTag, ":", msg, ":", err)
+					util.WriteBackESError(w, msg, err)
+					return
+				}
+			}
+		}
+		if err := flush(); err != nil {
+			msg := fmt.Sprintf("an error occurred while indexing the records, %d records were indexed", rep.Indexed)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+
+		raw, err := json.Marshal(rep)
+		if err != nil {
+			log.Errorln(logTag, ": unable to marshal ingest report:", err)
+			util.WriteBackError(w, "an error occurred while reporting the ingested records", http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// uploadFormat returns the format of the upload, named by the format query
+// param or else implied by the content type. It defaults to ndjson.
+func uploadFormat(req *http.Request) string {
+	if format := req.URL.Query().Get("format"); format != "" {
+		return format
+	}
+	contentType := req.Header.Get("Content-Type")
+	if strings.Contains(contentType, "csv") {
+		return formatCSV
+	}
+	return formatNDJSON
+}
@@ -0,0 +1,76 @@
+package ingest
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag             = "[ingest]"
+	envBatchSize       = "INGEST_BATCH_SIZE"
+	defaultBatchSize   = 1000
+	envMaxBodySize     = "INGEST_MAX_BODY_SIZE"
+	defaultMaxBodySize = 100 * 1024 * 1024
+)
+
+var (
+	singleton *ingest
+	once      sync.Once
+)
+
+// ingest converts the uploaded csv and ndjson records into documents,
+// renaming and coercing their fields by the rules of the upload, and
+// indexes them in batches of bulk requests.
+type ingest struct {
+	batchSize   int
+	maxBodySize int64
+}
+
+// Use only this function to fetch the instance of ingest from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *ingest {
+	once.Do(func() {
+		singleton = &ingest{batchSize: defaultBatchSize, maxBodySize: defaultMaxBodySize}
+	})
+	return singleton
+}
+
+func (i *ingest) Name() string {
+	return logTag
+}
+
+func (i *ingest) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	if value := os.Getenv(envBatchSize); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a positive integer", envBatchSize, value)
+		}
+		i.batchSize = n
+	}
+	if value := os.Getenv(envMaxBodySize); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a positive integer", envMaxBodySize, value)
+		}
+		i.maxBodySize = n
+	}
+	return nil
+}
+
+func (i *ingest) Routes() []plugins.Route {
+	return i.routes()
+}
+
+// Default empty middleware array function
+func (i *ingest) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
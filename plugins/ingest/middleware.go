@@ -0,0 +1,71 @@
+package ingest
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/tenancy"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The uploads are indexed by bulk requests, hence are validated as the bulk
+// requests made to elasticsearch are.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classifyOp,
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Sources(),
+		validate.Referers(),
+		validate.Indices(),
+		validate.Category(),
+		validate.ACL(),
+		validate.Operation(),
+		validate.PermissionExpiry(),
+		tenancy.Isolate(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		docsCategory := category.Docs
+		ctx := category.NewContext(req.Context(), &docsCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		bulkACL := acl.Bulk
+		ctx := acl.NewContext(req.Context(), &bulkACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyOp(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		writeOp := op.Write
+		ctx := op.NewContext(req.Context(), &writeOp)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
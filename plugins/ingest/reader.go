@@ -0,0 +1,114 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Formats of the uploads.
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// maxLineSize bounds the size of an ndjson record.
+const maxLineSize = 10 * 1024 * 1024
+
+// recordError is returned for a record that can't be read, the records that
+// follow it can still be read.
+type recordError struct {
+	error
+}
+
+// recordReader reads the records of an upload one by one, it returns io.EOF
+// once done.
+type recordReader interface {
+	next() (map[string]interface{}, error)
+}
+
+type csvReader struct {
+	reader *csv.Reader
+	header []string
+}
+
+// newCSVReader reads the records of the csv, whose first row names the
+// fields. The empty values are left out of the records.
+func newCSVReader(r io.Reader, delimiter rune) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("the csv is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the csv header: %v", err)
+	}
+	for i, name := range header {
+		// the spreadsheet exports may start with a byte order mark
+		header[i] = strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))
+		if header[i] == "" {
+			return nil, fmt.Errorf("column %d of the csv header is empty", i+1)
+		}
+	}
+	return &csvReader{reader: reader, header: header}, nil
+}
+
+func (c *csvReader) next() (map[string]interface{}, error) {
+	row, err := c.reader.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	if e, ok := err.(*csv.ParseError); ok {
+		return nil, recordError{e}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(row) != len(c.header) {
+		return nil, recordError{fmt.Errorf("expected %d values, got %d", len(c.header), len(row))}
+	}
+	record := make(map[string]interface{}, len(row))
+	for i, value := range row {
+		if value != "" {
+			record[c.header[i]] = value
+		}
+	}
+	return record, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func newNDJSONReader(r io.Reader) *ndjsonReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return &ndjsonReader{scanner: scanner}
+}
+
+// next reads the next json object, the blank lines are skipped.
+func (n *ndjsonReader) next() (map[string]interface{}, error) {
+	for n.scanner.Scan() {
+		line := n.scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		var record map[string]interface{}
+		if err := decoder.Decode(&record); err != nil || record == nil {
+			return nil, recordError{fmt.Errorf("malformed json object")}
+		}
+		return record, nil
+	}
+	if err := n.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
@@ -0,0 +1,21 @@
+package ingest
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (i *ingest) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Ingest records",
+			Methods:     []string{http.MethodPost},
+			Path:        "/{index}/_ingest",
+			HandlerFunc: middleware(i.ingest()),
+			Description: "Indexes the csv or ndjson records of the body into {index}",
+		},
+	}
+	return routes
+}
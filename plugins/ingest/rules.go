@@ -0,0 +1,133 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Types the fields can be coerced to.
+const (
+	typeString  = "string"
+	typeInteger = "integer"
+	typeFloat   = "float"
+	typeBoolean = "boolean"
+)
+
+// rules convert the records into documents: the fields of the records are
+// renamed after the fields of the documents, the excluded ones are dropped
+// and the values are coerced to the types of the fields. The id field names
+// the field whose value is the id of the document.
+type rules struct {
+	Fields  map[string]string `json:"fields"`
+	Types   map[string]string `json:"types"`
+	Exclude []string          `json:"exclude"`
+	IDField string            `json:"id_field"`
+}
+
+func (r *rules) validate() error {
+	for field, t := range r.Types {
+		switch t {
+		case typeString, typeInteger, typeFloat, typeBoolean:
+		default:
+			return fmt.Errorf(`invalid type "%s" of field "%s", must be one of "%s", "%s", "%s" or "%s"`,
+				t, field, typeString, typeInteger, typeFloat, typeBoolean)
+		}
+	}
+	for from, to := range r.Fields {
+		if to == "" {
+			return fmt.Errorf(`the field "%s" is renamed to an empty field`, from)
+		}
+	}
+	return nil
+}
+
+// apply converts the record into a document, along with its id if any.
+func (r *rules) apply(record map[string]interface{}) (map[string]interface{}, string, error) {
+	doc := make(map[string]interface{}, len(record))
+	for field, value := range record {
+		if to, ok := r.Fields[field]; ok {
+			field = to
+		}
+		doc[field] = value
+	}
+	for _, field := range r.Exclude {
+		delete(doc, field)
+	}
+	for field, t := range r.Types {
+		value, ok := doc[field]
+		if !ok {
+			continue
+		}
+		coerced, err := coerce(value, t)
+		if err != nil {
+			return nil, "", fmt.Errorf(`field "%s": %v`, field, err)
+		}
+		doc[field] = coerced
+	}
+
+	var id string
+	if r.IDField != "" {
+		value, ok := doc[r.IDField]
+		if !ok || value == nil {
+			return nil, "", fmt.Errorf(`id field "%s" is missing`, r.IDField)
+		}
+		id = stringValue(value)
+		if id == "" {
+			return nil, "", fmt.Errorf(`id field "%s" is empty`, r.IDField)
+		}
+	}
+	return doc, id, nil
+}
+
+// coerce converts the value to the type, the csv values are strings while
+// the ndjson ones are decoded with their numbers kept as json numbers.
+func coerce(value interface{}, t string) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch t {
+	case typeString:
+		return stringValue(value), nil
+	case typeInteger:
+		s := strings.TrimSpace(stringValue(value))
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf(`"%s" isn't an integer`, s)
+		}
+		return n, nil
+	case typeFloat:
+		s := strings.TrimSpace(stringValue(value))
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf(`"%s" isn't a number`, s)
+		}
+		return f, nil
+	case typeBoolean:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+		s := strings.TrimSpace(stringValue(value))
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf(`"%s" isn't a boolean`, s)
+		}
+		return b, nil
+	}
+	return nil, errors.New("unknown type " + t)
+}
+
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	raw, _ := json.Marshal(value)
+	return string(raw)
+}
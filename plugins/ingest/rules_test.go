@@ -0,0 +1,96 @@
+package ingest
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func readAll(r recordReader) ([]map[string]interface{}, []error) {
+	var records []map[string]interface{}
+	var errs []error
+	for {
+		record, err := r.next()
+		if err == io.EOF {
+			return records, errs
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		records = append(records, record)
+	}
+}
+
+func TestRules(t *testing.T) {
+	r := &rules{
+		Fields:  map[string]string{"Product Name": "name", "SKU": "sku"},
+		Types:   map[string]string{"price": typeFloat, "stock": typeInteger, "active": typeBoolean, "sku": typeString},
+		Exclude: []string{"internal"},
+		IDField: "sku",
+	}
+
+	Convey("The records are renamed, trimmed and coerced", t, func() {
+		doc, id, err := r.apply(map[string]interface{}{
+			"Product Name": "Shoes",
+			"SKU":          json.Number("1001"),
+			"price":        "49.90",
+			"stock":        " 12 ",
+			"active":       "true",
+			"internal":     "x",
+		})
+		So(err, ShouldBeNil)
+		So(id, ShouldEqual, "1001")
+		So(doc, ShouldResemble, map[string]interface{}{
+			"name":   "Shoes",
+			"sku":    "1001",
+			"price":  49.9,
+			"stock":  int64(12),
+			"active": true,
+		})
+	})
+
+	Convey("The records that can't be coerced are rejected", t, func() {
+		_, _, err := r.apply(map[string]interface{}{"SKU": "1", "stock": "many"})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, `field "stock"`)
+		_, _, err = r.apply(map[string]interface{}{"price": "1"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("The rules are validated", t, func() {
+		So((&rules{Types: map[string]string{"a": "date"}}).validate(), ShouldNotBeNil)
+		So((&rules{Fields: map[string]string{"a": ""}}).validate(), ShouldNotBeNil)
+		So(r.validate(), ShouldBeNil)
+	})
+}
+
+func TestReaders(t *testing.T) {
+	Convey("The csv rows are read by the header", t, func() {
+		csv := "\ufeffname;price\nshoes;10\nsocks;\nhats\n\"bad;1\n"
+		reader, err := newCSVReader(strings.NewReader(csv), ';')
+		So(err, ShouldBeNil)
+		records, errs := readAll(reader)
+		So(records, ShouldResemble, []map[string]interface{}{
+			{"name": "shoes", "price": "10"},
+			{"name": "socks"},
+		})
+		So(errs, ShouldHaveLength, 2)
+		_, ok := errs[0].(recordError)
+		So(ok, ShouldBeTrue)
+	})
+
+	Convey("The csv requires a header", t, func() {
+		_, err := newCSVReader(strings.NewReader(""), ',')
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("The ndjson objects are read line by line", t, func() {
+		records, errs := readAll(newNDJSONReader(strings.NewReader("{\"a\": 1}\n\nnot json\n{\"b\": \"x\"}")))
+		So(records, ShouldResemble, []map[string]interface{}{{"a": json.Number("1")}, {"b": "x"}})
+		So(errs, ShouldHaveLength, 1)
+	})
+}
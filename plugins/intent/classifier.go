@@ -0,0 +1,162 @@
+package intent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// maxCached is the number of queries whose intent told by the classifier is
+// kept, the cache is emptied once full.
+const maxCached = 10000
+
+// ruleSpec is the json representation of a rule in INTENT_RULES.
+type ruleSpec struct {
+	Intent   string   `json:"intent"`
+	Keywords []string `json:"keywords"`
+	Patterns []string `json:"patterns"`
+}
+
+// rule tags the queries containing one of its keywords, matched as whole
+// words regardless of the case, or matching one of its patterns.
+type rule struct {
+	intent   string
+	keywords []string
+	patterns []*regexp.Regexp
+}
+
+func parseRules(raw string) ([]rule, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var specs []ruleSpec
+	if err := json.Unmarshal([]byte(raw), &specs); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", envRules, err)
+	}
+	rules := make([]rule, 0, len(specs))
+	for i, spec := range specs {
+		if strings.TrimSpace(spec.Intent) == "" {
+			return nil, fmt.Errorf("intent rule %d has no intent", i)
+		}
+		if len(spec.Keywords) == 0 && len(spec.Patterns) == 0 {
+			return nil, fmt.Errorf(`intent rule "%s" has neither keywords nor patterns`, spec.Intent)
+		}
+		r := rule{intent: strings.TrimSpace(spec.Intent)}
+		for _, keyword := range spec.Keywords {
+			if keyword = normalize(keyword); keyword != "" {
+				r.keywords = append(r.keywords, " "+keyword+" ")
+			}
+		}
+		for _, pattern := range spec.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf(`invalid pattern for intent rule "%s": %v`, spec.Intent, err)
+			}
+			r.patterns = append(r.patterns, re)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// normalize lowercases the text and replaces the runs of the characters
+// other than the letters and digits by a single space.
+func normalize(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// match returns the intent of the first rule the query matches.
+func match(rules []rule, query string) (string, bool) {
+	words := " " + normalize(query) + " "
+	for _, r := range rules {
+		for _, keyword := range r.keywords {
+			if strings.Contains(words, keyword) {
+				return r.intent, true
+			}
+		}
+		for _, re := range r.patterns {
+			if re.MatchString(query) {
+				return r.intent, true
+			}
+		}
+	}
+	return "", false
+}
+
+// classifier asks an external service for the intent of the queries, the
+// service is sent {"query": "..."} and answers {"intent": "..."}.
+type classifier struct {
+	url     string
+	timeout time.Duration
+	client  *http.Client
+
+	mu     sync.Mutex
+	cached map[string]string
+}
+
+func newClassifier(url string, timeout time.Duration) *classifier {
+	return &classifier{
+		url:     url,
+		timeout: timeout,
+		client:  util.HTTPClient(),
+		cached:  make(map[string]string),
+	}
+}
+
+// classify returns the intent of the query, which is empty if the service
+// couldn't tell it.
+func (c *classifier) classify(ctx context.Context, query string) (string, error) {
+	key := normalize(query)
+	c.mu.Lock()
+	intent, ok := c.cached[key]
+	c.mu.Unlock()
+	if ok {
+		return intent, nil
+	}
+
+	raw, err := json.Marshal(map[string]string{"query": query})
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(raw))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	response, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the classifier responded with %d", response.StatusCode)
+	}
+	var result struct {
+		Intent string `json:"intent"`
+	}
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("unable to parse the response of the classifier: %v", err)
+	}
+	intent = strings.TrimSpace(result.Intent)
+
+	c.mu.Lock()
+	if len(c.cached) >= maxCached {
+		c.cached = make(map[string]string)
+	}
+	c.cached[key] = intent
+	c.mu.Unlock()
+	return intent, nil
+}
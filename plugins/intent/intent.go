@@ -0,0 +1,83 @@
+package intent
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag               = "[intent]"
+	envRules             = "INTENT_RULES"
+	envClassifierURL     = "INTENT_CLASSIFIER_URL"
+	envClassifierTimeout = "INTENT_CLASSIFIER_TIMEOUT"
+	defaultTimeout       = 100 * time.Millisecond
+	envDefault           = "INTENT_DEFAULT"
+)
+
+var (
+	singleton *intent
+	once      sync.Once
+)
+
+// intent tags the searches with the intent of their query, either matched
+// by the rules or told by the external classifier, so that the request logs
+// can be broken down by intent.
+type intent struct {
+	rules      []rule
+	classifier *classifier
+	// fallback is the intent of the queries nothing classified, the
+	// searches aren't tagged if empty.
+	fallback string
+}
+
+// Use only this function to fetch the instance of intent from within this
+// package to avoid creating stateless duplicates of the plugin.
+func Instance() *intent {
+	once.Do(func() { singleton = &intent{} })
+	return singleton
+}
+
+func (i *intent) Name() string {
+	return logTag
+}
+
+func (i *intent) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	var err error
+	i.rules, err = parseRules(os.Getenv(envRules))
+	if err != nil {
+		return err
+	}
+	if url := os.Getenv(envClassifierURL); url != "" {
+		timeout := defaultTimeout
+		if value := os.Getenv(envClassifierTimeout); value != "" {
+			timeout, err = time.ParseDuration(value)
+			if err != nil || timeout <= 0 {
+				return fmt.Errorf("invalid value for %s: %s, must be a positive duration", envClassifierTimeout, value)
+			}
+		}
+		i.classifier = newClassifier(url, timeout)
+	}
+	i.fallback = strings.TrimSpace(os.Getenv(envDefault))
+	if len(i.rules) == 0 && i.classifier == nil {
+		log.Println(logTag, ": neither", envRules, "nor", envClassifierURL, "is set, searches won't be tagged")
+	}
+	return nil
+}
+
+func (i *intent) Routes() []plugins.Route {
+	return []plugins.Route{}
+}
+
+func (i *intent) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{i.tag}
+}
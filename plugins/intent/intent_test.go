@@ -0,0 +1,116 @@
+package intent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/acl"
+)
+
+const rules = `[
+	{"intent": "support", "keywords": ["refund", "return policy"], "patterns": ["(?i)^how (do|to) "]},
+	{"intent": "navigational", "keywords": ["login", "contact"]}
+]`
+
+func TestRules(t *testing.T) {
+	Convey("The rules are parsed", t, func() {
+		parsed, err := parseRules(rules)
+		So(err, ShouldBeNil)
+		So(parsed, ShouldHaveLength, 2)
+
+		_, err = parseRules(`[{"keywords": ["refund"]}]`)
+		So(err, ShouldNotBeNil)
+		_, err = parseRules(`[{"intent": "support"}]`)
+		So(err, ShouldNotBeNil)
+		_, err = parseRules(`[{"intent": "support", "patterns": ["("]}]`)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("The keywords match whole words regardless of the case", t, func() {
+		parsed, _ := parseRules(rules)
+		label, ok := match(parsed, "Refund for my order")
+		So(ok, ShouldBeTrue)
+		So(label, ShouldEqual, "support")
+
+		label, _ = match(parsed, "what's the RETURN-policy?")
+		So(label, ShouldEqual, "support")
+
+		label, _ = match(parsed, "How to reset my password")
+		So(label, ShouldEqual, "support")
+
+		label, _ = match(parsed, "login")
+		So(label, ShouldEqual, "navigational")
+
+		_, ok = match(parsed, "refunded shoes")
+		So(ok, ShouldBeFalse)
+	})
+}
+
+func TestClassifier(t *testing.T) {
+	var calls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		var body map[string]string
+		json.NewDecoder(req.Body).Decode(&body)
+		if body["query"] == "slow" {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.Write([]byte(`{"intent": "product"}`))
+	}))
+	defer server.Close()
+
+	Convey("The intents told by the classifier are cached", t, func() {
+		c := newClassifier(server.URL, time.Second)
+		label, err := c.classify(context.Background(), "Red shoes")
+		So(err, ShouldBeNil)
+		So(label, ShouldEqual, "product")
+		label, _ = c.classify(context.Background(), "red  shoes")
+		So(label, ShouldEqual, "product")
+		So(atomic.LoadInt64(&calls), ShouldEqual, 1)
+	})
+
+	Convey("The classifier is bounded by the timeout", t, func() {
+		c := newClassifier(server.URL, 10*time.Millisecond)
+		_, err := c.classify(context.Background(), "slow")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestTag(t *testing.T) {
+	parsed, err := parseRules(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := &intent{rules: parsed, fallback: "product"}
+	serve := func(query string, a acl.ACL) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/products/_search", nil)
+		if query != "" {
+			req.Header.Set(queryHeader, query)
+		}
+		req = req.WithContext(acl.NewContext(req.Context(), &a))
+		w := httptest.NewRecorder()
+		i.tag(func(w http.ResponseWriter, req *http.Request) {})(w, req)
+		return w
+	}
+
+	Convey("The searches are tagged with their intent", t, func() {
+		So(serve("refund", acl.Search).Header().Get(Header), ShouldEqual, "support")
+		So(serve("login", acl.Msearch).Header().Get(Header), ShouldEqual, "navigational")
+	})
+
+	Convey("The searches nothing classified are tagged with the default intent", t, func() {
+		So(serve("red shoes", acl.Search).Header().Get(Header), ShouldEqual, "product")
+	})
+
+	Convey("The other requests aren't tagged", t, func() {
+		So(serve("", acl.Search).Header().Get(Header), ShouldBeEmpty)
+		So(serve("refund", acl.Index).Header().Get(Header), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,55 @@
+package intent
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+)
+
+const (
+	// queryHeader carries the query searched by the user, as it does for the
+	// analytics.
+	queryHeader = "X-Search-Query"
+	// Header carries the intent of the search in the response, the request
+	// logs store it as the intent of their record.
+	Header = "X-Search-Intent"
+)
+
+// tag sets the intent of the searches made with the query header on their
+// response. The rules are tried first, in order, then the classifier, the
+// searches whose intent neither told are tagged with the default intent.
+func (i *intent) tag(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searched := strings.TrimSpace(req.Header.Get(queryHeader))
+		reqACL, err := acl.FromContext(req.Context())
+		if searched == "" || err != nil || (*reqACL != acl.Search && *reqACL != acl.Msearch) {
+			h(w, req)
+			return
+		}
+		if label := i.classify(req.Context(), searched); label != "" {
+			w.Header().Set(Header, label)
+		}
+		h(w, req)
+	}
+}
+
+// classify returns the intent of the query, the default one if unknown.
+func (i *intent) classify(ctx context.Context, query string) string {
+	if label, ok := match(i.rules, query); ok {
+		return label
+	}
+	if i.classifier != nil {
+		label, err := i.classifier.classify(ctx, query)
+		if err != nil {
+			log.Errorln(logTag, ": unable to classify the query:", err)
+		}
+		if label != "" {
+			return label
+		}
+	}
+	return i.fallback
+}
@@ -0,0 +1,133 @@
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// elasticsearch manages the templates and policies of the upstream cluster.
+// Composable index templates are used where available (elasticsearch 7.8+
+// and OpenSearch), legacy templates otherwise. Lifecycle policies map to ILM
+// on elasticsearch and to ISM on OpenSearch.
+type elasticsearch struct{}
+
+func (es *elasticsearch) perform(ctx context.Context, method, path string, params url.Values, body []byte) (*es7.Response, error) {
+	options := es7.PerformRequestOptions{
+		Method: method,
+		Path:   path,
+		Params: params,
+	}
+	if body != nil {
+		options.Body = string(body)
+	}
+	return util.GetClient7().PerformRequest(ctx, options)
+}
+
+func (es *elasticsearch) do(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	response, err := es.perform(ctx, method, path, nil, body)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// composableTemplates checks whether the cluster supports the _index_template apis.
+func composableTemplates() bool {
+	if util.IsOpenSearch() {
+		return true
+	}
+	tokens := strings.Split(util.GetRawVersion(), ".")
+	if len(tokens) < 2 {
+		return util.GetVersion() > 7
+	}
+	major, _ := strconv.Atoi(tokens[0])
+	minor, _ := strconv.Atoi(tokens[1])
+	return major > 7 || (major == 7 && minor >= 8)
+}
+
+func templatesPath() string {
+	if composableTemplates() {
+		return "/_index_template"
+	}
+	return "/_template"
+}
+
+func policiesPath() string {
+	if util.IsOpenSearch() {
+		return "/_plugins/_ism/policies"
+	}
+	return "/_ilm/policy"
+}
+
+func (es *elasticsearch) getTemplates(ctx context.Context) ([]byte, error) {
+	return es.do(ctx, http.MethodGet, templatesPath(), nil)
+}
+
+func (es *elasticsearch) getTemplate(ctx context.Context, name string) ([]byte, error) {
+	return es.do(ctx, http.MethodGet, templatesPath()+"/"+url.PathEscape(name), nil)
+}
+
+func (es *elasticsearch) putTemplate(ctx context.Context, name string, body []byte) ([]byte, error) {
+	return es.do(ctx, http.MethodPut, templatesPath()+"/"+url.PathEscape(name), body)
+}
+
+func (es *elasticsearch) deleteTemplate(ctx context.Context, name string) ([]byte, error) {
+	return es.do(ctx, http.MethodDelete, templatesPath()+"/"+url.PathEscape(name), nil)
+}
+
+func (es *elasticsearch) simulateTemplate(ctx context.Context, index string) ([]byte, error) {
+	if !composableTemplates() {
+		return nil, fmt.Errorf("simulating index templates is not supported by elasticsearch %s", util.GetRawVersion())
+	}
+	return es.do(ctx, http.MethodPost, "/_index_template/_simulate_index/"+url.PathEscape(index), nil)
+}
+
+func (es *elasticsearch) getPolicies(ctx context.Context) ([]byte, error) {
+	return es.do(ctx, http.MethodGet, policiesPath(), nil)
+}
+
+func (es *elasticsearch) getPolicy(ctx context.Context, name string) ([]byte, error) {
+	return es.do(ctx, http.MethodGet, policiesPath()+"/"+url.PathEscape(name), nil)
+}
+
+func (es *elasticsearch) putPolicy(ctx context.Context, name string, body []byte) ([]byte, error) {
+	path := policiesPath() + "/" + url.PathEscape(name)
+	if !util.IsOpenSearch() {
+		return es.do(ctx, http.MethodPut, path, body)
+	}
+
+	// ISM requires the sequence number and primary term to update an existing policy
+	params := url.Values{}
+	response, err := es.perform(ctx, http.MethodGet, path, nil, nil)
+	if err == nil {
+		var current struct {
+			SeqNo       int64 `json:"_seq_no"`
+			PrimaryTerm int64 `json:"_primary_term"`
+		}
+		if err := json.Unmarshal(response.Body, &current); err != nil {
+			return nil, err
+		}
+		params.Set("if_seq_no", strconv.FormatInt(current.SeqNo, 10))
+		params.Set("if_primary_term", strconv.FormatInt(current.PrimaryTerm, 10))
+	} else if !es7.IsNotFound(err) {
+		return nil, err
+	}
+
+	response, err = es.perform(ctx, http.MethodPut, path, params, body)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func (es *elasticsearch) deletePolicy(ctx context.Context, name string) ([]byte, error) {
+	return es.do(ctx, http.MethodDelete, policiesPath()+"/"+url.PathEscape(name), nil)
+}
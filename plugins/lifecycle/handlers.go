@@ -0,0 +1,195 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/audit"
+	"github.com/gorilla/mux"
+)
+
+func (l *lifecycle) getTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := l.es.getTemplates(req.Context())
+		if err != nil {
+			msg := "an error occurred while fetching the index templates"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (l *lifecycle) getTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+		raw, err := l.es.getTemplate(req.Context(), name)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching index template "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (l *lifecycle) putTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		body, ok := readBody(w, req, "index_patterns")
+		if !ok {
+			return
+		}
+
+		raw, err := l.es.putTemplate(req.Context(), name, body)
+		record(req, "put_index_template", name, body, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while saving index template "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (l *lifecycle) deleteTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		raw, err := l.es.deleteTemplate(req.Context(), name)
+		record(req, "delete_index_template", name, nil, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while deleting index template "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (l *lifecycle) simulateTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+		raw, err := l.es.simulateTemplate(req.Context(), index)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while simulating index templates for "%s"`, index)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (l *lifecycle) getPolicies() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := l.es.getPolicies(req.Context())
+		if err != nil {
+			msg := "an error occurred while fetching the lifecycle policies"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (l *lifecycle) getPolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+		raw, err := l.es.getPolicy(req.Context(), name)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching lifecycle policy "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (l *lifecycle) putPolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		body, ok := readBody(w, req, "policy")
+		if !ok {
+			return
+		}
+
+		raw, err := l.es.putPolicy(req.Context(), name, body)
+		record(req, "put_lifecycle_policy", name, body, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while saving lifecycle policy "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (l *lifecycle) deletePolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		raw, err := l.es.deletePolicy(req.Context(), name)
+		record(req, "delete_lifecycle_policy", name, nil, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while deleting lifecycle policy "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// readBody reads the request body and validates that it is a json object
+// containing the required field, writing back the error otherwise.
+func readBody(w http.ResponseWriter, req *http.Request, required string) ([]byte, bool) {
+	body, err := util.ReadRequestBody(req)
+	if err != nil {
+		log.Errorln(logTag, ": can't read request body :", err)
+		util.WriteBackBodyError(w, err)
+		return nil, false
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(body, &obj); err != nil {
+		util.WriteBackError(w, fmt.Sprintf("can't parse request body: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	if _, ok := obj[required]; !ok {
+		util.WriteBackError(w, fmt.Sprintf(`"%s" is required`, required), http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+// record adds the change made by the request to the audit trail.
+func record(req *http.Request, action, resource string, body []byte, err error) {
+	status := http.StatusOK
+	details := make(map[string]interface{})
+	if body != nil {
+		details["body"] = json.RawMessage(body)
+	}
+	if err != nil {
+		status = http.StatusInternalServerError
+		details["error"] = err.Error()
+	}
+	event := audit.NewEvent(req, action, resource, status)
+	if len(details) > 0 {
+		event.Details = details
+	}
+	audit.Record(req.Context(), event)
+}
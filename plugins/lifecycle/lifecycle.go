@@ -0,0 +1,47 @@
+package lifecycle
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const logTag = "[lifecycle]"
+
+var (
+	singleton *lifecycle
+	once      sync.Once
+)
+
+type lifecycle struct {
+	es lifecycleService
+}
+
+// Use only this function to fetch the instance of lifecycle from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *lifecycle {
+	once.Do(func() { singleton = &lifecycle{} })
+	return singleton
+}
+
+func (l *lifecycle) Name() string {
+	return logTag
+}
+
+func (l *lifecycle) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+	l.es = &elasticsearch{}
+	return nil
+}
+
+func (l *lifecycle) Routes() []plugins.Route {
+	return l.routes()
+}
+
+// Default empty middleware array function
+func (l *lifecycle) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
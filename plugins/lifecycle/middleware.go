@@ -0,0 +1,42 @@
+package lifecycle
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+)
+
+// chain wraps the handlers with the middleware required to validate the
+// request against the given category and acl. Templates and policies apply
+// to the whole cluster, hence the routes without an {index} additionally
+// require cluster level access.
+type chain struct {
+	middleware.Fifo
+	category category.Category
+	acl      acl.ACL
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list(c.category, c.acl)...)
+}
+
+func list(c category.Category, a acl.ACL) []middleware.Middleware {
+	return []middleware.Middleware{
+		classify.Category(c),
+		classify.ACL(a),
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
@@ -0,0 +1,80 @@
+package lifecycle
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (l *lifecycle) routes() []plugins.Route {
+	templates := (&chain{category: category.Indices, acl: acl.Template}).Wrap
+	policies := (&chain{category: category.Clusters, acl: acl.Cluster}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get index templates",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_lifecycle/templates",
+			HandlerFunc: templates(l.getTemplates()),
+			Description: "Returns all the index templates",
+		},
+		{
+			Name:        "Get index template",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_lifecycle/template/{name}",
+			HandlerFunc: templates(l.getTemplate()),
+			Description: "Returns the index template {name}",
+		},
+		{
+			Name:        "Create index template",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_lifecycle/template/{name}",
+			HandlerFunc: templates(l.putTemplate()),
+			Description: "Creates or updates the index template {name}",
+		},
+		{
+			Name:        "Delete index template",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_lifecycle/template/{name}",
+			HandlerFunc: templates(l.deleteTemplate()),
+			Description: "Deletes the index template {name}",
+		},
+		{
+			Name:        "Simulate index template",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_lifecycle/simulate/{index}",
+			HandlerFunc: templates(l.simulateTemplate()),
+			Description: "Returns the settings, mappings and aliases the templates would apply to {index}",
+		},
+		{
+			Name:        "Get lifecycle policies",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_lifecycle/policies",
+			HandlerFunc: policies(l.getPolicies()),
+			Description: "Returns all the index lifecycle policies",
+		},
+		{
+			Name:        "Get lifecycle policy",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_lifecycle/policy/{name}",
+			HandlerFunc: policies(l.getPolicy()),
+			Description: "Returns the index lifecycle policy {name}",
+		},
+		{
+			Name:        "Create lifecycle policy",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_lifecycle/policy/{name}",
+			HandlerFunc: policies(l.putPolicy()),
+			Description: "Creates or updates the index lifecycle policy {name}",
+		},
+		{
+			Name:        "Delete lifecycle policy",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_lifecycle/policy/{name}",
+			HandlerFunc: policies(l.deletePolicy()),
+			Description: "Deletes the index lifecycle policy {name}",
+		},
+	}
+	return routes
+}
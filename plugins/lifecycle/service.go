@@ -0,0 +1,15 @@
+package lifecycle
+
+import "context"
+
+type lifecycleService interface {
+	getTemplates(ctx context.Context) ([]byte, error)
+	getTemplate(ctx context.Context, name string) ([]byte, error)
+	putTemplate(ctx context.Context, name string, body []byte) ([]byte, error)
+	deleteTemplate(ctx context.Context, name string) ([]byte, error)
+	simulateTemplate(ctx context.Context, index string) ([]byte, error)
+	getPolicies(ctx context.Context) ([]byte, error)
+	getPolicy(ctx context.Context, name string) ([]byte, error)
+	putPolicy(ctx context.Context, name string, body []byte) ([]byte, error)
+	deletePolicy(ctx context.Context, name string) ([]byte, error)
+}
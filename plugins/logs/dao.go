@@ -2,8 +2,10 @@ package logs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -52,8 +54,10 @@ func initPlugin(indexName, config string) (*elasticsearch, error) {
 func (es *elasticsearch) indexRecord(ctx context.Context, rec record) {
 	bulkIndex := es7.NewBulkIndexRequest().
 		Index(es.indexName).
-		Type("_doc").
 		Doc(rec)
+	if docType := util.DocType(); docType != "" {
+		bulkIndex.Type(docType)
+	}
 
 	_, err := util.GetClient7().Bulk().
 		Add(bulkIndex).
@@ -79,3 +83,68 @@ func (es *elasticsearch) getRawLogs(ctx context.Context, from, size, filter stri
 		return es.getRawLogsES7(ctx, from, s, filter, offset, indices...)
 	}
 }
+
+// storedRecord is a log record along with the id of its document.
+type storedRecord struct {
+	ID     string
+	Source json.RawMessage
+}
+
+// expiredRecords returns the oldest records logged before the given time.
+func (es *elasticsearch) expiredRecords(ctx context.Context, before time.Time, size int) ([]storedRecord, error) {
+	response, err := util.GetClient7().Search(es.indexName).
+		Query(es7.NewRangeQuery("timestamp").Lt(before)).
+		SortWithInfo(es7.SortInfo{Field: "timestamp", UnmappedType: "date", Ascending: true}).
+		Size(size).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []storedRecord
+	for _, hit := range response.Hits.Hits {
+		records = append(records, storedRecord{ID: hit.Id, Source: hit.Source})
+	}
+	return records, nil
+}
+
+// deleteRecords deletes the records with the given ids, the deletions are
+// visible to the searches once it returns.
+func (es *elasticsearch) deleteRecords(ctx context.Context, ids []string) (int64, error) {
+	bulk := util.GetClient7().Bulk().
+		Refresh("wait_for")
+	for _, id := range ids {
+		bulkDelete := es7.NewBulkDeleteRequest().
+			Index(es.indexName).
+			Id(id)
+		if docType := util.DocType(); docType != "" {
+			bulkDelete.Type(docType)
+		}
+		bulk.Add(bulkDelete)
+	}
+
+	response, err := bulk.Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if failed := response.Failed(); len(failed) > 0 {
+		reason := "unknown reason"
+		if failed[0].Error != nil {
+			reason = failed[0].Error.Reason
+		}
+		return int64(len(ids) - len(failed)), fmt.Errorf("unable to delete %d records: %s", len(failed), reason)
+	}
+	return int64(len(ids)), nil
+}
+
+// deleteExpiredRecords deletes the records logged before the given time.
+func (es *elasticsearch) deleteExpiredRecords(ctx context.Context, before time.Time) (int64, error) {
+	response, err := util.GetClient7().DeleteByQuery(es.indexName).
+		Query(es7.NewRangeQuery("timestamp").Lt(before)).
+		ProceedOnVersionConflict().
+		Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return response.Deleted, nil
+}
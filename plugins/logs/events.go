@@ -0,0 +1,318 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The headers the clients tag their searches with for the analytics, e.g.
+// X-Search-Filters: brand=apple,price="10,20",tags={"new":true}
+const (
+	filtersHeader       = "X-Search-Filters"
+	customEventHeader   = "X-Search-CustomEvent"
+	clickHeader         = "X-Search-Click"
+	clickPositionHeader = "X-Search-ClickPosition"
+	conversionHeader    = "X-Search-Conversion"
+)
+
+// eventName is the charset of the names of the custom events.
+var eventName = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// events are the filters, the custom events and the click of the search.
+type events struct {
+	Filters       map[string][]string `json:"filters,omitempty"`
+	CustomEvents  map[string][]string `json:"custom_events,omitempty"`
+	Click         bool                `json:"click,omitempty"`
+	ClickPosition int64               `json:"click_position,omitempty"`
+	Conversion    bool                `json:"conversion,omitempty"`
+	// Errors reports the values of the headers that were left out or clamped.
+	Errors []string `json:"errors,omitempty"`
+
+	// rejected and clamped are the fields of the values left out and clamped.
+	rejected []string
+	clamped  []string
+}
+
+// parseEvents parses and validates the analytics headers of the search, nil
+// if it isn't tagged. The click position is clamped to the total hits of the
+// search, if known.
+func parseEvents(header map[string][]string, totalHits *int64) *events {
+	var e events
+	if value := headerValue(header, filtersHeader); value != "" {
+		var errs []error
+		e.Filters, errs = parse(value)
+		e.reject("filters", filtersHeader, errs...)
+	}
+	if value := headerValue(header, customEventHeader); value != "" {
+		var errs []error
+		e.CustomEvents, errs = parse(value)
+		e.reject("custom_events", customEventHeader, errs...)
+		for name := range e.CustomEvents {
+			if !eventName.MatchString(name) {
+				delete(e.CustomEvents, name)
+				e.reject("custom_events", customEventHeader,
+					fmt.Errorf(`invalid event name "%s", expected at most 64 letters, digits, "_", "." or "-"`, name))
+			}
+		}
+		if len(e.CustomEvents) == 0 {
+			e.CustomEvents = nil
+		}
+	}
+	if value := headerValue(header, clickHeader); value != "" {
+		click, err := strconv.ParseBool(value)
+		if err != nil {
+			e.reject("click", clickHeader, fmt.Errorf(`invalid value "%s", expected a boolean`, value))
+		}
+		e.Click = click
+	}
+	if value := headerValue(header, clickPositionHeader); value != "" {
+		position, err := strconv.ParseInt(value, 10, 64)
+		switch {
+		case err != nil || position < 1:
+			e.reject("click_position", clickPositionHeader, fmt.Errorf(`invalid value "%s", expected a position from 1`, value))
+		case !e.Click:
+			e.reject("click_position", clickPositionHeader, fmt.Errorf("no click at position %d", position))
+		case totalHits != nil && position > *totalHits:
+			e.clamped = append(e.clamped, "click_position")
+			e.Errors = append(e.Errors, fmt.Sprintf("%s: position %d clamped to the %d hits", clickPositionHeader, position, *totalHits))
+			e.ClickPosition = *totalHits
+		default:
+			e.ClickPosition = position
+		}
+	}
+	if value := headerValue(header, conversionHeader); value != "" {
+		conversion, err := strconv.ParseBool(value)
+		switch {
+		case err != nil:
+			e.reject("conversion", conversionHeader, fmt.Errorf(`invalid value "%s", expected a boolean`, value))
+		case conversion && !e.Click:
+			e.reject("conversion", conversionHeader, fmt.Errorf("no conversion without a click"))
+		default:
+			e.Conversion = conversion
+		}
+	}
+	if e.Filters == nil && e.CustomEvents == nil && !e.Click && !e.Conversion && e.Errors == nil {
+		return nil
+	}
+	return &e
+}
+
+// headerValue joins the values of the repeated header.
+func headerValue(header map[string][]string, key string) string {
+	return strings.Join(header[http.CanonicalHeaderKey(key)], ",")
+}
+
+// reject reports the values of the header left out.
+func (e *events) reject(field, header string, errs ...error) {
+	for _, err := range errs {
+		e.rejected = append(e.rejected, field)
+		e.Errors = append(e.Errors, header+": "+err.Error())
+	}
+}
+
+// validation counts the values of the analytics headers that were left out
+// or clamped since arc started, so that the clients sending malformed
+// headers can be spotted.
+type validation struct {
+	mu       sync.Mutex
+	rejected map[string]int64
+	clamped  map[string]int64
+}
+
+// validationStatus is the report of the validation, per field.
+type validationStatus struct {
+	Rejected map[string]int64 `json:"rejected"`
+	Clamped  map[string]int64 `json:"clamped"`
+}
+
+func (v *validation) count(e *events) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.rejected == nil {
+		v.rejected = make(map[string]int64)
+		v.clamped = make(map[string]int64)
+	}
+	for _, field := range e.rejected {
+		v.rejected[field]++
+	}
+	for _, field := range e.clamped {
+		v.clamped[field]++
+	}
+}
+
+func (v *validation) report() validationStatus {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	status := validationStatus{Rejected: make(map[string]int64), Clamped: make(map[string]int64)}
+	for field, n := range v.rejected {
+		status.Rejected[field] = n
+	}
+	for field, n := range v.clamped {
+		status.Clamped[field] = n
+	}
+	return status
+}
+
+// parse parses the comma separated key=value entries of an analytics header.
+// The keys and the values are url decoded, unless the value is quoted, in
+// which case it may contain commas and the escaped quotes and backslashes,
+// or is a json object or array, which is compacted. The values of the
+// repeated keys are collected in order. The malformed entries are left out
+// and reported in the errors, along with their position.
+func parse(header string) (map[string][]string, []error) {
+	values := make(map[string][]string)
+	var errs []error
+	p := &parser{s: header}
+	for entry := 1; !p.done(); entry++ {
+		key, value, err := p.entry()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %v", entry, err))
+			p.skip()
+			continue
+		}
+		values[key] = append(values[key], value)
+	}
+	if len(values) == 0 {
+		values = nil
+	}
+	return values, errs
+}
+
+// parser scans the entries of a header.
+type parser struct {
+	s string
+	i int
+}
+
+func (p *parser) done() bool {
+	p.space()
+	return p.i >= len(p.s)
+}
+
+func (p *parser) space() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// skip moves past the next separator.
+func (p *parser) skip() {
+	if i := strings.IndexByte(p.s[p.i:], ','); i >= 0 {
+		p.i += i + 1
+		return
+	}
+	p.i = len(p.s)
+}
+
+// entry scans the key=value entry at the position and the separator that
+// follows it.
+func (p *parser) entry() (string, string, error) {
+	end := strings.IndexAny(p.s[p.i:], "=,")
+	if end < 0 || p.s[p.i+end] == ',' {
+		return "", "", fmt.Errorf(`missing "=" after the key`)
+	}
+	key, err := url.PathUnescape(strings.TrimSpace(p.s[p.i : p.i+end]))
+	if err != nil {
+		return "", "", fmt.Errorf("invalid key: %v", err)
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("empty key")
+	}
+	p.i += end + 1
+	p.space()
+
+	var value string
+	switch {
+	case p.i < len(p.s) && p.s[p.i] == '"':
+		value, err = p.quoted()
+	case p.i < len(p.s) && (p.s[p.i] == '{' || p.s[p.i] == '['):
+		value, err = p.json()
+	default:
+		end := strings.IndexByte(p.s[p.i:], ',')
+		if end < 0 {
+			end = len(p.s) - p.i
+		}
+		value, err = url.PathUnescape(strings.TrimSpace(p.s[p.i : p.i+end]))
+		if err != nil {
+			err = fmt.Errorf(`invalid value of "%s": %v`, key, err)
+		}
+		p.i += end
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	p.space()
+	if p.i < len(p.s) {
+		if p.s[p.i] != ',' {
+			return "", "", fmt.Errorf(`unexpected "%c" after the value of "%s"`, p.s[p.i], key)
+		}
+		p.i++
+	}
+	return key, value, nil
+}
+
+// quoted scans the quoted value at the position.
+func (p *parser) quoted() (string, error) {
+	var b strings.Builder
+	var err error
+	for i := p.i + 1; i < len(p.s); i++ {
+		switch c := p.s[i]; c {
+		case '\\':
+			if i+1 == len(p.s) {
+				continue
+			}
+			if p.s[i+1] != '"' && p.s[i+1] != '\\' {
+				// the value is skipped up to its closing quote
+				err = fmt.Errorf(`invalid escape "\\%c" in the quoted value`, p.s[i+1])
+			}
+			i++
+			b.WriteByte(p.s[i])
+		case '"':
+			p.i = i + 1
+			return b.String(), err
+		default:
+			b.WriteByte(c)
+		}
+	}
+	p.i = len(p.s)
+	return "", fmt.Errorf("unterminated quoted value")
+}
+
+// json scans the json object or array at the position, it is compacted.
+func (p *parser) json() (string, error) {
+	depth, inString := 0, false
+	for i := p.i; i < len(p.s); i++ {
+		c := p.s[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			var compacted bytes.Buffer
+			err := json.Compact(&compacted, []byte(p.s[p.i:i+1]))
+			p.i = i + 1
+			if err != nil {
+				return "", fmt.Errorf("invalid json value: %v", err)
+			}
+			return compacted.String(), nil
+		}
+	}
+	p.i = len(p.s)
+	return "", fmt.Errorf("unterminated json value")
+}
@@ -0,0 +1,156 @@
+package logs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParse(t *testing.T) {
+	Convey("The entries of the analytics headers are parsed", t, func() {
+		values, errs := parse(`brand=apple, price="10,20", tags={"new": true}, brand=samsung`)
+		So(errs, ShouldBeEmpty)
+		So(values, ShouldResemble, map[string][]string{
+			"brand": {"apple", "samsung"},
+			"price": {"10,20"},
+			"tags":  {`{"new":true}`},
+		})
+	})
+
+	Convey("The keys and the values are url decoded", t, func() {
+		values, errs := parse(`product%20name=iphone%2C%20black`)
+		So(errs, ShouldBeEmpty)
+		So(values, ShouldResemble, map[string][]string{"product name": {"iphone, black"}})
+	})
+
+	Convey("The quotes and the backslashes are escaped in the quoted values", t, func() {
+		values, errs := parse(`title="the \"best\" \\ phone"`)
+		So(errs, ShouldBeEmpty)
+		So(values["title"], ShouldResemble, []string{`the "best" \ phone`})
+	})
+
+	Convey("The json values may be nested and contain the separators", t, func() {
+		values, errs := parse(`range=[{"gte": 10, "note": "a,b}"}, 20], size=M`)
+		So(errs, ShouldBeEmpty)
+		So(values["range"], ShouldResemble, []string{`[{"gte":10,"note":"a,b}"},20]`})
+		So(values["size"], ShouldResemble, []string{"M"})
+	})
+
+	Convey("The malformed entries are reported and the others are kept", t, func() {
+		for header, key := range map[string]string{
+			`color, size=M`:                   "size",
+			`=red, size=M`:                    "size",
+			`color=%zz, size=M`:               "size",
+			`color="red\n, blue", size=M`:     "size",
+			`color="red"blue, size=M`:         "size",
+			`color={"red": }, size=M`:         "size",
+			`color={"red": [1, 2}, size=M`:    "",
+			`size=M, color="red`:              "size",
+			`color={"a": "b", "c": 1, size=M`: "",
+		} {
+			values, errs := parse(header)
+			So(errs, ShouldHaveLength, 1)
+			if key == "" {
+				So(values, ShouldBeNil)
+				continue
+			}
+			So(values, ShouldResemble, map[string][]string{key: {"M"}})
+		}
+	})
+
+	Convey("The position of the malformed entries is reported", t, func() {
+		_, errs := parse(`size=M, color`)
+		So(errs, ShouldHaveLength, 1)
+		So(errs[0].Error(), ShouldEqual, `entry 2: missing "=" after the key`)
+	})
+
+	Convey("Any truncation of a header is parsed without failing", t, func() {
+		header := `a%20b="x,\"y\"", c={"d": ["e", {"f": "}"}]}, g=h%2C, a%20b=1`
+		for i := range header {
+			So(func() { parse(header[:i]) }, ShouldNotPanic)
+		}
+	})
+}
+
+func TestParseEvents(t *testing.T) {
+	Convey("The searches without the analytics headers have no events", t, func() {
+		So(parseEvents(http.Header{}, nil), ShouldBeNil)
+	})
+
+	Convey("The filters and the custom events are parsed from their headers", t, func() {
+		header := http.Header{}
+		header.Set(filtersHeader, "brand=apple")
+		header.Add(customEventHeader, "platform=ios")
+		header.Add(customEventHeader, "platform=web, color")
+		e := parseEvents(header, nil)
+		So(e.Filters, ShouldResemble, map[string][]string{"brand": {"apple"}})
+		So(e.CustomEvents, ShouldResemble, map[string][]string{"platform": {"ios", "web"}})
+		So(e.Errors, ShouldResemble, []string{customEventHeader + `: entry 3: missing "=" after the key`})
+	})
+
+	Convey("The names of the custom events are validated", t, func() {
+		header := http.Header{}
+		header.Set(customEventHeader, "platform=ios, user%20agent=safari, "+strings.Repeat("a", 65)+"=1")
+		e := parseEvents(header, nil)
+		So(e.CustomEvents, ShouldResemble, map[string][]string{"platform": {"ios"}})
+		So(e.rejected, ShouldResemble, []string{"custom_events", "custom_events"})
+	})
+
+	Convey("The click positions are validated and clamped to the hits", t, func() {
+		total := int64(10)
+		for position, expected := range map[string]int64{"3": 3, "10": 10, "11": 10, "0": 0, "-1": 0, "first": 0} {
+			header := http.Header{}
+			header.Set(clickHeader, "true")
+			header.Set(clickPositionHeader, position)
+			e := parseEvents(header, &total)
+			So(e.ClickPosition, ShouldEqual, expected)
+			switch {
+			case expected == 0:
+				So(e.rejected, ShouldResemble, []string{"click_position"})
+			case position == "11":
+				So(e.clamped, ShouldResemble, []string{"click_position"})
+			default:
+				So(e.Errors, ShouldBeEmpty)
+			}
+		}
+
+		header := http.Header{}
+		header.Set(clickPositionHeader, "3")
+		e := parseEvents(header, nil)
+		So(e.ClickPosition, ShouldEqual, 0)
+		So(e.rejected, ShouldResemble, []string{"click_position"})
+	})
+
+	Convey("The conversions are only recorded after a click", t, func() {
+		header := http.Header{}
+		header.Set(conversionHeader, "true")
+		e := parseEvents(header, nil)
+		So(e.Conversion, ShouldBeFalse)
+		So(e.rejected, ShouldResemble, []string{"conversion"})
+
+		header.Set(clickHeader, "true")
+		e = parseEvents(header, nil)
+		So(e.Click, ShouldBeTrue)
+		So(e.Conversion, ShouldBeTrue)
+		So(e.Errors, ShouldBeEmpty)
+
+		header.Set(clickHeader, "yes")
+		e = parseEvents(header, nil)
+		So(e.rejected, ShouldResemble, []string{"click", "conversion"})
+	})
+}
+
+func TestValidation(t *testing.T) {
+	Convey("The rejected and the clamped values are counted per field", t, func() {
+		var v validation
+		So(v.report(), ShouldResemble, validationStatus{Rejected: map[string]int64{}, Clamped: map[string]int64{}})
+		v.count(&events{rejected: []string{"conversion", "filters"}, clamped: []string{"click_position"}})
+		v.count(&events{rejected: []string{"conversion"}})
+		So(v.report(), ShouldResemble, validationStatus{
+			Rejected: map[string]int64{"conversion": 2, "filters": 1},
+			Clamped:  map[string]int64{"click_position": 1},
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package logs
 
 import (
+	"encoding/json"
 	"net/http"
 
 	log "github.com/sirupsen/logrus"
@@ -33,3 +34,32 @@ func (l *Logs) getLogs() http.HandlerFunc {
 		util.WriteBackRaw(w, raw, http.StatusOK)
 	}
 }
+
+func (l *Logs) getRetention() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		status := retentionStatus{}
+		if l.retention != nil {
+			status = l.retention.report()
+		}
+
+		raw, err := json.Marshal(status)
+		if err != nil {
+			log.Errorln(logTag, ": error encoding the logs retention status :", err)
+			util.WriteBackError(w, "error encoding the logs retention status", http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (l *Logs) getValidation() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := json.Marshal(l.validation.report())
+		if err != nil {
+			log.Errorln(logTag, ": error encoding the analytics headers validation status :", err)
+			util.WriteBackError(w, "error encoding the analytics headers validation status", http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
@@ -2,18 +2,24 @@ package logs
 
 import (
 	"os"
+	"strconv"
 	"sync"
 
+	log "github.com/sirupsen/logrus"
+
 	"github.com/appbaseio/arc/middleware"
 	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util/indexwatch"
 )
 
 const (
-	logTag             = "[logs]"
-	defaultLogsEsIndex = ".logs"
-	envEsURL           = "ES_CLUSTER_URL"
-	envLogsEsIndex     = "LOGS_ES_INDEX"
-	config             = `
+	logTag                 = "[logs]"
+	defaultLogsEsIndex     = ".logs"
+	envEsURL               = "ES_CLUSTER_URL"
+	envLogsEsIndex         = "LOGS_ES_INDEX"
+	envMaxResponseBody     = "LOGS_MAX_RESPONSE_BODY_SIZE"
+	defaultMaxResponseBody = 1 << 20
+	config                 = `
 	{
 	  "settings": {
 	    "number_of_shards": %d,
@@ -29,14 +35,19 @@ var (
 
 // Logs plugin records an elasticsearch request and its response.
 type Logs struct {
-	es logsService
+	es              logsService
+	maxResponseBody int
+	// retention is nil unless the records expire.
+	retention *retention
+	// validation counts the malformed analytics headers of the searches.
+	validation validation
 }
 
 // Instance returns the singleton instance of Logs plugin.
 // Note: Only this function must be used (both within and outside the package) to
 // obtain the instance Logs in order to avoid stateless instances of the plugin.
 func Instance() *Logs {
-	once.Do(func() { singleton = &Logs{} })
+	once.Do(func() { singleton = &Logs{maxResponseBody: defaultMaxResponseBody} })
 	return singleton
 }
 
@@ -54,12 +65,31 @@ func (l *Logs) InitFunc() error {
 		indexName = defaultLogsEsIndex
 	}
 
+	if value := os.Getenv(envMaxResponseBody); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil {
+			log.Errorln(logTag, ": invalid value for", envMaxResponseBody, ":", err)
+		} else {
+			l.maxResponseBody = size
+		}
+	}
+
 	// initialize the elasticsearch client
 	var err error
 	l.es, err = initPlugin(indexName, config)
 	if err != nil {
 		return err
 	}
+	indexwatch.Subscribe(l.recordIndexEvent)
+
+	// expire the records older than the retention
+	l.retention, err = retentionFromEnv(indexName)
+	if err != nil {
+		return err
+	}
+	if l.retention != nil {
+		go l.retention.every(l.es)
+	}
 
 	return nil
 }
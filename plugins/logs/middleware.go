@@ -1,11 +1,8 @@
 package logs
 
 import (
-	"bytes"
 	"context"
-	"io/ioutil"
 	"net/http"
-	"net/http/httptest"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -17,6 +14,7 @@ import (
 	"github.com/appbaseio/arc/model/index"
 	"github.com/appbaseio/arc/plugins/auth"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/indexwatch"
 )
 
 type chain struct {
@@ -41,7 +39,7 @@ func list() []middleware.Middleware {
 
 func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		requestCategory := category.User
+		requestCategory := category.Logs
 
 		ctx := category.NewContext(req.Context(), &requestCategory)
 		req = req.WithContext(ctx)
@@ -58,18 +56,34 @@ type Request struct {
 }
 
 type Response struct {
-	Code    int    `json:"code"`
-	Status  string `json:"status"`
-	Headers map[string][]string
-	Body    string `json:"body"`
+	Code      int    `json:"code"`
+	Status    string `json:"status"`
+	Headers   map[string][]string
+	Body      string `json:"body"`
+	Truncated bool   `json:"truncated,omitempty"`
+	// TotalHits is the total of the hits of the search responses, whichever
+	// the version of elasticsearch that served them.
+	TotalHits *int64 `json:"total_hits,omitempty"`
 }
 
+// intentHeader carries the intent of the searches in their response, as set
+// by the intent plugin.
+const intentHeader = "X-Search-Intent"
+
 type record struct {
-	Indices   []string          `json:"indices"`
-	Category  category.Category `json:"category"`
-	Request   Request           `json:"request"`
-	Response  Response          `json:"response"`
-	Timestamp time.Time         `json:"timestamp"`
+	Indices  []string          `json:"indices"`
+	Category category.Category `json:"category"`
+	// Intent is the intent the search was tagged with, if any.
+	Intent string `json:"intent,omitempty"`
+	// Events are the filters, the custom events and the click of the
+	// analytics headers the search was tagged with, if any.
+	Events *events `json:"events,omitempty"`
+	// Event is set for the changes of the indices of the cluster, which
+	// aren't made through arc, e.g. "index.created".
+	Event     string    `json:"event,omitempty"`
+	Request   Request   `json:"request"`
+	Response  Response  `json:"response"`
+	Timestamp time.Time `json:"timestamp"`
 }
 
 // Recorder records a log "record" for every request.
@@ -86,15 +100,13 @@ func (l *Logs) recorder(h http.HandlerFunc) http.HandlerFunc {
 		}
 
 		// Read the request body
-		reqBody, err := ioutil.ReadAll(r.Body)
+		reqBody, err := util.ReadRequestBody(r)
 		if err != nil {
 			log.Errorln(logTag, ": unable to read request body: ", err)
-			util.WriteBackError(w, "Can't read request body", http.StatusInternalServerError)
+			util.WriteBackBodyError(w, err)
 			return
 		}
 
-		r.Body = ioutil.NopCloser(bytes.NewBuffer(reqBody))
-
 		var headers = make(map[string][]string)
 
 		for key, values := range r.Header {
@@ -107,23 +119,24 @@ func (l *Logs) recorder(h http.HandlerFunc) http.HandlerFunc {
 			Body:    string(reqBody),
 			Method:  r.Method,
 		}
-		// Serve using response recorder
-		respRecorder := httptest.NewRecorder()
-		h(respRecorder, r)
-
-		// Copy the response to writer
-		for k, v := range respRecorder.Header() {
-			w.Header()[k] = v
+		// Serve the client directly while retaining a bounded copy of the response
+		tee := util.NewTeeResponseWriter(w, l.maxResponseBody)
+		h(tee, r)
+
+		response := Response{
+			Code:      tee.Code(),
+			Status:    http.StatusText(tee.Code()),
+			Headers:   w.Header().Clone(),
+			Body:      string(tee.Body()),
+			Truncated: tee.Truncated(),
 		}
-		w.WriteHeader(respRecorder.Code)
-		w.Write(respRecorder.Body.Bytes())
 
 		// Record the document
-		go l.recordResponse(&request, respRecorder, r)
+		go l.recordResponse(&request, &response, r)
 	}
 }
 
-func (l *Logs) recordResponse(request *Request, w *httptest.ResponseRecorder, req *http.Request) {
+func (l *Logs) recordResponse(request *Request, response *Response, req *http.Request) {
 	ctx := req.Context()
 
 	reqCategory, err := category.FromContext(ctx)
@@ -142,21 +155,32 @@ func (l *Logs) recordResponse(request *Request, w *httptest.ResponseRecorder, re
 	rec.Indices = reqIndices
 	rec.Category = *reqCategory
 	rec.Timestamp = time.Now()
-
-	// record request
 	rec.Request = *request
+	rec.Response = *response
+	if rec.Category == category.Search && !response.Truncated {
+		if total, err := util.ParseTotalHits([]byte(response.Body)); err == nil {
+			rec.Response.TotalHits = &total.Value
+		}
+	}
+	if rec.Category == category.Search {
+		rec.Events = parseEvents(request.Headers, rec.Response.TotalHits)
+		if rec.Events != nil && len(rec.Events.Errors) > 0 {
+			l.validation.count(rec.Events)
+			log.Warnln(logTag, ": malformed analytics headers:", rec.Events.Errors)
+		}
+	}
+	rec.Intent = http.Header(response.Headers).Get(intentHeader)
+	l.es.indexRecord(context.Background(), rec)
+}
 
-	// record response
-	response := w.Result()
-	rec.Response.Code = response.StatusCode
-	rec.Response.Status = http.StatusText(response.StatusCode)
-	rec.Response.Headers = response.Header
-
-	responseBody, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		log.Errorln(logTag, "can't read response body: ", err)
-		return
+// recordIndexEvent records the creation or the deletion of an index
+// detected by the index watcher.
+func (l *Logs) recordIndexEvent(e indexwatch.Event) {
+	rec := record{
+		Indices:   []string{e.Index},
+		Category:  category.Indices,
+		Event:     string(e.Type),
+		Timestamp: e.Timestamp,
 	}
-	rec.Response.Body = string(responseBody)
 	l.es.indexRecord(context.Background(), rec)
 }
@@ -0,0 +1,92 @@
+package logs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+)
+
+// capturingLogs keeps the records indexed.
+type capturingLogs struct {
+	memoryLogs
+	indexed []record
+}
+
+func (c *capturingLogs) indexRecord(ctx context.Context, r record) {
+	c.indexed = append(c.indexed, r)
+}
+
+func TestRecordResponse(t *testing.T) {
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/products/_search", nil)
+		search := category.Search
+		ctx := category.NewContext(req.Context(), &search)
+		return req.WithContext(index.NewContext(ctx, []string{"products"}))
+	}
+
+	Convey("The intent of the searches is recorded", t, func() {
+		es := &capturingLogs{}
+		l := &Logs{es: es}
+		header := http.Header{}
+		header.Set(intentHeader, "support")
+		l.recordResponse(&Request{URI: "/products/_search"}, &Response{Code: http.StatusOK, Headers: header}, newRequest())
+		So(es.indexed, ShouldHaveLength, 1)
+		So(es.indexed[0].Intent, ShouldEqual, "support")
+		So(es.indexed[0].Indices, ShouldResemble, []string{"products"})
+	})
+
+	Convey("The total hits of the searches are recorded", t, func() {
+		for body, total := range map[string]int64{
+			`{"hits": {"total": 12, "hits": []}}`:                                  12,
+			`{"hits": {"total": {"value": 10000, "relation": "gte"}, "hits": []}}`: 10000,
+		} {
+			es := &capturingLogs{}
+			l := &Logs{es: es}
+			l.recordResponse(&Request{}, &Response{Code: http.StatusOK, Body: body}, newRequest())
+			So(*es.indexed[0].Response.TotalHits, ShouldEqual, total)
+		}
+
+		es := &capturingLogs{}
+		l := &Logs{es: es}
+		l.recordResponse(&Request{}, &Response{Code: http.StatusOK, Body: `{"hits": {"total": 12`, Truncated: true}, newRequest())
+		So(es.indexed[0].Response.TotalHits, ShouldBeNil)
+	})
+
+	Convey("The searches without an intent are recorded without one", t, func() {
+		es := &capturingLogs{}
+		l := &Logs{es: es}
+		l.recordResponse(&Request{}, &Response{Code: http.StatusOK, Headers: http.Header{}}, newRequest())
+		So(es.indexed, ShouldHaveLength, 1)
+		So(es.indexed[0].Intent, ShouldBeEmpty)
+	})
+
+	Convey("The analytics headers of the searches are recorded", t, func() {
+		es := &capturingLogs{}
+		l := &Logs{es: es}
+		header := http.Header{}
+		header.Set(filtersHeader, `brand=apple, price="10,20"`)
+		header.Set(customEventHeader, "platform=ios, =web")
+		l.recordResponse(&Request{Headers: header}, &Response{Code: http.StatusOK}, newRequest())
+		So(es.indexed[0].Events.Filters, ShouldResemble, map[string][]string{"brand": {"apple"}, "price": {"10,20"}})
+		So(es.indexed[0].Events.CustomEvents, ShouldResemble, map[string][]string{"platform": {"ios"}})
+		So(es.indexed[0].Events.Errors, ShouldHaveLength, 1)
+	})
+
+	Convey("The malformed analytics headers of the searches are counted", t, func() {
+		es := &capturingLogs{}
+		l := &Logs{es: es}
+		header := http.Header{}
+		header.Set(clickHeader, "true")
+		header.Set(clickPositionHeader, "20")
+		body := `{"hits": {"total": 12, "hits": []}}`
+		l.recordResponse(&Request{Headers: header}, &Response{Code: http.StatusOK, Body: body}, newRequest())
+		So(es.indexed[0].Events.ClickPosition, ShouldEqual, 12)
+		So(l.validation.report().Clamped, ShouldResemble, map[string]int64{"click_position": 1})
+	})
+}
@@ -0,0 +1,253 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/health"
+	"github.com/appbaseio/arc/util/objectstore"
+)
+
+const (
+	envRetention              = "LOGS_RETENTION"
+	envRetentionInterval      = "LOGS_RETENTION_INTERVAL"
+	envArchiveURL             = "LOGS_ARCHIVE_URL"
+	envArchiveBatchSize       = "LOGS_ARCHIVE_BATCH_SIZE"
+	defaultRetentionInterval  = time.Hour
+	defaultArchiveBatchSize   = 5000
+	maxArchiveBatchSize       = 10000
+	archiveContentType        = "application/x-ndjson"
+	archiveContentEncoding    = "gzip"
+	archiveKeyTimestampLayout = "20060102T150405.000000000Z"
+	archiveKeyDirectoryLayout = "2006/01/02"
+)
+
+// archiver uploads the archives of the expired records.
+type archiver interface {
+	Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error
+}
+
+// retention deletes the records older than the max age every interval,
+// archiving them beforehand if an archive is configured.
+type retention struct {
+	maxAge    time.Duration
+	interval  time.Duration
+	batchSize int
+	archive   archiver
+	// prefix of the keys of the archives, i.e. the name of the logs index.
+	prefix string
+
+	mu     sync.Mutex
+	status retentionStatus
+}
+
+// retentionStatus reports the runs of the retention job.
+type retentionStatus struct {
+	Enabled  bool   `json:"enabled"`
+	MaxAge   string `json:"max_age,omitempty"`
+	Interval string `json:"interval,omitempty"`
+	Archive  string `json:"archive,omitempty"`
+	Running  bool   `json:"running"`
+	// the outcome of the last run
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	LastSuccess  *time.Time `json:"last_success,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	LastArchived int64      `json:"last_archived"`
+	LastDeleted  int64      `json:"last_deleted"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+	// the totals since arc started
+	TotalArchived int64 `json:"total_archived"`
+	TotalDeleted  int64 `json:"total_deleted"`
+}
+
+// retentionFromEnv returns the retention configured by the env, nil if the
+// records are kept forever.
+func retentionFromEnv(indexName string) (*retention, error) {
+	value := os.Getenv(envRetention)
+	if value == "" {
+		return nil, nil
+	}
+	maxAge, err := time.ParseDuration(value)
+	if err != nil || maxAge <= 0 {
+		return nil, fmt.Errorf("invalid value for %s: %s, must be a positive duration", envRetention, value)
+	}
+
+	r := &retention{
+		maxAge:    maxAge,
+		interval:  defaultRetentionInterval,
+		batchSize: defaultArchiveBatchSize,
+		prefix:    strings.TrimPrefix(indexName, "."),
+	}
+	if value := os.Getenv(envRetentionInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be a positive duration", envRetentionInterval, value)
+		}
+		r.interval = d
+	}
+	if value := os.Getenv(envArchiveBatchSize); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxArchiveBatchSize {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be an integer between 1 and %d", envArchiveBatchSize, value, maxArchiveBatchSize)
+		}
+		r.batchSize = n
+	}
+	r.status = retentionStatus{Enabled: true, MaxAge: maxAge.String(), Interval: r.interval.String()}
+	if value := os.Getenv(envArchiveURL); value != "" {
+		store, err := objectstore.Open(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %v", envArchiveURL, err)
+		}
+		r.archive = store
+		r.status.Archive = store.String()
+	}
+	return r, nil
+}
+
+// every runs the retention job every interval.
+func (r *retention) every(es logsService) {
+	health.Register("logs.retention", r.health, false)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	r.scheduleNext(time.Now())
+	for now := range ticker.C {
+		r.run(context.Background(), es, now)
+		r.scheduleNext(now)
+	}
+}
+
+func (r *retention) scheduleNext(now time.Time) {
+	next := now.Add(r.interval)
+	r.mu.Lock()
+	r.status.NextRun = &next
+	r.mu.Unlock()
+}
+
+// run deletes the records older than the max age, after uploading them to the
+// archive in batches, if any. A batch is only deleted once it is archived.
+func (r *retention) run(ctx context.Context, es logsService, now time.Time) {
+	r.mu.Lock()
+	if r.status.Running {
+		r.mu.Unlock()
+		return
+	}
+	r.status.Running = true
+	r.mu.Unlock()
+
+	archived, deleted, err := r.expire(ctx, es, now.Add(-r.maxAge))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.Running = false
+	r.status.LastRun = &now
+	r.status.LastArchived, r.status.LastDeleted = archived, deleted
+	r.status.TotalArchived += archived
+	r.status.TotalDeleted += deleted
+	if err != nil {
+		log.Errorln(logTag, ": unable to expire the records older than", r.maxAge, ":", err)
+		r.status.LastError = err.Error()
+		return
+	}
+	r.status.LastError = ""
+	r.status.LastSuccess = &now
+	if deleted > 0 {
+		log.Println(logTag, ": deleted", deleted, "records older than", r.maxAge, "of which", archived, "were archived")
+	}
+}
+
+func (r *retention) expire(ctx context.Context, es logsService, before time.Time) (archived, deleted int64, err error) {
+	if r.archive == nil {
+		deleted, err = es.deleteExpiredRecords(ctx, before)
+		return 0, deleted, err
+	}
+
+	for {
+		records, err := es.expiredRecords(ctx, before, r.batchSize)
+		if err != nil {
+			return archived, deleted, err
+		}
+		if len(records) == 0 {
+			return archived, deleted, nil
+		}
+
+		key, body, err := archiveOf(r.prefix, records)
+		if err != nil {
+			return archived, deleted, err
+		}
+		if err := r.archive.Put(ctx, key, body, archiveContentType, archiveContentEncoding); err != nil {
+			return archived, deleted, err
+		}
+		archived += int64(len(records))
+
+		ids := make([]string, len(records))
+		for i, rec := range records {
+			ids[i] = rec.ID
+		}
+		n, err := es.deleteRecords(ctx, ids)
+		deleted += n
+		if err != nil {
+			return archived, deleted, err
+		}
+	}
+}
+
+// archiveOf returns the key and the gzipped ndjson of a batch of records. The
+// key is named after the timestamps of the first and the last records, so
+// that a batch archived again after its deletion failed replaces its archive.
+func archiveOf(prefix string, records []storedRecord) (string, []byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	for _, rec := range records {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, rec.Source); err != nil {
+			return "", nil, fmt.Errorf("invalid record %s: %v", rec.ID, err)
+		}
+		compact.WriteByte('\n')
+		if _, err := zw.Write(compact.Bytes()); err != nil {
+			return "", nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return "", nil, err
+	}
+
+	first, last := timestampOf(records[0]), timestampOf(records[len(records)-1])
+	key := fmt.Sprintf("%s/%s/%s-%s.ndjson.gz", prefix, first.Format(archiveKeyDirectoryLayout),
+		first.Format(archiveKeyTimestampLayout), last.Format(archiveKeyTimestampLayout))
+	return key, buf.Bytes(), nil
+}
+
+func timestampOf(rec storedRecord) time.Time {
+	var r struct {
+		Timestamp time.Time `json:"timestamp"`
+	}
+	json.Unmarshal(rec.Source, &r)
+	return r.Timestamp.UTC()
+}
+
+// report returns the status of the retention job.
+func (r *retention) report() retentionStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// health reports the error of the last run of the retention job.
+func (r *retention) health() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.LastError != "" {
+		return fmt.Errorf("the last run of the logs retention failed: %s", r.status.LastError)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package logs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// memoryLogs keeps the records in memory, keyed by id.
+type memoryLogs struct {
+	records    map[string]time.Time
+	failDelete bool
+}
+
+func (m *memoryLogs) getRawLogs(ctx context.Context, from, size, filter string, indices ...string) ([]byte, error) {
+	return nil, nil
+}
+
+func (m *memoryLogs) indexRecord(ctx context.Context, r record) {}
+
+func (m *memoryLogs) expiredRecords(ctx context.Context, before time.Time, size int) ([]storedRecord, error) {
+	var ids []string
+	for id, t := range m.records {
+		if t.Before(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return m.records[ids[i]].Before(m.records[ids[j]]) })
+	var records []storedRecord
+	for _, id := range ids {
+		if len(records) == size {
+			break
+		}
+		source := fmt.Sprintf(`{"indices": ["products"], "timestamp": "%s"}`, m.records[id].Format(time.RFC3339Nano))
+		records = append(records, storedRecord{ID: id, Source: []byte(source)})
+	}
+	return records, nil
+}
+
+func (m *memoryLogs) deleteRecords(ctx context.Context, ids []string) (int64, error) {
+	if m.failDelete {
+		return 0, errors.New("cluster_block_exception")
+	}
+	for _, id := range ids {
+		delete(m.records, id)
+	}
+	return int64(len(ids)), nil
+}
+
+func (m *memoryLogs) deleteExpiredRecords(ctx context.Context, before time.Time) (int64, error) {
+	var deleted int64
+	for id, t := range m.records {
+		if t.Before(before) {
+			delete(m.records, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+type memoryArchive struct {
+	objects map[string][]byte
+	fail    bool
+}
+
+func (m *memoryArchive) Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error {
+	if m.fail {
+		return errors.New("AccessDenied")
+	}
+	m.objects[key] = body
+	return nil
+}
+
+func TestRetention(t *testing.T) {
+	now := time.Date(2019, 1, 10, 0, 0, 0, 0, time.UTC)
+	newLogs := func() *memoryLogs {
+		m := &memoryLogs{records: make(map[string]time.Time)}
+		for i := 0; i < 5; i++ {
+			m.records[fmt.Sprintf("old-%d", i)] = now.Add(-48*time.Hour + time.Duration(i)*time.Minute)
+		}
+		m.records["recent"] = now.Add(-time.Hour)
+		return m
+	}
+	gunzip := func(body []byte) string {
+		zr, err := gzip.NewReader(bytes.NewReader(body))
+		So(err, ShouldBeNil)
+		raw, err := ioutil.ReadAll(zr)
+		So(err, ShouldBeNil)
+		return string(raw)
+	}
+
+	Convey("The expired records are deleted", t, func() {
+		es := newLogs()
+		r := &retention{maxAge: 24 * time.Hour}
+		r.run(context.Background(), es, now)
+		So(es.records, ShouldHaveLength, 1)
+		So(r.report().LastDeleted, ShouldEqual, 5)
+		So(r.report().LastSuccess, ShouldNotBeNil)
+	})
+
+	Convey("The expired records are archived in batches before their deletion", t, func() {
+		es := newLogs()
+		archive := &memoryArchive{objects: make(map[string][]byte)}
+		r := &retention{maxAge: 24 * time.Hour, batchSize: 2, archive: archive, prefix: "logs"}
+		r.run(context.Background(), es, now)
+		So(es.records, ShouldHaveLength, 1)
+		So(archive.objects, ShouldHaveLength, 3)
+		status := r.report()
+		So(status.LastArchived, ShouldEqual, 5)
+		So(status.LastDeleted, ShouldEqual, 5)
+
+		body, ok := archive.objects["logs/2019/01/08/20190108T000000.000000000Z-20190108T000100.000000000Z.ndjson.gz"]
+		So(ok, ShouldBeTrue)
+		lines := strings.Split(strings.TrimSpace(gunzip(body)), "\n")
+		So(lines, ShouldHaveLength, 2)
+		So(lines[0], ShouldEqual, `{"indices":["products"],"timestamp":"2019-01-08T00:00:00Z"}`)
+	})
+
+	Convey("The records aren't deleted unless they are archived", t, func() {
+		es := newLogs()
+		r := &retention{maxAge: 24 * time.Hour, batchSize: 2, archive: &memoryArchive{fail: true}}
+		r.run(context.Background(), es, now)
+		So(es.records, ShouldHaveLength, 6)
+		So(r.report().LastError, ShouldEqual, "AccessDenied")
+		So(r.health(), ShouldNotBeNil)
+	})
+
+	Convey("The failed deletions are reported", t, func() {
+		es := newLogs()
+		es.failDelete = true
+		archive := &memoryArchive{objects: make(map[string][]byte)}
+		r := &retention{maxAge: 24 * time.Hour, batchSize: 2, archive: archive}
+		r.run(context.Background(), es, now)
+		So(archive.objects, ShouldHaveLength, 1)
+		So(r.report().LastArchived, ShouldEqual, 2)
+		So(r.report().LastDeleted, ShouldEqual, 0)
+		So(r.report().LastError, ShouldEqual, "cluster_block_exception")
+	})
+}
@@ -16,6 +16,20 @@ func (l *Logs) routes() []plugins.Route {
 			HandlerFunc: middleware(l.getLogs()),
 			Description: "Returns the logs for an index",
 		},
+		{
+			Name:        "Get logs retention",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_logs/_retention",
+			HandlerFunc: middleware(l.getRetention()),
+			Description: "Returns the status of the retention of the logs",
+		},
+		{
+			Name:        "Get analytics headers validation",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_logs/_validation",
+			HandlerFunc: middleware(l.getValidation()),
+			Description: "Returns the number of analytics header values rejected or clamped",
+		},
 		{
 			Name:        "Get logs",
 			Methods:     []string{http.MethodGet},
@@ -1,8 +1,14 @@
 package logs
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 type logsService interface {
 	getRawLogs(ctx context.Context, from, size, filter string, indices ...string) ([]byte, error)
 	indexRecord(ctx context.Context, r record)
+	expiredRecords(ctx context.Context, before time.Time, size int) ([]storedRecord, error)
+	deleteRecords(ctx context.Context, ids []string) (int64, error)
+	deleteExpiredRecords(ctx context.Context, before time.Time) (int64, error)
 }
@@ -0,0 +1,171 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/gorilla/mux"
+)
+
+// status is the response of the maintenance apis, the fields of the window
+// are only set while it is in effect.
+type status struct {
+	Active bool `json:"active"`
+	*window
+}
+
+func writeBackStatus(w http.ResponseWriter, current *window, code int) {
+	raw, err := json.Marshal(status{Active: current != nil, window: current})
+	if err != nil {
+		msg := "error encoding the maintenance window"
+		log.Errorln(logTag, ":", msg, ":", err)
+		util.WriteBackError(w, msg, http.StatusInternalServerError)
+		return
+	}
+	util.WriteBackRaw(w, raw, code)
+}
+
+func (m *maintenance) getWindow() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		writeBackStatus(w, m.window(time.Now()), http.StatusOK)
+	}
+}
+
+// putWindow starts a maintenance window, replacing the one in effect.
+func (m *maintenance) putWindow() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		starter, _, _ := req.BasicAuth()
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		var next window
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &next); err != nil {
+				msg := "can't parse request body"
+				log.Errorln(logTag, ":", msg, ":", err)
+				util.WriteBackError(w, msg, http.StatusBadRequest)
+				return
+			}
+		}
+		next.StartedBy = starter
+		if err := next.init(time.Now()); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := m.start(req.Context(), &next); err != nil {
+			msg := "an error occurred while starting the maintenance window"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		log.Println(logTag, ":", starter, "started a maintenance window rejecting", next.Mode, "until", next.ExpiresAt)
+		writeBackStatus(w, &next, http.StatusOK)
+	}
+}
+
+func (m *maintenance) deleteWindow() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if err := m.end(req.Context()); err != nil {
+			msg := "an error occurred while ending the maintenance window"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		writeBackStatus(w, nil, http.StatusOK)
+	}
+}
+
+func (m *maintenance) getReadOnly() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := json.Marshal(m.readOnlyIndices())
+		if err != nil {
+			msg := "error encoding the read-only indices"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// putReadOnly marks the index, or index pattern, read-only. The body may
+// give the reason of the mark.
+func (m *maintenance) putReadOnly() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := strings.TrimSpace(mux.Vars(req)["index"])
+		if name == "" || name == "_all" || strings.Contains(name, ",") {
+			msg := fmt.Sprintf(`invalid index "%s", expected a single index or index pattern`, name)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		marker, _, _ := req.BasicAuth()
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		var mark readOnlyIndex
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &mark); err != nil {
+				msg := "can't parse request body"
+				log.Errorln(logTag, ":", msg, ":", err)
+				util.WriteBackError(w, msg, http.StatusBadRequest)
+				return
+			}
+		}
+		mark.Index = name
+		mark.MarkedBy = marker
+		mark.MarkedAt = time.Now()
+
+		if err := m.markReadOnly(req.Context(), mark); err != nil {
+			msg := "an error occurred while marking the index read-only"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		log.Println(logTag, ":", marker, "marked", name, "read-only")
+
+		raw, err := json.Marshal(mark)
+		if err != nil {
+			msg := "error encoding the read-only index"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (m *maintenance) deleteReadOnly() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["index"]
+
+		found, err := m.unmarkReadOnly(req.Context(), name)
+		if err != nil {
+			msg := "an error occurred while unmarking the read-only index"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			msg := fmt.Sprintf(`index "%s" isn't marked read-only`, name)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		log.Println(logTag, ":", name, "unmarked read-only")
+
+		msg := fmt.Sprintf(`index "%s" is no longer read-only`, name)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
@@ -0,0 +1,140 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util/state"
+)
+
+const (
+	logTag          = "[maintenance]"
+	stateKey        = "maintenance"
+	refreshInterval = 5 * time.Second
+)
+
+var (
+	singleton *maintenance
+	once      sync.Once
+)
+
+// maintenance rejects the requests made to elasticsearch with 503 during the
+// maintenance windows started by the admins, and the writes to the indices
+// they marked read-only. The window and the marks are kept in the shared
+// state, each instance periodically picks them up.
+type maintenance struct {
+	store state.Store
+
+	mu       sync.RWMutex
+	current  *window
+	readOnly map[string]readOnlyIndex
+
+	// updateMu serializes the updates of the read-only indices.
+	updateMu sync.Mutex
+}
+
+// Use only this function to fetch the instance of maintenance from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *maintenance {
+	once.Do(func() { singleton = &maintenance{} })
+	return singleton
+}
+
+func (m *maintenance) Name() string {
+	return logTag
+}
+
+func (m *maintenance) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+	m.store = state.Instance()
+
+	m.refresh()
+	go func() {
+		for range time.Tick(refreshInterval) {
+			m.refresh()
+		}
+	}()
+	return nil
+}
+
+func (m *maintenance) Routes() []plugins.Route {
+	return m.routes()
+}
+
+func (m *maintenance) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{m.reject, m.protect}
+}
+
+// window returns the maintenance window in effect, if any.
+func (m *maintenance) window(now time.Time) *window {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if !m.current.active(now) {
+		return nil
+	}
+	return m.current
+}
+
+func (m *maintenance) set(w *window) {
+	m.mu.Lock()
+	m.current = w
+	m.mu.Unlock()
+}
+
+// refresh loads the window started and the indices marked read-only via any
+// of the arc instances.
+func (m *maintenance) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), refreshInterval)
+	defer cancel()
+
+	readOnly, err := m.loadReadOnly(ctx)
+	if err != nil {
+		log.Errorln(logTag, ": unable to load the read-only indices :", err)
+	} else {
+		m.setReadOnly(readOnly)
+	}
+
+	raw, err := m.store.Get(ctx, stateKey)
+	if err == state.ErrNotFound {
+		m.set(nil)
+		return
+	}
+	if err != nil {
+		log.Errorln(logTag, ": unable to load the maintenance window :", err)
+		return
+	}
+	var w window
+	if err := json.Unmarshal(raw, &w); err != nil {
+		log.Errorln(logTag, ": unable to parse the maintenance window :", err)
+		return
+	}
+	m.set(&w)
+}
+
+// start stores the window until it expires.
+func (m *maintenance) start(ctx context.Context, w *window) error {
+	raw, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	if err := m.store.Set(ctx, stateKey, raw, time.Until(w.ExpiresAt)); err != nil {
+		return err
+	}
+	m.set(w)
+	return nil
+}
+
+// end removes the window before it expires.
+func (m *maintenance) end(ctx context.Context) error {
+	if err := m.store.Delete(ctx, stateKey); err != nil {
+		return err
+	}
+	m.set(nil)
+	return nil
+}
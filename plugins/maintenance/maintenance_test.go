@@ -0,0 +1,125 @@
+package maintenance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/util/state"
+)
+
+func TestWindow(t *testing.T) {
+	now := time.Date(2020, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+	Convey("The window rejects the writes for an hour by default", t, func() {
+		w := &window{}
+		So(w.init(now), ShouldBeNil)
+		So(w.Mode, ShouldEqual, ModeWrites)
+		So(w.ExpiresAt, ShouldEqual, now.Add(time.Hour))
+		So(w.rejects(op.Read), ShouldBeFalse)
+		So(w.rejects(op.Write), ShouldBeTrue)
+		So(w.rejects(op.Delete), ShouldBeTrue)
+		So(w.active(now.Add(59*time.Minute)), ShouldBeTrue)
+		So(w.active(now.Add(time.Hour)), ShouldBeFalse)
+		So(w.retryAfter(now.Add(59*time.Minute+500*time.Millisecond)), ShouldEqual, 60)
+	})
+
+	Convey("The window can reject all the requests", t, func() {
+		w := &window{Mode: ModeAll, Duration: "10m"}
+		So(w.init(now), ShouldBeNil)
+		So(w.rejects(op.Read), ShouldBeTrue)
+		So(w.ExpiresAt, ShouldEqual, now.Add(10*time.Minute))
+	})
+
+	Convey("Invalid windows are rejected", t, func() {
+		So((&window{Mode: "reads"}).init(now), ShouldNotBeNil)
+		So((&window{Duration: "48h"}).init(now), ShouldNotBeNil)
+		So((&window{Duration: "-1m"}).init(now), ShouldNotBeNil)
+	})
+}
+
+func TestReject(t *testing.T) {
+	m := &maintenance{store: state.NewMemoryStore()}
+	serve := func(reqOp op.Operation) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/products/_doc", nil)
+		req = req.WithContext(op.NewContext(req.Context(), &reqOp))
+		w := httptest.NewRecorder()
+		m.reject(func(w http.ResponseWriter, req *http.Request) {})(w, req)
+		return w
+	}
+
+	Convey("The requests are served outside of the windows", t, func() {
+		So(serve(op.Write).Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("The writes are rejected during the window", t, func() {
+		w := &window{Duration: "1m"}
+		So(w.init(time.Now()), ShouldBeNil)
+		So(m.start(context.Background(), w), ShouldBeNil)
+
+		rejected := serve(op.Write)
+		So(rejected.Code, ShouldEqual, http.StatusServiceUnavailable)
+		So(rejected.Header().Get("Retry-After"), ShouldEqual, "60")
+		So(serve(op.Read).Code, ShouldEqual, http.StatusOK)
+
+		Convey("The window is picked up from the shared state", func() {
+			other := &maintenance{store: m.store}
+			other.refresh()
+			So(other.window(time.Now()), ShouldNotBeNil)
+		})
+
+		Convey("The requests are served once the window ends", func() {
+			So(m.end(context.Background()), ShouldBeNil)
+			So(serve(op.Write).Code, ShouldEqual, http.StatusOK)
+		})
+	})
+}
+
+func TestProtect(t *testing.T) {
+	m := &maintenance{store: state.NewMemoryStore()}
+	serve := func(reqACL acl.ACL, reqOp op.Operation, indices []string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/_bulk", strings.NewReader(body))
+		ctx := op.NewContext(req.Context(), &reqOp)
+		ctx = acl.NewContext(ctx, &reqACL)
+		ctx = index.NewContext(ctx, indices)
+		req = req.WithContext(ctx)
+		w := httptest.NewRecorder()
+		m.protect(func(w http.ResponseWriter, req *http.Request) {})(w, req)
+		return w
+	}
+	if err := m.markReadOnly(context.Background(), readOnlyIndex{Index: "orders", Reason: "corrupted"}); err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("The writes to the read-only indices are rejected", t, func() {
+		So(serve(acl.Index, op.Write, []string{"orders"}, "").Code, ShouldEqual, http.StatusForbidden)
+		So(serve(acl.DeleteByQuery, op.Delete, []string{"ord*"}, "").Code, ShouldEqual, http.StatusForbidden)
+		So(serve(acl.Index, op.Write, []string{"products"}, "").Code, ShouldEqual, http.StatusOK)
+		So(serve(acl.Search, op.Read, []string{"orders"}, "").Code, ShouldEqual, http.StatusOK)
+	})
+
+	Convey("The bulk requests naming the read-only indices are rejected", t, func() {
+		body := `{"index":{"_index":"products"}}` + "\n" + `{}` + "\n" + `{"delete":{"_index":"orders","_id":"1"}}` + "\n"
+		w := serve(acl.Bulk, op.Write, nil, body)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(w.Body.String(), ShouldContainSubstring, "line 3")
+	})
+
+	Convey("The indices are writable once unmarked", t, func() {
+		found, err := m.unmarkReadOnly(context.Background(), "orders")
+		So(err, ShouldBeNil)
+		So(found, ShouldBeTrue)
+		So(serve(acl.Index, op.Write, []string{"orders"}, "").Code, ShouldEqual, http.StatusOK)
+
+		found, _ = m.unmarkReadOnly(context.Background(), "orders")
+		So(found, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,157 @@
+package maintenance
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The maintenance windows span the whole cluster, hence require cluster
+// level access.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clustersCategory := category.Clusters
+		ctx := category.NewContext(req.Context(), &clustersCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusterACL := acl.Cluster
+		ctx := acl.NewContext(req.Context(), &clusterACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the maintenance windows
+// affect all the credentials.
+var isAdmin = validate.Admin("manage the maintenance windows")
+
+// reject responds with 503 to the requests the maintenance window in effect
+// rejects, the Retry-After header tells when the window ends.
+func (m *maintenance) reject(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		now := time.Now()
+		current := m.window(now)
+		if current == nil {
+			h(w, req)
+			return
+		}
+		reqOp, err := op.FromContext(req.Context())
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, "an error occurred while checking the maintenance window", http.StatusInternalServerError)
+			return
+		}
+		if !current.rejects(*reqOp) {
+			h(w, req)
+			return
+		}
+		w.Header().Set("Retry-After", strconv.FormatInt(current.retryAfter(now), 10))
+		util.WriteBackError(w, current.message(), http.StatusServiceUnavailable)
+	}
+}
+
+// protect rejects with 403 the requests writing to or deleting the indices
+// marked read-only, including the bulk requests naming them in their body.
+func (m *maintenance) protect(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		patterns := m.readOnlyPatterns()
+		if len(patterns) == 0 {
+			h(w, req)
+			return
+		}
+		ctx := req.Context()
+		errMsg := "an error occurred while checking the read-only indices"
+		reqOp, err := op.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, errMsg, http.StatusInternalServerError)
+			return
+		}
+		if *reqOp == op.Read {
+			h(w, req)
+			return
+		}
+
+		var details []util.ErrorDetail
+		var denied []string
+		reqIndices, _ := index.FromContext(ctx)
+		for _, name := range reqIndices {
+			if index.Overlaps(patterns, name) {
+				denied = append(denied, name)
+				details = append(details, util.ErrorDetail{
+					Type:     "index_read_only",
+					Reason:   fmt.Sprintf("index %q is read-only", name),
+					Location: "path",
+				})
+			}
+		}
+		if reqACL, err := acl.FromContext(ctx); err == nil && *reqACL == acl.Bulk {
+			body, err := util.ReadRequestBody(req)
+			if err != nil {
+				util.WriteBackBodyError(w, err)
+				return
+			}
+			// malformed bodies are let through for elasticsearch to reject
+			named, _ := validate.IndicesInBody(*reqACL, body)
+			for _, i := range named {
+				if index.Overlaps(patterns, i.Name) {
+					denied = append(denied, i.Name)
+					details = append(details, util.ErrorDetail{
+						Type:     "index_read_only",
+						Reason:   fmt.Sprintf("index %q is read-only", i.Name),
+						Location: i.Location,
+					})
+				}
+			}
+		}
+		if len(details) > 0 {
+			msg := fmt.Sprintf("%v index/indices are read-only", denied)
+			util.WriteBackErrorWithDetails(w, msg, http.StatusForbidden, details)
+			return
+		}
+
+		h(w, req)
+	}
+}
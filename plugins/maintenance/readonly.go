@@ -0,0 +1,112 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/appbaseio/arc/util/state"
+)
+
+// readOnlyKey is the key of the read-only indices in the shared state.
+const readOnlyKey = "maintenance:read_only"
+
+// readOnlyIndex is an index, or index pattern, marked read-only at the arc
+// layer. The writes to it are rejected until it is unmarked, regardless of
+// its blocks in elasticsearch.
+type readOnlyIndex struct {
+	Index    string    `json:"index"`
+	Reason   string    `json:"reason,omitempty"`
+	MarkedBy string    `json:"marked_by,omitempty"`
+	MarkedAt time.Time `json:"marked_at"`
+}
+
+// readOnlyIndices returns the indices marked read-only sorted by name.
+func (m *maintenance) readOnlyIndices() []readOnlyIndex {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	indices := make([]readOnlyIndex, 0, len(m.readOnly))
+	for _, i := range m.readOnly {
+		indices = append(indices, i)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i].Index < indices[j].Index })
+	return indices
+}
+
+// readOnlyPatterns returns the names of the indices marked read-only.
+func (m *maintenance) readOnlyPatterns() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.readOnly) == 0 {
+		return nil
+	}
+	patterns := make([]string, 0, len(m.readOnly))
+	for name := range m.readOnly {
+		patterns = append(patterns, name)
+	}
+	return patterns
+}
+
+func (m *maintenance) setReadOnly(indices map[string]readOnlyIndex) {
+	m.mu.Lock()
+	m.readOnly = indices
+	m.mu.Unlock()
+}
+
+// loadReadOnly reads the indices marked read-only via any of the arc instances.
+func (m *maintenance) loadReadOnly(ctx context.Context) (map[string]readOnlyIndex, error) {
+	raw, err := m.store.Get(ctx, readOnlyKey)
+	if err == state.ErrNotFound {
+		return make(map[string]readOnlyIndex), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	indices := make(map[string]readOnlyIndex)
+	if err := json.Unmarshal(raw, &indices); err != nil {
+		return nil, err
+	}
+	return indices, nil
+}
+
+// updateReadOnly applies the update to the indices marked read-only and
+// stores them. The updates are serialized within the instance, the marks
+// made concurrently via another instance may be overwritten.
+func (m *maintenance) updateReadOnly(ctx context.Context, update func(map[string]readOnlyIndex)) error {
+	m.updateMu.Lock()
+	defer m.updateMu.Unlock()
+
+	indices, err := m.loadReadOnly(ctx)
+	if err != nil {
+		return err
+	}
+	update(indices)
+	raw, err := json.Marshal(indices)
+	if err != nil {
+		return err
+	}
+	if err := m.store.Set(ctx, readOnlyKey, raw, 0); err != nil {
+		return err
+	}
+	m.setReadOnly(indices)
+	return nil
+}
+
+// markReadOnly marks the index read-only, replacing its previous mark.
+func (m *maintenance) markReadOnly(ctx context.Context, i readOnlyIndex) error {
+	return m.updateReadOnly(ctx, func(indices map[string]readOnlyIndex) {
+		indices[i.Index] = i
+	})
+}
+
+// unmarkReadOnly lets the writes to the index through again, it reports
+// whether the index was marked.
+func (m *maintenance) unmarkReadOnly(ctx context.Context, name string) (bool, error) {
+	var found bool
+	err := m.updateReadOnly(ctx, func(indices map[string]readOnlyIndex) {
+		_, found = indices[name]
+		delete(indices, name)
+	})
+	return found, err
+}
@@ -0,0 +1,56 @@
+package maintenance
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (m *maintenance) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get maintenance window",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_maintenance",
+			HandlerFunc: middleware(isAdmin(m.getWindow())),
+			Description: "Returns the maintenance window in effect, if any",
+		},
+		{
+			Name:        "Start maintenance window",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_maintenance",
+			HandlerFunc: middleware(isAdmin(m.putWindow())),
+			Description: "Rejects the writes, or all the requests, made to elasticsearch until the window expires",
+		},
+		{
+			Name:        "End maintenance window",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_maintenance",
+			HandlerFunc: middleware(isAdmin(m.deleteWindow())),
+			Description: "Ends the maintenance window in effect",
+		},
+		{
+			Name:        "Get read-only indices",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_maintenance/read_only",
+			HandlerFunc: middleware(isAdmin(m.getReadOnly())),
+			Description: "Returns the indices marked read-only",
+		},
+		{
+			Name:        "Mark index read-only",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_maintenance/read_only/{index}",
+			HandlerFunc: middleware(isAdmin(m.putReadOnly())),
+			Description: "Rejects the writes to the index {index} until it is unmarked",
+		},
+		{
+			Name:        "Unmark read-only index",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_maintenance/read_only/{index}",
+			HandlerFunc: middleware(isAdmin(m.deleteReadOnly())),
+			Description: "Lets the writes to the index {index} through again",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,91 @@
+package maintenance
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/appbaseio/arc/model/op"
+)
+
+const (
+	defaultDuration = time.Hour
+	maxDuration     = 24 * time.Hour
+)
+
+// Modes of the maintenance windows.
+const (
+	// ModeWrites rejects the requests writing or deleting data, the reads
+	// are served as usual.
+	ModeWrites = "writes"
+	// ModeAll rejects all the requests made to elasticsearch.
+	ModeAll = "all"
+)
+
+// window is a maintenance window, it ends on its own once expired.
+type window struct {
+	Mode      string    `json:"mode"`
+	Message   string    `json:"message,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	StartedBy string    `json:"started_by,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// init validates the window requested to start now and sets its expiry.
+func (w *window) init(now time.Time) error {
+	switch w.Mode {
+	case "":
+		w.Mode = ModeWrites
+	case ModeWrites, ModeAll:
+	default:
+		return fmt.Errorf(`invalid "mode" "%s", expected "%s" or "%s"`, w.Mode, ModeWrites, ModeAll)
+	}
+	duration := defaultDuration
+	if w.Duration != "" {
+		d, err := time.ParseDuration(w.Duration)
+		if err != nil || d <= 0 || d > maxDuration {
+			return fmt.Errorf(`invalid "duration" "%s", expected a positive duration of at most %v`, w.Duration, maxDuration)
+		}
+		duration = d
+	}
+	w.Duration = duration.String()
+	w.StartedAt = now
+	w.ExpiresAt = now.Add(duration)
+	return nil
+}
+
+// active reports whether the window is in effect.
+func (w *window) active(now time.Time) bool {
+	return w != nil && now.Before(w.ExpiresAt)
+}
+
+// rejects reports whether the requests of the operation are rejected during
+// the window.
+func (w *window) rejects(reqOp op.Operation) bool {
+	return w.Mode == ModeAll || reqOp != op.Read
+}
+
+// retryAfter returns the number of seconds left until the window ends,
+// rounded up.
+func (w *window) retryAfter(now time.Time) int64 {
+	left := w.ExpiresAt.Sub(now)
+	seconds := int64(left / time.Second)
+	if left%time.Second > 0 {
+		seconds++
+	}
+	if seconds < 1 {
+		seconds = 1
+	}
+	return seconds
+}
+
+// message returns the reason the requests are rejected for.
+func (w *window) message() string {
+	if w.Message != "" {
+		return w.Message
+	}
+	if w.Mode == ModeAll {
+		return fmt.Sprintf("arc is under maintenance until %s", w.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	return fmt.Sprintf("arc is under maintenance until %s, only reads are served", w.ExpiresAt.UTC().Format(time.RFC3339))
+}
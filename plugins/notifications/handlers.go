@@ -0,0 +1,48 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/notify"
+)
+
+func (n *notifications) getDeadLetters() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := json.Marshal(notify.DeadLetters())
+		if err != nil {
+			msg := "an error occurred while fetching the dead letters"
+			log.Errorln(logTag, ": unable to marshal dead letters:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (n *notifications) redeliver() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		id := mux.Vars(req)["id"]
+
+		if err := notify.Redeliver(id); err != nil {
+			msg := fmt.Sprintf(`dead letter with "id"="%s" not found`, id)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		msg := fmt.Sprintf(`dead letter "%s" queued for redelivery`, id)
+		util.WriteBackMessage(w, msg, http.StatusAccepted)
+	}
+}
+
+func (n *notifications) deleteDeadLetters() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		notify.ClearDeadLetters()
+		util.WriteBackMessage(w, "dead letters deleted", http.StatusOK)
+	}
+}
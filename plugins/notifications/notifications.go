@@ -0,0 +1,46 @@
+package notifications
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const logTag = "[notifications]"
+
+var (
+	singleton *notifications
+	once      sync.Once
+)
+
+// notifications exposes the deliveries of the events to the webhooks that
+// failed after all their retries.
+type notifications struct{}
+
+// Use only this function to fetch the instance of notifications from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *notifications {
+	once.Do(func() { singleton = &notifications{} })
+	return singleton
+}
+
+func (n *notifications) Name() string {
+	return logTag
+}
+
+func (n *notifications) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+	return nil
+}
+
+func (n *notifications) Routes() []plugins.Route {
+	return n.routes()
+}
+
+// Default empty middleware array function
+func (n *notifications) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
@@ -0,0 +1,35 @@
+package notifications
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (n *notifications) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get dead letters",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_notifications/dead_letters",
+			HandlerFunc: middleware(isAdmin(n.getDeadLetters())),
+			Description: "Returns the events whose delivery to the webhooks failed",
+		},
+		{
+			Name:        "Redeliver dead letter",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_notifications/dead_letters/{id}/_redeliver",
+			HandlerFunc: middleware(isAdmin(n.redeliver())),
+			Description: "Delivers the failed event {id} to its webhook again",
+		},
+		{
+			Name:        "Delete dead letters",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_notifications/dead_letters",
+			HandlerFunc: middleware(isAdmin(n.deleteDeadLetters())),
+			Description: "Drops the events whose delivery to the webhooks failed",
+		},
+	}
+	return routes
+}
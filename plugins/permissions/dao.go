@@ -9,8 +9,16 @@ import (
 
 	"github.com/appbaseio/arc/model/permission"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/embedded"
+	"github.com/appbaseio/arc/util/migration"
+	"github.com/appbaseio/arc/util/occ"
 )
 
+// maxRevocations is the number of permissions that can be revoked at once.
+const maxRevocations = 10000
+
+var errTooManyRevocations = fmt.Errorf("more than %d permissions match, narrow down the revocation", maxRevocations)
+
 type elasticsearch struct {
 	indexName string
 	mapping   string
@@ -21,16 +29,18 @@ func initPlugin(indexName, mapping string) (*elasticsearch, error) {
 
 	es := &elasticsearch{indexName, mapping}
 
-	// Check if the meta index already exists
-	exists, err := util.GetClient7().IndexExists(indexName).
+	// Check if elasticsearch is reachable
+	_, err := util.GetClient7().IndexExists(indexName).
 		Do(ctx)
+	if err != nil && embedded.Enabled() && util.IsUnreachable(err) {
+		// the index is assumed to exist since it was set up when the
+		// embedded store was populated, which serves the credentials meanwhile
+		log.Warnln(logTag, ": elasticsearch is unreachable, skipping the setup of index", indexName)
+		return es, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: error while checking if index already exists: %v", logTag, err)
 	}
-	if exists {
-		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
-		return es, nil
-	}
 
 	// set number_of_replicas to (nodes-1)
 	nodes, err := util.GetTotalNodes()
@@ -39,12 +49,18 @@ func initPlugin(indexName, mapping string) (*elasticsearch, error) {
 	}
 	settings := fmt.Sprintf(mapping, nodes, nodes-1)
 
-	// Create a new meta index
-	_, err = util.GetClient7().CreateIndex(indexName).
-		Body(settings).
-		Do(ctx)
+	// create the meta index, or migrate it if its body changed
+	created, err := migration.Ensure(ctx, migration.Index{
+		Name:    indexName,
+		Version: indexVersion,
+		Body:    settings,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%s: error while creating index named %s: %v", logTag, indexName, err)
+		return nil, err
+	}
+	if !created {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+		return es, nil
 	}
 
 	log.Println(logTag, ": successfully created index named", indexName)
@@ -114,6 +130,17 @@ func (es *elasticsearch) patchPermission(ctx context.Context, username string, p
 	}
 }
 
+// patchPermissionIfMatch patches the permission if it is still at the
+// revision, or unconditionally if the revision is nil.
+func (es *elasticsearch) patchPermissionIfMatch(ctx context.Context, username string, patch map[string]interface{}, version *occ.Version) ([]byte, occ.Version, error) {
+	return occ.Update(ctx, es.indexName, username, patch, version)
+}
+
+// getRawPermissionVersion returns the permission along with its revision.
+func (es *elasticsearch) getRawPermissionVersion(ctx context.Context, username string) ([]byte, occ.Version, error) {
+	return occ.Get(ctx, es.indexName, username)
+}
+
 func (es *elasticsearch) deletePermission(ctx context.Context, username string) (bool, error) {
 	_, err := util.GetClient7().Delete().
 		Refresh("wait_for").
@@ -153,3 +180,16 @@ func (es *elasticsearch) getRawRolePermission(ctx context.Context, role string)
 		return es.getRawRolePermissionEs7(ctx, role)
 	}
 }
+
+// revokePermissions deletes the permissions owned by the owner and/or granting
+// access to the indices matching the index pattern with a single delete by
+// query. The usernames of the matching permissions are returned along with the
+// number of deleted permissions.
+func (es *elasticsearch) revokePermissions(ctx context.Context, owner, index string) ([]string, int64, error) {
+	switch util.GetVersion() {
+	case 6:
+		return es.revokePermissionsEs6(ctx, owner, index)
+	default:
+		return es.revokePermissionsEs7(ctx, owner, index)
+	}
+}
@@ -102,3 +102,42 @@ func (es *elasticsearch) patchPermissionEs6(ctx context.Context, username string
 
 	return src, nil
 }
+
+func (es *elasticsearch) revokePermissionsEs6(ctx context.Context, owner, index string) ([]string, int64, error) {
+	query := es6.NewBoolQuery()
+	if owner != "" {
+		query.Filter(es6.NewTermQuery("owner.keyword", owner))
+	}
+	if index != "" {
+		query.Filter(es6.NewWildcardQuery("indices.keyword", index))
+	}
+
+	resp, err := util.GetClient6().Search().
+		Index(es.indexName).
+		Query(query).
+		FetchSource(false).
+		Size(maxRevocations).
+		Do(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.Hits.TotalHits > maxRevocations {
+		return nil, 0, errTooManyRevocations
+	}
+	usernames := []string{}
+	for _, hit := range resp.Hits.Hits {
+		usernames = append(usernames, hit.Id)
+	}
+	if len(usernames) == 0 {
+		return usernames, 0, nil
+	}
+
+	deleted, err := util.GetClient6().DeleteByQuery(es.indexName).
+		Query(query).
+		Refresh("true").
+		Do(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return usernames, deleted.Deleted, nil
+}
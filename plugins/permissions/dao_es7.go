@@ -101,3 +101,43 @@ func (es *elasticsearch) patchPermissionEs7(ctx context.Context, username string
 
 	return src, nil
 }
+
+func (es *elasticsearch) revokePermissionsEs7(ctx context.Context, owner, index string) ([]string, int64, error) {
+	query := es7.NewBoolQuery()
+	if owner != "" {
+		query.Filter(es7.NewTermQuery("owner.keyword", owner))
+	}
+	if index != "" {
+		query.Filter(es7.NewWildcardQuery("indices.keyword", index))
+	}
+
+	resp, err := util.GetClient7().Search().
+		Index(es.indexName).
+		Query(query).
+		FetchSource(false).
+		Size(maxRevocations).
+		Do(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	// the total is only tracked up to the size of the search
+	if total := resp.Hits.TotalHits; total != nil && (total.Value > maxRevocations || total.Relation == "gte") {
+		return nil, 0, errTooManyRevocations
+	}
+	usernames := []string{}
+	for _, hit := range resp.Hits.Hits {
+		usernames = append(usernames, hit.Id)
+	}
+	if len(usernames) == 0 {
+		return usernames, 0, nil
+	}
+
+	deleted, err := util.GetClient7().DeleteByQuery(es.indexName).
+		Query(query).
+		Refresh("true").
+		Do(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	return usernames, deleted.Deleted, nil
+}
@@ -0,0 +1,162 @@
+package permissions
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util/occ"
+	"github.com/appbaseio/arc/util/storage"
+)
+
+// documents stores the permissions in the database of the storage
+// backend.
+type documents struct {
+	docs *storage.Documents
+}
+
+// permissions returns the permissions matching the filter.
+func (d *documents) permissions(ctx context.Context, filter func(p *permission.Permission) bool) ([]permission.Permission, []json.RawMessage, error) {
+	docs, err := d.docs.List(ctx, storage.Permissions)
+	if err != nil {
+		return nil, nil, err
+	}
+	var permissions []permission.Permission
+	var raws []json.RawMessage
+	for _, raw := range docs {
+		var p permission.Permission
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, nil, err
+		}
+		if filter(&p) {
+			permissions = append(permissions, p)
+			raws = append(raws, raw)
+		}
+	}
+	return permissions, raws, nil
+}
+
+func (d *documents) getPermission(ctx context.Context, username string) (*permission.Permission, error) {
+	raw, err := d.getRawPermission(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	var p permission.Permission
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (d *documents) getRawPermission(ctx context.Context, username string) ([]byte, error) {
+	raw, _, err := d.docs.Get(ctx, storage.Permissions, username)
+	if err != nil {
+		return nil, err
+	}
+	return applyExpiredField(raw)
+}
+
+func (d *documents) getRawPermissionVersion(ctx context.Context, username string) ([]byte, occ.Version, error) {
+	return d.docs.Get(ctx, storage.Permissions, username)
+}
+
+func (d *documents) postPermission(ctx context.Context, p permission.Permission) (bool, error) {
+	if err := d.docs.Put(ctx, storage.Permissions, p.Username, p); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *documents) patchPermission(ctx context.Context, username string, patch map[string]interface{}) ([]byte, error) {
+	raw, _, err := d.docs.Update(ctx, storage.Permissions, username, patch, nil)
+	return raw, err
+}
+
+func (d *documents) patchPermissionIfMatch(ctx context.Context, username string, patch map[string]interface{}, version *occ.Version) ([]byte, occ.Version, error) {
+	return d.docs.Update(ctx, storage.Permissions, username, patch, version)
+}
+
+func (d *documents) deletePermission(ctx context.Context, username string) (bool, error) {
+	deleted, err := d.docs.Delete(ctx, storage.Permissions, username)
+	if err == nil && !deleted {
+		return false, storage.ErrNotFound
+	}
+	return deleted, err
+}
+
+func (d *documents) getRawOwnerPermissions(ctx context.Context, owner string) ([]byte, error) {
+	_, raws, err := d.permissions(ctx, func(p *permission.Permission) bool {
+		return p.Owner == owner
+	})
+	if err != nil {
+		return nil, err
+	}
+	rawPermissions := []json.RawMessage{}
+	for _, raw := range raws {
+		rawPermission, err := applyExpiredField(raw)
+		if err != nil {
+			return nil, err
+		}
+		rawPermissions = append(rawPermissions, rawPermission)
+	}
+	raw, err := json.Marshal(rawPermissions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal slice of raw permissions: %v", err)
+	}
+	return raw, nil
+}
+
+// getRawRolePermission returns the first permission of the role, or nil if
+// the role doesn't exist.
+func (d *documents) getRawRolePermission(ctx context.Context, role string) ([]byte, error) {
+	_, raws, err := d.permissions(ctx, func(p *permission.Permission) bool {
+		return p.Role == role
+	})
+	if err != nil || len(raws) == 0 {
+		return nil, err
+	}
+	return raws[0], nil
+}
+
+func (d *documents) checkRoleExists(ctx context.Context, role string) (bool, error) {
+	raw, err := d.getRawRolePermission(ctx, role)
+	return raw != nil, err
+}
+
+func (d *documents) revokePermissions(ctx context.Context, owner, pattern string) ([]string, int64, error) {
+	matches, _, err := d.permissions(ctx, func(p *permission.Permission) bool {
+		if owner != "" && p.Owner != owner {
+			return false
+		}
+		if pattern == "" {
+			return true
+		}
+		for _, name := range p.Indices {
+			if index.Match([]string{pattern}, name) {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(matches) > maxRevocations {
+		return nil, 0, errTooManyRevocations
+	}
+	usernames := []string{}
+	var deleted int64
+	for _, p := range matches {
+		ok, err := d.docs.Delete(ctx, storage.Permissions, p.Username)
+		if err != nil {
+			return nil, 0, err
+		}
+		usernames = append(usernames, p.Username)
+		if ok {
+			deleted++
+		}
+	}
+	return usernames, deleted, nil
+}
@@ -0,0 +1,54 @@
+package permissions
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/storage"
+)
+
+func TestDocuments(t *testing.T) {
+	ctx := context.Background()
+	docs, err := storage.Open(storage.SQLite, filepath.Join(t.TempDir(), "arc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer docs.Close()
+	d := &documents{docs}
+	for _, p := range []permission.Permission{
+		{Username: "a", Owner: "foo", Role: "reader", Indices: []string{"products"}},
+		{Username: "b", Owner: "foo", Indices: []string{"logs-2020"}},
+		{Username: "c", Owner: "bar", Indices: []string{"logs-*"}},
+	} {
+		p.CreatedAt = time.Now().Format(time.RFC3339)
+		if _, err := d.postPermission(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	Convey("The permissions of the role are found", t, func() {
+		exists, err := d.checkRoleExists(ctx, "reader")
+		So(err, ShouldBeNil)
+		So(exists, ShouldBeTrue)
+		exists, _ = d.checkRoleExists(ctx, "writer")
+		So(exists, ShouldBeFalse)
+	})
+
+	Convey("The permissions are revoked by owner and index pattern", t, func() {
+		usernames, deleted, err := d.revokePermissions(ctx, "foo", "logs-*")
+		So(err, ShouldBeNil)
+		So(usernames, ShouldResemble, []string{"b"})
+		So(deleted, ShouldEqual, 1)
+
+		_, err = d.getRawPermission(ctx, "b")
+		So(util.IsNotFound(err), ShouldBeTrue)
+		_, err = d.getRawPermission(ctx, "c")
+		So(err, ShouldBeNil)
+	})
+}
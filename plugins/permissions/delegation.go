@@ -0,0 +1,165 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util"
+)
+
+// delegable checks that the permission grants no more than the user has
+// access to, so that the users that aren't admins can only delegate their
+// own access. The access the permission is itself denied isn't granted,
+// hence a user denied part of an index pattern can grant the pattern along
+// with the same denials. The fields that aren't set aren't checked.
+func delegable(u *user.User, p *permission.Permission) error {
+	for _, c := range p.Categories {
+		if !u.HasCategory(c) && !deniesCategory(p.DeniedCategories, c) {
+			return fmt.Errorf(`user with "username"="%s" can't grant the "%s" category it doesn't have`, u.Username, c)
+		}
+	}
+	for _, a := range p.ACLs {
+		if !u.HasACL(a) && !deniesACL(p.DeniedCategories, a) {
+			return fmt.Errorf(`user with "username"="%s" can't grant the "%s" acl it doesn't have`, u.Username, a)
+		}
+	}
+	for _, o := range p.Ops {
+		if !u.CanDo(o) {
+			return fmt.Errorf(`user with "username"="%s" can't grant the "%s" op it doesn't have`, u.Username, o)
+		}
+	}
+
+	// the indices are checked against the allowed ones of the user, and its
+	// denied ones against those of the permission
+	allowed := *u
+	allowed.DeniedIndices = nil
+	for _, name := range p.Indices {
+		ok, err := allowed.CanAccessIndex(name)
+		if err != nil {
+			return fmt.Errorf(`invalid index pattern "%s": %v`, name, err)
+		}
+		if !ok {
+			return fmt.Errorf(`user with "username"="%s" can't grant access to the "%s" indices it can't access`, u.Username, name)
+		}
+		for _, denied := range u.DeniedIndices {
+			if index.Overlaps([]string{denied}, name) && !index.Overlaps(p.DeniedIndices, denied) {
+				return fmt.Errorf(`user with "username"="%s" can't grant access to the "%s" indices without denying "%s"`, u.Username, name, denied)
+			}
+		}
+	}
+	return nil
+}
+
+func deniesCategory(denied []category.Category, c category.Category) bool {
+	for _, d := range denied {
+		if d == c {
+			return true
+		}
+	}
+	return false
+}
+
+func deniesACL(denied []category.Category, a acl.ACL) bool {
+	for _, d := range denied {
+		if d.HasACL(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// narrowDefaults restricts the default categories and acls of the
+// permission, the ones that aren't set explicitly, to those of the user. The
+// permission inherits the denials of the user unless they're set explicitly.
+func narrowDefaults(u *user.User, p *permission.Permission, body *permission.Permission) {
+	if body.Categories == nil {
+		categories := []category.Category{}
+		for _, c := range p.Categories {
+			if u.HasCategory(c) {
+				categories = append(categories, c)
+			}
+		}
+		p.Categories = categories
+	}
+	if body.ACLs == nil {
+		acls := []acl.ACL{}
+		for _, a := range category.ACLsFor(p.Categories...) {
+			if u.HasACL(a) {
+				acls = append(acls, a)
+			}
+		}
+		p.ACLs = acls
+	}
+	if body.DeniedIndices == nil {
+		p.DeniedIndices = u.DeniedIndices
+	}
+	if body.DeniedCategories == nil {
+		p.DeniedCategories = u.DeniedCategories
+	}
+}
+
+// changesAccess checks whether the patch sets any of the access fields.
+func changesAccess(patch *permission.Permission) bool {
+	return patch.Categories != nil || patch.ACLs != nil || patch.Ops != nil || patch.Indices != nil ||
+		patch.DeniedIndices != nil || patch.DeniedCategories != nil
+}
+
+// patched returns the permission with the access fields of the patch set,
+// the ones delegable checks.
+func patched(p *permission.Permission, patch *permission.Permission) *permission.Permission {
+	effective := *p
+	if patch.Categories != nil {
+		effective.Categories = patch.Categories
+		effective.ACLs = category.ACLsFor(patch.Categories...)
+	}
+	if patch.ACLs != nil {
+		effective.ACLs = patch.ACLs
+	}
+	if patch.Ops != nil {
+		effective.Ops = patch.Ops
+	}
+	if patch.Indices != nil {
+		effective.Indices = patch.Indices
+	}
+	if patch.DeniedIndices != nil {
+		effective.DeniedIndices = patch.DeniedIndices
+	}
+	if patch.DeniedCategories != nil {
+		effective.DeniedCategories = patch.DeniedCategories
+	}
+	return &effective
+}
+
+// owned fetches the permission and checks that the user is allowed to
+// manage it, the users that aren't admins can only manage the permissions
+// they own. The error is written back if it isn't the case.
+func (p *permissions) owned(ctx context.Context, w http.ResponseWriter, username string) (*permission.Permission, bool) {
+	reqUser, err := user.FromContext(ctx)
+	if err != nil {
+		log.Errorln(logTag, ":", err)
+		util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	reqPermission, err := p.es.getPermission(ctx, username)
+	if err != nil {
+		msg := fmt.Sprintf(`permission with "username"="%s" not found`, username)
+		log.Errorln(logTag, ":", msg, ":", err)
+		util.WriteBackError(w, msg, http.StatusNotFound)
+		return nil, false
+	}
+	if !reqUser.Admin() && reqPermission.Owner != reqUser.Username {
+		msg := fmt.Sprintf(`user with "username"="%s" doesn't own the permission with "username"="%s"`, reqUser.Username, username)
+		util.WriteBackError(w, msg, http.StatusForbidden)
+		return nil, false
+	}
+	return reqPermission, true
+}
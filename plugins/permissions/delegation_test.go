@@ -0,0 +1,70 @@
+package permissions
+
+import (
+	"testing"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDelegation(t *testing.T) {
+	u, _ := user.New("foo", "bar",
+		user.SetCategories([]category.Category{category.Docs, category.Search}),
+		user.SetOps([]op.Operation{op.Read, op.Write}),
+		user.SetIndices([]string{"products", "logs-*"}))
+
+	Convey("The users can delegate their own access", t, func() {
+		p := &permission.Permission{
+			Categories: []category.Category{category.Search},
+			ACLs:       []acl.ACL{acl.Search},
+			Ops:        []op.Operation{op.Read},
+			Indices:    []string{"products", "logs-2020"},
+		}
+		So(delegable(u, p), ShouldBeNil)
+	})
+
+	Convey("The users can't grant more than they have", t, func() {
+		for _, p := range []*permission.Permission{
+			{Categories: []category.Category{category.Indices}},
+			{ACLs: []acl.ACL{acl.Settings}},
+			{Ops: []op.Operation{op.Delete}},
+			{Indices: []string{"orders"}},
+		} {
+			So(delegable(u, p), ShouldNotBeNil)
+		}
+	})
+
+	Convey("The default categories and acls are narrowed to the user's", t, func() {
+		p, err := permission.New("foo")
+		So(err, ShouldBeNil)
+		narrowDefaults(u, p, &permission.Permission{})
+		So(p.Categories, ShouldResemble, []category.Category{category.Docs, category.Search})
+		So(p.ACLs, ShouldResemble, category.ACLsFor(category.Docs, category.Search))
+		So(delegable(u, p), ShouldBeNil)
+	})
+
+	Convey("The denials of the user are inherited and narrow the granted patterns", t, func() {
+		denied, _ := user.New("baz", "bar",
+			user.SetCategories([]category.Category{category.Docs, category.Search}),
+			user.SetIndices([]string{"logs-*"}),
+			user.SetDeniedIndices([]string{"logs-secure"}),
+			user.SetDeniedCategories([]category.Category{category.Docs}))
+
+		p := &permission.Permission{Indices: []string{"logs-*"}}
+		So(delegable(denied, p), ShouldNotBeNil)
+
+		p, _ = permission.New("baz", permission.SetIndices([]string{"logs-*"}))
+		narrowDefaults(denied, p, &permission.Permission{})
+		So(p.DeniedIndices, ShouldResemble, []string{"logs-secure"})
+		So(p.Categories, ShouldResemble, []category.Category{category.Search})
+		So(delegable(denied, p), ShouldBeNil)
+		ok, _ := p.CanAccessIndex("logs-app")
+		So(ok, ShouldBeTrue)
+		ok, _ = p.CanAccessIndex("logs-secure")
+		So(ok, ShouldBeFalse)
+	})
+}
@@ -28,6 +28,8 @@ var adminCategories = []category.Category{
 	category.Suggestions,
 	category.Functions,
 	category.Auth,
+	category.Logs,
+	category.Reindex,
 }
 
 var adminOps = []op.Operation{
@@ -53,6 +55,8 @@ var defaultAdminLimits = permission.Limits{
 	StreamsLimit:     30,
 	AuthLimit:        30,
 	FunctionsLimit:   30,
+	LogsLimit:        30,
+	ReindexLimit:     30,
 }
 
 var createPermissionResponse = map[string]interface{}{
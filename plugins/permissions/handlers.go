@@ -3,7 +3,6 @@ package permissions
 import (
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 
 	log "github.com/sirupsen/logrus"
@@ -11,7 +10,9 @@ import (
 	"github.com/appbaseio/arc/model/acl"
 	"github.com/appbaseio/arc/model/permission"
 	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/plugins/auth"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/occ"
 	"github.com/gorilla/mux"
 )
 
@@ -20,13 +21,18 @@ func (p *permissions) getPermission() http.HandlerFunc {
 		vars := mux.Vars(req)
 		username := vars["username"]
 
-		rawPermission, err := p.es.getRawPermission(req.Context(), username)
+		if _, ok := p.owned(req.Context(), w, username); !ok {
+			return
+		}
+
+		rawPermission, version, err := p.es.getRawPermissionVersion(req.Context(), username)
 		if err != nil {
 			msg := fmt.Sprintf(`permission with "username"="%s" not found`, username)
 			log.Errorln(logTag, ":", msg, ":", err)
 			util.WriteBackError(w, msg, http.StatusNotFound)
 			return
 		}
+		occ.SetETag(w, version)
 		util.WriteBackRaw(w, rawPermission, http.StatusOK)
 	}
 }
@@ -41,11 +47,11 @@ func (p *permissions) postPermission(opts ...permission.Options) http.HandlerFun
 			return
 		}
 
-		body, err := ioutil.ReadAll(req.Body)
+		body, err := util.ReadRequestBody(req)
 		if err != nil {
 			msg := "can't read request body"
 			log.Errorln(logTag, ":", msg, ":", err)
-			util.WriteBackError(w, msg, http.StatusBadRequest)
+			util.WriteBackBodyError(w, err)
 			return
 		}
 
@@ -59,47 +65,17 @@ func (p *permissions) postPermission(opts ...permission.Options) http.HandlerFun
 		}
 
 		if permissionBody.Owner != "" {
+			if !reqUser.Admin() && permissionBody.Owner != reqUser.Username {
+				msg := fmt.Sprintf(`user with "username"="%s" can't create permissions owned by "owner"="%s"`, reqUser.Username, permissionBody.Owner)
+				util.WriteBackError(w, msg, http.StatusForbidden)
+				return
+			}
 			opts = append(opts, permission.SetOwner(permissionBody.Owner))
 		}
-		if permissionBody.Ops != nil {
-			opts = append(opts, permission.SetOps(permissionBody.Ops))
-		}
-		if permissionBody.Role != "" {
-			opts = append(opts, permission.SetRole(permissionBody.Role))
-		}
-		if permissionBody.Categories != nil {
-			opts = append(opts, permission.SetCategories(permissionBody.Categories))
-		}
-		if permissionBody.ACLs != nil {
-			opts = append(opts, permission.SetACLs(permissionBody.ACLs))
-		}
-		if permissionBody.Sources != nil {
-			opts = append(opts, permission.SetSources(permissionBody.Sources))
-		}
-		if permissionBody.Referers != nil {
-			opts = append(opts, permission.SetReferers(permissionBody.Referers))
-		}
-		if permissionBody.Includes != nil {
-			opts = append(opts, permission.SetIncludes(permissionBody.Includes))
-		}
-		if permissionBody.Excludes != nil {
-			opts = append(opts, permission.SetExcludes(permissionBody.Excludes))
-		}
-		if permissionBody.Indices != nil {
-			opts = append(opts, permission.SetIndices(permissionBody.Indices))
-		}
-		if permissionBody.Limits != nil {
-			opts = append(opts, permission.SetLimits(permissionBody.Limits))
-		}
-		if permissionBody.Description != "" {
-			opts = append(opts, permission.SetDescription(permissionBody.Description))
-		}
-		if permissionBody.TTL != 0 {
-			opts = append(opts, permission.SetTTL(permissionBody.TTL))
-		}
+		opts = append(opts, permissionOptions(&permissionBody)...)
 
 		var newPermission *permission.Permission
-		if *reqUser.IsAdmin {
+		if reqUser.Admin() {
 			newPermission, err = permission.NewAdmin(creator, opts...)
 		} else {
 			newPermission, err = permission.New(creator, opts...)
@@ -109,6 +85,14 @@ func (p *permissions) postPermission(opts ...permission.Options) http.HandlerFun
 			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
 			return
 		}
+		if !reqUser.Admin() {
+			// the users that aren't admins only delegate their own access
+			narrowDefaults(reqUser, newPermission, &permissionBody)
+			if err := delegable(reqUser, newPermission); err != nil {
+				util.WriteBackError(w, err.Error(), http.StatusForbidden)
+				return
+			}
+		}
 
 		rawPermission, err := json.Marshal(*newPermission)
 		if err != nil {
@@ -153,11 +137,18 @@ func (p *permissions) patchPermission() http.HandlerFunc {
 		vars := mux.Vars(req)
 		username := vars["username"]
 
-		body, err := ioutil.ReadAll(req.Body)
+		// the permission is only patched if still at the revision of If-Match
+		version, err := occ.FromRequest(req)
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		body, err := util.ReadRequestBody(req)
 		if err != nil {
 			msg := "can't read request body"
 			log.Errorln(logTag, ":", msg, ":", err)
-			util.WriteBackError(w, msg, http.StatusBadRequest)
+			util.WriteBackBodyError(w, err)
 			return
 		}
 
@@ -180,6 +171,30 @@ func (p *permissions) patchPermission() http.HandlerFunc {
 		}
 		_, roleExistsInPatch := perMap["role"]
 
+		reqUser, err := user.FromContext(req.Context())
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		storedPermission, ok := p.owned(req.Context(), w, username)
+		if !ok {
+			return
+		}
+		if !reqUser.Admin() {
+			if obj.Owner != "" && obj.Owner != reqUser.Username {
+				msg := fmt.Sprintf(`user with "username"="%s" can't transfer permissions to "owner"="%s"`, reqUser.Username, obj.Owner)
+				util.WriteBackError(w, msg, http.StatusForbidden)
+				return
+			}
+			if changesAccess(&obj) {
+				if err := delegable(reqUser, patched(storedPermission, &obj)); err != nil {
+					util.WriteBackError(w, err.Error(), http.StatusForbidden)
+					return
+				}
+			}
+		}
+
 		patch, err := obj.GetPatch(roleExistsInPatch)
 		if err != nil {
 			log.Errorln(logTag, ":", err)
@@ -230,8 +245,14 @@ func (p *permissions) patchPermission() http.HandlerFunc {
 			}
 		}
 
-		raw, err := p.es.patchPermission(req.Context(), username, patch)
+		raw, updated, err := p.es.patchPermissionIfMatch(req.Context(), username, patch, version)
+		if err == occ.ErrConflict {
+			msg := fmt.Sprintf(`permission with "username"="%s" was modified since revision %s`, username, version.ETag())
+			util.WriteBackError(w, msg, http.StatusConflict)
+			return
+		}
 		if err == nil {
+			occ.SetETag(w, updated)
 			util.WriteBackRaw(w, raw, http.StatusOK)
 			return
 		}
@@ -247,6 +268,10 @@ func (p *permissions) deletePermission() http.HandlerFunc {
 		vars := mux.Vars(req)
 		username := vars["username"]
 
+		if _, ok := p.owned(req.Context(), w, username); !ok {
+			return
+		}
+
 		ok, err := p.es.deletePermission(req.Context(), username)
 		if ok && err == nil {
 			msg := fmt.Sprintf(`permission with "username"="%s" deleted`, username)
@@ -276,6 +301,61 @@ func (p *permissions) getUserPermissions() http.HandlerFunc {
 	}
 }
 
+// revokePermissions revokes all the permissions owned by the "owner" and/or
+// granting access to the indices matching the "index" pattern. The users that
+// aren't admins can only revoke the permissions they own.
+func (p *permissions) revokePermissions() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		reqUser, err := user.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		params := req.URL.Query()
+		owner, index := params.Get("owner"), params.Get("index")
+		if owner == "" && index == "" {
+			util.WriteBackError(w, `either "owner" or "index" query param is required`, http.StatusBadRequest)
+			return
+		}
+		if !reqUser.Admin() {
+			if owner != "" && owner != reqUser.Username {
+				msg := fmt.Sprintf(`user with "username"="%s" can't revoke the permissions of "owner"="%s"`, reqUser.Username, owner)
+				util.WriteBackError(w, msg, http.StatusForbidden)
+				return
+			}
+			owner = reqUser.Username
+		}
+
+		usernames, revoked, err := p.es.revokePermissions(ctx, owner, index)
+		if err == errTooManyRevocations {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			msg := "an error occurred while revoking the permissions"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		for _, username := range usernames {
+			auth.InvalidateCredential(ctx, username)
+		}
+		log.Println(logTag, ":", reqUser.Username, "revoked", revoked, "permissions matching owner=", owner, ", index=", index)
+
+		raw, err := json.Marshal(map[string]interface{}{"revoked": revoked, "usernames": usernames})
+		if err != nil {
+			msg := "an error occurred while revoking the permissions"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
 func (p *permissions) role() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		vars := mux.Vars(req)
@@ -303,6 +383,9 @@ func (p *permissions) role() http.HandlerFunc {
 
 		switch req.Method {
 		case http.MethodGet:
+			if _, ok := p.owned(req.Context(), w, perm.Username); !ok {
+				return
+			}
 			util.WriteBackRaw(w, raw, http.StatusOK)
 		case http.MethodPost:
 			p.postPermission(permission.SetRole(role))(w, req)
@@ -316,3 +399,73 @@ func (p *permissions) role() http.HandlerFunc {
 		}
 	}
 }
+
+// permissionOptions returns the options setting the fields of the permission
+// described by the body of the request, apart from its owner.
+func permissionOptions(permissionBody *permission.Permission) []permission.Options {
+	var opts []permission.Options
+	if permissionBody.Ops != nil {
+		opts = append(opts, permission.SetOps(permissionBody.Ops))
+	}
+	if permissionBody.Families != nil {
+		opts = append(opts, permission.SetFamilies(permissionBody.Families))
+	}
+	if permissionBody.Subcategories != nil {
+		opts = append(opts, permission.SetSubcategories(permissionBody.Subcategories))
+	}
+	if permissionBody.Role != "" {
+		opts = append(opts, permission.SetRole(permissionBody.Role))
+	}
+	if permissionBody.Categories != nil {
+		opts = append(opts, permission.SetCategories(permissionBody.Categories))
+	}
+	if permissionBody.ACLs != nil {
+		opts = append(opts, permission.SetACLs(permissionBody.ACLs))
+	}
+	if permissionBody.Sources != nil {
+		opts = append(opts, permission.SetSources(permissionBody.Sources))
+	}
+	if permissionBody.Referers != nil {
+		opts = append(opts, permission.SetReferers(permissionBody.Referers))
+	}
+	if permissionBody.Includes != nil {
+		opts = append(opts, permission.SetIncludes(permissionBody.Includes))
+	}
+	if permissionBody.Excludes != nil {
+		opts = append(opts, permission.SetExcludes(permissionBody.Excludes))
+	}
+	if permissionBody.Indices != nil {
+		opts = append(opts, permission.SetIndices(permissionBody.Indices))
+	}
+	if permissionBody.Limits != nil {
+		opts = append(opts, permission.SetLimits(permissionBody.Limits))
+	}
+	if permissionBody.Description != "" {
+		opts = append(opts, permission.SetDescription(permissionBody.Description))
+	}
+	if permissionBody.TTL != 0 {
+		opts = append(opts, permission.SetTTL(permissionBody.TTL))
+	}
+	if permissionBody.Tenant != "" {
+		opts = append(opts, permission.SetTenant(permissionBody.Tenant))
+	}
+	if permissionBody.SearchTimeout != "" {
+		opts = append(opts, permission.SetSearchTimeout(permissionBody.SearchTimeout))
+	}
+	if permissionBody.DeniedIndices != nil {
+		opts = append(opts, permission.SetDeniedIndices(permissionBody.DeniedIndices))
+	}
+	if permissionBody.DeniedCategories != nil {
+		opts = append(opts, permission.SetDeniedCategories(permissionBody.DeniedCategories))
+	}
+	if permissionBody.Guardrails != nil {
+		opts = append(opts, permission.SetGuardrails(permissionBody.Guardrails))
+	}
+	if permissionBody.Scripts != nil {
+		opts = append(opts, permission.SetScripts(permissionBody.Scripts))
+	}
+	if permissionBody.Aggregations != nil {
+		opts = append(opts, permission.SetAggregations(permissionBody.Aggregations))
+	}
+	return opts
+}
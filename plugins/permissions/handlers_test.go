@@ -0,0 +1,66 @@
+package permissions
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+)
+
+// ownedPermissions serves the permissions it holds.
+type ownedPermissions struct {
+	permissionService
+	stored  map[string]*permission.Permission
+	revoked string
+}
+
+func (o *ownedPermissions) getPermission(ctx context.Context, username string) (*permission.Permission, error) {
+	if p, ok := o.stored[username]; ok {
+		return p, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (o *ownedPermissions) revokePermissions(ctx context.Context, owner, index string) ([]string, int64, error) {
+	o.revoked = owner
+	return nil, 0, nil
+}
+
+func TestOwnership(t *testing.T) {
+	es := &ownedPermissions{stored: map[string]*permission.Permission{
+		"foo-key": {Username: "foo-key", Owner: "foo"},
+		"bar-key": {Username: "bar-key", Owner: "bar"},
+	}}
+	p := &permissions{es: es}
+	// a user stored without the is_admin flag
+	legacy := user.NewContext(context.Background(), &user.User{Username: "foo"})
+
+	Convey("The users without the admin flag can only manage their own permissions", t, func() {
+		w := httptest.NewRecorder()
+		_, ok := p.owned(legacy, w, "foo-key")
+		So(ok, ShouldBeTrue)
+
+		w = httptest.NewRecorder()
+		_, ok = p.owned(legacy, w, "bar-key")
+		So(ok, ShouldBeFalse)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("The users without the admin flag can only revoke their own permissions", t, func() {
+		req := httptest.NewRequest(http.MethodDelete, "/_permissions?owner=bar", nil).WithContext(legacy)
+		w := httptest.NewRecorder()
+		p.revokePermissions()(w, req)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+
+		req = httptest.NewRequest(http.MethodDelete, "/_permissions?index=products", nil).WithContext(legacy)
+		w = httptest.NewRecorder()
+		p.revokePermissions()(w, req)
+		So(es.revoked, ShouldEqual, "foo")
+	})
+}
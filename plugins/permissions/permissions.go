@@ -1,6 +1,7 @@
 package permissions
 
 import (
+	"context"
 	"os"
 	"sync"
 
@@ -8,6 +9,7 @@ import (
 
 	"github.com/appbaseio/arc/middleware"
 	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util/storage"
 )
 
 const (
@@ -17,6 +19,9 @@ const (
 	envEsURL                  = "ES_CLUSTER_URL"
 	envPermissionEsIndex      = "PERMISSIONS_ES_INDEX"
 	settings                  = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+	// indexVersion is the version of the body of the index, it must be
+	// bumped along with the settings in order to migrate the index.
+	indexVersion = 1
 )
 
 var (
@@ -47,14 +52,20 @@ func (p *permissions) InitFunc() error {
 		indexName = defaultPermissionsEsIndex
 	}
 
-	// initialize the dao
-	var err error
-	p.es, err = initPlugin(indexName, settings)
+	// initialize the dao, the permissions are kept either in elasticsearch
+	// or in the database of the storage backend
+	docs, err := storage.Instance()
 	if err != nil {
 		return err
 	}
+	if docs != nil {
+		p.es = &documents{docs}
+	} else if p.es, err = initPlugin(indexName, settings); err != nil {
+		return err
+	}
 
-	return nil
+	// create the permissions declared in the seed file on the first run
+	return p.seedPermissions(context.Background())
 }
 
 func (p *permissions) Routes() []plugins.Route {
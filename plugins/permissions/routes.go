@@ -44,6 +44,13 @@ func (p *permissions) routes() []plugins.Route {
 			HandlerFunc: middleware(p.getUserPermissions()),
 			Description: "Returns all the permissions of the user",
 		},
+		{
+			Name:        "Revoke permissions",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_permissions",
+			HandlerFunc: middleware(p.revokePermissions()),
+			Description: "Revokes all the permissions matching the owner and/or the index pattern",
+		},
 		{
 			Name:        "Create/Read/Update/Delete permission by role",
 			Methods:     []string{http.MethodPost, http.MethodGet, http.MethodPatch, http.MethodDelete},
@@ -0,0 +1,73 @@
+package permissions
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/embedded"
+	"github.com/appbaseio/arc/util/seed"
+)
+
+// seedPermissions creates the permissions of the seed file that don't exist
+// yet, the existing permissions are left untouched so that their later edits
+// persist. The permissions are created by the root user unless they name
+// their creator.
+func (p *permissions) seedPermissions(ctx context.Context) error {
+	f, err := seed.Load()
+	if err != nil || f == nil {
+		return err
+	}
+	root := os.Getenv("USERNAME")
+	if root == "" {
+		root = "foo"
+	}
+	for _, permissionBody := range f.Permissions {
+		_, err := p.es.getRawPermission(ctx, permissionBody.Username)
+		if err == nil {
+			log.Println(logTag, ": seed permission", permissionBody.Username, "already exists, skipping...")
+			continue
+		}
+		if util.IsUnreachable(err) && embedded.Enabled() {
+			log.Warnln(logTag, ": elasticsearch is unreachable, skipping the seed permissions")
+			return nil
+		}
+		if !util.IsNotFound(err) {
+			return fmt.Errorf("%s: unable to check whether the seed permission %s exists: %v", logTag, permissionBody.Username, err)
+		}
+
+		creator := permissionBody.Creator
+		if creator == "" {
+			creator = root
+		}
+		opts := permissionOptions(&permissionBody)
+		if permissionBody.Owner != "" {
+			opts = append(opts, permission.SetOwner(permissionBody.Owner))
+		}
+		newPermission, err := permission.New(creator, opts...)
+		if err != nil {
+			return fmt.Errorf("%s: invalid seed permission %s: %v", logTag, permissionBody.Username, err)
+		}
+		newPermission.Username = permissionBody.Username
+		newPermission.Password = permissionBody.Password
+
+		if newPermission.Role != "" {
+			exists, err := p.es.checkRoleExists(ctx, newPermission.Role)
+			if err != nil {
+				return fmt.Errorf("%s: unable to check whether the role of the seed permission %s exists: %v", logTag, permissionBody.Username, err)
+			}
+			if exists {
+				return fmt.Errorf("%s: seed permission %s: permission with role=%s already exists", logTag, permissionBody.Username, newPermission.Role)
+			}
+		}
+		if _, err := p.es.postPermission(ctx, *newPermission); err != nil {
+			return fmt.Errorf("%s: unable to create the seed permission %s: %v", logTag, permissionBody.Username, err)
+		}
+		log.Println(logTag, ": created the seed permission", permissionBody.Username)
+	}
+	return nil
+}
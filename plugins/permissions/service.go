@@ -4,15 +4,19 @@ import (
 	"context"
 
 	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util/occ"
 )
 
 type permissionService interface {
 	getPermission(ctx context.Context, username string) (*permission.Permission, error)
 	getRawPermission(ctx context.Context, username string) ([]byte, error)
+	getRawPermissionVersion(ctx context.Context, username string) ([]byte, occ.Version, error)
 	postPermission(ctx context.Context, p permission.Permission) (bool, error)
 	patchPermission(ctx context.Context, username string, patch map[string]interface{}) ([]byte, error)
+	patchPermissionIfMatch(ctx context.Context, username string, patch map[string]interface{}, version *occ.Version) ([]byte, occ.Version, error)
 	deletePermission(ctx context.Context, username string) (bool, error)
 	getRawOwnerPermissions(ctx context.Context, owner string) ([]byte, error)
 	getRawRolePermission(ctx context.Context, role string) ([]byte, error)
 	checkRoleExists(ctx context.Context, role string) (bool, error)
+	revokePermissions(ctx context.Context, owner, index string) ([]string, int64, error)
 }
@@ -0,0 +1,110 @@
+package pii
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util"
+)
+
+func (p *pii) getPolicies() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := json.Marshal(p.registry.all())
+		if err != nil {
+			msg := "an error occurred while fetching the pii policies"
+			log.Errorln(logTag, ": unable to marshal policies:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (p *pii) getPolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+
+		// the policy applying to the index, which may be that of a pattern
+		c := p.registry.lookup(index)
+		if c == nil {
+			msg := fmt.Sprintf(`pii policy for "index"="%s" not found`, index)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		raw, err := json.Marshal(c.policy)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching the pii policy of "%s"`, index)
+			log.Errorln(logTag, ": unable to marshal policy:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (p *pii) putPolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var policy policy
+		if err := json.Unmarshal(body, &policy); err != nil {
+			msg := "can't parse request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		policy.Index = index
+		policy.UpdatedAt = time.Now()
+		if reqUser, err := user.FromContext(req.Context()); err == nil {
+			policy.Creator = reqUser.Username
+		}
+		c, err := policy.compile()
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := p.es.putPolicy(req.Context(), policy); err != nil {
+			msg := fmt.Sprintf(`an error occurred while saving the pii policy of "%s"`, index)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		p.registry.put(c)
+
+		msg := fmt.Sprintf(`pii policy of "%s" saved`, index)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+func (p *pii) deletePolicy() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+
+		if err := p.es.deletePolicy(req.Context(), index); err != nil {
+			msg := fmt.Sprintf(`pii policy for "index"="%s" not found`, index)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		p.registry.delete(index)
+
+		msg := fmt.Sprintf(`pii policy of "%s" deleted`, index)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
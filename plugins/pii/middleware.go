@@ -0,0 +1,117 @@
+package pii
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clustersCategory := category.Clusters
+		ctx := category.NewContext(req.Context(), &clustersCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusterACL := acl.Cluster
+		ctx := acl.NewContext(req.Context(), &clusterACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the policies apply to the
+// documents indexed by all the credentials.
+var isAdmin = validate.Admin("manage the pii policies")
+
+// redact applies the policies of their indices to the documents of the
+// index, update and bulk requests, a bulk is rejected as a whole if any of
+// its documents is rejected. The index is the one named by the path, which
+// is that of the tenant, if any.
+func (p *pii) redact(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		reqACL, err := acl.FromContext(req.Context())
+		if err != nil || p.registry.empty() || req.Body == nil ||
+			(*reqACL != acl.Index && *reqACL != acl.Create && *reqACL != acl.Update && *reqACL != acl.Bulk) {
+			h(w, req)
+			return
+		}
+		index := pathIndex(req.URL.Path)
+		var c *compiled
+		if *reqACL != acl.Bulk {
+			if c = p.registry.lookup(index); c == nil {
+				h(w, req)
+				return
+			}
+		}
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var redacted []byte
+		var details []util.ErrorDetail
+		switch *reqACL {
+		case acl.Bulk:
+			redacted, details, err = p.registry.redactBulk(body, index, p.salt)
+		case acl.Update:
+			redacted, details, err = redactUpdate(c, body, p.salt, "")
+		default:
+			redacted, details, err = redactDoc(c, body, p.salt, "")
+		}
+		if err != nil || redacted == nil {
+			// the malformed bodies are left for elasticsearch to report
+			redacted = body
+		}
+		if len(details) > 0 {
+			msg := fmt.Sprintf("document contains personal data rejected by the policy of %s", c.Index)
+			if *reqACL == acl.Bulk {
+				msg = "documents of the bulk contain personal data rejected by the policies of their indices"
+			}
+			util.WriteBackErrorWithDetails(w, msg, http.StatusBadRequest, details)
+			return
+		}
+		util.SetRequestBody(req, redacted)
+		h(w, req)
+	}
+}
@@ -0,0 +1,125 @@
+package pii
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                 = "[pii]"
+	defaultPIIEsIndex      = ".pii_policies"
+	envPIIEsIndex          = "PII_ES_INDEX"
+	envRefreshInterval     = "PII_REFRESH_INTERVAL"
+	defaultRefreshInterval = time.Minute
+	envHashSalt            = "PII_HASH_SALT"
+	settings               = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+	mappings               = `
+	{
+	  "dynamic": false,
+	  "properties": {
+	    "index": { "type": "keyword" },
+	    "action": { "type": "keyword" },
+	    "detectors": { "type": "keyword" },
+	    "patterns": { "type": "object", "enabled": false },
+	    "fields": { "type": "keyword" },
+	    "creator": { "type": "keyword" },
+	    "updated_at": { "type": "date" }
+	  }
+	}`
+)
+
+var (
+	singleton *pii
+	once      sync.Once
+)
+
+// pii detects the personal data in the documents indexed into the indices
+// with a policy, and either masks, hashes or rejects them. The policies are
+// stored in elasticsearch and reloaded periodically by each arc instance.
+type pii struct {
+	es              *elasticsearch
+	registry        *registry
+	salt            string
+	refreshInterval time.Duration
+}
+
+// Use only this function to fetch the instance of pii from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *pii {
+	once.Do(func() {
+		singleton = &pii{
+			registry:        newRegistry(),
+			refreshInterval: defaultRefreshInterval,
+		}
+	})
+	return singleton
+}
+
+func (p *pii) Name() string {
+	return logTag
+}
+
+func (p *pii) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	indexName := os.Getenv(envPIIEsIndex)
+	if indexName == "" {
+		indexName = defaultPIIEsIndex
+	}
+	if value := os.Getenv(envRefreshInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Errorln(logTag, ": invalid value for", envRefreshInterval, ":", value)
+		} else {
+			p.refreshInterval = d
+		}
+	}
+	p.salt = os.Getenv(envHashSalt)
+	if p.salt == "" {
+		log.Warnln(logTag, ":", envHashSalt, "isn't set, the hashed values are unsalted")
+	}
+
+	// initialize the dao
+	var err error
+	p.es, err = initPlugin(indexName, settings, mappings)
+	if err != nil {
+		return err
+	}
+
+	// the policies changed through other arc instances are picked up on refresh
+	p.refresh()
+	go func() {
+		for range time.Tick(p.refreshInterval) {
+			p.refresh()
+		}
+	}()
+	return nil
+}
+
+func (p *pii) Routes() []plugins.Route {
+	return p.routes()
+}
+
+func (p *pii) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{p.redact}
+}
+
+// refresh replaces the policies with the ones stored in elasticsearch.
+func (p *pii) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), p.refreshInterval)
+	defer cancel()
+
+	policies, err := p.es.getPolicies(ctx)
+	if err != nil {
+		log.Errorln(logTag, ": unable to load the policies:", err)
+		return
+	}
+	p.registry.set(policies)
+}
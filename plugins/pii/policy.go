@@ -0,0 +1,249 @@
+package pii
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The actions taken on the detected values.
+const (
+	actionMask   = "mask"
+	actionHash   = "hash"
+	actionReject = "reject"
+)
+
+// detectorOrder is the order in which the built-in detectors run, the card
+// numbers are detected first so that their digits aren't taken for phones.
+var detectorOrder = []string{"credit_card", "email", "phone"}
+
+// detectors are the built-in detectors, which can be enabled by name.
+var detectors = map[string]*detector{
+	"email": {
+		name:    "email",
+		pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	},
+	"phone": {
+		name:    "phone",
+		pattern: regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{3}\)|\b\d{3})[\s.\-]?\d{3}[\s.\-]?\d{4}\b`),
+	},
+	"credit_card": {
+		name:    "credit_card",
+		pattern: regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`),
+		valid:   luhn,
+	},
+}
+
+// detector detects the values matching its pattern, the matches can be
+// further checked by valid to rule out the false positives.
+type detector struct {
+	name    string
+	pattern *regexp.Regexp
+	valid   func(string) bool
+}
+
+// policy is the policy of the indices matching the index, which is either a
+// name or a wildcard pattern. The values detected by the detectors or the
+// custom patterns are either masked, hashed or make the document rejected.
+// The fields, if any, restrict the detection to the values of the given
+// fields, along with those nested in them.
+type policy struct {
+	Index     string            `json:"index"`
+	Action    string            `json:"action"`
+	Detectors []string          `json:"detectors,omitempty"`
+	Patterns  map[string]string `json:"patterns,omitempty"`
+	Fields    []string          `json:"fields,omitempty"`
+	Creator   string            `json:"creator,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// compiled is the policy along with its detectors.
+type compiled struct {
+	*policy
+	detectors []*detector
+	pattern   *regexp.Regexp
+}
+
+func (p *policy) compile() (*compiled, error) {
+	if p.Index == "" {
+		return nil, errors.New(`"index" of the policy is required`)
+	}
+	if strings.ContainsAny(p.Index, ",/") {
+		return nil, fmt.Errorf(`invalid "index" "%s", expected a single index name or pattern`, p.Index)
+	}
+	switch p.Action {
+	case actionMask, actionHash, actionReject:
+	case "":
+		p.Action = actionMask
+	default:
+		return nil, fmt.Errorf(`invalid "action" "%s", must be one of "%s", "%s" or "%s"`, p.Action, actionMask, actionHash, actionReject)
+	}
+
+	c := &compiled{policy: p}
+	enabled := make(map[string]bool, len(p.Detectors))
+	for _, name := range p.Detectors {
+		if _, ok := detectors[name]; !ok {
+			return nil, fmt.Errorf(`unknown detector "%s"`, name)
+		}
+		enabled[name] = true
+	}
+	for _, name := range detectorOrder {
+		if enabled[name] {
+			c.detectors = append(c.detectors, detectors[name])
+		}
+	}
+	names := make([]string, 0, len(p.Patterns))
+	for name := range p.Patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		re, err := regexp.Compile(p.Patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf(`invalid pattern "%s": %v`, name, err)
+		}
+		c.detectors = append(c.detectors, &detector{name: name, pattern: re})
+	}
+	if len(c.detectors) == 0 {
+		return nil, errors.New(`either "detectors" or "patterns" of the policy are required`)
+	}
+
+	pattern := "^" + strings.Replace(regexp.QuoteMeta(p.Index), `\*`, ".*", -1) + "$"
+	c.pattern = regexp.MustCompile(pattern)
+	return c, nil
+}
+
+// finding locates a detected value by the json pointer of its field.
+type finding struct {
+	Path     string
+	Detector string
+}
+
+// apply detects the values of the document, which is decoded with its
+// numbers kept as json numbers, and masks or hashes them in place. The
+// findings are returned for the rejecting policies, whose documents are
+// left untouched.
+func (c *compiled) apply(doc interface{}, salt string) (interface{}, []finding, bool) {
+	var findings []finding
+	var modified bool
+	var walk func(value interface{}, path, field string, scanned bool) interface{}
+	walk = func(value interface{}, path, field string, scanned bool) interface{} {
+		scanned = scanned || c.scans(field)
+		switch v := value.(type) {
+		case map[string]interface{}:
+			names := make([]string, 0, len(v))
+			for name := range v {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				nested := name
+				if field != "" {
+					nested = field + "." + name
+				}
+				v[name] = walk(v[name], path+"/"+escape(name), nested, scanned)
+			}
+		case []interface{}:
+			for i, item := range v {
+				v[i] = walk(item, fmt.Sprintf("%s/%d", path, i), field, scanned)
+			}
+		case string:
+			if !scanned {
+				return v
+			}
+			// the later detectors scan the value with the earlier matches
+			// replaced, even if the document is rejected
+			replaced := v
+			for _, d := range c.detectors {
+				replaced = d.pattern.ReplaceAllStringFunc(replaced, func(match string) string {
+					if d.valid != nil && !d.valid(match) {
+						return match
+					}
+					findings = append(findings, finding{Path: path, Detector: d.name})
+					if c.Action == actionHash {
+						return hash(match, salt)
+					}
+					return mask(match)
+				})
+			}
+			if c.Action != actionReject && replaced != v {
+				modified = true
+				return replaced
+			}
+			return v
+		}
+		return value
+	}
+	doc = walk(doc, "", "", false)
+	if c.Action != actionReject {
+		findings = nil
+	}
+	return doc, findings, modified
+}
+
+// scans checks whether the values of the field are scanned, all the values
+// are scanned if the policy doesn't name the fields.
+func (c *compiled) scans(field string) bool {
+	if len(c.Fields) == 0 {
+		return true
+	}
+	for _, f := range c.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// mask replaces the characters of the value, other than the separators,
+// by asterisks.
+func mask(value string) string {
+	masked := make([]rune, 0, utf8.RuneCountInString(value))
+	for _, r := range value {
+		switch r {
+		case '@', '.', '-', ' ':
+			masked = append(masked, r)
+		default:
+			masked = append(masked, '*')
+		}
+	}
+	return string(masked)
+}
+
+// hash replaces the value by its salted sha256, so that the documents
+// sharing the value can still be matched.
+func hash(value, salt string) string {
+	sum := sha256.Sum256([]byte(salt + value))
+	return hex.EncodeToString(sum[:])
+}
+
+// luhn checks the digits of the card number.
+func luhn(number string) bool {
+	var sum, n int
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// escape escapes the field name as a json pointer token.
+func escape(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
@@ -0,0 +1,116 @@
+package pii
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func compilePolicy(p policy) *compiled {
+	c, err := p.compile()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+func TestCompile(t *testing.T) {
+	Convey("The policies mask by default", t, func() {
+		c := compilePolicy(policy{Index: "users", Detectors: []string{"email"}})
+		So(c.Action, ShouldEqual, actionMask)
+	})
+
+	Convey("Invalid policies are rejected", t, func() {
+		for _, p := range []policy{
+			{Index: "users"},
+			{Index: "users", Detectors: []string{"ssn"}},
+			{Index: "users", Action: "drop", Detectors: []string{"email"}},
+			{Index: "users", Patterns: map[string]string{"id": "("}},
+			{Index: "a,b", Detectors: []string{"email"}},
+		} {
+			_, err := p.compile()
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestRedactDoc(t *testing.T) {
+	Convey("The detected values are masked", t, func() {
+		c := compilePolicy(policy{Index: "users", Detectors: []string{"email", "phone", "credit_card"}})
+		raw, details, err := redactDoc(c, []byte(`{"note": "mail jo@ex.com or call 555-123-4567", "card": "4111 1111 1111 1111", "age": 30}`), "", "")
+		So(err, ShouldBeNil)
+		So(details, ShouldBeEmpty)
+		So(string(raw), ShouldEqual, `{"age":30,"card":"**** **** **** ****","note":"mail **@**.*** or call ***-***-****"}`)
+	})
+
+	Convey("Numbers failing the checksum aren't taken for cards", t, func() {
+		c := compilePolicy(policy{Index: "users", Detectors: []string{"credit_card"}})
+		raw, _, err := redactDoc(c, []byte(`{"order": "4111 1111 1111 1112"}`), "", "")
+		So(err, ShouldBeNil)
+		So(raw, ShouldBeNil)
+	})
+
+	Convey("The detected values are hashed", t, func() {
+		c := compilePolicy(policy{Index: "users", Action: actionHash, Patterns: map[string]string{"id": `ID-\d+`}})
+		raw, _, err := redactDoc(c, []byte(`{"id": "ID-42"}`), "salt", "")
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `{"id":"`+hash("ID-42", "salt")+`"}`)
+	})
+
+	Convey("The detection is restricted to the fields", t, func() {
+		c := compilePolicy(policy{Index: "users", Detectors: []string{"email"}, Fields: []string{"contact"}})
+		raw, _, err := redactDoc(c, []byte(`{"contact": {"email": "jo@ex.com"}, "support": "help@ex.com"}`), "", "")
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `{"contact":{"email":"**@**.***"},"support":"help@ex.com"}`)
+	})
+
+	Convey("The rejected values are located without being disclosed", t, func() {
+		c := compilePolicy(policy{Index: "users", Action: actionReject, Detectors: []string{"email"}})
+		raw, details, err := redactDoc(c, []byte(`{"emails": ["jo@ex.com"]}`), "", "")
+		So(err, ShouldBeNil)
+		So(raw, ShouldBeNil)
+		So(len(details), ShouldEqual, 1)
+		So(details[0].Location, ShouldEqual, "/emails/0")
+		So(details[0].Reason, ShouldEqual, "value matching email detected")
+	})
+}
+
+func TestRedactBulk(t *testing.T) {
+	r := newRegistry()
+	r.set([]policy{
+		{Index: "users", Detectors: []string{"email"}},
+		{Index: "logs-*", Action: actionReject, Detectors: []string{"email"}},
+	})
+
+	Convey("The sources of the bulk are redacted by the policies of their indices", t, func() {
+		body := `{"index": {"_id": "1"}}
+{"email": "jo@ex.com"}
+{"update": {"_id": "2"}}
+{"doc": {"email": "al@ex.com"}}
+{"index": {"_index": "orders"}}
+{"email": "jo@ex.com"}
+`
+		raw, details, err := r.redactBulk([]byte(body), "users", "")
+		So(err, ShouldBeNil)
+		So(details, ShouldBeEmpty)
+		So(string(raw), ShouldEqual, `{"index": {"_id": "1"}}
+{"email":"**@**.***"}
+{"update": {"_id": "2"}}
+{"doc":{"email":"**@**.***"}}
+{"index": {"_index": "orders"}}
+{"email": "jo@ex.com"}
+`)
+	})
+
+	Convey("The rejected values are located by the position of their action", t, func() {
+		body := `{"delete": {"_id": "1"}}
+{"create": {"_index": "logs-1"}}
+{"message": "from jo@ex.com"}
+`
+		raw, details, err := r.redactBulk([]byte(body), "users", "")
+		So(err, ShouldBeNil)
+		So(raw, ShouldBeNil)
+		So(len(details), ShouldEqual, 1)
+		So(details[0].Location, ShouldEqual, "[1]/message")
+	})
+}
@@ -0,0 +1,172 @@
+package pii
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// detectedType is the type of the error details locating the detected values.
+const detectedType = "pii_detected"
+
+// redactDoc applies the policy to the document, and returns the redacted
+// document, or nil if it's left untouched, along with the detected values
+// if the policy rejects them. The location prefixes the detected paths.
+func redactDoc(c *compiled, body []byte, salt, location string) ([]byte, []util.ErrorDetail, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, nil, err
+	}
+	doc, findings, modified := c.apply(doc, salt)
+	if len(findings) > 0 {
+		return nil, details(findings, location), nil
+	}
+	if !modified {
+		return nil, nil, nil
+	}
+	raw, err := json.Marshal(doc)
+	return raw, nil, err
+}
+
+// redactUpdate applies the policy to the partial document and the upsert of
+// the update, the scripts aren't inspected.
+func redactUpdate(c *compiled, body []byte, salt, location string) ([]byte, []util.ErrorDetail, error) {
+	var update map[string]json.RawMessage
+	if err := json.Unmarshal(body, &update); err != nil {
+		return nil, nil, err
+	}
+	var all []util.ErrorDetail
+	var modified bool
+	for _, key := range []string{"doc", "upsert"} {
+		doc, ok := update[key]
+		if !ok {
+			continue
+		}
+		redacted, details, err := redactDoc(c, doc, salt, location+"/"+key)
+		if err != nil {
+			return nil, nil, err
+		}
+		all = append(all, details...)
+		if redacted != nil {
+			update[key] = redacted
+			modified = true
+		}
+	}
+	if len(all) > 0 {
+		return nil, all, nil
+	}
+	if !modified {
+		return nil, nil, nil
+	}
+	raw, err := json.Marshal(update)
+	return raw, nil, err
+}
+
+// redactBulk applies the policies of their indices to the sources of the
+// index, create and update actions of the bulk, the actions without an
+// index target the one of the path. The detected values are located by the
+// position of the action in the bulk. It returns nil if the bulk is left
+// untouched.
+func (r *registry) redactBulk(body []byte, pathIndex, salt string) ([]byte, []util.ErrorDetail, error) {
+	var out bytes.Buffer
+	var all []util.ErrorDetail
+	var modified bool
+	var pending *compiled
+	var update, source bool
+	var position int
+	for line := 1; len(body) > 0; line++ {
+		n := bytes.IndexByte(body, '\n')
+		var raw []byte
+		if n < 0 {
+			raw, body = body, nil
+		} else {
+			raw, body = body[:n], body[n+1:]
+		}
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		if source {
+			if pending != nil {
+				redact := redactDoc
+				if update {
+					redact = redactUpdate
+				}
+				redacted, details, err := redact(pending, raw, salt, fmt.Sprintf("[%d]", position))
+				if err != nil {
+					return nil, nil, fmt.Errorf("malformed bulk source on line %d", line)
+				}
+				all = append(all, details...)
+				if redacted != nil {
+					raw, modified = redacted, true
+				}
+			}
+			out.Write(raw)
+			out.WriteByte('\n')
+			pending, source = nil, false
+			position++
+			continue
+		}
+
+		var meta map[string]struct {
+			Index string `json:"_index"`
+		}
+		if err := json.Unmarshal(raw, &meta); err != nil || len(meta) != 1 {
+			return nil, nil, fmt.Errorf("malformed bulk action on line %d", line)
+		}
+		for op, m := range meta {
+			switch op {
+			case "index", "create", "update":
+				index := m.Index
+				if index == "" {
+					index = pathIndex
+				}
+				pending = r.lookup(index)
+				update, source = op == "update", true
+			case "delete":
+				position++
+			default:
+				return nil, nil, fmt.Errorf(`unknown bulk action "%s" on line %d`, op, line)
+			}
+		}
+		out.Write(raw)
+		out.WriteByte('\n')
+	}
+	if len(all) > 0 {
+		return nil, all, nil
+	}
+	if !modified {
+		return nil, nil, nil
+	}
+	return out.Bytes(), nil, nil
+}
+
+// details locates the detected values, without disclosing them.
+func details(findings []finding, location string) []util.ErrorDetail {
+	details := make([]util.ErrorDetail, 0, len(findings))
+	for _, f := range findings {
+		path := f.Path
+		if path == "" {
+			path = "/"
+		}
+		details = append(details, util.ErrorDetail{
+			Type:     detectedType,
+			Reason:   fmt.Sprintf("value matching %s detected", f.Detector),
+			Location: location + path,
+		})
+	}
+	return details
+}
+
+// pathIndex returns the index named by the path, if it names a single one.
+func pathIndex(path string) string {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	if segment == "" || strings.HasPrefix(segment, "_") || strings.Contains(segment, ",") {
+		return ""
+	}
+	return segment
+}
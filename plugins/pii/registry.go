@@ -0,0 +1,85 @@
+package pii
+
+import (
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// registry holds the compiled policies of the indices, looked up on each
+// write.
+type registry struct {
+	sync.RWMutex
+	policies map[string]*compiled
+}
+
+func newRegistry() *registry {
+	return &registry{policies: make(map[string]*compiled)}
+}
+
+// set replaces the policies, the ones that no longer compile are skipped.
+func (r *registry) set(stored []policy) {
+	policies := make(map[string]*compiled, len(stored))
+	for i := range stored {
+		c, err := stored[i].compile()
+		if err != nil {
+			log.Errorln(logTag, ": skipping the policy of", stored[i].Index, ":", err)
+			continue
+		}
+		policies[stored[i].Index] = c
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.policies = policies
+}
+
+func (r *registry) put(c *compiled) {
+	r.Lock()
+	defer r.Unlock()
+	r.policies[c.Index] = c
+}
+
+func (r *registry) delete(index string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.policies, index)
+}
+
+func (r *registry) all() []policy {
+	r.RLock()
+	defer r.RUnlock()
+	all := make([]policy, 0, len(r.policies))
+	for _, c := range r.policies {
+		all = append(all, *c.policy)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Index < all[j].Index })
+	return all
+}
+
+func (r *registry) empty() bool {
+	r.RLock()
+	defer r.RUnlock()
+	return len(r.policies) == 0
+}
+
+// lookup returns the policy of the index, which is the one named after it,
+// else the one of the longest matching pattern.
+func (r *registry) lookup(index string) *compiled {
+	r.RLock()
+	defer r.RUnlock()
+	if c, ok := r.policies[index]; ok {
+		return c
+	}
+	var match *compiled
+	for _, c := range r.policies {
+		if !c.pattern.MatchString(index) {
+			continue
+		}
+		if match == nil || len(c.Index) > len(match.Index) ||
+			(len(c.Index) == len(match.Index) && c.Index < match.Index) {
+			match = c
+		}
+	}
+	return match
+}
@@ -0,0 +1,42 @@
+package pii
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (p *pii) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get pii policies",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_pii_policies",
+			HandlerFunc: middleware(isAdmin(p.getPolicies())),
+			Description: "Returns the pii policies of all the indices",
+		},
+		{
+			Name:        "Get index pii policy",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_pii_policies/{index}",
+			HandlerFunc: middleware(isAdmin(p.getPolicy())),
+			Description: "Returns the pii policy applying to the documents indexed into {index}",
+		},
+		{
+			Name:        "Put index pii policy",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_pii_policies/{index}",
+			HandlerFunc: middleware(isAdmin(p.putPolicy())),
+			Description: "Creates or updates the pii policy of the index or index pattern {index}",
+		},
+		{
+			Name:        "Delete index pii policy",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_pii_policies/{index}",
+			HandlerFunc: middleware(isAdmin(p.deletePolicy())),
+			Description: "Deletes the pii policy of the index or index pattern {index}",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,301 @@
+package querydefaults
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxSize bounds the default size, elasticsearch rejects larger windows by
+// default.
+const maxSize = 10000
+
+// timeoutPattern matches the elasticsearch time units.
+var timeoutPattern = regexp.MustCompile(`^[0-9]+(nanos|micros|ms|s|m|h|d)$`)
+
+// queryDefaults are the settings injected into the searches made to the
+// indices matching the index, which is either a name or a wildcard pattern,
+// unless the searches set them.
+type queryDefaults struct {
+	Index          string          `json:"index"`
+	Size           *int            `json:"size,omitempty"`
+	Sort           json.RawMessage `json:"sort,omitempty"`
+	Timeout        string          `json:"timeout,omitempty"`
+	TrackTotalHits json.RawMessage `json:"track_total_hits,omitempty"`
+	Highlight      json.RawMessage `json:"highlight,omitempty"`
+	// MaxFragmentSize and MaxFragments cap the highlighting of the searches,
+	// including the ones setting their own highlight.
+	MaxFragmentSize int       `json:"max_fragment_size,omitempty"`
+	MaxFragments    int       `json:"max_number_of_fragments,omitempty"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (d *queryDefaults) validate() error {
+	if d.Index == "" {
+		return errors.New(`"index" of the defaults is required`)
+	}
+	if strings.ContainsAny(d.Index, ",/") {
+		return fmt.Errorf(`invalid "index" "%s", expected a single index name or pattern`, d.Index)
+	}
+	if d.Size != nil && (*d.Size < 0 || *d.Size > maxSize) {
+		return fmt.Errorf(`"size" must be between 0 and %d`, maxSize)
+	}
+	if len(d.Sort) > 0 {
+		var sort interface{}
+		if err := json.Unmarshal(d.Sort, &sort); err != nil {
+			return fmt.Errorf(`invalid "sort": %v`, err)
+		}
+		switch sort.(type) {
+		case string, map[string]interface{}, []interface{}:
+		default:
+			return errors.New(`"sort" must be a field, a sort object or an array of them`)
+		}
+	}
+	if d.Timeout != "" && !timeoutPattern.MatchString(d.Timeout) {
+		return fmt.Errorf(`invalid "timeout" "%s", expected a time value such as "5s"`, d.Timeout)
+	}
+	if len(d.TrackTotalHits) > 0 {
+		var track interface{}
+		if err := json.Unmarshal(d.TrackTotalHits, &track); err != nil {
+			return fmt.Errorf(`invalid "track_total_hits": %v`, err)
+		}
+		switch t := track.(type) {
+		case bool:
+		case float64:
+			if t < 0 || t != float64(int(t)) {
+				return errors.New(`"track_total_hits" must be a boolean or a non-negative integer`)
+			}
+		default:
+			return errors.New(`"track_total_hits" must be a boolean or a non-negative integer`)
+		}
+	}
+	if len(d.Highlight) > 0 {
+		var highlight map[string]interface{}
+		if err := json.Unmarshal(d.Highlight, &highlight); err != nil {
+			return errors.New(`"highlight" must be a highlight object`)
+		}
+		if _, ok := highlight["fields"]; !ok {
+			return errors.New(`"highlight" must set the "fields" to highlight`)
+		}
+	}
+	if d.MaxFragmentSize < 0 || d.MaxFragments < 0 {
+		return errors.New(`"max_fragment_size" and "max_number_of_fragments" can't be negative`)
+	}
+	if d.settings() == nil && !d.capsHighlight() {
+		return errors.New(`at least one of "size", "sort", "timeout", "track_total_hits", "highlight", "max_fragment_size" or "max_number_of_fragments" is required`)
+	}
+	return nil
+}
+
+// settings returns the search body settings of the defaults.
+func (d *queryDefaults) settings() map[string]json.RawMessage {
+	settings := make(map[string]json.RawMessage)
+	if d.Size != nil {
+		settings["size"] = json.RawMessage(fmt.Sprintf("%d", *d.Size))
+	}
+	if len(d.Sort) > 0 {
+		settings["sort"] = d.Sort
+	}
+	if d.Timeout != "" {
+		raw, _ := json.Marshal(d.Timeout)
+		settings["timeout"] = raw
+	}
+	if len(d.TrackTotalHits) > 0 {
+		settings["track_total_hits"] = d.TrackTotalHits
+	}
+	if len(d.Highlight) > 0 {
+		settings["highlight"] = d.Highlight
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	return settings
+}
+
+// apply sets the settings absent from both the search body and the query
+// params then caps the highlight of the search, and reports whether the
+// search was modified.
+func (d *queryDefaults) apply(search map[string]json.RawMessage, params map[string][]string) bool {
+	var applied bool
+	for key, value := range d.settings() {
+		if _, ok := search[key]; ok {
+			continue
+		}
+		if _, ok := params[key]; ok {
+			continue
+		}
+		search[key] = value
+		applied = true
+	}
+	if raw, ok := search["highlight"]; ok && d.capsHighlight() {
+		if capped := d.capHighlight(raw); capped != nil {
+			search["highlight"] = capped
+			applied = true
+		}
+	}
+	return applied
+}
+
+func (d *queryDefaults) capsHighlight() bool {
+	return d.MaxFragmentSize > 0 || d.MaxFragments > 0
+}
+
+// capHighlight returns the highlight with its fragment size and number of
+// fragments capped, both the global ones and those of the fields, or nil if
+// they are within the caps. The global options are set when absent since
+// the defaults of elasticsearch may exceed the caps, and a number of
+// fragments of 0, which highlights the whole field, exceeds any cap. The
+// malformed highlights are left for elasticsearch to report.
+func (d *queryDefaults) capHighlight(raw json.RawMessage) json.RawMessage {
+	var highlight map[string]interface{}
+	if err := json.Unmarshal(raw, &highlight); err != nil {
+		return nil
+	}
+	modified := d.capOptions(highlight, true)
+	switch fields := highlight["fields"].(type) {
+	case map[string]interface{}:
+		for _, options := range fields {
+			if o, ok := options.(map[string]interface{}); ok && d.capOptions(o, false) {
+				modified = true
+			}
+		}
+	case []interface{}:
+		for _, field := range fields {
+			f, _ := field.(map[string]interface{})
+			for _, options := range f {
+				if o, ok := options.(map[string]interface{}); ok && d.capOptions(o, false) {
+					modified = true
+				}
+			}
+		}
+	}
+	if !modified {
+		return nil
+	}
+	capped, err := json.Marshal(highlight)
+	if err != nil {
+		return nil
+	}
+	return capped
+}
+
+// capOptions caps the highlight options, the absent ones are set if global.
+func (d *queryDefaults) capOptions(options map[string]interface{}, global bool) bool {
+	var modified bool
+	for key, limit := range map[string]int{"fragment_size": d.MaxFragmentSize, "number_of_fragments": d.MaxFragments} {
+		if limit == 0 {
+			continue
+		}
+		current, set := options[key]
+		value, isNumber := current.(float64)
+		switch {
+		case !set && global:
+		case isNumber && value > float64(limit):
+		case isNumber && value == 0 && key == "number_of_fragments":
+		default:
+			continue
+		}
+		options[key] = limit
+		modified = true
+	}
+	return modified
+}
+
+// entry are the defaults along with the compiled index pattern.
+type entry struct {
+	*queryDefaults
+	pattern *regexp.Regexp
+}
+
+// store holds the defaults of the indices, looked up on each search.
+type store struct {
+	sync.RWMutex
+	entries map[string]*entry
+}
+
+func newStore() *store {
+	return &store{entries: make(map[string]*entry)}
+}
+
+func newEntry(d *queryDefaults) *entry {
+	pattern := "^" + strings.Replace(regexp.QuoteMeta(d.Index), `\*`, ".*", -1) + "$"
+	return &entry{queryDefaults: d, pattern: regexp.MustCompile(pattern)}
+}
+
+func (s *store) set(defaults []queryDefaults) {
+	entries := make(map[string]*entry, len(defaults))
+	for i := range defaults {
+		entries[defaults[i].Index] = newEntry(&defaults[i])
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.entries = entries
+}
+
+func (s *store) put(d queryDefaults) {
+	s.Lock()
+	defer s.Unlock()
+	s.entries[d.Index] = newEntry(&d)
+}
+
+func (s *store) delete(index string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.entries, index)
+}
+
+func (s *store) all() []queryDefaults {
+	s.RLock()
+	defer s.RUnlock()
+	defaults := make([]queryDefaults, 0, len(s.entries))
+	for _, e := range s.entries {
+		defaults = append(defaults, *e.queryDefaults)
+	}
+	sort.Slice(defaults, func(i, j int) bool { return defaults[i].Index < defaults[j].Index })
+	return defaults
+}
+
+// lookup returns the defaults of the indices, those of an index are the ones
+// named after it, else the ones of the longest matching pattern. The searches
+// spanning indices with differing defaults have none.
+func (s *store) lookup(indices []string) *queryDefaults {
+	if len(indices) == 0 {
+		return nil
+	}
+	s.RLock()
+	defer s.RUnlock()
+	var defaults *queryDefaults
+	for i, index := range indices {
+		d := s.forIndex(index)
+		if d == nil || (i > 0 && d != defaults) {
+			return nil
+		}
+		defaults = d
+	}
+	return defaults
+}
+
+func (s *store) forIndex(index string) *queryDefaults {
+	if e, ok := s.entries[index]; ok {
+		return e.queryDefaults
+	}
+	var match *entry
+	for _, e := range s.entries {
+		if !e.pattern.MatchString(index) {
+			continue
+		}
+		if match == nil || len(e.Index) > len(match.Index) ||
+			(len(e.Index) == len(match.Index) && e.Index < match.Index) {
+			match = e
+		}
+	}
+	if match == nil {
+		return nil
+	}
+	return match.queryDefaults
+}
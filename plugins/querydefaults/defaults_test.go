@@ -0,0 +1,117 @@
+package querydefaults
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func TestValidate(t *testing.T) {
+	Convey("The defaults are validated", t, func() {
+		So((&queryDefaults{Index: "products", Size: intPtr(20)}).validate(), ShouldBeNil)
+		So((&queryDefaults{Index: "logs-*", Sort: json.RawMessage(`[{"timestamp": "desc"}]`), Timeout: "5s", TrackTotalHits: json.RawMessage(`1000`)}).validate(), ShouldBeNil)
+		So((&queryDefaults{Index: "products"}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Size: intPtr(20)}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Index: "a,b", Size: intPtr(20)}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Index: "products", Size: intPtr(maxSize + 1)}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Index: "products", Sort: json.RawMessage(`1`)}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Index: "products", Timeout: "5 seconds"}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Index: "products", TrackTotalHits: json.RawMessage(`"yes"`)}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Index: "products", TrackTotalHits: json.RawMessage(`1.5`)}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Index: "products", Highlight: json.RawMessage(`{"fields": {"title": {}}}`)}).validate(), ShouldBeNil)
+		So((&queryDefaults{Index: "products", MaxFragments: 3}).validate(), ShouldBeNil)
+		So((&queryDefaults{Index: "products", Highlight: json.RawMessage(`{"pre_tags": ["<b>"]}`)}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Index: "products", Highlight: json.RawMessage(`["title"]`)}).validate(), ShouldNotBeNil)
+		So((&queryDefaults{Index: "products", MaxFragmentSize: -1}).validate(), ShouldNotBeNil)
+	})
+}
+
+func TestLookup(t *testing.T) {
+	s := newStore()
+	s.set([]queryDefaults{
+		{Index: "logs-*", Size: intPtr(10)},
+		{Index: "logs-app-*", Size: intPtr(20)},
+		{Index: "logs-app-1", Size: intPtr(30)},
+	})
+
+	Convey("The defaults named after the index apply first", t, func() {
+		So(*s.lookup([]string{"logs-app-1"}).Size, ShouldEqual, 30)
+	})
+
+	Convey("Else the ones of the longest matching pattern", t, func() {
+		So(*s.lookup([]string{"logs-app-2"}).Size, ShouldEqual, 20)
+		So(*s.lookup([]string{"logs-db"}).Size, ShouldEqual, 10)
+		So(s.lookup([]string{"products"}), ShouldBeNil)
+	})
+
+	Convey("Indices with differing defaults have none", t, func() {
+		So(*s.lookup([]string{"logs-app-2", "logs-app-3"}).Size, ShouldEqual, 20)
+		So(s.lookup([]string{"logs-app-2", "logs-db"}), ShouldBeNil)
+		So(s.lookup([]string{"logs-app-2", "products"}), ShouldBeNil)
+		So(s.lookup(nil), ShouldBeNil)
+	})
+
+	Convey("The deleted defaults no longer apply", t, func() {
+		s.delete("logs-app-*")
+		So(*s.lookup([]string{"logs-app-2"}).Size, ShouldEqual, 10)
+		So(s.all(), ShouldHaveLength, 2)
+	})
+}
+
+func TestInject(t *testing.T) {
+	d := &queryDefaults{Index: "products", Size: intPtr(20), Timeout: "2s"}
+
+	Convey("The defaults are set unless the search sets them", t, func() {
+		raw, err := injectSearch([]byte(`{"query": {"match_all": {}}, "size": 5}`), d, nil)
+		So(err, ShouldBeNil)
+		var search map[string]interface{}
+		So(json.Unmarshal(raw, &search), ShouldBeNil)
+		So(search["size"], ShouldEqual, 5)
+		So(search["timeout"], ShouldEqual, "2s")
+		So(search["query"], ShouldNotBeNil)
+	})
+
+	Convey("The query params set the settings too", t, func() {
+		raw, err := injectSearch(nil, d, map[string][]string{"size": {"5"}, "timeout": {"1s"}})
+		So(err, ShouldBeNil)
+		So(raw, ShouldBeNil)
+	})
+
+	Convey("The searches of the multi searches get the defaults of their indices", t, func() {
+		q := &queryDefaultsPlugin{store: newStore()}
+		q.store.put(*d)
+		body := "{}\n{\"query\": {\"match_all\": {}}}\n{\"index\": \"orders\"}\n{}\n"
+		raw, err := q.injectMsearch([]byte(body), []string{"products"})
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, "{}\n{\"query\":{\"match_all\":{}},\"size\":20,\"timeout\":\"2s\"}\n{\"index\": \"orders\"}\n{}\n")
+
+		raw, err = q.injectMsearch([]byte(body), []string{"orders"})
+		So(err, ShouldBeNil)
+		So(raw, ShouldBeNil)
+	})
+
+	Convey("The indices are named by the path", t, func() {
+		So(pathIndices("/products,orders/_search"), ShouldResemble, []string{"products", "orders"})
+		So(pathIndices("/_msearch"), ShouldBeNil)
+	})
+
+	Convey("The highlight is set by default and capped", t, func() {
+		d := &queryDefaults{Index: "products", Highlight: json.RawMessage(`{"fields":{"title":{}}}`), MaxFragmentSize: 150, MaxFragments: 3}
+		raw, err := injectSearch([]byte(`{}`), d, nil)
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `{"highlight":{"fields":{"title":{}},"fragment_size":150,"number_of_fragments":3}}`)
+
+		raw, err = injectSearch([]byte(`{"highlight":{"fragment_size":50,"number_of_fragments":2,"fields":[{"body":{"fragment_size":1000,"number_of_fragments":0}}]}}`), d, nil)
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `{"highlight":{"fields":[{"body":{"fragment_size":150,"number_of_fragments":3}}],"fragment_size":50,"number_of_fragments":2}}`)
+
+		raw, err = injectSearch([]byte(`{"highlight":{"fragment_size":50,"number_of_fragments":2,"fields":{"body":{}}}}`), d, nil)
+		So(err, ShouldBeNil)
+		So(raw, ShouldBeNil)
+	})
+}
@@ -0,0 +1,109 @@
+package querydefaults
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+func (q *queryDefaultsPlugin) getAllDefaults() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := json.Marshal(q.store.all())
+		if err != nil {
+			msg := "an error occurred while fetching the query defaults"
+			log.Errorln(logTag, ": unable to marshal query defaults:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (q *queryDefaultsPlugin) getDefaults() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+
+		// the defaults applying to the index, which may be those of a pattern
+		d := q.store.lookup([]string{index})
+		if d == nil {
+			msg := fmt.Sprintf(`query defaults for "index"="%s" not found`, index)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		raw, err := json.Marshal(d)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching the query defaults of "%s"`, index)
+			log.Errorln(logTag, ": unable to marshal query defaults:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (q *queryDefaultsPlugin) putDefaults() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var d queryDefaults
+		if err := json.Unmarshal(body, &d); err != nil {
+			msg := "can't parse request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		d.Index = index
+		d.UpdatedAt = time.Now()
+		if err := d.validate(); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(d.TrackTotalHits) > 0 && util.GetVersion() < 7 {
+			util.WriteBackError(w, `"track_total_hits" requires elasticsearch 7 or above`, http.StatusBadRequest)
+			return
+		}
+
+		if err := q.es.putDefaults(req.Context(), d); err != nil {
+			msg := fmt.Sprintf(`an error occurred while saving the query defaults of "%s"`, index)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		q.store.put(d)
+
+		msg := fmt.Sprintf(`query defaults of "%s" saved`, index)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+func (q *queryDefaultsPlugin) deleteDefaults() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+
+		if err := q.es.deleteDefaults(req.Context(), index); err != nil {
+			msg := fmt.Sprintf(`query defaults for "index"="%s" not found`, index)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		q.store.delete(index)
+
+		msg := fmt.Sprintf(`query defaults of "%s" deleted`, index)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
@@ -0,0 +1,187 @@
+package querydefaults
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/feature"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clustersCategory := category.Clusters
+		ctx := category.NewContext(req.Context(), &clustersCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusterACL := acl.Cluster
+		ctx := acl.NewContext(req.Context(), &clusterACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the defaults apply to the
+// searches of all the credentials.
+var isAdmin = validate.Admin("manage the query defaults")
+
+// inject sets the defaults of the searched indices in the searches that
+// don't set them either in the body or by the query params. The indices are
+// the ones named by the path, which are those of the tenant, if any, and for
+// the multi searches the ones named by the headers. The "rewrite" feature
+// toggled off by an admin leaves the searches untouched.
+func (q *queryDefaultsPlugin) inject(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		reqACL, err := acl.FromContext(req.Context())
+		if err != nil || (*reqACL != acl.Search && *reqACL != acl.Msearch) || !feature.Enabled(req.Context(), feature.Rewrite, true) {
+			h(w, req)
+			return
+		}
+		indices := pathIndices(req.URL.Path)
+		if *reqACL == acl.Search && q.store.lookup(indices) == nil {
+			h(w, req)
+			return
+		}
+
+		var body []byte
+		if req.Body != nil {
+			body, err = util.ReadRequestBody(req)
+			if err != nil {
+				log.Errorln(logTag, ":", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+		}
+		var modified []byte
+		if *reqACL == acl.Msearch {
+			modified, err = q.injectMsearch(body, indices)
+		} else {
+			modified, err = injectSearch(body, q.store.lookup(indices), req.URL.Query())
+		}
+		if err != nil || modified == nil {
+			// the malformed bodies are left for elasticsearch to report
+			modified = body
+		}
+		util.SetRequestBody(req, modified)
+		if req.Method == http.MethodGet && len(modified) > 0 {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		h(w, req)
+	}
+}
+
+// pathIndices returns the indices named by the path, if any.
+func pathIndices(path string) []string {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	if segment == "" || strings.HasPrefix(segment, "_") {
+		return nil
+	}
+	return strings.Split(segment, ",")
+}
+
+// injectSearch returns the search body with the defaults set, or nil if the
+// defaults are already set.
+func injectSearch(body []byte, d *queryDefaults, params map[string][]string) ([]byte, error) {
+	if d == nil {
+		return nil, nil
+	}
+	search := make(map[string]json.RawMessage)
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err := json.Unmarshal(body, &search); err != nil {
+			return nil, err
+		}
+	}
+	if !d.apply(search, params) {
+		return nil, nil
+	}
+	return json.Marshal(search)
+}
+
+// injectMsearch sets the defaults of the indices of each search of the multi
+// search, the searches without indices target the ones of the path.
+func (q *queryDefaultsPlugin) injectMsearch(body []byte, indices []string) ([]byte, error) {
+	var out bytes.Buffer
+	var searchIndices []string
+	var modified bool
+	header := true
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if header {
+			var meta map[string]interface{}
+			if err := json.Unmarshal(line, &meta); err != nil {
+				return nil, err
+			}
+			searchIndices = headerIndices(meta, indices)
+		} else if injected, err := injectSearch(line, q.store.lookup(searchIndices), nil); err != nil {
+			return nil, err
+		} else if injected != nil {
+			line = injected
+			modified = true
+		}
+		out.Write(line)
+		out.WriteByte('\n')
+		header = !header
+	}
+	if !modified {
+		return nil, nil
+	}
+	return out.Bytes(), nil
+}
+
+// headerIndices returns the indices of the msearch header.
+func headerIndices(meta map[string]interface{}, indices []string) []string {
+	switch index := meta["index"].(type) {
+	case string:
+		return strings.Split(index, ",")
+	case []interface{}:
+		var names []string
+		for _, name := range index {
+			if s, ok := name.(string); ok {
+				names = append(names, strings.Split(s, ",")...)
+			}
+		}
+		return names
+	}
+	return indices
+}
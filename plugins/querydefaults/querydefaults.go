@@ -0,0 +1,114 @@
+package querydefaults
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                 = "[querydefaults]"
+	defaultDefaultsEsIndex = ".query_defaults"
+	envDefaultsEsIndex     = "QUERY_DEFAULTS_ES_INDEX"
+	envRefreshInterval     = "QUERY_DEFAULTS_REFRESH_INTERVAL"
+	defaultRefreshInterval = time.Minute
+	settings               = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+	mappings               = `
+	{
+	  "dynamic": false,
+	  "properties": {
+	    "index": { "type": "keyword" },
+	    "updated_at": { "type": "date" }
+	  }
+	}`
+)
+
+var (
+	singleton *queryDefaultsPlugin
+	once      sync.Once
+)
+
+// queryDefaultsPlugin injects the defaults configured for the indices into
+// the searches made to them. The defaults are stored in elasticsearch and
+// reloaded periodically by each arc instance.
+type queryDefaultsPlugin struct {
+	es              *elasticsearch
+	store           *store
+	refreshInterval time.Duration
+}
+
+// Use only this function to fetch the instance of queryDefaultsPlugin from
+// within this package to avoid creating stateless duplicates of the plugin.
+func Instance() *queryDefaultsPlugin {
+	once.Do(func() {
+		singleton = &queryDefaultsPlugin{
+			store:           newStore(),
+			refreshInterval: defaultRefreshInterval,
+		}
+	})
+	return singleton
+}
+
+func (q *queryDefaultsPlugin) Name() string {
+	return logTag
+}
+
+func (q *queryDefaultsPlugin) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	indexName := os.Getenv(envDefaultsEsIndex)
+	if indexName == "" {
+		indexName = defaultDefaultsEsIndex
+	}
+	if value := os.Getenv(envRefreshInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Errorln(logTag, ": invalid value for", envRefreshInterval, ":", value)
+		} else {
+			q.refreshInterval = d
+		}
+	}
+
+	// initialize the dao
+	var err error
+	q.es, err = initPlugin(indexName, settings, mappings)
+	if err != nil {
+		return err
+	}
+
+	// the defaults changed through other arc instances are picked up on refresh
+	q.refresh()
+	go func() {
+		for range time.Tick(q.refreshInterval) {
+			q.refresh()
+		}
+	}()
+	return nil
+}
+
+func (q *queryDefaultsPlugin) Routes() []plugins.Route {
+	return q.routes()
+}
+
+func (q *queryDefaultsPlugin) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{q.inject}
+}
+
+// refresh replaces the defaults with the ones stored in elasticsearch.
+func (q *queryDefaultsPlugin) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), q.refreshInterval)
+	defer cancel()
+
+	defaults, err := q.es.getDefaults(ctx)
+	if err != nil {
+		log.Errorln(logTag, ": unable to load the query defaults:", err)
+		return
+	}
+	q.store.set(defaults)
+}
@@ -0,0 +1,42 @@
+package querydefaults
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (q *queryDefaultsPlugin) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get query defaults",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_query_defaults",
+			HandlerFunc: middleware(isAdmin(q.getAllDefaults())),
+			Description: "Returns the query defaults of all the indices",
+		},
+		{
+			Name:        "Get index query defaults",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_query_defaults/{index}",
+			HandlerFunc: middleware(isAdmin(q.getDefaults())),
+			Description: "Returns the query defaults applying to the searches made to {index}",
+		},
+		{
+			Name:        "Put index query defaults",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_query_defaults/{index}",
+			HandlerFunc: middleware(isAdmin(q.putDefaults())),
+			Description: "Creates or updates the query defaults of the index or index pattern {index}",
+		},
+		{
+			Name:        "Delete index query defaults",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_query_defaults/{index}",
+			HandlerFunc: middleware(isAdmin(q.deleteDefaults())),
+			Description: "Deletes the query defaults of the index or index pattern {index}",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/reqcontext"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/iplookup"
+	"github.com/appbaseio/arc/util/ratelimit"
+)
+
+// limit applies each of the rules to the request and rejects it once any of
+// the limits is exceeded. The limits are all checked before the request is
+// counted against any of them, so that a rejected request doesn't use up the
+// other limits. The RateLimit headers reflect the most restrictive limit.
+func (rl *rateLimit) limit(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type check struct {
+			rule *rule
+			key  string
+		}
+		var checks []check
+		for _, r := range rl.rules {
+			for _, value := range r.values(req) {
+				checks = append(checks, check{r, fmt.Sprintf("ratelimit:%s:%s:%s", r.name, r.key, value)})
+			}
+		}
+
+		for _, c := range checks {
+			result, err := c.rule.limiter.Check(req.Context(), c.key)
+			if err != nil {
+				// fail open, the cluster shouldn't be unavailable because the store is
+				log.Errorln(logTag, ": unable to check rate limit rule", c.rule.name, ":", err)
+				continue
+			}
+			if !result.Allowed {
+				tooManyRequests(w, result)
+				return
+			}
+		}
+
+		var strictest *ratelimit.Result
+		for _, c := range checks {
+			result, err := c.rule.limiter.Allow(req.Context(), c.key)
+			if err != nil {
+				log.Errorln(logTag, ": unable to apply rate limit rule", c.rule.name, ":", err)
+				continue
+			}
+			// a concurrent request may have used up the limit since it was checked
+			if !result.Allowed {
+				tooManyRequests(w, result)
+				return
+			}
+			if strictest == nil || result.Remaining < strictest.Remaining {
+				current := result
+				strictest = &current
+			}
+		}
+		if strictest != nil {
+			setHeaders(w, *strictest)
+		}
+		h(w, req)
+	}
+}
+
+// values returns the values of the rule's key for the request, the request is
+// counted once against each of the indices it targets.
+func (r *rule) values(req *http.Request) []string {
+	switch r.key {
+	case keyIP:
+		return []string{iplookup.FromRequest(req)}
+	case keyCredential:
+		// the credential resolved by the authentication, whether from the
+		// basic auth or a jwt
+		reqCtx, err := reqcontext.FromContext(req.Context())
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			return nil
+		}
+		if username := reqCtx.Username(); username != "" {
+			return []string{username}
+		}
+	case keyIndex:
+		indices, err := index.FromContext(req.Context())
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			return nil
+		}
+		return indices
+	}
+	return nil
+}
+
+func tooManyRequests(w http.ResponseWriter, result ratelimit.Result) {
+	setHeaders(w, result)
+	w.Header().Set("Retry-After", seconds(result.RetryAfter))
+	util.WriteBackError(w, "Rate limit exceeded", http.StatusTooManyRequests)
+}
+
+func setHeaders(w http.ResponseWriter, result ratelimit.Result) {
+	w.Header().Set("RateLimit-Limit", strconv.FormatInt(result.Limit, 10))
+	w.Header().Set("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	w.Header().Set("RateLimit-Reset", seconds(result.Reset))
+}
+
+// seconds rounds the duration up to the whole seconds.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,59 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util/ratelimit"
+)
+
+func TestLimit(t *testing.T) {
+	newRequest := func(username string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/products/_search", nil)
+		search, read := category.Search, op.Read
+		ctx := category.NewContext(req.Context(), &search)
+		ctx = op.NewContext(ctx, &read)
+		ctx = credential.NewContext(ctx, credential.Permission)
+		ctx = permission.NewContext(ctx, &permission.Permission{Username: username})
+		// the basic auth username is ignored in favour of the resolved credential
+		req = req.WithContext(ctx)
+		req.SetBasicAuth("spoofed", "")
+		return req
+	}
+	serve := func(rl *rateLimit, req *http.Request) int {
+		w := httptest.NewRecorder()
+		rl.limit(func(w http.ResponseWriter, req *http.Request) {})(w, req)
+		return w.Code
+	}
+
+	Convey("The requests are limited per resolved credential", t, func() {
+		rules, err := parseRules(`[{"key": "credential", "limit": 1, "period": "1h"}]`, ratelimit.NewMemoryStore())
+		So(err, ShouldBeNil)
+		rl := &rateLimit{rules: rules}
+		So(serve(rl, newRequest("a")), ShouldEqual, http.StatusOK)
+		So(serve(rl, newRequest("a")), ShouldEqual, http.StatusTooManyRequests)
+		So(serve(rl, newRequest("b")), ShouldEqual, http.StatusOK)
+	})
+
+	Convey("The rejected requests don't count against the other limits", t, func() {
+		rules, err := parseRules(`[
+			{"name": "loose", "key": "credential", "limit": 2, "period": "1h"},
+			{"name": "strict", "key": "ip", "limit": 1, "period": "1h"}
+		]`, ratelimit.NewMemoryStore())
+		So(err, ShouldBeNil)
+		rl := &rateLimit{rules: rules}
+		So(serve(rl, newRequest("a")), ShouldEqual, http.StatusOK)
+		So(serve(rl, newRequest("a")), ShouldEqual, http.StatusTooManyRequests)
+
+		result, err := rules[0].limiter.Check(newRequest("a").Context(), "ratelimit:loose:credential:a")
+		So(err, ShouldBeNil)
+		So(result.Remaining, ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util/ratelimit"
+)
+
+const (
+	logTag           = "[ratelimit]"
+	envRules         = "RATE_LIMIT_RULES"
+	envStore         = "RATE_LIMIT_STORE"
+	envRedisAddr     = "RATE_LIMIT_REDIS_ADDR"
+	envRedisPassword = "RATE_LIMIT_REDIS_PASSWORD"
+	envRedisDB       = "RATE_LIMIT_REDIS_DB"
+	storeMemory      = "memory"
+	storeRedis       = "redis"
+	defaultRedisAddr = "localhost:6379"
+	redisKeyPrefix   = "arc:"
+)
+
+var (
+	singleton *rateLimit
+	once      sync.Once
+)
+
+type rateLimit struct {
+	rules []*rule
+}
+
+// Use only this function to fetch the instance of ratelimit from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *rateLimit {
+	once.Do(func() { singleton = &rateLimit{} })
+	return singleton
+}
+
+func (rl *rateLimit) Name() string {
+	return logTag
+}
+
+func (rl *rateLimit) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	store, err := newStore()
+	if err != nil {
+		return err
+	}
+	rl.rules, err = parseRules(os.Getenv(envRules), store)
+	if err != nil {
+		return err
+	}
+	if len(rl.rules) == 0 {
+		log.Println(logTag, ":", envRules, "isn't set, requests won't be limited")
+	}
+	return nil
+}
+
+func (rl *rateLimit) Routes() []plugins.Route {
+	return []plugins.Route{}
+}
+
+func (rl *rateLimit) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{rl.limit}
+}
+
+// newStore returns the store configured via RATE_LIMIT_STORE. The limits are
+// enforced per arc instance unless they are shared via redis.
+func newStore() (ratelimit.Store, error) {
+	switch store := os.Getenv(envStore); store {
+	case "", storeMemory:
+		return ratelimit.NewMemoryStore(), nil
+	case storeRedis:
+		addr := os.Getenv(envRedisAddr)
+		if addr == "" {
+			addr = defaultRedisAddr
+		}
+		db := 0
+		if value := os.Getenv(envRedisDB); value != "" {
+			var err error
+			if db, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %v", envRedisDB, err)
+			}
+		}
+		client := redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: os.Getenv(envRedisPassword),
+			DB:       db,
+		})
+		if err := client.Ping().Err(); err != nil {
+			return nil, fmt.Errorf("unable to connect to redis at %s: %v", addr, err)
+		}
+		return ratelimit.NewRedisStore(client, redisKeyPrefix), nil
+	default:
+		return nil, fmt.Errorf(`invalid value "%s" for %s, must be either "%s" or "%s"`,
+			store, envStore, storeMemory, storeRedis)
+	}
+}
@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/appbaseio/arc/util/ratelimit"
+)
+
+// Keys by which the requests can be limited.
+const (
+	keyIP         = "ip"
+	keyCredential = "credential"
+	keyIndex      = "index"
+)
+
+// ruleSpec is the json representation of a rule in RATE_LIMIT_RULES.
+type ruleSpec struct {
+	Name     string             `json:"name"`
+	Key      string             `json:"key"`
+	Strategy ratelimit.Strategy `json:"strategy"`
+	Limit    int64              `json:"limit"`
+	Period   string             `json:"period"`
+	Burst    int64              `json:"burst"`
+}
+
+// rule limits the requests sharing the same value of the key.
+type rule struct {
+	name    string
+	key     string
+	limiter *ratelimit.Limiter
+}
+
+func parseRules(raw string, store ratelimit.Store) ([]*rule, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var specs []ruleSpec
+	if err := json.Unmarshal([]byte(raw), &specs); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", envRules, err)
+	}
+
+	var rules []*rule
+	names := make(map[string]bool)
+	for i, spec := range specs {
+		if spec.Name == "" {
+			spec.Name = fmt.Sprintf("%s-%d", spec.Key, i)
+		}
+		if names[spec.Name] {
+			return nil, fmt.Errorf(`duplicate rate limit rule "%s"`, spec.Name)
+		}
+		names[spec.Name] = true
+
+		switch spec.Key {
+		case keyIP, keyCredential, keyIndex:
+		default:
+			return nil, fmt.Errorf(`invalid key "%s" for rate limit rule "%s", must be one of "%s", "%s" or "%s"`,
+				spec.Key, spec.Name, keyIP, keyCredential, keyIndex)
+		}
+		period, err := time.ParseDuration(spec.Period)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid period for rate limit rule "%s": %v`, spec.Name, err)
+		}
+		limiter, err := ratelimit.New(store, spec.Strategy, spec.Limit, period, spec.Burst)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid rate limit rule "%s": %v`, spec.Name, err)
+		}
+		rules = append(rules, &rule{name: spec.Name, key: spec.Key, limiter: limiter})
+	}
+	return rules, nil
+}
@@ -0,0 +1,24 @@
+package reactivesearch
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+type elasticsearch struct{}
+
+func (es *elasticsearch) search(ctx context.Context, index string, dsl map[string]interface{}) ([]byte, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(index) + "/_search",
+		Body:   dsl,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
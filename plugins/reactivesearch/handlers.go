@@ -0,0 +1,56 @@
+package reactivesearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+func (r *reactiveSearch) search() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		// the path names the index of the tenant, if any
+		indexName := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		var searchBody searchBody
+		if len(bytes.TrimSpace(body)) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			if err := decoder.Decode(&searchBody); err != nil {
+				util.WriteBackError(w, "can't parse request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		var fields restrictions
+		if reqPermission, err := permission.FromContext(req.Context()); err == nil {
+			fields = restrictions{reqPermission.Includes, reqPermission.Excludes}
+		}
+		dsl, err := searchBody.dsl(fields)
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		raw, err := r.es.search(req.Context(), indexName, dsl)
+		if err != nil {
+			msg := "an error occurred while searching " + indexName
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
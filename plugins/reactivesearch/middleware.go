@@ -0,0 +1,71 @@
+package reactivesearch
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/tenancy"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The declarative searches are searches, hence are validated as the
+// searches made to elasticsearch are.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classifyOp,
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Sources(),
+		validate.Referers(),
+		validate.Indices(),
+		validate.Category(),
+		validate.ACL(),
+		validate.Operation(),
+		validate.PermissionExpiry(),
+		tenancy.Isolate(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searchCategory := category.Search
+		ctx := category.NewContext(req.Context(), &searchCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searchACL := acl.Search
+		ctx := acl.NewContext(req.Context(), &searchACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyOp(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		readOp := op.Read
+		ctx := op.NewContext(req.Context(), &readOp)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
@@ -0,0 +1,225 @@
+package reactivesearch
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/appbaseio/arc/model/permission"
+)
+
+const (
+	defaultSize      = 10
+	defaultFacetSize = 10
+	maxFacetSize     = 1000
+	// maxWindow is the default index.max_result_window of elasticsearch.
+	maxWindow = 10000
+)
+
+// searchBody is the declarative search, it's translated into the query DSL
+// by dsl.
+type searchBody struct {
+	// Query is the text searched in the Fields, all the documents match
+	// when it's empty.
+	Query string `json:"query"`
+	// Fields maps the searched fields to their weights.
+	Fields  map[string]float64 `json:"fields"`
+	Filters []filter           `json:"filters"`
+	Facets  []facet            `json:"facets"`
+	From    int                `json:"from"`
+	Size    *int               `json:"size"`
+	Sort    []sortField        `json:"sort"`
+}
+
+// filter restricts the hits to the documents whose field either holds one of
+// the values or falls in the range.
+type filter struct {
+	Field  string        `json:"field"`
+	Values []interface{} `json:"values,omitempty"`
+	Range  *valueRange   `json:"range,omitempty"`
+}
+
+type valueRange struct {
+	GT  interface{} `json:"gt,omitempty"`
+	GTE interface{} `json:"gte,omitempty"`
+	LT  interface{} `json:"lt,omitempty"`
+	LTE interface{} `json:"lte,omitempty"`
+}
+
+func (r *valueRange) bounded() bool {
+	return r.GT != nil || r.GTE != nil || r.LT != nil || r.LTE != nil
+}
+
+// facet aggregates the most frequent values of the field.
+type facet struct {
+	Field string `json:"field"`
+	Size  int    `json:"size"`
+}
+
+type sortField struct {
+	Field string `json:"field"`
+	Order string `json:"order"`
+}
+
+// restrictions are the field patterns a credential is restricted to, the
+// include_fields and exclude_fields of its permission. The fields that are
+// filtered out of the hits can't be searched, filtered, faceted or sorted on
+// either.
+type restrictions struct {
+	includes []string
+	excludes []string
+}
+
+func (r restrictions) restricted() bool {
+	return len(r.excludes) > 0 || !(len(r.includes) == 0 || (len(r.includes) == 1 && r.includes[0] == "*"))
+}
+
+// allowed checks whether the field is allowed by the restrictions, a pattern
+// matching an object field matches its sub fields as source filters do.
+func (r restrictions) allowed(field string) bool {
+	if !r.restricted() {
+		return true
+	}
+	if len(r.includes) > 0 && !permission.MatchesField(r.includes, field) {
+		return false
+	}
+	return !permission.MatchesField(r.excludes, field)
+}
+
+// dsl validates the search and translates it into the query DSL.
+func (b *searchBody) dsl(r restrictions) (map[string]interface{}, error) {
+	check := func(field, of string) error {
+		if field == "" {
+			return fmt.Errorf(`"field" of %s is required`, of)
+		}
+		if !r.allowed(field) {
+			return fmt.Errorf(`field "%s" isn't accessible`, field)
+		}
+		return nil
+	}
+
+	boolQuery := map[string]interface{}{}
+	if b.Query != "" {
+		if len(b.Fields) == 0 {
+			return nil, errors.New(`"fields" are required to search the "query"`)
+		}
+		fields := make([]string, 0, len(b.Fields))
+		for field, weight := range b.Fields {
+			if err := check(field, "fields"); err != nil {
+				return nil, err
+			}
+			if weight <= 0 {
+				return nil, fmt.Errorf(`weight of field "%s" must be positive`, field)
+			}
+			fields = append(fields, fmt.Sprintf("%s^%g", field, weight))
+		}
+		sort.Strings(fields)
+		boolQuery["must"] = map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  b.Query,
+				"fields": fields,
+				"type":   "best_fields",
+			},
+		}
+	}
+
+	var filters []interface{}
+	for _, f := range b.Filters {
+		if err := check(f.Field, "filters"); err != nil {
+			return nil, err
+		}
+		switch {
+		case len(f.Values) > 0 && f.Range == nil:
+			filters = append(filters, map[string]interface{}{
+				"terms": map[string]interface{}{f.Field: f.Values},
+			})
+		case len(f.Values) == 0 && f.Range != nil:
+			if !f.Range.bounded() {
+				return nil, fmt.Errorf(`"range" of field "%s" must be bounded`, f.Field)
+			}
+			filters = append(filters, map[string]interface{}{
+				"range": map[string]interface{}{f.Field: f.Range},
+			})
+		default:
+			return nil, fmt.Errorf(`filter on field "%s" requires either "values" or a "range"`, f.Field)
+		}
+	}
+	if len(filters) > 0 {
+		boolQuery["filter"] = filters
+	}
+
+	query := map[string]interface{}{"match_all": map[string]interface{}{}}
+	if len(boolQuery) > 0 {
+		query = map[string]interface{}{"bool": boolQuery}
+	}
+
+	size := defaultSize
+	if b.Size != nil {
+		size = *b.Size
+	}
+	if b.From < 0 || size < 0 {
+		return nil, errors.New(`"from" and "size" can't be negative`)
+	}
+	if b.From+size > maxWindow {
+		return nil, fmt.Errorf(`"from" and "size" can't exceed %d hits`, maxWindow)
+	}
+	dsl := map[string]interface{}{
+		"query": query,
+		"from":  b.From,
+		"size":  size,
+	}
+
+	if len(b.Facets) > 0 {
+		aggs := make(map[string]interface{}, len(b.Facets))
+		for _, f := range b.Facets {
+			if err := check(f.Field, "facets"); err != nil {
+				return nil, err
+			}
+			if _, ok := aggs[f.Field]; ok {
+				return nil, fmt.Errorf(`duplicate facet on field "%s"`, f.Field)
+			}
+			facetSize := f.Size
+			if facetSize == 0 {
+				facetSize = defaultFacetSize
+			}
+			if facetSize < 0 || facetSize > maxFacetSize {
+				return nil, fmt.Errorf(`"size" of facet "%s" must be between 1 and %d`, f.Field, maxFacetSize)
+			}
+			aggs[f.Field] = map[string]interface{}{
+				"terms": map[string]interface{}{"field": f.Field, "size": facetSize},
+			}
+		}
+		dsl["aggs"] = aggs
+	}
+
+	if len(b.Sort) > 0 {
+		sorts := make([]interface{}, 0, len(b.Sort))
+		for _, s := range b.Sort {
+			if err := check(s.Field, "sort"); err != nil {
+				return nil, err
+			}
+			order := strings.ToLower(s.Order)
+			if order == "" {
+				order = "asc"
+			}
+			if order != "asc" && order != "desc" {
+				return nil, fmt.Errorf(`invalid sort order "%s" of field "%s", must be either "asc" or "desc"`, s.Order, s.Field)
+			}
+			sorts = append(sorts, map[string]interface{}{s.Field: map[string]interface{}{"order": order}})
+		}
+		dsl["sort"] = sorts
+	}
+
+	if r.restricted() {
+		source := map[string]interface{}{}
+		if len(r.includes) > 0 {
+			source["includes"] = r.includes
+		}
+		if len(r.excludes) > 0 {
+			source["excludes"] = r.excludes
+		}
+		dsl["_source"] = source
+	}
+	return dsl, nil
+}
@@ -0,0 +1,89 @@
+package reactivesearch
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func toDSL(body string, r restrictions) (string, error) {
+	var b searchBody
+	if err := json.Unmarshal([]byte(body), &b); err != nil {
+		return "", err
+	}
+	dsl, err := b.dsl(r)
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(dsl)
+	return string(raw), err
+}
+
+func TestDSL(t *testing.T) {
+	Convey("An empty search matches all the documents", t, func() {
+		dsl, err := toDSL(`{}`, restrictions{})
+		So(err, ShouldBeNil)
+		So(dsl, ShouldEqual, `{"from":0,"query":{"match_all":{}},"size":10}`)
+	})
+
+	Convey("The search is translated into the query DSL", t, func() {
+		dsl, err := toDSL(`{
+			"query": "running shoes",
+			"fields": {"title": 3, "description": 1},
+			"filters": [{"field": "brand", "values": ["nike", "adidas"]}, {"field": "price", "range": {"gte": 10, "lt": 100}}],
+			"facets": [{"field": "brand", "size": 5}],
+			"from": 20,
+			"size": 20,
+			"sort": [{"field": "price", "order": "DESC"}]
+		}`, restrictions{})
+		So(err, ShouldBeNil)
+		So(dsl, ShouldEqual, `{"aggs":{"brand":{"terms":{"field":"brand","size":5}}},"from":20,`+
+			`"query":{"bool":{"filter":[{"terms":{"brand":["nike","adidas"]}},{"range":{"price":{"gte":10,"lt":100}}}],`+
+			`"must":{"multi_match":{"fields":["description^1","title^3"],"query":"running shoes","type":"best_fields"}}}},`+
+			`"size":20,"sort":[{"price":{"order":"desc"}}]}`)
+	})
+
+	Convey("Invalid searches are rejected", t, func() {
+		invalid := []string{
+			`{"query": "shoes"}`,
+			`{"query": "shoes", "fields": {"title": 0}}`,
+			`{"filters": [{"field": "brand"}]}`,
+			`{"filters": [{"field": "price", "range": {}}]}`,
+			`{"filters": [{"values": ["nike"]}]}`,
+			`{"facets": [{"field": "brand"}, {"field": "brand"}]}`,
+			`{"from": 9995, "size": 10}`,
+			`{"sort": [{"field": "price", "order": "up"}]}`,
+		}
+		for _, body := range invalid {
+			_, err := toDSL(body, restrictions{})
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
+
+func TestRestrictions(t *testing.T) {
+	r := restrictions{includes: []string{"title", "brand*", "meta"}, excludes: []string{"meta.internal"}}
+
+	Convey("Fields are allowed by the include and exclude patterns", t, func() {
+		So(r.allowed("title"), ShouldBeTrue)
+		So(r.allowed("brand.keyword"), ShouldBeTrue)
+		So(r.allowed("meta.tags"), ShouldBeTrue)
+		So(r.allowed("meta.internal.cost"), ShouldBeFalse)
+		So(r.allowed("price"), ShouldBeFalse)
+		So(restrictions{includes: []string{"*"}}.allowed("price"), ShouldBeTrue)
+	})
+
+	Convey("Searches on inaccessible fields are rejected", t, func() {
+		_, err := toDSL(`{"query": "shoes", "fields": {"title": 1, "price": 1}}`, r)
+		So(err, ShouldNotBeNil)
+		_, err = toDSL(`{"sort": [{"field": "meta.internal"}]}`, r)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("The hits are source filtered", t, func() {
+		dsl, err := toDSL(`{"facets": [{"field": "brand.keyword"}]}`, r)
+		So(err, ShouldBeNil)
+		So(dsl, ShouldContainSubstring, `"_source":{"excludes":["meta.internal"],"includes":["title","brand*","meta"]}`)
+	})
+}
@@ -0,0 +1,49 @@
+package reactivesearch
+
+import (
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const logTag = "[reactivesearch]"
+
+var (
+	singleton *reactiveSearch
+	once      sync.Once
+)
+
+// reactiveSearch translates the declarative search requests into the query
+// DSL of elasticsearch, so that the clients don't have to know the DSL.
+type reactiveSearch struct {
+	es searchService
+}
+
+// Use only this function to fetch the instance of reactiveSearch from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *reactiveSearch {
+	once.Do(func() { singleton = &reactiveSearch{} })
+	return singleton
+}
+
+func (r *reactiveSearch) Name() string {
+	return logTag
+}
+
+func (r *reactiveSearch) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+	r.es = &elasticsearch{}
+	return nil
+}
+
+func (r *reactiveSearch) Routes() []plugins.Route {
+	return r.routes()
+}
+
+// Default empty middleware array function
+func (r *reactiveSearch) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
@@ -0,0 +1,21 @@
+package reactivesearch
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (r *reactiveSearch) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Reactive search",
+			Methods:     []string{http.MethodPost},
+			Path:        "/{index}/_reactivesearch",
+			HandlerFunc: middleware(r.search()),
+			Description: "Translates the declarative search of the body into the query DSL and searches {index} with it",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,7 @@
+package reactivesearch
+
+import "context"
+
+type searchService interface {
+	search(ctx context.Context, index string, dsl map[string]interface{}) ([]byte, error)
+}
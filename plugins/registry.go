@@ -1,8 +1,13 @@
 package plugins
 
 import (
+	"fmt"
+	"net/http"
+	"os"
 	"sort"
 	"strconv"
+	"strings"
+	"sync"
 
 	log "github.com/sirupsen/logrus"
 
@@ -11,13 +16,41 @@ import (
 	"github.com/gorilla/mux"
 )
 
-const logTag = "[registry]"
+const (
+	logTag         = "[registry]"
+	envLegacyPaths = "LEGACY_API_PATHS"
+
+	// APIVersion prefixes the paths of the arc apis, the paths without the
+	// prefix are served for backwards compatibility unless disabled.
+	APIVersion = "/v1"
+)
 
 // plugins is a map of a unique identifier, usually the plugin name,
 // to the Plugin. So, in practice all plugins must have a name,
 // preferably following the same practice while naming a package.
 var plugins = make(map[string]Plugin)
 
+// handler is the router the routes of the plugins are loaded in, served is
+// the handler arc serves the http requests with.
+var (
+	handlerMu sync.RWMutex
+	handler   http.Handler
+	served    http.Handler
+)
+
+// statuses records the outcome of initializing each of the loaded plugins.
+var (
+	statusMu sync.RWMutex
+	statuses = make(map[string]Status)
+)
+
+// Status reports whether the plugin was initialized successfully.
+type Status struct {
+	Name   string `json:"name"`
+	Loaded bool   `json:"loaded"`
+	Error  string `json:"error,omitempty"`
+}
+
 type nameRoutes interface {
 	// Name returns the name of the plugin. Name of the plugin must be
 	// unique as it is the name of the plugin that is used as a key
@@ -70,20 +103,80 @@ func RegisterPlugin(p Plugin) {
 // calling loadRoutes
 func LoadPlugin(router *mux.Router, p Plugin) error {
 	log.Println(logTag, ": Initializing plugin:", p.Name())
+	setHandler(router)
 	err := p.InitFunc()
-	if err != nil {
-		return err
+	if err == nil {
+		err = loadVersionedRoutes(router, p, os.Getenv(envLegacyPaths) != "false")
 	}
-	return loadRoutes(router, p)
+	setStatus(p.Name(), err)
+	return err
 }
 
 func LoadESPlugin(router *mux.Router, p ESPlugin, mw []middleware.Middleware) error {
 	log.Println(logTag, ": Initializing plugin:", p.Name())
+	setHandler(router)
 	err := p.InitFunc(mw)
+	if err == nil {
+		err = loadRoutes(router, p)
+	}
+	setStatus(p.Name(), err)
+	return err
+}
+
+func setHandler(router *mux.Router) {
+	handlerMu.Lock()
+	defer handlerMu.Unlock()
+	handler = router
+}
+
+// Handler returns the router serving the routes of the loaded plugins, so
+// that the plugins serving the apis over other protocols can dispatch their
+// requests through the same middleware and handlers. It's nil until the
+// first plugin is loaded.
+func Handler() http.Handler {
+	handlerMu.RLock()
+	defer handlerMu.RUnlock()
+	return handler
+}
+
+// SetServedHandler registers the handler arc serves the http requests with,
+// i.e. the router wrapped in the middleware applied to every request.
+func SetServedHandler(h http.Handler) {
+	handlerMu.Lock()
+	defer handlerMu.Unlock()
+	served = h
+}
+
+// ServedHandler returns the handler arc serves the http requests with, so
+// that the plugins serving the apis over other protocols are bound by the
+// same deadlines, load shedding, headers and request logs as http. It's nil
+// until arc starts serving the http requests.
+func ServedHandler() http.Handler {
+	handlerMu.RLock()
+	defer handlerMu.RUnlock()
+	return served
+}
+
+func setStatus(name string, err error) {
+	status := Status{Name: name, Loaded: err == nil}
 	if err != nil {
-		return err
+		status.Error = err.Error()
+	}
+	statusMu.Lock()
+	defer statusMu.Unlock()
+	statuses[name] = status
+}
+
+// Statuses returns the status of the loaded plugins sorted by name.
+func Statuses() []Status {
+	statusMu.RLock()
+	list := make([]Status, 0, len(statuses))
+	for _, status := range statuses {
+		list = append(list, status)
 	}
-	return loadRoutes(router, p)
+	statusMu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
 }
 
 // loadRoutes registers the routes to the router that are associated with
@@ -102,6 +195,58 @@ func loadRoutes(router *mux.Router, p nameRoutes) error {
 	return nil
 }
 
+// loadVersionedRoutes registers the routes of the plugin under the api
+// version prefix, which is stripped before the request is handled. The
+// legacy unversioned paths are registered as well if enabled, their
+// responses point at the versioned paths.
+func loadVersionedRoutes(router *mux.Router, p nameRoutes, legacy bool) error {
+	for _, r := range p.Routes() {
+		err := router.Methods(r.Methods...).
+			Name(r.Name).
+			Path(APIVersion + r.Path).
+			HandlerFunc(stripVersion(r.HandlerFunc)).
+			GetError()
+		if err != nil {
+			return err
+		}
+		if !legacy {
+			continue
+		}
+		err = router.Methods(r.Methods...).
+			Name(r.Name + " (legacy)").
+			Path(r.Path).
+			HandlerFunc(deprecated(r.HandlerFunc)).
+			GetError()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripVersion removes the api version prefix from the request path, the
+// path variables have been matched already.
+func stripVersion(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		u := *req.URL
+		u.Path = strings.TrimPrefix(u.Path, APIVersion)
+		u.RawPath = strings.TrimPrefix(u.RawPath, APIVersion)
+		r := req.WithContext(req.Context())
+		r.URL = &u
+		h(w, r)
+	}
+}
+
+// deprecated marks the responses of the legacy paths as deprecated in favour
+// of the versioned paths.
+func deprecated(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="successor-version"`, APIVersion, req.URL.EscapedPath()))
+		h(w, req)
+	}
+}
+
 // ListPluginsStr returns a string listing the registered plugins.
 func ListPluginsStr() string {
 	str := "Registered plugins:\n"
@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type routesOnly []Route
+
+func (r routesOnly) Name() string    { return "[routes]" }
+func (r routesOnly) Routes() []Route { return r }
+
+func TestLoadVersionedRoutes(t *testing.T) {
+	var path, username string
+	p := routesOnly{{
+		Name:    "Get user",
+		Methods: []string{http.MethodGet},
+		Path:    "/_user/{username}",
+		HandlerFunc: func(w http.ResponseWriter, req *http.Request) {
+			path, username = req.URL.Path, mux.Vars(req)["username"]
+		},
+	}}
+	serve := func(router *mux.Router, target string) *httptest.ResponseRecorder {
+		path, username = "", ""
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	Convey("The versioned paths are handled as the unversioned ones", t, func() {
+		router := mux.NewRouter()
+		So(loadVersionedRoutes(router, p, true), ShouldBeNil)
+		w := serve(router, "/v1/_user/foo")
+		So(path, ShouldEqual, "/_user/foo")
+		So(username, ShouldEqual, "foo")
+		So(w.Header().Get("Deprecation"), ShouldBeEmpty)
+	})
+
+	Convey("The legacy paths point at the versioned ones", t, func() {
+		router := mux.NewRouter()
+		So(loadVersionedRoutes(router, p, true), ShouldBeNil)
+		w := serve(router, "/_user/foo")
+		So(username, ShouldEqual, "foo")
+		So(w.Header().Get("Deprecation"), ShouldEqual, "true")
+		So(w.Header().Get("Link"), ShouldEqual, `</v1/_user/foo>; rel="successor-version"`)
+	})
+
+	Convey("The legacy paths can be disabled", t, func() {
+		router := mux.NewRouter()
+		So(loadVersionedRoutes(router, p, false), ShouldBeNil)
+		w := serve(router, "/_user/foo")
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(username, ShouldBeEmpty)
+	})
+}
+
+func TestServedHandler(t *testing.T) {
+	defer SetServedHandler(nil)
+
+	Convey("The served handler is the wrapped router, once arc serves", t, func() {
+		router := mux.NewRouter()
+		setHandler(router)
+		So(ServedHandler(), ShouldBeNil)
+
+		wrapped := http.StripPrefix("/", router)
+		SetServedHandler(wrapped)
+		So(ServedHandler(), ShouldEqual, wrapped)
+		So(Handler(), ShouldEqual, router)
+	})
+}
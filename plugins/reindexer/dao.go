@@ -4,10 +4,12 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/notify"
 	es7 "github.com/olivere/elastic/v7"
 )
 
@@ -28,6 +30,11 @@ import (
 func reindex(ctx context.Context, sourceIndex string, config *reindexConfig, waitForCompletion bool, destinationIndex string) ([]byte, error) {
 	var err error
 
+	// Mapping types have been removed in the type-less clusters.
+	if len(config.Types) > 0 && util.IsTypeless() {
+		return nil, fmt.Errorf(`"types" are not supported by elasticsearch %d`, util.GetVersion())
+	}
+
 	// We fetch the index name pointing to the given alias first.
 	// If an index has already been reindexed before, user would
 	// pass in the alias i.e. the original name of the index when
@@ -66,19 +73,45 @@ func reindex(ctx context.Context, sourceIndex string, config *reindexConfig, wai
 	body := make(map[string]interface{})
 	body["mappings"] = config.Mappings
 	body["settings"] = config.Settings
-	newIndexName := destinationIndex
-	if destinationIndex == "" {
-		newIndexName, err = reindexedName(sourceIndex)
-	}
-
+	exists := func(name string) (bool, error) { return indexExists(ctx, name) }
+	newIndexName, found, err := destinationName(sourceIndex, destinationIndex, config, time.Now(), exists)
 	if err != nil {
+		if _, ok := err.(*indexExistsError); ok {
+			return nil, err
+		}
 		return nil, fmt.Errorf(`error generating a new index name for index "%s": %v`, sourceIndex, err)
 	}
 
+	// Overwrite the existing destination index if asked to, or reindex into
+	// it as it is.
+	if found && config.OnConflict == conflictOverwrite {
+		if err := deleteIndex(ctx, newIndexName); err != nil {
+			return nil, fmt.Errorf(`error overwriting index "%s": %v`, newIndexName, err)
+		}
+		found = false
+	}
+
+	// Restore a snapshot of the source index into the new index instead of
+	// copying its documents, unless the changes of the reindex require them
+	// to be copied.
+	var fallback string
+	if config.Strategy == strategySnapshot {
+		fallback, err = snapshotIncompatibility(ctx, sourceIndex, config, found)
+		if err != nil {
+			return nil, err
+		}
+		if fallback == "" {
+			return snapshotReindex(ctx, sourceIndex, newIndexName, destinationIndex == "", config)
+		}
+		log.Warnln(logTag, ": copying the documents of index", sourceIndex, "instead of restoring a snapshot:", fallback)
+	}
+
 	// Create the new index.
-	err = createIndex(ctx, newIndexName, body)
-	if err != nil {
-		return nil, err
+	if !found {
+		err = createIndex(ctx, newIndexName, body)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// abruptly return if action is mappings
@@ -86,64 +119,89 @@ func reindex(ctx context.Context, sourceIndex string, config *reindexConfig, wai
 		return nil, nil
 	}
 
-	// Configure reindex source
-	src := es7.NewReindexSource().
-		Index(sourceIndex).
-		Type(config.Types...).
-		FetchSourceIncludeExclude(config.Include, config.Exclude)
+	// Large indices are reindexed in slices running in parallel.
+	slices, err := Instance().slicesFor(ctx, sourceIndex, config.Slices)
+	if err != nil {
+		return nil, err
+	}
 
 	// Configure reindex dest
 	dest := es7.NewReindexDestination().
 		Index(newIndexName)
 
-	// Reindex action.
-	reindex := util.GetClient7().Reindex().
-		Source(src).
-		Destination(dest).
-		WaitForCompletion(waitForCompletion)
+	// Reindex action, one per slice.
+	reindexSlice := func(slice int) *es7.ReindexService {
+		return util.GetClient7().Reindex().
+			Source(reindexSource(sourceIndex, config, slice, slices)).
+			Destination(dest).
+			WaitForCompletion(waitForCompletion)
+	}
 
 	// If wait_for_completion = true, then we carry out the task synchronously along with three more steps:
 	// 	- fetch any aliases of the old index
 	//  - delete the old index
 	//  - set the aliases of the old index to the new index
 	if waitForCompletion {
-		response, err := reindex.Do(ctx)
+		response, err := runSlices(slices, func(slice int) (*es7.BulkIndexByScrollResponse, error) {
+			return reindexSlice(slice).Do(ctx)
+		})
 		if err != nil {
 			return nil, err
 		}
 
-		if destinationIndex == "" {
-			// Fetch all the aliases of old index
-			aliases, err := aliasesOf(ctx, sourceIndex)
+		// Verify the destination before the source is replaced by it.
+		var result *verification
+		if config.Verify {
+			result, err = verifyReindex(ctx, sourceIndex, newIndexName, config)
 			if err != nil {
-				return nil, fmt.Errorf(`error fetching aliases of index "%s": %v`, sourceIndex, err)
+				return nil, err
 			}
-			aliases = append(aliases, sourceIndex)
-
-			// Delete old index
-			err = deleteIndex(ctx, sourceIndex)
-			if err != nil {
-				return nil, fmt.Errorf(`error deleting index "%s": %v\n`, sourceIndex, err)
+			if !result.Passed {
+				return nil, &verificationError{sourceIndex: sourceIndex, destIndex: newIndexName, result: result}
 			}
-			// Set aliases of old index to the new index.
-			err = setAlias(ctx, newIndexName, aliases...)
-			if err != nil {
-				return nil, fmt.Errorf(`error setting alias "%s" for index "%s"`, sourceIndex, newIndexName)
+		}
+
+		if destinationIndex == "" {
+			if err := replaceIndex(ctx, sourceIndex, newIndexName); err != nil {
+				return nil, err
 			}
 		}
 
-		return json.Marshal(response)
+		notify.Send(notify.Event{
+			Type:     notify.ReindexCompleted,
+			Resource: sourceIndex,
+			Message:  fmt.Sprintf(`reindexed %d documents of index "%s" into "%s" in %dms`, response.Created+response.Updated, sourceIndex, newIndexName, response.Took),
+			Details:  response,
+		})
+		return json.Marshal(verifiedResponse{
+			BulkIndexByScrollResponse: response,
+			Verification:              result,
+			SnapshotFallback:          fallback,
+		})
 	}
 
 	// If wait_for_completion = false, we carry out the reindexing asynchronously and return the task ID.
-	response, err := reindex.DoAsync(context.Background())
+	tasks, err := startSlices(slices, func(slice int) (string, error) {
+		response, err := reindexSlice(slice).DoAsync(context.Background())
+		if err != nil {
+			return "", err
+		}
+		return response.TaskId, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	taskID := response.TaskId
+
+	// The progress of the slices is aggregated by the progress route.
+	if slices > 1 {
+		return json.Marshal(map[string]interface{}{
+			"slices": slices,
+			"tasks":  tasks,
+		})
+	}
 
 	// Get the reindex task by ID
-	task, err := util.GetClient7().TasksGetTask().TaskId(taskID).Do(context.Background())
+	task, err := util.GetClient7().TasksGetTask().TaskId(tasks[0]).Do(context.Background())
 	if err != nil {
 		return nil, err
 	}
@@ -151,6 +209,29 @@ func reindex(ctx context.Context, sourceIndex string, config *reindexConfig, wai
 	return json.Marshal(task)
 }
 
+// replaceIndex deletes the old index and points its name, along with its
+// aliases, to the new index.
+func replaceIndex(ctx context.Context, oldIndexName, newIndexName string) error {
+	// Fetch all the aliases of old index
+	aliases, err := aliasesOf(ctx, oldIndexName)
+	if err != nil {
+		return fmt.Errorf(`error fetching aliases of index "%s": %v`, oldIndexName, err)
+	}
+	aliases = append(aliases, oldIndexName)
+
+	// Delete old index
+	err = deleteIndex(ctx, oldIndexName)
+	if err != nil {
+		return fmt.Errorf(`error deleting index "%s": %v\n`, oldIndexName, err)
+	}
+	// Set aliases of old index to the new index.
+	err = setAlias(ctx, newIndexName, aliases...)
+	if err != nil {
+		return fmt.Errorf(`error setting alias "%s" for index "%s"`, oldIndexName, newIndexName)
+	}
+	return nil
+}
+
 func mappingsOf(ctx context.Context, indexName string) (map[string]interface{}, error) {
 	response, err := util.GetClient7().GetMapping().
 		Index(indexName).
@@ -243,6 +324,11 @@ func createIndex(ctx context.Context, indexName string, body map[string]interfac
 	return nil
 }
 
+func indexExists(ctx context.Context, indexName string) (bool, error) {
+	return util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+}
+
 func deleteIndex(ctx context.Context, indexName string) error {
 	response, err := util.GetClient7().DeleteIndex(indexName).
 		Do(ctx)
@@ -2,9 +2,10 @@ package reindexer
 
 import (
 	"encoding/json"
-	"io/ioutil"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	log "github.com/sirupsen/logrus"
 
@@ -19,6 +20,22 @@ type reindexConfig struct {
 	Exclude  []string               `json:"exclude_fields"`
 	Types    []string               `json:"types"`
 	Action   string                 `json:"action"`
+	// Naming, DestIndex and OnConflict name the destination of the
+	// reindexes in place, see validateNaming.
+	Naming     string `json:"naming"`
+	DestIndex  string `json:"dest_index"`
+	OnConflict string `json:"on_conflict"`
+	// Slices is the number of slices to reindex in parallel, the large
+	// indices are sliced automatically unless it is set.
+	Slices int `json:"slices"`
+	// Verify compares the destination with the source before the reindex
+	// succeeds, sampling VerifySample documents.
+	Verify       bool `json:"verify"`
+	VerifySample int  `json:"verify_sample"`
+	// Strategy is either the copy of the documents or the restore of a
+	// snapshot taken in SnapshotRepository.
+	Strategy           string `json:"strategy"`
+	SnapshotRepository string `json:"snapshot_repository"`
 }
 
 func (rx *reindexer) reindex() http.HandlerFunc {
@@ -33,6 +50,14 @@ func (rx *reindexer) reindex() http.HandlerFunc {
 		if done {
 			return
 		}
+		if err := validateNaming(&body, false); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validateStrategy(&body, waitForCompletion, rx.snapshotRepository); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		response, err := reindex(req.Context(), indexName, &body, waitForCompletion, "")
 		errorHandler(err, w, response)
@@ -54,15 +79,55 @@ func (rx *reindexer) reindexSrcToDest() http.HandlerFunc {
 		if done {
 			return
 		}
+		if err := validateNaming(&body, true); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := validateStrategy(&body, waitForCompletion, rx.snapshotRepository); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
 		response, err := reindex(req.Context(), sourceIndex, &body, waitForCompletion, destinationIndex)
 		errorHandler(err, w, response)
 	}
 }
 
+func (rx *reindexer) progress() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		param := req.URL.Query().Get("tasks")
+		if param == "" {
+			util.WriteBackError(w, `query param "tasks" is required`, http.StatusBadRequest)
+			return
+		}
+
+		p, err := reindexProgress(req.Context(), strings.Split(param, ","))
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		raw, err := json.Marshal(p)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, "error encoding the reindex progress", http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
 func errorHandler(err error, w http.ResponseWriter, response []byte) {
 	if err != nil {
 		log.Errorln(logTag, ":", err)
+		if _, ok := err.(*indexExistsError); ok {
+			util.WriteBackError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if e, ok := err.(*verificationError); ok {
+			util.WriteBackErrorWithDetails(w, err.Error(), http.StatusConflict, e.details())
+			return
+		}
 		util.WriteBackError(w, err.Error(), http.StatusNotFound)
 		return
 	}
@@ -79,10 +144,10 @@ func checkVar(okS bool, w http.ResponseWriter, variable string) bool {
 }
 
 func reindexConfigResponse(req *http.Request, w http.ResponseWriter) (error, reindexConfig, bool, bool) {
-	reqBody, err := ioutil.ReadAll(req.Body)
+	reqBody, err := util.ReadRequestBody(req)
 	if err != nil {
 		log.Errorln(logTag, ":", err)
-		util.WriteBackError(w, "Can't read request body", http.StatusBadRequest)
+		util.WriteBackBodyError(w, err)
 		return nil, reindexConfig{}, false, true
 	}
 	defer req.Body.Close()
@@ -94,6 +159,17 @@ func reindexConfigResponse(req *http.Request, w http.ResponseWriter) (error, rei
 		util.WriteBackError(w, "Can't parse request body", http.StatusBadRequest)
 		return nil, reindexConfig{}, false, true
 	}
+	if body.Slices < 0 {
+		util.WriteBackError(w, `"slices" must be a positive integer`, http.StatusBadRequest)
+		return nil, reindexConfig{}, false, true
+	}
+	if body.VerifySample < 0 || body.VerifySample > maxVerifySample {
+		util.WriteBackError(w, fmt.Sprintf(`"verify_sample" must be between 0 and %d`, maxVerifySample), http.StatusBadRequest)
+		return nil, reindexConfig{}, false, true
+	}
+	if body.VerifySample == 0 {
+		body.VerifySample = defaultVerifySample
+	}
 
 	// By default, wait_for_completion = true
 	param := req.URL.Query().Get("wait_for_completion")
@@ -106,5 +182,9 @@ func reindexConfigResponse(req *http.Request, w http.ResponseWriter) (error, rei
 		util.WriteBackError(w, err.Error(), http.StatusBadRequest)
 		return nil, reindexConfig{}, false, true
 	}
+	if body.Verify && !waitForCompletion {
+		util.WriteBackError(w, `"verify" requires the reindex to wait for completion`, http.StatusBadRequest)
+		return nil, reindexConfig{}, false, true
+	}
 	return err, body, waitForCompletion, false
 }
@@ -1,16 +1,29 @@
 package reindexer
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/middleware"
 	"github.com/appbaseio/arc/middleware/classify"
 	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
 	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/reqcontext"
 	"github.com/appbaseio/arc/plugins/auth"
 	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/audit"
 )
 
+// auditBodyLimit is the size of the reindex responses retained to find the
+// id of their task.
+const auditBodyLimit = 4 << 10
+
 type chain struct {
 	middleware.Fifo
 }
@@ -34,9 +47,101 @@ func list() []middleware.Middleware {
 
 func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		requestCategory := category.User
+		requestCategory := category.Reindex
 		ctx := category.NewContext(req.Context(), &requestCategory)
 		req = req.WithContext(ctx)
 		h(w, req)
 	}
 }
+
+// record is the audit trail the reindex operations are recorded to.
+var record = audit.Record
+
+// reindexSpec holds the fields of a reindex request body the audit event needs.
+type reindexSpec struct {
+	Source struct {
+		Index  interface{} `json:"index"`
+		Remote *struct {
+			Host string `json:"host"`
+		} `json:"remote"`
+	} `json:"source"`
+	Dest struct {
+		Index string `json:"index"`
+	} `json:"dest"`
+}
+
+// guardReindex requires the reindex category for the elasticsearch reindex
+// operations, which would otherwise only need the reindex acl of the docs
+// category, and records them in the audit trail. The reindex requests that
+// don't wait for completion are recorded along with the id of their task, so
+// that their progress can be followed up from the audit trail.
+func (rx *reindexer) guardReindex(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		reqACL, err := acl.FromContext(req.Context())
+		if err != nil || *reqACL != acl.Reindex {
+			h(w, req)
+			return
+		}
+
+		rc, err := reqcontext.FromContext(req.Context())
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackError(w, "an error occurred while validating the reindex request", http.StatusInternalServerError)
+			return
+		}
+		if !rc.Authenticated() {
+			util.WriteBackError(w, "the request isn't authenticated", http.StatusUnauthorized)
+			return
+		}
+		if !canReindex(rc) {
+			msg := fmt.Sprintf(`credentials cannot access the "%s" category`, category.Reindex)
+			util.WriteBackError(w, msg, http.StatusForbidden)
+			return
+		}
+
+		details := map[string]interface{}{"path": req.URL.Path}
+		resource := req.URL.Path
+		if req.Method == http.MethodPost && strings.TrimSuffix(req.URL.Path, "/") == "/_reindex" {
+			body, err := util.ReadRequestBody(req)
+			if err != nil {
+				util.WriteBackBodyError(w, err)
+				return
+			}
+			var spec reindexSpec
+			if err := json.Unmarshal(body, &spec); err == nil {
+				details["source"] = spec.Source.Index
+				details["dest"] = spec.Dest.Index
+				if spec.Source.Remote != nil {
+					details["remote"] = spec.Source.Remote.Host
+				}
+				resource = spec.Dest.Index
+			}
+			details["wait_for_completion"] = req.URL.Query().Get("wait_for_completion") != "false"
+		}
+
+		tee := util.NewTeeResponseWriter(w, auditBodyLimit)
+		h(tee, req)
+
+		var response struct {
+			Task string `json:"task"`
+		}
+		if !tee.Truncated() && json.Unmarshal(tee.Body(), &response) == nil && response.Task != "" {
+			details["task"] = response.Task
+		}
+		event := audit.NewEvent(req, "reindex", resource, tee.Code())
+		event.Details = details
+		record(req.Context(), event)
+	}
+}
+
+// canReindex checks whether the credential of the request can access the
+// reindex category, the admin users can unless they are denied it.
+func canReindex(rc *reqcontext.RequestContext) bool {
+	if rc.User != nil {
+		if rc.User.Admin() {
+			return !rc.User.DeniesCategory(category.Reindex)
+		}
+		return rc.User.HasCategory(category.Reindex)
+	}
+	return rc.Permission.HasCategory(category.Reindex)
+}
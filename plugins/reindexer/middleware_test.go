@@ -0,0 +1,80 @@
+package reindexer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util/audit"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGuardReindex(t *testing.T) {
+	var events []audit.Event
+	record = func(ctx context.Context, e audit.Event) { events = append(events, e) }
+	defer func() { record = audit.Record }()
+
+	upstream := func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(`{"task":"node:42"}`))
+	}
+	serve := func(ctx context.Context, a acl.ACL, path, body string) int {
+		docs, write := category.Docs, op.Write
+		ctx = acl.NewContext(ctx, &a)
+		ctx = category.NewContext(ctx, &docs)
+		ctx = op.NewContext(ctx, &write)
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)).WithContext(ctx)
+		w := httptest.NewRecorder()
+		Instance().guardReindex(upstream)(w, req)
+		return w.Code
+	}
+	withUser := func(u *user.User) context.Context {
+		ctx := credential.NewContext(context.Background(), credential.User)
+		return user.NewContext(ctx, u)
+	}
+	withPermission := func(p *permission.Permission) context.Context {
+		ctx := credential.NewContext(context.Background(), credential.Permission)
+		return permission.NewContext(ctx, p)
+	}
+	admin := true
+	body := `{"source":{"index":"products"},"dest":{"index":"products_v2"}}`
+
+	Convey("The requests of the other acls are passed through", t, func() {
+		events = nil
+		So(serve(context.Background(), acl.Bulk, "/_bulk", ""), ShouldEqual, http.StatusOK)
+		So(events, ShouldBeEmpty)
+	})
+
+	Convey("The reindex requests require the reindex category", t, func() {
+		events = nil
+		p := &permission.Permission{Categories: []category.Category{category.Docs}, ACLs: []acl.ACL{acl.Reindex}}
+		So(serve(withPermission(p), acl.Reindex, "/_reindex", body), ShouldEqual, http.StatusForbidden)
+		So(events, ShouldBeEmpty)
+
+		p.Categories = append(p.Categories, category.Reindex)
+		So(serve(withPermission(p), acl.Reindex, "/_reindex", body), ShouldEqual, http.StatusOK)
+
+		u := &user.User{IsAdmin: &admin, DeniedCategories: []category.Category{category.Reindex}}
+		So(serve(withUser(u), acl.Reindex, "/_reindex", body), ShouldEqual, http.StatusForbidden)
+	})
+
+	Convey("The reindex requests are recorded along with their task", t, func() {
+		events = nil
+		u := &user.User{IsAdmin: &admin}
+		So(serve(withUser(u), acl.Reindex, "/_reindex?wait_for_completion=false", body), ShouldEqual, http.StatusOK)
+		So(events, ShouldHaveLength, 1)
+		So(events[0].Action, ShouldEqual, "reindex")
+		So(events[0].Resource, ShouldEqual, "products_v2")
+		details := events[0].Details.(map[string]interface{})
+		So(details["source"], ShouldEqual, "products")
+		So(details["task"], ShouldEqual, "node:42")
+		So(details["wait_for_completion"], ShouldBeFalse)
+	})
+}
@@ -1,6 +1,9 @@
 package reindexer
 
 import (
+	"fmt"
+	"os"
+	"strconv"
 	"sync"
 
 	"github.com/appbaseio/arc/middleware"
@@ -8,8 +11,13 @@ import (
 )
 
 const (
-	logTag   = "[reindexer]"
-	envEsURL = "ES_CLUSTER_URL"
+	logTag                = "[reindexer]"
+	envEsURL              = "ES_CLUSTER_URL"
+	envSliceThreshold     = "REINDEX_SLICE_THRESHOLD"
+	envMaxSlices          = "REINDEX_MAX_SLICES"
+	envSnapshotRepository = "REINDEX_SNAPSHOT_REPOSITORY"
+	defaultSliceThreshold = 1000000
+	defaultMaxSlices      = 8
 )
 
 var (
@@ -18,6 +26,13 @@ var (
 )
 
 type reindexer struct {
+	// sliceThreshold is the number of documents from which the source
+	// indices are reindexed in slices, at most maxSlices of them.
+	sliceThreshold int64
+	maxSlices      int
+	// snapshotRepository is the default repository of the snapshots of the
+	// snapshot strategy.
+	snapshotRepository string
 }
 
 // Use only this function to fetch the instance of user from within
@@ -32,6 +47,23 @@ func (rx *reindexer) Name() string {
 }
 
 func (rx *reindexer) InitFunc() error {
+	rx.sliceThreshold = defaultSliceThreshold
+	if value := os.Getenv(envSliceThreshold); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a non-negative integer", envSliceThreshold, value)
+		}
+		rx.sliceThreshold = n
+	}
+	rx.maxSlices = defaultMaxSlices
+	if value := os.Getenv(envMaxSlices); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid value for %s: %s, must be a positive integer", envMaxSlices, value)
+		}
+		rx.maxSlices = n
+	}
+	rx.snapshotRepository = os.Getenv(envSnapshotRepository)
 	return nil
 }
 
@@ -39,7 +71,7 @@ func (rx *reindexer) Routes() []plugins.Route {
 	return rx.routes()
 }
 
-// Default empty middleware array function
+// ESMiddleware guards the reindex operations made to elasticsearch.
 func (rx *reindexer) ESMiddleware() []middleware.Middleware {
-	return make([]middleware.Middleware, 0)
+	return []middleware.Middleware{rx.guardReindex}
 }
@@ -9,6 +9,13 @@ import (
 func (rx *reindexer) routes() []plugins.Route {
 	middleware := (&chain{}).Wrap
 	routes := []plugins.Route{
+		{
+			Name:        "Reindex progress",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_reindex/_progress",
+			HandlerFunc: middleware(rx.progress()),
+			Description: "Aggregates the progress of the tasks of the slices of a reindex.",
+		},
 		{
 			Name:        "Reindex source to destination",
 			Methods:     []string{http.MethodPost},
@@ -0,0 +1,249 @@
+package reindexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// slicesFor returns the number of slices the index is reindexed in. The
+// requested number is capped by the max slices, and the indices holding at
+// least the threshold of documents are sliced automatically, one slice per
+// primary shard, unless a number is requested.
+func (rx *reindexer) slicesFor(ctx context.Context, index string, requested int) (int, error) {
+	maxSlices := rx.maxSlices
+	if maxSlices < 1 {
+		maxSlices = 1
+	}
+	if requested > 0 {
+		if requested > maxSlices {
+			return maxSlices, nil
+		}
+		return requested, nil
+	}
+	if rx.sliceThreshold == 0 {
+		return 1, nil
+	}
+
+	count, err := util.GetClient7().Count(index).
+		Do(ctx)
+	if err != nil {
+		return 0, fmt.Errorf(`error counting the documents of index "%s": %v`, index, err)
+	}
+	if count < rx.sliceThreshold {
+		return 1, nil
+	}
+	shards, err := primaryShards(ctx, index)
+	if err != nil {
+		return 0, err
+	}
+	if shards > maxSlices {
+		return maxSlices, nil
+	}
+	return shards, nil
+}
+
+func primaryShards(ctx context.Context, index string) (int, error) {
+	response, err := util.GetClient7().IndexGetSettings().
+		Index(index).
+		Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	info, found := response[index]
+	if !found {
+		return 0, fmt.Errorf("settings for index %s not found", index)
+	}
+	indexSettings, ok := info.Settings["index"].(map[string]interface{})
+	if !ok {
+		return 0, fmt.Errorf("error casting index settings to map[string]interface{}")
+	}
+	shards, err := strconv.Atoi(fmt.Sprint(indexSettings["number_of_shards"]))
+	if err != nil {
+		return 0, fmt.Errorf(`invalid number of shards of index "%s": %v`, index, err)
+	}
+	return shards, nil
+}
+
+// reindexSource returns the source of the given slice of the reindex.
+func reindexSource(index string, config *reindexConfig, slice, slices int) *es7.ReindexSource {
+	src := es7.NewReindexSource()
+	if slices > 1 {
+		src.Request(es7.NewSearchRequest().
+			Slice(es7.NewSliceQuery().Id(slice).Max(slices)))
+	}
+	src.Index(index).
+		FetchSourceIncludeExclude(config.Include, config.Exclude)
+	if len(config.Types) > 0 {
+		src.Type(config.Types...)
+	}
+	return src
+}
+
+// runSlices runs the slices of a reindex in parallel and merges their
+// responses once they are all done. The reindex fails if any of its slices
+// does, after the others are done.
+func runSlices(slices int, run func(slice int) (*es7.BulkIndexByScrollResponse, error)) (*es7.BulkIndexByScrollResponse, error) {
+	responses := make([]*es7.BulkIndexByScrollResponse, slices)
+	errs := make([]error, slices)
+	var wg sync.WaitGroup
+	for i := 0; i < slices; i++ {
+		wg.Add(1)
+		go func(slice int) {
+			defer wg.Done()
+			responses[slice], errs[slice] = run(slice)
+		}(i)
+	}
+	wg.Wait()
+
+	var failed []string
+	for slice, err := range errs {
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("slice %d: %v", slice, err))
+		}
+	}
+	if len(failed) > 0 {
+		if slices == 1 {
+			return nil, errs[0]
+		}
+		return nil, fmt.Errorf("%d of %d slices failed: %s", len(failed), slices, strings.Join(failed, "; "))
+	}
+	return mergeResponses(responses), nil
+}
+
+// mergeResponses sums up the counts of the responses of the slices and
+// gathers their failures. The reindex took as long as its slowest slice.
+func mergeResponses(responses []*es7.BulkIndexByScrollResponse) *es7.BulkIndexByScrollResponse {
+	if len(responses) == 1 {
+		return responses[0]
+	}
+	merged := &es7.BulkIndexByScrollResponse{}
+	for _, r := range responses {
+		if r.Took > merged.Took {
+			merged.Took = r.Took
+		}
+		merged.TimedOut = merged.TimedOut || r.TimedOut
+		merged.Total += r.Total
+		merged.Created += r.Created
+		merged.Updated += r.Updated
+		merged.Deleted += r.Deleted
+		merged.Batches += r.Batches
+		merged.VersionConflicts += r.VersionConflicts
+		merged.Noops += r.Noops
+		merged.Retries.Bulk += r.Retries.Bulk
+		merged.Retries.Search += r.Retries.Search
+		merged.ThrottledMillis += r.ThrottledMillis
+		merged.Failures = append(merged.Failures, r.Failures...)
+	}
+	return merged
+}
+
+// startSlices starts the slices of a reindex as tasks and returns their ids.
+// The tasks already started are cancelled if a slice fails to start.
+func startSlices(slices int, start func(slice int) (string, error)) ([]string, error) {
+	var tasks []string
+	for i := 0; i < slices; i++ {
+		task, err := start(i)
+		if err != nil {
+			for _, t := range tasks {
+				if _, err := util.GetClient7().TasksCancel().TaskId(t).Do(context.Background()); err != nil {
+					log.Errorln(logTag, ": error cancelling reindex task", t, ":", err)
+				}
+			}
+			return nil, fmt.Errorf("error starting slice %d of %d: %v", i, slices, err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// reindexStatus holds the counts of a reindex task.
+type reindexStatus struct {
+	Total            int64 `json:"total"`
+	Created          int64 `json:"created"`
+	Updated          int64 `json:"updated"`
+	Deleted          int64 `json:"deleted"`
+	Batches          int64 `json:"batches"`
+	VersionConflicts int64 `json:"version_conflicts"`
+	Noops            int64 `json:"noops"`
+}
+
+// sliceTask is the state of the task of a slice as returned by the tasks API.
+type sliceTask struct {
+	Completed bool `json:"completed"`
+	Task      struct {
+		Status reindexStatus `json:"status"`
+	} `json:"task"`
+	Response *struct {
+		Failures []interface{} `json:"failures"`
+	} `json:"response"`
+	Error interface{} `json:"error"`
+}
+
+// progress aggregates the state of the tasks of the slices of a reindex.
+type progress struct {
+	Completed bool `json:"completed"`
+	Slices    int  `json:"slices"`
+	reindexStatus
+	// Percent is the share of the documents of the source processed so far.
+	Percent  float64       `json:"percent"`
+	Failures []interface{} `json:"failures"`
+	Errors   []interface{} `json:"errors,omitempty"`
+}
+
+func aggregateProgress(tasks []sliceTask) progress {
+	p := progress{Completed: true, Slices: len(tasks), Failures: []interface{}{}}
+	for _, t := range tasks {
+		p.Completed = p.Completed && t.Completed
+		s := t.Task.Status
+		p.Total += s.Total
+		p.Created += s.Created
+		p.Updated += s.Updated
+		p.Deleted += s.Deleted
+		p.Batches += s.Batches
+		p.VersionConflicts += s.VersionConflicts
+		p.Noops += s.Noops
+		if t.Response != nil {
+			p.Failures = append(p.Failures, t.Response.Failures...)
+		}
+		if t.Error != nil {
+			p.Errors = append(p.Errors, t.Error)
+		}
+	}
+	if p.Total > 0 {
+		processed := p.Created + p.Updated + p.Deleted + p.VersionConflicts + p.Noops
+		p.Percent = float64(processed) * 100 / float64(p.Total)
+	} else if p.Completed {
+		p.Percent = 100
+	}
+	return p
+}
+
+// reindexProgress fetches the tasks of the slices of a reindex and aggregates
+// their progress.
+func reindexProgress(ctx context.Context, taskIDs []string) (*progress, error) {
+	tasks := make([]sliceTask, len(taskIDs))
+	for i, id := range taskIDs {
+		response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+			Method: "GET",
+			Path:   "/_tasks/" + url.PathEscape(id),
+		})
+		if err != nil {
+			return nil, fmt.Errorf(`error fetching reindex task "%s": %v`, id, err)
+		}
+		if err := json.Unmarshal(response.Body, &tasks[i]); err != nil {
+			return nil, fmt.Errorf(`error parsing reindex task "%s": %v`, id, err)
+		}
+	}
+	p := aggregateProgress(tasks)
+	return &p, nil
+}
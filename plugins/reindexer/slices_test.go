@@ -0,0 +1,75 @@
+package reindexer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	es7 "github.com/olivere/elastic/v7"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSlices(t *testing.T) {
+	Convey("The requested number of slices is capped", t, func() {
+		rx := &reindexer{maxSlices: 4}
+		slices, err := rx.slicesFor(context.Background(), "twitter", 2)
+		So(err, ShouldBeNil)
+		So(slices, ShouldEqual, 2)
+		slices, err = rx.slicesFor(context.Background(), "twitter", 16)
+		So(err, ShouldBeNil)
+		So(slices, ShouldEqual, 4)
+	})
+
+	Convey("The source of a slice selects the slice", t, func() {
+		config := &reindexConfig{Include: []string{"title"}}
+		src, err := reindexSource("twitter", config, 1, 3).Source()
+		So(err, ShouldBeNil)
+		body, _ := json.Marshal(src)
+		So(string(body), ShouldContainSubstring, `"slice":{"id":1,"max":3}`)
+		So(string(body), ShouldContainSubstring, `"index":"twitter"`)
+		So(string(body), ShouldContainSubstring, `"includes":["title"]`)
+
+		src, err = reindexSource("twitter", config, 0, 1).Source()
+		So(err, ShouldBeNil)
+		body, _ = json.Marshal(src)
+		So(string(body), ShouldNotContainSubstring, `"slice"`)
+	})
+
+	Convey("The responses of the slices are merged", t, func() {
+		response, err := runSlices(3, func(slice int) (*es7.BulkIndexByScrollResponse, error) {
+			return &es7.BulkIndexByScrollResponse{Took: int64(10 * (slice + 1)), Total: 100, Created: 90, Updated: 10}, nil
+		})
+		So(err, ShouldBeNil)
+		So(response.Took, ShouldEqual, 30)
+		So(response.Total, ShouldEqual, 300)
+		So(response.Created, ShouldEqual, 270)
+		So(response.Updated, ShouldEqual, 30)
+	})
+
+	Convey("The reindex fails if any of its slices does", t, func() {
+		_, err := runSlices(3, func(slice int) (*es7.BulkIndexByScrollResponse, error) {
+			if slice == 1 {
+				return nil, errors.New("node disconnected")
+			}
+			return &es7.BulkIndexByScrollResponse{}, nil
+		})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "1 of 3 slices failed: slice 1: node disconnected")
+	})
+
+	Convey("The progress of the slices is aggregated", t, func() {
+		var tasks []sliceTask
+		So(json.Unmarshal([]byte(`[
+			{"completed": true, "task": {"status": {"total": 100, "created": 100}}, "response": {"failures": [{"id": "1"}]}},
+			{"completed": false, "task": {"status": {"total": 100, "created": 40, "version_conflicts": 10}}}
+		]`), &tasks), ShouldBeNil)
+		p := aggregateProgress(tasks)
+		So(p.Completed, ShouldBeFalse)
+		So(p.Slices, ShouldEqual, 2)
+		So(p.Total, ShouldEqual, 200)
+		So(p.Created, ShouldEqual, 140)
+		So(p.Percent, ShouldEqual, 75)
+		So(p.Failures, ShouldHaveLength, 1)
+	})
+}
@@ -0,0 +1,307 @@
+package reindexer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/notify"
+)
+
+// The strategies reindexing an index.
+const (
+	// strategyReindex copies the documents with the reindex API.
+	strategyReindex = "reindex"
+	// strategySnapshot restores a snapshot of the index under the new name,
+	// which avoids copying the documents of the very large indices, and then
+	// applies the changes of the reindex that the restored index allows.
+	strategySnapshot = "snapshot"
+)
+
+// dynamicSettings are the settings applied to the restored index, the other
+// settings can't be changed once an index is created.
+var dynamicSettings = map[string]bool{
+	"number_of_replicas": true,
+	"refresh_interval":   true,
+}
+
+// validateStrategy checks the strategy of the request and sets its default.
+func validateStrategy(config *reindexConfig, waitForCompletion bool, defaultRepository string) error {
+	switch config.Strategy {
+	case "":
+		config.Strategy = strategyReindex
+	case strategyReindex:
+	case strategySnapshot:
+		if !waitForCompletion {
+			return fmt.Errorf(`the "%s" strategy requires the reindex to wait for completion`, strategySnapshot)
+		}
+		if config.Action == "mappings" {
+			return fmt.Errorf(`the "%s" strategy doesn't apply to the "mappings" action`, strategySnapshot)
+		}
+		if config.SnapshotRepository == "" {
+			config.SnapshotRepository = defaultRepository
+		}
+		if config.SnapshotRepository == "" {
+			return fmt.Errorf(`"snapshot_repository" is required by the "%s" strategy, unless %s is set`, strategySnapshot, envSnapshotRepository)
+		}
+	default:
+		return fmt.Errorf(`invalid "strategy": %s, must be one of "%s" or "%s"`, config.Strategy, strategyReindex, strategySnapshot)
+	}
+	if config.Strategy != strategySnapshot && config.SnapshotRepository != "" {
+		return fmt.Errorf(`"snapshot_repository" is only used by the "%s" strategy`, strategySnapshot)
+	}
+	return nil
+}
+
+// snapshotIncompatibility returns the reason why the index can't be reindexed
+// by restoring a snapshot, empty if it can.
+func snapshotIncompatibility(ctx context.Context, index string, config *reindexConfig, destinationExists bool) (string, error) {
+	switch {
+	case !util.IsTypeless():
+		return fmt.Sprintf(`the "%s" strategy isn't supported with elasticsearch %d`, strategySnapshot, util.GetVersion()), nil
+	case destinationExists:
+		return "the destination index already exists", nil
+	case len(config.Include) > 0 || len(config.Exclude) > 0:
+		return "the reindex filters the fields of the documents", nil
+	case len(config.Types) > 0:
+		return "the reindex filters the types of the documents", nil
+	}
+
+	mappings, err := mappingsOf(ctx, index)
+	if err != nil {
+		return "", fmt.Errorf(`error fetching mappings of index "%s": %v`, index, err)
+	}
+	settings, err := settingsOf(ctx, index)
+	if err != nil {
+		return "", fmt.Errorf(`error fetching settings of index "%s": %v`, index, err)
+	}
+	if reason := mappingsIncompatibility(config.Mappings, mappings, ""); reason != "" {
+		return reason, nil
+	}
+	return settingsIncompatibility(config.Settings, settings), nil
+}
+
+// mappingsIncompatibility returns the reason why the mappings can't be put on
+// an index with the current mappings, empty if they can: new fields can be
+// added, along with new sub-fields and properties to the existing fields,
+// but the existing definitions can't change.
+func mappingsIncompatibility(mappings, current map[string]interface{}, path string) string {
+	for key, value := range mappings {
+		currentValue, found := current[key]
+		if !found {
+			if key == "properties" || key == "fields" {
+				continue
+			}
+			if path == "" {
+				if key == "_meta" || key == "dynamic" {
+					continue
+				}
+				return fmt.Sprintf(`the mappings change "%s"`, key)
+			}
+			return fmt.Sprintf(`the mappings change the definition of field "%s"`, strings.TrimPrefix(path, "."))
+		}
+		if key == "properties" || key == "fields" {
+			fields, ok := value.(map[string]interface{})
+			currentFields, currentOK := currentValue.(map[string]interface{})
+			if !ok || !currentOK {
+				return fmt.Sprintf(`invalid "%s" in the mappings`, key)
+			}
+			for name, definition := range fields {
+				currentDefinition, found := currentFields[name]
+				if !found {
+					continue
+				}
+				d, ok := definition.(map[string]interface{})
+				currentD, currentOK := currentDefinition.(map[string]interface{})
+				if !ok || !currentOK {
+					return fmt.Sprintf(`invalid definition of field "%s" in the mappings`, strings.TrimPrefix(path+"."+name, "."))
+				}
+				if reason := mappingsIncompatibility(d, currentD, path+"."+name); reason != "" {
+					return reason
+				}
+			}
+			continue
+		}
+		if path == "" && (key == "_meta" || key == "dynamic") {
+			continue
+		}
+		if !reflect.DeepEqual(value, currentValue) {
+			if path == "" {
+				return fmt.Sprintf(`the mappings change "%s"`, key)
+			}
+			return fmt.Sprintf(`the mappings change the definition of field "%s"`, strings.TrimPrefix(path, "."))
+		}
+	}
+	return ""
+}
+
+// settingsIncompatibility returns the reason why the settings can't be
+// applied to the restored index, empty if they can: only the dynamic settings
+// can differ from the current settings.
+func settingsIncompatibility(settings, current map[string]interface{}) string {
+	requested, existing := flattenSettings(settings), flattenSettings(current)
+	var changed []string
+	for key, value := range requested {
+		if dynamicSettings[key] {
+			continue
+		}
+		if currentValue, found := existing[key]; !found || currentValue != value {
+			changed = append(changed, key)
+		}
+	}
+	if len(changed) > 0 {
+		sort.Strings(changed)
+		return fmt.Sprintf(`the settings change "%s"`, strings.Join(changed, `", "`))
+	}
+	return ""
+}
+
+// flattenSettings returns the settings keyed by their dotted names, without
+// the "index." prefix, along with their values as strings as elasticsearch
+// returns them.
+func flattenSettings(settings map[string]interface{}) map[string]string {
+	flat := make(map[string]string)
+	var flatten func(prefix string, value interface{})
+	flatten = func(prefix string, value interface{}) {
+		if m, ok := value.(map[string]interface{}); ok {
+			for k, v := range m {
+				flatten(prefix+k+".", v)
+			}
+			return
+		}
+		flat[strings.TrimPrefix(strings.TrimSuffix(prefix, "."), "index.")] = fmt.Sprint(value)
+	}
+	flatten("", settings)
+	return flat
+}
+
+// snapshotResponse is the response of a reindex carried out by restoring a
+// snapshot.
+type snapshotResponse struct {
+	Strategy     string        `json:"strategy"`
+	Repository   string        `json:"repository"`
+	Snapshot     string        `json:"snapshot"`
+	Index        string        `json:"index"`
+	Took         int64         `json:"took"`
+	Verification *verification `json:"verification,omitempty"`
+}
+
+// snapshotReindex snapshots the source index, restores it under the new name,
+// puts the mappings and the dynamic settings of the reindex on it, and then
+// replaces the source index with it if the reindex is in place. The snapshot
+// is deleted once it has been restored.
+func snapshotReindex(ctx context.Context, sourceIndex, newIndexName string, inPlace bool, config *reindexConfig) ([]byte, error) {
+	start := time.Now()
+	repository := config.SnapshotRepository
+	snapshot := fmt.Sprintf("reindex-%s-%d", strings.TrimPrefix(sourceIndex, "."), start.UnixNano())
+
+	created, err := util.GetClient7().SnapshotCreate(repository, snapshot).
+		BodyJson(map[string]interface{}{
+			"indices":              sourceIndex,
+			"include_global_state": false,
+		}).
+		WaitForCompletion(true).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(`error creating snapshot "%s" of index "%s": %v`, snapshot, sourceIndex, err)
+	}
+	defer func() {
+		if _, err := util.GetClient7().SnapshotDelete(repository, snapshot).Do(context.Background()); err != nil {
+			log.Errorln(logTag, ": error deleting snapshot", snapshot, ":", err)
+		}
+	}()
+	if created.Snapshot == nil || created.Snapshot.State != "SUCCESS" {
+		state := "unknown"
+		if created.Snapshot != nil {
+			state = created.Snapshot.State
+		}
+		return nil, fmt.Errorf(`snapshot "%s" of index "%s" didn't succeed, state=%s`, snapshot, sourceIndex, state)
+	}
+
+	restored, err := util.GetClient7().SnapshotRestore(repository, snapshot).
+		Indices(sourceIndex).
+		RenamePattern(".+").
+		RenameReplacement(newIndexName).
+		IncludeAliases(false).
+		IncludeGlobalState(false).
+		WaitForCompletion(true).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(`error restoring snapshot "%s" into index "%s": %v`, snapshot, newIndexName, err)
+	}
+	if restored.Snapshot != nil && restored.Snapshot.Shards.Failed > 0 {
+		return nil, fmt.Errorf(`%d shards failed to be restored into index "%s"`, restored.Snapshot.Shards.Failed, newIndexName)
+	}
+
+	if err := applyChanges(ctx, newIndexName, config); err != nil {
+		return nil, err
+	}
+
+	var result *verification
+	if config.Verify {
+		result, err = verifyReindex(ctx, sourceIndex, newIndexName, config)
+		if err != nil {
+			return nil, err
+		}
+		if !result.Passed {
+			return nil, &verificationError{sourceIndex: sourceIndex, destIndex: newIndexName, result: result}
+		}
+	}
+
+	if inPlace {
+		if err := replaceIndex(ctx, sourceIndex, newIndexName); err != nil {
+			return nil, err
+		}
+	}
+
+	response := snapshotResponse{
+		Strategy:     strategySnapshot,
+		Repository:   repository,
+		Snapshot:     snapshot,
+		Index:        newIndexName,
+		Took:         time.Since(start).Milliseconds(),
+		Verification: result,
+	}
+	notify.Send(notify.Event{
+		Type:     notify.ReindexCompleted,
+		Resource: sourceIndex,
+		Message:  fmt.Sprintf(`restored a snapshot of index "%s" into "%s" in %dms`, sourceIndex, newIndexName, response.Took),
+		Details:  response,
+	})
+	return json.Marshal(response)
+}
+
+// applyChanges puts the mappings and the dynamic settings of the reindex on
+// the restored index.
+func applyChanges(ctx context.Context, index string, config *reindexConfig) error {
+	if len(config.Mappings) > 0 {
+		if _, err := util.GetClient7().PutMapping().
+			Index(index).
+			BodyJson(config.Mappings).
+			Do(ctx); err != nil {
+			return fmt.Errorf(`error putting the mappings on index "%s": %v`, index, err)
+		}
+	}
+
+	settings := make(map[string]interface{})
+	for key, value := range flattenSettings(config.Settings) {
+		if dynamicSettings[key] {
+			settings[key] = value
+		}
+	}
+	if len(settings) > 0 {
+		if _, err := util.GetClient7().IndexPutSettings(index).
+			BodyJson(map[string]interface{}{"index": settings}).
+			Do(ctx); err != nil {
+			return fmt.Errorf(`error putting the settings on index "%s": %v`, index, err)
+		}
+	}
+	return nil
+}
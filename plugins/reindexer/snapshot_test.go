@@ -0,0 +1,60 @@
+package reindexer
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSnapshotStrategy(t *testing.T) {
+	decode := func(s string) map[string]interface{} {
+		var m map[string]interface{}
+		So(json.Unmarshal([]byte(s), &m), ShouldBeNil)
+		return m
+	}
+
+	Convey("The strategy is validated", t, func() {
+		config := &reindexConfig{}
+		So(validateStrategy(config, true, ""), ShouldBeNil)
+		So(config.Strategy, ShouldEqual, strategyReindex)
+
+		config = &reindexConfig{Strategy: strategySnapshot}
+		So(validateStrategy(config, true, "backups"), ShouldBeNil)
+		So(config.SnapshotRepository, ShouldEqual, "backups")
+
+		So(validateStrategy(&reindexConfig{Strategy: strategySnapshot}, true, ""), ShouldNotBeNil)
+		So(validateStrategy(&reindexConfig{Strategy: strategySnapshot}, false, "backups"), ShouldNotBeNil)
+		So(validateStrategy(&reindexConfig{Strategy: "rsync"}, true, ""), ShouldNotBeNil)
+	})
+
+	Convey("The mappings adding fields are compatible", t, func() {
+		current := decode(`{"properties": {"title": {"type": "text", "fields": {"raw": {"type": "keyword"}}}}}`)
+		So(mappingsIncompatibility(current, current, ""), ShouldBeEmpty)
+		So(mappingsIncompatibility(decode(`{
+			"dynamic": "strict",
+			"properties": {
+				"title": {"type": "text", "fields": {"raw": {"type": "keyword"}, "en": {"type": "text", "analyzer": "english"}}},
+				"views": {"type": "long"}
+			}
+		}`), current, ""), ShouldBeEmpty)
+	})
+
+	Convey("The mappings changing fields aren't compatible", t, func() {
+		current := decode(`{"properties": {"title": {"type": "text"}, "author": {"properties": {"name": {"type": "text"}}}}}`)
+		So(mappingsIncompatibility(decode(`{"properties": {"title": {"type": "keyword"}}}`), current, ""),
+			ShouldEqual, `the mappings change the definition of field "title"`)
+		So(mappingsIncompatibility(decode(`{"properties": {"author": {"properties": {"name": {"type": "keyword"}}}}}`), current, ""),
+			ShouldEqual, `the mappings change the definition of field "author.name"`)
+		So(mappingsIncompatibility(decode(`{"_source": {"enabled": false}}`), current, ""),
+			ShouldEqual, `the mappings change "_source"`)
+	})
+
+	Convey("Only the dynamic settings can change", t, func() {
+		current := decode(`{"index": {}, "number_of_shards": "3", "number_of_replicas": "1"}`)
+		So(settingsIncompatibility(decode(`{"index": {"number_of_shards": 3, "number_of_replicas": 0, "refresh_interval": "30s"}}`), current), ShouldBeEmpty)
+		So(settingsIncompatibility(decode(`{"number_of_shards": 6}`), current), ShouldEqual, `the settings change "number_of_shards"`)
+		So(settingsIncompatibility(decode(`{"analysis": {"analyzer": {"folding": {"tokenizer": "standard"}}}}`), current),
+			ShouldEqual, `the settings change "analysis.analyzer.folding.tokenizer"`)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -38,3 +39,133 @@ func reindexedName(indexName string) (string, error) {
 
 	return indexName, nil
 }
+
+// The strategies naming the index an index is reindexed into in place.
+const (
+	// namingSuffix appends the number of times the index has been
+	// reindexed, see reindexedName.
+	namingSuffix = "suffix"
+	// namingTimestamp appends the time of the reindex, e.g. "twitter_20190102150405".
+	namingTimestamp = "timestamp"
+	// namingCustom uses the name given in the dest_index of the request.
+	namingCustom = "custom"
+)
+
+// The ways to resolve a destination index that already exists.
+const (
+	// conflictError fails the reindex, the default for the given names.
+	conflictError = "error"
+	// conflictIncrement picks the next free name, the default for the
+	// generated names.
+	conflictIncrement = "increment"
+	// conflictReuse reindexes into the existing index as it is.
+	conflictReuse = "reuse"
+	// conflictOverwrite deletes the existing index and creates it again.
+	conflictOverwrite = "overwrite"
+)
+
+// maxIncrements bounds the names tried in search of a free one.
+const maxIncrements = 100
+
+const timestampLayout = "20060102150405"
+
+var timestampSuffix = regexp.MustCompile(`_[0-9]{14}$`)
+
+// indexExistsError is returned when the destination of a reindex already
+// exists and the request doesn't resolve the conflict.
+type indexExistsError struct {
+	index string
+}
+
+func (e *indexExistsError) Error() string {
+	return fmt.Sprintf(`index "%s" already exists, set "on_conflict" to "increment", "reuse" or "overwrite" to reindex into it`, e.index)
+}
+
+// validateNaming checks the naming options of the request and sets their defaults.
+func validateNaming(config *reindexConfig, destinationGiven bool) error {
+	switch config.Naming {
+	case "":
+		config.Naming = namingSuffix
+		if config.DestIndex != "" {
+			config.Naming = namingCustom
+		}
+	case namingSuffix, namingTimestamp:
+	case namingCustom:
+		if config.DestIndex == "" && !destinationGiven {
+			return fmt.Errorf(`"dest_index" is required by the "%s" naming`, namingCustom)
+		}
+	default:
+		return fmt.Errorf(`invalid "naming": %s, must be one of "%s", "%s" or "%s"`,
+			config.Naming, namingSuffix, namingTimestamp, namingCustom)
+	}
+	if config.Naming != namingCustom && config.DestIndex != "" {
+		return fmt.Errorf(`"dest_index" is only used by the "%s" naming`, namingCustom)
+	}
+
+	switch config.OnConflict {
+	case "":
+		config.OnConflict = conflictIncrement
+		if config.Naming == namingCustom || destinationGiven {
+			config.OnConflict = conflictError
+		}
+	case conflictError, conflictIncrement, conflictReuse, conflictOverwrite:
+	default:
+		return fmt.Errorf(`invalid "on_conflict": %s, must be one of "%s", "%s", "%s" or "%s"`,
+			config.OnConflict, conflictError, conflictIncrement, conflictReuse, conflictOverwrite)
+	}
+	return nil
+}
+
+// destinationName returns the name of the index the source index is reindexed
+// into, as named by the strategy of the config, and whether the index already
+// exists, in which case it is to be reused or overwritten.
+func destinationName(sourceIndex, destinationIndex string, config *reindexConfig, now time.Time,
+	exists func(string) (bool, error)) (string, bool, error) {
+	var name string
+	var err error
+	switch {
+	case destinationIndex != "":
+		name = destinationIndex
+	case config.Naming == namingCustom:
+		name = config.DestIndex
+	case config.Naming == namingTimestamp:
+		name = timestampSuffix.ReplaceAllString(sourceIndex, "") + "_" + now.UTC().Format(timestampLayout)
+	default:
+		name, err = reindexedName(sourceIndex)
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	base := name
+	for i := 1; ; i++ {
+		if name == sourceIndex {
+			return "", false, fmt.Errorf(`cannot reindex index "%s" into itself`, sourceIndex)
+		}
+		found, err := exists(name)
+		if err != nil {
+			return "", false, err
+		}
+		if !found {
+			return name, false, nil
+		}
+		switch config.OnConflict {
+		case conflictReuse, conflictOverwrite:
+			return name, true, nil
+		case conflictIncrement:
+			if i >= maxIncrements {
+				return "", false, fmt.Errorf(`no free index name found for "%s" after %d attempts`, base, maxIncrements)
+			}
+			if config.Naming == namingSuffix && destinationIndex == "" {
+				name, err = reindexedName(name)
+				if err != nil {
+					return "", false, err
+				}
+			} else {
+				name = fmt.Sprintf("%s_%d", base, i+1)
+			}
+		default:
+			return "", false, &indexExistsError{index: name}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package reindexer
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDestinationName(t *testing.T) {
+	now := time.Date(2019, 1, 2, 15, 4, 5, 0, time.UTC)
+	taken := func(names ...string) func(string) (bool, error) {
+		return func(name string) (bool, error) {
+			for _, n := range names {
+				if n == name {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+	}
+	name := func(source, destination string, config reindexConfig, exists func(string) (bool, error)) (string, bool, error) {
+		if err := validateNaming(&config, destination != ""); err != nil {
+			return "", false, err
+		}
+		return destinationName(source, destination, &config, now, exists)
+	}
+
+	Convey("The destination is named by the strategy", t, func() {
+		n, _, err := name("twitter", "", reindexConfig{}, taken())
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, "twitter_reindexed_1")
+
+		n, _, err = name("twitter_20180101000000", "", reindexConfig{Naming: namingTimestamp}, taken())
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, "twitter_20190102150405")
+
+		n, _, err = name("twitter", "", reindexConfig{DestIndex: "tweets"}, taken())
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, "tweets")
+	})
+
+	Convey("The generated names skip the existing indices", t, func() {
+		n, found, err := name("twitter", "", reindexConfig{}, taken("twitter_reindexed_1", "twitter_reindexed_2"))
+		So(err, ShouldBeNil)
+		So(found, ShouldBeFalse)
+		So(n, ShouldEqual, "twitter_reindexed_3")
+
+		n, _, err = name("twitter", "", reindexConfig{Naming: namingTimestamp}, taken("twitter_20190102150405"))
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, "twitter_20190102150405_2")
+	})
+
+	Convey("The given names conflict unless they are reused or overwritten", t, func() {
+		_, _, err := name("twitter", "tweets", reindexConfig{}, taken("tweets"))
+		So(err, ShouldHaveSameTypeAs, &indexExistsError{})
+
+		n, found, err := name("twitter", "tweets", reindexConfig{OnConflict: conflictReuse}, taken("tweets"))
+		So(err, ShouldBeNil)
+		So(found, ShouldBeTrue)
+		So(n, ShouldEqual, "tweets")
+
+		_, _, err = name("twitter", "", reindexConfig{Naming: namingSuffix, OnConflict: conflictError}, taken("twitter_reindexed_1"))
+		So(err, ShouldHaveSameTypeAs, &indexExistsError{})
+	})
+
+	Convey("The invalid options are rejected", t, func() {
+		_, _, err := name("twitter", "", reindexConfig{Naming: namingCustom}, taken())
+		So(err, ShouldNotBeNil)
+		_, _, err = name("twitter", "", reindexConfig{Naming: "random"}, taken())
+		So(err, ShouldNotBeNil)
+		_, _, err = name("twitter", "", reindexConfig{Naming: namingTimestamp, DestIndex: "tweets"}, taken())
+		So(err, ShouldNotBeNil)
+		_, _, err = name("twitter", "", reindexConfig{DestIndex: "twitter"}, taken())
+		So(err, ShouldNotBeNil)
+	})
+}
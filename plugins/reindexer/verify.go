@@ -0,0 +1,158 @@
+package reindexer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+const (
+	defaultVerifySample = 100
+	maxVerifySample     = 1000
+)
+
+// verification is the outcome of the comparison of the destination of a
+// reindex with its source.
+type verification struct {
+	Passed      bool  `json:"passed"`
+	SourceCount int64 `json:"source_count"`
+	DestCount   int64 `json:"dest_count"`
+	// Sampled is the number of documents of the source whose checksum was
+	// compared with the one of their copy, Missing and Mismatched hold the
+	// ids of those whose copy is missing or differs.
+	Sampled    int      `json:"sampled"`
+	Missing    []string `json:"missing,omitempty"`
+	Mismatched []string `json:"mismatched,omitempty"`
+}
+
+// verificationError is returned when the destination of a reindex doesn't
+// match its source, in which case the aliases aren't swapped.
+type verificationError struct {
+	sourceIndex, destIndex string
+	result                 *verification
+}
+
+func (e *verificationError) Error() string {
+	return fmt.Sprintf(`verification of the reindex of index "%s" into "%s" failed`, e.sourceIndex, e.destIndex)
+}
+
+// details reports the discrepancies found by the verification.
+func (e *verificationError) details() []util.ErrorDetail {
+	var details []util.ErrorDetail
+	if e.result.SourceCount != e.result.DestCount {
+		details = append(details, util.ErrorDetail{
+			Type:   "count_mismatch",
+			Reason: fmt.Sprintf("source has %d documents, destination has %d", e.result.SourceCount, e.result.DestCount),
+		})
+	}
+	for _, id := range e.result.Missing {
+		details = append(details, util.ErrorDetail{Type: "missing_document", Reason: id})
+	}
+	for _, id := range e.result.Mismatched {
+		details = append(details, util.ErrorDetail{Type: "checksum_mismatch", Reason: id})
+	}
+	return details
+}
+
+// verifiedResponse is the response of a reindex along with its verification.
+type verifiedResponse struct {
+	*es7.BulkIndexByScrollResponse
+	Verification *verification `json:"verification,omitempty"`
+	// SnapshotFallback is the reason the documents were copied although
+	// the snapshot strategy was requested.
+	SnapshotFallback string `json:"snapshot_fallback,omitempty"`
+}
+
+// verifyReindex compares the number of documents of the source and the
+// destination indices and the checksums of a random sample of the documents
+// of the source with those of their copies. The sampled documents are
+// filtered by the fields of the reindex, as their copies are.
+func verifyReindex(ctx context.Context, sourceIndex, destIndex string, config *reindexConfig) (*verification, error) {
+	if _, err := util.GetClient7().Refresh(destIndex).Do(ctx); err != nil {
+		return nil, fmt.Errorf(`error refreshing index "%s": %v`, destIndex, err)
+	}
+
+	result := &verification{}
+	var err error
+	if result.SourceCount, err = util.GetClient7().Count(sourceIndex).Do(ctx); err != nil {
+		return nil, fmt.Errorf(`error counting the documents of index "%s": %v`, sourceIndex, err)
+	}
+	if result.DestCount, err = util.GetClient7().Count(destIndex).Do(ctx); err != nil {
+		return nil, fmt.Errorf(`error counting the documents of index "%s": %v`, destIndex, err)
+	}
+
+	sample, err := util.GetClient7().Search(sourceIndex).
+		Query(es7.NewFunctionScoreQuery().AddScoreFunc(es7.NewRandomFunction())).
+		FetchSourceContext(es7.NewFetchSourceContext(true).Include(config.Include...).Exclude(config.Exclude...)).
+		Size(config.VerifySample).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(`error sampling the documents of index "%s": %v`, sourceIndex, err)
+	}
+	sources := make(map[string]json.RawMessage)
+	if sample.Hits != nil {
+		for _, hit := range sample.Hits.Hits {
+			sources[hit.Id] = hit.Source
+		}
+	}
+
+	copies := make(map[string]json.RawMessage)
+	if len(sources) > 0 {
+		mget := util.GetClient7().Mget()
+		for id := range sources {
+			mget.Add(es7.NewMultiGetItem().Index(destIndex).Id(id))
+		}
+		response, err := mget.Do(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(`error fetching the sampled documents from index "%s": %v`, destIndex, err)
+		}
+		for _, doc := range response.Docs {
+			if doc != nil && doc.Found {
+				copies[doc.Id] = doc.Source
+			}
+		}
+	}
+
+	result.Sampled = len(sources)
+	result.Missing, result.Mismatched = compareSamples(sources, copies)
+	result.Passed = result.SourceCount == result.DestCount &&
+		len(result.Missing) == 0 && len(result.Mismatched) == 0
+	return result, nil
+}
+
+// compareSamples returns the ids of the sampled documents whose copy is
+// missing and of those whose copy has a different checksum.
+func compareSamples(sources, copies map[string]json.RawMessage) (missing, mismatched []string) {
+	for id, source := range sources {
+		copied, ok := copies[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		if checksum(source) != checksum(copied) {
+			mismatched = append(mismatched, id)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(mismatched)
+	return missing, mismatched
+}
+
+// checksum returns the sha256 of the document, regardless of the order of
+// its fields and of its whitespace.
+func checksum(doc json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(doc, &v); err == nil {
+		if canonical, err := json.Marshal(v); err == nil {
+			doc = canonical
+		}
+	}
+	sum := sha256.Sum256(doc)
+	return hex.EncodeToString(sum[:])
+}
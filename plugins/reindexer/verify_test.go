@@ -0,0 +1,54 @@
+package reindexer
+
+import (
+	"encoding/json"
+	"testing"
+
+	es7 "github.com/olivere/elastic/v7"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVerification(t *testing.T) {
+	Convey("The checksums ignore the order of the fields and the whitespace", t, func() {
+		So(checksum(json.RawMessage(`{"a": 1, "b": [1, 2]}`)), ShouldEqual, checksum(json.RawMessage(`{"b":[1,2],"a":1}`)))
+		So(checksum(json.RawMessage(`{"a": 1}`)), ShouldNotEqual, checksum(json.RawMessage(`{"a": 2}`)))
+	})
+
+	Convey("The sampled documents missing or differing from their copy are reported", t, func() {
+		sources := map[string]json.RawMessage{
+			"1": json.RawMessage(`{"title": "foo"}`),
+			"2": json.RawMessage(`{"title": "bar"}`),
+			"3": json.RawMessage(`{"title": "baz"}`),
+		}
+		copies := map[string]json.RawMessage{
+			"1": json.RawMessage(`{"title":"foo"}`),
+			"2": json.RawMessage(`{"title":"qux"}`),
+		}
+		missing, mismatched := compareSamples(sources, copies)
+		So(missing, ShouldResemble, []string{"3"})
+		So(mismatched, ShouldResemble, []string{"2"})
+	})
+
+	Convey("The discrepancies are reported as the details of the error", t, func() {
+		err := &verificationError{sourceIndex: "twitter", destIndex: "twitter_reindexed_1", result: &verification{
+			SourceCount: 3,
+			DestCount:   2,
+			Missing:     []string{"3"},
+		}}
+		details := err.details()
+		So(details, ShouldHaveLength, 2)
+		So(details[0].Type, ShouldEqual, "count_mismatch")
+		So(details[1].Type, ShouldEqual, "missing_document")
+		So(details[1].Reason, ShouldEqual, "3")
+	})
+
+	Convey("The verification is returned along with the response", t, func() {
+		raw, err := json.Marshal(verifiedResponse{
+			BulkIndexByScrollResponse: &es7.BulkIndexByScrollResponse{Total: 3},
+			Verification:              &verification{Passed: true, Sampled: 3},
+		})
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldContainSubstring, `"total":3`)
+		So(string(raw), ShouldContainSubstring, `"verification":{"passed":true`)
+	})
+}
@@ -0,0 +1,206 @@
+package relevance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// maxJudgments bounds the number of judgments fetched for an index.
+const maxJudgments = 10000
+
+type elasticsearch struct {
+	indexName string
+}
+
+func initPlugin(indexName, config, mappings string) (*elasticsearch, error) {
+	ctx := context.Background()
+
+	es := &elasticsearch{indexName}
+	exists, err := util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while checking if index already exists: %v", logTag, err)
+	}
+	if exists {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+		return es, nil
+	}
+
+	// set number_of_replicas to (nodes-1)
+	nodes, err := util.GetTotalNodes()
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf(config, nodes, nodes-1)), &body); err != nil {
+		return nil, err
+	}
+	if docType := util.DocType(); docType != "" {
+		body["mappings"] = map[string]json.RawMessage{docType: json.RawMessage(mappings)}
+	} else {
+		body["mappings"] = json.RawMessage(mappings)
+	}
+
+	_, err = util.GetClient7().CreateIndex(indexName).
+		BodyJson(body).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while creating index named %s: %v", logTag, indexName, err)
+	}
+
+	log.Println(logTag, ": successfully created index named", indexName)
+	return es, nil
+}
+
+func (es *elasticsearch) putJudgments(ctx context.Context, judgments []judgment) error {
+	bulk := util.GetClient7().Bulk().Refresh("wait_for")
+	for _, j := range judgments {
+		request := es7.NewBulkIndexRequest().
+			Index(es.indexName).
+			Id(j.id()).
+			Doc(j)
+		if docType := util.DocType(); docType != "" {
+			request.Type(docType)
+		}
+		bulk.Add(request)
+	}
+	response, err := bulk.Do(ctx)
+	if err != nil {
+		return err
+	}
+	if failed := response.Failed(); len(failed) > 0 && failed[0].Error != nil {
+		return fmt.Errorf("unable to index %d judgments: %s", len(failed), failed[0].Error.Reason)
+	}
+	return nil
+}
+
+func (es *elasticsearch) judgmentsQuery(index, query, docID string) es7.Query {
+	q := es7.NewBoolQuery().Filter(es7.NewTermQuery("index", index))
+	if query != "" {
+		q.Filter(es7.NewTermQuery("query", query))
+	}
+	if docID != "" {
+		q.Filter(es7.NewTermQuery("doc_id", docID))
+	}
+	return q
+}
+
+func (es *elasticsearch) getJudgments(ctx context.Context, index, query string) ([]judgment, error) {
+	response, err := util.GetClient7().Search().
+		Index(es.indexName).
+		Query(es.judgmentsQuery(index, query, "")).
+		Sort("query", true).
+		Sort("doc_id", true).
+		Size(maxJudgments).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	judgments := []judgment{}
+	for _, hit := range response.Hits.Hits {
+		var j judgment
+		if err := json.Unmarshal(hit.Source, &j); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal judgment %s: %v", hit.Id, err)
+		}
+		judgments = append(judgments, j)
+	}
+	return judgments, nil
+}
+
+func (es *elasticsearch) deleteJudgments(ctx context.Context, index, query, docID string) (int64, error) {
+	response, err := util.GetClient7().DeleteByQuery(es.indexName).
+		Query(es.judgmentsQuery(index, query, docID)).
+		Refresh("true").
+		Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return response.Deleted, nil
+}
+
+// rankings searches the index for each of the queries with the request, in a
+// single multi search, and returns the ids of the top k hits of each.
+func (es *elasticsearch) rankings(ctx context.Context, index string, queries []string, request json.RawMessage, k int) ([]ranking, error) {
+	var body bytes.Buffer
+	for _, query := range queries {
+		search, err := searchFor(request, query, k)
+		if err != nil {
+			return nil, err
+		}
+		body.WriteString("{}\n")
+		body.Write(search)
+		body.WriteByte('\n')
+	}
+
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method:      http.MethodPost,
+		Path:        "/" + url.PathEscape(index) + "/_msearch",
+		Body:        body.String(),
+		ContentType: "application/x-ndjson",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var msearch struct {
+		Responses []struct {
+			Error json.RawMessage `json:"error"`
+			Hits  struct {
+				Hits []struct {
+					ID string `json:"_id"`
+				} `json:"hits"`
+			} `json:"hits"`
+		} `json:"responses"`
+	}
+	if err := json.Unmarshal(response.Body, &msearch); err != nil {
+		return nil, err
+	}
+	if len(msearch.Responses) != len(queries) {
+		return nil, fmt.Errorf("expected %d responses, got %d", len(queries), len(msearch.Responses))
+	}
+
+	rankings := make([]ranking, len(queries))
+	for i, r := range msearch.Responses {
+		if len(r.Error) > 0 && string(r.Error) != "null" {
+			rankings[i].err = fmt.Errorf("search failed: %s", r.Error)
+			continue
+		}
+		rankings[i].ids = make([]string, 0, len(r.Hits.Hits))
+		for _, hit := range r.Hits.Hits {
+			rankings[i].ids = append(rankings[i].ids, hit.ID)
+		}
+	}
+	return rankings, nil
+}
+
+// searchFor substitutes the query placeholder of the request with the JSON
+// escaped query, and only fetches the ids of the top k hits.
+func searchFor(request json.RawMessage, query string, k int) ([]byte, error) {
+	escaped, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	rendered := strings.Replace(string(request), queryPlaceholder, string(escaped[1:len(escaped)-1]), -1)
+
+	var search map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(rendered))
+	decoder.UseNumber()
+	if err := decoder.Decode(&search); err != nil {
+		return nil, invalidRequestError{fmt.Errorf(`"request" must be a search body: %v`, err)}
+	}
+	search["size"] = k
+	search["from"] = 0
+	search["_source"] = false
+	return json.Marshal(search)
+}
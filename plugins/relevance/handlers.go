@@ -0,0 +1,237 @@
+package relevance
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	// queryPlaceholder is substituted by each of the judged queries in the
+	// request evaluated.
+	queryPlaceholder = "{{query}}"
+	defaultK         = 10
+	maxK             = 100
+	// defaultRelevantGrade is the least grade of the relevant documents,
+	// by which the reciprocal rank is computed.
+	defaultRelevantGrade = 1
+	defaultRequest       = `{"query": {"simple_query_string": {"query": "{{query}}"}}}`
+)
+
+// invalidRequestError is returned when the evaluated request can't be
+// rendered for the judged queries.
+type invalidRequestError struct {
+	error
+}
+
+// judgmentsBody is the body of the requests recording the judgments.
+type judgmentsBody struct {
+	Judgments []judgment `json:"judgments"`
+}
+
+// evaluationBody is the body of the evaluations, the request is the search
+// body with the query placeholder, which defaults to a simple query string.
+type evaluationBody struct {
+	K             *int            `json:"k"`
+	RelevantGrade *int            `json:"relevant_grade"`
+	Request       json.RawMessage `json:"request"`
+}
+
+// indexName returns the index named by the path, which is the index of the
+// tenant, if any.
+func indexName(req *http.Request) string {
+	return strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+}
+
+// creator returns the name of the user or the permission recording the
+// judgments.
+func creator(req *http.Request) string {
+	if u, err := user.FromContext(req.Context()); err == nil {
+		return u.Username
+	}
+	if p, err := permission.FromContext(req.Context()); err == nil {
+		return p.Username
+	}
+	return ""
+}
+
+func (r *relevance) putJudgments() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		var b judgmentsBody
+		if err := json.Unmarshal(body, &b); err != nil {
+			util.WriteBackError(w, "can't parse request body", http.StatusBadRequest)
+			return
+		}
+		if len(b.Judgments) == 0 {
+			util.WriteBackError(w, `"judgments" can't be empty`, http.StatusBadRequest)
+			return
+		}
+
+		index, by, now := indexName(req), creator(req), time.Now()
+		for i := range b.Judgments {
+			j := &b.Judgments[i]
+			if err := j.validate(); err != nil {
+				util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			j.Index, j.Creator, j.CreatedAt = index, by, now
+		}
+
+		if err := r.es.putJudgments(req.Context(), b.Judgments); err != nil {
+			msg := "an error occurred while recording the judgments"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		msg := fmt.Sprintf("recorded %d judgments", len(b.Judgments))
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+func (r *relevance) getJudgments() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := strings.TrimSpace(req.URL.Query().Get("query"))
+
+		judgments, err := r.es.getJudgments(req.Context(), indexName(req), query)
+		if err != nil {
+			msg := "an error occurred while fetching the judgments"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		raw, err := json.Marshal(judgments)
+		if err != nil {
+			msg := "an error occurred while fetching the judgments"
+			log.Errorln(logTag, ": unable to marshal judgments:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (r *relevance) deleteJudgments() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := strings.TrimSpace(req.URL.Query().Get("query"))
+		docID := req.URL.Query().Get("doc_id")
+		if query == "" && docID != "" {
+			util.WriteBackError(w, `"doc_id" can only be deleted along with the "query"`, http.StatusBadRequest)
+			return
+		}
+
+		deleted, err := r.es.deleteJudgments(req.Context(), indexName(req), query, docID)
+		if err != nil {
+			msg := "an error occurred while deleting the judgments"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		msg := fmt.Sprintf("deleted %d judgments", deleted)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+func (r *relevance) evaluate() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		var b evaluationBody
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err := json.Unmarshal(body, &b); err != nil {
+				util.WriteBackError(w, "can't parse request body", http.StatusBadRequest)
+				return
+			}
+		}
+		k, relevant := defaultK, defaultRelevantGrade
+		if b.K != nil {
+			k = *b.K
+		}
+		if k < 1 || k > maxK {
+			msg := fmt.Sprintf(`"k" must be between 1 and %d`, maxK)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		if b.RelevantGrade != nil {
+			relevant = *b.RelevantGrade
+		}
+		if relevant < 1 || relevant > maxGrade {
+			msg := fmt.Sprintf(`"relevant_grade" must be between 1 and %d`, maxGrade)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		request := b.Request
+		if len(request) == 0 {
+			request = json.RawMessage(defaultRequest)
+		}
+
+		index := indexName(req)
+		judgments, err := r.es.getJudgments(req.Context(), index, "")
+		if err != nil {
+			msg := "an error occurred while fetching the judgments"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		grades := make(map[string]map[string]int)
+		for _, j := range judgments {
+			if grades[j.Query] == nil {
+				grades[j.Query] = make(map[string]int)
+			}
+			grades[j.Query][j.DocID] = j.Grade
+		}
+		if len(grades) == 0 {
+			msg := fmt.Sprintf(`no judgments recorded for index "%s"`, index)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		queries := make([]string, 0, len(grades))
+		for query := range grades {
+			queries = append(queries, query)
+		}
+		sort.Strings(queries)
+
+		rankings, err := r.es.rankings(req.Context(), index, queries, request, k)
+		if e, ok := err.(invalidRequestError); ok {
+			util.WriteBackError(w, e.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			msg := "an error occurred while ranking the judged queries"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+
+		raw, err := json.Marshal(newReport(index, grades, queries, rankings, k, relevant))
+		if err != nil {
+			msg := "an error occurred while evaluating the rankings"
+			log.Errorln(logTag, ": unable to marshal evaluation:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
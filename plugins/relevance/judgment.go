@@ -0,0 +1,142 @@
+package relevance
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxGrade is the highest grade of relevance.
+const maxGrade = 10
+
+// judgment grades the relevance of the document of the index for the query,
+// from 0 for an irrelevant document up to maxGrade.
+type judgment struct {
+	Index     string    `json:"index"`
+	Query     string    `json:"query"`
+	DocID     string    `json:"doc_id"`
+	Grade     int       `json:"grade"`
+	Creator   string    `json:"creator,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (j *judgment) validate() error {
+	j.Query = strings.TrimSpace(j.Query)
+	if j.Query == "" {
+		return errors.New(`"query" of the judgment is required`)
+	}
+	if j.DocID == "" {
+		return fmt.Errorf(`"doc_id" of the judgment for query "%s" is required`, j.Query)
+	}
+	if j.Grade < 0 || j.Grade > maxGrade {
+		return fmt.Errorf(`"grade" of document "%s" for query "%s" must be between 0 and %d`, j.DocID, j.Query, maxGrade)
+	}
+	return nil
+}
+
+// id identifies the judgment by its index, query and document, so that
+// judging the document again for the query replaces the judgment.
+func (j *judgment) id() string {
+	hash := sha1.Sum([]byte(j.Index + "\x00" + j.Query + "\x00" + j.DocID))
+	return hex.EncodeToString(hash[:])
+}
+
+// ranking is the live ranking of a query, the ids of its top hits.
+type ranking struct {
+	ids []string
+	err error
+}
+
+// queryMetrics are the metrics of the ranking of a query at k.
+type queryMetrics struct {
+	Query          string   `json:"query"`
+	NDCG           float64  `json:"ndcg"`
+	ReciprocalRank float64  `json:"reciprocal_rank"`
+	Judged         int      `json:"judged"`
+	Unjudged       int      `json:"unjudged"`
+	Ranking        []string `json:"ranking"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// report are the metrics of the queries along with their means, the queries
+// whose ranking failed are left out of the means.
+type report struct {
+	Index    string         `json:"index"`
+	K        int            `json:"k"`
+	NDCG     float64        `json:"ndcg"`
+	MRR      float64        `json:"mrr"`
+	Queries  []queryMetrics `json:"queries"`
+	Failures int            `json:"failures"`
+}
+
+// evaluate computes the nDCG and the reciprocal rank of the ranking at k, the
+// unjudged documents count as irrelevant. The rank of the first document
+// graded at least relevant is reciprocated.
+func evaluate(query string, ids []string, grades map[string]int, k, relevant int) queryMetrics {
+	if len(ids) > k {
+		ids = ids[:k]
+	}
+	m := queryMetrics{Query: query, Judged: len(grades), Ranking: ids}
+
+	gains := make([]int, len(ids))
+	for i, id := range ids {
+		grade, ok := grades[id]
+		if !ok {
+			m.Unjudged++
+		}
+		gains[i] = grade
+		if m.ReciprocalRank == 0 && ok && grade >= relevant {
+			m.ReciprocalRank = 1 / float64(i+1)
+		}
+	}
+
+	ideal := make([]int, 0, len(grades))
+	for _, grade := range grades {
+		ideal = append(ideal, grade)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ideal)))
+	if len(ideal) > k {
+		ideal = ideal[:k]
+	}
+	if idcg := dcg(ideal); idcg > 0 {
+		m.NDCG = dcg(gains) / idcg
+	}
+	return m
+}
+
+// dcg is the discounted cumulative gain of the graded ranking.
+func dcg(grades []int) float64 {
+	var sum float64
+	for i, grade := range grades {
+		sum += (math.Pow(2, float64(grade)) - 1) / math.Log2(float64(i+2))
+	}
+	return sum
+}
+
+// newReport evaluates the rankings of the judged queries.
+func newReport(index string, judgments map[string]map[string]int, queries []string, rankings []ranking, k, relevant int) report {
+	r := report{Index: index, K: k, Queries: make([]queryMetrics, 0, len(queries))}
+	var evaluated int
+	for i, query := range queries {
+		if err := rankings[i].err; err != nil {
+			r.Queries = append(r.Queries, queryMetrics{Query: query, Judged: len(judgments[query]), Error: err.Error()})
+			r.Failures++
+			continue
+		}
+		m := evaluate(query, rankings[i].ids, judgments[query], k, relevant)
+		r.Queries = append(r.Queries, m)
+		r.NDCG += m.NDCG
+		r.MRR += m.ReciprocalRank
+		evaluated++
+	}
+	if evaluated > 0 {
+		r.NDCG /= float64(evaluated)
+		r.MRR /= float64(evaluated)
+	}
+	return r
+}
@@ -0,0 +1,110 @@
+package relevance
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
+
+func TestEvaluate(t *testing.T) {
+	grades := map[string]int{"a": 3, "b": 2, "c": 0, "d": 1}
+
+	Convey("The ideal ranking scores 1", t, func() {
+		m := evaluate("shoes", []string{"a", "b", "d", "c"}, grades, 10, 1)
+		So(m.NDCG, ShouldEqual, 1)
+		So(m.ReciprocalRank, ShouldEqual, 1)
+		So(m.Unjudged, ShouldEqual, 0)
+		So(m.Judged, ShouldEqual, 4)
+	})
+
+	Convey("The unjudged documents count as irrelevant", t, func() {
+		m := evaluate("shoes", []string{"x", "c", "b", "a"}, grades, 10, 1)
+		So(m.Unjudged, ShouldEqual, 1)
+		So(m.ReciprocalRank, ShouldEqual, 1.0/3)
+		// (3/log2(4) + 7/log2(5)) / (7 + 3/log2(3) + 1/2)
+		So(round(m.NDCG), ShouldEqual, 0.481)
+	})
+
+	Convey("The ranking is cut at k", t, func() {
+		m := evaluate("shoes", []string{"c", "a", "b"}, grades, 1, 1)
+		So(m.Ranking, ShouldResemble, []string{"c"})
+		So(m.NDCG, ShouldEqual, 0)
+		So(m.ReciprocalRank, ShouldEqual, 0)
+	})
+
+	Convey("The reciprocal rank is of the first relevant document", t, func() {
+		m := evaluate("shoes", []string{"d", "b", "a"}, grades, 10, 2)
+		So(m.ReciprocalRank, ShouldEqual, 0.5)
+	})
+
+	Convey("Queries without relevant documents score 0", t, func() {
+		m := evaluate("socks", []string{"c"}, map[string]int{"c": 0}, 10, 1)
+		So(m.NDCG, ShouldEqual, 0)
+	})
+}
+
+func TestNewReport(t *testing.T) {
+	judgments := map[string]map[string]int{
+		"boots": {"a": 1},
+		"hats":  {"b": 1},
+		"shoes": {"a": 1},
+	}
+	rankings := []ranking{
+		{ids: []string{"a"}},
+		{err: errors.New("search failed")},
+		{ids: []string{"b", "a"}},
+	}
+
+	Convey("The failed queries are left out of the means", t, func() {
+		r := newReport("products", judgments, []string{"boots", "hats", "shoes"}, rankings, 10, 1)
+		So(r.Failures, ShouldEqual, 1)
+		So(r.Queries, ShouldHaveLength, 3)
+		So(r.Queries[1].Error, ShouldEqual, "search failed")
+		So(r.MRR, ShouldEqual, 0.75)
+		So(round(r.NDCG), ShouldEqual, round((1+1/math.Log2(3))/2))
+	})
+}
+
+func TestJudgment(t *testing.T) {
+	Convey("The judgments are validated", t, func() {
+		So((&judgment{Query: " ", DocID: "a"}).validate(), ShouldNotBeNil)
+		So((&judgment{Query: "shoes"}).validate(), ShouldNotBeNil)
+		So((&judgment{Query: "shoes", DocID: "a", Grade: maxGrade + 1}).validate(), ShouldNotBeNil)
+		So((&judgment{Query: "shoes", DocID: "a", Grade: -1}).validate(), ShouldNotBeNil)
+	})
+
+	Convey("Judging a document again replaces the judgment", t, func() {
+		j1 := &judgment{Index: "products", Query: " shoes ", DocID: "a", Grade: 1}
+		j2 := &judgment{Index: "products", Query: "shoes", DocID: "a", Grade: 3}
+		So(j1.validate(), ShouldBeNil)
+		So(j2.validate(), ShouldBeNil)
+		So(j1.id(), ShouldEqual, j2.id())
+		So(j1.id(), ShouldNotEqual, (&judgment{Index: "items", Query: "shoes", DocID: "a"}).id())
+	})
+}
+
+func TestSearchFor(t *testing.T) {
+	Convey("The query is escaped into the request", t, func() {
+		raw, err := searchFor(json.RawMessage(defaultRequest), `red "running" shoes`, 5)
+		So(err, ShouldBeNil)
+		var search map[string]interface{}
+		So(json.Unmarshal(raw, &search), ShouldBeNil)
+		So(search["size"], ShouldEqual, 5)
+		So(search["_source"], ShouldEqual, false)
+		q := search["query"].(map[string]interface{})["simple_query_string"].(map[string]interface{})
+		So(q["query"], ShouldEqual, `red "running" shoes`)
+	})
+
+	Convey("Invalid requests are rejected", t, func() {
+		_, err := searchFor(json.RawMessage(`[1]`), "shoes", 5)
+		_, ok := err.(invalidRequestError)
+		So(ok, ShouldBeTrue)
+	})
+}
@@ -0,0 +1,78 @@
+package relevance
+
+import (
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                  = "[relevance]"
+	defaultJudgmentsEsIndex = ".relevance_judgments"
+	envJudgmentsEsIndex     = "RELEVANCE_JUDGMENTS_ES_INDEX"
+	settings                = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+	mappings                = `
+	{
+	  "properties": {
+	    "index": { "type": "keyword" },
+	    "query": { "type": "keyword" },
+	    "doc_id": { "type": "keyword" },
+	    "grade": { "type": "integer" },
+	    "creator": { "type": "keyword" },
+	    "created_at": { "type": "date" }
+	  }
+	}`
+)
+
+var (
+	singleton *relevance
+	once      sync.Once
+)
+
+// relevance records the relevance judgments of the documents for the queries
+// and evaluates the live rankings of the queries against them.
+type relevance struct {
+	es relevanceService
+}
+
+// Use only this function to fetch the instance of relevance from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *relevance {
+	once.Do(func() { singleton = &relevance{} })
+	return singleton
+}
+
+func (r *relevance) Name() string {
+	return logTag
+}
+
+func (r *relevance) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	indexName := os.Getenv(envJudgmentsEsIndex)
+	if indexName == "" {
+		indexName = defaultJudgmentsEsIndex
+	}
+
+	// initialize the dao
+	var err error
+	r.es, err = initPlugin(indexName, settings, mappings)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *relevance) Routes() []plugins.Route {
+	return r.routes()
+}
+
+// Default empty middleware array function
+func (r *relevance) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
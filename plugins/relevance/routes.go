@@ -0,0 +1,43 @@
+package relevance
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (r *relevance) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	evaluationMiddleware := (&evaluationChain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get relevance judgments",
+			Methods:     []string{http.MethodGet},
+			Path:        "/{index}/_relevance/judgments",
+			HandlerFunc: middleware(r.getJudgments()),
+			Description: "Returns the relevance judgments of {index}, optionally of the query param",
+		},
+		{
+			Name:        "Put relevance judgments",
+			Methods:     []string{http.MethodPost},
+			Path:        "/{index}/_relevance/judgments",
+			HandlerFunc: middleware(r.putJudgments()),
+			Description: "Records the relevance judgments of the documents of {index} for the queries",
+		},
+		{
+			Name:        "Delete relevance judgments",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/{index}/_relevance/judgments",
+			HandlerFunc: middleware(r.deleteJudgments()),
+			Description: "Deletes the relevance judgments of {index}, optionally of the query and doc_id params",
+		},
+		{
+			Name:        "Evaluate relevance",
+			Methods:     []string{http.MethodPost},
+			Path:        "/{index}/_relevance/evaluate",
+			HandlerFunc: evaluationMiddleware(r.evaluate()),
+			Description: "Computes the nDCG and MRR of the live rankings of the judged queries of {index}",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,13 @@
+package relevance
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type relevanceService interface {
+	putJudgments(ctx context.Context, judgments []judgment) error
+	getJudgments(ctx context.Context, index, query string) ([]judgment, error)
+	deleteJudgments(ctx context.Context, index, query, docID string) (int64, error)
+	rankings(ctx context.Context, index string, queries []string, request json.RawMessage, k int) ([]ranking, error)
+}
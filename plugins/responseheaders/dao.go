@@ -0,0 +1,104 @@
+package responseheaders
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware/headers"
+	"github.com/appbaseio/arc/util"
+)
+
+// maxRules bounds the number of rules loaded.
+const maxRules = 1000
+
+type elasticsearch struct {
+	indexName string
+}
+
+func initPlugin(indexName, config, mappings string) (*elasticsearch, error) {
+	ctx := context.Background()
+
+	es := &elasticsearch{indexName}
+	exists, err := util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while checking if index already exists: %v", logTag, err)
+	}
+	if exists {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+		return es, nil
+	}
+
+	// set number_of_replicas to (nodes-1)
+	nodes, err := util.GetTotalNodes()
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf(config, nodes, nodes-1)), &body); err != nil {
+		return nil, err
+	}
+	if docType := util.DocType(); docType != "" {
+		body["mappings"] = map[string]json.RawMessage{docType: json.RawMessage(mappings)}
+	} else {
+		body["mappings"] = json.RawMessage(mappings)
+	}
+
+	_, err = util.GetClient7().CreateIndex(indexName).
+		BodyJson(body).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while creating index named %s: %v", logTag, indexName, err)
+	}
+
+	log.Println(logTag, ": successfully created index named", indexName)
+	return es, nil
+}
+
+func (es *elasticsearch) getRules(ctx context.Context) ([]headers.Rule, error) {
+	response, err := util.GetClient7().Search().
+		Index(es.indexName).
+		Size(maxRules).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []headers.Rule{}
+	for _, hit := range response.Hits.Hits {
+		var r headers.Rule
+		if err := json.Unmarshal(hit.Source, &r); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal response headers rule %s: %v", hit.Id, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (es *elasticsearch) putRule(ctx context.Context, r headers.Rule) error {
+	request := util.GetClient7().Index().
+		Refresh("wait_for").
+		Index(es.indexName).
+		Id(r.Name).
+		BodyJson(r)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+func (es *elasticsearch) deleteRule(ctx context.Context, name string) error {
+	request := util.GetClient7().Delete().
+		Refresh("wait_for").
+		Index(es.indexName).
+		Id(name)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
@@ -0,0 +1,103 @@
+package responseheaders
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware/headers"
+	"github.com/appbaseio/arc/util"
+	"github.com/gorilla/mux"
+)
+
+// getRules returns all the rules applied, the rules of the file first.
+func (r *responseHeaders) getRules() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := json.Marshal(headers.Rules())
+		if err != nil {
+			msg := "an error occurred while fetching the response headers rules"
+			log.Errorln(logTag, ": unable to marshal response headers rules:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (r *responseHeaders) getRule() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		rule, ok := r.get(name)
+		if !ok {
+			msg := fmt.Sprintf(`response headers rule "%s" not found`, name)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		raw, err := json.Marshal(rule)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching the response headers rule "%s"`, name)
+			log.Errorln(logTag, ": unable to marshal response headers rule:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (r *responseHeaders) putRule() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var rule headers.Rule
+		if err := json.Unmarshal(body, &rule); err != nil {
+			msg := "can't parse request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		rule.Name = name
+		rule.Source = ""
+		if err := rule.Validate(); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := r.es.putRule(req.Context(), rule); err != nil {
+			msg := fmt.Sprintf(`an error occurred while saving the response headers rule "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		r.put(rule)
+
+		msg := fmt.Sprintf(`response headers rule "%s" saved`, name)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+func (r *responseHeaders) deleteRule() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		if err := r.es.deleteRule(req.Context(), name); err != nil {
+			msg := fmt.Sprintf(`response headers rule "%s" not found`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		r.delete(name)
+
+		msg := fmt.Sprintf(`response headers rule "%s" deleted`, name)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
@@ -0,0 +1,164 @@
+package responseheaders
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/headers"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                 = "[responseheaders]"
+	defaultRulesEsIndex    = ".response_headers"
+	envRulesEsIndex        = "RESPONSE_HEADERS_ES_INDEX"
+	envRefreshInterval     = "RESPONSE_HEADERS_REFRESH_INTERVAL"
+	defaultRefreshInterval = time.Minute
+	// source is the source the rules managed via the api are registered under.
+	source   = "api"
+	settings = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+	mappings = `
+	{
+	  "dynamic": false,
+	  "properties": {
+	    "name": { "type": "keyword" }
+	  }
+	}`
+)
+
+var (
+	singleton *responseHeaders
+	once      sync.Once
+)
+
+// responseHeaders manages the rules injecting custom response headers at
+// runtime, on top of those of RESPONSE_HEADERS_FILE. The rules are stored in
+// elasticsearch and reloaded periodically by each arc instance.
+type responseHeaders struct {
+	es              *elasticsearch
+	refreshInterval time.Duration
+
+	mu    sync.RWMutex
+	rules map[string]headers.Rule
+}
+
+// Use only this function to fetch the instance of responseHeaders from
+// within this package to avoid creating stateless duplicates of the plugin.
+func Instance() *responseHeaders {
+	once.Do(func() {
+		singleton = &responseHeaders{
+			refreshInterval: defaultRefreshInterval,
+			rules:           make(map[string]headers.Rule),
+		}
+	})
+	return singleton
+}
+
+func (r *responseHeaders) Name() string {
+	return logTag
+}
+
+func (r *responseHeaders) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	indexName := os.Getenv(envRulesEsIndex)
+	if indexName == "" {
+		indexName = defaultRulesEsIndex
+	}
+	if value := os.Getenv(envRefreshInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Errorln(logTag, ": invalid value for", envRefreshInterval, ":", value)
+		} else {
+			r.refreshInterval = d
+		}
+	}
+
+	// initialize the dao
+	var err error
+	r.es, err = initPlugin(indexName, settings, mappings)
+	if err != nil {
+		return err
+	}
+
+	// the rules changed through other arc instances are picked up on refresh
+	r.refresh()
+	go func() {
+		for range time.Tick(r.refreshInterval) {
+			r.refresh()
+		}
+	}()
+	return nil
+}
+
+func (r *responseHeaders) Routes() []plugins.Route {
+	return r.routes()
+}
+
+func (r *responseHeaders) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{bindTenant}
+}
+
+// refresh replaces the rules with the ones stored in elasticsearch.
+func (r *responseHeaders) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.refreshInterval)
+	defer cancel()
+
+	rules, err := r.es.getRules(ctx)
+	if err != nil {
+		log.Errorln(logTag, ": unable to load the response headers rules:", err)
+		return
+	}
+	set := make(map[string]headers.Rule, len(rules))
+	for _, rule := range rules {
+		set[rule.Name] = rule
+	}
+	r.set(set)
+}
+
+// set replaces the rules and registers them, sorted by name.
+func (r *responseHeaders) set(rules map[string]headers.Rule) {
+	r.mu.Lock()
+	r.rules = rules
+	r.mu.Unlock()
+	headers.Set(source, r.all())
+}
+
+func (r *responseHeaders) put(rule headers.Rule) {
+	r.mu.Lock()
+	r.rules[rule.Name] = rule
+	r.mu.Unlock()
+	headers.Set(source, r.all())
+}
+
+func (r *responseHeaders) delete(name string) {
+	r.mu.Lock()
+	delete(r.rules, name)
+	r.mu.Unlock()
+	headers.Set(source, r.all())
+}
+
+func (r *responseHeaders) get(name string) (headers.Rule, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rule, ok := r.rules[name]
+	return rule, ok
+}
+
+// all returns the rules managed via the api sorted by name.
+func (r *responseHeaders) all() []headers.Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rules := make([]headers.Rule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
@@ -0,0 +1,42 @@
+package responseheaders
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (r *responseHeaders) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get response headers rules",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_response_headers",
+			HandlerFunc: middleware(isAdmin(r.getRules())),
+			Description: "Returns the rules injecting custom response headers, those of the file included, in the order they apply",
+		},
+		{
+			Name:        "Get response headers rule",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_response_headers/{name}",
+			HandlerFunc: middleware(isAdmin(r.getRule())),
+			Description: "Returns the response headers rule {name}",
+		},
+		{
+			Name:        "Put response headers rule",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_response_headers/{name}",
+			HandlerFunc: middleware(isAdmin(r.putRule())),
+			Description: "Creates or updates the response headers rule {name}",
+		},
+		{
+			Name:        "Delete response headers rule",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_response_headers/{name}",
+			HandlerFunc: middleware(isAdmin(r.deleteRule())),
+			Description: "Deletes the response headers rule {name}",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,103 @@
+package schemas
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// maxSchemas bounds the number of schemas loaded.
+const maxSchemas = 1000
+
+type elasticsearch struct {
+	indexName string
+}
+
+func initPlugin(indexName, config, mappings string) (*elasticsearch, error) {
+	ctx := context.Background()
+
+	es := &elasticsearch{indexName}
+	exists, err := util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while checking if index already exists: %v", logTag, err)
+	}
+	if exists {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+		return es, nil
+	}
+
+	// set number_of_replicas to (nodes-1)
+	nodes, err := util.GetTotalNodes()
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf(config, nodes, nodes-1)), &body); err != nil {
+		return nil, err
+	}
+	if docType := util.DocType(); docType != "" {
+		body["mappings"] = map[string]json.RawMessage{docType: json.RawMessage(mappings)}
+	} else {
+		body["mappings"] = json.RawMessage(mappings)
+	}
+
+	_, err = util.GetClient7().CreateIndex(indexName).
+		BodyJson(body).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while creating index named %s: %v", logTag, indexName, err)
+	}
+
+	log.Println(logTag, ": successfully created index named", indexName)
+	return es, nil
+}
+
+func (es *elasticsearch) getSchemas(ctx context.Context) ([]indexSchema, error) {
+	response, err := util.GetClient7().Search().
+		Index(es.indexName).
+		Size(maxSchemas).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := []indexSchema{}
+	for _, hit := range response.Hits.Hits {
+		var s indexSchema
+		if err := json.Unmarshal(hit.Source, &s); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal schema %s: %v", hit.Id, err)
+		}
+		stored = append(stored, s)
+	}
+	return stored, nil
+}
+
+func (es *elasticsearch) putSchema(ctx context.Context, s indexSchema) error {
+	request := util.GetClient7().Index().
+		Refresh("wait_for").
+		Index(es.indexName).
+		Id(s.Index).
+		BodyJson(s)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+func (es *elasticsearch) deleteSchema(ctx context.Context, index string) error {
+	request := util.GetClient7().Delete().
+		Refresh("wait_for").
+		Index(es.indexName).
+		Id(index)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
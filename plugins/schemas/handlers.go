@@ -0,0 +1,104 @@
+package schemas
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util"
+)
+
+func (s *schemas) getSchemas() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := json.Marshal(s.registry.all())
+		if err != nil {
+			msg := "an error occurred while fetching the schemas"
+			log.Errorln(logTag, ": unable to marshal schemas:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *schemas) getSchema() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+
+		// the schema applying to the index, which may be that of a pattern
+		e := s.registry.lookup(index)
+		if e == nil {
+			msg := fmt.Sprintf(`schema for "index"="%s" not found`, index)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		raw, err := json.Marshal(e.indexSchema)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching the schema of "%s"`, index)
+			log.Errorln(logTag, ": unable to marshal schema:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// putSchema takes the json schema itself as the body, the schemas using
+// unsupported keywords are rejected.
+func (s *schemas) putSchema() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		schema := indexSchema{Index: index, Schema: body, UpdatedAt: time.Now()}
+		if reqUser, err := user.FromContext(req.Context()); err == nil {
+			schema.Creator = reqUser.Username
+		}
+		compiled, err := schema.compile()
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := s.es.putSchema(req.Context(), schema); err != nil {
+			msg := fmt.Sprintf(`an error occurred while saving the schema of "%s"`, index)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		s.registry.put(schema, compiled)
+
+		msg := fmt.Sprintf(`schema of "%s" saved`, index)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+func (s *schemas) deleteSchema() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		index := mux.Vars(req)["index"]
+
+		if err := s.es.deleteSchema(req.Context(), index); err != nil {
+			msg := fmt.Sprintf(`schema for "index"="%s" not found`, index)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		s.registry.delete(index)
+
+		msg := fmt.Sprintf(`schema of "%s" deleted`, index)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
@@ -0,0 +1,113 @@
+package schemas
+
+import (
+	"fmt"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clustersCategory := category.Clusters
+		ctx := category.NewContext(req.Context(), &clustersCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusterACL := acl.Cluster
+		ctx := acl.NewContext(req.Context(), &clusterACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the schemas apply to the
+// documents indexed by all the credentials.
+var isAdmin = validate.Admin("manage the schemas")
+
+// validateDocs rejects the index and bulk requests whose documents violate
+// the schemas of their indices, a bulk is rejected as a whole if any of its
+// documents is invalid. The index is the one named by the path, which is
+// that of the tenant, if any. The partial updates aren't validated.
+func (s *schemas) validateDocs(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		reqACL, err := acl.FromContext(req.Context())
+		if err != nil || s.registry.empty() || req.Body == nil ||
+			(*reqACL != acl.Index && *reqACL != acl.Create && *reqACL != acl.Bulk) {
+			h(w, req)
+			return
+		}
+		index := pathIndex(req.URL.Path)
+		var e *entry
+		if *reqACL != acl.Bulk {
+			if e = s.registry.lookup(index); e == nil {
+				h(w, req)
+				return
+			}
+		}
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var details []util.ErrorDetail
+		if *reqACL == acl.Bulk {
+			details, err = s.registry.validateBulk(body, index)
+			if err != nil {
+				// the malformed bulks are left for elasticsearch to report
+				h(w, req)
+				return
+			}
+		} else {
+			details = validateDoc(e.compiled, body, "")
+		}
+		if len(details) > 0 {
+			msg := fmt.Sprintf("document violates the schema of %s", e.Index)
+			if *reqACL == acl.Bulk {
+				msg = "documents of the bulk violate the schemas of their indices"
+			}
+			util.WriteBackErrorWithDetails(w, msg, http.StatusBadRequest, details)
+			return
+		}
+		h(w, req)
+	}
+}
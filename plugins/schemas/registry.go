@@ -0,0 +1,126 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/jsonschema"
+)
+
+// indexSchema is the json schema attached to the indices matching the
+// index, which is either a name or a wildcard pattern.
+type indexSchema struct {
+	Index     string          `json:"index"`
+	Schema    json.RawMessage `json:"schema"`
+	Creator   string          `json:"creator,omitempty"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+func (s *indexSchema) compile() (*jsonschema.Schema, error) {
+	if s.Index == "" {
+		return nil, errors.New(`"index" of the schema is required`)
+	}
+	if strings.ContainsAny(s.Index, ",/") {
+		return nil, fmt.Errorf(`invalid "index" "%s", expected a single index name or pattern`, s.Index)
+	}
+	if len(s.Schema) == 0 {
+		return nil, errors.New(`"schema" is required`)
+	}
+	return jsonschema.Compile(s.Schema)
+}
+
+// entry is the schema along with its compiled form and index pattern.
+type entry struct {
+	*indexSchema
+	compiled *jsonschema.Schema
+	pattern  *regexp.Regexp
+}
+
+func newEntry(s *indexSchema, compiled *jsonschema.Schema) *entry {
+	pattern := "^" + strings.Replace(regexp.QuoteMeta(s.Index), `\*`, ".*", -1) + "$"
+	return &entry{indexSchema: s, compiled: compiled, pattern: regexp.MustCompile(pattern)}
+}
+
+// registry holds the schemas of the indices, looked up on each write.
+type registry struct {
+	sync.RWMutex
+	entries map[string]*entry
+}
+
+func newRegistry() *registry {
+	return &registry{entries: make(map[string]*entry)}
+}
+
+// set replaces the schemas, the ones that no longer compile are skipped.
+func (r *registry) set(stored []indexSchema) {
+	entries := make(map[string]*entry, len(stored))
+	for i := range stored {
+		compiled, err := stored[i].compile()
+		if err != nil {
+			log.Errorln(logTag, ": skipping the schema of", stored[i].Index, ":", err)
+			continue
+		}
+		entries[stored[i].Index] = newEntry(&stored[i], compiled)
+	}
+	r.Lock()
+	defer r.Unlock()
+	r.entries = entries
+}
+
+func (r *registry) put(s indexSchema, compiled *jsonschema.Schema) {
+	r.Lock()
+	defer r.Unlock()
+	r.entries[s.Index] = newEntry(&s, compiled)
+}
+
+func (r *registry) delete(index string) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.entries, index)
+}
+
+func (r *registry) all() []indexSchema {
+	r.RLock()
+	defer r.RUnlock()
+	all := make([]indexSchema, 0, len(r.entries))
+	for _, e := range r.entries {
+		all = append(all, *e.indexSchema)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Index < all[j].Index })
+	return all
+}
+
+func (r *registry) empty() bool {
+	r.RLock()
+	defer r.RUnlock()
+	return len(r.entries) == 0
+}
+
+// lookup returns the schema of the index, which is the one named after it,
+// else the one of the longest matching pattern.
+func (r *registry) lookup(index string) *entry {
+	r.RLock()
+	defer r.RUnlock()
+	if e, ok := r.entries[index]; ok {
+		return e
+	}
+	var match *entry
+	for _, e := range r.entries {
+		if !e.pattern.MatchString(index) {
+			continue
+		}
+		if match == nil || len(e.Index) > len(match.Index) ||
+			(len(e.Index) == len(match.Index) && e.Index < match.Index) {
+			match = e
+		}
+	}
+	return match
+}
@@ -0,0 +1,42 @@
+package schemas
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (s *schemas) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get schemas",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_schemas",
+			HandlerFunc: middleware(isAdmin(s.getSchemas())),
+			Description: "Returns the schemas of all the indices",
+		},
+		{
+			Name:        "Get index schema",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_schemas/{index}",
+			HandlerFunc: middleware(isAdmin(s.getSchema())),
+			Description: "Returns the schema validating the documents indexed into {index}",
+		},
+		{
+			Name:        "Put index schema",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_schemas/{index}",
+			HandlerFunc: middleware(isAdmin(s.putSchema())),
+			Description: "Creates or updates the schema of the index or index pattern {index}",
+		},
+		{
+			Name:        "Delete index schema",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_schemas/{index}",
+			HandlerFunc: middleware(isAdmin(s.deleteSchema())),
+			Description: "Deletes the schema of the index or index pattern {index}",
+		},
+	}
+	return routes
+}
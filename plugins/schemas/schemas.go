@@ -0,0 +1,116 @@
+package schemas
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                 = "[schemas]"
+	defaultSchemasEsIndex  = ".schemas"
+	envSchemasEsIndex      = "SCHEMAS_ES_INDEX"
+	envRefreshInterval     = "SCHEMAS_REFRESH_INTERVAL"
+	defaultRefreshInterval = time.Minute
+	settings               = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+	mappings               = `
+	{
+	  "dynamic": false,
+	  "properties": {
+	    "index": { "type": "keyword" },
+	    "schema": { "type": "object", "enabled": false },
+	    "creator": { "type": "keyword" },
+	    "updated_at": { "type": "date" }
+	  }
+	}`
+)
+
+var (
+	singleton *schemas
+	once      sync.Once
+)
+
+// schemas validates the documents indexed into the indices against the
+// json schemas attached to them. The schemas are stored in elasticsearch
+// and reloaded periodically by each arc instance.
+type schemas struct {
+	es              *elasticsearch
+	registry        *registry
+	refreshInterval time.Duration
+}
+
+// Use only this function to fetch the instance of schemas from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *schemas {
+	once.Do(func() {
+		singleton = &schemas{
+			registry:        newRegistry(),
+			refreshInterval: defaultRefreshInterval,
+		}
+	})
+	return singleton
+}
+
+func (s *schemas) Name() string {
+	return logTag
+}
+
+func (s *schemas) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	indexName := os.Getenv(envSchemasEsIndex)
+	if indexName == "" {
+		indexName = defaultSchemasEsIndex
+	}
+	if value := os.Getenv(envRefreshInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Errorln(logTag, ": invalid value for", envRefreshInterval, ":", value)
+		} else {
+			s.refreshInterval = d
+		}
+	}
+
+	// initialize the dao
+	var err error
+	s.es, err = initPlugin(indexName, settings, mappings)
+	if err != nil {
+		return err
+	}
+
+	// the schemas changed through other arc instances are picked up on refresh
+	s.refresh()
+	go func() {
+		for range time.Tick(s.refreshInterval) {
+			s.refresh()
+		}
+	}()
+	return nil
+}
+
+func (s *schemas) Routes() []plugins.Route {
+	return s.routes()
+}
+
+func (s *schemas) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{s.validateDocs}
+}
+
+// refresh replaces the schemas with the ones stored in elasticsearch.
+func (s *schemas) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), s.refreshInterval)
+	defer cancel()
+
+	stored, err := s.es.getSchemas(ctx)
+	if err != nil {
+		log.Errorln(logTag, ": unable to load the schemas:", err)
+		return
+	}
+	s.registry.set(stored)
+}
@@ -0,0 +1,99 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/jsonschema"
+)
+
+// violationType is the type of the error details locating the violations.
+const violationType = "schema_violation"
+
+// validateDoc validates the document against the schema of the index, and
+// returns the violations prefixed by the location of the document, if any.
+func validateDoc(s *jsonschema.Schema, doc []byte, location string) []util.ErrorDetail {
+	errs, err := s.ValidateJSON(doc)
+	if err != nil {
+		return []util.ErrorDetail{{Type: violationType, Reason: "malformed document: " + err.Error(), Location: location}}
+	}
+	details := make([]util.ErrorDetail, 0, len(errs))
+	for _, e := range errs {
+		path := e.Path
+		if path == "" {
+			path = "/"
+		}
+		details = append(details, util.ErrorDetail{Type: violationType, Reason: e.Message, Location: location + path})
+	}
+	return details
+}
+
+// validateBulk validates the sources of the index and create actions of the
+// bulk against the schemas of their indices, the actions without an index
+// target the one of the path. The violations are located by the position of
+// the action in the bulk. The update and delete actions aren't validated.
+func (r *registry) validateBulk(body []byte, pathIndex string) ([]util.ErrorDetail, error) {
+	var details []util.ErrorDetail
+	var pending *jsonschema.Schema
+	var validating bool
+	var position int
+	for line := 1; len(body) > 0; line++ {
+		n := bytes.IndexByte(body, '\n')
+		var raw []byte
+		if n < 0 {
+			raw, body = body, nil
+		} else {
+			raw, body = body[:n], body[n+1:]
+		}
+		if len(bytes.TrimSpace(raw)) == 0 {
+			continue
+		}
+		if validating {
+			if pending != nil {
+				details = append(details, validateDoc(pending, raw, fmt.Sprintf("[%d]", position))...)
+			}
+			pending, validating = nil, false
+			position++
+			continue
+		}
+
+		var meta map[string]struct {
+			Index string `json:"_index"`
+		}
+		if err := json.Unmarshal(raw, &meta); err != nil || len(meta) != 1 {
+			return nil, fmt.Errorf("malformed bulk action on line %d", line)
+		}
+		for op, m := range meta {
+			switch op {
+			case "index", "create":
+				index := m.Index
+				if index == "" {
+					index = pathIndex
+				}
+				if e := r.lookup(index); e != nil {
+					pending = e.compiled
+				}
+				validating = true
+			case "update":
+				validating = true
+			case "delete":
+				position++
+			default:
+				return nil, fmt.Errorf(`unknown bulk action "%s" on line %d`, op, line)
+			}
+		}
+	}
+	return details, nil
+}
+
+// pathIndex returns the index named by the path, if it names a single one.
+func pathIndex(path string) string {
+	segment := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	if segment == "" || strings.HasPrefix(segment, "_") || strings.Contains(segment, ",") {
+		return ""
+	}
+	return segment
+}
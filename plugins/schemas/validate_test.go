@@ -0,0 +1,94 @@
+package schemas
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const productSchema = `{
+  "type": "object",
+  "required": ["name"],
+  "properties": {
+    "name": {"type": "string"},
+    "price": {"type": "number", "minimum": 0}
+  },
+  "additionalProperties": false
+}`
+
+func newTestRegistry() *registry {
+	r := newRegistry()
+	r.set([]indexSchema{
+		{Index: "products", Schema: []byte(productSchema)},
+		{Index: "logs-*", Schema: []byte(`{"type": "object", "required": ["message"]}`)},
+		{Index: "broken", Schema: []byte(`{"oneOf": []}`)},
+	})
+	return r
+}
+
+func TestRegistry(t *testing.T) {
+	r := newTestRegistry()
+
+	Convey("The schemas are looked up by name, else by pattern", t, func() {
+		So(r.lookup("products").Index, ShouldEqual, "products")
+		So(r.lookup("logs-2020").Index, ShouldEqual, "logs-*")
+		So(r.lookup("orders"), ShouldBeNil)
+	})
+
+	Convey("The schemas that don't compile are skipped", t, func() {
+		So(r.lookup("broken"), ShouldBeNil)
+		So(len(r.all()), ShouldEqual, 2)
+	})
+}
+
+func TestValidateDoc(t *testing.T) {
+	r := newTestRegistry()
+	s := r.lookup("products").compiled
+
+	Convey("Valid documents have no violations", t, func() {
+		So(validateDoc(s, []byte(`{"name": "shoe", "price": 10}`), ""), ShouldBeEmpty)
+	})
+
+	Convey("The violations are located by their path", t, func() {
+		details := validateDoc(s, []byte(`{"price": -1, "color": "red"}`), "")
+		So(len(details), ShouldEqual, 3)
+		So(details[0].Location, ShouldEqual, "/")
+		So(details[0].Reason, ShouldEqual, `missing required property "name"`)
+		So(details[1].Location, ShouldEqual, "/color")
+		So(details[2].Location, ShouldEqual, "/price")
+	})
+}
+
+func TestValidateBulk(t *testing.T) {
+	r := newTestRegistry()
+
+	Convey("The index and create actions are validated by their position", t, func() {
+		body := `{"delete": {"_id": "1"}}
+{"index": {"_id": "2"}}
+{"name": "shoe"}
+{"update": {"_id": "3"}}
+{"doc": {"color": "red"}}
+{"create": {"_index": "logs-1", "_id": "4"}}
+{}
+{"index": {"_index": "orders"}}
+{"anything": true}
+`
+		details, err := r.validateBulk([]byte(body), "products")
+		So(err, ShouldBeNil)
+		So(len(details), ShouldEqual, 1)
+		So(details[0].Location, ShouldEqual, "[3]/")
+	})
+
+	Convey("Malformed bulks are reported", t, func() {
+		_, err := r.validateBulk([]byte("{\"unknown\": {}}\n"), "products")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestPathIndex(t *testing.T) {
+	Convey("Only a single index is taken from the path", t, func() {
+		So(pathIndex("/products/_doc/1"), ShouldEqual, "products")
+		So(pathIndex("/_bulk"), ShouldEqual, "")
+		So(pathIndex("/a,b/_bulk"), ShouldEqual, "")
+	})
+}
@@ -0,0 +1,154 @@
+package searchtemplates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// maxTemplates bounds the number of templates listed.
+const maxTemplates = 1000
+
+type elasticsearch struct {
+	indexName string
+}
+
+func initPlugin(indexName, config, mappings string) (*elasticsearch, error) {
+	ctx := context.Background()
+
+	es := &elasticsearch{indexName}
+	exists, err := util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while checking if index already exists: %v", logTag, err)
+	}
+	if exists {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+		return es, nil
+	}
+
+	// set number_of_replicas to (nodes-1)
+	nodes, err := util.GetTotalNodes()
+	if err != nil {
+		return nil, err
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(fmt.Sprintf(config, nodes, nodes-1)), &body); err != nil {
+		return nil, err
+	}
+	if docType := util.DocType(); docType != "" {
+		body["mappings"] = map[string]json.RawMessage{docType: json.RawMessage(mappings)}
+	} else {
+		body["mappings"] = json.RawMessage(mappings)
+	}
+
+	_, err = util.GetClient7().CreateIndex(indexName).
+		BodyJson(body).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while creating index named %s: %v", logTag, indexName, err)
+	}
+
+	log.Println(logTag, ": successfully created index named", indexName)
+	return es, nil
+}
+
+func (es *elasticsearch) getTemplates(ctx context.Context) ([]searchTemplate, error) {
+	response, err := util.GetClient7().Search().
+		Index(es.indexName).
+		Sort("name", true).
+		Size(maxTemplates).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := []searchTemplate{}
+	for _, hit := range response.Hits.Hits {
+		var t searchTemplate
+		if err := json.Unmarshal(hit.Source, &t); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal search template %s: %v", hit.Id, err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+func (es *elasticsearch) getTemplate(ctx context.Context, name string) (*searchTemplate, error) {
+	request := util.GetClient7().Get().
+		Index(es.indexName).
+		Id(name).
+		FetchSource(true)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	response, err := request.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var t searchTemplate
+	if err := json.Unmarshal(response.Source, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (es *elasticsearch) putTemplate(ctx context.Context, t searchTemplate) error {
+	request := util.GetClient7().Index().
+		Refresh("wait_for").
+		Index(es.indexName).
+		Id(t.Name).
+		BodyJson(t)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+func (es *elasticsearch) deleteTemplate(ctx context.Context, name string) error {
+	request := util.GetClient7().Delete().
+		Refresh("wait_for").
+		Index(es.indexName).
+		Id(name)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+// search executes the template against the index. The arc templates are
+// rendered by arc while the mustache templates are rendered by elasticsearch.
+func (es *elasticsearch) search(ctx context.Context, index string, t searchTemplate, params map[string]interface{}, query url.Values) ([]byte, error) {
+	options := es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Params: query,
+	}
+	switch t.Lang {
+	case langMustache:
+		options.Path = "/" + url.PathEscape(index) + "/_search/template"
+		options.Body = map[string]interface{}{"source": t.Source, "params": params}
+	default:
+		body, err := t.render(params)
+		if err != nil {
+			return nil, invalidParamsError{err}
+		}
+		options.Path = "/" + url.PathEscape(index) + "/_search"
+		options.Body = string(body)
+	}
+
+	response, err := util.GetClient7().PerformRequest(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
@@ -0,0 +1,216 @@
+package searchtemplates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/audit"
+	"github.com/gorilla/mux"
+)
+
+// invalidParamsError is returned when the template can't be rendered with
+// the params provided by the client.
+type invalidParamsError struct {
+	error
+}
+
+// templateSearchBody is the body of the template searches.
+type templateSearchBody struct {
+	Params map[string]interface{} `json:"params"`
+}
+
+func (s *searchTemplates) getTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		templates, err := s.es.getTemplates(req.Context())
+		if err != nil {
+			msg := "an error occurred while fetching the search templates"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		raw, err := json.Marshal(templates)
+		if err != nil {
+			msg := "an error occurred while fetching the search templates"
+			log.Errorln(logTag, ": unable to marshal search templates:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *searchTemplates) getTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		t, err := s.es.getTemplate(req.Context(), name)
+		if err != nil {
+			msg := fmt.Sprintf(`search template with "name"="%s" not found`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		raw, err := json.Marshal(t)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching search template "%s"`, name)
+			log.Errorln(logTag, ": unable to marshal search template:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *searchTemplates) putTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+		creator, _, _ := req.BasicAuth()
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var t searchTemplate
+		if err := json.Unmarshal(body, &t); err != nil {
+			msg := "can't parse request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		t.Name = name
+		t.Creator = creator
+		t.CreatedAt = time.Now()
+		if err := t.validate(); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.es.putTemplate(req.Context(), t)
+		s.audit(req, "put_search_template", name, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while saving search template "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		msg := fmt.Sprintf(`search template "%s" saved`, name)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+func (s *searchTemplates) deleteTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		err := s.es.deleteTemplate(req.Context(), name)
+		s.audit(req, "delete_search_template", name, err)
+		if err != nil {
+			msg := fmt.Sprintf(`search template with "name"="%s" not found`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		msg := fmt.Sprintf(`search template "%s" deleted`, name)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+// templateSearch renders the template with the params of the body and
+// searches the index with it. The source filters of the permission apply to
+// the hits as they do for the searches made to elasticsearch.
+func (s *searchTemplates) templateSearch() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+		// the path names the index of the tenant, if any
+		indexName := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		var searchBody templateSearchBody
+		if len(bytes.TrimSpace(body)) > 0 {
+			decoder := json.NewDecoder(bytes.NewReader(body))
+			decoder.UseNumber()
+			if err := decoder.Decode(&searchBody); err != nil {
+				util.WriteBackError(w, "can't parse request body", http.StatusBadRequest)
+				return
+			}
+		}
+
+		t, err := s.es.getTemplate(req.Context(), name)
+		if err != nil {
+			msg := fmt.Sprintf(`search template with "name"="%s" not found`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		raw, err := s.es.search(req.Context(), indexName, *t, t.params(searchBody.Params), sourceFilters(req))
+		if e, ok := err.(invalidParamsError); ok {
+			util.WriteBackError(w, e.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while searching with template "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// sourceFilters returns the query params applying the source filters of the
+// permission, if any.
+func sourceFilters(req *http.Request) url.Values {
+	params := url.Values{}
+	reqPermission, err := permission.FromContext(req.Context())
+	if err != nil {
+		return params
+	}
+	includes, excludes := "_source_includes", "_source_excludes"
+	if util.GetVersion() == 6 {
+		includes, excludes = "_source_include", "_source_exclude"
+	}
+	if len(reqPermission.Includes) > 0 && !(len(reqPermission.Includes) == 1 && reqPermission.Includes[0] == "*") {
+		params.Set(includes, strings.Join(reqPermission.Includes, ","))
+	}
+	if len(reqPermission.Excludes) > 0 {
+		params.Set(excludes, strings.Join(reqPermission.Excludes, ","))
+	}
+	return params
+}
+
+// audit records the outcome of the template operation performed by the request.
+func (s *searchTemplates) audit(req *http.Request, action, resource string, err error) {
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusInternalServerError
+	}
+	event := audit.NewEvent(req, action, resource, status)
+	if err != nil {
+		event.Details = map[string]interface{}{"error": err.Error()}
+	}
+	audit.Record(req.Context(), event)
+}
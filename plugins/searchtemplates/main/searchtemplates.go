@@ -0,0 +1,6 @@
+package main
+
+import "github.com/appbaseio/arc/plugins/searchtemplates"
+import "github.com/appbaseio/arc/plugins"
+
+var PluginInstance plugins.Plugin = searchtemplates.Instance()
@@ -0,0 +1,111 @@
+package searchtemplates
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/tenancy"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The templates are managed by the credentials that can access the
+// templates category.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyIndices,
+		logs.Recorder(),
+		classify.Op(),
+		auth.BasicAuth(),
+		validate.Operation(),
+		validate.Category(),
+	}
+}
+
+type searchChain struct {
+	middleware.Fifo
+}
+
+func (c *searchChain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, searchList()...)
+}
+
+// The executions of the templates are searches, hence are validated as
+// the searches made to elasticsearch are.
+func searchList() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifySearchCategory,
+		classifySearchACL,
+		classifySearchOp,
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Sources(),
+		validate.Referers(),
+		validate.Indices(),
+		validate.Category(),
+		validate.ACL(),
+		validate.Operation(),
+		validate.PermissionExpiry(),
+		tenancy.Isolate(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		templatesCategory := category.Templates
+		ctx := category.NewContext(req.Context(), &templatesCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyIndices(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := index.NewContext(req.Context(), []string{})
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifySearchCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searchCategory := category.Search
+		ctx := category.NewContext(req.Context(), &searchCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifySearchACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searchACL := acl.Search
+		ctx := acl.NewContext(req.Context(), &searchACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifySearchOp(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		readOp := op.Read
+		ctx := op.NewContext(req.Context(), &readOp)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
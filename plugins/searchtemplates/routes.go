@@ -0,0 +1,50 @@
+package searchtemplates
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (s *searchTemplates) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	searchMiddleware := (&searchChain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get search templates",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_search_templates",
+			HandlerFunc: middleware(s.getTemplates()),
+			Description: "Returns all the search templates",
+		},
+		{
+			Name:        "Get search template",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_search_template/{name}",
+			HandlerFunc: middleware(s.getTemplate()),
+			Description: "Returns the search template {name}",
+		},
+		{
+			Name:        "Put search template",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_search_template/{name}",
+			HandlerFunc: middleware(s.putTemplate()),
+			Description: "Creates or updates the search template {name}",
+		},
+		{
+			Name:        "Delete search template",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_search_template/{name}",
+			HandlerFunc: middleware(s.deleteTemplate()),
+			Description: "Deletes the search template {name}",
+		},
+		{
+			Name:        "Template search",
+			Methods:     []string{http.MethodPost},
+			Path:        "/{index}/_template_search/{name}",
+			HandlerFunc: searchMiddleware(s.templateSearch()),
+			Description: "Renders the search template {name} with the params of the body and searches {index} with it",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,79 @@
+package searchtemplates
+
+import (
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                        = "[searchtemplates]"
+	defaultSearchTemplatesEsIndex = ".search_templates"
+	envSearchTemplatesEsIndex     = "SEARCH_TEMPLATES_ES_INDEX"
+	settings                      = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+	// the sources of the templates are query dsl, which isn't indexed
+	mappings = `
+	{
+	  "dynamic": false,
+	  "properties": {
+	    "name": { "type": "keyword" },
+	    "lang": { "type": "keyword" },
+	    "creator": { "type": "keyword" },
+	    "created_at": { "type": "date" }
+	  }
+	}`
+)
+
+var (
+	singleton *searchTemplates
+	once      sync.Once
+)
+
+// searchTemplates stores named search templates and executes them with the
+// params provided by the clients, so that the clients don't need to send the
+// query dsl.
+type searchTemplates struct {
+	es templateService
+}
+
+// Use only this function to fetch the instance of searchtemplates from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *searchTemplates {
+	once.Do(func() { singleton = &searchTemplates{} })
+	return singleton
+}
+
+func (s *searchTemplates) Name() string {
+	return logTag
+}
+
+func (s *searchTemplates) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	indexName := os.Getenv(envSearchTemplatesEsIndex)
+	if indexName == "" {
+		indexName = defaultSearchTemplatesEsIndex
+	}
+
+	// initialize the dao
+	var err error
+	s.es, err = initPlugin(indexName, settings, mappings)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *searchTemplates) Routes() []plugins.Route {
+	return s.routes()
+}
+
+// Default empty middleware array function
+func (s *searchTemplates) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
@@ -0,0 +1,14 @@
+package searchtemplates
+
+import (
+	"context"
+	"net/url"
+)
+
+type templateService interface {
+	getTemplates(ctx context.Context) ([]searchTemplate, error)
+	getTemplate(ctx context.Context, name string) (*searchTemplate, error)
+	putTemplate(ctx context.Context, t searchTemplate) error
+	deleteTemplate(ctx context.Context, name string) error
+	search(ctx context.Context, index string, t searchTemplate, params map[string]interface{}, query url.Values) ([]byte, error)
+}
@@ -0,0 +1,153 @@
+package searchtemplates
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Languages in which the templates can be written.
+const (
+	// langArc substitutes the {{param}} placeholders of the json source with
+	// the params, a placeholder making up a whole string is replaced by the
+	// json value of the param.
+	langArc = "arc"
+	// langMustache renders the source with the mustache engine of elasticsearch.
+	langMustache = "mustache"
+)
+
+var (
+	placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+	namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+)
+
+// searchTemplate is a named query whose params are provided at execution, the
+// params declared by the template hold their default values.
+type searchTemplate struct {
+	Name        string                 `json:"name"`
+	Lang        string                 `json:"lang"`
+	Source      json.RawMessage        `json:"source"`
+	Params      map[string]interface{} `json:"params,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Creator     string                 `json:"creator"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+func (t *searchTemplate) validate() error {
+	if !namePattern.MatchString(t.Name) {
+		return fmt.Errorf(`invalid template name "%s", must consist of alphanumerics, hyphens and underscores`, t.Name)
+	}
+	if t.Lang == "" {
+		t.Lang = langArc
+	}
+	source := bytes.TrimSpace(t.Source)
+	if len(source) == 0 {
+		return errors.New(`"source" is required`)
+	}
+	switch t.Lang {
+	case langArc:
+		var v map[string]interface{}
+		if err := json.Unmarshal(source, &v); err != nil {
+			return errors.New(`"source" of an arc template must be a json object`)
+		}
+	case langMustache:
+		// mustache sources can't be checked until rendered, unless json
+		if source[0] != '{' && source[0] != '"' {
+			return errors.New(`"source" of a mustache template must be either a json object or a string`)
+		}
+	default:
+		return fmt.Errorf(`invalid "lang" "%s", must be either "%s" or "%s"`, t.Lang, langArc, langMustache)
+	}
+	return nil
+}
+
+// params merges the params provided at execution with the defaults of the template.
+func (t *searchTemplate) params(provided map[string]interface{}) map[string]interface{} {
+	params := make(map[string]interface{}, len(t.Params)+len(provided))
+	for k, v := range t.Params {
+		params[k] = v
+	}
+	for k, v := range provided {
+		params[k] = v
+	}
+	return params
+}
+
+// render substitutes the placeholders of the arc template with the params.
+func (t *searchTemplate) render(params map[string]interface{}) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(t.Source))
+	decoder.UseNumber()
+	var source interface{}
+	if err := decoder.Decode(&source); err != nil {
+		return nil, err
+	}
+	rendered, err := substitute(source, params)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rendered)
+}
+
+func substitute(v interface{}, params map[string]interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			k, err := interpolate(key, params)
+			if err != nil {
+				return nil, err
+			}
+			if out[k], err = substitute(value, params); err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case []interface{}:
+		for i, value := range t {
+			var err error
+			if t[i], err = substitute(value, params); err != nil {
+				return nil, err
+			}
+		}
+		return t, nil
+	case string:
+		// the placeholder making up the whole string keeps the type of the param
+		if m := placeholder.FindStringSubmatch(t); m != nil && m[0] == t {
+			value, ok := params[m[1]]
+			if !ok {
+				return nil, fmt.Errorf(`missing value for param "%s"`, m[1])
+			}
+			return value, nil
+		}
+		return interpolate(t, params)
+	default:
+		return v, nil
+	}
+}
+
+// interpolate replaces the placeholders within the string with the params,
+// the params that aren't strings are inserted as json.
+func interpolate(s string, params map[string]interface{}) (string, error) {
+	var err error
+	out := placeholder.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		value, ok := params[name]
+		if !ok {
+			err = fmt.Errorf(`missing value for param "%s"`, name)
+			return match
+		}
+		if str, ok := value.(string); ok {
+			return str
+		}
+		raw, e := json.Marshal(value)
+		if e != nil {
+			err = e
+			return match
+		}
+		return string(raw)
+	})
+	return out, err
+}
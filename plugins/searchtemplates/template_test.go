@@ -0,0 +1,65 @@
+package searchtemplates
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidate(t *testing.T) {
+	Convey("Arc templates default and must be json objects", t, func() {
+		tmpl := searchTemplate{Name: "by-title", Source: json.RawMessage(`{"query": {"match": {"title": "{{q}}"}}}`)}
+		So(tmpl.validate(), ShouldBeNil)
+		So(tmpl.Lang, ShouldEqual, langArc)
+
+		tmpl = searchTemplate{Name: "by-title", Source: json.RawMessage(`"{\"query\": {{q}}}"`)}
+		So(tmpl.validate(), ShouldNotBeNil)
+	})
+
+	Convey("Mustache templates can be strings", t, func() {
+		tmpl := searchTemplate{Name: "by-title", Lang: langMustache, Source: json.RawMessage(`"{\"size\": {{size}}}"`)}
+		So(tmpl.validate(), ShouldBeNil)
+	})
+
+	Convey("Invalid names, languages and sources are rejected", t, func() {
+		So((&searchTemplate{Name: "by title", Source: json.RawMessage(`{}`)}).validate(), ShouldNotBeNil)
+		So((&searchTemplate{Name: "by-title", Lang: "painless", Source: json.RawMessage(`{}`)}).validate(), ShouldNotBeNil)
+		So((&searchTemplate{Name: "by-title"}).validate(), ShouldNotBeNil)
+	})
+}
+
+func TestRender(t *testing.T) {
+	tmpl := searchTemplate{
+		Name:   "by-title",
+		Source: json.RawMessage(`{"query": {"bool": {"must": {"match": {"{{field}}": "{{q}}"}}, "filter": {"terms": {"tags": "{{tags}}"}}}}, "size": "{{size}}", "_name": "title {{q}} in {{tags}}"}`),
+		Params: map[string]interface{}{"size": 10, "field": "title"},
+	}
+
+	Convey("Whole string placeholders keep the type of the params", t, func() {
+		raw, err := tmpl.render(tmpl.params(map[string]interface{}{"q": "shoes", "tags": []interface{}{"a", "b"}}))
+		So(err, ShouldBeNil)
+
+		var v map[string]interface{}
+		So(json.Unmarshal(raw, &v), ShouldBeNil)
+		So(v["size"], ShouldEqual, 10)
+		So(v["_name"], ShouldEqual, `title shoes in ["a","b"]`)
+		query := v["query"].(map[string]interface{})["bool"].(map[string]interface{})
+		So(query["must"].(map[string]interface{})["match"], ShouldResemble, map[string]interface{}{"title": "shoes"})
+		So(query["filter"].(map[string]interface{})["terms"], ShouldResemble, map[string]interface{}{"tags": []interface{}{"a", "b"}})
+	})
+
+	Convey("The provided params override the defaults", t, func() {
+		raw, err := tmpl.render(tmpl.params(map[string]interface{}{"q": "shoes", "tags": "a", "size": 5}))
+		So(err, ShouldBeNil)
+		var v map[string]interface{}
+		So(json.Unmarshal(raw, &v), ShouldBeNil)
+		So(v["size"], ShouldEqual, 5)
+	})
+
+	Convey("Missing params are reported", t, func() {
+		_, err := tmpl.render(tmpl.params(map[string]interface{}{"q": "shoes"}))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, `"tags"`)
+	})
+}
@@ -0,0 +1,180 @@
+package snapshots
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+type elasticsearch struct {
+	indexName string
+}
+
+func initPlugin(indexName, config string) (*elasticsearch, error) {
+	ctx := context.Background()
+
+	es := &elasticsearch{indexName}
+
+	// Check if the meta index already exists
+	exists, err := util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while checking if index already exists: %v", logTag, err)
+	}
+	if exists {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+		return es, nil
+	}
+
+	// set number_of_replicas to (nodes-1)
+	nodes, err := util.GetTotalNodes()
+	if err != nil {
+		return nil, err
+	}
+	settings := fmt.Sprintf(config, nodes, nodes-1)
+
+	// Create a new meta index
+	_, err = util.GetClient7().CreateIndex(indexName).
+		Body(settings).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while creating index named %s: %v", logTag, indexName, err)
+	}
+
+	log.Println(logTag, ": successfully created index named", indexName)
+	return es, nil
+}
+
+// perform forwards the request to the snapshot apis, which share the same
+// shape across es6 and es7, hence the es7 client is used for both versions.
+func (es *elasticsearch) perform(ctx context.Context, method, path string, params url.Values, body []byte) ([]byte, error) {
+	options := es7.PerformRequestOptions{
+		Method: method,
+		Path:   path,
+		Params: params,
+	}
+	if body != nil {
+		options.Body = string(body)
+	}
+	response, err := util.GetClient7().PerformRequest(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+func repositoryPath(repository string) string {
+	return "/_snapshot/" + url.PathEscape(repository)
+}
+
+func snapshotPath(repository, snapshot string) string {
+	return repositoryPath(repository) + "/" + url.PathEscape(snapshot)
+}
+
+func (es *elasticsearch) getRepositories(ctx context.Context) ([]byte, error) {
+	return es.perform(ctx, http.MethodGet, "/_snapshot", nil, nil)
+}
+
+func (es *elasticsearch) getRepository(ctx context.Context, repository string) ([]byte, error) {
+	return es.perform(ctx, http.MethodGet, repositoryPath(repository), nil, nil)
+}
+
+func (es *elasticsearch) putRepository(ctx context.Context, repository string, body []byte) ([]byte, error) {
+	return es.perform(ctx, http.MethodPut, repositoryPath(repository), nil, body)
+}
+
+func (es *elasticsearch) deleteRepository(ctx context.Context, repository string) ([]byte, error) {
+	return es.perform(ctx, http.MethodDelete, repositoryPath(repository), nil, nil)
+}
+
+func (es *elasticsearch) getSnapshots(ctx context.Context, repository string) ([]byte, error) {
+	return es.perform(ctx, http.MethodGet, repositoryPath(repository)+"/_all", nil, nil)
+}
+
+func (es *elasticsearch) getSnapshot(ctx context.Context, repository, snapshot string) ([]byte, error) {
+	return es.perform(ctx, http.MethodGet, snapshotPath(repository, snapshot), nil, nil)
+}
+
+func (es *elasticsearch) createSnapshot(ctx context.Context, repository, snapshot string, body []byte) ([]byte, error) {
+	return es.perform(ctx, http.MethodPut, snapshotPath(repository, snapshot), nil, body)
+}
+
+func (es *elasticsearch) deleteSnapshot(ctx context.Context, repository, snapshot string) ([]byte, error) {
+	return es.perform(ctx, http.MethodDelete, snapshotPath(repository, snapshot), nil, nil)
+}
+
+func (es *elasticsearch) restoreSnapshot(ctx context.Context, repository, snapshot string, body []byte) ([]byte, error) {
+	return es.perform(ctx, http.MethodPost, snapshotPath(repository, snapshot)+"/_restore", nil, body)
+}
+
+func (es *elasticsearch) getSchedules(ctx context.Context) ([]schedule, error) {
+	response, err := util.GetClient7().Search().
+		Index(es.indexName).
+		Size(1000).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules := []schedule{}
+	for _, hit := range response.Hits.Hits {
+		var s schedule
+		if err := json.Unmarshal(hit.Source, &s); err != nil {
+			return nil, fmt.Errorf("unable to unmarshal snapshot schedule %s: %v", hit.Id, err)
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, nil
+}
+
+func (es *elasticsearch) getSchedule(ctx context.Context, name string) (*schedule, error) {
+	request := util.GetClient7().Get().
+		Index(es.indexName).
+		Id(name).
+		FetchSource(true)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	response, err := request.Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var s schedule
+	if err := json.Unmarshal(response.Source, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (es *elasticsearch) putSchedule(ctx context.Context, s schedule) error {
+	request := util.GetClient7().Index().
+		Refresh("wait_for").
+		Index(es.indexName).
+		Id(s.Name).
+		BodyJson(s)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
+
+func (es *elasticsearch) deleteSchedule(ctx context.Context, name string) error {
+	request := util.GetClient7().Delete().
+		Refresh("wait_for").
+		Index(es.indexName).
+		Id(name)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	_, err := request.Do(ctx)
+	return err
+}
@@ -0,0 +1,323 @@
+package snapshots
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/audit"
+	"github.com/gorilla/mux"
+)
+
+func (s *snapshots) getRepositories() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		raw, err := s.es.getRepositories(req.Context())
+		if err != nil {
+			msg := "an error occurred while fetching the snapshot repositories"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) getRepository() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		repository := mux.Vars(req)["repository"]
+		raw, err := s.es.getRepository(req.Context(), repository)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching repository "%s"`, repository)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) putRepository() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		repository := mux.Vars(req)["repository"]
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		if len(body) == 0 {
+			util.WriteBackError(w, "request body is required", http.StatusBadRequest)
+			return
+		}
+
+		raw, err := s.es.putRepository(req.Context(), repository, body)
+		s.audit(req, "put_repository", repository, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while registering repository "%s"`, repository)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) deleteRepository() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		repository := mux.Vars(req)["repository"]
+
+		raw, err := s.es.deleteRepository(req.Context(), repository)
+		s.audit(req, "delete_repository", repository, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while deleting repository "%s"`, repository)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) getSnapshots() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		repository := mux.Vars(req)["repository"]
+		raw, err := s.es.getSnapshots(req.Context(), repository)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching snapshots of repository "%s"`, repository)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) getSnapshot() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repository, snapshot := vars["repository"], vars["snapshot"]
+
+		raw, err := s.es.getSnapshot(req.Context(), repository, snapshot)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching snapshot "%s"`, snapshot)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) createSnapshot() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repository, snapshot := vars["repository"], vars["snapshot"]
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		if len(body) == 0 {
+			body = nil
+		}
+
+		raw, err := s.es.createSnapshot(req.Context(), repository, snapshot, body)
+		s.audit(req, "create_snapshot", repository+"/"+snapshot, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while creating snapshot "%s"`, snapshot)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) deleteSnapshot() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repository, snapshot := vars["repository"], vars["snapshot"]
+
+		raw, err := s.es.deleteSnapshot(req.Context(), repository, snapshot)
+		s.audit(req, "delete_snapshot", repository+"/"+snapshot, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while deleting snapshot "%s"`, snapshot)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) restoreSnapshot() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		repository, snapshot := vars["repository"], vars["snapshot"]
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+		if !isAdmin(req.Context()) {
+			body, err = restrictRestore(body, internalIndices())
+			if err != nil {
+				status := http.StatusBadRequest
+				if _, ok := err.(internalIndexError); ok {
+					status = http.StatusForbidden
+				}
+				util.WriteBackError(w, err.Error(), status)
+				return
+			}
+		}
+		if len(body) == 0 {
+			body = nil
+		}
+
+		raw, err := s.es.restoreSnapshot(req.Context(), repository, snapshot, body)
+		s.audit(req, "restore_snapshot", repository+"/"+snapshot, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while restoring snapshot "%s"`, snapshot)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) getSchedules() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		schedules, err := s.es.getSchedules(req.Context())
+		if err != nil {
+			msg := "an error occurred while fetching the snapshot schedules"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		raw, err := json.Marshal(schedules)
+		if err != nil {
+			msg := "an error occurred while fetching the snapshot schedules"
+			log.Errorln(logTag, ": unable to marshal snapshot schedules:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) getSchedule() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		sch, err := s.es.getSchedule(req.Context(), name)
+		if err != nil {
+			msg := fmt.Sprintf(`snapshot schedule with "name"="%s" not found`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+
+		raw, err := json.Marshal(sch)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while fetching snapshot schedule "%s"`, name)
+			log.Errorln(logTag, ": unable to marshal snapshot schedule:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *snapshots) putSchedule() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+		creator, _, _ := req.BasicAuth()
+
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			msg := "can't read request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var sch schedule
+		if err := json.Unmarshal(body, &sch); err != nil {
+			msg := "can't parse request body"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+		sch.Name = name
+		sch.Creator = creator
+		sch.CreatedAt = time.Now()
+		if err := sch.validate(); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		err = s.es.putSchedule(req.Context(), sch)
+		s.audit(req, "put_snapshot_schedule", name, err)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while saving snapshot schedule "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		if err := s.scheduler.add(sch); err != nil {
+			msg := fmt.Sprintf(`an error occurred while scheduling snapshot schedule "%s"`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		msg := fmt.Sprintf(`snapshot schedule "%s" saved`, name)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+func (s *snapshots) deleteSchedule() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["name"]
+
+		err := s.es.deleteSchedule(req.Context(), name)
+		s.audit(req, "delete_snapshot_schedule", name, err)
+		if err != nil {
+			msg := fmt.Sprintf(`snapshot schedule with "name"="%s" not found`, name)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		s.scheduler.remove(name)
+
+		msg := fmt.Sprintf(`snapshot schedule "%s" deleted`, name)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+	}
+}
+
+// audit records the outcome of the snapshot operation performed by the request.
+func (s *snapshots) audit(req *http.Request, action, resource string, err error) {
+	status := http.StatusOK
+	if err != nil {
+		status = http.StatusInternalServerError
+	}
+	event := audit.NewEvent(req, action, resource, status)
+	if err != nil {
+		event.Details = map[string]interface{}{"error": err.Error()}
+	}
+	audit.Record(req.Context(), event)
+}
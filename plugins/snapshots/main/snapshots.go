@@ -0,0 +1,6 @@
+package main
+
+import "github.com/appbaseio/arc/plugins/snapshots"
+import "github.com/appbaseio/arc/plugins"
+
+var PluginInstance plugins.Plugin = snapshots.Instance()
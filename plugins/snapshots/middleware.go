@@ -0,0 +1,47 @@
+package snapshots
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// Snapshots are taken at the cluster level, hence the credentials must be
+// able to access the cluster level routes along with the snapshot acl.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classify.Category(category.Misc),
+		classify.ACL(acl.Snapshot),
+		classifyIndices,
+		logs.Recorder(),
+		classify.Op(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyIndices(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := index.NewContext(req.Context(), []string{})
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
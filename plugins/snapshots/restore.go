@@ -0,0 +1,105 @@
+package snapshots
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util/audit"
+)
+
+// internalIndices returns the indices holding the users, the permissions and
+// the audit trail, which only the admin users are allowed to restore.
+func internalIndices() []string {
+	usersIndex := os.Getenv(envUsersEsIndex)
+	if usersIndex == "" {
+		usersIndex = defaultUsersEsIndex
+	}
+	permissionsIndex := os.Getenv(envPermissionsEsIndex)
+	if permissionsIndex == "" {
+		permissionsIndex = defaultPermissionsEsIndex
+	}
+	return []string{usersIndex, permissionsIndex, audit.Index()}
+}
+
+// isAdmin checks whether the request is authenticated by an admin user.
+func isAdmin(ctx context.Context) bool {
+	reqCredential, err := credential.FromContext(ctx)
+	if err != nil || reqCredential != credential.User {
+		return false
+	}
+	reqUser, err := user.FromContext(ctx)
+	return err == nil && reqUser.Admin()
+}
+
+// internalIndexError is returned when a non admin user requests the restore
+// of an internal index.
+type internalIndexError string
+
+func (e internalIndexError) Error() string {
+	return fmt.Sprintf(`index "%s" can only be restored by the admin users`, string(e))
+}
+
+// restrictRestore rewrites the body of the restore request so that the
+// internal indices aren't restored. The index patterns are narrowed down by
+// excluding the internal indices, which are also excluded from the restore of
+// the whole snapshot. The internal indices requested by name, either directly
+// or as the target of a rename, are rejected with an internalIndexError.
+func restrictRestore(body []byte, internal []string) ([]byte, error) {
+	restore := map[string]interface{}{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &restore); err != nil {
+			return nil, fmt.Errorf("can't parse request body: %v", err)
+		}
+	}
+
+	var names []string
+	switch indices := restore["indices"].(type) {
+	case nil:
+		names = []string{"*"}
+	case string:
+		names = strings.Split(indices, ",")
+	case []interface{}:
+		for _, name := range indices {
+			s, ok := name.(string)
+			if !ok {
+				return nil, fmt.Errorf(`"indices" must be a string or an array of strings`)
+			}
+			names = append(names, s)
+		}
+	default:
+		return nil, fmt.Errorf(`"indices" must be a string or an array of strings`)
+	}
+
+	wildcard := false
+	for i, name := range names {
+		name = strings.TrimSpace(name)
+		names[i] = name
+		if strings.HasPrefix(name, "-") {
+			continue
+		}
+		if strings.Contains(name, "*") || name == "_all" {
+			wildcard = true
+			continue
+		}
+		if index.Match(internal, name) {
+			return nil, internalIndexError(name)
+		}
+	}
+	if replacement, ok := restore["rename_replacement"].(string); ok && index.Match(internal, replacement) {
+		return nil, internalIndexError(replacement)
+	}
+	if wildcard {
+		for _, name := range internal {
+			names = append(names, "-"+name)
+		}
+	}
+	restore["indices"] = strings.Join(names, ",")
+
+	return json.Marshal(restore)
+}
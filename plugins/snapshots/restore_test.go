@@ -0,0 +1,67 @@
+package snapshots
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/user"
+)
+
+func TestRestrictRestore(t *testing.T) {
+	internal := []string{".users", ".permissions", ".audit"}
+	indicesOf := func(body []byte) string {
+		var restore map[string]interface{}
+		So(json.Unmarshal(body, &restore), ShouldBeNil)
+		return restore["indices"].(string)
+	}
+
+	Convey("The internal indices are excluded from the restore of the whole snapshot", t, func() {
+		body, err := restrictRestore(nil, internal)
+		So(err, ShouldBeNil)
+		So(indicesOf(body), ShouldEqual, "*,-.users,-.permissions,-.audit")
+	})
+
+	Convey("The internal indices are excluded from the index patterns", t, func() {
+		body, err := restrictRestore([]byte(`{"indices": ["products", "logs-*"], "include_global_state": false}`), internal)
+		So(err, ShouldBeNil)
+		So(indicesOf(body), ShouldEqual, "products,logs-*,-.users,-.permissions,-.audit")
+	})
+
+	Convey("The indices requested by name are restored as is", t, func() {
+		body, err := restrictRestore([]byte(`{"indices": "products, orders"}`), internal)
+		So(err, ShouldBeNil)
+		So(indicesOf(body), ShouldEqual, "products,orders")
+	})
+
+	Convey("The internal indices requested by name are rejected", t, func() {
+		for _, body := range []string{
+			`{"indices": "products,.users"}`,
+			`{"indices": "products", "rename_pattern": "(.+)", "rename_replacement": ".permissions"}`,
+		} {
+			_, err := restrictRestore([]byte(body), internal)
+			So(err, ShouldHaveSameTypeAs, internalIndexError(""))
+		}
+	})
+
+	Convey("The invalid bodies are rejected", t, func() {
+		_, err := restrictRestore([]byte(`{"indices": 12}`), internal)
+		So(err, ShouldNotBeNil)
+		_, err = restrictRestore([]byte(`{`), internal)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestIsAdmin(t *testing.T) {
+	Convey("Only the admin users are admins", t, func() {
+		admin, other := true, false
+		ctx := credential.NewContext(context.Background(), credential.User)
+		So(isAdmin(user.NewContext(ctx, &user.User{IsAdmin: &admin})), ShouldBeTrue)
+		So(isAdmin(user.NewContext(ctx, &user.User{IsAdmin: &other})), ShouldBeFalse)
+		So(isAdmin(user.NewContext(ctx, &user.User{})), ShouldBeFalse)
+		So(isAdmin(credential.NewContext(context.Background(), credential.Permission)), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,105 @@
+package snapshots
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (s *snapshots) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get repositories",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_repositories",
+			HandlerFunc: middleware(s.getRepositories()),
+			Description: "Returns the registered snapshot repositories",
+		},
+		{
+			Name:        "Get repository",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_repository/{repository}",
+			HandlerFunc: middleware(s.getRepository()),
+			Description: "Returns the snapshot repository {repository}",
+		},
+		{
+			Name:        "Register repository",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_repository/{repository}",
+			HandlerFunc: middleware(s.putRepository()),
+			Description: "Registers or updates the snapshot repository {repository}",
+		},
+		{
+			Name:        "Delete repository",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_repository/{repository}",
+			HandlerFunc: middleware(s.deleteRepository()),
+			Description: "Unregisters the snapshot repository {repository}",
+		},
+		{
+			Name:        "Get snapshots",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_repository/{repository}/snapshots",
+			HandlerFunc: middleware(s.getSnapshots()),
+			Description: "Returns all the snapshots in the repository {repository}",
+		},
+		{
+			Name:        "Get snapshot",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_repository/{repository}/snapshot/{snapshot}",
+			HandlerFunc: middleware(s.getSnapshot()),
+			Description: "Returns the snapshot {snapshot}",
+		},
+		{
+			Name:        "Create snapshot",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_repository/{repository}/snapshot/{snapshot}",
+			HandlerFunc: middleware(s.createSnapshot()),
+			Description: "Triggers the snapshot {snapshot} in the repository {repository}",
+		},
+		{
+			Name:        "Delete snapshot",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_repository/{repository}/snapshot/{snapshot}",
+			HandlerFunc: middleware(s.deleteSnapshot()),
+			Description: "Deletes the snapshot {snapshot}",
+		},
+		{
+			Name:        "Restore snapshot",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_repository/{repository}/snapshot/{snapshot}/restore",
+			HandlerFunc: middleware(s.restoreSnapshot()),
+			Description: "Restores the snapshot {snapshot} with optionally provided indices and settings",
+		},
+		{
+			Name:        "Get snapshot schedules",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_snapshot_schedules",
+			HandlerFunc: middleware(s.getSchedules()),
+			Description: "Returns all the recurring snapshot schedules",
+		},
+		{
+			Name:        "Get snapshot schedule",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_snapshot_schedule/{name}",
+			HandlerFunc: middleware(s.getSchedule()),
+			Description: "Returns the recurring snapshot schedule {name}",
+		},
+		{
+			Name:        "Create snapshot schedule",
+			Methods:     []string{http.MethodPut},
+			Path:        "/_snapshot_schedule/{name}",
+			HandlerFunc: middleware(s.putSchedule()),
+			Description: "Creates or updates the recurring snapshot schedule {name}",
+		},
+		{
+			Name:        "Delete snapshot schedule",
+			Methods:     []string{http.MethodDelete},
+			Path:        "/_snapshot_schedule/{name}",
+			HandlerFunc: middleware(s.deleteSchedule()),
+			Description: "Deletes the recurring snapshot schedule {name}",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,132 @@
+package snapshots
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/audit"
+)
+
+const (
+	schedulerActor       = "scheduler"
+	snapshotSuffixLayout = "2006.01.02-15.04.05"
+)
+
+// schedule describes a recurring snapshot of the given indices, or the whole
+// cluster if no indices are provided, into the repository.
+type schedule struct {
+	Name       string    `json:"name"`
+	Repository string    `json:"repository"`
+	Cron       string    `json:"cron"`
+	Indices    []string  `json:"indices,omitempty"`
+	Creator    string    `json:"creator,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (s *schedule) validate() error {
+	if s.Repository == "" {
+		return fmt.Errorf(`"repository" is required`)
+	}
+	if s.Cron == "" {
+		return fmt.Errorf(`"cron" is required`)
+	}
+	if _, err := cron.Parse(s.Cron); err != nil {
+		return fmt.Errorf(`invalid "cron" expression "%s": %v`, s.Cron, err)
+	}
+	return nil
+}
+
+// snapshotName returns a unique name for the snapshot taken at the given time.
+func (s *schedule) snapshotName(t time.Time) string {
+	return strings.ToLower(s.Name) + "-" + t.UTC().Format(snapshotSuffixLayout)
+}
+
+type scheduler struct {
+	mu   sync.Mutex
+	es   snapshotService
+	jobs map[string]*cron.Cron
+}
+
+func newScheduler(es snapshotService) *scheduler {
+	return &scheduler{
+		es:   es,
+		jobs: make(map[string]*cron.Cron),
+	}
+}
+
+// load schedules all the persisted snapshot schedules.
+func (sc *scheduler) load() error {
+	schedules, err := sc.es.getSchedules(context.Background())
+	if err != nil {
+		return fmt.Errorf("%s: error while fetching snapshot schedules: %v", logTag, err)
+	}
+	for _, s := range schedules {
+		if err := sc.add(s); err != nil {
+			log.Errorln(logTag, ": unable to schedule", s.Name, ":", err)
+		}
+	}
+	return nil
+}
+
+// add schedules the snapshot, replacing the existing schedule with the same name.
+func (sc *scheduler) add(s schedule) error {
+	job := cron.New()
+	if err := job.AddFunc(s.Cron, func() { sc.run(s) }); err != nil {
+		return err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if existing, ok := sc.jobs[s.Name]; ok {
+		existing.Stop()
+	}
+	sc.jobs[s.Name] = job
+	job.Start()
+	return nil
+}
+
+func (sc *scheduler) remove(name string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if job, ok := sc.jobs[name]; ok {
+		job.Stop()
+		delete(sc.jobs, name)
+	}
+}
+
+func (sc *scheduler) run(s schedule) {
+	ctx := context.Background()
+	name := s.snapshotName(time.Now())
+
+	var body []byte
+	if len(s.Indices) > 0 {
+		body, _ = json.Marshal(map[string]interface{}{
+			"indices": strings.Join(s.Indices, ","),
+		})
+	}
+
+	event := audit.Event{
+		Timestamp: time.Now(),
+		Actor:     schedulerActor,
+		Action:    "create_snapshot",
+		Resource:  s.Repository + "/" + name,
+		Status:    http.StatusOK,
+		Details:   map[string]interface{}{"schedule": s.Name},
+	}
+	if _, err := sc.es.createSnapshot(ctx, s.Repository, name, body); err != nil {
+		log.Errorln(logTag, ": scheduled snapshot", name, "failed:", err)
+		event.Status = http.StatusInternalServerError
+		event.Details = map[string]interface{}{"schedule": s.Name, "error": err.Error()}
+	} else {
+		log.Println(logTag, ": triggered scheduled snapshot", name)
+	}
+	audit.Record(ctx, event)
+}
@@ -0,0 +1,19 @@
+package snapshots
+
+import "context"
+
+type snapshotService interface {
+	getRepositories(ctx context.Context) ([]byte, error)
+	getRepository(ctx context.Context, repository string) ([]byte, error)
+	putRepository(ctx context.Context, repository string, body []byte) ([]byte, error)
+	deleteRepository(ctx context.Context, repository string) ([]byte, error)
+	getSnapshots(ctx context.Context, repository string) ([]byte, error)
+	getSnapshot(ctx context.Context, repository, snapshot string) ([]byte, error)
+	createSnapshot(ctx context.Context, repository, snapshot string, body []byte) ([]byte, error)
+	deleteSnapshot(ctx context.Context, repository, snapshot string) ([]byte, error)
+	restoreSnapshot(ctx context.Context, repository, snapshot string, body []byte) ([]byte, error)
+	getSchedules(ctx context.Context) ([]schedule, error)
+	getSchedule(ctx context.Context, name string) (*schedule, error)
+	putSchedule(ctx context.Context, s schedule) error
+	deleteSchedule(ctx context.Context, name string) error
+}
@@ -0,0 +1,72 @@
+package snapshots
+
+import (
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                    = "[snapshots]"
+	defaultSchedulesEsIndex   = ".snapshot_schedules"
+	envSchedulesEsIndex       = "SNAPSHOT_SCHEDULES_ES_INDEX"
+	envUsersEsIndex           = "USERS_ES_INDEX"
+	defaultUsersEsIndex       = ".users"
+	envPermissionsEsIndex     = "PERMISSIONS_ES_INDEX"
+	defaultPermissionsEsIndex = ".permissions"
+	settings                  = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+)
+
+var (
+	singleton *snapshots
+	once      sync.Once
+)
+
+type snapshots struct {
+	es        snapshotService
+	scheduler *scheduler
+}
+
+// Use only this function to fetch the instance of snapshots from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *snapshots {
+	once.Do(func() { singleton = &snapshots{} })
+	return singleton
+}
+
+func (s *snapshots) Name() string {
+	return logTag
+}
+
+func (s *snapshots) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	indexName := os.Getenv(envSchedulesEsIndex)
+	if indexName == "" {
+		indexName = defaultSchedulesEsIndex
+	}
+
+	// initialize the dao
+	var err error
+	s.es, err = initPlugin(indexName, settings)
+	if err != nil {
+		return err
+	}
+
+	// resume the persisted snapshot schedules
+	s.scheduler = newScheduler(s.es)
+	return s.scheduler.load()
+}
+
+func (s *snapshots) Routes() []plugins.Route {
+	return s.routes()
+}
+
+// Default empty middleware array function
+func (s *snapshots) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
@@ -0,0 +1,71 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	es7 "github.com/olivere/elastic/v7"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// statementErrors are the errors of the sql of elasticsearch caused by the
+// statement itself, as opposed to the sql being unavailable.
+var statementErrors = map[string]bool{
+	"parsing_exception":              true,
+	"verification_exception":         true,
+	"planning_exception":             true,
+	"mapping_exception":              true,
+	"sql_illegal_argument_exception": true,
+}
+
+type elasticsearch struct{}
+
+// translate returns the query dsl elasticsearch translates the statement into.
+func (es *elasticsearch) translate(ctx context.Context, statement string) (map[string]interface{}, error) {
+	path := "/_sql/translate"
+	if util.GetVersion() == 6 {
+		path = "/_xpack/sql/translate"
+	}
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   path,
+		Body:   queryBody{Query: statement},
+	})
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(response.Body))
+	decoder.UseNumber()
+	var body map[string]interface{}
+	if err := decoder.Decode(&body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// search searches the indices with the query dsl.
+func (es *elasticsearch) search(ctx context.Context, indices string, body map[string]interface{}) ([]byte, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   "/" + url.PathEscape(indices) + "/_search",
+		Body:   body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
+
+// statementErrorReason returns the reason elasticsearch rejected the
+// statement for, or false if the error isn't caused by the statement.
+func statementErrorReason(err error) (string, bool) {
+	e, ok := err.(*es7.Error)
+	if !ok || e.Details == nil || !statementErrors[e.Details.Type] {
+		return "", false
+	}
+	return e.Details.Reason, true
+}
@@ -0,0 +1,110 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/util"
+)
+
+// statementError is returned when the statement can't be translated.
+type statementError struct {
+	error
+}
+
+func (s *sql) search() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		indices, body, ok := s.restrictedDSL(w, req)
+		if !ok {
+			return
+		}
+		raw, err := s.es.search(req.Context(), indices, body)
+		if err != nil {
+			msg := "an error occurred while searching with the sql statement"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackESError(w, msg, err)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *sql) translate() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		_, body, ok := s.restrictedDSL(w, req)
+		if !ok {
+			return
+		}
+		raw, err := json.Marshal(body)
+		if err != nil {
+			msg := "an error occurred while translating the sql statement"
+			log.Errorln(logTag, ": unable to marshal the query dsl:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// restrictedDSL translates the statement of the request and restricts the
+// query dsl to the fields the credential can access. It returns false if it
+// can't, the error being written back.
+func (s *sql) restrictedDSL(w http.ResponseWriter, req *http.Request) (string, map[string]interface{}, bool) {
+	ctx := req.Context()
+	statement, _ := ctx.Value(queryKey).(string)
+	indices, err := fromIndices(statement)
+	if err != nil {
+		util.WriteBackError(w, "invalid sql statement: "+err.Error(), http.StatusBadRequest)
+		return "", nil, false
+	}
+
+	body, err := s.translateStatement(ctx, statement)
+	if e, ok := err.(statementError); ok {
+		util.WriteBackError(w, "invalid sql statement: "+e.Error(), http.StatusBadRequest)
+		return "", nil, false
+	}
+	if err != nil {
+		msg := "an error occurred while translating the sql statement"
+		log.Errorln(logTag, ":", msg, ":", err)
+		util.WriteBackESError(w, msg, err)
+		return "", nil, false
+	}
+
+	if reqPermission, err := permission.FromContext(ctx); err == nil {
+		if err := restrict(body, reqPermission); err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusForbidden)
+			return "", nil, false
+		}
+	}
+	return indices, body, true
+}
+
+// translateStatement translates the statement with the translator set via
+// SQL_TRANSLATOR. The "auto" translator falls back to the internal one if
+// the sql of elasticsearch is unavailable, e.g. with the oss distributions.
+func (s *sql) translateStatement(ctx context.Context, statement string) (map[string]interface{}, error) {
+	if s.translator != translatorBuiltin {
+		body, err := s.es.translate(ctx, statement)
+		if err == nil {
+			return body, nil
+		}
+		if reason, ok := statementErrorReason(err); ok {
+			return nil, statementError{errors.New(reason)}
+		}
+		if s.translator == translatorES || util.IsUnreachable(err) {
+			return nil, err
+		}
+		log.Debugln(logTag, ": the sql of elasticsearch is unavailable, translating internally:", err)
+	}
+
+	parsed, err := parse(statement)
+	if err != nil {
+		return nil, statementError{err}
+	}
+	return parsed.body(s.defaultSize), nil
+}
@@ -0,0 +1,118 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/tenancy"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+// queryKey is the key against which the sql statement of the request is
+// stored in the context.
+const queryKey = contextKey("sql_query")
+
+type contextKey string
+
+// queryBody is the body of the sql requests, as for the sql api of
+// elasticsearch.
+type queryBody struct {
+	Query string `json:"query"`
+}
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+// The sql statements are searches of the indices they select from, hence
+// are validated as the searches made to elasticsearch are.
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classifyOp,
+		classifyIndices,
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Sources(),
+		validate.Referers(),
+		validate.Indices(),
+		validate.Category(),
+		validate.ACL(),
+		validate.Operation(),
+		validate.PermissionExpiry(),
+		tenancy.Isolate(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searchCategory := category.Search
+		ctx := category.NewContext(req.Context(), &searchCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		searchACL := acl.Search
+		ctx := acl.NewContext(req.Context(), &searchACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyOp(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		readOp := op.Read
+		ctx := op.NewContext(req.Context(), &readOp)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// classifyIndices identifies the indices the statement selects from, the
+// statement is stored in the context.
+func classifyIndices(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, err := util.ReadRequestBody(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		var q queryBody
+		if err := json.Unmarshal(body, &q); err != nil || strings.TrimSpace(q.Query) == "" {
+			util.WriteBackError(w, `the request body must be of the form {"query": "<sql statement>"}`, http.StatusBadRequest)
+			return
+		}
+		indices, err := fromIndices(q.Query)
+		if err != nil {
+			util.WriteBackError(w, "invalid sql statement: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := index.NewContext(req.Context(), strings.Split(indices, ","))
+		ctx = context.WithValue(ctx, queryKey, q.Query)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
@@ -0,0 +1,510 @@
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The internal translator supports the subset of the sql of elasticsearch
+// that maps directly to the query dsl:
+//
+//	SELECT * | field [, field]* FROM index
+//	[WHERE condition] [ORDER BY field [ASC|DESC] [, ...]] [LIMIT n]
+//
+// The conditions combine, with AND, OR, NOT and parentheses, the comparisons
+// (=, !=, <>, <, <=, >, >=) of a field with a literal, IN, LIKE, BETWEEN,
+// IS [NOT] NULL and MATCH(field, 'text').
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+// is checks whether the token is the keyword or the symbol.
+func (t token) is(keyword string) bool {
+	return (t.kind == tokenIdent || t.kind == tokenSymbol) && strings.EqualFold(t.text, keyword)
+}
+
+func isIdentStart(r rune) bool {
+	return unicode.IsLetter(r) || r == '_' || r == '@' || r == '.'
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r) || r == '-' || r == '*'
+}
+
+// tokenize splits the statement into tokens, the quoted identifiers are
+// returned as identifiers and the numbers are parsed as json numbers.
+func tokenize(statement string) ([]token, error) {
+	var tokens []token
+	chars := []rune(statement)
+	for i := 0; i < len(chars); {
+		r := chars[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '\'' || r == '"' || r == '`':
+			var b strings.Builder
+			start := i
+			i++
+			for {
+				if i >= len(chars) {
+					return nil, fmt.Errorf("unterminated quote at position %d", start)
+				}
+				if chars[i] == r {
+					// a doubled quote escapes itself
+					if i+1 < len(chars) && chars[i+1] == r {
+						b.WriteRune(r)
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteRune(chars[i])
+				i++
+			}
+			kind := tokenIdent
+			if r == '\'' {
+				kind = tokenString
+			}
+			tokens = append(tokens, token{kind: kind, text: b.String(), value: b.String(), pos: start})
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(chars) && unicode.IsDigit(chars[i+1])):
+			start := i
+			i++
+			for i < len(chars) && (unicode.IsDigit(chars[i]) || chars[i] == '.' || chars[i] == 'e' || chars[i] == 'E') {
+				i++
+			}
+			text := string(chars[start:i])
+			if _, err := strconv.ParseFloat(text, 64); err != nil {
+				return nil, fmt.Errorf("invalid number %s at position %d", text, start)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: json.Number(text), pos: start})
+		case isIdentStart(r):
+			start := i
+			for i < len(chars) && isIdentPart(chars[i]) {
+				i++
+			}
+			text := string(chars[start:i])
+			tokens = append(tokens, token{kind: tokenIdent, text: text, value: text, pos: start})
+		default:
+			start := i
+			text := string(r)
+			if i+1 < len(chars) {
+				switch two := string(chars[i : i+2]); two {
+				case "!=", "<>", "<=", ">=":
+					text = two
+				}
+			}
+			if !strings.Contains("(),*=<>!", text[:1]) || text == "!" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, start)
+			}
+			i += len([]rune(text))
+			tokens = append(tokens, token{kind: tokenSymbol, text: text, pos: start})
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(chars)}), nil
+}
+
+// fromIndices returns the indices the statement reads from, i.e. the source
+// following the FROM keyword outside of any parentheses. It only tokenizes
+// the statement, hence works with the statements the internal translator
+// doesn't support.
+func fromIndices(statement string) (string, error) {
+	tokens, err := tokenize(statement)
+	if err != nil {
+		return "", err
+	}
+	depth := 0
+	for i, t := range tokens {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case depth == 0 && t.kind == tokenIdent && t.is("from"):
+			next := tokens[i+1]
+			if next.kind != tokenIdent || next.text == "" {
+				return "", fmt.Errorf("expected an index after FROM at position %d", next.pos)
+			}
+			return next.text, nil
+		}
+	}
+	return "", fmt.Errorf("the statement must select FROM an index")
+}
+
+// statement is a parsed SELECT statement.
+type statement struct {
+	fields []string
+	index  string
+	query  map[string]interface{}
+	sort   []interface{}
+	limit  int
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses the statement supported by the internal translator.
+func parse(sql string) (*statement, error) {
+	tokens, err := tokenize(sql)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	s := &statement{limit: -1}
+
+	if err := p.expect("select"); err != nil {
+		return nil, err
+	}
+	if p.peek().is("*") {
+		p.next()
+	} else {
+		for {
+			field, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			s.fields = append(s.fields, field)
+			if !p.peek().is(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if err := p.expect("from"); err != nil {
+		return nil, err
+	}
+	if s.index, err = p.ident(); err != nil {
+		return nil, err
+	}
+	if p.peek().is("where") {
+		p.next()
+		if s.query, err = p.or(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek().is("order") {
+		p.next()
+		if err := p.expect("by"); err != nil {
+			return nil, err
+		}
+		for {
+			field, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			order := "asc"
+			if p.peek().is("asc") || p.peek().is("desc") {
+				order = strings.ToLower(p.next().text)
+			}
+			s.sort = append(s.sort, map[string]interface{}{field: map[string]interface{}{"order": order}})
+			if !p.peek().is(",") {
+				break
+			}
+			p.next()
+		}
+	}
+	if p.peek().is("limit") {
+		p.next()
+		t := p.next()
+		n, err := strconv.Atoi(t.text)
+		if t.kind != tokenNumber || err != nil || n < 0 {
+			return nil, fmt.Errorf("expected a non-negative integer after LIMIT at position %d", t.pos)
+		}
+		s.limit = n
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+	return s, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(keyword string) error {
+	if t := p.next(); !t.is(keyword) {
+		return fmt.Errorf("expected %s at position %d", strings.ToUpper(keyword), t.pos)
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.next()
+	if t.kind != tokenIdent || reserved[strings.ToLower(t.text)] {
+		return "", fmt.Errorf("expected a field or an index at position %d", t.pos)
+	}
+	return t.text, nil
+}
+
+func (p *parser) literal() (interface{}, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokenString, t.kind == tokenNumber:
+		return t.value, nil
+	case t.is("true"):
+		return true, nil
+	case t.is("false"):
+		return false, nil
+	}
+	return nil, fmt.Errorf("expected a literal at position %d", t.pos)
+}
+
+// reserved are the keywords that can't be used as unquoted identifiers.
+var reserved = map[string]bool{
+	"select": true, "from": true, "where": true, "order": true, "by": true,
+	"limit": true, "and": true, "or": true, "not": true, "in": true,
+	"like": true, "is": true, "null": true, "between": true, "asc": true,
+	"desc": true, "true": true, "false": true,
+}
+
+func (p *parser) or() (map[string]interface{}, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	clauses := []interface{}{left}
+	for p.peek().is("or") {
+		p.next()
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, right)
+	}
+	if len(clauses) == 1 {
+		return left, nil
+	}
+	return boolQuery("should", clauses, "minimum_should_match", 1), nil
+}
+
+func (p *parser) and() (map[string]interface{}, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	clauses := []interface{}{left}
+	for p.peek().is("and") {
+		p.next()
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, right)
+	}
+	if len(clauses) == 1 {
+		return left, nil
+	}
+	return boolQuery("must", clauses), nil
+}
+
+func (p *parser) not() (map[string]interface{}, error) {
+	if p.peek().is("not") {
+		p.next()
+		q, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return negate(q), nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (map[string]interface{}, error) {
+	if p.peek().is("(") {
+		p.next()
+		q, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); !t.is(")") {
+			return nil, fmt.Errorf("expected ) at position %d", t.pos)
+		}
+		return q, nil
+	}
+	if p.peek().is("match") && p.tokens[p.pos+1].is("(") {
+		p.pos += 2
+		field, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); !t.is(",") {
+			return nil, fmt.Errorf("expected , at position %d", t.pos)
+		}
+		t := p.next()
+		if t.kind != tokenString {
+			return nil, fmt.Errorf("expected the text to match at position %d", t.pos)
+		}
+		if t := p.next(); !t.is(")") {
+			return nil, fmt.Errorf("expected ) at position %d", t.pos)
+		}
+		return leaf("match", field, t.value), nil
+	}
+
+	field, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	return p.predicate(field)
+}
+
+func (p *parser) predicate(field string) (map[string]interface{}, error) {
+	t := p.next()
+	switch {
+	case t.is("="), t.is("!="), t.is("<>"):
+		value, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		q := leaf("term", field, value)
+		if !t.is("=") {
+			q = negate(q)
+		}
+		return q, nil
+	case t.is("<"), t.is("<="), t.is(">"), t.is(">="):
+		value, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		op := map[string]string{"<": "lt", "<=": "lte", ">": "gt", ">=": "gte"}[t.text]
+		return leaf("range", field, map[string]interface{}{op: value}), nil
+	case t.is("is"):
+		negated := p.peek().is("not")
+		if negated {
+			p.next()
+		}
+		if t := p.next(); !t.is("null") {
+			return nil, fmt.Errorf("expected NULL at position %d", t.pos)
+		}
+		q := map[string]interface{}{"exists": map[string]interface{}{"field": field}}
+		if !negated {
+			q = negate(q)
+		}
+		return q, nil
+	case t.is("not"):
+		q, err := p.predicate(field)
+		if err != nil {
+			return nil, err
+		}
+		return negate(q), nil
+	case t.is("in"):
+		if t := p.next(); !t.is("(") {
+			return nil, fmt.Errorf("expected ( at position %d", t.pos)
+		}
+		var values []interface{}
+		for {
+			value, err := p.literal()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			if t := p.next(); t.is(")") {
+				break
+			} else if !t.is(",") {
+				return nil, fmt.Errorf("expected , or ) at position %d", t.pos)
+			}
+		}
+		return leaf("terms", field, values), nil
+	case t.is("like"):
+		pattern := p.next()
+		if pattern.kind != tokenString {
+			return nil, fmt.Errorf("expected a pattern at position %d", pattern.pos)
+		}
+		return leaf("wildcard", field, likeToWildcard(pattern.text)), nil
+	case t.is("between"):
+		from, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("and"); err != nil {
+			return nil, err
+		}
+		to, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		return leaf("range", field, map[string]interface{}{"gte": from, "lte": to}), nil
+	}
+	return nil, fmt.Errorf("expected a comparison at position %d", t.pos)
+}
+
+func leaf(kind, field string, value interface{}) map[string]interface{} {
+	return map[string]interface{}{kind: map[string]interface{}{field: value}}
+}
+
+func boolQuery(occur string, clauses []interface{}, options ...interface{}) map[string]interface{} {
+	b := map[string]interface{}{occur: clauses}
+	for i := 0; i+1 < len(options); i += 2 {
+		b[options[i].(string)] = options[i+1]
+	}
+	return map[string]interface{}{"bool": b}
+}
+
+func negate(q map[string]interface{}) map[string]interface{} {
+	return boolQuery("must_not", []interface{}{q})
+}
+
+// likeToWildcard converts the LIKE pattern to a wildcard pattern, the
+// wildcards of the pattern are escaped.
+func likeToWildcard(pattern string) string {
+	var b strings.Builder
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteRune('*')
+		case '_':
+			b.WriteRune('?')
+		case '*', '?', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// body returns the search body of the statement.
+func (s *statement) body(defaultSize int) map[string]interface{} {
+	body := map[string]interface{}{"size": defaultSize}
+	if s.limit >= 0 {
+		body["size"] = s.limit
+	}
+	if s.query != nil {
+		body["query"] = s.query
+	}
+	if len(s.fields) > 0 {
+		body["_source"] = map[string]interface{}{"includes": s.fields}
+	}
+	if len(s.sort) > 0 {
+		body["sort"] = s.sort
+	}
+	return body
+}
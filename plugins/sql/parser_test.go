@@ -0,0 +1,86 @@
+package sql
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func toJSON(v interface{}) string {
+	raw, _ := json.Marshal(v)
+	return string(raw)
+}
+
+func TestFromIndices(t *testing.T) {
+	Convey("The indices are read from the FROM clause", t, func() {
+		indices, err := fromIndices(`SELECT name FROM products WHERE price > 10`)
+		So(err, ShouldBeNil)
+		So(indices, ShouldEqual, "products")
+
+		indices, err = fromIndices(`select count(*) from "logs-*" group by status`)
+		So(err, ShouldBeNil)
+		So(indices, ShouldEqual, "logs-*")
+
+		indices, err = fromIndices(`SELECT EXTRACT(YEAR FROM date) FROM orders`)
+		So(err, ShouldBeNil)
+		So(indices, ShouldEqual, "orders")
+	})
+
+	Convey("The statements without a source are rejected", t, func() {
+		_, err := fromIndices(`SELECT 1`)
+		So(err, ShouldNotBeNil)
+		_, err = fromIndices(`SELECT 'unterminated FROM products`)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestParse(t *testing.T) {
+	Convey("The statements are translated into query dsl", t, func() {
+		s, err := parse(`SELECT name, price FROM products WHERE brand = 'acme' AND (price >= 10 OR tags IN ('sale', 'new')) ORDER BY price DESC LIMIT 5`)
+		So(err, ShouldBeNil)
+		So(s.index, ShouldEqual, "products")
+		So(toJSON(s.body(1000)), ShouldEqual, toJSON(map[string]interface{}{
+			"_source": map[string]interface{}{"includes": []string{"name", "price"}},
+			"query": map[string]interface{}{"bool": map[string]interface{}{"must": []interface{}{
+				map[string]interface{}{"term": map[string]interface{}{"brand": "acme"}},
+				map[string]interface{}{"bool": map[string]interface{}{
+					"minimum_should_match": 1,
+					"should": []interface{}{
+						map[string]interface{}{"range": map[string]interface{}{"price": map[string]interface{}{"gte": 10}}},
+						map[string]interface{}{"terms": map[string]interface{}{"tags": []string{"sale", "new"}}},
+					},
+				}},
+			}}},
+			"size": 5,
+			"sort": []interface{}{map[string]interface{}{"price": map[string]interface{}{"order": "desc"}}},
+		}))
+	})
+
+	Convey("The predicates are translated", t, func() {
+		s, err := parse(`SELECT * FROM products WHERE NOT name LIKE 'ph%_1*' AND discount IS NULL AND stock IS NOT NULL AND price BETWEEN 1 AND 2 AND MATCH(title, 'red shoes') AND color <> 'blue'`)
+		So(err, ShouldBeNil)
+		body := toJSON(s.body(1000))
+		So(body, ShouldContainSubstring, `{"bool":{"must_not":[{"wildcard":{"name":"ph*?1\\*"}}]}}`)
+		So(body, ShouldContainSubstring, `{"bool":{"must_not":[{"exists":{"field":"discount"}}]}}`)
+		So(body, ShouldContainSubstring, `{"exists":{"field":"stock"}}`)
+		So(body, ShouldContainSubstring, `{"range":{"price":{"gte":1,"lte":2}}}`)
+		So(body, ShouldContainSubstring, `{"match":{"title":"red shoes"}}`)
+		So(body, ShouldContainSubstring, `{"bool":{"must_not":[{"term":{"color":"blue"}}]}}`)
+		So(body, ShouldNotContainSubstring, `_source`)
+		So(body, ShouldContainSubstring, `"size":1000`)
+	})
+
+	Convey("The unsupported statements are rejected", t, func() {
+		for _, statement := range []string{
+			`SELECT name FROM products GROUP BY name`,
+			`SELECT COUNT(*) FROM products`,
+			`SELECT name FROM products WHERE price >`,
+			`SELECT name FROM products LIMIT -1`,
+			`DELETE FROM products`,
+		} {
+			_, err := parse(statement)
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/appbaseio/arc/model/permission"
+)
+
+// leafQueries are the queries keyed by the field they query.
+var leafQueries = map[string]bool{
+	"term":                true,
+	"terms":               true,
+	"match":               true,
+	"match_phrase":        true,
+	"match_phrase_prefix": true,
+	"match_bool_prefix":   true,
+	"range":               true,
+	"prefix":              true,
+	"wildcard":            true,
+	"regexp":              true,
+	"fuzzy":               true,
+	"common":              true,
+	"intervals":           true,
+	"span_term":           true,
+	"geo_distance":        true,
+	"geo_bounding_box":    true,
+	"geo_polygon":         true,
+	"geo_shape":           true,
+}
+
+// queryOptions are the options of the leaf queries that aren't fields.
+var queryOptions = map[string]bool{
+	"boost":                true,
+	"_name":                true,
+	"distance":             true,
+	"distance_type":        true,
+	"validation_method":    true,
+	"ignore_unmapped":      true,
+	"type":                 true,
+	"minimum_should_match": true,
+}
+
+// accessError reports the parts of the search the credential can't access.
+type accessError struct {
+	reason string
+}
+
+func (e accessError) Error() string {
+	return e.reason
+}
+
+// restrict applies the include and exclude fields of the permission to the
+// search body. The fields the query, the aggregations and the sort refer to
+// must be accessible and the scripts are rejected, since they could reveal
+// the fields that aren't, whereas the fields returned are narrowed down to
+// the accessible ones. The aggregations must be allowed by the aggregation
+// policy of the permission, if any.
+func restrict(body map[string]interface{}, p *permission.Permission) error {
+	for _, key := range []string{"aggs", "aggregations"} {
+		aggs, _ := body[key].(map[string]interface{})
+		if violations := p.InspectAggregations(aggs, key); len(violations) > 0 {
+			return accessError{violations[0].Reason}
+		}
+	}
+	if !restricted(p) {
+		return nil
+	}
+
+	var refs references
+	refs.walk(body["query"], "", true)
+	refs.walk(body["post_filter"], "", true)
+	refs.walk(body["aggs"], "", false)
+	refs.walk(body["aggregations"], "", false)
+	refs.sort(body["sort"])
+	if _, ok := body["script_fields"]; ok {
+		refs.scripts = true
+	}
+	if refs.scripts {
+		return accessError{"scripts aren't allowed for the credentials restricted to some fields"}
+	}
+	for _, field := range refs.fields {
+		if !p.CanAccessField(field) {
+			return accessError{fmt.Sprintf("credentials cannot access field %s", field)}
+		}
+	}
+
+	body["_source"] = restrictSource(body["_source"], p)
+	for _, key := range []string{"docvalue_fields", "fields"} {
+		if value, ok := body[key].([]interface{}); ok {
+			body[key] = restrictFields(value, p)
+		}
+	}
+	return nil
+}
+
+func restricted(p *permission.Permission) bool {
+	includesAll := len(p.Includes) == 0 || (len(p.Includes) == 1 && p.Includes[0] == "*")
+	return !includesAll || len(p.Excludes) > 0
+}
+
+// references collects the fields a search refers to.
+type references struct {
+	fields  []string
+	scripts bool
+}
+
+// walk collects the fields referred to by the value, either a query or
+// aggregations, the parent being the key of the value.
+func (r *references) walk(value interface{}, parent string, query bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if query && (parent == "query_string" || parent == "simple_query_string") {
+			if _, ok := v["fields"]; !ok {
+				if _, ok := v["default_field"]; !ok {
+					r.fields = append(r.fields, "*")
+				}
+			}
+		}
+		for key, item := range v {
+			switch {
+			case key == "script" || key == "_script" || key == "script_fields":
+				r.scripts = true
+				continue
+			case key == "field" || key == "default_field":
+				if field, ok := item.(string); ok {
+					r.fields = append(r.fields, field)
+				}
+			case key == "fields":
+				if list, ok := item.([]interface{}); ok {
+					for _, f := range list {
+						if field, ok := f.(string); ok {
+							r.fields = append(r.fields, strings.SplitN(field, "^", 2)[0])
+						}
+					}
+				}
+			case query && leafQueries[parent] && !queryOptions[key]:
+				r.fields = append(r.fields, key)
+			}
+			// the filters of the aggregations are queries
+			inQuery := query || key == "filter" || key == "filters" || key == "query"
+			r.walk(item, key, inQuery)
+		}
+	case []interface{}:
+		for _, item := range v {
+			r.walk(item, parent, query)
+		}
+	}
+}
+
+// sort collects the fields the sort refers to.
+func (r *references) sort(value interface{}) {
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case nil:
+		return
+	default:
+		items = []interface{}{v}
+	}
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			if !strings.HasPrefix(v, "_") {
+				r.fields = append(r.fields, v)
+			}
+		case map[string]interface{}:
+			for key, options := range v {
+				if key == "_script" {
+					r.scripts = true
+				} else if !strings.HasPrefix(key, "_") {
+					r.fields = append(r.fields, key)
+				}
+				r.walk(options, key, false)
+			}
+		}
+	}
+}
+
+// restrictSource narrows the source filter down to the accessible fields.
+func restrictSource(source interface{}, p *permission.Permission) interface{} {
+	var requested, excludes []interface{}
+	switch v := source.(type) {
+	case bool:
+		if !v {
+			return false
+		}
+	case string:
+		requested = []interface{}{v}
+	case []interface{}:
+		requested = v
+	case map[string]interface{}:
+		requested, _ = v["includes"].([]interface{})
+		excludes, _ = v["excludes"].([]interface{})
+	}
+
+	var includes []interface{}
+	if len(requested) == 0 {
+		for _, field := range p.Includes {
+			includes = append(includes, field)
+		}
+	} else {
+		includes = restrictFields(requested, p)
+		if len(includes) == 0 {
+			return false
+		}
+	}
+	for _, field := range p.Excludes {
+		excludes = append(excludes, field)
+	}
+	filter := map[string]interface{}{}
+	if len(includes) > 0 {
+		filter["includes"] = includes
+	}
+	if len(excludes) > 0 {
+		filter["excludes"] = excludes
+	}
+	return filter
+}
+
+// restrictFields drops the fields that can't be accessed, the fields being
+// either names or objects with a field, as the docvalue fields are.
+func restrictFields(fields []interface{}, p *permission.Permission) []interface{} {
+	kept := []interface{}{}
+	for _, item := range fields {
+		field, ok := item.(string)
+		if object, isObject := item.(map[string]interface{}); isObject {
+			field, ok = object["field"].(string)
+		}
+		if ok && p.CanAccessField(field) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,75 @@
+package sql
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/permission"
+)
+
+func TestRestrict(t *testing.T) {
+	p := &permission.Permission{Includes: []string{"name", "price"}, Excludes: []string{"price.cost"}}
+
+	Convey("The credentials without field restrictions are left untouched", t, func() {
+		body := map[string]interface{}{"query": map[string]interface{}{"term": map[string]interface{}{"secret": "x"}}}
+		So(restrict(body, &permission.Permission{Includes: []string{"*"}}), ShouldBeNil)
+		So(body["_source"], ShouldBeNil)
+	})
+
+	Convey("The returned fields are narrowed down", t, func() {
+		body := map[string]interface{}{
+			"_source":         map[string]interface{}{"includes": []interface{}{"name", "secret"}},
+			"docvalue_fields": []interface{}{map[string]interface{}{"field": "price"}, map[string]interface{}{"field": "secret"}},
+		}
+		So(restrict(body, p), ShouldBeNil)
+		So(toJSON(body["_source"]), ShouldEqual, `{"excludes":["price.cost"],"includes":["name"]}`)
+		So(toJSON(body["docvalue_fields"]), ShouldEqual, `[{"field":"price"}]`)
+
+		body = map[string]interface{}{}
+		So(restrict(body, p), ShouldBeNil)
+		So(toJSON(body["_source"]), ShouldEqual, `{"excludes":["price.cost"],"includes":["name","price"]}`)
+
+		body = map[string]interface{}{"_source": []interface{}{"secret"}}
+		So(restrict(body, p), ShouldBeNil)
+		So(body["_source"], ShouldEqual, false)
+	})
+
+	Convey("The queries, sorts and aggregations of inaccessible fields are rejected", t, func() {
+		for _, body := range []map[string]interface{}{
+			{"query": map[string]interface{}{"bool": map[string]interface{}{"must": []interface{}{
+				map[string]interface{}{"range": map[string]interface{}{"secret": map[string]interface{}{"gt": 1}}},
+			}}}},
+			{"query": map[string]interface{}{"exists": map[string]interface{}{"field": "price.cost"}}},
+			{"query": map[string]interface{}{"query_string": map[string]interface{}{"query": "x"}}},
+			{"sort": []interface{}{map[string]interface{}{"secret": map[string]interface{}{"order": "asc"}}}},
+			{"aggs": map[string]interface{}{"by": map[string]interface{}{"terms": map[string]interface{}{"field": "secret"}}}},
+			{"aggs": map[string]interface{}{"by": map[string]interface{}{"filter": map[string]interface{}{"term": map[string]interface{}{"secret": 1}}}}},
+		} {
+			So(restrict(body, p), ShouldHaveSameTypeAs, accessError{})
+		}
+	})
+
+	Convey("The scripts are rejected", t, func() {
+		body := map[string]interface{}{"query": map[string]interface{}{"script": map[string]interface{}{"script": "doc['secret'].value > 1"}}}
+		So(restrict(body, p), ShouldHaveSameTypeAs, accessError{})
+	})
+
+	Convey("The accessible fields are allowed", t, func() {
+		body := map[string]interface{}{
+			"query": map[string]interface{}{"term": map[string]interface{}{"name": map[string]interface{}{"value": "x", "boost": 2}}},
+			"sort":  []interface{}{"_score", map[string]interface{}{"price": "desc"}},
+			"aggs":  map[string]interface{}{"terms": map[string]interface{}{"terms": map[string]interface{}{"field": "name.keyword", "size": 10}}},
+		}
+		So(restrict(body, p), ShouldBeNil)
+	})
+
+	Convey("The aggregations must be allowed by the aggregation policy", t, func() {
+		policy := &permission.Permission{Aggregations: &permission.AggregationPolicy{Types: []string{"terms"}, Fields: []string{"name"}}}
+		body := map[string]interface{}{"aggs": map[string]interface{}{"names": map[string]interface{}{"terms": map[string]interface{}{"field": "name"}}}}
+		So(restrict(body, policy), ShouldBeNil)
+
+		body = map[string]interface{}{"aggs": map[string]interface{}{"prices": map[string]interface{}{"avg": map[string]interface{}{"field": "price"}}}}
+		So(restrict(body, policy), ShouldHaveSameTypeAs, accessError{})
+	})
+}
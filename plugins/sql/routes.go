@@ -0,0 +1,28 @@
+package sql
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (s *sql) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "SQL search",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_sql",
+			HandlerFunc: middleware(s.search()),
+			Description: "Translates the sql statement of the body into query dsl and searches with it",
+		},
+		{
+			Name:        "SQL translate",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_sql/translate",
+			HandlerFunc: middleware(s.translate()),
+			Description: "Returns the query dsl the sql statement of the body translates into for the credential",
+		},
+	}
+	return routes
+}
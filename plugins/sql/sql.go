@@ -0,0 +1,86 @@
+package sql
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag            = "[sql]"
+	envTranslator     = "SQL_TRANSLATOR"
+	envDefaultSize    = "SQL_DEFAULT_SIZE"
+	defaultSize       = 1000
+	translatorAuto    = "auto"
+	translatorES      = "elasticsearch"
+	translatorBuiltin = "internal"
+)
+
+var (
+	singleton *sql
+	once      sync.Once
+)
+
+// sql translates the sql statements into query dsl, restricts the dsl to
+// the indices and the fields the credential can access and executes it.
+// The statements are translated by elasticsearch if its sql is available,
+// and otherwise by the internal translator.
+type sql struct {
+	es          *elasticsearch
+	translator  string
+	defaultSize int
+}
+
+// Use only this function to fetch the instance of sql from within this
+// package to avoid creating stateless duplicates of the plugin.
+func Instance() *sql {
+	once.Do(func() {
+		singleton = &sql{
+			es:          &elasticsearch{},
+			translator:  translatorAuto,
+			defaultSize: defaultSize,
+		}
+	})
+	return singleton
+}
+
+func (s *sql) Name() string {
+	return logTag
+}
+
+func (s *sql) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	if value := os.Getenv(envTranslator); value != "" {
+		switch value {
+		case translatorAuto, translatorES, translatorBuiltin:
+			s.translator = value
+		default:
+			return fmt.Errorf(`invalid value for %s: %s, must be one of "%s", "%s" or "%s"`,
+				envTranslator, value, translatorAuto, translatorES, translatorBuiltin)
+		}
+	}
+	if value := os.Getenv(envDefaultSize); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a non-negative integer", envDefaultSize, value)
+		}
+		s.defaultSize = n
+	}
+	return nil
+}
+
+func (s *sql) Routes() []plugins.Route {
+	return s.routes()
+}
+
+// Default empty middleware array function
+func (s *sql) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
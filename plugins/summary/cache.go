@@ -0,0 +1,77 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// maxCachedHealths bounds the number of distinct query strings the cluster
+// health is cached for, the cache is emptied once full.
+const maxCachedHealths = 100
+
+// cachedHealth is the cluster health fetched for a query string, ready is
+// closed once the fetch is done so that the concurrent requests wait for it
+// instead of hitting elasticsearch.
+type cachedHealth struct {
+	ready     chan struct{}
+	raw       json.RawMessage
+	err       error
+	fetchedAt time.Time
+}
+
+// healthCache caches the cluster health per query string for the ttl.
+type healthCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cachedHealth
+}
+
+func newHealthCache(ttl time.Duration) *healthCache {
+	return &healthCache{ttl: ttl, entries: make(map[string]*cachedHealth)}
+}
+
+// get returns the cluster health cached for the key along with the time it
+// was fetched at, fetching it if missing or expired. The failed fetches
+// aren't cached.
+func (c *healthCache) get(ctx context.Context, key string, fetch func(context.Context) (json.RawMessage, error)) (json.RawMessage, time.Time, error) {
+	if c.ttl <= 0 {
+		raw, err := fetch(ctx)
+		return raw, time.Now(), err
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if ok {
+		select {
+		case <-entry.ready:
+			if entry.err != nil || time.Since(entry.fetchedAt) >= c.ttl {
+				ok = false
+			}
+		default:
+		}
+	}
+	if !ok {
+		if len(c.entries) >= maxCachedHealths {
+			c.entries = make(map[string]*cachedHealth)
+		}
+		entry = &cachedHealth{ready: make(chan struct{})}
+		c.entries[key] = entry
+		c.mu.Unlock()
+
+		entry.raw, entry.err = fetch(ctx)
+		entry.fetchedAt = time.Now()
+		close(entry.ready)
+		return entry.raw, entry.fetchedAt, entry.err
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-entry.ready:
+		return entry.raw, entry.fetchedAt, entry.err
+	case <-ctx.Done():
+		return nil, time.Time{}, ctx.Err()
+	}
+}
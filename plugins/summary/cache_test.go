@@ -0,0 +1,65 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHealthCache(t *testing.T) {
+	var calls int64
+	fetch := func(ctx context.Context) (json.RawMessage, error) {
+		atomic.AddInt64(&calls, 1)
+		time.Sleep(10 * time.Millisecond)
+		return json.RawMessage(`{"status":"green"}`), nil
+	}
+
+	Convey("The concurrent requests share a single fetch", t, func() {
+		atomic.StoreInt64(&calls, 0)
+		c := newHealthCache(time.Minute)
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.get(context.Background(), "", fetch)
+			}()
+		}
+		wg.Wait()
+		raw, _, err := c.get(context.Background(), "", fetch)
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `{"status":"green"}`)
+		So(atomic.LoadInt64(&calls), ShouldEqual, 1)
+
+		Convey("The health is cached per query string", func() {
+			c.get(context.Background(), "level=indices", fetch)
+			So(atomic.LoadInt64(&calls), ShouldEqual, 2)
+		})
+	})
+
+	Convey("The health is fetched again once expired", t, func() {
+		atomic.StoreInt64(&calls, 0)
+		c := newHealthCache(time.Millisecond)
+		c.get(context.Background(), "", fetch)
+		time.Sleep(2 * time.Millisecond)
+		c.get(context.Background(), "", fetch)
+		So(atomic.LoadInt64(&calls), ShouldEqual, 2)
+	})
+
+	Convey("The failed fetches aren't cached", t, func() {
+		c := newHealthCache(time.Minute)
+		_, _, err := c.get(context.Background(), "", func(context.Context) (json.RawMessage, error) {
+			return nil, errors.New("unavailable")
+		})
+		So(err, ShouldNotBeNil)
+		raw, _, err := c.get(context.Background(), "", fetch)
+		So(err, ShouldBeNil)
+		So(raw, ShouldNotBeEmpty)
+	})
+}
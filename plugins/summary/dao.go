@@ -0,0 +1,47 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+type elasticsearch struct{}
+
+// count returns the number of docs in the index and whether the index exists.
+func (es *elasticsearch) count(ctx context.Context, indexName string) (int64, bool, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodGet,
+		Path:   "/" + url.PathEscape(indexName) + "/_count",
+	})
+	if es7.IsNotFound(err) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	var result struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.Unmarshal(response.Body, &result); err != nil {
+		return 0, true, err
+	}
+	return result.Count, true, nil
+}
+
+func (es *elasticsearch) clusterHealth(ctx context.Context, params url.Values) (json.RawMessage, error) {
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodGet,
+		Path:   "/_cluster/health",
+		Params: params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.Body, nil
+}
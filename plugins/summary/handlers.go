@@ -0,0 +1,181 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/health"
+	"github.com/appbaseio/arc/util/iplookup"
+	"github.com/appbaseio/arc/util/notify"
+)
+
+// sectionTimeout bounds the time spent on each section of the summary so
+// that an unresponsive component doesn't hold up the whole view.
+const sectionTimeout = 5 * time.Second
+
+type cluster struct {
+	Distribution string          `json:"distribution"`
+	Version      string          `json:"version"`
+	Health       json.RawMessage `json:"health,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+type docCount struct {
+	Available bool   `json:"available"`
+	Count     int64  `json:"count"`
+	Error     string `json:"error,omitempty"`
+}
+
+type response struct {
+	Cluster     cluster             `json:"cluster"`
+	Users       docCount            `json:"users"`
+	Permissions docCount            `json:"permissions"`
+	Analytics   docCount            `json:"analytics"`
+	Plugins     []plugins.Status    `json:"plugins"`
+	Hooks       []plugins.HookStats `json:"response_hooks"`
+	Health      health.Report       `json:"health"`
+}
+
+func (s *summary) getSummary() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), sectionTimeout)
+		defer cancel()
+
+		result := response{
+			Cluster: cluster{
+				Distribution: util.GetDistribution(),
+				Version:      util.GetRawVersion(),
+			},
+			Plugins: plugins.Statuses(),
+			Hooks:   plugins.ResponseHookStats(),
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(5)
+		go func() {
+			defer wg.Done()
+			clusterHealth, err := s.es.clusterHealth(ctx, nil)
+			if err != nil {
+				log.Errorln(logTag, ": unable to fetch the cluster health :", err)
+				result.Cluster.Error = err.Error()
+				return
+			}
+			result.Cluster.Health = clusterHealth
+		}()
+		go func() {
+			defer wg.Done()
+			result.Users = s.count(ctx, s.usersIndex)
+		}()
+		go func() {
+			defer wg.Done()
+			result.Permissions = s.count(ctx, s.permissionsIndex)
+		}()
+		go func() {
+			defer wg.Done()
+			result.Analytics = s.count(ctx, s.analyticsIndex)
+		}()
+		go func() {
+			defer wg.Done()
+			result.Health = health.Run()
+		}()
+		wg.Wait()
+
+		raw, err := json.Marshal(result)
+		if err != nil {
+			msg := "error encoding the summary"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+func (s *summary) count(ctx context.Context, indexName string) docCount {
+	n, exists, err := s.es.count(ctx, indexName)
+	if err != nil {
+		log.Errorln(logTag, ": unable to count the docs in", indexName, ":", err)
+		return docCount{Error: err.Error()}
+	}
+	return docCount{Available: exists, Count: n}
+}
+
+// queue is the number of items waiting in one of the background queues of
+// arc, out of its capacity.
+type queue struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+}
+
+// status is the status of arc added to the cluster health.
+type status struct {
+	Status  health.Status    `json:"status"`
+	Health  health.Report    `json:"health"`
+	Plugins []plugins.Status `json:"plugins"`
+	Queues  map[string]queue `json:"queues"`
+}
+
+func arcStatus() status {
+	report := health.Run()
+	result := status{
+		Status:  report.Status,
+		Health:  report,
+		Plugins: plugins.Statuses(),
+		Queues:  make(map[string]queue),
+	}
+	length, capacity := notify.Queued()
+	result.Queues["notifications"] = queue{length, capacity}
+	length, capacity = iplookup.Instance().Queued()
+	result.Queues["iplookup"] = queue{length, capacity}
+	return result
+}
+
+// getClusterHealth serves the cluster health for the status pages polled by
+// many clients: the response of elasticsearch is cached per query string for
+// CLUSTER_HEALTH_CACHE_TTL and the status of arc, computed on each request,
+// is added to it under "arc". The Age header tells how long ago the cluster
+// health was fetched.
+func (s *summary) getClusterHealth() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx, cancel := context.WithTimeout(req.Context(), sectionTimeout)
+		defer cancel()
+
+		params := req.URL.Query()
+		raw, fetchedAt, err := s.healths.get(ctx, params.Encode(), func(ctx context.Context) (json.RawMessage, error) {
+			return s.es.clusterHealth(ctx, params)
+		})
+		if err != nil {
+			msg := "unable to fetch the cluster health"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusBadGateway)
+			return
+		}
+
+		var result map[string]interface{}
+		if err := json.Unmarshal(raw, &result); err != nil {
+			msg := "unable to parse the cluster health"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		result["arc"] = arcStatus()
+
+		raw, err = json.Marshal(result)
+		if err != nil {
+			msg := "error encoding the cluster health"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Age", strconv.Itoa(int(time.Since(fetchedAt).Seconds())))
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
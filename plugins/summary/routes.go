@@ -0,0 +1,28 @@
+package summary
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (s *summary) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get arc summary",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_arc/summary",
+			HandlerFunc: middleware(isAdmin(s.getSummary())),
+			Description: "Returns the status of arc, its plugins and the cluster",
+		},
+		{
+			Name:        "Get cluster health",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_cluster/health",
+			HandlerFunc: middleware(s.getClusterHealth()),
+			Description: "Returns the cached cluster health along with the status of arc",
+		},
+	}
+	return routes
+}
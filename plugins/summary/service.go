@@ -0,0 +1,12 @@
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+type summaryService interface {
+	count(ctx context.Context, indexName string) (int64, bool, error)
+	clusterHealth(ctx context.Context, params url.Values) (json.RawMessage, error)
+}
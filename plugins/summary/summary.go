@@ -0,0 +1,84 @@
+package summary
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag                    = "[summary]"
+	envUsersEsIndex           = "USERS_ES_INDEX"
+	defaultUsersEsIndex       = ".users"
+	envPermissionsEsIndex     = "PERMISSIONS_ES_INDEX"
+	defaultPermissionsEsIndex = ".permissions"
+	envAnalyticsEsIndex       = "ANALYTICS_ES_INDEX"
+	defaultAnalyticsEsIndex   = ".analytics"
+	envHealthCacheTTL         = "CLUSTER_HEALTH_CACHE_TTL"
+	defaultHealthCacheTTL     = 5 * time.Second
+)
+
+var (
+	singleton *summary
+	once      sync.Once
+)
+
+type summary struct {
+	es               summaryService
+	usersIndex       string
+	permissionsIndex string
+	analyticsIndex   string
+	healths          *healthCache
+}
+
+// Use only this function to fetch the instance of summary from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *summary {
+	once.Do(func() { singleton = &summary{} })
+	return singleton
+}
+
+func (s *summary) Name() string {
+	return logTag
+}
+
+func (s *summary) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+	s.es = &elasticsearch{}
+	s.usersIndex = fromEnv(envUsersEsIndex, defaultUsersEsIndex)
+	s.permissionsIndex = fromEnv(envPermissionsEsIndex, defaultPermissionsEsIndex)
+	s.analyticsIndex = fromEnv(envAnalyticsEsIndex, defaultAnalyticsEsIndex)
+
+	ttl := defaultHealthCacheTTL
+	if value := os.Getenv(envHealthCacheTTL); value != "" {
+		var err error
+		ttl, err = time.ParseDuration(value)
+		if err != nil || ttl < 0 {
+			return fmt.Errorf("invalid value for %s: %s, must be a non-negative duration", envHealthCacheTTL, value)
+		}
+	}
+	s.healths = newHealthCache(ttl)
+	return nil
+}
+
+func (s *summary) Routes() []plugins.Route {
+	return s.routes()
+}
+
+// Default empty middleware array function
+func (s *summary) ESMiddleware() []middleware.Middleware {
+	return make([]middleware.Middleware, 0)
+}
+
+func fromEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,47 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// preview returns the report that would be sent next, along with the
+// telemetry settings.
+func (t *telemetry) preview() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		t.mu.Lock()
+		lastSent := t.lastSent
+		t.mu.Unlock()
+
+		response := struct {
+			Enabled  bool       `json:"enabled"`
+			Endpoint string     `json:"endpoint,omitempty"`
+			Interval string     `json:"interval"`
+			LastSent *time.Time `json:"last_sent,omitempty"`
+			Report   report     `json:"report"`
+		}{
+			Enabled:  t.enabled && t.endpoint != "",
+			Endpoint: t.endpoint,
+			Interval: t.interval.String(),
+			Report:   t.build(req.Context(), atomic.LoadUint64(&t.requests)),
+		}
+		if !lastSent.IsZero() {
+			response.LastSent = &lastSent
+		}
+
+		raw, err := json.Marshal(response)
+		if err != nil {
+			msg := "an error occurred while building the telemetry report"
+			log.Errorln(logTag, ": unable to marshal the report:", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
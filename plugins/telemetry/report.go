@@ -0,0 +1,174 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	es7 "github.com/olivere/elastic/v7"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util"
+)
+
+// report is the anonymized usage sent to the telemetry endpoint. It holds
+// neither the data nor the credentials, the indices or the addresses.
+type report struct {
+	Installation   string   `json:"installation"`
+	ArcVersion     string   `json:"arc_version"`
+	ESVersion      string   `json:"es_version"`
+	ESDistribution string   `json:"es_distribution"`
+	Plugins        []string `json:"plugins"`
+	Requests       string   `json:"requests"`
+	Interval       string   `json:"interval"`
+	OS             string   `json:"os"`
+	Arch           string   `json:"arch"`
+}
+
+// volumeBuckets are the upper bounds of the request volume buckets, the
+// request counts are only ever reported as one of the buckets.
+var volumeBuckets = []struct {
+	max  uint64
+	name string
+}{
+	{0, "0"},
+	{100, "1-100"},
+	{1000, "101-1k"},
+	{10000, "1k-10k"},
+	{100000, "10k-100k"},
+	{1000000, "100k-1m"},
+}
+
+// volumeBucket returns the bucket of the request count.
+func volumeBucket(requests uint64) string {
+	for _, b := range volumeBuckets {
+		if requests <= b.max {
+			return b.name
+		}
+	}
+	return "1m+"
+}
+
+// clusterVersion returns the version and the distribution of the cluster.
+var clusterVersion = func() (string, string) {
+	return util.GetRawVersion(), util.GetDistribution()
+}
+
+// build builds the report of the requests made since the last one.
+func (t *telemetry) build(ctx context.Context, requests uint64) report {
+	version := util.Version
+	if version == "" {
+		version = "dev"
+	}
+	esVersion, distribution := clusterVersion()
+	return report{
+		Installation:   t.installationID(ctx),
+		ArcVersion:     version,
+		ESVersion:      esVersion,
+		ESDistribution: distribution,
+		Plugins:        loadedPlugins(),
+		Requests:       volumeBucket(requests),
+		Interval:       t.interval.String(),
+		OS:             runtime.GOOS,
+		Arch:           runtime.GOARCH,
+	}
+}
+
+// loadedPlugins returns the names of the plugins initialized successfully.
+func loadedPlugins() []string {
+	var names []string
+	for _, status := range plugins.Statuses() {
+		if status.Loaded {
+			names = append(names, strings.Trim(status.Name, "[]"))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// installationID identifies the installation by a digest of the uuid of the
+// cluster, so that the reports of an installation can be told apart without
+// revealing the cluster. A random id is used if the uuid can't be fetched.
+func (t *telemetry) installationID(ctx context.Context) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.installation != "" {
+		return t.installation
+	}
+
+	seed := util.RandStr()
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodGet,
+		Path:   "/",
+	})
+	if err == nil {
+		var info struct {
+			ClusterUUID string `json:"cluster_uuid"`
+		}
+		if err := json.Unmarshal(response.Body, &info); err == nil && info.ClusterUUID != "" {
+			seed = info.ClusterUUID
+		}
+	} else {
+		log.Errorln(logTag, ": unable to fetch the cluster uuid:", err)
+	}
+	sum := sha256.Sum256([]byte("arc-telemetry:" + seed))
+	t.installation = hex.EncodeToString(sum[:])[:16]
+	return t.installation
+}
+
+// client sends the reports.
+var client = &http.Client{Timeout: 10 * time.Second}
+
+// reportEvery sends the report every interval.
+func (t *telemetry) reportEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := t.send(context.Background()); err != nil {
+			log.Errorln(logTag, ": unable to send the report:", err)
+		}
+	}
+}
+
+// send sends the report of the requests made since the last one, the
+// requests are carried over to the next report if the endpoint fails.
+func (t *telemetry) send(ctx context.Context) error {
+	requests := atomic.SwapUint64(&t.requests, 0)
+	raw, err := json.Marshal(t.build(ctx, requests))
+	if err != nil {
+		atomic.AddUint64(&t.requests, requests)
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(raw))
+	if err != nil {
+		atomic.AddUint64(&t.requests, requests)
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		atomic.AddUint64(&t.requests, requests)
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		atomic.AddUint64(&t.requests, requests)
+		return fmt.Errorf("endpoint responded with %d", resp.StatusCode)
+	}
+
+	t.mu.Lock()
+	t.lastSent = time.Now()
+	t.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,56 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVolumeBucket(t *testing.T) {
+	Convey("The request counts are reported as buckets", t, func() {
+		So(volumeBucket(0), ShouldEqual, "0")
+		So(volumeBucket(100), ShouldEqual, "1-100")
+		So(volumeBucket(101), ShouldEqual, "101-1k")
+		So(volumeBucket(54321), ShouldEqual, "10k-100k")
+		So(volumeBucket(5000000), ShouldEqual, "1m+")
+	})
+}
+
+func TestSend(t *testing.T) {
+	clusterVersion = func() (string, string) { return "7.10.2", "elasticsearch" }
+	status := http.StatusOK
+	var received report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		raw, _ := ioutil.ReadAll(req.Body)
+		json.Unmarshal(raw, &received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	tm := &telemetry{enabled: true, endpoint: server.URL, interval: time.Hour, installation: "abc", requests: 250}
+
+	Convey("The requests are carried over if the endpoint fails", t, func() {
+		status = http.StatusServiceUnavailable
+		So(tm.send(context.Background()), ShouldNotBeNil)
+		So(atomic.LoadUint64(&tm.requests), ShouldEqual, 250)
+		So(tm.lastSent.IsZero(), ShouldBeTrue)
+	})
+
+	Convey("The report holds the buckets of the requests since the last one", t, func() {
+		status = http.StatusOK
+		So(tm.send(context.Background()), ShouldBeNil)
+		So(received.Installation, ShouldEqual, "abc")
+		So(received.Requests, ShouldEqual, "101-1k")
+		So(received.ESVersion, ShouldEqual, "7.10.2")
+		So(received.ArcVersion, ShouldEqual, "dev")
+		So(atomic.LoadUint64(&tm.requests), ShouldEqual, 0)
+		So(tm.lastSent.IsZero(), ShouldBeFalse)
+	})
+}
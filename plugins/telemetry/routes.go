@@ -0,0 +1,21 @@
+package telemetry
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (t *telemetry) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get telemetry report",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_telemetry",
+			HandlerFunc: middleware(isAdmin(t.preview())),
+			Description: "Returns the anonymized usage report exactly as it would be sent next",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,89 @@
+package telemetry
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag          = "[telemetry]"
+	envDisabled     = "TELEMETRY_DISABLED"
+	envEndpoint     = "TELEMETRY_ENDPOINT"
+	envInterval     = "TELEMETRY_INTERVAL"
+	defaultInterval = 24 * time.Hour
+)
+
+var (
+	singleton *telemetry
+	once      sync.Once
+)
+
+// telemetry periodically reports the anonymized usage of arc to the
+// configured endpoint, unless opted out of. The report that would be sent
+// can be inspected locally.
+type telemetry struct {
+	enabled  bool
+	endpoint string
+	interval time.Duration
+	// requests counts the requests made to elasticsearch since the last report.
+	requests uint64
+
+	mu           sync.Mutex
+	installation string
+	lastSent     time.Time
+}
+
+// Use only this function to fetch the instance of telemetry from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *telemetry {
+	once.Do(func() { singleton = &telemetry{} })
+	return singleton
+}
+
+func (t *telemetry) Name() string {
+	return logTag
+}
+
+// InitFunc starts reporting the usage periodically if telemetry is enabled
+// and an endpoint is configured.
+func (t *telemetry) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	t.enabled = os.Getenv(envDisabled) != "true"
+	t.endpoint = os.Getenv(envEndpoint)
+	t.interval = defaultInterval
+	if value := os.Getenv(envInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Errorln(logTag, ": invalid value for", envInterval, ":", value)
+		} else {
+			t.interval = d
+		}
+	}
+
+	if !t.enabled {
+		log.Println(logTag, ": telemetry is disabled")
+		return nil
+	}
+	if t.endpoint == "" {
+		log.Println(logTag, ":", envEndpoint, "isn't set, the usage won't be reported")
+		return nil
+	}
+	go t.reportEvery(t.interval)
+	return nil
+}
+
+func (t *telemetry) Routes() []plugins.Route {
+	return t.routes()
+}
+
+// ESMiddleware counts the requests made to elasticsearch.
+func (t *telemetry) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{t.count}
+}
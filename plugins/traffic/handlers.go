@@ -0,0 +1,29 @@
+package traffic
+
+import (
+	"encoding/json"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// getRules reports the routing rules along with the metrics this arc instance
+// has gathered since it started.
+func (t *traffic) getRules() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		reports := make([]ruleReport, 0, len(t.rules))
+		for _, r := range t.rules {
+			reports = append(reports, r.report())
+		}
+		raw, err := json.Marshal(map[string]interface{}{"rules": reports})
+		if err != nil {
+			msg := "error encoding the traffic rules"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
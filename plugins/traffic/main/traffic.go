@@ -0,0 +1,6 @@
+package main
+
+import "github.com/appbaseio/arc/plugins/traffic"
+import "github.com/appbaseio/arc/plugins"
+
+var PluginInstance plugins.Plugin = traffic.Instance()
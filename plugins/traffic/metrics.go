@@ -0,0 +1,193 @@
+package traffic
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// topHits is the number of leading hits compared between the responses.
+const topHits = 10
+
+// observation is the outcome of a search served by either the primary or the
+// candidate index or cluster.
+type observation struct {
+	latency time.Duration
+	code    int
+	hits    bool
+	total   int64
+	ids     []string
+}
+
+// observe extracts the total and the ids of the top hits from the search
+// response, the hits are left out if the body can't be parsed.
+func observe(code int, body []byte, latency time.Duration) observation {
+	o := observation{latency: latency, code: code}
+	if code >= 400 {
+		return o
+	}
+	var response struct {
+		Hits struct {
+			Total *util.TotalHits `json:"total"`
+			Hits  []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Hits.Total == nil {
+		return o
+	}
+	o.total = response.Hits.Total.Value
+	for i, hit := range response.Hits.Hits {
+		if i == topHits {
+			break
+		}
+		o.ids = append(o.ids, hit.ID)
+	}
+	o.hits = true
+	return o
+}
+
+func (o observation) failed() bool {
+	return o.code == 0 || o.code >= 400
+}
+
+// overlap is the fraction of the top hits of the primary response also
+// present in the top hits of the candidate response.
+func overlap(primary, candidate []string) float64 {
+	if len(primary) == 0 {
+		if len(candidate) == 0 {
+			return 1
+		}
+		return 0
+	}
+	ids := make(map[string]bool, len(candidate))
+	for _, id := range candidate {
+		ids[id] = true
+	}
+	var common int
+	for _, id := range primary {
+		if ids[id] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(primary))
+}
+
+type variant struct {
+	requests int64
+	errors   int64
+	zeroHits int64
+	latency  time.Duration
+}
+
+func (v *variant) add(o observation) {
+	v.requests++
+	if o.failed() {
+		v.errors++
+		return
+	}
+	v.latency += o.latency
+	if o.hits && o.total == 0 {
+		v.zeroHits++
+	}
+}
+
+func (v variant) report() variantReport {
+	r := variantReport{Requests: v.requests, Errors: v.errors, ZeroHits: v.zeroHits}
+	if served := v.requests - v.errors; served > 0 {
+		r.AvgLatencyMs = float64(v.latency) / float64(served) / float64(time.Millisecond)
+	}
+	return r
+}
+
+// metrics compares the searches served by the primary and the candidate.
+// The shadowed searches are compared pairwise on the total and the top hits.
+type metrics struct {
+	mu             sync.Mutex
+	primary        variant
+	candidate      variant
+	comparisons    int64
+	hitsMismatches int64
+	overlap        float64
+	dropped        int64
+}
+
+func (m *metrics) addPrimary(o observation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.primary.add(o)
+}
+
+func (m *metrics) addCandidate(o observation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.candidate.add(o)
+}
+
+func (m *metrics) compare(primary, candidate observation) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !primary.hits || !candidate.hits {
+		return
+	}
+	m.comparisons++
+	if primary.total != candidate.total {
+		m.hitsMismatches++
+	}
+	m.overlap += overlap(primary.ids, candidate.ids)
+}
+
+func (m *metrics) drop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropped++
+}
+
+type variantReport struct {
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	ZeroHits     int64   `json:"zero_hits"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+type ruleReport struct {
+	Name           string        `json:"name"`
+	Mode           string        `json:"mode"`
+	Index          string        `json:"index"`
+	Target         string        `json:"target,omitempty"`
+	TargetIndex    string        `json:"target_index,omitempty"`
+	Percentage     float64       `json:"percentage"`
+	Primary        variantReport `json:"primary"`
+	Candidate      variantReport `json:"candidate"`
+	Comparisons    int64         `json:"comparisons,omitempty"`
+	HitsMismatches int64         `json:"hits_mismatches,omitempty"`
+	AvgOverlap     *float64      `json:"avg_overlap,omitempty"`
+	Dropped        int64         `json:"dropped,omitempty"`
+}
+
+func (r *rule) report() ruleReport {
+	m := r.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	report := ruleReport{
+		Name:           r.Name,
+		Mode:           r.Mode,
+		Index:          r.Index,
+		Target:         r.redactedTarget(),
+		TargetIndex:    r.TargetIndex,
+		Percentage:     r.Percentage,
+		Primary:        m.primary.report(),
+		Candidate:      m.candidate.report(),
+		Comparisons:    m.comparisons,
+		HitsMismatches: m.hitsMismatches,
+		Dropped:        m.dropped,
+	}
+	if m.comparisons > 0 {
+		avg := m.overlap / float64(m.comparisons)
+		report.AvgOverlap = &avg
+	}
+	return report
+}
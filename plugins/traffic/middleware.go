@@ -0,0 +1,228 @@
+package traffic
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+// shadowTimeout bounds the shadow requests, which outlive the client request.
+const shadowTimeout = 30 * time.Second
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clustersCategory := category.Clusters
+		ctx := category.NewContext(req.Context(), &clustersCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusterACL := acl.Cluster
+		ctx := acl.NewContext(req.Context(), &clusterACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the routing rules span all
+// the credentials.
+var isAdmin = validate.Admin("access the traffic rules")
+
+// route applies the first rule matching the searched index. The sampled
+// requests are either served by the candidate (canary) or served as usual and
+// duplicated to the candidate once the client is served (shadow).
+func (t *traffic) route(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r := t.match(req)
+		if r == nil {
+			h(w, req)
+			return
+		}
+		sampled := rand.Float64()*100 < r.Percentage
+
+		var body []byte
+		if sampled && req.Body != nil {
+			var err error
+			body, err = util.ReadRequestBody(req)
+			if err != nil {
+				log.Errorln(logTag, ": unable to read request body:", err)
+				util.WriteBackBodyError(w, err)
+				return
+			}
+		}
+
+		switch {
+		case sampled && r.Mode == modeCanary && r.target != nil:
+			r.metrics.addCandidate(t.forward(w, req, body, r))
+		case sampled && r.Mode == modeCanary:
+			// the target index lives on the upstream cluster
+			req.URL.Path = r.candidatePath(req.URL.Path)
+			req.URL.RawPath = ""
+			r.metrics.addCandidate(serve(h, w, req))
+		case sampled && r.Mode == modeShadow:
+			// the upstream request mutates the url and the headers
+			shadowed := req.Clone(context.Background())
+			primary := serve(h, w, req)
+			r.metrics.addPrimary(primary)
+			select {
+			case t.shadows <- struct{}{}:
+				go func() {
+					defer func() { <-t.shadows }()
+					t.shadow(shadowed, body, r, primary)
+				}()
+			default:
+				r.metrics.drop()
+			}
+		default:
+			r.metrics.addPrimary(serve(h, w, req))
+		}
+	}
+}
+
+// match returns the rule applicable to the search, the rules only apply to
+// the searches made to a single index.
+func (t *traffic) match(req *http.Request) *rule {
+	if len(t.rules) == 0 {
+		return nil
+	}
+	reqACL, err := acl.FromContext(req.Context())
+	if err != nil || *reqACL != acl.Search {
+		return nil
+	}
+	if mux.Vars(req)["index"] == "" {
+		return nil
+	}
+	index := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/"), "/", 2)[0]
+	if strings.ContainsAny(index, ",*") {
+		return nil
+	}
+	for _, r := range t.rules {
+		if r.matches(index) {
+			return r
+		}
+	}
+	return nil
+}
+
+// serve serves the request via the upstream cluster and observes the response.
+func serve(h http.HandlerFunc, w http.ResponseWriter, req *http.Request) observation {
+	start := time.Now()
+	tee := util.NewTeeResponseWriter(w, maxBodySize)
+	h(tee, req)
+	return observe(tee.Code(), tee.Body(), time.Since(start))
+}
+
+// forward serves the request via the target cluster of the rule.
+func (t *traffic) forward(w http.ResponseWriter, req *http.Request, body []byte, r *rule) observation {
+	start := time.Now()
+	code, header, respBody, err := send(req.Context(), req, body, r)
+	latency := time.Since(start)
+	if err != nil {
+		log.Errorln(logTag, ": unable to route the request to", r.redactedTarget(), "of rule", r.Name, ":", err)
+		util.WriteBackError(w, "error fetching response from the canary cluster", http.StatusBadGateway)
+		return observation{latency: latency}
+	}
+
+	for k, v := range header {
+		if k != "Content-Length" {
+			w.Header().Set(k, v[0])
+		}
+	}
+	w.Header().Set("X-Origin", "ES")
+	resp := &plugins.Response{Code: code, Header: w.Header(), Body: respBody}
+	plugins.RunResponseHooks(req, resp)
+	w.WriteHeader(resp.Code)
+	w.Write(resp.Body)
+	return observe(code, respBody, latency)
+}
+
+// shadow duplicates the request to the candidate and compares its response
+// with the one served to the client.
+func (t *traffic) shadow(req *http.Request, body []byte, r *rule, primary observation) {
+	ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+	defer cancel()
+
+	start := time.Now()
+	code, _, respBody, err := send(ctx, req, body, r)
+	if err != nil {
+		log.Errorln(logTag, ": unable to shadow the request of rule", r.Name, ":", err)
+		r.metrics.addCandidate(observation{latency: time.Since(start)})
+		return
+	}
+	candidate := observe(code, respBody, time.Since(start))
+	r.metrics.addCandidate(candidate)
+	r.metrics.compare(primary, candidate)
+}
+
+// send makes the request to the candidate of the rule. The arc credentials
+// aren't forwarded, the candidate is authenticated with the credentials of its url.
+func send(ctx context.Context, req *http.Request, body []byte, r *rule) (int, http.Header, []byte, error) {
+	u, err := r.candidateURL(os.Getenv(envEsURL), req.URL.Path, req.URL.RawQuery)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	candidate, err := http.NewRequest(req.Method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	candidate.Header = req.Header.Clone()
+	candidate.Header.Del("Authorization")
+	candidate.Header.Del("Accept-Encoding")
+	if candidate.Header.Get("Content-Type") == "" {
+		candidate.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := util.HTTPClient().Do(candidate.WithContext(ctx))
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer response.Body.Close()
+	respBody, err := util.ReadBody(response.Body, util.MaxResponseSize())
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return response.StatusCode, response.Header, respBody, nil
+}
@@ -0,0 +1,21 @@
+package traffic
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (t *traffic) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get traffic rules",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_traffic",
+			HandlerFunc: middleware(isAdmin(t.getRules())),
+			Description: "Returns the traffic routing rules along with the metrics of the primary and the candidate",
+		},
+	}
+	return routes
+}
@@ -0,0 +1,136 @@
+package traffic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Modes in which the sampled requests are routed.
+const (
+	modeCanary = "canary"
+	modeShadow = "shadow"
+)
+
+// ruleSpec is the json representation of a rule in TRAFFIC_RULES.
+type ruleSpec struct {
+	Name        string  `json:"name"`
+	Mode        string  `json:"mode"`
+	Index       string  `json:"index"`
+	Target      string  `json:"target"`
+	TargetIndex string  `json:"target_index"`
+	Percentage  float64 `json:"percentage"`
+}
+
+// rule routes a percentage of the searches made to the indices matching its
+// pattern to the target cluster and/or the target index.
+type rule struct {
+	ruleSpec
+	index   *regexp.Regexp
+	target  *url.URL
+	metrics *metrics
+}
+
+func parseRules(raw string) ([]*rule, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var specs []ruleSpec
+	if err := json.Unmarshal([]byte(raw), &specs); err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", envRules, err)
+	}
+
+	var rules []*rule
+	names := make(map[string]bool)
+	for i, spec := range specs {
+		if spec.Name == "" {
+			spec.Name = fmt.Sprintf("%s-%d", spec.Mode, i)
+		}
+		if names[spec.Name] {
+			return nil, fmt.Errorf(`duplicate traffic rule "%s"`, spec.Name)
+		}
+		names[spec.Name] = true
+
+		r, err := newRule(spec)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func newRule(spec ruleSpec) (*rule, error) {
+	switch spec.Mode {
+	case modeCanary, modeShadow:
+	default:
+		return nil, fmt.Errorf(`invalid mode "%s" for traffic rule "%s", must be either "%s" or "%s"`,
+			spec.Mode, spec.Name, modeCanary, modeShadow)
+	}
+	if spec.Index == "" {
+		return nil, fmt.Errorf(`"index" is required for traffic rule "%s"`, spec.Name)
+	}
+	if spec.Target == "" && spec.TargetIndex == "" {
+		return nil, fmt.Errorf(`either "target" or "target_index" is required for traffic rule "%s"`, spec.Name)
+	}
+	if spec.Percentage <= 0 || spec.Percentage > 100 {
+		return nil, fmt.Errorf(`"percentage" of traffic rule "%s" must be within (0, 100]`, spec.Name)
+	}
+
+	pattern := "^" + strings.Replace(regexp.QuoteMeta(spec.Index), `\*`, ".*", -1) + "$"
+	r := &rule{ruleSpec: spec, index: regexp.MustCompile(pattern), metrics: &metrics{}}
+	if spec.Target != "" {
+		u, err := url.Parse(spec.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf(`invalid "target" "%s" for traffic rule "%s", expected an http(s) url`, spec.Target, spec.Name)
+		}
+		r.target = u
+	}
+	return r, nil
+}
+
+// matches checks whether the rule applies to the searches made to the index.
+func (r *rule) matches(index string) bool {
+	return r.index.MatchString(index)
+}
+
+// candidateURL returns the url the request is routed to, the path addresses
+// the target index instead of the requested one. Requests without a target
+// cluster are routed to the upstream cluster.
+func (r *rule) candidateURL(upstream string, path, rawQuery string) (*url.URL, error) {
+	base := r.target
+	if base == nil {
+		var err error
+		base, err = url.Parse(upstream)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", envEsURL, err)
+		}
+	}
+	u := *base
+	u.Path = strings.TrimSuffix(base.Path, "/") + r.candidatePath(path)
+	u.RawPath = ""
+	u.RawQuery = rawQuery
+	return &u, nil
+}
+
+// candidatePath replaces the index of the request path with the target index, if any.
+func (r *rule) candidatePath(path string) string {
+	if r.TargetIndex == "" {
+		return path
+	}
+	segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	segments[0] = r.TargetIndex
+	return "/" + strings.Join(segments, "/")
+}
+
+// redactedTarget returns the target cluster without its credentials.
+func (r *rule) redactedTarget() string {
+	if r.target == nil {
+		return ""
+	}
+	u := *r.target
+	u.User = nil
+	return u.String()
+}
@@ -0,0 +1,68 @@
+package traffic
+
+import (
+	"os"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag   = "[traffic]"
+	envRules = "TRAFFIC_RULES"
+	envEsURL = "ES_CLUSTER_URL"
+	// maxShadows bounds the shadow requests in flight, the requests sampled
+	// beyond it aren't shadowed.
+	maxShadows = 64
+	// maxBodySize is the size of the response bodies retained for comparison.
+	maxBodySize = 1 << 20
+)
+
+var (
+	singleton *traffic
+	once      sync.Once
+)
+
+// traffic routes a share of the search requests matching its rules to a
+// candidate index or cluster, either serving the client from the candidate
+// (canary) or duplicating the request to it in the background (shadow).
+type traffic struct {
+	rules   []*rule
+	shadows chan struct{}
+}
+
+// Use only this function to fetch the instance of traffic from within
+// this package to avoid creating stateless duplicates of the plugin.
+func Instance() *traffic {
+	once.Do(func() { singleton = &traffic{shadows: make(chan struct{}, maxShadows)} })
+	return singleton
+}
+
+func (t *traffic) Name() string {
+	return logTag
+}
+
+func (t *traffic) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	var err error
+	t.rules, err = parseRules(os.Getenv(envRules))
+	if err != nil {
+		return err
+	}
+	if len(t.rules) == 0 {
+		log.Println(logTag, ":", envRules, "isn't set, requests won't be routed")
+	}
+	return nil
+}
+
+func (t *traffic) Routes() []plugins.Route {
+	return t.routes()
+}
+
+func (t *traffic) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{t.route}
+}
@@ -0,0 +1,151 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+// incrementScript adds the flushed usage to the stored rollup, the instances
+// of arc flush their own usage concurrently.
+const incrementScript = `
+ctx._source.requests += params.requests;
+ctx._source.searches += params.searches;
+ctx._source.ingested_bytes += params.ingested_bytes;
+ctx._source.indexed_docs = (ctx._source.indexed_docs == null ? 0 : ctx._source.indexed_docs) + params.indexed_docs;
+ctx._source.analytics_calls = (ctx._source.analytics_calls == null ? 0 : ctx._source.analytics_calls) + params.analytics_calls;
+ctx._source.errors += params.errors;
+if (params.credential_type != '') { ctx._source.credential_type = params.credential_type; }`
+
+// maxRollups bounds the rollups returned by a single usage report.
+const maxRollups = 10000
+
+type elasticsearch struct {
+	indexName string
+}
+
+func initPlugin(indexName, mappings string) (*elasticsearch, error) {
+	ctx := context.Background()
+
+	var es = &elasticsearch{indexName}
+	exists, err := util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error while checking if index already exists: %v", err)
+	}
+	if exists {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping ...")
+		return es, nil
+	}
+
+	// set number_of_replicas to (nodes-1)
+	nodes, err := util.GetTotalNodes()
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"settings": map[string]interface{}{
+			"number_of_shards":   1,
+			"number_of_replicas": nodes - 1,
+		},
+	}
+	if docType := util.DocType(); docType != "" {
+		body["mappings"] = map[string]json.RawMessage{docType: json.RawMessage(mappings)}
+	} else {
+		body["mappings"] = json.RawMessage(mappings)
+	}
+
+	_, err = util.GetClient7().CreateIndex(indexName).
+		BodyJson(body).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating index named \"%s\": %v", indexName, err)
+	}
+
+	log.Println(logTag, ": successfully created index name", indexName)
+	return es, nil
+}
+
+// flush adds the usage to the stored rollups and returns the rollups that
+// failed to update.
+func (es *elasticsearch) flush(ctx context.Context, rollups []rollup) ([]rollup, error) {
+	bulk := util.GetClient7().Bulk()
+	for i := range rollups {
+		r := rollups[i]
+		script := es7.NewScript(incrementScript).Params(map[string]interface{}{
+			"requests":        r.Requests,
+			"searches":        r.Searches,
+			"ingested_bytes":  r.IngestedBytes,
+			"indexed_docs":    r.IndexedDocs,
+			"analytics_calls": r.AnalyticsCalls,
+			"errors":          r.Errors,
+			"credential_type": r.CredentialType,
+		})
+		update := es7.NewBulkUpdateRequest().
+			Index(es.indexName).
+			Id(r.id()).
+			RetryOnConflict(3).
+			Script(script).
+			Upsert(r)
+		if docType := util.DocType(); docType != "" {
+			update.Type(docType)
+		}
+		bulk.Add(update)
+	}
+
+	response, err := bulk.Do(ctx)
+	if err != nil {
+		return rollups, err
+	}
+	failed := response.Failed()
+	if len(failed) == 0 {
+		return nil, nil
+	}
+	ids := make(map[string]bool, len(failed))
+	for _, item := range failed {
+		ids[item.Id] = true
+	}
+	var retry []rollup
+	for _, r := range rollups {
+		if ids[r.id()] {
+			retry = append(retry, r)
+		}
+	}
+	return retry, fmt.Errorf("%d of %d rollups failed to update: %s", len(failed), len(rollups), failed[0].Error.Reason)
+}
+
+// rollups returns the stored rollups within the days, optionally of a
+// credential and tenant, in the order of the days.
+func (es *elasticsearch) rollups(ctx context.Context, from, to, credential, tenant string) ([]rollup, error) {
+	query := es7.NewBoolQuery().Filter(es7.NewRangeQuery("day").Gte(from).Lte(to))
+	if credential != "" {
+		query.Filter(es7.NewTermQuery("credential", credential))
+	}
+	if tenant != "" {
+		query.Filter(es7.NewTermQuery("tenant", tenant))
+	}
+
+	response, err := util.GetClient7().Search(es.indexName).
+		Query(query).
+		Sort("day", true).
+		Size(maxRollups).
+		Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rollups := make([]rollup, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		var r rollup
+		if err := json.Unmarshal(hit.Source, &r); err != nil {
+			return nil, err
+		}
+		rollups = append(rollups, r)
+	}
+	return rollups, nil
+}
@@ -0,0 +1,162 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+type report struct {
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Total   counts   `json:"total"`
+	Rollups []rollup `json:"rollups"`
+}
+
+// credentialUsage is the usage of a credential across the days of a report.
+type credentialUsage struct {
+	Credential     string `json:"credential"`
+	CredentialType string `json:"credential_type,omitempty"`
+	counts
+}
+
+type credentialsReport struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Types is the usage per type of credential, the usage metered before
+	// the types were recorded is under "unknown".
+	Types       map[string]counts `json:"types"`
+	Credentials []credentialUsage `json:"credentials"`
+}
+
+// reportDays returns the "from" and "to" days of the report, the current
+// month by default.
+func reportDays(req *http.Request) (string, string, error) {
+	now := time.Now().UTC()
+	params := req.URL.Query()
+
+	from := params.Get("from")
+	if from == "" {
+		from = now.AddDate(0, 0, 1-now.Day()).Format(dayLayout)
+	}
+	to := params.Get("to")
+	if to == "" {
+		to = now.Format(dayLayout)
+	}
+	for name, value := range map[string]string{"from": from, "to": to} {
+		if _, err := time.Parse(dayLayout, value); err != nil {
+			return "", "", fmt.Errorf(`invalid value "%s" for query param "%s", expected a day of the form YYYY-MM-DD`, value, name)
+		}
+	}
+	return from, to, nil
+}
+
+// getUsage reports the daily rollups between the "from" and "to" days, both
+// inclusive, optionally filtered by "credential" and "tenant". The report
+// defaults to the current month.
+func (u *Usage) getUsage() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := req.URL.Query()
+		from, to, err := reportDays(req)
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rollups, err := u.es.rollups(req.Context(), from, to, params.Get("credential"), params.Get("tenant"))
+		if err != nil {
+			msg := "error fetching the usage"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		result := report{From: from, To: to, Rollups: rollups}
+		for _, r := range rollups {
+			result.Total.add(r.counts)
+		}
+		raw, err := json.Marshal(result)
+		if err != nil {
+			msg := "error encoding the usage"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// getCredentialsUsage breaks the usage between the "from" and "to" days down
+// by credential, and by type of credential, i.e. users, permissions or
+// anonymous, optionally for a "tenant". The credentials are ranked by their
+// searches, so that the keys driving the traffic come first.
+func (u *Usage) getCredentialsUsage() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		from, to, err := reportDays(req)
+		if err != nil {
+			util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		rollups, err := u.es.rollups(req.Context(), from, to, "", req.URL.Query().Get("tenant"))
+		if err != nil {
+			msg := "error fetching the usage"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+
+		raw, err := json.Marshal(byCredential(from, to, rollups))
+		if err != nil {
+			msg := "error encoding the usage"
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		util.WriteBackRaw(w, raw, http.StatusOK)
+	}
+}
+
+// byCredential sums the rollups per credential and per type of credential.
+func byCredential(from, to string, rollups []rollup) credentialsReport {
+	result := credentialsReport{From: from, To: to, Types: make(map[string]counts), Credentials: []credentialUsage{}}
+	usages := make(map[string]*credentialUsage)
+	for _, r := range rollups {
+		c, ok := usages[r.Credential]
+		if !ok {
+			c = &credentialUsage{Credential: r.Credential}
+			usages[r.Credential] = c
+		}
+		c.add(r.counts)
+		if r.CredentialType != "" {
+			c.CredentialType = r.CredentialType
+		}
+	}
+	for _, c := range usages {
+		kind := c.CredentialType
+		if kind == "" {
+			kind = "unknown"
+		}
+		total := result.Types[kind]
+		total.add(c.counts)
+		result.Types[kind] = total
+		result.Credentials = append(result.Credentials, *c)
+	}
+	sort.Slice(result.Credentials, func(i, j int) bool {
+		a, b := result.Credentials[i], result.Credentials[j]
+		if a.Searches != b.Searches {
+			return a.Searches > b.Searches
+		}
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		return a.Credential < b.Credential
+	})
+	return result
+}
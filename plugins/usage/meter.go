@@ -0,0 +1,110 @@
+package usage
+
+import (
+	"sync"
+	"time"
+)
+
+// dayLayout is the format of the day the usage is rolled up by.
+const dayLayout = "2006-01-02"
+
+// counts are the metered quantities.
+type counts struct {
+	Requests       int64 `json:"requests"`
+	Searches       int64 `json:"searches"`
+	IngestedBytes  int64 `json:"ingested_bytes"`
+	IndexedDocs    int64 `json:"indexed_docs"`
+	AnalyticsCalls int64 `json:"analytics_calls"`
+	Errors         int64 `json:"errors"`
+}
+
+func (c *counts) add(other counts) {
+	c.Requests += other.Requests
+	c.Searches += other.Searches
+	c.IngestedBytes += other.IngestedBytes
+	c.IndexedDocs += other.IndexedDocs
+	c.AnalyticsCalls += other.AnalyticsCalls
+	c.Errors += other.Errors
+}
+
+// rollup is the usage of a credential, and the tenant it is bound to, on a day.
+type rollup struct {
+	Day        string `json:"day"`
+	Credential string `json:"credential"`
+	// CredentialType is either "user", "permission" or "anonymous", it's
+	// empty for the usage metered before the type was recorded.
+	CredentialType string `json:"credential_type,omitempty"`
+	Tenant         string `json:"tenant,omitempty"`
+	counts
+}
+
+// id identifies the rollup document, each day of a credential and tenant is
+// rolled up into a single document.
+func (r *rollup) id() string {
+	return r.Day + ":" + r.Credential + ":" + r.Tenant
+}
+
+// meter accumulates the usage in memory until it is flushed to the rollup index.
+type meter struct {
+	mu      sync.Mutex
+	pending map[string]*rollup
+}
+
+func newMeter() *meter {
+	return &meter{pending: make(map[string]*rollup)}
+}
+
+// add accumulates the usage, the day of the usage is set to the day of t in UTC.
+func (m *meter) add(t time.Time, usage rollup) {
+	usage.Day = t.UTC().Format(dayLayout)
+	m.merge(usage)
+}
+
+func (m *meter) merge(usage rollup) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := usage.id()
+	if r, ok := m.pending[id]; ok {
+		r.add(usage.counts)
+		if r.CredentialType == "" {
+			r.CredentialType = usage.CredentialType
+		}
+		return
+	}
+	m.pending[id] = &usage
+}
+
+// pendingOf returns the usage of the credential on the day across its
+// tenants that is yet to be flushed.
+func (m *meter) pendingOf(day, credential string) counts {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var c counts
+	for _, r := range m.pending {
+		if r.Day == day && r.Credential == credential {
+			c.add(r.counts)
+		}
+	}
+	return c
+}
+
+// drain returns the accumulated usage and resets the meter.
+func (m *meter) drain() []rollup {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[string]*rollup)
+	m.mu.Unlock()
+
+	rollups := make([]rollup, 0, len(pending))
+	for _, r := range pending {
+		rollups = append(rollups, *r)
+	}
+	return rollups
+}
+
+// restore puts back the usage that couldn't be flushed, it is retried on the next flush.
+func (m *meter) restore(rollups []rollup) {
+	for _, r := range rollups {
+		m.merge(r)
+	}
+}
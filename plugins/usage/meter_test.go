@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMeter(t *testing.T) {
+	day := time.Date(2020, time.March, 1, 23, 30, 0, 0, time.UTC)
+
+	Convey("Usage is rolled up per day, credential and tenant", t, func() {
+		m := newMeter()
+		m.add(day, rollup{Credential: "foo", counts: counts{Requests: 1, Searches: 1}})
+		m.add(day, rollup{Credential: "foo", counts: counts{Requests: 1, IngestedBytes: 42}})
+		m.add(day, rollup{Credential: "foo", Tenant: "acme", counts: counts{Requests: 1}})
+		m.add(day.Add(time.Hour), rollup{Credential: "foo", counts: counts{Requests: 1, Errors: 1}})
+
+		rollups := make(map[string]rollup)
+		for _, r := range m.drain() {
+			rollups[r.id()] = r
+		}
+		So(rollups, ShouldHaveLength, 3)
+		So(rollups["2020-03-01:foo:"].counts, ShouldResemble, counts{Requests: 2, Searches: 1, IngestedBytes: 42})
+		So(rollups["2020-03-01:foo:acme"].counts, ShouldResemble, counts{Requests: 1})
+		So(rollups["2020-03-02:foo:"].counts, ShouldResemble, counts{Requests: 1, Errors: 1})
+
+		Convey("Draining resets the meter", func() {
+			So(m.drain(), ShouldBeEmpty)
+		})
+	})
+
+	Convey("Restored usage is merged with the usage metered since", t, func() {
+		m := newMeter()
+		m.add(day, rollup{Credential: "foo", counts: counts{Requests: 1}})
+		failed := m.drain()
+		m.add(day, rollup{Credential: "foo", counts: counts{Requests: 2}})
+		m.restore(failed)
+
+		rollups := m.drain()
+		So(rollups, ShouldHaveLength, 1)
+		So(rollups[0].Requests, ShouldEqual, 3)
+	})
+
+	Convey("The pending usage of a credential spans its tenants", t, func() {
+		m := newMeter()
+		m.add(day, rollup{Credential: "foo", counts: counts{Requests: 1, Searches: 1}})
+		m.add(day, rollup{Credential: "foo", Tenant: "acme", counts: counts{Requests: 1, IndexedDocs: 5}})
+		m.add(day, rollup{Credential: "bar", counts: counts{Requests: 1, AnalyticsCalls: 1}})
+		m.add(day.Add(time.Hour), rollup{Credential: "foo", counts: counts{Requests: 1}})
+
+		So(m.pendingOf("2020-03-01", "foo"), ShouldResemble, counts{Requests: 2, Searches: 1, IndexedDocs: 5})
+		So(m.pendingOf("2020-03-01", "baz"), ShouldResemble, counts{})
+	})
+	Convey("The credential type is kept once known", t, func() {
+		m := newMeter()
+		m.add(day, rollup{Credential: "foo", counts: counts{Requests: 1}})
+		m.add(day, rollup{Credential: "foo", CredentialType: typePermission, counts: counts{Requests: 1}})
+		m.add(day, rollup{Credential: "foo", counts: counts{Requests: 1}})
+
+		rollups := m.drain()
+		So(rollups, ShouldHaveLength, 1)
+		So(rollups[0].CredentialType, ShouldEqual, typePermission)
+	})
+}
+
+func TestByCredential(t *testing.T) {
+	Convey("The usage is summed per credential and per type of credential", t, func() {
+		report := byCredential("2020-03-01", "2020-03-02", []rollup{
+			{Day: "2020-03-01", Credential: "foo", CredentialType: typeUser, counts: counts{Requests: 2, Searches: 1}},
+			{Day: "2020-03-02", Credential: "foo", Tenant: "acme", counts: counts{Requests: 1, Searches: 1}},
+			{Day: "2020-03-01", Credential: "key", CredentialType: typePermission, counts: counts{Requests: 5, Searches: 5}},
+			{Day: "2020-03-01", Credential: typeAnonymous, CredentialType: typeAnonymous, counts: counts{Requests: 1}},
+			{Day: "2020-03-01", Credential: "old", counts: counts{Requests: 1, Searches: 1}},
+		})
+
+		So(report.Types, ShouldResemble, map[string]counts{
+			typeUser:       {Requests: 3, Searches: 2},
+			typePermission: {Requests: 5, Searches: 5},
+			typeAnonymous:  {Requests: 1},
+			"unknown":      {Requests: 1, Searches: 1},
+		})
+		So(report.Credentials, ShouldHaveLength, 4)
+		So(report.Credentials[0].Credential, ShouldEqual, "key")
+		So(report.Credentials[1].Credential, ShouldEqual, "foo")
+		So(report.Credentials[1].CredentialType, ShouldEqual, typeUser)
+		So(report.Credentials[2].Credential, ShouldEqual, "old")
+		So(report.Credentials[3].Credential, ShouldEqual, typeAnonymous)
+	})
+}
@@ -0,0 +1,174 @@
+package usage
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/classify"
+	"github.com/appbaseio/arc/middleware/tenancy"
+	"github.com/appbaseio/arc/middleware/validate"
+	"github.com/appbaseio/arc/model/acl"
+	"github.com/appbaseio/arc/model/category"
+	"github.com/appbaseio/arc/model/credential"
+	"github.com/appbaseio/arc/model/op"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/util"
+)
+
+// anonymous is the credential the requests made without credentials are metered against.
+const anonymous = "anonymous"
+
+// Types of the credentials the requests are made with.
+const (
+	typeUser       = "user"
+	typePermission = "permission"
+	typeAnonymous  = anonymous
+)
+
+type chain struct {
+	middleware.Fifo
+}
+
+func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return c.Adapt(h, list()...)
+}
+
+func list() []middleware.Middleware {
+	return []middleware.Middleware{
+		classifyCategory,
+		classifyACL,
+		classify.Op(),
+		classify.Indices(),
+		logs.Recorder(),
+		auth.BasicAuth(),
+		validate.Indices(),
+		validate.Operation(),
+		validate.Category(),
+		validate.ACL(),
+	}
+}
+
+func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clustersCategory := category.Clusters
+		ctx := category.NewContext(req.Context(), &clustersCategory)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+func classifyACL(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		clusterACL := acl.Cluster
+		ctx := acl.NewContext(req.Context(), &clusterACL)
+		req = req.WithContext(ctx)
+		h(w, req)
+	}
+}
+
+// isAdmin only lets the admin users through since the usage spans all the credentials.
+var isAdmin = validate.Admin("access the usage")
+
+// Recorder returns the middleware metering the requests, for the plugins
+// serving the requests that aren't proxied to elasticsearch.
+func Recorder() middleware.Middleware {
+	return Instance().record
+}
+
+// record meters the request against its credential and tenant. The bytes of
+// the write requests are counted as ingested, each query of a multi search
+// counts as a search and the documents of a bulk request, approximated from
+// its number of lines, are counted as indexed.
+func (u *Usage) record(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+		usage := rollup{counts: counts{Requests: 1}}
+		usage.Credential, usage.CredentialType = credentialOf(req)
+		tenant, err := tenancy.FromRequest(req)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+		}
+		usage.Tenant = tenant
+
+		// the requests that aren't proxied to elasticsearch carry no acl
+		reqACL, _ := acl.FromContext(ctx)
+		reqOp, err := op.FromContext(ctx)
+		if err != nil {
+			log.Errorln(logTag, ":", err)
+		}
+
+		// the body is shared along the chain, it's the one forwarded to
+		// elasticsearch once the chain returns
+		if _, err := util.ReadRequestBody(req); err != nil {
+			log.Errorln(logTag, ":", err)
+			util.WriteBackBodyError(w, err)
+			return
+		}
+
+		tee := util.NewTeeResponseWriter(w, 0)
+		h(tee, req)
+
+		body, _ := util.ReadRequestBody(req)
+		lines := int64(bytes.Count(body, []byte{'\n'}))
+
+		if reqACL != nil && *reqACL == acl.Search {
+			usage.Searches = 1
+		}
+		if reqACL != nil && *reqACL == acl.Msearch {
+			usage.Searches = 1
+			if lines > 1 {
+				usage.Searches = lines / 2
+			}
+		}
+		if reqOp != nil && *reqOp == op.Write {
+			usage.IngestedBytes = int64(len(body))
+		}
+		if reqOp != nil && *reqOp == op.Write && reqACL != nil && req.Method != http.MethodDelete {
+			switch *reqACL {
+			case acl.Doc, acl.Create, acl.Index, acl.Update:
+				usage.IndexedDocs = 1
+			case acl.Bulk:
+				// the index, create and update actions span two lines
+				usage.IndexedDocs = lines / 2
+			}
+		}
+		if reqCategory, err := category.FromContext(ctx); err == nil && *reqCategory == category.Analytics {
+			usage.AnalyticsCalls = 1
+		}
+		if tee.Code() >= http.StatusBadRequest {
+			usage.Errors = 1
+		}
+		u.meter.add(time.Now(), usage)
+	}
+}
+
+// credentialOf returns the username and the type of the credential the
+// request is made with. The type is only known once the request is
+// authenticated, the requests metered before are left untyped unless they
+// carry no credentials.
+func credentialOf(req *http.Request) (string, string) {
+	ctx := req.Context()
+	if reqCredential, err := credential.FromContext(ctx); err == nil {
+		switch reqCredential {
+		case credential.User:
+			if reqUser, err := user.FromContext(ctx); err == nil {
+				return reqUser.Username, typeUser
+			}
+		case credential.Permission:
+			if reqPermission, err := permission.FromContext(ctx); err == nil {
+				return reqPermission.Username, typePermission
+			}
+		}
+	}
+	if username, _, ok := req.BasicAuth(); ok && username != "" {
+		return username, ""
+	}
+	return anonymous, typeAnonymous
+}
@@ -0,0 +1,28 @@
+package usage
+
+import (
+	"net/http"
+
+	"github.com/appbaseio/arc/plugins"
+)
+
+func (u *Usage) routes() []plugins.Route {
+	middleware := (&chain{}).Wrap
+	routes := []plugins.Route{
+		{
+			Name:        "Get usage",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_usage",
+			HandlerFunc: middleware(isAdmin(u.getUsage())),
+			Description: "Returns the daily usage per credential and tenant",
+		},
+		{
+			Name:        "Get usage per credential",
+			Methods:     []string{http.MethodGet},
+			Path:        "/_usage/credentials",
+			HandlerFunc: middleware(isAdmin(u.getCredentialsUsage())),
+			Description: "Returns the usage per credential and per type of credential, the credentials with the most searches first",
+		},
+	}
+	return routes
+}
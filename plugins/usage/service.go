@@ -0,0 +1,66 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// flushEvery flushes the metered usage to the rollup index at every interval.
+func (u *Usage) flushEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		u.flush(context.Background())
+	}
+}
+
+// flush writes the metered usage to the rollup index, the usage of the rollups
+// that can't be updated is retained for the next flush.
+func (u *Usage) flush(ctx context.Context) {
+	rollups := u.meter.drain()
+	if len(rollups) == 0 {
+		return
+	}
+	failed, err := u.es.flush(ctx, rollups)
+	if err != nil {
+		log.Errorln(logTag, ": unable to flush the usage :", err)
+		u.meter.restore(failed)
+	}
+}
+
+// Counters are the usage of a credential on the current day.
+type Counters struct {
+	Day            string `json:"day"`
+	Requests       int64  `json:"requests"`
+	Searches       int64  `json:"searches"`
+	IndexedDocs    int64  `json:"indexed_docs"`
+	AnalyticsCalls int64  `json:"analytics_calls"`
+}
+
+// Today returns the usage of the credential on the current day in UTC,
+// across its tenants. The usage metered by this instance is included live,
+// the usage metered by the other instances as of their last flush. Nil is
+// returned if the usage plugin isn't loaded.
+func (u *Usage) Today(ctx context.Context, credential string) (*Counters, error) {
+	if u.es == nil {
+		return nil, nil
+	}
+	day := time.Now().UTC().Format(dayLayout)
+	rollups, err := u.es.rollups(ctx, day, day, credential, "")
+	if err != nil {
+		return nil, err
+	}
+	total := u.meter.pendingOf(day, credential)
+	for _, r := range rollups {
+		total.add(r.counts)
+	}
+	return &Counters{
+		Day:            day,
+		Requests:       total.Requests,
+		Searches:       total.Searches,
+		IndexedDocs:    total.IndexedDocs,
+		AnalyticsCalls: total.AnalyticsCalls,
+	}, nil
+}
@@ -0,0 +1,100 @@
+package usage
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/plugins"
+)
+
+const (
+	logTag               = "[usage]"
+	defaultUsageEsIndex  = ".usage"
+	envUsageEsIndex      = "USAGE_ES_INDEX"
+	envFlushInterval     = "USAGE_FLUSH_INTERVAL"
+	defaultFlushInterval = time.Minute
+	mappings             = `
+	{
+	  "properties": {
+	    "day": { "type": "date", "format": "yyyy-MM-dd" },
+	    "credential": { "type": "keyword" },
+	    "credential_type": { "type": "keyword" },
+	    "tenant": { "type": "keyword" },
+	    "requests": { "type": "long" },
+	    "searches": { "type": "long" },
+	    "ingested_bytes": { "type": "long" },
+	    "indexed_docs": { "type": "long" },
+	    "analytics_calls": { "type": "long" },
+	    "errors": { "type": "long" }
+	  }
+	}`
+)
+
+var (
+	singleton *Usage
+	once      sync.Once
+)
+
+// Usage plugin meters the requests made to elasticsearch per credential and
+// tenant per day, the daily rollups are reported for billing and chargeback.
+type Usage struct {
+	es    *elasticsearch
+	meter *meter
+}
+
+// Instance returns the singleton instance of Usage plugin.
+// Note: Only this function must be used (both within and outside the package) to
+// obtain the instance Usage in order to avoid stateless instances of the plugin.
+func Instance() *Usage {
+	once.Do(func() { singleton = &Usage{meter: newMeter()} })
+	return singleton
+}
+
+// Name returns the name of the plugin: "[usage]"
+func (u *Usage) Name() string {
+	return logTag
+}
+
+// InitFunc initializes the rollup index and starts flushing the metered
+// usage to it periodically.
+func (u *Usage) InitFunc() error {
+	log.Println(logTag, ": initializing plugin")
+
+	indexName := os.Getenv(envUsageEsIndex)
+	if indexName == "" {
+		indexName = defaultUsageEsIndex
+	}
+
+	interval := defaultFlushInterval
+	if value := os.Getenv(envFlushInterval); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			log.Errorln(logTag, ": invalid value for", envFlushInterval, ":", value)
+		} else {
+			interval = d
+		}
+	}
+
+	var err error
+	u.es, err = initPlugin(indexName, mappings)
+	if err != nil {
+		return err
+	}
+
+	go u.flushEvery(interval)
+	return nil
+}
+
+// Routes returns the usage report routes.
+func (u *Usage) Routes() []plugins.Route {
+	return u.routes()
+}
+
+// ESMiddleware meters the requests made to elasticsearch.
+func (u *Usage) ESMiddleware() []middleware.Middleware {
+	return []middleware.Middleware{u.record}
+}
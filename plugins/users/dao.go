@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
+	es7 "github.com/olivere/elastic/v7"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/model/user"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/embedded"
+	"github.com/appbaseio/arc/util/migration"
+	"github.com/appbaseio/arc/util/occ"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -23,29 +28,25 @@ func initPlugin(indexName, mapping string) (*elasticsearch, error) {
 	es := &elasticsearch{indexName}
 	defer func() {
 		if es != nil {
-			if err := es.postMasterUser(); err != nil {
+			if err := postMasterUser(es); err != nil {
 				log.Errorln(logTag, ":", err)
 			}
 		}
 	}()
 
-	// Check if the meta index already exists
-	exists, err := util.GetClient7().IndexExists(indexName).
+	// Check if elasticsearch is reachable
+	_, err := util.GetClient7().IndexExists(indexName).
 		Do(ctx)
+	if err != nil && embedded.Enabled() && util.IsUnreachable(err) {
+		// the index is assumed to exist since it was set up when the
+		// embedded store was populated, which serves the credentials meanwhile
+		log.Warnln(logTag, ": elasticsearch is unreachable, skipping the setup of index", indexName)
+		return es, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: error while checking if index already exists: %v",
 			logTag, err)
 	}
-	if exists {
-		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
-		// hash the passwords if not hashed already
-		err := es.hashPasswords()
-		if err != nil {
-			return nil, err
-		}
-
-		return es, nil
-	}
 
 	// set the number_of_replicas to (nodes-1)
 	nodes, err := util.GetTotalNodes()
@@ -53,31 +54,46 @@ func initPlugin(indexName, mapping string) (*elasticsearch, error) {
 		return nil, err
 	}
 	settings := fmt.Sprintf(mapping, nodes, nodes-1)
-	// Meta index does not exists, create a new one
-	_, err = util.GetClient7().CreateIndex(indexName).
-		Body(settings).
-		Do(ctx)
+
+	// create the meta index, or migrate it if its body changed
+	created, err := migration.Ensure(ctx, migration.Index{
+		Name:    indexName,
+		Version: indexVersion,
+		Body:    settings,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("%s: error while creating index named %s: %v",
-			logTag, indexName, err)
+		return nil, err
+	}
+	if !created {
+		log.Println(logTag, ": index named", indexName, "already exists, skipping...")
+		// hash the passwords if not hashed already
+		if err := hashPasswords(es); err != nil {
+			return nil, err
+		}
+		return es, nil
 	}
 
 	log.Println(logTag, ": successfully created index named", indexName)
 	return es, nil
 }
 
-func (es *elasticsearch) hashPasswords() error {
-	// get all users
-	rawUsers, err := es.getRawUsers(context.Background())
-	if err != nil {
-		return err
-	}
-
-	// unmarshal into list of users
+// hashPasswords hashes the passwords of the users stored in plain text.
+func hashPasswords(s userService) error {
+	// get all users, the deleted ones included
 	users := []user.User{}
-	err = json.Unmarshal(rawUsers, &users)
-	if err != nil {
-		return err
+	for _, deleted := range []bool{false, true} {
+		rawUsers, err := s.getRawUsers(context.Background(), deleted)
+		if err != nil {
+			return err
+		}
+
+		// unmarshal into list of users
+		var page []user.User
+		err = json.Unmarshal(rawUsers, &page)
+		if err != nil {
+			return err
+		}
+		users = append(users, page...)
 	}
 
 	for _, user := range users {
@@ -94,7 +110,7 @@ func (es *elasticsearch) hashPasswords() error {
 		}
 
 		// patch the user
-		_, err = es.patchUser(context.Background(), user.Username, map[string]interface{}{
+		_, err = s.patchUser(context.Background(), user.Username, map[string]interface{}{
 			"password":           string(hashedPassword),
 			"password_hash_type": "bcrypt",
 		})
@@ -109,7 +125,7 @@ func (es *elasticsearch) hashPasswords() error {
 	return nil
 }
 
-func (es *elasticsearch) postMasterUser() error {
+func postMasterUser(s userService) error {
 	// Create a master user, if credentials are not provided, we create a default
 	// master user. Arc shouldn't be initialized without a root user.
 	username, password := os.Getenv("USERNAME"), os.Getenv("PASSWORD")
@@ -131,7 +147,7 @@ func (es *elasticsearch) postMasterUser() error {
 
 	admin.PasswordHashType = "bcrypt"
 
-	if created, err := es.postUser(context.Background(), *admin); !created || err != nil {
+	if created, err := s.postUser(context.Background(), *admin); !created || err != nil {
 		return fmt.Errorf("%s: error while creating a master user: %v", logTag, err)
 	}
 	return nil
@@ -152,12 +168,13 @@ func (es *elasticsearch) getUser(ctx context.Context, username string) (*user.Us
 	return &u, nil
 }
 
-func (es *elasticsearch) getRawUsers(ctx context.Context) ([]byte, error) {
+// getRawUsers returns either the active or the soft deleted users.
+func (es *elasticsearch) getRawUsers(ctx context.Context, deleted bool) ([]byte, error) {
 	switch util.GetVersion() {
 	case 6:
-		return es.getRawUsersEs6(ctx)
+		return es.getRawUsersEs6(ctx, deleted)
 	default:
-		return es.getRawUsersEs7(ctx)
+		return es.getRawUsersEs7(ctx, deleted)
 	}
 }
 
@@ -170,6 +187,11 @@ func (es *elasticsearch) getRawUser(ctx context.Context, username string) ([]byt
 	}
 }
 
+// getRawUserVersion returns the user along with its revision.
+func (es *elasticsearch) getRawUserVersion(ctx context.Context, username string) ([]byte, occ.Version, error) {
+	return occ.Get(ctx, es.indexName, username)
+}
+
 func (es *elasticsearch) postUser(ctx context.Context, u user.User) (bool, error) {
 	_, err := util.GetClient7().Index().
 		Refresh("wait_for").
@@ -193,15 +215,43 @@ func (es *elasticsearch) patchUser(ctx context.Context, username string, patch m
 	}
 }
 
+// patchUserIfMatch patches the user if it is still at the revision, or
+// unconditionally if the revision is nil.
+func (es *elasticsearch) patchUserIfMatch(ctx context.Context, username string, patch map[string]interface{}, version *occ.Version) ([]byte, occ.Version, error) {
+	return occ.Update(ctx, es.indexName, username, patch, version)
+}
+
+// deleteUser soft deletes the user, the user is disabled until its deletion
+// is purged.
 func (es *elasticsearch) deleteUser(ctx context.Context, username string) (bool, error) {
-	_, err := util.GetClient7().Delete().
-		Refresh("wait_for").
-		Index(es.indexName).
-		Id(username).
-		Do(ctx)
+	_, err := es.patchUser(ctx, username, map[string]interface{}{
+		"disabled":   true,
+		"deleted_at": time.Now().UTC().Format(time.RFC3339),
+	})
 	if err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
+
+// restoreUser enables the soft deleted user again.
+func (es *elasticsearch) restoreUser(ctx context.Context, username string) ([]byte, error) {
+	return es.patchUser(ctx, username, map[string]interface{}{
+		"disabled":   false,
+		"deleted_at": nil,
+	})
+}
+
+// purgeDeletedUsers permanently removes the users deleted before the time.
+func (es *elasticsearch) purgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	response, err := util.GetClient7().DeleteByQuery(es.indexName).
+		Query(es7.NewRangeQuery("deleted_at").Lt(before.UTC().Format(time.RFC3339))).
+		Refresh("true").
+		Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return response.Deleted, nil
+}
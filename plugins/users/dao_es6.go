@@ -4,12 +4,21 @@ import (
 	"context"
 	"encoding/json"
 
+	es6 "gopkg.in/olivere/elastic.v6"
+
 	"github.com/appbaseio/arc/util"
 )
 
-func (es *elasticsearch) getRawUsersEs6(ctx context.Context) ([]byte, error) {
+func (es *elasticsearch) getRawUsersEs6(ctx context.Context, deleted bool) ([]byte, error) {
+	query := es6.NewBoolQuery()
+	if deleted {
+		query.Filter(es6.NewExistsQuery("deleted_at"))
+	} else {
+		query.MustNot(es6.NewExistsQuery("deleted_at"))
+	}
 	response, err := util.GetClient6().Search().
 		Index(es.indexName).
+		Query(query).
 		Do(ctx)
 
 	if err != nil {
@@ -4,12 +4,21 @@ import (
 	"context"
 	"encoding/json"
 
+	es7 "github.com/olivere/elastic/v7"
+
 	"github.com/appbaseio/arc/util"
 )
 
-func (es *elasticsearch) getRawUsersEs7(ctx context.Context) ([]byte, error) {
+func (es *elasticsearch) getRawUsersEs7(ctx context.Context, deleted bool) ([]byte, error) {
+	query := es7.NewBoolQuery()
+	if deleted {
+		query.Filter(es7.NewExistsQuery("deleted_at"))
+	} else {
+		query.MustNot(es7.NewExistsQuery("deleted_at"))
+	}
 	response, err := util.GetClient7().Search().
 		Index(es.indexName).
+		Query(query).
 		Do(ctx)
 
 	if err != nil {
@@ -0,0 +1,133 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util/occ"
+	"github.com/appbaseio/arc/util/storage"
+)
+
+// documents stores the users in the database of the storage backend.
+type documents struct {
+	docs *storage.Documents
+}
+
+func initDocuments(docs *storage.Documents) (*documents, error) {
+	d := &documents{docs}
+	if err := hashPasswords(d); err != nil {
+		return nil, err
+	}
+	if err := postMasterUser(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// users returns either the active or the soft deleted users.
+func (d *documents) users(ctx context.Context, deleted bool) ([]user.User, []json.RawMessage, error) {
+	docs, err := d.docs.List(ctx, storage.Users)
+	if err != nil {
+		return nil, nil, err
+	}
+	var users []user.User
+	var raws []json.RawMessage
+	for _, raw := range docs {
+		var u user.User
+		if err := json.Unmarshal(raw, &u); err != nil {
+			return nil, nil, err
+		}
+		if u.IsDeleted() == deleted {
+			users = append(users, u)
+			raws = append(raws, raw)
+		}
+	}
+	return users, raws, nil
+}
+
+func (d *documents) getRawUsers(ctx context.Context, deleted bool) ([]byte, error) {
+	_, raws, err := d.users(ctx, deleted)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(raws)
+}
+
+func (d *documents) getUser(ctx context.Context, username string) (*user.User, error) {
+	raw, err := d.getRawUser(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	var u user.User
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (d *documents) getRawUser(ctx context.Context, username string) ([]byte, error) {
+	raw, _, err := d.docs.Get(ctx, storage.Users, username)
+	return raw, err
+}
+
+func (d *documents) getRawUserVersion(ctx context.Context, username string) ([]byte, occ.Version, error) {
+	return d.docs.Get(ctx, storage.Users, username)
+}
+
+func (d *documents) postUser(ctx context.Context, u user.User) (bool, error) {
+	if err := d.docs.Put(ctx, storage.Users, u.Username, u); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *documents) patchUser(ctx context.Context, username string, patch map[string]interface{}) ([]byte, error) {
+	raw, _, err := d.docs.Update(ctx, storage.Users, username, patch, nil)
+	return raw, err
+}
+
+func (d *documents) patchUserIfMatch(ctx context.Context, username string, patch map[string]interface{}, version *occ.Version) ([]byte, occ.Version, error) {
+	return d.docs.Update(ctx, storage.Users, username, patch, version)
+}
+
+func (d *documents) deleteUser(ctx context.Context, username string) (bool, error) {
+	_, err := d.patchUser(ctx, username, map[string]interface{}{
+		"disabled":   true,
+		"deleted_at": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (d *documents) restoreUser(ctx context.Context, username string) ([]byte, error) {
+	return d.patchUser(ctx, username, map[string]interface{}{
+		"disabled":   false,
+		"deleted_at": nil,
+	})
+}
+
+func (d *documents) purgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	users, _, err := d.users(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+	var purged int64
+	for _, u := range users {
+		deletedAt, err := time.Parse(time.RFC3339, u.DeletedAt)
+		if err != nil || !deletedAt.Before(before) {
+			continue
+		}
+		deleted, err := d.docs.Delete(ctx, storage.Users, u.Username)
+		if err != nil {
+			return purged, err
+		}
+		if deleted {
+			purged++
+		}
+	}
+	return purged, nil
+}
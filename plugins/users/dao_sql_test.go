@@ -0,0 +1,62 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util/storage"
+)
+
+func TestDocuments(t *testing.T) {
+	ctx := context.Background()
+	docs, err := storage.Open(storage.SQLite, filepath.Join(t.TempDir(), "arc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer docs.Close()
+	d := &documents{docs}
+
+	Convey("The soft deleted users are listed apart and purged", t, func() {
+		for _, username := range []string{"foo", "bar"} {
+			_, err := d.postUser(ctx, user.User{Username: username})
+			So(err, ShouldBeNil)
+		}
+		_, err := d.deleteUser(ctx, "bar")
+		So(err, ShouldBeNil)
+
+		var active, deleted []user.User
+		raw, err := d.getRawUsers(ctx, false)
+		So(err, ShouldBeNil)
+		So(json.Unmarshal(raw, &active), ShouldBeNil)
+		So(active, ShouldHaveLength, 1)
+		So(active[0].Username, ShouldEqual, "foo")
+		raw, _ = d.getRawUsers(ctx, true)
+		So(json.Unmarshal(raw, &deleted), ShouldBeNil)
+		So(deleted, ShouldHaveLength, 1)
+		So(deleted[0].Disabled, ShouldBeTrue)
+
+		purged, err := d.purgeDeletedUsers(ctx, time.Now().Add(-time.Hour))
+		So(err, ShouldBeNil)
+		So(purged, ShouldEqual, 0)
+		purged, err = d.purgeDeletedUsers(ctx, time.Now().Add(time.Hour))
+		So(err, ShouldBeNil)
+		So(purged, ShouldEqual, 1)
+	})
+
+	Convey("The restored users are active again", t, func() {
+		_, err := d.deleteUser(ctx, "foo")
+		So(err, ShouldBeNil)
+		_, err = d.restoreUser(ctx, "foo")
+		So(err, ShouldBeNil)
+		u, err := d.getUser(ctx, "foo")
+		So(err, ShouldBeNil)
+		So(u.IsDeleted(), ShouldBeFalse)
+		So(u.Disabled, ShouldBeFalse)
+	})
+}
@@ -56,6 +56,8 @@ var defaultUser = map[string]interface{}{
 		"templates",
 		"suggestions",
 		"auth",
+		"logs",
+		"reindex",
 	},
 	"acls": []string{
 		"reindex",
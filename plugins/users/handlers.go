@@ -1,16 +1,21 @@
 package users
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"strconv"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/model/acl"
 	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/usage"
 	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/notify"
+	"github.com/appbaseio/arc/util/occ"
 	"github.com/gorilla/mux"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -29,7 +34,7 @@ func (u *Users) getUser() http.HandlerFunc {
 				util.WriteBackError(w, msg, http.StatusInternalServerError)
 				return
 			}
-			util.WriteBackRaw(w, rawUser, http.StatusOK)
+			util.WriteBackRaw(w, withUsage(ctx, reqUser.Username, rawUser), http.StatusOK)
 			return
 		}
 
@@ -41,11 +46,35 @@ func (u *Users) getUser() http.HandlerFunc {
 			util.WriteBackError(w, msg, http.StatusNotFound)
 			return
 		}
-		util.WriteBackRaw(w, rawUser, http.StatusOK)
+		util.WriteBackRaw(w, withUsage(ctx, username, rawUser), http.StatusOK)
 		return
 	}
 }
 
+// withUsage adds the usage of the user on the current day to the user, so
+// that the users can keep track of their own consumption. The user is left
+// as is if the usage isn't metered or can't be fetched.
+func withUsage(ctx context.Context, username string, rawUser []byte) []byte {
+	counters, err := usage.Instance().Today(ctx, username)
+	if err != nil {
+		log.Errorln(logTag, ": unable to fetch the usage of user", username, ":", err)
+		return rawUser
+	}
+	if counters == nil {
+		return rawUser
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(rawUser, &obj); err != nil {
+		return rawUser
+	}
+	obj["usage"] = counters
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		return rawUser
+	}
+	return raw
+}
+
 func (u *Users) getUserWithUsername() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		vars := mux.Vars(req)
@@ -55,24 +84,25 @@ func (u *Users) getUserWithUsername() http.HandlerFunc {
 			return
 		}
 
-		rawUser, err := u.es.getRawUser(req.Context(), username)
+		rawUser, version, err := u.es.getRawUserVersion(req.Context(), username)
 		if err != nil {
 			msg := fmt.Sprintf(`user with "username"="%s" not found`, username)
 			log.Errorln(logTag, ":", msg, ":", err)
 			util.WriteBackError(w, msg, http.StatusNotFound)
 			return
 		}
+		occ.SetETag(w, version)
 		util.WriteBackRaw(w, rawUser, http.StatusOK)
 	}
 }
 
 func (u *Users) postUser() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		body, err := ioutil.ReadAll(req.Body)
+		body, err := util.ReadRequestBody(req)
 		if err != nil {
 			const msg = "can't read request body"
 			log.Errorln(logTag, ":", msg, ":", err)
-			util.WriteBackError(w, msg, http.StatusBadRequest)
+			util.WriteBackBodyError(w, err)
 			return
 		}
 
@@ -85,24 +115,6 @@ func (u *Users) postUser() http.HandlerFunc {
 			return
 		}
 
-		opts := []user.Options{
-			user.SetEmail(userBody.Email),
-		}
-		if userBody.IsAdmin != nil {
-			opts = append(opts, user.SetIsAdmin(*userBody.IsAdmin))
-		}
-		if userBody.Categories != nil {
-			opts = append(opts, user.SetCategories(userBody.Categories))
-		}
-		if userBody.ACLs != nil {
-			opts = append(opts, user.SetACLs(userBody.ACLs))
-		}
-		if userBody.Ops != nil {
-			opts = append(opts, user.SetOps(userBody.Ops))
-		}
-		if userBody.Indices != nil {
-			opts = append(opts, user.SetIndices(userBody.Indices))
-		}
 		if userBody.Username == "" {
 			util.WriteBackError(w, `can't create a user without a "username"`, http.StatusBadRequest)
 			return
@@ -112,20 +124,7 @@ func (u *Users) postUser() http.HandlerFunc {
 			return
 		}
 
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userBody.Password), bcrypt.DefaultCost)
-		if err != nil {
-			msg := fmt.Sprintf("an error occurred while hashing password: %v", userBody.Password)
-			log.Errorln(logTag, ":", msg, ":", err)
-			util.WriteBackError(w, msg, http.StatusInternalServerError)
-		}
-
-		var newUser *user.User
-		if userBody.IsAdmin != nil && *userBody.IsAdmin {
-			newUser, err = user.NewAdmin(userBody.Username, string(hashedPassword), opts...)
-		} else {
-			newUser, err = user.New(userBody.Username, string(hashedPassword), opts...)
-		}
-
+		newUser, err := buildUser(userBody)
 		if err != nil {
 			msg := fmt.Sprintf("an error occurred while creating user: %v", err)
 			log.Errorln(logTag, ":", msg, ":", err)
@@ -133,8 +132,6 @@ func (u *Users) postUser() http.HandlerFunc {
 			return
 		}
 
-		newUser.PasswordHashType = "bcrypt"
-
 		rawUser, err := json.Marshal(*newUser)
 		if err != nil {
 			msg := fmt.Sprintf(`an error occurred while creating a user with "username"="%s"`, userBody.Username)
@@ -145,6 +142,7 @@ func (u *Users) postUser() http.HandlerFunc {
 
 		ok, err := u.es.postUser(req.Context(), *newUser)
 		if ok && err == nil {
+			notifyUser(req, notify.UserCreated, newUser.Username, "created")
 			util.WriteBackRaw(w, rawUser, http.StatusCreated)
 			return
 		}
@@ -159,11 +157,11 @@ func (u *Users) patchUser() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		username, _, _ := req.BasicAuth()
 
-		body, err := ioutil.ReadAll(req.Body)
+		body, err := util.ReadRequestBody(req)
 		if err != nil {
 			msg := "can't read request body"
 			log.Errorln(logTag, ":", msg, ":", err)
-			util.WriteBackError(w, msg, http.StatusBadRequest)
+			util.WriteBackBodyError(w, err)
 			return
 		}
 
@@ -210,15 +208,7 @@ func (u *Users) patchUser() http.HandlerFunc {
 			}
 		}
 
-		raw, err := u.es.patchUser(req.Context(), username, patch)
-		if err == nil {
-			util.WriteBackRaw(w, raw, http.StatusOK)
-			return
-		}
-
-		msg := fmt.Sprintf(`user with "username"="%s" not found`, username)
-		log.Errorln(logTag, ":", msg, ":", err)
-		util.WriteBackError(w, msg, http.StatusNotFound)
+		u.applyPatch(w, req, username, patch)
 	}
 }
 
@@ -231,11 +221,11 @@ func (u *Users) patchUserWithUsername() http.HandlerFunc {
 			return
 		}
 
-		body, err := ioutil.ReadAll(req.Body)
+		body, err := util.ReadRequestBody(req)
 		if err != nil {
 			msg := "can't read request body"
 			log.Errorln(logTag, ":", msg, ":", err)
-			util.WriteBackError(w, msg, http.StatusBadRequest)
+			util.WriteBackBodyError(w, err)
 			return
 		}
 
@@ -281,32 +271,14 @@ func (u *Users) patchUserWithUsername() http.HandlerFunc {
 			}
 		}
 
-		raw, err := u.es.patchUser(req.Context(), username, patch)
-		if err == nil {
-			util.WriteBackRaw(w, raw, http.StatusOK)
-			return
-		}
-
-		msg := fmt.Sprintf(`user with "username"="%s" not found`, username)
-		log.Errorln(logTag, ":", msg, ":", err)
-		util.WriteBackError(w, msg, http.StatusNotFound)
+		u.applyPatch(w, req, username, patch)
 	}
 }
 
 func (u *Users) deleteUser() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		username, _, _ := req.BasicAuth()
-
-		ok, err := u.es.deleteUser(req.Context(), username)
-		if ok && err == nil {
-			msg := fmt.Sprintf(`user with "username"="%s" deleted`, username)
-			util.WriteBackMessage(w, msg, http.StatusOK)
-			return
-		}
-
-		msg := fmt.Sprintf(`user with "username"="%s" not found`, username)
-		log.Errorln(logTag, ":", msg, ":", err)
-		util.WriteBackError(w, msg, http.StatusNotFound)
+		u.softDelete(w, req, username)
 	}
 }
 
@@ -318,23 +290,107 @@ func (u *Users) deleteUserWithUsername() http.HandlerFunc {
 			util.WriteBackError(w, `can't delete a user without a "username"`, http.StatusBadRequest)
 			return
 		}
+		u.softDelete(w, req, username)
+	}
+}
 
-		ok, err := u.es.deleteUser(req.Context(), username)
-		if ok && err == nil {
-			msg := fmt.Sprintf(`user with "username"="%s" deleted`, username)
-			util.WriteBackMessage(w, msg, http.StatusOK)
-			return
-		}
+// applyPatch patches the user, only if the user is still at the revision of
+// the If-Match header of the request when set.
+func (u *Users) applyPatch(w http.ResponseWriter, req *http.Request, username string, patch map[string]interface{}) {
+	version, err := occ.FromRequest(req)
+	if err != nil {
+		util.WriteBackError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
+	raw, updated, err := u.es.patchUserIfMatch(req.Context(), username, patch, version)
+	if err == occ.ErrConflict {
+		msg := fmt.Sprintf(`user with "username"="%s" was modified since revision %s`, username, version.ETag())
+		util.WriteBackError(w, msg, http.StatusConflict)
+		return
+	}
+	if err == nil {
+		occ.SetETag(w, updated)
+		util.WriteBackRaw(w, raw, http.StatusOK)
+		return
+	}
+
+	msg := fmt.Sprintf(`user with "username"="%s" not found`, username)
+	log.Errorln(logTag, ":", msg, ":", err)
+	util.WriteBackError(w, msg, http.StatusNotFound)
+}
+
+// softDelete disables the user and marks it as deleted, the user can be
+// restored until its deletion is purged after the retention window.
+func (u *Users) softDelete(w http.ResponseWriter, req *http.Request, username string) {
+	ctx := req.Context()
+	existing, err := u.es.getUser(ctx, username)
+	if err != nil || existing.IsDeleted() {
 		msg := fmt.Sprintf(`user with "username"="%s" not found`, username)
 		log.Errorln(logTag, ":", msg, ":", err)
 		util.WriteBackError(w, msg, http.StatusNotFound)
+		return
+	}
+
+	ok, err := u.es.deleteUser(ctx, username)
+	if ok && err == nil {
+		auth.InvalidateCredential(ctx, username)
+		notifyUser(req, notify.UserDeleted, username, "deleted")
+		msg := fmt.Sprintf(`user with "username"="%s" deleted`, username)
+		util.WriteBackMessage(w, msg, http.StatusOK)
+		return
+	}
+
+	msg := fmt.Sprintf(`user with "username"="%s" not found`, username)
+	log.Errorln(logTag, ":", msg, ":", err)
+	util.WriteBackError(w, msg, http.StatusNotFound)
+}
+
+func (u *Users) restoreUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		username := mux.Vars(req)["username"]
+		ctx := req.Context()
+
+		existing, err := u.es.getUser(ctx, username)
+		if err != nil {
+			msg := fmt.Sprintf(`user with "username"="%s" not found`, username)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusNotFound)
+			return
+		}
+		if !existing.IsDeleted() {
+			msg := fmt.Sprintf(`user with "username"="%s" isn't deleted`, username)
+			util.WriteBackError(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		raw, err := u.es.restoreUser(ctx, username)
+		if err != nil {
+			msg := fmt.Sprintf(`an error occurred while restoring user with "username"="%s"`, username)
+			log.Errorln(logTag, ":", msg, ":", err)
+			util.WriteBackError(w, msg, http.StatusInternalServerError)
+			return
+		}
+		auth.InvalidateCredential(ctx, username)
+		notifyUser(req, notify.UserRestored, username, "restored")
+		util.WriteBackRaw(w, raw, http.StatusOK)
 	}
 }
 
 func (u *Users) getAllUsers() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
-		raw, err := u.es.getRawUsers(req.Context())
+		var deleted bool
+		if value := req.URL.Query().Get("deleted"); value != "" {
+			var err error
+			deleted, err = strconv.ParseBool(value)
+			if err != nil {
+				msg := fmt.Sprintf(`invalid value "%s" for query param "deleted"`, value)
+				util.WriteBackError(w, msg, http.StatusBadRequest)
+				return
+			}
+		}
+
+		raw, err := u.es.getRawUsers(req.Context(), deleted)
 		if err != nil {
 			msg := `an error occurred while fetching users`
 			log.Errorln(logTag, ":", err)
@@ -345,3 +401,60 @@ func (u *Users) getAllUsers() http.HandlerFunc {
 		util.WriteBackRaw(w, raw, http.StatusOK)
 	}
 }
+
+// notifyUser notifies the webhooks of the change made to the user.
+func notifyUser(req *http.Request, eventType notify.EventType, username, change string) {
+	actor, _, _ := req.BasicAuth()
+	notify.Send(notify.Event{
+		Type:     eventType,
+		Actor:    actor,
+		Resource: username,
+		Message:  fmt.Sprintf(`user with "username"="%s" %s by "%s"`, username, change, actor),
+	})
+}
+
+// buildUser creates the user described by the body of the request, with its
+// password hashed.
+func buildUser(userBody user.User) (*user.User, error) {
+	opts := []user.Options{
+		user.SetEmail(userBody.Email),
+	}
+	if userBody.IsAdmin != nil {
+		opts = append(opts, user.SetIsAdmin(*userBody.IsAdmin))
+	}
+	if userBody.Categories != nil {
+		opts = append(opts, user.SetCategories(userBody.Categories))
+	}
+	if userBody.ACLs != nil {
+		opts = append(opts, user.SetACLs(userBody.ACLs))
+	}
+	if userBody.Ops != nil {
+		opts = append(opts, user.SetOps(userBody.Ops))
+	}
+	if userBody.Indices != nil {
+		opts = append(opts, user.SetIndices(userBody.Indices))
+	}
+	if userBody.DeniedIndices != nil {
+		opts = append(opts, user.SetDeniedIndices(userBody.DeniedIndices))
+	}
+	if userBody.DeniedCategories != nil {
+		opts = append(opts, user.SetDeniedCategories(userBody.DeniedCategories))
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(userBody.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("unable to hash the password: %v", err)
+	}
+
+	var newUser *user.User
+	if userBody.IsAdmin != nil && *userBody.IsAdmin {
+		newUser, err = user.NewAdmin(userBody.Username, string(hashedPassword), opts...)
+	} else {
+		newUser, err = user.New(userBody.Username, string(hashedPassword), opts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	newUser.PasswordHashType = "bcrypt"
+	return newUser, nil
+}
@@ -27,7 +27,7 @@ func (c *chain) Wrap(h http.HandlerFunc) http.HandlerFunc {
 
 func list() []middleware.Middleware {
 	return []middleware.Middleware{
-		classifyCategory,
+		classify.Category(category.User),
 		classifyIndices,
 		logs.Recorder(),
 		classify.Op(),
@@ -37,17 +37,6 @@ func list() []middleware.Middleware {
 	}
 }
 
-func classifyCategory(h http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, req *http.Request) {
-		userCategory := category.User
-
-		ctx := category.NewContext(req.Context(), &userCategory)
-		req = req.WithContext(ctx)
-
-		h(w, req)
-	}
-}
-
 func classifyIndices(h http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		ctx := index.NewContext(req.Context(), []string{defaultUsersEsIndex})
@@ -67,10 +56,9 @@ func isAdmin(h http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		if !*reqUser.IsAdmin {
+		if !reqUser.Admin() {
 			msg := fmt.Sprintf(`user with "username"="%s" is not an admin`, reqUser.Username)
-			w.Header().Set("www-authenticate", "Basic realm=\"Authentication Required\"")
-			util.WriteBackError(w, msg, http.StatusUnauthorized)
+			util.WriteBackError(w, msg, http.StatusForbidden)
 			return
 		}
 
@@ -28,7 +28,7 @@ func (u *Users) routes() []plugins.Route {
 			Methods:     []string{http.MethodGet},
 			Path:        "/_users",
 			HandlerFunc: middleware(isAdmin(u.getAllUsers())),
-			Description: "Returns all the users",
+			Description: "Returns all the users, or the soft deleted ones with ?deleted=true",
 		},
 		{
 			Name:        "Post user",
@@ -65,6 +65,13 @@ func (u *Users) routes() []plugins.Route {
 			HandlerFunc: middleware(isAdmin(u.deleteUserWithUsername())),
 			Description: "Deletes the user with {username}",
 		},
+		{
+			Name:        "Restore user with {username}",
+			Methods:     []string{http.MethodPost},
+			Path:        "/_user/{username}/_restore",
+			HandlerFunc: middleware(isAdmin(u.restoreUser())),
+			Description: "Restores the soft deleted user with {username}",
+		},
 	}
 	return routes
 }
@@ -0,0 +1,45 @@
+package users
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/embedded"
+	"github.com/appbaseio/arc/util/seed"
+)
+
+// seedUsers creates the users of the seed file that don't exist yet, the
+// existing users are left untouched so that their later edits persist.
+func (u *Users) seedUsers(ctx context.Context) error {
+	f, err := seed.Load()
+	if err != nil || f == nil {
+		return err
+	}
+	for _, userBody := range f.Users {
+		_, err := u.es.getRawUser(ctx, userBody.Username)
+		if err == nil {
+			log.Println(logTag, ": seed user", userBody.Username, "already exists, skipping...")
+			continue
+		}
+		if util.IsUnreachable(err) && embedded.Enabled() {
+			log.Warnln(logTag, ": elasticsearch is unreachable, skipping the seed users")
+			return nil
+		}
+		if !util.IsNotFound(err) {
+			return fmt.Errorf("%s: unable to check whether the seed user %s exists: %v", logTag, userBody.Username, err)
+		}
+
+		newUser, err := buildUser(userBody)
+		if err != nil {
+			return fmt.Errorf("%s: invalid seed user %s: %v", logTag, userBody.Username, err)
+		}
+		if _, err := u.es.postUser(ctx, *newUser); err != nil {
+			return fmt.Errorf("%s: unable to create the seed user %s: %v", logTag, userBody.Username, err)
+		}
+		log.Println(logTag, ": created the seed user", userBody.Username)
+	}
+	return nil
+}
@@ -2,14 +2,21 @@ package users
 
 import (
 	"context"
+	"time"
+
 	"github.com/appbaseio/arc/model/user"
+	"github.com/appbaseio/arc/util/occ"
 )
 
 type userService interface {
-	getRawUsers(ctx context.Context) ([]byte, error)
+	getRawUsers(ctx context.Context, deleted bool) ([]byte, error)
 	getUser(ctx context.Context, username string) (*user.User, error)
 	getRawUser(ctx context.Context, username string) ([]byte, error)
+	getRawUserVersion(ctx context.Context, username string) ([]byte, occ.Version, error)
 	postUser(ctx context.Context, u user.User) (bool, error)
 	patchUser(ctx context.Context, username string, patch map[string]interface{}) ([]byte, error)
+	patchUserIfMatch(ctx context.Context, username string, patch map[string]interface{}, version *occ.Version) ([]byte, occ.Version, error)
 	deleteUser(ctx context.Context, username string) (bool, error)
+	restoreUser(ctx context.Context, username string) ([]byte, error)
+	purgeDeletedUsers(ctx context.Context, before time.Time) (int64, error)
 }
@@ -1,11 +1,16 @@
 package users
 
 import (
+	"context"
 	"os"
 	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
 
 	"github.com/appbaseio/arc/middleware"
 	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/util/storage"
 )
 
 const (
@@ -15,6 +20,16 @@ const (
 	envEsURL            = "ES_CLUSTER_URL"
 	defaultUsersEsIndex = ".users"
 	settings            = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+	// indexVersion is the version of the body of the index, it must be
+	// bumped along with the settings in order to migrate the index.
+	indexVersion = 1
+)
+
+// The soft deleted users are purged once deleted for longer than the retention.
+const (
+	envDeletedRetention     = "USERS_DELETED_RETENTION"
+	defaultDeletedRetention = 30 * 24 * time.Hour
+	purgeInterval           = time.Hour
 )
 
 var (
@@ -48,14 +63,51 @@ func (u *Users) InitFunc() error {
 		indexName = defaultUsersEsIndex
 	}
 
-	// initialize the dao
-	var err error
-	u.es, err = initPlugin(indexName, settings)
+	// initialize the dao, the users are kept either in elasticsearch or
+	// in the database of the storage backend
+	docs, err := storage.Instance()
 	if err != nil {
 		return err
 	}
+	if docs != nil {
+		u.es, err = initDocuments(docs)
+	} else {
+		u.es, err = initPlugin(indexName, settings)
+	}
+	if err != nil {
+		return err
+	}
+
+	retention := defaultDeletedRetention
+	if value := os.Getenv(envDeletedRetention); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			log.Errorln(logTag, ": invalid value for", envDeletedRetention, ":", value)
+		} else {
+			retention = d
+		}
+	}
+	go u.purgeEvery(retention)
 
-	return nil
+	// create the users declared in the seed file on the first run
+	return u.seedUsers(context.Background())
+}
+
+// purgeEvery permanently removes the users soft deleted for longer than the
+// retention window, every purge interval.
+func (u *Users) purgeEvery(retention time.Duration) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		purged, err := u.es.purgeDeletedUsers(context.Background(), time.Now().Add(-retention))
+		if err != nil {
+			log.Errorln(logTag, ": unable to purge the deleted users:", err)
+			continue
+		}
+		if purged > 0 {
+			log.Println(logTag, ": purged", purged, "users deleted for longer than", retention)
+		}
+	}
 }
 
 // Routes is the implementation of plugin interface.
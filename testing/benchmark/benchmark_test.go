@@ -0,0 +1,108 @@
+package benchmark
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const (
+	searchBody  = `{"query":{"match":{"title":"arc"}},"size":10}`
+	msearchBody = `{"index":"products"}` + "\n" + searchBody + "\n" + `{"index":"products"}` + "\n" + searchBody + "\n"
+	indexBody   = `{"title":"arc","price":10}`
+)
+
+func harnessOf(tb testing.TB) *Harness {
+	h, err := Setup()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return h
+}
+
+func serve(b *testing.B, newRequest func(h *Harness) *http.Request) {
+	h := harnessOf(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			w := httptest.NewRecorder()
+			h.Handler.ServeHTTP(w, newRequest(h))
+			if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+				b.Fatalf("unexpected response %d: %s", w.Code, w.Body.String())
+			}
+		}
+	})
+}
+
+func TestHarness(t *testing.T) {
+	h := harnessOf(t)
+
+	Convey("The searches are proxied to the stub", t, func() {
+		before := h.Stub.Requests()
+		w := httptest.NewRecorder()
+		h.Handler.ServeHTTP(w, h.Request(http.MethodPost, "/products/_search", searchBody))
+		So(w.Code, ShouldEqual, http.StatusOK)
+		So(w.Body.String(), ShouldContainSubstring, `"hits"`)
+		So(h.Stub.Requests(), ShouldBeGreaterThan, before)
+	})
+
+	Convey("The requests without credentials are rejected", t, func() {
+		req := httptest.NewRequest(http.MethodPost, "/products/_search", strings.NewReader(searchBody))
+		w := httptest.NewRecorder()
+		h.Handler.ServeHTTP(w, req)
+		So(w.Code, ShouldEqual, http.StatusUnauthorized)
+	})
+
+	Convey("The load is served and reported", t, func() {
+		r := Run(context.Background(), h.Handler, Load{
+			Concurrency: 4,
+			Requests:    40,
+			Request: func(int) *http.Request {
+				return h.Request(http.MethodPost, "/products/_search", searchBody)
+			},
+		})
+		So(r.Requests, ShouldEqual, 40)
+		So(r.Failures, ShouldEqual, 0)
+		So(r.P50, ShouldBeLessThanOrEqualTo, r.P99)
+		So(r.P99, ShouldBeLessThanOrEqualTo, r.Max)
+	})
+
+	Convey("The requests left once the context is done aren't sent", t, func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		<-ctx.Done()
+		r := Run(ctx, h.Handler, Load{
+			Requests: 10,
+			Request: func(int) *http.Request {
+				return h.Request(http.MethodPost, "/products/_search", searchBody)
+			},
+		})
+		So(r.Requests, ShouldEqual, 0)
+	})
+}
+
+func BenchmarkSearch(b *testing.B) {
+	serve(b, func(h *Harness) *http.Request {
+		return h.Request(http.MethodPost, "/products/_search", searchBody)
+	})
+}
+
+func BenchmarkMsearch(b *testing.B) {
+	serve(b, func(h *Harness) *http.Request {
+		req := h.Request(http.MethodPost, "/_msearch", msearchBody)
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		return req
+	})
+}
+
+func BenchmarkIndex(b *testing.B) {
+	serve(b, func(h *Harness) *http.Request {
+		return h.Request(http.MethodPost, "/products/_doc", indexBody)
+	})
+}
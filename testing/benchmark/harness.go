@@ -0,0 +1,139 @@
+// Package benchmark measures the hot path of arc, i.e. the requests proxied
+// to elasticsearch through the whole middleware chain: the classifiers, the
+// request logs recorder, the authentication and the proxy. The plugins are
+// loaded in process, in front of a synthetic elasticsearch cluster and with
+// the credentials kept in sqlite, so the benchmarks run without any service.
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/middleware"
+	"github.com/appbaseio/arc/middleware/deadline"
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/plugins"
+	"github.com/appbaseio/arc/plugins/auth"
+	"github.com/appbaseio/arc/plugins/elasticsearch"
+	"github.com/appbaseio/arc/plugins/logs"
+	"github.com/appbaseio/arc/plugins/permissions"
+	"github.com/appbaseio/arc/plugins/users"
+	"github.com/appbaseio/arc/util"
+)
+
+// Credentials of the master user of the harness.
+const (
+	masterUsername = "bench"
+	masterPassword = "bench"
+)
+
+// permissionBody lifts the rate limits of the categories benchmarked, the
+// limiter is still on the path of the requests.
+const permissionBody = `{"limits": {"ip_limit": 1000000000, "docs_limit": 1000000000, "search_limit": 1000000000, "indices_limit": 1000000000}}`
+
+// Harness is arc loaded in process in front of a stub cluster.
+type Harness struct {
+	// Handler serves the requests as arc does.
+	Handler http.Handler
+	// Stub is the cluster the requests are proxied to.
+	Stub *Stub
+	// Permission is the credential the requests are made with, as the
+	// clients searching elasticsearch through arc do.
+	Permission permission.Permission
+}
+
+var (
+	harness    *Harness
+	harnessErr error
+	setupOnce  sync.Once
+)
+
+// Setup loads the plugins of the hot path and returns the harness serving
+// them. The plugins being singletons, they're loaded once per process and
+// the same harness is returned to every caller.
+func Setup() (*Harness, error) {
+	setupOnce.Do(func() {
+		harness, harnessErr = setup()
+	})
+	return harness, harnessErr
+}
+
+func setup() (*Harness, error) {
+	dir, err := ioutil.TempDir("", "arc-benchmark")
+	if err != nil {
+		return nil, err
+	}
+	stub := NewStub()
+	env := map[string]string{
+		"ES_CLUSTER_URL":  stub.URL,
+		"USERNAME":        masterUsername,
+		"PASSWORD":        masterPassword,
+		"STORAGE_BACKEND": "sqlite",
+		"STORAGE_DSN":     filepath.Join(dir, "arc.db"),
+	}
+	for key, value := range env {
+		if err := os.Setenv(key, value); err != nil {
+			return nil, err
+		}
+	}
+	// the logs of every request would dominate the measures
+	log.SetLevel(log.FatalLevel)
+
+	util.NewClient()
+	router := mux.NewRouter().StrictSlash(true)
+	mw := make([]middleware.Middleware, 0)
+	for _, p := range []plugins.Plugin{users.Instance(), permissions.Instance(), auth.Instance(), logs.Instance()} {
+		if err := plugins.LoadPlugin(router, p); err != nil {
+			stub.Close()
+			return nil, fmt.Errorf("unable to load the plugin %s: %v", p.Name(), err)
+		}
+		mw = append(mw, p.ESMiddleware()...)
+	}
+	if err := plugins.LoadESPlugin(router, elasticsearch.Instance(), mw); err != nil {
+		stub.Close()
+		return nil, fmt.Errorf("unable to load the plugin %s: %v", elasticsearch.Instance().Name(), err)
+	}
+	h := &Harness{Handler: deadline.Handler(router), Stub: stub}
+
+	// the users are authenticated against their bcrypt hash, which would
+	// outweigh the rest of the chain, whereas the permissions aren't
+	req := newRequest(http.MethodPost, "/_permission", permissionBody)
+	req.SetBasicAuth(masterUsername, masterPassword)
+	w := httptest.NewRecorder()
+	h.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		stub.Close()
+		return nil, fmt.Errorf("unable to create the permission: %d %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &h.Permission); err != nil {
+		stub.Close()
+		return nil, fmt.Errorf("unable to parse the permission created: %v", err)
+	}
+	return h, nil
+}
+
+// Request returns a request authenticated with the permission of the harness.
+func (h *Harness) Request(method, path, body string) *http.Request {
+	req := newRequest(method, path, body)
+	req.SetBasicAuth(h.Permission.Username, h.Permission.Password)
+	return req
+}
+
+func newRequest(method, path, body string) *http.Request {
+	if body == "" {
+		return httptest.NewRequest(method, path, nil)
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
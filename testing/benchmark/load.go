@@ -0,0 +1,110 @@
+package benchmark
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Load describes the requests generated against the handler.
+type Load struct {
+	// Concurrency is the number of clients sending requests, one if unset.
+	Concurrency int
+	// Requests is the number of requests sent across the clients.
+	Requests int
+	// Request returns the i-th request to send.
+	Request func(i int) *http.Request
+}
+
+// Report summarizes the requests served under load.
+type Report struct {
+	Requests int
+	// Failures counts the responses with a status of 400 or above.
+	Failures int
+	Duration time.Duration
+	P50      time.Duration
+	P90      time.Duration
+	P99      time.Duration
+	Max      time.Duration
+}
+
+// Throughput returns the number of requests served per second.
+func (r Report) Throughput() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / r.Duration.Seconds()
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("%d requests, %d failures in %s (%.0f req/s), p50=%s p90=%s p99=%s max=%s",
+		r.Requests, r.Failures, r.Duration, r.Throughput(), r.P50, r.P90, r.P99, r.Max)
+}
+
+// Run serves the requests of the load with the handler, the requests are
+// served in process so that the measures only cover arc and the stub. The
+// requests left once the context is done aren't sent.
+func Run(ctx context.Context, h http.Handler, l Load) Report {
+	concurrency := l.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	latencies := make([]time.Duration, l.Requests)
+	var next, sent, failures int64
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for c := 0; c < concurrency; c++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(atomic.AddInt64(&next, 1) - 1)
+				if i >= l.Requests {
+					return
+				}
+				req := l.Request(i).WithContext(ctx)
+				w := httptest.NewRecorder()
+				began := time.Now()
+				h.ServeHTTP(w, req)
+				latencies[i] = time.Since(began)
+				atomic.AddInt64(&sent, 1)
+				if w.Code >= http.StatusBadRequest {
+					atomic.AddInt64(&failures, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	r := Report{Requests: int(sent), Failures: int(failures), Duration: time.Since(start)}
+	served := make([]time.Duration, 0, sent)
+	for _, latency := range latencies {
+		if latency > 0 {
+			served = append(served, latency)
+		}
+	}
+	if len(served) == 0 {
+		return r
+	}
+	sort.Slice(served, func(i, j int) bool { return served[i] < served[j] })
+	r.P50 = percentile(served, 50)
+	r.P90 = percentile(served, 90)
+	r.P99 = percentile(served, 99)
+	r.Max = served[len(served)-1]
+	return r
+}
+
+// percentile returns the p-th percentile of the sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
@@ -0,0 +1,70 @@
+package benchmark
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+)
+
+// Version is the elasticsearch version the stub reports.
+const Version = "7.10.2"
+
+// SearchResponse is the response of the stub to the searches.
+const SearchResponse = `{"took":1,"timed_out":false,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0},"hits":{"total":{"value":1,"relation":"eq"},"max_score":1.0,"hits":[{"_index":"products","_type":"_doc","_id":"1","_score":1.0,"_source":{"title":"arc","price":10}}]}}`
+
+// Stub is a synthetic elasticsearch cluster answering every request with a
+// canned response, so that the time spent in arc can be measured without
+// the variance of a real cluster.
+type Stub struct {
+	*httptest.Server
+	requests int64
+}
+
+// NewStub starts a stub cluster, which must be closed once done with.
+func NewStub() *Stub {
+	s := &Stub{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Requests returns the number of requests the stub has served.
+func (s *Stub) Requests() int64 {
+	return atomic.LoadInt64(&s.requests)
+}
+
+func (s *Stub) serve(w http.ResponseWriter, req *http.Request) {
+	atomic.AddInt64(&s.requests, 1)
+	io.Copy(ioutil.Discard, req.Body)
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+
+	path := strings.TrimSuffix(req.URL.Path, "/")
+	switch {
+	case path == "":
+		io.WriteString(w, `{"name":"stub","cluster_name":"benchmark","version":{"number":"`+Version+`","build_flavor":"default"},"tagline":"You Know, for Search"}`)
+	case strings.HasSuffix(path, "/_msearch"):
+		io.WriteString(w, `{"took":1,"responses":[`+SearchResponse+`]}`)
+	case strings.HasSuffix(path, "/_search"):
+		io.WriteString(w, SearchResponse)
+	case strings.HasSuffix(path, "/_count"):
+		io.WriteString(w, `{"count":1,"_shards":{"total":1,"successful":1,"skipped":0,"failed":0}}`)
+	case strings.HasSuffix(path, "/_bulk"):
+		io.WriteString(w, `{"took":1,"errors":false,"items":[]}`)
+	case req.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case req.Method == http.MethodPut && !strings.Contains(path[1:], "/"):
+		io.WriteString(w, `{"acknowledged":true,"shards_acknowledged":true}`)
+	case strings.Contains(path, "/_doc/") || strings.Contains(path, "/_create/"):
+		if req.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"found":false}`)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, `{"_index":"products","_type":"_doc","_id":"1","_version":1,"result":"created","_shards":{"total":1,"successful":1,"failed":0},"_seq_no":0,"_primary_term":1}`)
+	default:
+		io.WriteString(w, `{}`)
+	}
+}
@@ -0,0 +1,142 @@
+// Package aliases resolves the index aliases of the cluster, the aliases
+// are cached and reloaded once stale.
+package aliases
+
+import (
+	"context"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	logTag     = "[aliases]"
+	envTTL     = "ALIAS_CACHE_TTL"
+	defaultTTL = 30 * time.Second
+)
+
+// table maps the aliases to their indices and the indices to their aliases.
+type table struct {
+	indices map[string][]string
+	aliases map[string][]string
+}
+
+// cache holds the aliases of the cluster, the aliases are fetched again
+// once older than the ttl.
+type cache struct {
+	mu        sync.Mutex
+	fetch     func(ctx context.Context) (map[string][]string, error)
+	ttl       time.Duration
+	table     table
+	fetchedAt time.Time
+}
+
+var (
+	singleton *cache
+	once      sync.Once
+)
+
+func instance() *cache {
+	once.Do(func() {
+		ttl := defaultTTL
+		if value := os.Getenv(envTTL); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				log.Errorln(logTag, ": invalid value for", envTTL, ":", value)
+			} else {
+				ttl = d
+			}
+		}
+		singleton = newCache(fetchAliases, ttl)
+	})
+	return singleton
+}
+
+func newCache(fetch func(ctx context.Context) (map[string][]string, error), ttl time.Duration) *cache {
+	return &cache{fetch: fetch, ttl: ttl}
+}
+
+// fetchAliases returns the aliases of each index of the cluster.
+func fetchAliases(ctx context.Context) (map[string][]string, error) {
+	response, err := util.GetClient7().Aliases().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byIndex := make(map[string][]string, len(response.Indices))
+	for name, index := range response.Indices {
+		for _, alias := range index.Aliases {
+			byIndex[name] = append(byIndex[name], alias.AliasName)
+		}
+	}
+	return byIndex, nil
+}
+
+// current returns the aliases, fetching them if stale. The stale aliases are
+// kept if they can't be fetched, and fetched again once the ttl elapses.
+func (c *cache) current(ctx context.Context) table {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl == 0 || time.Since(c.fetchedAt) < c.ttl {
+		return c.table
+	}
+
+	byIndex, err := c.fetch(ctx)
+	if err != nil {
+		log.Errorln(logTag, ": unable to fetch the aliases:", err)
+		c.fetchedAt = time.Now()
+		return c.table
+	}
+	t := table{indices: make(map[string][]string), aliases: make(map[string][]string)}
+	for index, aliases := range byIndex {
+		for _, alias := range aliases {
+			t.indices[alias] = append(t.indices[alias], index)
+			t.aliases[index] = append(t.aliases[index], alias)
+		}
+	}
+	for _, indices := range t.indices {
+		sort.Strings(indices)
+	}
+	for _, aliases := range t.aliases {
+		sort.Strings(aliases)
+	}
+	c.table, c.fetchedAt = t, time.Now()
+	return t
+}
+
+// Invalidate marks the aliases stale, they are fetched again on their next use.
+func Invalidate() {
+	c := instance()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fetchedAt = time.Time{}
+}
+
+// Indices returns the indices the alias points at, or false if the name
+// isn't an alias. The aliases aren't resolved if ALIAS_CACHE_TTL is 0.
+func Indices(ctx context.Context, alias string) ([]string, bool) {
+	indices, ok := instance().current(ctx).indices[alias]
+	return indices, ok
+}
+
+// Of returns the aliases pointing at the index.
+func Of(ctx context.Context, index string) []string {
+	return instance().current(ctx).aliases[index]
+}
+
+// Matching returns the aliases matching the index pattern.
+func Matching(ctx context.Context, pattern string) []string {
+	var matching []string
+	for alias := range instance().current(ctx).indices {
+		if index.Overlaps([]string{pattern}, alias) {
+			matching = append(matching, alias)
+		}
+	}
+	sort.Strings(matching)
+	return matching
+}
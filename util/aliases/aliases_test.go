@@ -0,0 +1,50 @@
+package aliases
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCache(t *testing.T) {
+	var fetches int
+	var fail bool
+	c := newCache(func(ctx context.Context) (map[string][]string, error) {
+		fetches++
+		if fail {
+			return nil, errors.New("unavailable")
+		}
+		return map[string][]string{
+			"products_v3": {"products"},
+			"logs-1":      {"logs", "recent"},
+			"logs-2":      {"logs"},
+		}, nil
+	}, time.Hour)
+	ctx := context.Background()
+
+	Convey("The aliases are resolved both ways", t, func() {
+		t := c.current(ctx)
+		So(t.indices["logs"], ShouldResemble, []string{"logs-1", "logs-2"})
+		So(t.indices["products"], ShouldResemble, []string{"products_v3"})
+		So(t.aliases["logs-1"], ShouldResemble, []string{"logs", "recent"})
+		So(t.indices["products_v3"], ShouldBeNil)
+	})
+
+	Convey("The aliases are cached until stale", t, func() {
+		c.current(ctx)
+		So(fetches, ShouldEqual, 1)
+
+		c.fetchedAt = time.Now().Add(-2 * time.Hour)
+		fail = true
+		So(c.current(ctx).indices["products"], ShouldResemble, []string{"products_v3"})
+		So(fetches, ShouldEqual, 2)
+	})
+
+	Convey("A zero ttl disables the resolution", t, func() {
+		disabled := newCache(nil, 0)
+		So(disabled.current(ctx).indices, ShouldBeNil)
+	})
+}
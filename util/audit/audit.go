@@ -0,0 +1,117 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	es7 "github.com/olivere/elastic/v7"
+)
+
+const (
+	logTag              = "[audit]"
+	envAuditEsIndex     = "AUDIT_ES_INDEX"
+	defaultAuditEsIndex = ".audit"
+	settings            = `{ "settings" : { "number_of_shards" : %d, "number_of_replicas" : %d } }`
+)
+
+var (
+	indexName string
+	initOnce  sync.Once
+	initErr   error
+)
+
+// Event is a single entry of the audit trail.
+type Event struct {
+	Timestamp time.Time   `json:"timestamp"`
+	Actor     string      `json:"actor"`
+	Action    string      `json:"action"`
+	Resource  string      `json:"resource"`
+	Status    int         `json:"status"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// NewEvent returns an audit event for the given action on the resource performed
+// by the credential that made the request.
+func NewEvent(req *http.Request, action, resource string, status int) Event {
+	actor, _, _ := req.BasicAuth()
+	return Event{
+		Timestamp: time.Now(),
+		Actor:     actor,
+		Action:    action,
+		Resource:  resource,
+		Status:    status,
+	}
+}
+
+// Index returns the name of the index that holds the audit trail.
+func Index() string {
+	initialize()
+	return indexName
+}
+
+// initialize resolves the name of the audit index and creates the index once.
+func initialize() {
+	initOnce.Do(func() {
+		indexName = os.Getenv(envAuditEsIndex)
+		if indexName == "" {
+			indexName = defaultAuditEsIndex
+		}
+		initErr = createIndex(context.Background())
+	})
+}
+
+// Record persists the event in the audit index. Failures are logged and
+// never propagated to the caller since auditing must not break the request.
+func Record(ctx context.Context, e Event) {
+	initialize()
+	if initErr != nil {
+		log.Errorln(logTag, ": unable to record", e.Action, "on", e.Resource, ":", initErr)
+		return
+	}
+
+	request := util.GetClient7().Index().
+		Index(indexName).
+		BodyJson(e)
+	if docType := util.DocType(); docType != "" {
+		request.Type(docType)
+	}
+	if _, err := request.Do(ctx); err != nil {
+		log.Errorln(logTag, ": error indexing audit event:", err)
+	}
+}
+
+func createIndex(ctx context.Context) error {
+	exists, err := util.GetClient7().IndexExists(indexName).
+		Do(ctx)
+	if err != nil {
+		return fmt.Errorf("error while checking if index already exists: %v", err)
+	}
+	if exists {
+		return nil
+	}
+
+	// set number_of_replicas to (nodes-1)
+	nodes, err := util.GetTotalNodes()
+	if err != nil {
+		return err
+	}
+	_, err = util.GetClient7().CreateIndex(indexName).
+		Body(fmt.Sprintf(settings, nodes, nodes-1)).
+		Do(ctx)
+	if err != nil {
+		if e, ok := err.(*es7.Error); ok && e.Details != nil && e.Details.Type == "resource_already_exists_exception" {
+			return nil
+		}
+		return fmt.Errorf("error while creating index named %s: %v", indexName, err)
+	}
+
+	log.Println(logTag, ": successfully created index named", indexName)
+	return nil
+}
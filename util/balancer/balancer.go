@@ -0,0 +1,356 @@
+package balancer
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/errors"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/notify"
+)
+
+const (
+	logTag                     = "[balancer]"
+	envEsClusterURL            = "ES_CLUSTER_URL"
+	envSniff                   = "ES_SNIFF"
+	envStrategy                = "ES_LOAD_BALANCER"
+	envSniffInterval           = "ES_SNIFF_INTERVAL"
+	envHealthcheckInterval     = "ES_HEALTHCHECK_INTERVAL"
+	defaultSniffInterval       = 5 * time.Minute
+	defaultHealthcheckInterval = 10 * time.Second
+	healthcheckTimeout         = 2 * time.Second
+)
+
+// Strategy defines the way a node is picked for the next request.
+type Strategy string
+
+// Supported load balancing strategies.
+const (
+	RoundRobin       Strategy = "round_robin"
+	LeastConnections Strategy = "least_connections"
+)
+
+type contextKey string
+
+// ctxKey is a key against which the selected *Node is stored in the context.
+const ctxKey = contextKey("node")
+
+var (
+	instance *Balancer
+	once     sync.Once
+)
+
+// Node is an upstream elasticsearch node that serves http requests.
+type Node struct {
+	URL     *url.URL
+	healthy int32
+	active  int64
+}
+
+// Healthy checks whether the last health check of the node succeeded.
+func (n *Node) Healthy() bool {
+	return atomic.LoadInt32(&n.healthy) == 1
+}
+
+// Active returns the number of in-flight requests served by the node.
+func (n *Node) Active() int64 {
+	return atomic.LoadInt64(&n.active)
+}
+
+// Release must be called once the request forwarded to the node is served.
+func (n *Node) Release() {
+	atomic.AddInt64(&n.active, -1)
+}
+
+// MarkUnhealthy removes the node from the rotation until the next health check.
+func (n *Node) MarkUnhealthy() {
+	n.setHealthy(false)
+}
+
+// Fail takes the node out of the rotation after the request forwarded to it
+// failed with err, unless the request was canceled or timed out, which says
+// nothing about the health of the node.
+func (n *Node) Fail(ctx context.Context, err error) {
+	if ctx.Err() != nil || stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	n.MarkUnhealthy()
+}
+
+// setHealthy updates the health of the node, the webhooks are notified when
+// the node is taken out of the rotation.
+func (n *Node) setHealthy(healthy bool) {
+	var v int32
+	if healthy {
+		v = 1
+	}
+	if atomic.SwapInt32(&n.healthy, v) == 1 && !healthy {
+		notify.Send(notify.Event{
+			Type:     notify.CircuitOpen,
+			Resource: n.URL.Host,
+			Message:  fmt.Sprintf("node %s is unhealthy and taken out of the rotation until it recovers", n.URL.Host),
+		})
+	}
+}
+
+// Balancer distributes the requests across the nodes of the upstream cluster.
+// Nodes are discovered by sniffing the cluster when enabled, otherwise the
+// balancer always picks the node configured via ES_CLUSTER_URL.
+type Balancer struct {
+	mu       sync.RWMutex
+	seed     *Node
+	nodes    []*Node
+	strategy Strategy
+	sniff    bool
+	next     uint64
+}
+
+// Instance returns the singleton instance of the Balancer configured from the env.
+func Instance() *Balancer {
+	once.Do(func() {
+		var err error
+		instance, err = New(os.Getenv(envEsClusterURL), Strategy(os.Getenv(envStrategy)), os.Getenv(envSniff) == "true")
+		if err != nil {
+			log.Fatal(logTag, ": ", err)
+		}
+		instance.Start(durationFromEnv(envSniffInterval, defaultSniffInterval),
+			durationFromEnv(envHealthcheckInterval, defaultHealthcheckInterval))
+	})
+	return instance
+}
+
+// New returns a balancer seeded with the given cluster url.
+func New(rawURL string, strategy Strategy, sniff bool) (*Balancer, error) {
+	if rawURL == "" {
+		return nil, errors.NewEnvVarNotSetError(envEsClusterURL)
+	}
+	seedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing %s=%s: %v", envEsClusterURL, rawURL, err)
+	}
+	switch strategy {
+	case "":
+		strategy = RoundRobin
+	case RoundRobin, LeastConnections:
+	default:
+		return nil, fmt.Errorf(`invalid load balancing strategy "%s"`, strategy)
+	}
+	seed := &Node{URL: seedURL, healthy: 1}
+	return &Balancer{
+		seed:     seed,
+		nodes:    []*Node{seed},
+		strategy: strategy,
+		sniff:    sniff,
+	}, nil
+}
+
+// Start schedules the periodic health checks of the nodes, and sniffs the
+// cluster periodically when sniffing is enabled. The health checks run
+// regardless so that the seed node is put back in the rotation once it
+// recovers.
+func (b *Balancer) Start(sniffInterval, healthcheckInterval time.Duration) {
+	var sniffC <-chan time.Time
+	if b.sniff {
+		if err := b.Sniff(context.Background()); err != nil {
+			log.Errorln(logTag, ": error sniffing the cluster nodes:", err)
+		}
+		sniffC = time.NewTicker(sniffInterval).C
+	}
+	go func() {
+		healthTicker := time.NewTicker(healthcheckInterval)
+		for {
+			select {
+			case <-sniffC:
+				if err := b.Sniff(context.Background()); err != nil {
+					log.Errorln(logTag, ": error sniffing the cluster nodes:", err)
+				}
+			case <-healthTicker.C:
+				b.Healthcheck()
+			}
+		}
+	}()
+}
+
+// Nodes returns the nodes currently known to the balancer.
+func (b *Balancer) Nodes() []*Node {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	nodes := make([]*Node, len(b.nodes))
+	copy(nodes, b.nodes)
+	return nodes
+}
+
+// Next picks the node to which the next request should be forwarded. The
+// returned node must be released once the request has been served. The seed
+// node is returned in case none of the known nodes are healthy.
+func (b *Balancer) Next() *Node {
+	b.mu.RLock()
+	var healthy []*Node
+	for _, n := range b.nodes {
+		if n.Healthy() {
+			healthy = append(healthy, n)
+		}
+	}
+	b.mu.RUnlock()
+
+	node := b.seed
+	if len(healthy) > 0 {
+		switch b.strategy {
+		case LeastConnections:
+			node = healthy[0]
+			for _, n := range healthy[1:] {
+				if n.Active() < node.Active() {
+					node = n
+				}
+			}
+		default:
+			i := atomic.AddUint64(&b.next, 1)
+			node = healthy[(i-1)%uint64(len(healthy))]
+		}
+	}
+	atomic.AddInt64(&node.active, 1)
+	return node
+}
+
+type nodesInfo struct {
+	Nodes map[string]struct {
+		HTTP struct {
+			PublishAddress string `json:"publish_address"`
+		} `json:"http"`
+	} `json:"nodes"`
+}
+
+// Sniff discovers the http enabled nodes of the cluster via the seed node.
+func (b *Balancer) Sniff(ctx context.Context) error {
+	sniffURL := *b.seed.URL
+	sniffURL.Path = "/_nodes/http"
+	req, err := http.NewRequest(http.MethodGet, sniffURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	response, err := util.HTTPClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", response.StatusCode, string(body))
+	}
+
+	var info nodesInfo
+	if err := json.Unmarshal(body, &info); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	existing := make(map[string]*Node)
+	for _, n := range b.nodes {
+		existing[n.URL.Host] = n
+	}
+	var nodes []*Node
+	for _, n := range info.Nodes {
+		host := publishHost(n.HTTP.PublishAddress)
+		if host == "" {
+			continue
+		}
+		if node, ok := existing[host]; ok {
+			nodes = append(nodes, node)
+			continue
+		}
+		nodeURL := *b.seed.URL
+		nodeURL.Host = host
+		nodeURL.Path = ""
+		nodes = append(nodes, &Node{URL: &nodeURL, healthy: 1})
+	}
+	if len(nodes) > 0 {
+		b.nodes = nodes
+		log.Println(logTag, ": discovered", len(nodes), "node(s)")
+	}
+	return nil
+}
+
+// publishHost extracts "host:port" from the publish address which can either
+// be in "ip:port" or "hostname/ip:port" format.
+func publishHost(address string) string {
+	if i := strings.Index(address, "/"); i >= 0 {
+		address = address[i+1:]
+	}
+	return address
+}
+
+// Healthcheck pings each of the known nodes and updates their health.
+func (b *Balancer) Healthcheck() {
+	var wg sync.WaitGroup
+	for _, n := range b.Nodes() {
+		wg.Add(1)
+		go func(n *Node) {
+			defer wg.Done()
+			n.setHealthy(ping(n))
+		}(n)
+	}
+	wg.Wait()
+}
+
+func ping(n *Node) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), healthcheckTimeout)
+	defer cancel()
+	req, err := http.NewRequest(http.MethodHead, n.URL.String(), nil)
+	if err != nil {
+		return false
+	}
+	response, err := util.HTTPClient().Do(req.WithContext(ctx))
+	if err != nil {
+		log.Errorln(logTag, ": node", n.URL.Host, "is unreachable:", err)
+		return false
+	}
+	response.Body.Close()
+	return response.StatusCode < http.StatusInternalServerError
+}
+
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Errorln(logTag, ": invalid duration for", key, ":", err)
+		return defaultValue
+	}
+	return d
+}
+
+// NewContext returns a new context carrying the selected node.
+func NewContext(ctx context.Context, n *Node) context.Context {
+	return context.WithValue(ctx, ctxKey, n)
+}
+
+// FromContext retrieves the node stored against the balancer.ctxKey from the context.
+func FromContext(ctx context.Context) (*Node, error) {
+	ctxNode := ctx.Value(ctxKey)
+	if ctxNode == nil {
+		return nil, errors.NewNotFoundInContextError("*balancer.Node")
+	}
+	reqNode, ok := ctxNode.(*Node)
+	if !ok {
+		return nil, errors.NewInvalidCastError("ctxNode", "*balancer.Node")
+	}
+	return reqNode, nil
+}
@@ -0,0 +1,73 @@
+package balancer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func testBalancer(strategy Strategy, hosts ...string) *Balancer {
+	b, _ := New("http://"+hosts[0], strategy, true)
+	b.nodes = nil
+	for _, host := range hosts {
+		b.nodes = append(b.nodes, &Node{URL: &url.URL{Scheme: "http", Host: host}, healthy: 1})
+	}
+	return b
+}
+
+func TestNext(t *testing.T) {
+	Convey("Round robin", t, func() {
+		b := testBalancer(RoundRobin, "a:9200", "b:9200")
+		So(b.Next().URL.Host, ShouldEqual, "a:9200")
+		So(b.Next().URL.Host, ShouldEqual, "b:9200")
+		So(b.Next().URL.Host, ShouldEqual, "a:9200")
+	})
+
+	Convey("Least connections", t, func() {
+		b := testBalancer(LeastConnections, "a:9200", "b:9200")
+		first := b.Next()
+		So(first.URL.Host, ShouldEqual, "a:9200")
+		So(b.Next().URL.Host, ShouldEqual, "b:9200")
+		first.Release()
+		So(b.Next().URL.Host, ShouldEqual, "a:9200")
+	})
+
+	Convey("Unhealthy nodes are skipped", t, func() {
+		b := testBalancer(RoundRobin, "a:9200", "b:9200")
+		b.nodes[0].MarkUnhealthy()
+		So(b.Next().URL.Host, ShouldEqual, "b:9200")
+		So(b.Next().URL.Host, ShouldEqual, "b:9200")
+	})
+
+	Convey("Invalid strategy", t, func() {
+		_, err := New("http://localhost:9200", Strategy("random"), false)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestFail(t *testing.T) {
+	Convey("The nodes failing a request are taken out of the rotation", t, func() {
+		b := testBalancer(RoundRobin, "a:9200")
+		b.nodes[0].Fail(context.Background(), errors.New("connection refused"))
+		So(b.nodes[0].Healthy(), ShouldBeFalse)
+	})
+
+	Convey("The canceled or timed out requests don't affect the nodes", t, func() {
+		b := testBalancer(RoundRobin, "a:9200")
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		b.nodes[0].Fail(ctx, ctx.Err())
+		b.nodes[0].Fail(context.Background(), context.DeadlineExceeded)
+		So(b.nodes[0].Healthy(), ShouldBeTrue)
+	})
+}
+
+func TestPublishHost(t *testing.T) {
+	Convey("Publish address", t, func() {
+		So(publishHost("127.0.0.1:9200"), ShouldEqual, "127.0.0.1:9200")
+		So(publishHost("es-node-1/10.0.0.2:9200"), ShouldEqual, "10.0.0.2:9200")
+	})
+}
@@ -0,0 +1,188 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	envMaxBodySize = "MAX_BODY_SIZE"
+	// defaultMaxBodySize matches the default http.max_content_length of
+	// elasticsearch, which rejects the larger bodies anyway.
+	defaultMaxBodySize = 100 << 20
+	envMaxResponseSize = "MAX_RESPONSE_SIZE"
+	// defaultMaxResponseSize bounds the upstream responses arc buffers to
+	// process, the others are streamed as they are.
+	defaultMaxResponseSize = 100 << 20
+	// maxPooledBuffer is the capacity above which the buffers aren't returned
+	// to the pool, so that an occasional large body doesn't pin its memory.
+	maxPooledBuffer = 1 << 20
+)
+
+// ErrBodyTooLarge is returned when a body exceeds the size it's read within.
+var ErrBodyTooLarge = errors.New("body too large")
+
+var (
+	bufferPool = sync.Pool{
+		New: func() interface{} { return new(bytes.Buffer) },
+	}
+	maxBodySize         int64
+	maxBodySizeOnce     sync.Once
+	maxResponseSize     int64
+	maxResponseSizeOnce sync.Once
+)
+
+// GetBuffer returns an empty buffer from the pool, which must be handed back
+// with PutBuffer once its bytes are no longer referenced.
+func GetBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// PutBuffer returns the buffer to the pool.
+func PutBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// MaxBodySize returns the maximum size of the request bodies read by arc,
+// set with MAX_BODY_SIZE in bytes, zero if they aren't bounded.
+func MaxBodySize() int64 {
+	maxBodySizeOnce.Do(func() {
+		maxBodySize = sizeFromEnv(envMaxBodySize, defaultMaxBodySize)
+	})
+	return maxBodySize
+}
+
+// MaxResponseSize returns the maximum size of the upstream responses read
+// whole by arc, set with MAX_RESPONSE_SIZE in bytes, zero if they aren't
+// bounded.
+func MaxResponseSize() int64 {
+	maxResponseSizeOnce.Do(func() {
+		maxResponseSize = sizeFromEnv(envMaxResponseSize, defaultMaxResponseSize)
+	})
+	return maxResponseSize
+}
+
+func sizeFromEnv(env string, defaultSize int64) int64 {
+	value := os.Getenv(env)
+	if value == "" {
+		return defaultSize
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		log.Errorln("invalid value for", env, ":", value, ", defaulting to", defaultSize)
+		return defaultSize
+	}
+	return n
+}
+
+// ReadBody reads r to the end within limit bytes, a non-positive limit
+// reading it whole. The body is read into a pooled buffer and copied out
+// once at its exact size, which saves the successive allocations the buffer
+// of ioutil.ReadAll grows through. ErrBodyTooLarge is returned if the body
+// exceeds the limit.
+func ReadBody(r io.Reader, limit int64) ([]byte, error) {
+	buf := GetBuffer()
+	defer PutBuffer(buf)
+
+	src := r
+	if limit > 0 {
+		src = io.LimitReader(r, limit+1)
+	}
+	if _, err := buf.ReadFrom(src); err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(buf.Len()) > limit {
+		return nil, ErrBodyTooLarge
+	}
+	body := make([]byte, buf.Len())
+	copy(body, buf.Bytes())
+	return body, nil
+}
+
+// sharedBody holds the request body read once and shared by the handlers
+// down the chain through the request context.
+type sharedBody struct {
+	read  bool
+	bytes []byte
+	err   error
+}
+
+type sharedBodyKey struct{}
+
+// WithSharedBody returns a shallow copy of the request whose context shares
+// its body: once read by ReadRequestBody, the body is reused by the handlers
+// down the chain instead of being read and copied by each of them. The body
+// isn't shared with the request the copy is made from.
+func WithSharedBody(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), sharedBodyKey{}, &sharedBody{}))
+}
+
+// ReadRequestBody reads the body of the request within MaxBodySize and
+// replaces it with a reader over the bytes read, so that the handlers down
+// the chain can read it again. The body of a request set up with
+// WithSharedBody is read only once, the bytes returned are then shared and
+// must not be modified, SetRequestBody replaces them.
+func ReadRequestBody(req *http.Request) ([]byte, error) {
+	shared, _ := req.Context().Value(sharedBodyKey{}).(*sharedBody)
+	if shared != nil && shared.read {
+		if shared.err == nil {
+			req.Body = ioutil.NopCloser(bytes.NewReader(shared.bytes))
+		}
+		return shared.bytes, shared.err
+	}
+	body, err := readRequestBody(req)
+	if shared != nil {
+		shared.read, shared.bytes, shared.err = true, body, err
+	}
+	return body, err
+}
+
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := ReadBody(req.Body, MaxBodySize())
+	req.Body.Close()
+	if err != nil {
+		req.Body = http.NoBody
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// SetRequestBody replaces the body of the request, and the body shared with
+// the handlers down the chain, with body.
+func SetRequestBody(req *http.Request, body []byte) {
+	if shared, ok := req.Context().Value(sharedBodyKey{}).(*sharedBody); ok {
+		shared.read, shared.bytes, shared.err = true, body, nil
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+}
+
+// WriteBackBodyError reports the failure to read the request body, with a
+// 413 if it exceeded MaxBodySize.
+func WriteBackBodyError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrBodyTooLarge) {
+		WriteBackErrorWithDetails(w, "request body exceeds "+strconv.FormatInt(MaxBodySize(), 10)+" bytes", http.StatusRequestEntityTooLarge, []ErrorDetail{
+			{Type: "body_too_large", Reason: "must not exceed " + strconv.FormatInt(MaxBodySize(), 10) + " bytes", Location: "body"},
+		})
+		return
+	}
+	WriteBackError(w, "can't read request body", http.StatusInternalServerError)
+}
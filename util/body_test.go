@@ -0,0 +1,144 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadBody(t *testing.T) {
+	Convey("The body is read whole within the limit", t, func() {
+		body, err := ReadBody(strings.NewReader("hello"), 5)
+		So(err, ShouldBeNil)
+		So(string(body), ShouldEqual, "hello")
+
+		body, err = ReadBody(strings.NewReader("hello"), 0)
+		So(err, ShouldBeNil)
+		So(string(body), ShouldEqual, "hello")
+	})
+
+	Convey("The bodies exceeding the limit are rejected", t, func() {
+		_, err := ReadBody(strings.NewReader("hello"), 4)
+		So(err, ShouldEqual, ErrBodyTooLarge)
+	})
+
+	Convey("The body doesn't share the memory of the pooled buffer", t, func() {
+		body, _ := ReadBody(strings.NewReader("first"), 0)
+		ReadBody(strings.NewReader("other"), 0)
+		So(string(body), ShouldEqual, "first")
+	})
+
+	Convey("The request body can be read again", t, func() {
+		req := httptest.NewRequest(http.MethodPost, "/products/_search", strings.NewReader(`{"size":1}`))
+		body, err := ReadRequestBody(req)
+		So(err, ShouldBeNil)
+		So(string(body), ShouldEqual, `{"size":1}`)
+		again, _ := ioutil.ReadAll(req.Body)
+		So(string(again), ShouldEqual, `{"size":1}`)
+	})
+
+	Convey("The shared request body is read once", t, func() {
+		r := &countingReader{Reader: strings.NewReader(`{"size":1}`)}
+		req := WithSharedBody(httptest.NewRequest(http.MethodPost, "/products/_search", r))
+		body, err := ReadRequestBody(req)
+		So(err, ShouldBeNil)
+		again, err := ReadRequestBody(req)
+		So(err, ShouldBeNil)
+		So(string(again), ShouldEqual, `{"size":1}`)
+		So(&again[0], ShouldEqual, &body[0])
+		So(r.reads, ShouldEqual, 2)
+		forwarded, _ := ioutil.ReadAll(req.Body)
+		So(string(forwarded), ShouldEqual, `{"size":1}`)
+	})
+
+	Convey("The rewritten body is shared down the chain", t, func() {
+		req := WithSharedBody(httptest.NewRequest(http.MethodPost, "/products/_search", strings.NewReader(`{"size":1}`)))
+		ReadRequestBody(req)
+		SetRequestBody(req, []byte(`{"size":2}`))
+		So(req.ContentLength, ShouldEqual, len(`{"size":2}`))
+		body, _ := ReadRequestBody(req)
+		So(string(body), ShouldEqual, `{"size":2}`)
+
+		chunk := WithSharedBody(req.Clone(req.Context()))
+		SetRequestBody(chunk, []byte(`{"size":3}`))
+		body, _ = ReadRequestBody(req)
+		So(string(body), ShouldEqual, `{"size":2}`)
+	})
+
+	Convey("The failure to read the shared body is reported to each reader", t, func() {
+		os.Setenv(envMaxBodySize, "4")
+		defer os.Unsetenv(envMaxBodySize)
+		maxBodySizeOnce = sync.Once{}
+		defer func() { maxBodySizeOnce = sync.Once{} }()
+		req := WithSharedBody(httptest.NewRequest(http.MethodPost, "/products/_search", strings.NewReader(`{"size":1}`)))
+		_, err := ReadRequestBody(req)
+		So(err, ShouldEqual, ErrBodyTooLarge)
+		_, err = ReadRequestBody(req)
+		So(err, ShouldEqual, ErrBodyTooLarge)
+	})
+
+	Convey("The bodies too large are answered with a 413", t, func() {
+		w := httptest.NewRecorder()
+		WriteBackBodyError(w, ErrBodyTooLarge)
+		So(w.Code, ShouldEqual, http.StatusRequestEntityTooLarge)
+	})
+}
+
+// countingReader counts the reads of the underlying reader.
+type countingReader struct {
+	io.Reader
+	reads int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	c.reads++
+	return c.Reader.Read(p)
+}
+
+func TestSizeFromEnv(t *testing.T) {
+	const env = "TEST_MAX_SIZE"
+	defer os.Unsetenv(env)
+
+	Convey("The size defaults unless set to a non-negative integer", t, func() {
+		os.Unsetenv(env)
+		So(sizeFromEnv(env, 10), ShouldEqual, 10)
+		os.Setenv(env, "0")
+		So(sizeFromEnv(env, 10), ShouldEqual, 0)
+		os.Setenv(env, "2048")
+		So(sizeFromEnv(env, 10), ShouldEqual, 2048)
+		os.Setenv(env, "-1")
+		So(sizeFromEnv(env, 10), ShouldEqual, 10)
+		os.Setenv(env, "1MB")
+		So(sizeFromEnv(env, 10), ShouldEqual, 10)
+	})
+}
+
+var benchmarkBody = bytes.Repeat([]byte(`{"query":{"match":{"title":"arc"}}}`+"\n"), 1024)
+
+func BenchmarkReadAll(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkBody)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ioutil.ReadAll(bytes.NewReader(benchmarkBody)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadBody(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkBody)))
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadBody(bytes.NewReader(benchmarkBody), MaxBodySize()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Package chaos injects faults into the upstream calls for resilience testing,
+// so that the retries of the clients and the circuit breaker of the upstream
+// nodes can be validated in staging. It must never be enabled in production:
+// the faults are only injected once arc is started with the -chaos flag, as
+// configured by the CHAOS_* env vars.
+package chaos
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/health"
+)
+
+const (
+	logTag             = "[chaos]"
+	envLatency         = "CHAOS_LATENCY"
+	envLatencyPercent  = "CHAOS_LATENCY_PERCENT"
+	envErrorPercent    = "CHAOS_ERROR_PERCENT"
+	envErrorStatus     = "CHAOS_ERROR_STATUS"
+	envResetPercent    = "CHAOS_RESET_PERCENT"
+	envPath            = "CHAOS_PATH"
+	defaultErrorStatus = http.StatusServiceUnavailable
+	defaultLatency     = time.Second
+	// headerInjected marks the responses of the injected errors.
+	headerInjected = "X-Chaos-Injected"
+)
+
+// Config of the faults, the percentages are of the upstream calls whose path
+// matches Path, any if unset.
+type Config struct {
+	Latency        time.Duration
+	LatencyPercent float64
+	ErrorPercent   float64
+	ErrorStatus    int
+	ResetPercent   float64
+	Path           *regexp.Regexp
+}
+
+func (c *Config) String() string {
+	s := fmt.Sprintf("latency=%s on %g%%, %d errors on %g%%, resets on %g%%",
+		c.Latency, c.LatencyPercent, c.ErrorStatus, c.ErrorPercent, c.ResetPercent)
+	if c.Path != nil {
+		s += fmt.Sprintf(" of the calls matching %s", c.Path)
+	}
+	return s
+}
+
+var current atomic.Value
+
+// Enable reads the configuration of the faults from the env and starts
+// injecting them into the upstream calls.
+func Enable() error {
+	config, err := configFromEnv()
+	if err != nil {
+		return err
+	}
+	current.Store(config)
+	health.Register("chaos", func() error {
+		return fmt.Errorf("injecting faults into the upstream calls: %s", config)
+	}, false)
+	log.Warnln(logTag, ": CHAOS MODE ENABLED, injecting faults into the upstream calls:", config)
+	return nil
+}
+
+// Enabled returns the configuration of the faults, nil if chaos mode is off.
+func Enabled() *Config {
+	config, _ := current.Load().(*Config)
+	return config
+}
+
+func configFromEnv() (*Config, error) {
+	config := &Config{Latency: defaultLatency, ErrorStatus: defaultErrorStatus}
+	if value := os.Getenv(envLatency); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be a non-negative duration", envLatency, value)
+		}
+		config.Latency = d
+	}
+	for env, percent := range map[string]*float64{
+		envLatencyPercent: &config.LatencyPercent,
+		envErrorPercent:   &config.ErrorPercent,
+		envResetPercent:   &config.ResetPercent,
+	} {
+		value := os.Getenv(env)
+		if value == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(value, 64)
+		if err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be a percentage between 0 and 100", env, value)
+		}
+		*percent = p
+	}
+	if value := os.Getenv(envErrorStatus); value != "" {
+		code, err := strconv.Atoi(value)
+		if err != nil || code < 500 || code > 599 {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be a 5xx status code", envErrorStatus, value)
+		}
+		config.ErrorStatus = code
+	}
+	if value := os.Getenv(envPath); value != "" {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s, must be a regular expression: %v", envPath, value, err)
+		}
+		config.Path = re
+	}
+	return config, nil
+}
+
+// Transport wraps the transport of an upstream client, the calls are passed
+// through as they are unless chaos mode is enabled.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &transport{next: next, config: Enabled, roll: rand.Float64}
+}
+
+type transport struct {
+	next   http.RoundTripper
+	config func() *Config
+	// roll returns a number in [0, 1).
+	roll func() float64
+}
+
+func (t *transport) hit(percent float64) bool {
+	return percent > 0 && t.roll()*100 < percent
+}
+
+// RoundTrip delays the call, then either fails it with a connection reset,
+// responds with an error status or passes it through.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	config := t.config()
+	if config == nil || (config.Path != nil && !config.Path.MatchString(req.URL.Path)) {
+		return t.next.RoundTrip(req)
+	}
+
+	if t.hit(config.LatencyPercent) {
+		log.Debugln(logTag, ": delaying", req.Method, req.URL.Path, "by", config.Latency)
+		timer := time.NewTimer(config.Latency)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			closeBody(req)
+			return nil, req.Context().Err()
+		}
+	}
+	if t.hit(config.ResetPercent) {
+		log.Debugln(logTag, ": resetting", req.Method, req.URL.Path)
+		closeBody(req)
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}
+	}
+	if t.hit(config.ErrorPercent) {
+		log.Debugln(logTag, ": failing", req.Method, req.URL.Path, "with", config.ErrorStatus)
+		closeBody(req)
+		return errorResponse(req, config.ErrorStatus), nil
+	}
+	return t.next.RoundTrip(req)
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
+
+func errorResponse(req *http.Request, code int) *http.Response {
+	body := fmt.Sprintf(`{"error":{"code":%d,"message":"fault injected by chaos mode","status":"%s"}}`, code, http.StatusText(code))
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json")
+	header.Set(headerInjected, "true")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", code, http.StatusText(code)),
+		StatusCode:    code,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
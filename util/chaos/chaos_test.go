@@ -0,0 +1,127 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type upstream struct{ calls int }
+
+func (u *upstream) RoundTrip(req *http.Request) (*http.Response, error) {
+	u.calls++
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestTransport(t *testing.T) {
+	newTransport := func(config *Config, roll float64) (*transport, *upstream) {
+		u := &upstream{}
+		return &transport{
+			next:   u,
+			config: func() *Config { return config },
+			roll:   func() float64 { return roll },
+		}, u
+	}
+	get := func(rt http.RoundTripper, path string) (*http.Response, error) {
+		return rt.RoundTrip(httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	Convey("The calls are passed through unless chaos mode is enabled", t, func() {
+		rt, u := newTransport(nil, 0)
+		res, err := get(rt, "/products/_search")
+		So(err, ShouldBeNil)
+		So(res.StatusCode, ShouldEqual, http.StatusOK)
+		So(u.calls, ShouldEqual, 1)
+	})
+
+	Convey("The calls within the percentage are reset", t, func() {
+		config := &Config{ResetPercent: 50, ErrorStatus: http.StatusBadGateway}
+		rt, u := newTransport(config, 0.4)
+		_, err := get(rt, "/products/_search")
+		So(errors.Is(err, syscall.ECONNRESET), ShouldBeTrue)
+		So(u.calls, ShouldEqual, 0)
+
+		rt, u = newTransport(config, 0.6)
+		_, err = get(rt, "/products/_search")
+		So(err, ShouldBeNil)
+		So(u.calls, ShouldEqual, 1)
+	})
+
+	Convey("The calls within the percentage fail with the error status", t, func() {
+		rt, u := newTransport(&Config{ErrorPercent: 100, ErrorStatus: http.StatusBadGateway}, 0.99)
+		res, err := get(rt, "/products/_search")
+		So(err, ShouldBeNil)
+		So(res.StatusCode, ShouldEqual, http.StatusBadGateway)
+		So(res.Header.Get(headerInjected), ShouldEqual, "true")
+		So(u.calls, ShouldEqual, 0)
+	})
+
+	Convey("The calls are delayed until their context is done", t, func() {
+		rt, u := newTransport(&Config{Latency: 20 * time.Millisecond, LatencyPercent: 100}, 0)
+		start := time.Now()
+		_, err := get(rt, "/products/_search")
+		So(err, ShouldBeNil)
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 20*time.Millisecond)
+		So(u.calls, ShouldEqual, 1)
+
+		rt, u = newTransport(&Config{Latency: time.Minute, LatencyPercent: 100}, 0)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = rt.RoundTrip(httptest.NewRequest(http.MethodGet, "/products/_search", nil).WithContext(ctx))
+		So(errors.Is(err, context.DeadlineExceeded), ShouldBeTrue)
+		So(u.calls, ShouldEqual, 0)
+	})
+
+	Convey("Only the calls matching the path are faulted", t, func() {
+		rt, u := newTransport(&Config{ResetPercent: 100, Path: regexp.MustCompile("_bulk$")}, 0)
+		_, err := get(rt, "/products/_search")
+		So(err, ShouldBeNil)
+		_, err = get(rt, "/products/_bulk")
+		So(err, ShouldNotBeNil)
+		So(u.calls, ShouldEqual, 1)
+	})
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	envs := []string{envLatency, envLatencyPercent, envErrorPercent, envErrorStatus, envResetPercent, envPath}
+	reset := func() {
+		for _, env := range envs {
+			os.Unsetenv(env)
+		}
+	}
+	defer reset()
+
+	Convey("The defaults are used for the unset env vars", t, func() {
+		reset()
+		os.Setenv(envErrorPercent, "12.5")
+		config, err := configFromEnv()
+		So(err, ShouldBeNil)
+		So(config.ErrorPercent, ShouldEqual, 12.5)
+		So(config.ErrorStatus, ShouldEqual, defaultErrorStatus)
+		So(config.Latency, ShouldEqual, defaultLatency)
+		So(config.Path, ShouldBeNil)
+	})
+
+	Convey("The invalid values are rejected", t, func() {
+		for env, value := range map[string]string{
+			envLatency:        "soon",
+			envResetPercent:   "120",
+			envErrorStatus:    "404",
+			envLatencyPercent: "-1",
+			envPath:           "(",
+		} {
+			reset()
+			os.Setenv(env, value)
+			_, err := configFromEnv()
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
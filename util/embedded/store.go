@@ -0,0 +1,192 @@
+package embedded
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	logTag       = "[embedded]"
+	envStorePath = "EMBEDDED_STORE_PATH"
+)
+
+// maxSize is the size the file of the store can't grow past, the writes
+// that would exceed it are refused.
+var maxSize = 16 << 20
+
+var (
+	singleton *Store
+	once      sync.Once
+)
+
+// Store is a key-value store embedded in arc and persisted to a single file,
+// the keys are grouped into buckets. It holds the internal data arc requires
+// to serve requests while elasticsearch is unreachable, hence it is meant
+// for small amounts of data: the whole file is rewritten on each write and
+// is at most 16MiB. The file is replaced atomically, hence a crash partway
+// through a write leaves the previous content of the store in place.
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	buckets map[string]map[string]json.RawMessage
+}
+
+// Instance returns the store persisted at EMBEDDED_STORE_PATH, or nil if the
+// path isn't set or the store can't be opened.
+func Instance() *Store {
+	once.Do(func() {
+		path := os.Getenv(envStorePath)
+		if path == "" {
+			return
+		}
+		s, err := Open(path)
+		if err != nil {
+			log.Errorln(logTag, ": unable to open the embedded store, falling back to elasticsearch only:", err)
+			return
+		}
+		log.Println(logTag, ": using the embedded store at", path)
+		singleton = s
+	})
+	return singleton
+}
+
+// Enabled checks whether the embedded store is configured.
+func Enabled() bool {
+	return Instance() != nil
+}
+
+// Open opens the store persisted at path, the file is created on the first
+// write. The temporary files left by a crash partway through a write are
+// removed.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		buckets: make(map[string]map[string]json.RawMessage),
+	}
+	stale, _ := filepath.Glob(filepath.Join(filepath.Dir(path), tmpPrefix(path)+"*"))
+	for _, name := range stale {
+		if err := os.Remove(name); err != nil {
+			log.Warnln(logTag, ": unable to remove the temporary file", name, ":", err)
+		}
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.buckets); err != nil {
+		return nil, fmt.Errorf("corrupt store %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// Get returns the json value stored against the key of the bucket.
+func (s *Store) Get(bucket, key string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.buckets[bucket][key]
+	return value, ok
+}
+
+// Put stores the json value against the key of the bucket.
+func (s *Store) Put(bucket, key string, value []byte) error {
+	if !json.Valid(value) {
+		return fmt.Errorf("value of %s/%s isn't valid json", bucket, key)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string]json.RawMessage)
+	}
+	previous, existed := s.buckets[bucket][key]
+	s.buckets[bucket][key] = append(json.RawMessage(nil), value...)
+	if err := s.persist(); err != nil {
+		// the store keeps matching its file
+		if existed {
+			s.buckets[bucket][key] = previous
+		} else {
+			delete(s.buckets[bucket], key)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes the key of the bucket.
+func (s *Store) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil
+	}
+	delete(s.buckets[bucket], key)
+	if err := s.persist(); err != nil {
+		s.buckets[bucket][key] = previous
+		return err
+	}
+	return nil
+}
+
+// Keys returns the sorted keys of the bucket.
+func (s *Store) Keys(bucket string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// tmpPrefix is the prefix of the temporary files the store is written to.
+func tmpPrefix(path string) string {
+	return filepath.Base(path) + ".tmp"
+}
+
+// persist atomically replaces the file with the buckets, the caller must hold
+// the lock. The directory is synced for the rename to survive a crash.
+func (s *Store) persist() error {
+	data, err := json.Marshal(s.buckets)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxSize {
+		return fmt.Errorf("the store %s would exceed %d bytes", s.path, maxSize)
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), tmpPrefix(s.path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	dir, err := os.Open(filepath.Dir(s.path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
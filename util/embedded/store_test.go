@@ -0,0 +1,102 @@
+package embedded
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arc-embedded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	Convey("Values are persisted across reopens", t, func() {
+		path := filepath.Join(dir, "persisted.json")
+		s, err := Open(path)
+		So(err, ShouldBeNil)
+		So(s.Put("users", "foo", []byte(`{"username":"foo"}`)), ShouldBeNil)
+		So(s.Put("users", "bar", []byte(`{"username":"bar"}`)), ShouldBeNil)
+		So(s.Put("permissions", "baz", []byte(`{"username":"baz"}`)), ShouldBeNil)
+
+		reopened, err := Open(path)
+		So(err, ShouldBeNil)
+		value, ok := reopened.Get("users", "foo")
+		So(ok, ShouldBeTrue)
+		So(string(value), ShouldEqual, `{"username":"foo"}`)
+		So(reopened.Keys("users"), ShouldResemble, []string{"bar", "foo"})
+
+		info, err := os.Stat(path)
+		So(err, ShouldBeNil)
+		So(info.Mode().Perm(), ShouldEqual, os.FileMode(0600))
+	})
+
+	Convey("Deleted keys are gone", t, func() {
+		s, err := Open(filepath.Join(dir, "deleted.json"))
+		So(err, ShouldBeNil)
+		So(s.Put("users", "foo", []byte(`{}`)), ShouldBeNil)
+		So(s.Delete("users", "foo"), ShouldBeNil)
+		So(s.Delete("users", "missing"), ShouldBeNil)
+		_, ok := s.Get("users", "foo")
+		So(ok, ShouldBeFalse)
+		So(s.Keys("users"), ShouldBeEmpty)
+	})
+
+	Convey("Invalid json values are rejected", t, func() {
+		s, err := Open(filepath.Join(dir, "invalid.json"))
+		So(err, ShouldBeNil)
+		So(s.Put("users", "foo", []byte(`{`)), ShouldNotBeNil)
+	})
+
+	Convey("Corrupt files fail to open", t, func() {
+		path := filepath.Join(dir, "corrupt.json")
+		for _, data := range []string{"not json", `{"users": {"foo": {"username": "fo`} {
+			So(ioutil.WriteFile(path, []byte(data), 0600), ShouldBeNil)
+			_, err := Open(path)
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("A crash partway through a write leaves the previous content", t, func() {
+		path := filepath.Join(dir, "crashed.json")
+		s, err := Open(path)
+		So(err, ShouldBeNil)
+		So(s.Put("users", "foo", []byte(`{"username":"foo"}`)), ShouldBeNil)
+
+		// the temporary file the crashed write was interrupted in
+		partial := filepath.Join(dir, tmpPrefix(path)+"123")
+		So(ioutil.WriteFile(partial, []byte(`{"users": {"foo": {"usern`), 0600), ShouldBeNil)
+
+		reopened, err := Open(path)
+		So(err, ShouldBeNil)
+		value, ok := reopened.Get("users", "foo")
+		So(ok, ShouldBeTrue)
+		So(string(value), ShouldEqual, `{"username":"foo"}`)
+		_, err = os.Stat(partial)
+		So(os.IsNotExist(err), ShouldBeTrue)
+	})
+
+	Convey("The writes past the size of the store are refused", t, func() {
+		defer func(size int) { maxSize = size }(maxSize)
+		maxSize = 48
+
+		path := filepath.Join(dir, "full.json")
+		s, err := Open(path)
+		So(err, ShouldBeNil)
+		So(s.Put("users", "foo", []byte(`{"username":"foo"}`)), ShouldBeNil)
+		So(s.Put("users", "foo", []byte(`{"username":"foo","roles":["admin","dev"]}`)), ShouldNotBeNil)
+		So(s.Put("users", "bar", []byte(`{"username":"bar","roles":["admin"]}`)), ShouldNotBeNil)
+
+		value, _ := s.Get("users", "foo")
+		So(string(value), ShouldEqual, `{"username":"foo"}`)
+		So(s.Keys("users"), ShouldResemble, []string{"foo"})
+		reopened, err := Open(path)
+		So(err, ShouldBeNil)
+		So(reopened.Keys("users"), ShouldResemble, []string{"foo"})
+	})
+}
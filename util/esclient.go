@@ -1,7 +1,11 @@
 package util
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -10,9 +14,27 @@ import (
 	es7 "github.com/olivere/elastic/v7"
 	log "github.com/sirupsen/logrus"
 	es6 "gopkg.in/olivere/elastic.v6"
+
+	"github.com/appbaseio/arc/util/embedded"
+)
+
+// Keys of the upstream cluster info in the embedded store.
+const (
+	upstreamBucket = "upstream"
+	upstreamKey    = "root"
+)
+
+// Upstream search engine distributions supported by arc.
+const (
+	DistributionElasticsearch = "elasticsearch"
+	DistributionOpenSearch    = "opensearch"
 )
 
-var version int
+var (
+	version      int
+	distribution string
+	rawVersion   string
+)
 
 var (
 	clientInit sync.Once
@@ -38,25 +60,152 @@ func GetClient6() *es6.Client {
 	return client6
 }
 
-// GetVersion returns the es version
+// GetVersion returns the es version. OpenSearch clusters are reported as
+// version 7 since both OpenSearch 1.x and 2.x expose the type-less es7 APIs.
 func GetVersion() int {
 	// Get the version if not present
 	if version == 0 {
-		esVersion, err := client7.ElasticsearchVersion(getURL())
-		if err != nil {
-			log.Fatal("Error encountered: ", fmt.Errorf("error while retrieving the elastic version: %v", err))
-		}
-		var splitStr = strings.Split(esVersion, ".")
-		if len(splitStr) > 0 && splitStr[0] != "" {
-			version, _ = strconv.Atoi(splitStr[0])
-			if err != nil {
-				log.Errorln("Error encountered: error while calculating the elastic version", err)
+		if err := detectDistribution(); err != nil {
+			if !restoreDistribution() {
+				log.Fatal("Error encountered: ", fmt.Errorf("error while retrieving the elastic version: %v", err))
 			}
+			log.Warnln("elasticsearch is unreachable, assuming the last known version", rawVersion, ":", err)
 		}
 	}
 	return version
 }
 
+// IsTypeless checks whether the upstream cluster serves the type-less apis,
+// i.e. elasticsearch 7 and above or any OpenSearch version. Mapping types
+// must neither be sent nor expected in the responses of such clusters.
+func IsTypeless() bool {
+	return GetVersion() >= 7
+}
+
+// DocType returns the mapping type to be used while indexing documents into
+// the internal indices. It is empty for the type-less clusters.
+func DocType() string {
+	if IsTypeless() {
+		return ""
+	}
+	return "_doc"
+}
+
+// GetDistribution returns the distribution of the upstream cluster, i.e.
+// one of DistributionElasticsearch or DistributionOpenSearch.
+func GetDistribution() string {
+	if distribution == "" {
+		GetVersion()
+	}
+	return distribution
+}
+
+// GetRawVersion returns the version number reported by the upstream cluster.
+func GetRawVersion() string {
+	if rawVersion == "" {
+		GetVersion()
+	}
+	return rawVersion
+}
+
+// IsOpenSearch checks whether the upstream cluster is an OpenSearch cluster.
+func IsOpenSearch() bool {
+	return GetDistribution() == DistributionOpenSearch
+}
+
+type rootInfo struct {
+	Version struct {
+		Number       string `json:"number"`
+		Distribution string `json:"distribution"`
+	} `json:"version"`
+}
+
+// detectDistribution queries the root endpoint of the upstream cluster to
+// identify its distribution and version.
+func detectDistribution() error {
+	response, err := GetClient7().PerformRequest(context.Background(), es7.PerformRequestOptions{
+		Method: "GET",
+		Path:   "/",
+	})
+	if err != nil {
+		return err
+	}
+	var info rootInfo
+	if err := json.Unmarshal(response.Body, &info); err != nil {
+		return err
+	}
+	distribution, version = parseVersion(info.Version.Number, info.Version.Distribution)
+	rawVersion = info.Version.Number
+	if store := embedded.Instance(); store != nil {
+		if err := store.Put(upstreamBucket, upstreamKey, response.Body); err != nil {
+			log.Errorln("Error encountered: unable to store the upstream version:", err)
+		}
+	}
+	return nil
+}
+
+// IsUnreachable checks whether the error indicates that elasticsearch
+// couldn't serve the request at all, as opposed to rejecting it.
+func IsUnreachable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if e, ok := err.(*es7.Error); ok {
+		return e.Status >= 500
+	}
+	if e, ok := err.(*es6.Error); ok {
+		return e.Status >= 500
+	}
+	if es7.IsConnErr(err) || es6.IsConnErr(err) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// IsNotFound checks whether elasticsearch responded with a 404 to the request
+// made with either of the clients.
+func IsNotFound(err error) bool {
+	return es7.IsNotFound(err) || es6.IsNotFound(err)
+}
+
+// restoreDistribution restores the distribution and the version of the
+// upstream cluster last stored in the embedded store, if any.
+func restoreDistribution() bool {
+	store := embedded.Instance()
+	if store == nil {
+		return false
+	}
+	raw, ok := store.Get(upstreamBucket, upstreamKey)
+	if !ok {
+		return false
+	}
+	var info rootInfo
+	if err := json.Unmarshal(raw, &info); err != nil || info.Version.Number == "" {
+		return false
+	}
+	distribution, version = parseVersion(info.Version.Number, info.Version.Distribution)
+	rawVersion = info.Version.Number
+	return true
+}
+
+// parseVersion returns the distribution and the compatible es major version.
+func parseVersion(number, dist string) (string, int) {
+	if strings.ToLower(dist) == DistributionOpenSearch {
+		return DistributionOpenSearch, 7
+	}
+	var major int
+	var splitStr = strings.Split(number, ".")
+	if len(splitStr) > 0 && splitStr[0] != "" {
+		var err error
+		major, err = strconv.Atoi(splitStr[0])
+		if err != nil {
+			log.Errorln("Error encountered: error while calculating the elastic version", err)
+		}
+	}
+	return DistributionElasticsearch, major
+}
+
 func getURL() string {
 	url := os.Getenv("ES_CLUSTER_URL")
 	if url == "" {
@@ -73,7 +222,7 @@ func initClient6() {
 	wrappedLoggerError := &WrapKitLoggerError{*loggerT}
 
 	// Initialize the ES v6 client
-	client6, err = es6.NewClient(
+	options := []es6.ClientOptionFunc{
 		es6.SetURL(getURL()),
 		es6.SetRetrier(NewRetrier()),
 		es6.SetSniff(false),
@@ -81,7 +230,13 @@ func initClient6() {
 		es6.SetErrorLog(wrappedLoggerError),
 		es6.SetInfoLog(wrappedLoggerDebug),
 		es6.SetTraceLog(wrappedLoggerDebug),
-	)
+	}
+	// skip the startup health check so that arc starts while es is
+	// unreachable, the embedded store serves the credentials meanwhile
+	if embedded.Enabled() {
+		options = append(options, es6.SetHealthcheck(false))
+	}
+	client6, err = es6.NewClient(options...)
 
 	if err != nil {
 		log.Fatal("Error encountered: ", fmt.Errorf("error while initializing elastic v6 client: %v", err))
@@ -96,7 +251,7 @@ func initClient7() {
 	wrappedLoggerDebug := &WrapKitLoggerDebug{*loggerT}
 	wrappedLoggerError := &WrapKitLoggerError{*loggerT}
 
-	client7, err = es7.NewClient(
+	options := []es7.ClientOptionFunc{
 		es7.SetURL(getURL()),
 		es7.SetRetrier(NewRetrier()),
 		es7.SetSniff(false),
@@ -104,7 +259,13 @@ func initClient7() {
 		es7.SetErrorLog(wrappedLoggerError),
 		es7.SetInfoLog(wrappedLoggerDebug),
 		es7.SetTraceLog(wrappedLoggerDebug),
-	)
+	}
+	// skip the startup health check so that arc starts while es is
+	// unreachable, the embedded store serves the credentials meanwhile
+	if embedded.Enabled() {
+		options = append(options, es7.SetHealthcheck(false))
+	}
+	client7, err = es7.NewClient(options...)
 	if err != nil {
 		log.Fatal("Error encountered: ", fmt.Errorf("error while initializing elastic v7 client: %v", err))
 	}
@@ -120,6 +281,6 @@ func NewClient() {
 		// Get the ES version
 		GetVersion()
 
-		log.Println("clients instantiated, elastic search version is", version)
+		log.Println("clients instantiated, upstream is", distribution, rawVersion, "with es version", version, "compatible apis")
 	})
 }
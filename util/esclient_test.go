@@ -0,0 +1,72 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	es7 "github.com/olivere/elastic/v7"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseVersion(t *testing.T) {
+	Convey("Parse upstream version", t, func() {
+		Convey("Elasticsearch 6", func() {
+			dist, major := parseVersion("6.8.2", "")
+			So(dist, ShouldEqual, DistributionElasticsearch)
+			So(major, ShouldEqual, 6)
+		})
+		Convey("Elasticsearch 8", func() {
+			dist, major := parseVersion("8.4.1", "default")
+			So(dist, ShouldEqual, DistributionElasticsearch)
+			So(major, ShouldEqual, 8)
+		})
+		Convey("OpenSearch 1.x", func() {
+			dist, major := parseVersion("1.3.6", "opensearch")
+			So(dist, ShouldEqual, DistributionOpenSearch)
+			So(major, ShouldEqual, 7)
+		})
+		Convey("OpenSearch 2.x", func() {
+			dist, major := parseVersion("2.11.0", "opensearch")
+			So(dist, ShouldEqual, DistributionOpenSearch)
+			So(major, ShouldEqual, 7)
+		})
+	})
+}
+
+func TestParseTotalHits(t *testing.T) {
+	Convey("Parse hits.total", t, func() {
+		Convey("Integer total from es6", func() {
+			total, err := ParseTotalHits([]byte(`{"hits":{"total":42,"hits":[]}}`))
+			So(err, ShouldBeNil)
+			So(total.Value, ShouldEqual, 42)
+			So(total.Relation, ShouldEqual, "eq")
+		})
+		Convey("Object total from es7 and above", func() {
+			total, err := ParseTotalHits([]byte(`{"hits":{"total":{"value":10000,"relation":"gte"},"hits":[]}}`))
+			So(err, ShouldBeNil)
+			So(total.Value, ShouldEqual, 10000)
+			So(total.Relation, ShouldEqual, "gte")
+		})
+		Convey("Missing total", func() {
+			_, err := ParseTotalHits([]byte(`{"hits":{"hits":[]}}`))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestIsUnreachable(t *testing.T) {
+	Convey("Unreachable errors", t, func() {
+		So(IsUnreachable(es7.ErrNoClient), ShouldBeTrue)
+		So(IsUnreachable(&net.OpError{Op: "dial", Err: errors.New("connection refused")}), ShouldBeTrue)
+		So(IsUnreachable(context.DeadlineExceeded), ShouldBeTrue)
+		So(IsUnreachable(&es7.Error{Status: 503}), ShouldBeTrue)
+	})
+	Convey("Rejections aren't unreachable errors", t, func() {
+		So(IsUnreachable(nil), ShouldBeFalse)
+		So(IsUnreachable(&es7.Error{Status: 404}), ShouldBeFalse)
+		So(IsUnreachable(errors.New("more than one result")), ShouldBeFalse)
+	})
+}
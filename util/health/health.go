@@ -0,0 +1,108 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Status of a component or the service as a whole.
+type Status string
+
+// Possible health statuses. The service is degraded when a non-critical
+// component is down and down when a critical component is down.
+const (
+	StatusUp       Status = "up"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Check reports the health of a component, a nil error indicates the component is up.
+type Check func() error
+
+type registration struct {
+	check    Check
+	critical bool
+}
+
+// Result is the outcome of a single health check.
+type Result struct {
+	Status   Status `json:"status"`
+	Critical bool   `json:"critical"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the aggregated health of the service.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+var (
+	mu     sync.RWMutex
+	checks = make(map[string]registration)
+)
+
+// Register adds the named check to the registry, replacing the existing
+// check with the same name. The service is reported down if a critical check fails.
+func Register(name string, check Check, critical bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	checks[name] = registration{check, critical}
+}
+
+// Unregister removes the named check from the registry.
+func Unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(checks, name)
+}
+
+// Run executes all the registered checks.
+func Run() Report {
+	mu.RLock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	registered := make(map[string]registration, len(checks))
+	for name, r := range checks {
+		registered[name] = r
+	}
+	mu.RUnlock()
+	sort.Strings(names)
+
+	report := Report{Status: StatusUp, Checks: make(map[string]Result)}
+	for _, name := range names {
+		r := registered[name]
+		result := Result{Status: StatusUp, Critical: r.critical}
+		if err := r.check(); err != nil {
+			result.Status = StatusDown
+			result.Error = err.Error()
+			if r.critical {
+				report.Status = StatusDown
+			} else if report.Status == StatusUp {
+				report.Status = StatusDegraded
+			}
+		}
+		report.Checks[name] = result
+	}
+	return report
+}
+
+// Handler serves the health report, responding with http.StatusServiceUnavailable
+// if any of the critical components are down.
+func Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		report := Run()
+		code := http.StatusOK
+		if report.Status == StatusDown {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(report)
+	}
+}
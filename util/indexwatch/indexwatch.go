@@ -0,0 +1,219 @@
+// Package indexwatch watches the indices of the upstream cluster. The indices
+// are polled via the cat indices api, the indices created and deleted since
+// the previous poll are notified to the webhooks and to the subscribers, and
+// the polled indices are cached for the resolution of the index patterns.
+package indexwatch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/model/index"
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/aliases"
+	"github.com/appbaseio/arc/util/notify"
+	"github.com/appbaseio/arc/util/state"
+)
+
+const (
+	logTag      = "[indexwatch]"
+	envInterval = "INDEX_WATCH_INTERVAL"
+)
+
+// Event is a change of the indices of the cluster, of either the
+// notify.IndexCreated or the notify.IndexDeleted type.
+type Event struct {
+	Type      notify.EventType `json:"type"`
+	Index     string           `json:"index"`
+	UUID      string           `json:"uuid"`
+	Timestamp time.Time        `json:"timestamp"`
+}
+
+// watcher holds the indices of the cluster as of the last poll, by name
+// along with their uuid, which tells the indices recreated under the same
+// name apart.
+type watcher struct {
+	mu          sync.RWMutex
+	fetch       func(ctx context.Context) (map[string]string, error)
+	interval    time.Duration
+	indices     map[string]string
+	polled      bool
+	subscribers []func(Event)
+}
+
+var (
+	singleton *watcher
+	once      sync.Once
+	startOnce sync.Once
+)
+
+func instance() *watcher {
+	once.Do(func() {
+		singleton = newWatcher(fetchIndices)
+		if value := os.Getenv(envInterval); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				log.Errorln(logTag, ": invalid value for", envInterval, ":", value)
+			} else {
+				singleton.interval = d
+			}
+		}
+	})
+	return singleton
+}
+
+func newWatcher(fetch func(ctx context.Context) (map[string]string, error)) *watcher {
+	return &watcher{fetch: fetch}
+}
+
+// fetchIndices returns the uuid of each index of the cluster.
+func fetchIndices(ctx context.Context) (map[string]string, error) {
+	response, err := util.GetClient7().CatIndices().Columns("index", "uuid").Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	indices := make(map[string]string, len(response))
+	for _, row := range response {
+		indices[row.Index] = row.UUID
+	}
+	return indices, nil
+}
+
+// Start starts watching the indices at the interval set via
+// INDEX_WATCH_INTERVAL, the indices aren't watched if it isn't set.
+func Start() {
+	startOnce.Do(func() {
+		w := instance()
+		if w.interval == 0 {
+			return
+		}
+		log.Println(logTag, ": watching the indices every", w.interval)
+		go w.pollEvery()
+	})
+}
+
+// Subscribe registers the function to be called with the changes of the
+// indices. The changes are only handed to the subscribers of the instance
+// that notifies them.
+func Subscribe(fn func(Event)) {
+	w := instance()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Matching returns the indices matching the index pattern as of the last
+// poll, or false if the indices aren't watched.
+func Matching(pattern string) ([]string, bool) {
+	return instance().matching(pattern)
+}
+
+func (w *watcher) matching(pattern string) ([]string, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if !w.polled {
+		return nil, false
+	}
+	var matching []string
+	for name := range w.indices {
+		if index.Overlaps([]string{pattern}, name) {
+			matching = append(matching, name)
+		}
+	}
+	sort.Strings(matching)
+	return matching, true
+}
+
+// poll fetches the indices and returns the changes since the previous poll.
+// The first poll only records the indices.
+func (w *watcher) poll(ctx context.Context) ([]Event, error) {
+	indices, err := w.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	previous, polled := w.indices, w.polled
+	w.indices, w.polled = indices, true
+	if !polled {
+		return nil, nil
+	}
+
+	now := time.Now()
+	var events []Event
+	for name, uuid := range previous {
+		if indices[name] != uuid {
+			events = append(events, Event{Type: notify.IndexDeleted, Index: name, UUID: uuid, Timestamp: now})
+		}
+	}
+	for name, uuid := range indices {
+		if previous[name] != uuid {
+			events = append(events, Event{Type: notify.IndexCreated, Index: name, UUID: uuid, Timestamp: now})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Type != events[j].Type {
+			// the deletions precede the creations of the recreated indices
+			return events[i].Type == notify.IndexDeleted
+		}
+		return events[i].Index < events[j].Index
+	})
+	return events, nil
+}
+
+// emit notifies the change, unless another arc instance already did.
+func (w *watcher) emit(ctx context.Context, e Event) {
+	ok, err := state.Instance().SetNX(ctx, "indexwatch:"+string(e.Type)+":"+e.UUID, []byte("1"), 10*w.interval)
+	if err != nil {
+		log.Errorln(logTag, ": unable to claim the notification of", e.Type, e.Index, ":", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	verb := "created"
+	if e.Type == notify.IndexDeleted {
+		verb = "deleted"
+	}
+	log.Println(logTag, ": index", e.Index, verb)
+	notify.Send(notify.Event{
+		Type:      e.Type,
+		Timestamp: e.Timestamp,
+		Resource:  e.Index,
+		Message:   fmt.Sprintf("index %s was %s", e.Index, verb),
+		Details:   map[string]interface{}{"uuid": e.UUID},
+	})
+
+	w.mu.RLock()
+	subscribers := w.subscribers
+	w.mu.RUnlock()
+	for _, fn := range subscribers {
+		fn(e)
+	}
+}
+
+func (w *watcher) pollEvery() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for ; true; <-ticker.C {
+		ctx := context.Background()
+		events, err := w.poll(ctx)
+		if err != nil {
+			log.Errorln(logTag, ": unable to poll the indices:", err)
+			continue
+		}
+		if len(events) > 0 {
+			// the aliases of the deleted indices are gone along with them
+			aliases.Invalidate()
+		}
+		for _, e := range events {
+			w.emit(ctx, e)
+		}
+	}
+}
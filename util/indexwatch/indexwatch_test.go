@@ -0,0 +1,66 @@
+package indexwatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/util/notify"
+)
+
+func TestPoll(t *testing.T) {
+	var indices map[string]string
+	var fail bool
+	w := newWatcher(func(ctx context.Context) (map[string]string, error) {
+		if fail {
+			return nil, errors.New("unavailable")
+		}
+		return indices, nil
+	})
+	ctx := context.Background()
+
+	Convey("The indices aren't resolved before the first poll", t, func() {
+		_, ok := w.matching("*")
+		So(ok, ShouldBeFalse)
+	})
+
+	Convey("The first poll only records the indices", t, func() {
+		indices = map[string]string{"products": "a", "logs-1": "b"}
+		events, err := w.poll(ctx)
+		So(err, ShouldBeNil)
+		So(events, ShouldBeEmpty)
+
+		matching, ok := w.matching("logs-*")
+		So(ok, ShouldBeTrue)
+		So(matching, ShouldResemble, []string{"logs-1"})
+	})
+
+	Convey("The created, deleted and recreated indices are detected", t, func() {
+		indices = map[string]string{"products": "c", "logs-2": "d"}
+		events, err := w.poll(ctx)
+		So(err, ShouldBeNil)
+		So(len(events), ShouldEqual, 4)
+		So(events[0].Type, ShouldEqual, notify.IndexDeleted)
+		So(events[0].Index, ShouldEqual, "logs-1")
+		So(events[1].Type, ShouldEqual, notify.IndexDeleted)
+		So(events[1].UUID, ShouldEqual, "a")
+		So(events[2].Type, ShouldEqual, notify.IndexCreated)
+		So(events[2].Index, ShouldEqual, "logs-2")
+		So(events[3].Type, ShouldEqual, notify.IndexCreated)
+		So(events[3].UUID, ShouldEqual, "c")
+
+		matching, _ := w.matching("logs-*")
+		So(matching, ShouldResemble, []string{"logs-2"})
+	})
+
+	Convey("The indices are kept if they can't be polled", t, func() {
+		fail = true
+		_, err := w.poll(ctx)
+		So(err, ShouldNotBeNil)
+		matching, ok := w.matching("products")
+		So(ok, ShouldBeTrue)
+		So(matching, ShouldResemble, []string{"products"})
+	})
+}
@@ -0,0 +1,54 @@
+package iplookup
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+const (
+	// StatusInternal marks the information of the ips that belong to the
+	// private, loopback or link-local ranges, which aren't geo resolvable.
+	StatusInternal = "internal"
+)
+
+// Normalize returns the canonical representation of the ip address. The
+// address may be enclosed in brackets, carry a port or an IPv6 zone, and
+// IPv4-mapped IPv6 addresses are converted to their IPv4 form.
+func Normalize(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	address = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+	if i := strings.LastIndex(address, "%"); i >= 0 {
+		address = address[:i]
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return "", fmt.Errorf("invalid ip address %q", address)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return ip.String(), nil
+}
+
+// IsInternal checks whether the ip address belongs to the private (RFC 1918
+// and unique local IPv6), loopback, link-local or unspecified ranges.
+func IsInternal(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	if ip.IsUnspecified() {
+		return true
+	}
+	for _, cidr := range cidrs {
+		if cidr.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package iplookup
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNormalize(t *testing.T) {
+	Convey("Normalize ip addresses", t, func() {
+		cases := map[string]string{
+			"1.1.1.1":              "1.1.1.1",
+			" 1.1.1.1:8080 ":       "1.1.1.1",
+			"::ffff:1.1.1.1":       "1.1.1.1",
+			"[2001:DB8::1]:443":    "2001:db8::1",
+			"2001:0db8:0000::0001": "2001:db8::1",
+			"fe80::1%eth0":         "fe80::1",
+			"[fe80::1%25eth0]":     "fe80::1",
+		}
+		for address, expected := range cases {
+			normalized, err := Normalize(address)
+			So(err, ShouldBeNil)
+			So(normalized, ShouldEqual, expected)
+		}
+
+		_, err := Normalize("unknown")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestIsInternal(t *testing.T) {
+	Convey("Internal ranges", t, func() {
+		for _, ip := range []string{"10.1.2.3", "172.16.0.1", "192.168.1.1", "127.0.0.1", "169.254.1.1", "::1", "fd00::1", "fe80::1", "0.0.0.0", "::"} {
+			So(IsInternal(ip), ShouldBeTrue)
+		}
+		for _, ip := range []string{"1.1.1.1", "172.32.0.1", "2001:db8::1"} {
+			So(IsInternal(ip), ShouldBeFalse)
+		}
+	})
+
+	Convey("Internal ips are not looked up", t, func() {
+		info := &IPInfo{cache: newLRUCache(1, 0), provider: chain{}}
+		ipLookup, err := info.Lookup("::ffff:10.0.0.1")
+		So(err, ShouldBeNil)
+		So(ipLookup.Status, ShouldEqual, StatusInternal)
+		So(ipLookup.Query, ShouldEqual, "10.0.0.1")
+	})
+}
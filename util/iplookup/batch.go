@@ -0,0 +1,118 @@
+package iplookup
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultBatchInterval = 5 * time.Second
+	batchConcurrency     = 8
+	batchQueueSize       = 10000
+)
+
+// batchResolver resolves the enqueued ips in the background, in batches of
+// unique ips, so that the information is cached by the time it is needed for
+// enrichment without blocking the request path.
+type batchResolver struct {
+	info     *IPInfo
+	queue    chan string
+	size     int
+	interval time.Duration
+}
+
+func newBatchResolver(info *IPInfo, size int, interval time.Duration) *batchResolver {
+	return &batchResolver{
+		info:     info,
+		queue:    make(chan string, batchQueueSize),
+		size:     size,
+		interval: interval,
+	}
+}
+
+// enqueue schedules the ip to be resolved. The ip is dropped if the queue is full.
+func (b *batchResolver) enqueue(ip string) {
+	select {
+	case b.queue <- ip:
+	default:
+		log.Warnln(logTag, ": batch queue is full, dropping", ip)
+	}
+}
+
+func (b *batchResolver) run() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	pending := make(map[string]bool)
+	for {
+		select {
+		case ip := <-b.queue:
+			if _, ok := b.info.Cached(ip); !ok {
+				pending[ip] = true
+			}
+			if len(pending) >= b.size {
+				b.resolve(pending)
+				pending = make(map[string]bool)
+			}
+		case <-ticker.C:
+			if len(pending) > 0 {
+				b.resolve(pending)
+				pending = make(map[string]bool)
+			}
+		}
+	}
+}
+
+func (b *batchResolver) resolve(pending map[string]bool) {
+	ips := make([]string, 0, len(pending))
+	for ip := range pending {
+		ips = append(ips, ip)
+	}
+	b.info.LookupBatch(ips)
+}
+
+// LookupBatch resolves the information of the given ips concurrently. The
+// ips that couldn't be resolved are absent from the returned map.
+func (info *IPInfo) LookupBatch(ips []string) map[string]*IPLookup {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]*IPLookup)
+		sem     = make(chan struct{}, batchConcurrency)
+		seen    = make(map[string]bool)
+	)
+	for _, ip := range ips {
+		if seen[ip] {
+			continue
+		}
+		seen[ip] = true
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ip string) {
+			defer func() { <-sem; wg.Done() }()
+			ipLookup, err := info.Lookup(ip)
+			if err != nil {
+				log.Errorln(logTag, ": error resolving", ip, ":", err)
+				return
+			}
+			mu.Lock()
+			results[ip] = ipLookup
+			mu.Unlock()
+		}(ip)
+	}
+	wg.Wait()
+	return results
+}
+
+// Enqueue schedules the ip to be resolved and cached in the background.
+func (info *IPInfo) Enqueue(ip string) {
+	info.batch.enqueue(ip)
+}
+
+// Queued returns the number of ips waiting to be resolved and the capacity
+// of the queue.
+func (info *IPInfo) Queued() (int, int) {
+	return len(info.batch.queue), cap(info.batch.queue)
+}
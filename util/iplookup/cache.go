@@ -0,0 +1,83 @@
+package iplookup
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruCache is a fixed size cache of IPLookup information that evicts the least
+// recently used entries first. Entries older than the ttl are treated as absent.
+type lruCache struct {
+	sync.Mutex
+	size  int
+	ttl   time.Duration
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	ip        string
+	ipLookup  *IPLookup
+	expiresAt time.Time
+}
+
+func newLRUCache(size int, ttl time.Duration) *lruCache {
+	return &lruCache{
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+func (c *lruCache) get(ip string) (*IPLookup, bool) {
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.items[ip]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if c.ttl > 0 && time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return entry.ipLookup, true
+}
+
+func (c *lruCache) add(ip string, ipLookup *IPLookup) {
+	c.Lock()
+	defer c.Unlock()
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.items[ip]; ok {
+		entry := elem.Value.(*cacheEntry)
+		entry.ipLookup = ipLookup
+		entry.expiresAt = expiresAt
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.items[ip] = c.ll.PushFront(&cacheEntry{ip, ipLookup, expiresAt})
+	if c.size > 0 && c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+func (c *lruCache) purge() {
+	c.Lock()
+	defer c.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+func (c *lruCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.ll.Len()
+}
+
+func (c *lruCache) removeElement(elem *list.Element) {
+	c.ll.Remove(elem)
+	delete(c.items, elem.Value.(*cacheEntry).ip)
+}
@@ -0,0 +1,41 @@
+package iplookup
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLRUCache(t *testing.T) {
+	Convey("Least recently used entries are evicted", t, func() {
+		c := newLRUCache(2, time.Hour)
+		c.add("1.1.1.1", &IPLookup{Query: "1.1.1.1"})
+		c.add("8.8.8.8", &IPLookup{Query: "8.8.8.8"})
+		_, ok := c.get("1.1.1.1")
+		So(ok, ShouldBeTrue)
+
+		c.add("9.9.9.9", &IPLookup{Query: "9.9.9.9"})
+		So(c.len(), ShouldEqual, 2)
+		_, ok = c.get("8.8.8.8")
+		So(ok, ShouldBeFalse)
+		_, ok = c.get("1.1.1.1")
+		So(ok, ShouldBeTrue)
+	})
+
+	Convey("Expired entries are absent", t, func() {
+		c := newLRUCache(2, time.Millisecond)
+		c.add("1.1.1.1", &IPLookup{Query: "1.1.1.1"})
+		time.Sleep(5 * time.Millisecond)
+		_, ok := c.get("1.1.1.1")
+		So(ok, ShouldBeFalse)
+		So(c.len(), ShouldEqual, 0)
+	})
+
+	Convey("Purge removes all the entries", t, func() {
+		c := newLRUCache(2, time.Hour)
+		c.add("1.1.1.1", &IPLookup{Query: "1.1.1.1"})
+		c.purge()
+		So(c.len(), ShouldEqual, 0)
+	})
+}
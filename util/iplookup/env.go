@@ -0,0 +1,50 @@
+package iplookup
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	logTag                   = "[iplookup]"
+	envProviders             = "IPLOOKUP_PROVIDERS"
+	envIPInfoToken           = "IPINFO_TOKEN"
+	envGeoIPDBPath           = "GEOIP_DB_PATH"
+	envGeoIPDBReloadInterval = "GEOIP_DB_RELOAD_INTERVAL"
+	envCacheSize             = "IPLOOKUP_CACHE_SIZE"
+	envCacheTTL              = "IPLOOKUP_CACHE_TTL"
+	envBatchSize             = "IPLOOKUP_BATCH_SIZE"
+	envBatchInterval         = "IPLOOKUP_BATCH_INTERVAL"
+	defaultReloadInterval    = time.Minute
+	defaultCacheSize         = 10000
+	defaultCacheTTL          = 24 * time.Hour
+)
+
+func intFromEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i <= 0 {
+		log.Errorln(logTag, ": invalid value for", key, ":", value)
+		return defaultValue
+	}
+	return i
+}
+
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Errorln(logTag, ": invalid duration for", key, ":", err)
+		return defaultValue
+	}
+	return d
+}
@@ -1,15 +1,10 @@
 package iplookup
 
 import (
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"sync"
 )
 
-const ipLookupURL = "http://extreme-ip-lookup.com/json/"
-
 // Info is the information associated with an IP address provided by ip-lookup service.
 type Info int
 
@@ -37,14 +32,16 @@ var (
 	once     sync.Once
 )
 
-// IPInfo maintains a cache to hold IpLookup information to avoid redundant
-// network requests made for the same IP address.
+// IPInfo maintains an LRU cache with a TTL to hold IpLookup information to
+// avoid redundant network requests made for the same IP address. The
+// information is resolved via the chain of configured providers.
 type IPInfo struct {
-	sync.Mutex
-	cache map[string]*IPLookup
+	cache    *lruCache
+	provider Provider
+	batch    *batchResolver
 }
 
-// IPLookup represents the response received from the ip-llokup service.
+// IPLookup represents the information of an IP address resolved by a provider.
 type IPLookup struct {
 	BusinessName    string `json:"businessName"`
 	BusinessWebsite string `json:"businessWebsite"`
@@ -66,7 +63,12 @@ type IPLookup struct {
 // Instance returns the singleton instance of IPInfo.
 func Instance() *IPInfo {
 	once.Do(func() {
-		instance = &IPInfo{cache: make(map[string]*IPLookup)}
+		instance = &IPInfo{cache: newLRUCache(intFromEnv(envCacheSize, defaultCacheSize),
+			durationFromEnv(envCacheTTL, defaultCacheTTL))}
+		instance.batch = newBatchResolver(instance, intFromEnv(envBatchSize, defaultBatchSize),
+			durationFromEnv(envBatchInterval, defaultBatchInterval))
+		instance.provider = newChain(instance)
+		go instance.batch.run()
 	})
 	return instance
 }
@@ -74,46 +76,42 @@ func Instance() *IPInfo {
 // Cached checks if the info for the ipAddr is present in the cache. If so
 // we return the result from the cache itself.
 func (info *IPInfo) Cached(ipAddr string) (*IPLookup, bool) {
-	info.Lock()
-	defer info.Unlock()
-	if ip, ok := info.cache[ipAddr]; ok {
-		return ip, true
-	}
-	return nil, false
+	return info.cache.get(ipAddr)
 }
 
 // Cache stores the IP information i.e. IPLookup in the cache.
 func (info *IPInfo) Cache(ip string, ipLookup *IPLookup) {
-	info.Lock()
-	defer info.Unlock()
-	info.cache[ip] = ipLookup
+	info.cache.add(ip, ipLookup)
 }
 
-// Lookup fetches the ip information from the ip-lookup service. A request to
-// ip-lookup service is made only when the information is not available in the cache.
-func (info *IPInfo) Lookup(ip string) (*IPLookup, error) {
-	if ip, ok := info.Cached(ip); ok {
-		return ip, nil
-	}
+// Purge clears all the cached IP information.
+func (info *IPInfo) Purge() {
+	info.cache.purge()
+}
 
-	response, err := http.Get(ipLookupURL + ip)
+// Lookup fetches the ip information from the configured providers. The lookup
+// is made only when the information is not available in the cache. Internal
+// ips are never looked up and are reported with the StatusInternal status.
+func (info *IPInfo) Lookup(ip string) (*IPLookup, error) {
+	ip, err := Normalize(ip)
 	if err != nil {
 		return nil, err
 	}
-	defer response.Body.Close()
+	if IsInternal(ip) {
+		return &IPLookup{Query: ip, IPType: StatusInternal, Status: StatusInternal}, nil
+	}
 
-	responseBody, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return nil, err
+	if ip, ok := info.Cached(ip); ok {
+		return ip, nil
 	}
 
-	var ipLookup IPLookup
-	if err := json.Unmarshal(responseBody, &ipLookup); err != nil {
+	ipLookup, err := info.provider.Lookup(ip)
+	if err != nil {
 		return nil, err
 	}
 
-	info.Cache(ip, &ipLookup)
-	return &ipLookup, nil
+	info.Cache(ip, ipLookup)
+	return ipLookup, nil
 }
 
 // Get returns the specific field of information i.e. Info from IPLookup.
@@ -155,7 +153,7 @@ func (info *IPInfo) Get(field Info, ip string) (string, error) {
 	case Status:
 		ipInfo = ipLookup.Status
 	default:
-		return "", fmt.Errorf("cannot fetch %v from %s", field, ipLookupURL)
+		return "", fmt.Errorf("cannot fetch %v from %s", field, info.provider.Name())
 	}
 
 	return ipInfo, nil
@@ -0,0 +1,131 @@
+package iplookup
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	statusSuccess = "success"
+	statusFail    = "fail"
+)
+
+// maxmindDB resolves the ip information from a local MaxMind GeoLite2/GeoIP2
+// City database. The database is reopened whenever the file is replaced.
+type maxmindDB struct {
+	sync.RWMutex
+	path     string
+	reader   *geoip2.Reader
+	modTime  time.Time
+	onReload func()
+}
+
+func openMaxMind(path string) (*maxmindDB, error) {
+	db := &maxmindDB{path: path}
+	if _, err := db.reload(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// reload reopens the database if the file has been modified since it was
+// last opened and reports whether the database was reloaded.
+func (db *maxmindDB) reload() (bool, error) {
+	stat, err := os.Stat(db.path)
+	if err != nil {
+		return false, err
+	}
+
+	db.RLock()
+	unchanged := db.reader != nil && stat.ModTime().Equal(db.modTime)
+	db.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	reader, err := geoip2.Open(db.path)
+	if err != nil {
+		return false, fmt.Errorf("unable to open geoip database %s: %v", db.path, err)
+	}
+
+	db.Lock()
+	old := db.reader
+	db.reader = reader
+	db.modTime = stat.ModTime()
+	db.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return true, nil
+}
+
+// watch periodically checks the database file for changes.
+func (db *maxmindDB) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		reloaded, err := db.reload()
+		if err != nil {
+			log.Errorln(logTag, ": error reloading geoip database:", err)
+			continue
+		}
+		if reloaded {
+			log.Println(logTag, ": reloaded geoip database", db.path)
+			if db.onReload != nil {
+				db.onReload()
+			}
+		}
+	}
+}
+
+func (db *maxmindDB) Name() string {
+	return ProviderMaxMind
+}
+
+func (db *maxmindDB) Health() error {
+	db.RLock()
+	defer db.RUnlock()
+	if db.reader == nil {
+		return fmt.Errorf("geoip database %s isn't loaded", db.path)
+	}
+	return nil
+}
+
+func (db *maxmindDB) Lookup(ip string) (*IPLookup, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return nil, fmt.Errorf("invalid ip address %s", ip)
+	}
+
+	db.RLock()
+	record, err := db.reader.City(addr)
+	db.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	ipLookup := &IPLookup{
+		City:        record.City.Names["en"],
+		Continent:   record.Continent.Names["en"],
+		Country:     record.Country.Names["en"],
+		CountryCode: record.Country.IsoCode,
+		Query:       ip,
+		Status:      statusSuccess,
+	}
+	if len(record.Subdivisions) > 0 {
+		ipLookup.Region = record.Subdivisions[0].Names["en"]
+	}
+	if record.Country.IsoCode == "" {
+		ipLookup.Status = statusFail
+	} else {
+		ipLookup.Lat = strconv.FormatFloat(record.Location.Latitude, 'f', -1, 64)
+		ipLookup.Lon = strconv.FormatFloat(record.Location.Longitude, 'f', -1, 64)
+	}
+	return ipLookup, nil
+}
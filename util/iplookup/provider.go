@@ -0,0 +1,160 @@
+package iplookup
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/health"
+)
+
+// Supported geo enrichment providers.
+const (
+	ProviderMaxMind         = "maxmind"
+	ProviderIPInfo          = "ipinfo"
+	ProviderIPAPI           = "ip-api"
+	ProviderExtremeIPLookup = "extreme-ip-lookup"
+)
+
+// Provider resolves the geo information of an ip address.
+type Provider interface {
+	// Name returns the unique name of the provider.
+	Name() string
+
+	// Lookup returns the information of the ip address.
+	Lookup(ip string) (*IPLookup, error)
+
+	// Health returns a non-nil error if the provider is currently unable to serve lookups.
+	Health() error
+}
+
+// monitored records the outcome of the last lookup made via the provider,
+// which is reported as its health.
+type monitored struct {
+	Provider
+	mu      sync.RWMutex
+	lastErr error
+}
+
+func (m *monitored) Lookup(ip string) (*IPLookup, error) {
+	ipLookup, err := m.Provider.Lookup(ip)
+	m.mu.Lock()
+	m.lastErr = err
+	m.mu.Unlock()
+	return ipLookup, err
+}
+
+func (m *monitored) Health() error {
+	if err := m.Provider.Health(); err != nil {
+		return err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.lastErr
+}
+
+// chain tries the providers in order and returns the first successful lookup.
+type chain []Provider
+
+func (c chain) Name() string {
+	names := make([]string, len(c))
+	for i, p := range c {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c chain) Lookup(ip string) (*IPLookup, error) {
+	var errs []string
+	for _, p := range c {
+		ipLookup, err := p.Lookup(ip)
+		if err == nil {
+			return ipLookup, nil
+		}
+		log.Errorln(logTag, ":", p.Name(), "failed to lookup", ip, ":", err)
+		errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+	}
+	return nil, fmt.Errorf("unable to lookup %s: %s", ip, strings.Join(errs, "; "))
+}
+
+// Health reports the chain healthy as long as any of the providers is healthy.
+func (c chain) Health() error {
+	var errs []string
+	for _, p := range c {
+		err := p.Health()
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", p.Name(), err))
+	}
+	return fmt.Errorf("all providers are down: %s", strings.Join(errs, "; "))
+}
+
+// newProvider returns the provider registered against the name.
+func newProvider(name string, info *IPInfo) (Provider, error) {
+	switch name {
+	case ProviderMaxMind:
+		path := os.Getenv(envGeoIPDBPath)
+		if path == "" {
+			return nil, fmt.Errorf("%s must be set to use the %s provider", envGeoIPDBPath, ProviderMaxMind)
+		}
+		db, err := openMaxMind(path)
+		if err != nil {
+			return nil, err
+		}
+		db.onReload = info.Purge
+		go db.watch(durationFromEnv(envGeoIPDBReloadInterval, defaultReloadInterval))
+		return db, nil
+	case ProviderIPInfo:
+		return &ipInfoIO{token: os.Getenv(envIPInfoToken)}, nil
+	case ProviderIPAPI:
+		return &ipAPI{}, nil
+	case ProviderExtremeIPLookup:
+		return &extremeIPLookup{}, nil
+	default:
+		return nil, fmt.Errorf("unknown provider %s", name)
+	}
+}
+
+// providerNames returns the configured providers in the order of preference.
+// The local database, if present, is preferred over the extreme-ip-lookup service.
+func providerNames() []string {
+	if value := os.Getenv(envProviders); value != "" {
+		var names []string
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	if os.Getenv(envGeoIPDBPath) != "" {
+		return []string{ProviderMaxMind, ProviderExtremeIPLookup}
+	}
+	return []string{ProviderExtremeIPLookup}
+}
+
+// newChain initializes the configured providers and registers their health checks.
+func newChain(info *IPInfo) chain {
+	var c chain
+	for _, name := range providerNames() {
+		p, err := newProvider(name, info)
+		if err != nil {
+			log.Errorln(logTag, ": skipping provider", name, ":", err)
+			continue
+		}
+		m := &monitored{Provider: p}
+		health.Register("iplookup."+p.Name(), m.Health, false)
+		c = append(c, m)
+	}
+	if len(c) == 0 {
+		log.Errorln(logTag, ": no valid provider configured, using", ProviderExtremeIPLookup)
+		m := &monitored{Provider: &extremeIPLookup{}}
+		health.Register("iplookup."+ProviderExtremeIPLookup, m.Health, false)
+		c = append(c, m)
+	}
+	return c
+}
@@ -0,0 +1,163 @@
+package iplookup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ipLookupURL = "http://extreme-ip-lookup.com/json/"
+	ipInfoURL   = "https://ipinfo.io/"
+	ipAPIURL    = "http://ip-api.com/json/"
+	ipAPIFields = "status,message,continent,country,countryCode,regionName,city,lat,lon,isp,org,query"
+)
+
+var httpClient = &http.Client{Timeout: 5 * time.Second}
+
+func getJSON(rawURL string, v interface{}) error {
+	response, err := httpClient.Get(rawURL)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", response.StatusCode, string(responseBody))
+	}
+	return json.Unmarshal(responseBody, v)
+}
+
+// extremeIPLookup resolves the information via extreme-ip-lookup.com.
+type extremeIPLookup struct{}
+
+func (p *extremeIPLookup) Name() string {
+	return ProviderExtremeIPLookup
+}
+
+func (p *extremeIPLookup) Lookup(ip string) (*IPLookup, error) {
+	var ipLookup IPLookup
+	if err := getJSON(ipLookupURL+url.PathEscape(ip), &ipLookup); err != nil {
+		return nil, err
+	}
+	return &ipLookup, nil
+}
+
+func (p *extremeIPLookup) Health() error {
+	return nil
+}
+
+// ipInfoIO resolves the information via ipinfo.io.
+type ipInfoIO struct {
+	token string
+}
+
+type ipInfoResponse struct {
+	IP       string `json:"ip"`
+	Hostname string `json:"hostname"`
+	City     string `json:"city"`
+	Region   string `json:"region"`
+	Country  string `json:"country"`
+	Loc      string `json:"loc"`
+	Org      string `json:"org"`
+	Bogon    bool   `json:"bogon"`
+}
+
+func (p *ipInfoIO) Name() string {
+	return ProviderIPInfo
+}
+
+func (p *ipInfoIO) Lookup(ip string) (*IPLookup, error) {
+	rawURL := ipInfoURL + url.PathEscape(ip) + "/json"
+	if p.token != "" {
+		rawURL += "?token=" + url.QueryEscape(p.token)
+	}
+
+	var response ipInfoResponse
+	if err := getJSON(rawURL, &response); err != nil {
+		return nil, err
+	}
+
+	ipLookup := &IPLookup{
+		City:        response.City,
+		CountryCode: response.Country,
+		IPName:      response.Hostname,
+		Org:         response.Org,
+		Query:       ip,
+		Region:      response.Region,
+		Status:      statusSuccess,
+	}
+	if response.Bogon {
+		ipLookup.Status = statusFail
+	}
+	if coordinates := strings.Split(response.Loc, ","); len(coordinates) == 2 {
+		ipLookup.Lat, ipLookup.Lon = coordinates[0], coordinates[1]
+	}
+	return ipLookup, nil
+}
+
+func (p *ipInfoIO) Health() error {
+	return nil
+}
+
+// ipAPI resolves the information via ip-api.com.
+type ipAPI struct{}
+
+type ipAPIResponse struct {
+	Status      string  `json:"status"`
+	Message     string  `json:"message"`
+	Continent   string  `json:"continent"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"countryCode"`
+	RegionName  string  `json:"regionName"`
+	City        string  `json:"city"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	ISP         string  `json:"isp"`
+	Org         string  `json:"org"`
+	Query       string  `json:"query"`
+}
+
+func (p *ipAPI) Name() string {
+	return ProviderIPAPI
+}
+
+func (p *ipAPI) Lookup(ip string) (*IPLookup, error) {
+	var response ipAPIResponse
+	if err := getJSON(ipAPIURL+url.PathEscape(ip)+"?fields="+ipAPIFields, &response); err != nil {
+		return nil, err
+	}
+	if response.Status != statusSuccess && response.Message != "private range" && response.Message != "reserved range" {
+		return nil, fmt.Errorf("lookup failed: %s", response.Message)
+	}
+
+	ipLookup := &IPLookup{
+		City:        response.City,
+		Continent:   response.Continent,
+		Country:     response.Country,
+		CountryCode: response.CountryCode,
+		ISP:         response.ISP,
+		Org:         response.Org,
+		Query:       response.Query,
+		Region:      response.RegionName,
+		Status:      response.Status,
+	}
+	if response.Status == statusSuccess {
+		ipLookup.Lat = strconv.FormatFloat(response.Lat, 'f', -1, 64)
+		ipLookup.Lon = strconv.FormatFloat(response.Lon, 'f', -1, 64)
+	}
+	return ipLookup, nil
+}
+
+func (p *ipAPI) Health() error {
+	return nil
+}
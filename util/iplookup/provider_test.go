@@ -0,0 +1,46 @@
+package iplookup
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeProvider struct {
+	name string
+	err  error
+}
+
+func (p *fakeProvider) Name() string { return p.name }
+
+func (p *fakeProvider) Lookup(ip string) (*IPLookup, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &IPLookup{Query: ip, Org: p.name}, nil
+}
+
+func (p *fakeProvider) Health() error { return nil }
+
+func TestChain(t *testing.T) {
+	Convey("Lookup fails over to the next provider", t, func() {
+		down := &monitored{Provider: &fakeProvider{name: "down", err: errors.New("timeout")}}
+		up := &monitored{Provider: &fakeProvider{name: "up"}}
+		c := chain{down, up}
+
+		ipLookup, err := c.Lookup("1.1.1.1")
+		So(err, ShouldBeNil)
+		So(ipLookup.Org, ShouldEqual, "up")
+		So(down.Health(), ShouldNotBeNil)
+		So(up.Health(), ShouldBeNil)
+		So(c.Health(), ShouldBeNil)
+	})
+
+	Convey("Lookup fails if all the providers fail", t, func() {
+		c := chain{&monitored{Provider: &fakeProvider{name: "down", err: errors.New("timeout")}}}
+		_, err := c.Lookup("1.1.1.1")
+		So(err, ShouldNotBeNil)
+		So(c.Health(), ShouldNotBeNil)
+	})
+}
@@ -1,14 +1,41 @@
 package iplookup
 
 import (
-	"errors"
 	"net"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	envTrustedProxies  = "TRUSTED_PROXIES"
+	envClientIPHeaders = "CLIENT_IP_HEADERS"
 )
 
-var cidrs []*net.IPNet
+// Headers from which the client ip can be extracted.
+const (
+	HeaderXForwardedFor  = "X-Forwarded-For"
+	HeaderXRealIP        = "X-Real-Ip"
+	HeaderForwarded      = "Forwarded"
+	HeaderCFConnectingIP = "Cf-Connecting-Ip"
+)
+
+var defaultClientIPHeaders = []string{HeaderXForwardedFor, HeaderXRealIP}
+
+var (
+	cidrs        []*net.IPNet
+	resolver     *ipResolver
+	resolverOnce sync.Once
+)
 
+// The private networks are trusted as proxies unless TRUSTED_PROXIES is set.
+// List of private CIDR blocks can be seen on :
+//
+// https://en.wikipedia.org/wiki/Private_network
+// https://en.wikipedia.org/wiki/Link-local_address
 func init() {
 	maxCIDRBlocks := []string{
 		"127.0.0.1/8",    // localhost
@@ -28,56 +55,169 @@ func init() {
 	}
 }
 
-// isLocalAddress works by checking if the address is under private CIDR blocks.
-// List of private CIDR blocks can be seen on :
-//
-// https://en.wikipedia.org/wiki/Private_network
-// https://en.wikipedia.org/wiki/Link-local_address
-func isPrivateAddress(address string) (bool, error) {
-	ipAddress := net.ParseIP(address)
-	if ipAddress == nil {
-		return false, errors.New("address is not valid")
-	}
+// ipResolver extracts the client ip from the request headers set by the
+// trusted proxies. Headers are only honored when the request is received
+// from a trusted proxy, otherwise they could be spoofed by the client.
+type ipResolver struct {
+	trusted []*net.IPNet
+	headers []string
+}
 
-	for i := range cidrs {
-		if cidrs[i].Contains(ipAddress) {
-			return true, nil
+// newResolver returns a resolver that trusts the given proxies, each of which
+// can either be an ip or a cidr block, and consults the headers in order.
+func newResolver(proxies, headers []string) (*ipResolver, error) {
+	r := &ipResolver{}
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, cidr, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		r.trusted = append(r.trusted, cidr)
+	}
+	for _, header := range headers {
+		header = strings.TrimSpace(header)
+		if header != "" {
+			r.headers = append(r.headers, http.CanonicalHeaderKey(header))
 		}
 	}
+	return r, nil
+}
+
+func defaultResolver() *ipResolver {
+	resolverOnce.Do(func() {
+		headers := defaultClientIPHeaders
+		if value := os.Getenv(envClientIPHeaders); value != "" {
+			headers = strings.Split(value, ",")
+		}
 
-	return false, nil
+		var err error
+		if value := os.Getenv(envTrustedProxies); value != "" {
+			resolver, err = newResolver(strings.Split(value, ","), headers)
+			if err == nil {
+				return
+			}
+			log.Errorln(logTag, ": invalid", envTrustedProxies, ", trusting the private networks only:", err)
+		}
+		resolver, _ = newResolver(nil, headers)
+		resolver.trusted = cidrs
+	})
+	return resolver
 }
 
-// FromRequest identifies the remote ip from an http request
-func FromRequest(r *http.Request) string {
-	// Fetch header value
-	xRealIP := r.Header.Get("X-Real-Ip")
-	xForwardedFor := r.Header.Get("X-Forwarded-For")
-
-	// If both empty, return IP from remote address
-	if xRealIP == "" && xForwardedFor == "" {
-		var remoteIP string
-
-		// If there are colon in remote address, remove the port number
-		// otherwise, return remote address as is
-		if strings.ContainsRune(r.RemoteAddr, ':') {
-			remoteIP, _, _ = net.SplitHostPort(r.RemoteAddr)
-		} else {
-			remoteIP = r.RemoteAddr
+func (r *ipResolver) isTrusted(address string) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, cidr := range r.trusted {
+		if cidr.Contains(ip) {
+			return true
 		}
+	}
+	return false
+}
 
+// clientIP walks the configured headers and returns the first client ip found.
+func (r *ipResolver) clientIP(req *http.Request) string {
+	remoteIP := req.RemoteAddr
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		remoteIP = host
+	}
+	if !r.isTrusted(remoteIP) {
 		return remoteIP
 	}
 
-	// Check list of IP in X-Forwarded-For and return the first global address
-	for _, address := range strings.Split(xForwardedFor, ",") {
-		address = strings.TrimSpace(address)
-		isPrivate, err := isPrivateAddress(address)
-		if !isPrivate && err == nil {
+	for _, header := range r.headers {
+		values := req.Header[header]
+		if len(values) == 0 {
+			continue
+		}
+		var addresses []string
+		switch header {
+		case HeaderXForwardedFor:
+			addresses = parseXForwardedFor(values)
+		case HeaderForwarded:
+			addresses = parseForwarded(values)
+		default:
+			addresses = []string{strings.TrimSpace(values[0])}
+		}
+		if ip := r.rightmostUntrusted(addresses); ip != "" {
+			return ip
+		}
+	}
+	return remoteIP
+}
+
+// rightmostUntrusted returns the rightmost address that isn't a trusted proxy,
+// since the addresses on its left are controlled by the client. The leftmost
+// address is returned if the request only passed through the trusted proxies.
+func (r *ipResolver) rightmostUntrusted(addresses []string) string {
+	var leftmost string
+	for i := len(addresses) - 1; i >= 0; i-- {
+		address, err := Normalize(addresses[i])
+		if err != nil {
+			// the chain can't be trusted beyond an invalid hop
+			break
+		}
+		if !r.isTrusted(address) {
 			return address
 		}
+		leftmost = address
+	}
+	return leftmost
+}
+
+func parseXForwardedFor(values []string) []string {
+	var addresses []string
+	for _, value := range values {
+		for _, address := range strings.Split(value, ",") {
+			addresses = append(addresses, strings.TrimSpace(address))
+		}
+	}
+	return addresses
+}
+
+// parseForwarded extracts the "for" parameters of the Forwarded header as
+// defined in RFC 7239, e.g. for=192.0.2.60;proto=http, for="[2001:db8::17]:4711"
+func parseForwarded(values []string) []string {
+	var addresses []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				tokens := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+				if len(tokens) != 2 || !strings.EqualFold(tokens[0], "for") {
+					continue
+				}
+				address := strings.Trim(tokens[1], `"`)
+				if host, _, err := net.SplitHostPort(address); err == nil {
+					address = host
+				}
+				address = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+				addresses = append(addresses, address)
+			}
+		}
 	}
+	return addresses
+}
 
-	// If nothing succeed, return X-Real-IP
-	return xRealIP
+// FromRequest identifies the remote ip from an http request and returns its
+// normalized form. The client ip headers are only honored if the request is
+// received from a trusted proxy.
+func FromRequest(r *http.Request) string {
+	ip := defaultResolver().clientIP(r)
+	if normalized, err := Normalize(ip); err == nil {
+		return normalized
+	}
+	return ip
 }
@@ -0,0 +1,59 @@
+package iplookup
+
+import (
+	"net/http"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newRequest(remoteAddr string, headers map[string]string) *http.Request {
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestClientIP(t *testing.T) {
+	r, err := newResolver([]string{"10.0.0.0/8", "203.0.113.7"},
+		[]string{HeaderForwarded, HeaderXForwardedFor, HeaderXRealIP})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("Headers from untrusted peers are ignored", t, func() {
+		req := newRequest("198.51.100.1:1234", map[string]string{HeaderXForwardedFor: "1.1.1.1"})
+		So(r.clientIP(req), ShouldEqual, "198.51.100.1")
+	})
+
+	Convey("Rightmost untrusted address is selected", t, func() {
+		req := newRequest("10.0.0.2:1234", map[string]string{
+			HeaderXForwardedFor: "6.6.6.6, 1.1.1.1, 203.0.113.7, 10.0.0.3",
+		})
+		So(r.clientIP(req), ShouldEqual, "1.1.1.1")
+	})
+
+	Convey("Leftmost address is selected if all hops are trusted", t, func() {
+		req := newRequest("10.0.0.2:1234", map[string]string{HeaderXForwardedFor: "10.0.0.5, 10.0.0.3"})
+		So(r.clientIP(req), ShouldEqual, "10.0.0.5")
+	})
+
+	Convey("Forwarded header", t, func() {
+		req := newRequest("10.0.0.2:1234", map[string]string{
+			HeaderForwarded: `for="[2001:db8::17]:4711";proto=https, for=10.0.0.3`,
+		})
+		So(r.clientIP(req), ShouldEqual, "2001:db8::17")
+	})
+
+	Convey("Single value headers", t, func() {
+		req := newRequest("10.0.0.2:1234", map[string]string{HeaderXRealIP: "1.1.1.1"})
+		So(r.clientIP(req), ShouldEqual, "1.1.1.1")
+	})
+
+	Convey("Remote address without headers", t, func() {
+		req := newRequest("10.0.0.2:1234", nil)
+		So(r.clientIP(req), ShouldEqual, "10.0.0.2")
+	})
+}
@@ -0,0 +1,386 @@
+// Package jsonschema validates json documents against the subset of JSON
+// Schema that describes the shape of the documents indexed in elasticsearch.
+// The schemas using keywords beyond the subset are rejected rather than
+// partially enforced.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// keywords are the supported keywords, along with the annotations which
+// don't affect the validation.
+var keywords = map[string]bool{
+	"type":                 true,
+	"properties":           true,
+	"required":             true,
+	"additionalProperties": true,
+	"maxProperties":        true,
+	"items":                true,
+	"minItems":             true,
+	"maxItems":             true,
+	"enum":                 true,
+	"minimum":              true,
+	"maximum":              true,
+	"exclusiveMinimum":     true,
+	"exclusiveMaximum":     true,
+	"minLength":            true,
+	"maxLength":            true,
+	"pattern":              true,
+	"$schema":              true,
+	"$id":                  true,
+	"title":                true,
+	"description":          true,
+	"default":              true,
+	"examples":             true,
+}
+
+var types = map[string]bool{
+	"object":  true,
+	"array":   true,
+	"string":  true,
+	"number":  true,
+	"integer": true,
+	"boolean": true,
+	"null":    true,
+}
+
+// Schema is a compiled schema.
+type Schema struct {
+	types                []string
+	properties           map[string]*Schema
+	required             []string
+	additionalProperties *Schema
+	noAdditional         bool
+	maxProperties        *int
+	items                *Schema
+	minItems, maxItems   *int
+	enum                 []interface{}
+	minimum, maximum     *float64
+	exclusiveMinimum     *float64
+	exclusiveMaximum     *float64
+	minLength, maxLength *int
+	pattern              *regexp.Regexp
+}
+
+// ValidationError locates a violation of the schema by the json pointer of
+// the invalid value.
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// Compile parses the schema.
+func Compile(raw []byte) (*Schema, error) {
+	return compile(raw, "")
+}
+
+func compile(raw json.RawMessage, path string) (*Schema, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || fields == nil {
+		return nil, fmt.Errorf("schema at %s must be an object", pointer(path))
+	}
+	var unsupported []string
+	for key := range fields {
+		if !keywords[key] {
+			unsupported = append(unsupported, key)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		return nil, fmt.Errorf(`unsupported keywords "%s" in schema at %s`, strings.Join(unsupported, `", "`), pointer(path))
+	}
+
+	s := &Schema{}
+	invalid := func(keyword string, err error) error {
+		return fmt.Errorf(`invalid "%s" in schema at %s: %v`, keyword, pointer(path), err)
+	}
+	if t, ok := fields["type"]; ok {
+		var name string
+		if err := json.Unmarshal(t, &name); err == nil {
+			s.types = []string{name}
+		} else if err := json.Unmarshal(t, &s.types); err != nil {
+			return nil, invalid("type", fmt.Errorf("must be a type or an array of types"))
+		}
+		for _, name := range s.types {
+			if !types[name] {
+				return nil, invalid("type", fmt.Errorf(`unknown type "%s"`, name))
+			}
+		}
+	}
+	if p, ok := fields["properties"]; ok {
+		var properties map[string]json.RawMessage
+		if err := json.Unmarshal(p, &properties); err != nil {
+			return nil, invalid("properties", err)
+		}
+		s.properties = make(map[string]*Schema, len(properties))
+		for name, property := range properties {
+			var err error
+			if s.properties[name], err = compile(property, path+"/properties/"+escape(name)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if r, ok := fields["required"]; ok {
+		if err := json.Unmarshal(r, &s.required); err != nil {
+			return nil, invalid("required", err)
+		}
+	}
+	if a, ok := fields["additionalProperties"]; ok {
+		var allowed bool
+		if err := json.Unmarshal(a, &allowed); err == nil {
+			s.noAdditional = !allowed
+		} else {
+			var err error
+			if s.additionalProperties, err = compile(a, path+"/additionalProperties"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if i, ok := fields["items"]; ok {
+		var err error
+		if s.items, err = compile(i, path+"/items"); err != nil {
+			return nil, err
+		}
+	}
+	if e, ok := fields["enum"]; ok {
+		if err := json.Unmarshal(e, &s.enum); err != nil {
+			return nil, invalid("enum", err)
+		}
+	}
+	for keyword, limit := range map[string]**int{
+		"maxProperties": &s.maxProperties,
+		"minItems":      &s.minItems,
+		"maxItems":      &s.maxItems,
+		"minLength":     &s.minLength,
+		"maxLength":     &s.maxLength,
+	} {
+		if l, ok := fields[keyword]; ok {
+			var n int
+			if err := json.Unmarshal(l, &n); err != nil || n < 0 {
+				return nil, invalid(keyword, fmt.Errorf("must be a non-negative integer"))
+			}
+			*limit = &n
+		}
+	}
+	for keyword, limit := range map[string]**float64{
+		"minimum":          &s.minimum,
+		"maximum":          &s.maximum,
+		"exclusiveMinimum": &s.exclusiveMinimum,
+		"exclusiveMaximum": &s.exclusiveMaximum,
+	} {
+		if l, ok := fields[keyword]; ok {
+			var f float64
+			if err := json.Unmarshal(l, &f); err != nil {
+				return nil, invalid(keyword, fmt.Errorf("must be a number"))
+			}
+			*limit = &f
+		}
+	}
+	if p, ok := fields["pattern"]; ok {
+		var pattern string
+		if err := json.Unmarshal(p, &pattern); err != nil {
+			return nil, invalid("pattern", err)
+		}
+		var err error
+		if s.pattern, err = regexp.Compile(pattern); err != nil {
+			return nil, invalid("pattern", err)
+		}
+	}
+	return s, nil
+}
+
+// Validate validates the document, which must be decoded with its numbers
+// kept as json numbers, and returns the violations of the schema.
+func (s *Schema) Validate(doc interface{}) []ValidationError {
+	var errs []ValidationError
+	s.validate(doc, "", &errs)
+	return errs
+}
+
+// ValidateJSON decodes and validates the json document.
+func (s *Schema) ValidateJSON(raw []byte) ([]ValidationError, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return s.Validate(doc), nil
+}
+
+func (s *Schema) validate(value interface{}, path string, errs *[]ValidationError) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if len(s.types) > 0 && !s.hasType(value) {
+		fail("expected %s, got %s", strings.Join(s.types, " or "), typeOf(value))
+		return
+	}
+	if len(s.enum) > 0 {
+		normalized := normalize(value)
+		var found bool
+		for _, e := range s.enum {
+			if reflect.DeepEqual(normalized, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("must be one of the enumerated values")
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				fail(`missing required property "%s"`, name)
+			}
+		}
+		if s.maxProperties != nil && len(v) > *s.maxProperties {
+			fail("must have at most %d properties, got %d", *s.maxProperties, len(v))
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propertyPath := path + "/" + escape(name)
+			if property, ok := s.properties[name]; ok {
+				property.validate(v[name], propertyPath, errs)
+			} else if s.noAdditional {
+				*errs = append(*errs, ValidationError{Path: propertyPath, Message: "additional property isn't allowed"})
+			} else if s.additionalProperties != nil {
+				s.additionalProperties.validate(v[name], propertyPath, errs)
+			}
+		}
+	case []interface{}:
+		if s.minItems != nil && len(v) < *s.minItems {
+			fail("must have at least %d items, got %d", *s.minItems, len(v))
+		}
+		if s.maxItems != nil && len(v) > *s.maxItems {
+			fail("must have at most %d items, got %d", *s.maxItems, len(v))
+		}
+		if s.items != nil {
+			for i, item := range v {
+				s.items.validate(item, fmt.Sprintf("%s/%d", path, i), errs)
+			}
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.minLength != nil && length < *s.minLength {
+			fail("must be at least %d characters long", *s.minLength)
+		}
+		if s.maxLength != nil && length > *s.maxLength {
+			fail("must be at most %d characters long", *s.maxLength)
+		}
+		if s.pattern != nil && !s.pattern.MatchString(v) {
+			fail(`must match the pattern "%s"`, s.pattern)
+		}
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			fail("invalid number %s", v)
+			return
+		}
+		if s.minimum != nil && f < *s.minimum {
+			fail("must be at least %v", *s.minimum)
+		}
+		if s.maximum != nil && f > *s.maximum {
+			fail("must be at most %v", *s.maximum)
+		}
+		if s.exclusiveMinimum != nil && f <= *s.exclusiveMinimum {
+			fail("must be greater than %v", *s.exclusiveMinimum)
+		}
+		if s.exclusiveMaximum != nil && f >= *s.exclusiveMaximum {
+			fail("must be less than %v", *s.exclusiveMaximum)
+		}
+	}
+}
+
+func (s *Schema) hasType(value interface{}) bool {
+	actual := typeOf(value)
+	for _, t := range s.types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// typeOf returns the json type of the value, the numbers without a
+// fractional part are integers.
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	case json.Number:
+		if f, err := v.Float64(); err == nil && f == math.Trunc(f) && !math.IsInf(f, 0) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// normalize converts the json numbers into floats to compare the value with
+// the enumerated ones.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalize(item)
+		}
+		return normalized
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			normalized[name] = normalize(item)
+		}
+		return normalized
+	}
+	return value
+}
+
+// escape escapes the property name as a json pointer token.
+func escape(name string) string {
+	return strings.Replace(strings.Replace(name, "~", "~0", -1), "/", "~1", -1)
+}
+
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
@@ -0,0 +1,86 @@
+package jsonschema
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const productSchema = `{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "required": ["name", "price"],
+  "additionalProperties": false,
+  "properties": {
+    "name": {"type": "string", "minLength": 1, "maxLength": 20},
+    "price": {"type": "number", "minimum": 0},
+    "stock": {"type": "integer", "exclusiveMaximum": 1000},
+    "status": {"enum": ["active", "archived", 1]},
+    "sku": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]+$"},
+    "tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}},
+    "attrs": {"type": "object", "additionalProperties": {"type": ["string", "null"]}}
+  }
+}`
+
+func messages(errs []ValidationError) []string {
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return messages
+}
+
+func TestValidate(t *testing.T) {
+	s, err := Compile([]byte(productSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Convey("The valid documents pass", t, func() {
+		errs, err := s.ValidateJSON([]byte(`{"name": "shoes", "price": 10.5, "stock": 3.0, "status": 1, "sku": "ABC-1",
+			"tags": ["a"], "attrs": {"color": "red", "size": null}}`))
+		So(err, ShouldBeNil)
+		So(errs, ShouldBeEmpty)
+	})
+
+	Convey("The violations are located by their path", t, func() {
+		errs, err := s.ValidateJSON([]byte(`{"name": "", "stock": 1.5, "status": "draft", "sku": "abc",
+			"tags": ["a", 2, "c"], "attrs": {"a/b": 1}, "color": "red"}`))
+		So(err, ShouldBeNil)
+		So(messages(errs), ShouldResemble, []string{
+			`/: missing required property "price"`,
+			"/attrs/a~1b: expected string or null, got integer",
+			"/color: additional property isn't allowed",
+			"/name: must be at least 1 characters long",
+			`/sku: must match the pattern "^[A-Z]{3}-[0-9]+$"`,
+			"/status: must be one of the enumerated values",
+			"/stock: expected integer, got number",
+			"/tags: must have at most 2 items, got 3",
+			"/tags/1: expected string, got integer",
+		})
+	})
+
+	Convey("The bounds of the numbers are enforced", t, func() {
+		errs, _ := s.ValidateJSON([]byte(`{"name": "shoes", "price": -1, "stock": 1000}`))
+		So(messages(errs), ShouldResemble, []string{"/price: must be at least 0", "/stock: must be less than 1000"})
+	})
+}
+
+func TestCompile(t *testing.T) {
+	Convey("The unsupported keywords are rejected", t, func() {
+		_, err := Compile([]byte(`{"type": "object", "properties": {"a": {"oneOf": [], "$ref": "#"}}}`))
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, `unsupported keywords "$ref", "oneOf" in schema at /properties/a`)
+	})
+
+	Convey("The invalid schemas are rejected", t, func() {
+		_, err := Compile([]byte(`{"type": "text"}`))
+		So(err, ShouldNotBeNil)
+		_, err = Compile([]byte(`{"pattern": "("}`))
+		So(err, ShouldNotBeNil)
+		_, err = Compile([]byte(`{"minLength": -1}`))
+		So(err, ShouldNotBeNil)
+		_, err = Compile([]byte(`[]`))
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,363 @@
+// Package migration keeps the internal indices of arc in line with the
+// bodies the plugins expect. The indices are served through aliases, once
+// the body of an index changes its version is bumped and the documents are
+// migrated blue/green: a new index is created with the body, the documents
+// are reindexed into it and the alias is swapped over to it.
+package migration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	es7 "github.com/olivere/elastic/v7"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/state"
+)
+
+const (
+	logTag              = "[migration]"
+	envHistoryIndex     = "MIGRATIONS_ES_INDEX"
+	defaultHistoryIndex = ".migrations"
+	lockTTL             = 10 * time.Minute
+	lockPollInterval    = 2 * time.Second
+)
+
+// versionSuffix matches the suffix of the versioned indices, e.g. _v2.
+var versionSuffix = regexp.MustCompile(`_v[0-9]+$`)
+
+// Index is an internal index of arc, served through an alias.
+type Index struct {
+	// Name is the name of the alias the index is served through.
+	Name string
+	// Version is the version of the body, it must be bumped whenever the
+	// body changes in order to migrate the existing index.
+	Version int
+	// Body holds the settings and the mappings of the index.
+	Body string
+}
+
+// Record is an entry of the migration history.
+type Record struct {
+	Index       string    `json:"index"`
+	FromVersion int       `json:"from_version"`
+	ToVersion   int       `json:"to_version"`
+	Source      string    `json:"source,omitempty"`
+	Destination string    `json:"destination"`
+	Documents   int64     `json:"documents"`
+	StartedAt   time.Time `json:"started_at"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// current is the state of the index in the cluster.
+type current struct {
+	exists   bool
+	alias    bool
+	concrete string
+	version  int
+}
+
+// HistoryIndex returns the index recording the migrations.
+func HistoryIndex() string {
+	if index := os.Getenv(envHistoryIndex); index != "" {
+		return index
+	}
+	return defaultHistoryIndex
+}
+
+// VersionedName returns the name of the concrete index of the version, e.g. .users_v2.
+func VersionedName(name string, version int) string {
+	return name + "_v" + strconv.Itoa(version)
+}
+
+// IsVersionOf checks whether the concrete index is either the index itself,
+// as created before the migrations, or one of its versions.
+func IsVersionOf(concrete, name string) bool {
+	return concrete == name || versionSuffix.ReplaceAllString(concrete, "") == name
+}
+
+// Ensure creates the index if it doesn't exist, or migrates it if its
+// version is behind, and reports whether the index was created. The arc
+// instances take turns, the others wait for the migration to complete.
+// The documents written to the index while it's being reindexed are lost,
+// hence the migrations are meant to run on startup.
+func Ensure(ctx context.Context, idx Index) (bool, error) {
+	unlock, err := lock(ctx, idx.Name)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	cur, err := inspect(ctx, idx.Name)
+	if err != nil {
+		return false, fmt.Errorf("%s: unable to inspect index %s: %v", logTag, idx.Name, err)
+	}
+	if cur.exists && cur.version >= idx.Version {
+		return false, nil
+	}
+
+	body, err := withVersion(idx.Body, idx.Version, util.DocType())
+	if err != nil {
+		return false, fmt.Errorf("%s: invalid body of index %s: %v", logTag, idx.Name, err)
+	}
+	record := Record{
+		Index:       idx.Name,
+		FromVersion: cur.version,
+		ToVersion:   idx.Version,
+		Source:      cur.concrete,
+		Destination: VersionedName(idx.Name, idx.Version),
+		StartedAt:   time.Now(),
+	}
+	if err := migrate(ctx, idx.Name, cur, record.Destination, body, &record.Documents); err != nil {
+		return false, fmt.Errorf("%s: unable to migrate index %s to version %d: %v", logTag, idx.Name, idx.Version, err)
+	}
+	record.CompletedAt = time.Now()
+
+	if cur.exists {
+		log.Println(logTag, ": migrated index", idx.Name, "from version", cur.version, "to", idx.Version)
+	} else {
+		log.Println(logTag, ": created index", idx.Name, "at version", idx.Version)
+	}
+	if err := recordMigration(ctx, record); err != nil {
+		log.Errorln(logTag, ": unable to record the migration of index", idx.Name, ":", err)
+	}
+	return !cur.exists, nil
+}
+
+// migrate creates the destination index and, if the index exists, reindexes
+// its documents before swapping the alias over to the destination. The
+// previous versions are kept, apart from the indices created before the
+// migrations whose name is taken over by the alias.
+func migrate(ctx context.Context, name string, cur current, dest, body string, docs *int64) error {
+	client := util.GetClient7()
+	if dest == cur.concrete {
+		return fmt.Errorf("index %s lacks the version of its body", dest)
+	}
+
+	// drop the leftovers of an interrupted migration, the alias never
+	// points to the destination before the migration completes
+	exists, err := client.IndexExists(dest).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Warnln(logTag, ": deleting index", dest, "left over by an interrupted migration")
+		if _, err := client.DeleteIndex(dest).Do(ctx); err != nil {
+			return err
+		}
+	}
+
+	if !cur.exists {
+		_, err := client.CreateIndex(dest).Body(body).Do(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = client.Alias().Add(dest, name).Do(ctx)
+		return err
+	}
+
+	if _, err := client.CreateIndex(dest).Body(body).Do(ctx); err != nil {
+		return err
+	}
+	response, err := client.Reindex().
+		SourceIndex(cur.concrete).
+		DestinationIndex(dest).
+		WaitForCompletion(true).
+		Refresh("true").
+		Do(ctx)
+	if err != nil {
+		return err
+	}
+	if len(response.Failures) > 0 {
+		return fmt.Errorf("%d documents failed to reindex: %v", len(response.Failures), response.Failures[0])
+	}
+	*docs = response.Created
+
+	swap := client.Alias().Action(es7.NewAliasAddAction(name).Index(dest))
+	if cur.alias {
+		swap = swap.Action(es7.NewAliasRemoveAction(name).Index(cur.concrete))
+	} else {
+		swap = swap.Action(es7.NewAliasRemoveIndexAction(cur.concrete))
+	}
+	_, err = swap.Do(ctx)
+	return err
+}
+
+// inspect resolves the concrete index behind the name along with its version.
+func inspect(ctx context.Context, name string) (current, error) {
+	client := util.GetClient7()
+	var cur current
+
+	response, err := client.PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: "GET",
+		Path:   "/_alias/" + name,
+	})
+	switch {
+	case err == nil:
+		var indices map[string]json.RawMessage
+		if err := json.Unmarshal(response.Body, &indices); err != nil {
+			return cur, err
+		}
+		// the alias points to a single index, unless tampered with
+		for concrete := range indices {
+			if cur.concrete == "" || suffixVersion(concrete) > suffixVersion(cur.concrete) {
+				cur.concrete = concrete
+			}
+		}
+		cur.exists, cur.alias = cur.concrete != "", true
+	case util.IsNotFound(err):
+		exists, err := client.IndexExists(name).Do(ctx)
+		if err != nil {
+			return cur, err
+		}
+		if exists {
+			cur.exists, cur.concrete = true, name
+		}
+	default:
+		return cur, err
+	}
+	if !cur.exists {
+		return cur, nil
+	}
+
+	response, err = client.PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: "GET",
+		Path:   "/" + cur.concrete + "/_mapping",
+	})
+	if err != nil {
+		return cur, err
+	}
+	cur.version, err = mappingVersion(response.Body, cur.concrete)
+	return cur, err
+}
+
+// suffixVersion returns the version of the versioned index name, zero if unversioned.
+func suffixVersion(concrete string) int {
+	suffix := versionSuffix.FindString(concrete)
+	if suffix == "" {
+		return 0
+	}
+	version, _ := strconv.Atoi(suffix[len("_v"):])
+	return version
+}
+
+// mappingVersion returns the version recorded in the _meta of the mappings
+// of the index, either type-less or under the type, zero if there's none.
+func mappingVersion(raw []byte, index string) (int, error) {
+	var indices map[string]struct {
+		Mappings map[string]json.RawMessage `json:"mappings"`
+	}
+	if err := json.Unmarshal(raw, &indices); err != nil {
+		return 0, err
+	}
+	mappings := indices[index].Mappings
+	candidates := []json.RawMessage{mappings["_meta"]}
+	for key, value := range mappings {
+		if key != "_meta" {
+			var typed struct {
+				Meta json.RawMessage `json:"_meta"`
+			}
+			if json.Unmarshal(value, &typed) == nil {
+				candidates = append(candidates, typed.Meta)
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		var meta struct {
+			Version int `json:"version"`
+		}
+		if len(candidate) > 0 && json.Unmarshal(candidate, &meta) == nil && meta.Version > 0 {
+			return meta.Version, nil
+		}
+	}
+	return 0, nil
+}
+
+// withVersion records the version in the _meta of the mappings of the body,
+// under the mapping type if the cluster still uses them.
+func withVersion(body string, version int, docType string) (string, error) {
+	doc := make(map[string]interface{})
+	if strings.TrimSpace(body) != "" {
+		if err := json.Unmarshal([]byte(body), &doc); err != nil {
+			return "", err
+		}
+	}
+	mappings, _ := doc["mappings"].(map[string]interface{})
+	if mappings == nil {
+		mappings = make(map[string]interface{})
+		doc["mappings"] = mappings
+	}
+	target := mappings
+	if docType != "" {
+		typed, _ := mappings[docType].(map[string]interface{})
+		if typed == nil {
+			typed = make(map[string]interface{})
+			mappings[docType] = typed
+		}
+		target = typed
+	}
+	meta, _ := target["_meta"].(map[string]interface{})
+	if meta == nil {
+		meta = make(map[string]interface{})
+		target["_meta"] = meta
+	}
+	meta["version"] = version
+
+	raw, err := json.Marshal(doc)
+	return string(raw), err
+}
+
+// lock makes the arc instances take turns migrating the index.
+func lock(ctx context.Context, name string) (func(), error) {
+	key := "migration:" + name
+	for {
+		ok, err := state.Instance().SetNX(ctx, key, []byte("1"), lockTTL)
+		if err != nil {
+			return nil, fmt.Errorf("%s: unable to lock index %s: %v", logTag, name, err)
+		}
+		if ok {
+			return func() {
+				if err := state.Instance().Delete(context.Background(), key); err != nil {
+					log.Errorln(logTag, ": unable to unlock index", name, ":", err)
+				}
+			}, nil
+		}
+		log.Println(logTag, ": waiting for another instance to migrate index", name)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
+
+// recordMigration adds the record to the migration history.
+func recordMigration(ctx context.Context, record Record) error {
+	client := util.GetClient7()
+	index := HistoryIndex()
+	exists, err := client.IndexExists(index).Do(ctx)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		_, err := client.CreateIndex(index).Do(ctx)
+		if e, ok := err.(*es7.Error); ok && e.Details != nil && e.Details.Type == "resource_already_exists_exception" {
+			err = nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err = client.Index().
+		Index(index).
+		BodyJson(record).
+		Do(ctx)
+	return err
+}
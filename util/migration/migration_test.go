@@ -0,0 +1,46 @@
+package migration
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVersions(t *testing.T) {
+	Convey("Versioned index names", t, func() {
+		So(VersionedName(".users", 2), ShouldEqual, ".users_v2")
+		So(IsVersionOf(".users", ".users"), ShouldBeTrue)
+		So(IsVersionOf(".users_v2", ".users"), ShouldBeTrue)
+		So(IsVersionOf(".users_v12", ".users"), ShouldBeTrue)
+		So(IsVersionOf(".users_backup", ".users"), ShouldBeFalse)
+		So(IsVersionOf(".permissions_v1", ".users"), ShouldBeFalse)
+		So(suffixVersion(".users_v12"), ShouldEqual, 12)
+		So(suffixVersion(".users"), ShouldEqual, 0)
+	})
+
+	Convey("The version is recorded in the _meta of the mappings", t, func() {
+		Convey("type-less", func() {
+			body, err := withVersion(`{"settings":{"number_of_shards":1}}`, 2, "")
+			So(err, ShouldBeNil)
+			So(body, ShouldEqual, `{"mappings":{"_meta":{"version":2}},"settings":{"number_of_shards":1}}`)
+
+			version, err := mappingVersion([]byte(`{".users_v2":`+body+`}`), ".users_v2")
+			So(err, ShouldBeNil)
+			So(version, ShouldEqual, 2)
+		})
+		Convey("under the mapping type", func() {
+			body, err := withVersion(`{"mappings":{"_doc":{"dynamic":true}}}`, 3, "_doc")
+			So(err, ShouldBeNil)
+			So(body, ShouldEqual, `{"mappings":{"_doc":{"_meta":{"version":3},"dynamic":true}}}`)
+
+			version, err := mappingVersion([]byte(`{".users_v3":`+body+`}`), ".users_v3")
+			So(err, ShouldBeNil)
+			So(version, ShouldEqual, 3)
+		})
+		Convey("indices created before the migrations are at version zero", func() {
+			version, err := mappingVersion([]byte(`{".users":{"mappings":{"properties":{"username":{"type":"text"}}}}}`), ".users")
+			So(err, ShouldBeNil)
+			So(version, ShouldEqual, 0)
+		})
+	})
+}
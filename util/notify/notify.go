@@ -0,0 +1,363 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util"
+)
+
+const (
+	logTag         = "[notify]"
+	envWebhooks    = "NOTIFY_WEBHOOKS"
+	queueSize      = 256
+	defaultRetries = 3
+	// deadLettersSize bounds the failed deliveries kept for inspection, the
+	// oldest ones are dropped first.
+	deadLettersSize = 100
+
+	// SignatureHeader carries the hex encoded HMAC-SHA256 of the timestamp
+	// and the payload, joined by a dot, keyed by the secret of the webhook.
+	SignatureHeader = "X-Arc-Signature"
+	// TimestampHeader carries the unix time at which the payload was signed,
+	// letting the receivers reject the replayed deliveries.
+	TimestampHeader = "X-Arc-Timestamp"
+
+	// defaultTemplate renders a slack compatible payload along with the event.
+	defaultTemplate = `{"text": {{ json (printf "[arc] %s: %s" .Type .Message) }}, "event": {{ json . }}}`
+)
+
+// EventType identifies the kind of event webhooks subscribe to.
+type EventType string
+
+// Events notified by arc.
+const (
+	UserCreated       EventType = "user.created"
+	UserDeleted       EventType = "user.deleted"
+	UserRestored      EventType = "user.restored"
+	PermissionExpired EventType = "permission.expired"
+	AuthFailures      EventType = "auth.failures"
+	CircuitOpen       EventType = "circuit.open"
+	ReindexCompleted  EventType = "reindex.completed"
+	AlertTriggered    EventType = "alert.triggered"
+	IndexCreated      EventType = "index.created"
+	IndexDeleted      EventType = "index.deleted"
+)
+
+// Event is a notification sent to the webhooks subscribed to its type.
+type Event struct {
+	Type      EventType   `json:"type"`
+	Timestamp time.Time   `json:"timestamp"`
+	Actor     string      `json:"actor,omitempty"`
+	Resource  string      `json:"resource"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+}
+
+// Webhook is an endpoint the events are posted to. The payload is rendered
+// from the event with the go template, which can use the "json" function to
+// encode values. A webhook without events is subscribed to all of them. The
+// payloads posted to a webhook with a secret are signed.
+type Webhook struct {
+	Name     string            `json:"name"`
+	URL      string            `json:"url"`
+	Events   []EventType       `json:"events"`
+	Template string            `json:"template"`
+	Headers  map[string]string `json:"headers"`
+	Retries  int               `json:"retries"`
+	Secret   string            `json:"secret"`
+	tmpl     *template.Template
+}
+
+func (wh *Webhook) subscribed(t EventType) bool {
+	if len(wh.Events) == 0 {
+		return true
+	}
+	for _, e := range wh.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (wh *Webhook) render(e Event) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := wh.tmpl.Execute(&buf, e); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	},
+}
+
+// Sign returns the signature of the payload signed at the timestamp.
+func Sign(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeadLetter is an event whose delivery to the webhook failed after all
+// the retries.
+type DeadLetter struct {
+	ID       string    `json:"id"`
+	Webhook  string    `json:"webhook"`
+	Event    Event     `json:"event"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+	webhook  *Webhook
+}
+
+// ErrDeadLetterNotFound is returned when redelivering an unknown dead letter.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// notifier delivers the queued events to the webhooks in the background.
+type notifier struct {
+	webhooks []*Webhook
+	client   *http.Client
+	backoff  time.Duration
+	queue    chan Event
+
+	mu          sync.Mutex
+	deadLetters []DeadLetter
+	sequence    uint64
+}
+
+var (
+	singleton *notifier
+	once      sync.Once
+)
+
+func instance() *notifier {
+	once.Do(func() {
+		webhooks, err := parseWebhooks(os.Getenv(envWebhooks))
+		if err != nil {
+			log.Errorln(logTag, ": invalid", envWebhooks, ", events won't be notified :", err)
+		}
+		singleton = newNotifier(webhooks, util.HTTPClient(), time.Second)
+		if len(webhooks) > 0 {
+			go singleton.run()
+		}
+	})
+	return singleton
+}
+
+func newNotifier(webhooks []*Webhook, client *http.Client, backoff time.Duration) *notifier {
+	return &notifier{
+		webhooks: webhooks,
+		client:   client,
+		backoff:  backoff,
+		queue:    make(chan Event, queueSize),
+	}
+}
+
+// parseWebhooks reads the json array of webhooks.
+func parseWebhooks(raw string) ([]*Webhook, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var webhooks []*Webhook
+	if err := json.Unmarshal([]byte(raw), &webhooks); err != nil {
+		return nil, err
+	}
+	for i, wh := range webhooks {
+		if wh.URL == "" {
+			return nil, fmt.Errorf("webhook at position %d has no url", i)
+		}
+		if wh.Name == "" {
+			wh.Name = wh.URL
+		}
+		if wh.Retries <= 0 {
+			wh.Retries = defaultRetries
+		}
+		if wh.Template == "" {
+			wh.Template = defaultTemplate
+		}
+		tmpl, err := template.New(wh.Name).Funcs(funcs).Parse(wh.Template)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template of webhook %s: %v", wh.Name, err)
+		}
+		wh.tmpl = tmpl
+	}
+	return webhooks, nil
+}
+
+// Send queues the event for delivery to the subscribed webhooks. It never
+// blocks the caller, the event is dropped if the queue is full.
+func Send(e Event) {
+	instance().send(e)
+}
+
+// Queued returns the number of events waiting to be delivered and the
+// capacity of the queue.
+func Queued() (int, int) {
+	n := instance()
+	return len(n.queue), cap(n.queue)
+}
+
+func (n *notifier) send(e Event) {
+	if len(n.webhooks) == 0 {
+		return
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	select {
+	case n.queue <- e:
+	default:
+		log.Errorln(logTag, ": queue is full, dropping", e.Type, "event on", e.Resource)
+	}
+}
+
+func (n *notifier) run() {
+	for e := range n.queue {
+		for _, wh := range n.webhooks {
+			if !wh.subscribed(e.Type) {
+				continue
+			}
+			if err := n.deliver(wh, e); err != nil {
+				log.Errorln(logTag, ": unable to notify", wh.Name, "of", e.Type, ":", err)
+				n.bury(wh, e, err)
+			}
+		}
+	}
+}
+
+// bury keeps the failed delivery as a dead letter.
+func (n *notifier) bury(wh *Webhook, e Event, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sequence++
+	n.deadLetters = append(n.deadLetters, DeadLetter{
+		ID:       strconv.FormatUint(n.sequence, 10),
+		Webhook:  wh.Name,
+		Event:    e,
+		Error:    err.Error(),
+		FailedAt: time.Now(),
+		webhook:  wh,
+	})
+	if len(n.deadLetters) > deadLettersSize {
+		n.deadLetters = n.deadLetters[len(n.deadLetters)-deadLettersSize:]
+	}
+}
+
+// DeadLetters returns the failed deliveries, the oldest first.
+func DeadLetters() []DeadLetter {
+	return instance().listDeadLetters()
+}
+
+func (n *notifier) listDeadLetters() []DeadLetter {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]DeadLetter{}, n.deadLetters...)
+}
+
+// Redeliver delivers the dead letter again in the background, it's buried
+// again if the delivery fails.
+func Redeliver(id string) error {
+	return instance().redeliver(id)
+}
+
+func (n *notifier) redeliver(id string) error {
+	n.mu.Lock()
+	var letter *DeadLetter
+	for i := range n.deadLetters {
+		if n.deadLetters[i].ID == id {
+			l := n.deadLetters[i]
+			letter = &l
+			n.deadLetters = append(n.deadLetters[:i], n.deadLetters[i+1:]...)
+			break
+		}
+	}
+	n.mu.Unlock()
+	if letter == nil {
+		return ErrDeadLetterNotFound
+	}
+
+	go func() {
+		if err := n.deliver(letter.webhook, letter.Event); err != nil {
+			log.Errorln(logTag, ": unable to redeliver", letter.Event.Type, "to", letter.Webhook, ":", err)
+			n.bury(letter.webhook, letter.Event, err)
+		}
+	}()
+	return nil
+}
+
+// ClearDeadLetters drops all the failed deliveries.
+func ClearDeadLetters() {
+	n := instance()
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.deadLetters = nil
+}
+
+// deliver posts the event to the webhook, retrying with exponential backoff
+// on network errors and the responses that can succeed when retried.
+func (n *notifier) deliver(wh *Webhook, e Event) error {
+	payload, err := wh.render(e)
+	if err != nil {
+		return err
+	}
+
+	backoff := n.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(wh, payload)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= wh.Retries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (n *notifier) post(wh *Webhook, payload []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, wh.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range wh.Headers {
+		req.Header.Set(k, v)
+	}
+	if wh.Secret != "" {
+		// each attempt is signed afresh so that retries aren't taken for replays
+		timestamp := time.Now().Unix()
+		req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+		req.Header.Set(SignatureHeader, Sign(wh.Secret, timestamp, payload))
+	}
+
+	response, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	response.Body.Close()
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+	retry := response.StatusCode == http.StatusTooManyRequests || response.StatusCode >= 500
+	return retry, fmt.Errorf("webhook responded with %s", response.Status)
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseWebhooks(t *testing.T) {
+	Convey("Webhooks are parsed with their defaults", t, func() {
+		webhooks, err := parseWebhooks(`[{"url": "http://localhost/hook", "events": ["user.created"]}]`)
+		So(err, ShouldBeNil)
+		So(webhooks, ShouldHaveLength, 1)
+		So(webhooks[0].Name, ShouldEqual, "http://localhost/hook")
+		So(webhooks[0].Retries, ShouldEqual, defaultRetries)
+		So(webhooks[0].subscribed(UserCreated), ShouldBeTrue)
+		So(webhooks[0].subscribed(UserDeleted), ShouldBeFalse)
+	})
+
+	Convey("Webhooks without url or with invalid templates are rejected", t, func() {
+		_, err := parseWebhooks(`[{"name": "slack"}]`)
+		So(err, ShouldNotBeNil)
+
+		_, err = parseWebhooks(`[{"url": "http://localhost/hook", "template": "{{ .Type "}]`)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestDeliver(t *testing.T) {
+	Convey("The default payload is slack compatible", t, func() {
+		var payload map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			raw, _ := ioutil.ReadAll(req.Body)
+			json.Unmarshal(raw, &payload)
+		}))
+		defer server.Close()
+
+		webhooks, err := parseWebhooks(`[{"url": "` + server.URL + `"}]`)
+		So(err, ShouldBeNil)
+		n := newNotifier(webhooks, server.Client(), 0)
+		err = n.deliver(webhooks[0], Event{Type: UserCreated, Resource: "foo", Message: `user "foo" created`})
+		So(err, ShouldBeNil)
+		So(payload["text"], ShouldEqual, `[arc] user.created: user "foo" created`)
+		So(payload["event"].(map[string]interface{})["resource"], ShouldEqual, "foo")
+	})
+
+	Convey("Failed deliveries are retried", t, func() {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		webhooks, _ := parseWebhooks(`[{"url": "` + server.URL + `", "retries": 3}]`)
+		n := newNotifier(webhooks, server.Client(), 0)
+		So(n.deliver(webhooks[0], Event{Type: UserDeleted}), ShouldBeNil)
+		So(atomic.LoadInt32(&calls), ShouldEqual, 3)
+	})
+
+	Convey("Client errors aren't retried", t, func() {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		webhooks, _ := parseWebhooks(`[{"url": "` + server.URL + `"}]`)
+		n := newNotifier(webhooks, server.Client(), 0)
+		So(n.deliver(webhooks[0], Event{Type: UserDeleted}), ShouldNotBeNil)
+		So(atomic.LoadInt32(&calls), ShouldEqual, 1)
+	})
+}
+
+func TestSignature(t *testing.T) {
+	Convey("The payloads of the webhooks with a secret are signed", t, func() {
+		var verified bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			raw, _ := ioutil.ReadAll(req.Body)
+			timestamp, _ := strconv.ParseInt(req.Header.Get(TimestampHeader), 10, 64)
+			verified = req.Header.Get(SignatureHeader) == Sign("s3cret", timestamp, raw)
+		}))
+		defer server.Close()
+
+		webhooks, _ := parseWebhooks(`[{"url": "` + server.URL + `", "secret": "s3cret"}]`)
+		n := newNotifier(webhooks, server.Client(), 0)
+		So(n.deliver(webhooks[0], Event{Type: UserCreated}), ShouldBeNil)
+		So(verified, ShouldBeTrue)
+	})
+
+	Convey("The signature depends on the secret, timestamp and payload", t, func() {
+		signature := Sign("s3cret", 1, []byte("{}"))
+		So(signature, ShouldStartWith, "sha256=")
+		So(Sign("other", 1, []byte("{}")), ShouldNotEqual, signature)
+		So(Sign("s3cret", 2, []byte("{}")), ShouldNotEqual, signature)
+		So(Sign("s3cret", 1, []byte("[]")), ShouldNotEqual, signature)
+	})
+}
+
+func TestDeadLetters(t *testing.T) {
+	Convey("The failed deliveries are kept and can be redelivered", t, func() {
+		var fail int32 = 1
+		delivered := make(chan struct{}, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if atomic.LoadInt32(&fail) == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			delivered <- struct{}{}
+		}))
+		defer server.Close()
+
+		webhooks, _ := parseWebhooks(`[{"name": "hook", "url": "` + server.URL + `"}]`)
+		n := newNotifier(webhooks, server.Client(), 0)
+		go n.run()
+		n.send(Event{Type: UserDeleted, Resource: "foo"})
+		So(func() bool {
+			for i := 0; i < 100 && len(n.listDeadLetters()) == 0; i++ {
+				time.Sleep(10 * time.Millisecond)
+			}
+			return len(n.listDeadLetters()) == 1
+		}(), ShouldBeTrue)
+
+		letter := n.listDeadLetters()[0]
+		So(letter.Webhook, ShouldEqual, "hook")
+		So(letter.Event.Resource, ShouldEqual, "foo")
+
+		atomic.StoreInt32(&fail, 0)
+		So(n.redeliver(letter.ID), ShouldBeNil)
+		<-delivered
+		So(n.listDeadLetters(), ShouldBeEmpty)
+		So(n.redeliver(letter.ID), ShouldEqual, ErrDeadLetterNotFound)
+	})
+}
@@ -0,0 +1,225 @@
+// Package objectstore uploads objects to the S3 compatible object stores,
+// i.e. Amazon S3, Google Cloud Storage through its XML API and the self-hosted
+// stores such as MinIO. The requests are signed with AWS Signature Version 4,
+// which Google Cloud Storage accepts along with its HMAC keys.
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	envAccessKeyID     = "OBJECT_STORE_ACCESS_KEY_ID"
+	envSecretAccessKey = "OBJECT_STORE_SECRET_ACCESS_KEY"
+	envRegion          = "OBJECT_STORE_REGION"
+	envEndpoint        = "OBJECT_STORE_ENDPOINT"
+	// the credentials of the aws cli are used unless the ones of the
+	// object store are set
+	envAWSAccessKeyID     = "AWS_ACCESS_KEY_ID"
+	envAWSSecretAccessKey = "AWS_SECRET_ACCESS_KEY"
+	envAWSRegion          = "AWS_REGION"
+	defaultRegion         = "us-east-1"
+	gcsEndpoint           = "https://storage.googleapis.com"
+	amzDateLayout         = "20060102T150405Z"
+)
+
+// Store is a bucket of an object store, along with the prefix of the keys of
+// the objects put in it.
+type Store struct {
+	url       string
+	endpoint  *url.URL
+	bucket    string
+	prefix    string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// Open returns the store of the given url, either "s3://{bucket}/{prefix}" or
+// "gs://{bucket}/{prefix}", configured by the OBJECT_STORE_* env vars.
+func Open(rawURL string) (*Store, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object store url %s: %v", rawURL, err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid object store url %s: the bucket is missing", rawURL)
+	}
+
+	s := &Store{
+		url:       rawURL,
+		bucket:    u.Host,
+		prefix:    strings.Trim(u.Path, "/"),
+		region:    firstEnv(envRegion, envAWSRegion),
+		accessKey: firstEnv(envAccessKeyID, envAWSAccessKeyID),
+		secretKey: firstEnv(envSecretAccessKey, envAWSSecretAccessKey),
+		client:    &http.Client{Timeout: 5 * time.Minute},
+		now:       time.Now,
+	}
+	endpoint := os.Getenv(envEndpoint)
+	switch u.Scheme {
+	case "s3":
+		if s.region == "" {
+			s.region = defaultRegion
+		}
+		if endpoint == "" {
+			endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", s.region)
+		}
+	case "gs":
+		if s.region == "" {
+			s.region = "auto"
+		}
+		if endpoint == "" {
+			endpoint = gcsEndpoint
+		}
+	default:
+		return nil, fmt.Errorf(`invalid object store url %s: the scheme must be either "s3" or "gs"`, rawURL)
+	}
+	if s.endpoint, err = url.Parse(endpoint); err != nil || s.endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid value for %s: %s", envEndpoint, endpoint)
+	}
+	if s.accessKey == "" || s.secretKey == "" {
+		return nil, fmt.Errorf("the credentials of the object store aren't set, see %s and %s", envAccessKeyID, envSecretAccessKey)
+	}
+	return s, nil
+}
+
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// String returns the url of the store.
+func (s *Store) String() string {
+	return s.url
+}
+
+// Put uploads the object under the key, relative to the prefix of the store.
+// The content encoding is optional, e.g. "gzip" for the compressed objects.
+func (s *Store) Put(ctx context.Context, key string, body []byte, contentType, contentEncoding string) error {
+	u := *s.endpoint
+	u.Path = "/" + path.Join(s.bucket, s.prefix, key)
+	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", contentType)
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+	s.sign(req, body)
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error uploading %s to %s: %v", key, s, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("error uploading %s to %s: %s: %s", key, s, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sign signs the request with AWS Signature Version 4, covering the host and
+// the headers set on the request.
+func (s *Store) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format(amzDateLayout)
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := strings.Join([]string{now.Format("20060102"), s.region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{now.Format("20060102"), s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(values url.Values) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		vs := append([]string(nil), values[key]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			pairs = append(pairs, escape(key)+"="+escape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escape encodes the string as signature version 4 expects, i.e. every byte
+// but the unreserved characters.
+func escape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
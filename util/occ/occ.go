@@ -0,0 +1,139 @@
+// Package occ implements the optimistic concurrency control of the updates
+// made to the internal documents of arc. The revision of a document, its
+// sequence number and primary term, is handed to the clients as an entity
+// tag, which they send back in the If-Match header of their updates. The
+// update is rejected if the document was modified in between.
+package occ
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	es7 "github.com/olivere/elastic/v7"
+
+	"github.com/appbaseio/arc/util"
+)
+
+// ErrConflict is returned when the document was modified since the revision
+// the update was made against.
+var ErrConflict = errors.New("the document was modified concurrently")
+
+// Version is the revision of a document.
+type Version struct {
+	SeqNo       int64 `json:"_seq_no"`
+	PrimaryTerm int64 `json:"_primary_term"`
+}
+
+// ETag returns the entity tag of the revision, e.g. "12-1".
+func (v Version) ETag() string {
+	return fmt.Sprintf(`"%d-%d"`, v.SeqNo, v.PrimaryTerm)
+}
+
+// valid checks whether the revision was returned by elasticsearch, the
+// releases prior to 6.7 don't return it.
+func (v Version) valid() bool {
+	return v.PrimaryTerm > 0
+}
+
+// Parse parses the entity tag of a revision.
+func Parse(etag string) (Version, error) {
+	var v Version
+	value := strings.Trim(strings.TrimSpace(etag), `"`)
+	parts := strings.Split(value, "-")
+	if len(parts) != 2 {
+		return v, fmt.Errorf(`invalid entity tag %s, expected a revision of the form "<seq_no>-<primary_term>"`, etag)
+	}
+	var err error
+	if v.SeqNo, err = strconv.ParseInt(parts[0], 10, 64); err != nil || v.SeqNo < 0 {
+		return v, fmt.Errorf("invalid sequence number in entity tag %s", etag)
+	}
+	if v.PrimaryTerm, err = strconv.ParseInt(parts[1], 10, 64); err != nil || v.PrimaryTerm < 1 {
+		return v, fmt.Errorf("invalid primary term in entity tag %s", etag)
+	}
+	return v, nil
+}
+
+// FromRequest returns the revision the request was made against, via its
+// If-Match header, or nil if the header isn't set.
+func FromRequest(req *http.Request) (*Version, error) {
+	etag := req.Header.Get("If-Match")
+	if etag == "" {
+		return nil, nil
+	}
+	v, err := Parse(etag)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// SetETag sets the ETag header of the response to the revision, if known.
+func SetETag(w http.ResponseWriter, v Version) {
+	if v.valid() {
+		w.Header().Set("ETag", v.ETag())
+	}
+}
+
+// Get returns the source of the document along with its revision.
+func Get(ctx context.Context, index, id string) ([]byte, Version, error) {
+	var v Version
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodGet,
+		Path:   "/" + url.PathEscape(index) + "/_doc/" + url.PathEscape(id),
+	})
+	if err != nil {
+		return nil, v, err
+	}
+	var doc struct {
+		Version
+		Source json.RawMessage `json:"_source"`
+	}
+	if err := json.Unmarshal(response.Body, &doc); err != nil {
+		return nil, v, err
+	}
+	return doc.Source, doc.Version, nil
+}
+
+// Update applies the partial document to the document if it is still at
+// the revision, it returns the update response along with the new revision.
+// The update is unconditional if the revision is nil.
+func Update(ctx context.Context, index, id string, doc interface{}, v *Version) ([]byte, Version, error) {
+	var updated Version
+	path := "/" + url.PathEscape(index) + "/_update/" + url.PathEscape(id)
+	if util.GetVersion() == 6 {
+		path = "/" + url.PathEscape(index) + "/_doc/" + url.PathEscape(id) + "/_update"
+	}
+	params := url.Values{"refresh": []string{"wait_for"}}
+	if v != nil {
+		params.Set("if_seq_no", strconv.FormatInt(v.SeqNo, 10))
+		params.Set("if_primary_term", strconv.FormatInt(v.PrimaryTerm, 10))
+	}
+	response, err := util.GetClient7().PerformRequest(ctx, es7.PerformRequestOptions{
+		Method: http.MethodPost,
+		Path:   path,
+		Params: params,
+		Body:   map[string]interface{}{"doc": doc},
+	})
+	if e, ok := err.(*es7.Error); ok && e.Status == http.StatusConflict {
+		return nil, updated, ErrConflict
+	}
+	if err != nil {
+		return nil, updated, err
+	}
+	// the response is encoded as the client encodes it, alike the other updates
+	var result es7.UpdateResponse
+	if err := json.Unmarshal(response.Body, &result); err != nil {
+		return nil, updated, err
+	}
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return nil, updated, err
+	}
+	return raw, Version{SeqNo: result.SeqNo, PrimaryTerm: result.PrimaryTerm}, nil
+}
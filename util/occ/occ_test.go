@@ -0,0 +1,50 @@
+package occ
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestVersion(t *testing.T) {
+	Convey("Entity tags round trip", t, func() {
+		v := Version{SeqNo: 12, PrimaryTerm: 1}
+		So(v.ETag(), ShouldEqual, `"12-1"`)
+		parsed, err := Parse(v.ETag())
+		So(err, ShouldBeNil)
+		So(parsed, ShouldResemble, v)
+
+		parsed, err = Parse("0-3")
+		So(err, ShouldBeNil)
+		So(parsed, ShouldResemble, Version{SeqNo: 0, PrimaryTerm: 3})
+	})
+
+	Convey("Malformed entity tags are rejected", t, func() {
+		for _, etag := range []string{`"12"`, `"a-1"`, `"12-0"`, `"-1-1"`, `W/"12-1"`, `*`} {
+			_, err := Parse(etag)
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("The revision is read from the If-Match header", t, func() {
+		req := httptest.NewRequest(http.MethodPatch, "/_user", nil)
+		v, err := FromRequest(req)
+		So(err, ShouldBeNil)
+		So(v, ShouldBeNil)
+
+		req.Header.Set("If-Match", `"4-2"`)
+		v, err = FromRequest(req)
+		So(err, ShouldBeNil)
+		So(*v, ShouldResemble, Version{SeqNo: 4, PrimaryTerm: 2})
+	})
+
+	Convey("Unknown revisions aren't advertised", t, func() {
+		w := httptest.NewRecorder()
+		SetETag(w, Version{})
+		So(w.Header().Get("ETag"), ShouldBeEmpty)
+		SetETag(w, Version{SeqNo: 1, PrimaryTerm: 1})
+		So(w.Header().Get("ETag"), ShouldEqual, `"1-1"`)
+	})
+}
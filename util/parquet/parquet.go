@@ -0,0 +1,285 @@
+// Package parquet writes flat tables as Apache Parquet files, so that they can
+// be queried by the lakehouse engines, e.g. Spark, Trino or DuckDB. The files
+// hold a single row group of optional columns, each written as one data page
+// compressed with gzip, which is all the exports of arc need.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Type is the type of the values of a column.
+type Type int
+
+// The supported types, the values are respectively bool, int64, float64,
+// string and the int64 epoch millis.
+const (
+	Boolean Type = iota
+	Int64
+	Double
+	String
+	TimestampMillis
+)
+
+// ContentType is the media type of the parquet files.
+const ContentType = "application/vnd.apache.parquet"
+
+const magic = "PAR1"
+
+// the enums of the parquet format
+const (
+	physicalBoolean   = 0
+	physicalInt64     = 2
+	physicalDouble    = 5
+	physicalByteArray = 6
+
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecGzip          = 2
+	pageData           = 0
+)
+
+// Column of a table.
+type Column struct {
+	Name string
+	Type Type
+}
+
+func (c Column) physicalType() int32 {
+	switch c.Type {
+	case Boolean:
+		return physicalBoolean
+	case Double:
+		return physicalDouble
+	case String:
+		return physicalByteArray
+	default:
+		return physicalInt64
+	}
+}
+
+// chunk is a column of the row group, written as a single page.
+type chunk struct {
+	offset           int64
+	compressedSize   int64
+	uncompressedSize int64
+}
+
+// Write writes the rows as a parquet file. Each row holds the values of the
+// columns in their order, nil for the null values.
+func Write(w io.Writer, columns []Column, rows [][]interface{}) error {
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return fmt.Errorf("row %d has %d values, expected %d", i, len(row), len(columns))
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(magic)
+	var chunks []chunk
+	if len(rows) > 0 {
+		chunks = make([]chunk, len(columns))
+		for i, c := range columns {
+			page, err := encodePage(c, i, rows)
+			if err != nil {
+				return err
+			}
+			compressed, err := compress(page)
+			if err != nil {
+				return err
+			}
+			header := pageHeader(len(rows), len(page), len(compressed))
+			chunks[i] = chunk{
+				offset:           int64(buf.Len()),
+				compressedSize:   int64(len(header) + len(compressed)),
+				uncompressedSize: int64(len(header) + len(page)),
+			}
+			buf.Write(header)
+			buf.Write(compressed)
+		}
+	}
+
+	footer := fileMetadata(columns, int64(len(rows)), chunks)
+	buf.Write(footer)
+	binary.Write(&buf, binary.LittleEndian, uint32(len(footer)))
+	buf.WriteString(magic)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// encodePage returns the uncompressed data page of the column: the definition
+// levels, i.e. whether each of the values is set, followed by the values set.
+func encodePage(c Column, index int, rows [][]interface{}) ([]byte, error) {
+	levels := make([]byte, len(rows))
+	var values bytes.Buffer
+	var bits []bool
+	for i, row := range rows {
+		v := row[index]
+		if v == nil {
+			continue
+		}
+		levels[i] = 1
+		var ok bool
+		switch c.Type {
+		case Boolean:
+			var b bool
+			if b, ok = v.(bool); ok {
+				bits = append(bits, b)
+			}
+		case Int64, TimestampMillis:
+			var n int64
+			if n, ok = v.(int64); ok {
+				binary.Write(&values, binary.LittleEndian, n)
+			}
+		case Double:
+			var f float64
+			if f, ok = v.(float64); ok {
+				binary.Write(&values, binary.LittleEndian, math.Float64bits(f))
+			}
+		case String:
+			var s string
+			if s, ok = v.(string); ok {
+				binary.Write(&values, binary.LittleEndian, uint32(len(s)))
+				values.WriteString(s)
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("invalid value %v of type %T in row %d for column %s", v, v, i, c.Name)
+		}
+	}
+	if c.Type == Boolean {
+		values.Write(bitPack(bits))
+	}
+
+	encoded := encodeLevels(levels)
+	page := make([]byte, 4, 4+len(encoded)+values.Len())
+	binary.LittleEndian.PutUint32(page, uint32(len(encoded)))
+	page = append(page, encoded...)
+	return append(page, values.Bytes()...), nil
+}
+
+// encodeLevels encodes the definition levels, of bit width 1, as the runs of
+// the rle/bit-packing hybrid encoding.
+func encodeLevels(levels []byte) []byte {
+	var buf []byte
+	var b [binary.MaxVarintLen64]byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		n := binary.PutUvarint(b[:], uint64(j-i)<<1)
+		buf = append(buf, b[:n]...)
+		buf = append(buf, levels[i])
+		i = j
+	}
+	return buf
+}
+
+// bitPack packs the booleans, the least significant bit first.
+func bitPack(bits []bool) []byte {
+	packed := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b {
+			packed[i/8] |= 1 << uint(i%8)
+		}
+	}
+	return packed
+}
+
+func compress(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func pageHeader(numValues, uncompressedSize, compressedSize int) []byte {
+	t := newThriftWriter()
+	t.i32(1, pageData)
+	t.i32(2, int32(uncompressedSize))
+	t.i32(3, int32(compressedSize))
+	t.beginStruct(5)
+	t.i32(1, int32(numValues))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.endStruct()
+	return t.bytes()
+}
+
+func fileMetadata(columns []Column, numRows int64, chunks []chunk) []byte {
+	t := newThriftWriter()
+	t.i32(1, 1)
+
+	t.list(2, thriftStruct, len(columns)+1)
+	t.element()
+	t.string(4, "schema")
+	t.i32(5, int32(len(columns)))
+	t.endStruct()
+	for _, c := range columns {
+		t.element()
+		t.i32(1, c.physicalType())
+		t.i32(3, repetitionOptional)
+		t.string(4, c.Name)
+		switch c.Type {
+		case String:
+			t.i32(6, convertedUTF8)
+		case TimestampMillis:
+			t.i32(6, convertedTimestampMillis)
+		}
+		t.endStruct()
+	}
+
+	t.i64(3, numRows)
+
+	// the empty tables have no row group
+	if len(chunks) == 0 {
+		t.list(4, thriftStruct, 0)
+	} else {
+		t.list(4, thriftStruct, 1)
+		t.element()
+		t.list(1, thriftStruct, len(columns))
+		var totalSize int64
+		for i, c := range columns {
+			ch := chunks[i]
+			totalSize += ch.uncompressedSize
+			t.element()
+			t.i64(2, ch.offset)
+			t.beginStruct(3)
+			t.i32(1, c.physicalType())
+			t.list(2, thriftI32, 2)
+			t.varint(encodingPlain)
+			t.varint(encodingRLE)
+			t.list(3, thriftBinary, 1)
+			t.binary(c.Name)
+			t.i32(4, codecGzip)
+			t.i64(5, numRows)
+			t.i64(6, ch.uncompressedSize)
+			t.i64(7, ch.compressedSize)
+			t.i64(9, ch.offset)
+			t.endStruct()
+			t.endStruct()
+		}
+		t.i64(2, totalSize)
+		t.i64(3, numRows)
+		t.endStruct()
+	}
+
+	t.string(6, "arc")
+	return t.bytes()
+}
@@ -0,0 +1,201 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// thriftReader decodes the structs of the thrift compact protocol into maps
+// of the field ids to the values.
+type thriftReader struct {
+	b   []byte
+	pos int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.b[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.b[r.pos]
+		r.pos++
+		n, elem := int(header>>4), header&0x0f
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = r.value(elem)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unexpected thrift type")
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	s := map[int16]interface{}{}
+	var last int16
+	for {
+		header := r.b[r.pos]
+		r.pos++
+		if header == 0 {
+			return s
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.varint())
+		}
+		s[id] = r.value(header & 0x0f)
+		last = id
+	}
+}
+
+// readColumn decodes the values of the column of the chunk, nil for the nulls.
+func readColumn(file []byte, c Column, numRows int, offset int64) []interface{} {
+	r := &thriftReader{b: file, pos: int(offset)}
+	header := r.readStruct()
+	size := int(header[3].(int64))
+	zr, err := gzip.NewReader(bytes.NewReader(file[r.pos : r.pos+size]))
+	So(err, ShouldBeNil)
+	page, err := ioutil.ReadAll(zr)
+	So(err, ShouldBeNil)
+	So(len(page), ShouldEqual, header[2])
+
+	n := int(binary.LittleEndian.Uint32(page))
+	levels := &thriftReader{b: page[4 : 4+n]}
+	var defined []bool
+	for levels.pos < n {
+		run := int(levels.uvarint() >> 1)
+		level := page[4+levels.pos]
+		levels.pos++
+		for i := 0; i < run; i++ {
+			defined = append(defined, level == 1)
+		}
+	}
+	So(len(defined), ShouldEqual, numRows)
+
+	values := page[4+n:]
+	var out []interface{}
+	var bit int
+	for _, d := range defined {
+		if !d {
+			out = append(out, nil)
+			continue
+		}
+		switch c.Type {
+		case Boolean:
+			out = append(out, values[bit/8]&(1<<uint(bit%8)) != 0)
+			bit++
+		case Int64, TimestampMillis:
+			out = append(out, int64(binary.LittleEndian.Uint64(values)))
+			values = values[8:]
+		case Double:
+			out = append(out, math.Float64frombits(binary.LittleEndian.Uint64(values)))
+			values = values[8:]
+		case String:
+			l := int(binary.LittleEndian.Uint32(values))
+			out = append(out, string(values[4:4+l]))
+			values = values[4+l:]
+		}
+	}
+	return out
+}
+
+func footerOf(file []byte) map[int16]interface{} {
+	So(string(file[:4]), ShouldEqual, magic)
+	So(string(file[len(file)-4:]), ShouldEqual, magic)
+	n := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	r := &thriftReader{b: file[len(file)-8-n : len(file)-8]}
+	footer := r.readStruct()
+	So(r.pos, ShouldEqual, n)
+	return footer
+}
+
+func TestWrite(t *testing.T) {
+	columns := []Column{
+		{Name: "query", Type: String},
+		{Name: "hits", Type: Int64},
+		{Name: "click", Type: Boolean},
+		{Name: "took", Type: Double},
+		{Name: "timestamp", Type: TimestampMillis},
+	}
+
+	Convey("The rows are read back from the file", t, func() {
+		var rows [][]interface{}
+		for i := 0; i < 20; i++ {
+			row := []interface{}{"shoes", int64(i), i%3 == 0, float64(i) / 4, int64(1546300800000 + i)}
+			if i%5 == 0 {
+				row[0], row[3] = nil, nil
+			}
+			rows = append(rows, row)
+		}
+		var buf bytes.Buffer
+		So(Write(&buf, columns, rows), ShouldBeNil)
+		file := buf.Bytes()
+
+		footer := footerOf(file)
+		So(footer[1], ShouldEqual, 1)
+		So(footer[3], ShouldEqual, 20)
+		So(footer[6], ShouldEqual, "arc")
+		schema := footer[2].([]interface{})
+		So(schema, ShouldHaveLength, len(columns)+1)
+		So(schema[0].(map[int16]interface{})[5], ShouldEqual, len(columns))
+		So(schema[1].(map[int16]interface{})[4], ShouldEqual, "query")
+		So(schema[1].(map[int16]interface{})[6], ShouldEqual, convertedUTF8)
+		So(schema[5].(map[int16]interface{})[6], ShouldEqual, convertedTimestampMillis)
+
+		groups := footer[4].([]interface{})
+		So(groups, ShouldHaveLength, 1)
+		chunks := groups[0].(map[int16]interface{})[1].([]interface{})
+		So(chunks, ShouldHaveLength, len(columns))
+		for i, c := range columns {
+			meta := chunks[i].(map[int16]interface{})[3].(map[int16]interface{})
+			So(meta[3], ShouldResemble, []interface{}{c.Name})
+			So(meta[4], ShouldEqual, codecGzip)
+			So(meta[5], ShouldEqual, 20)
+			values := readColumn(file, c, 20, meta[9].(int64))
+			for j, row := range rows {
+				So(values[j], ShouldEqual, row[i])
+			}
+		}
+	})
+
+	Convey("The empty tables have no row group", t, func() {
+		var buf bytes.Buffer
+		So(Write(&buf, columns, nil), ShouldBeNil)
+		footer := footerOf(buf.Bytes())
+		So(footer[3], ShouldEqual, 0)
+		So(footer[4], ShouldBeEmpty)
+	})
+
+	Convey("The values must match the types of the columns", t, func() {
+		var buf bytes.Buffer
+		err := Write(&buf, columns, [][]interface{}{{"shoes", 12, true, 0.5, int64(0)}})
+		So(err, ShouldNotBeNil)
+		So(Write(&buf, columns, [][]interface{}{{"shoes"}}), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memoryStore keeps the state of the limits in memory, hence the limits are
+// enforced per arc instance.
+type memoryStore struct {
+	sync.Mutex
+	buckets map[string]*bucket
+	windows map[string]*counter
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+type counter struct {
+	period   time.Duration
+	window   int64
+	current  int64
+	previous int64
+}
+
+// NewMemoryStore returns a store that keeps the state of the limits in memory.
+// The stale entries are evicted periodically.
+func NewMemoryStore() Store {
+	s := &memoryStore{
+		buckets: make(map[string]*bucket),
+		windows: make(map[string]*counter),
+	}
+	go s.evict(time.Minute)
+	return s
+}
+
+func (s *memoryStore) TakeToken(ctx context.Context, key string, limit int64, period time.Duration, burst int64, now time.Time, take bool) (Result, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(burst), updated: now}
+		s.buckets[key] = b
+	}
+	rate := float64(limit) / float64(period)
+	b.tokens = math.Min(float64(burst), b.tokens+float64(now.Sub(b.updated))*rate)
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed && take {
+		b.tokens--
+	}
+	result := tokenBucketResult(allowed, b.tokens, limit, period, burst)
+	b.full = now.Add(result.Reset)
+	return result, nil
+}
+
+func (s *memoryStore) Increment(ctx context.Context, key string, limit int64, period time.Duration, now time.Time, take bool) (Result, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	start, elapsed := window(now, period)
+	c, ok := s.windows[key]
+	if !ok {
+		c = &counter{period: period, window: start}
+		s.windows[key] = c
+	}
+	switch {
+	case c.window == start-1:
+		c.window, c.previous, c.current = start, c.current, 0
+	case c.window != start:
+		c.window, c.previous, c.current = start, 0, 0
+	}
+
+	weighted := float64(c.previous)*float64(period-elapsed)/float64(period) + float64(c.current)
+	allowed := weighted+1 <= float64(limit)
+	if allowed && take {
+		c.current++
+		weighted++
+	}
+	return slidingWindowResult(allowed, weighted, limit, period, elapsed), nil
+}
+
+// evict removes the buckets and windows that haven't been used in a while.
+func (s *memoryStore) evict(interval time.Duration) {
+	for range time.Tick(interval) {
+		now := time.Now()
+		s.Lock()
+		for key, b := range s.buckets {
+			// a bucket that would have been refilled is equivalent to a new one
+			if now.After(b.full) {
+				delete(s.buckets, key)
+			}
+		}
+		for key, c := range s.windows {
+			if start, _ := window(now, c.period); start > c.window+1 {
+				delete(s.windows, key)
+			}
+		}
+		s.Unlock()
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Token bucket allows bursts and refills over time", t, func() {
+		s := NewMemoryStore()
+		now := time.Unix(1000, 0)
+		for i := 0; i < 3; i++ {
+			result, err := s.TakeToken(ctx, "key", 1, time.Second, 3, now, true)
+			So(err, ShouldBeNil)
+			So(result.Allowed, ShouldBeTrue)
+			So(result.Remaining, ShouldEqual, 2-i)
+		}
+
+		result, _ := s.TakeToken(ctx, "key", 1, time.Second, 3, now, true)
+		So(result.Allowed, ShouldBeFalse)
+		So(result.RetryAfter, ShouldEqual, time.Second)
+		So(result.Reset, ShouldEqual, 3*time.Second)
+
+		result, _ = s.TakeToken(ctx, "key", 1, time.Second, 3, now.Add(time.Second), true)
+		So(result.Allowed, ShouldBeTrue)
+		So(result.Remaining, ShouldEqual, 0)
+
+		result, _ = s.TakeToken(ctx, "other", 1, time.Second, 3, now, true)
+		So(result.Allowed, ShouldBeTrue)
+	})
+
+	Convey("Sliding window weights the previous window", t, func() {
+		s := NewMemoryStore()
+		start := time.Unix(1000, 0)
+		for i := 0; i < 4; i++ {
+			result, err := s.Increment(ctx, "key", 4, time.Minute, start, true)
+			So(err, ShouldBeNil)
+			So(result.Allowed, ShouldBeTrue)
+		}
+		result, _ := s.Increment(ctx, "key", 4, time.Minute, start, true)
+		So(result.Allowed, ShouldBeFalse)
+		So(result.Remaining, ShouldEqual, 0)
+
+		// half way into the next window only half of the previous count is considered
+		next := start.Add(time.Minute).Truncate(time.Minute).Add(30 * time.Second)
+		for i := 0; i < 2; i++ {
+			result, _ = s.Increment(ctx, "key", 4, time.Minute, next, true)
+			So(result.Allowed, ShouldBeTrue)
+		}
+		result, _ = s.Increment(ctx, "key", 4, time.Minute, next, true)
+		So(result.Allowed, ShouldBeFalse)
+		So(result.RetryAfter, ShouldEqual, 30*time.Second)
+
+		result, _ = s.Increment(ctx, "key", 4, time.Minute, next.Add(2*time.Minute), true)
+		So(result.Allowed, ShouldBeTrue)
+		So(result.Remaining, ShouldEqual, 3)
+	})
+	Convey("Checking a limit doesn't count the request", t, func() {
+		s := NewMemoryStore()
+		now := time.Unix(1000, 0)
+		result, _ := s.TakeToken(ctx, "key", 1, time.Second, 1, now, false)
+		So(result.Allowed, ShouldBeTrue)
+		result, _ = s.TakeToken(ctx, "key", 1, time.Second, 1, now, true)
+		So(result.Allowed, ShouldBeTrue)
+		result, _ = s.TakeToken(ctx, "key", 1, time.Second, 1, now, false)
+		So(result.Allowed, ShouldBeFalse)
+
+		result, _ = s.Increment(ctx, "key", 1, time.Minute, now, false)
+		So(result.Allowed, ShouldBeTrue)
+		result, _ = s.Increment(ctx, "key", 1, time.Minute, now, true)
+		So(result.Allowed, ShouldBeTrue)
+		result, _ = s.Increment(ctx, "key", 1, time.Minute, now, false)
+		So(result.Allowed, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,142 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Strategy is the algorithm used to limit the requests.
+type Strategy string
+
+// Supported rate limiting strategies.
+const (
+	// TokenBucket allows bursts of up to Burst requests while refilling the
+	// bucket at the rate of Limit requests per Period.
+	TokenBucket Strategy = "token_bucket"
+
+	// SlidingWindow allows Limit requests in any window of length Period,
+	// approximated by weighting the count of the previous fixed window.
+	SlidingWindow Strategy = "sliding_window"
+)
+
+// Result is the outcome of taking a request into account against a limit.
+type Result struct {
+	Allowed    bool
+	Limit      int64
+	Remaining  int64
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store persists the state of the limits. Implementations must apply each
+// call atomically since the same key may be limited by concurrent requests.
+type Store interface {
+	// TakeToken takes a token from the bucket identified by the key, or only
+	// checks whether one is available unless take is set.
+	TakeToken(ctx context.Context, key string, limit int64, period time.Duration, burst int64, now time.Time, take bool) (Result, error)
+
+	// Increment counts a request in the sliding window identified by the key,
+	// or only checks whether it would be allowed unless take is set.
+	Increment(ctx context.Context, key string, limit int64, period time.Duration, now time.Time, take bool) (Result, error)
+}
+
+// Limiter limits the requests with the strategy against the store.
+type Limiter struct {
+	Strategy Strategy
+	Limit    int64
+	Period   time.Duration
+	Burst    int64
+	Store    Store
+}
+
+// New returns a limiter for the strategy that allows limit requests per period.
+// The burst is only relevant to the token bucket and defaults to the limit.
+func New(store Store, strategy Strategy, limit int64, period time.Duration, burst int64) (*Limiter, error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive, got %d", limit)
+	}
+	if period <= 0 {
+		return nil, fmt.Errorf("period must be positive, got %s", period)
+	}
+	if burst <= 0 {
+		burst = limit
+	}
+	switch strategy {
+	case TokenBucket, SlidingWindow:
+	case "":
+		strategy = TokenBucket
+	default:
+		return nil, fmt.Errorf(`invalid rate limiting strategy "%s"`, strategy)
+	}
+	return &Limiter{
+		Strategy: strategy,
+		Limit:    limit,
+		Period:   period,
+		Burst:    burst,
+		Store:    store,
+	}, nil
+}
+
+// Allow takes the request identified by the key into account.
+func (l *Limiter) Allow(ctx context.Context, key string) (Result, error) {
+	return l.apply(ctx, key, true)
+}
+
+// Check checks whether the request identified by the key would be allowed,
+// without taking it into account.
+func (l *Limiter) Check(ctx context.Context, key string) (Result, error) {
+	return l.apply(ctx, key, false)
+}
+
+func (l *Limiter) apply(ctx context.Context, key string, take bool) (Result, error) {
+	now := time.Now()
+	switch l.Strategy {
+	case SlidingWindow:
+		return l.Store.Increment(ctx, key, l.Limit, l.Period, now, take)
+	default:
+		return l.Store.TakeToken(ctx, key, l.Limit, l.Period, l.Burst, now, take)
+	}
+}
+
+// tokenBucketResult computes the result from the tokens left in the bucket.
+func tokenBucketResult(allowed bool, tokens float64, limit int64, period time.Duration, burst int64) Result {
+	perToken := float64(period) / float64(limit)
+	result := Result{
+		Allowed:   allowed,
+		Limit:     burst,
+		Remaining: int64(math.Floor(tokens)),
+		Reset:     time.Duration((float64(burst) - tokens) * perToken),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+	return result
+}
+
+// slidingWindowResult computes the result from the weighted count of requests.
+func slidingWindowResult(allowed bool, count float64, limit int64, period, elapsed time.Duration) Result {
+	remaining := limit - int64(math.Ceil(count))
+	if remaining < 0 {
+		remaining = 0
+	}
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     period - elapsed,
+	}
+	if !allowed {
+		result.RetryAfter = period - elapsed
+	}
+	return result
+}
+
+// window returns the start of the fixed window the time falls in and the
+// time elapsed since then.
+func window(now time.Time, period time.Duration) (int64, time.Duration) {
+	start := now.UnixNano() / int64(period)
+	elapsed := time.Duration(now.UnixNano() - start*int64(period))
+	return start, elapsed
+}
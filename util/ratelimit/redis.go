@@ -0,0 +1,137 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// tokenBucketScript refills the bucket based on the time elapsed since it was
+// last updated and takes a token if available, unless only checking.
+// KEYS[1]: bucket, ARGV: tokens per ms, burst, now in ms, take (1 or 0)
+var tokenBucketScript = redis.NewScript(`
+local data = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local take = ARGV[4] == "1"
+local tokens = tonumber(data[1])
+local ts = tonumber(data[2])
+if tokens == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+	allowed = 1
+end
+if not take then
+	return {allowed, tostring(tokens)}
+end
+if allowed == 1 then
+	tokens = tokens - 1
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil(burst / rate))
+return {allowed, tostring(tokens)}
+`)
+
+// slidingWindowScript counts the request in the current window if the count
+// weighted with the previous window is within the limit, unless only checking.
+// KEYS[1]: current window, KEYS[2]: previous window, ARGV: limit, period in ms, elapsed ms, take (1 or 0)
+var slidingWindowScript = redis.NewScript(`
+local current = tonumber(redis.call("GET", KEYS[1]) or "0")
+local previous = tonumber(redis.call("GET", KEYS[2]) or "0")
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+local weighted = previous * (period - elapsed) / period + current
+if weighted + 1 > limit then
+	return {0, tostring(weighted)}
+end
+if ARGV[4] ~= "1" then
+	return {1, tostring(weighted)}
+end
+current = redis.call("INCR", KEYS[1])
+if current == 1 then
+	redis.call("PEXPIRE", KEYS[1], period * 2)
+end
+return {1, tostring(weighted + 1)}
+`)
+
+// redisStore keeps the state of the limits in redis so that the limits are
+// shared by all the arc instances.
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore returns a store that keeps the state of the limits in redis,
+// the keys are prefixed with the given prefix.
+func NewRedisStore(client *redis.Client, prefix string) Store {
+	return &redisStore{client: client, prefix: prefix}
+}
+
+func (s *redisStore) TakeToken(ctx context.Context, key string, limit int64, period time.Duration, burst int64, now time.Time, take bool) (Result, error) {
+	rate := float64(limit) / float64(period/time.Millisecond)
+	reply, err := tokenBucketScript.Run(s.client.WithContext(ctx), []string{s.prefix + key},
+		rate, burst, now.UnixNano()/int64(time.Millisecond), flag(take)).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	allowed, tokens, err := parseReply(reply)
+	if err != nil {
+		return Result{}, err
+	}
+	return tokenBucketResult(allowed, tokens, limit, period, burst), nil
+}
+
+func (s *redisStore) Increment(ctx context.Context, key string, limit int64, period time.Duration, now time.Time, take bool) (Result, error) {
+	start, elapsed := window(now, period)
+	keys := []string{
+		fmt.Sprintf("%s%s:%d", s.prefix, key, start),
+		fmt.Sprintf("%s%s:%d", s.prefix, key, start-1),
+	}
+	reply, err := slidingWindowScript.Run(s.client.WithContext(ctx), keys,
+		limit, int64(period/time.Millisecond), int64(elapsed/time.Millisecond), flag(take)).Result()
+	if err != nil {
+		return Result{}, err
+	}
+	allowed, count, err := parseReply(reply)
+	if err != nil {
+		return Result{}, err
+	}
+	return slidingWindowResult(allowed, count, limit, period, elapsed), nil
+}
+
+// flag encodes the boolean as a script argument.
+func flag(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func parseReply(reply interface{}) (bool, float64, error) {
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("unexpected reply from redis: %v", reply)
+	}
+	allowed, ok := values[0].(int64)
+	if !ok {
+		return false, 0, fmt.Errorf("unexpected reply from redis: %v", reply)
+	}
+	raw, ok := values[1].(string)
+	if !ok {
+		return false, 0, fmt.Errorf("unexpected reply from redis: %v", reply)
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed == 1, value, nil
+}
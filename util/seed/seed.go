@@ -0,0 +1,65 @@
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/appbaseio/arc/model/permission"
+	"github.com/appbaseio/arc/model/user"
+)
+
+const envSeedFile = "SEED_FILE"
+
+var (
+	singleton *File
+	loadErr   error
+	once      sync.Once
+)
+
+// File declares the users and the permissions arc is seeded with. The
+// entries take the same form as the bodies of the apis creating them, the
+// permissions additionally carry their username and password so that the
+// deployments are reproducible.
+type File struct {
+	Users       []user.User             `json:"users"`
+	Permissions []permission.Permission `json:"permissions"`
+}
+
+// Load returns the seed file at SEED_FILE, or nil if it isn't set. The file
+// is read only once, so that all the plugins seed from the same contents.
+func Load() (*File, error) {
+	once.Do(func() {
+		path := os.Getenv(envSeedFile)
+		if path == "" {
+			return
+		}
+		singleton, loadErr = Read(path)
+	})
+	return singleton, loadErr
+}
+
+// Read parses the seed file at path.
+func Read(path string) (*File, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the seed file: %v", err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("unable to parse the seed file %s: %v", path, err)
+	}
+	for i, u := range f.Users {
+		if u.Username == "" || u.Password == "" {
+			return nil, fmt.Errorf(`seed file %s: users[%d] requires a "username" and a "password"`, path, i)
+		}
+	}
+	for i, p := range f.Permissions {
+		if p.Username == "" || p.Password == "" {
+			return nil, fmt.Errorf(`seed file %s: permissions[%d] requires a "username" and a "password"`, path, i)
+		}
+	}
+	return &f, nil
+}
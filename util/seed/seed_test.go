@@ -0,0 +1,52 @@
+package seed
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arc-seed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	Convey("Seed files declare users and permissions", t, func() {
+		f, err := Read(write("valid.json", `{
+			"users": [{"username": "ops", "password": "secret", "is_admin": true}],
+			"permissions": [{"username": "search", "password": "key", "categories": ["search"], "indices": ["products"]}]
+		}`))
+		So(err, ShouldBeNil)
+		So(f.Users, ShouldHaveLength, 1)
+		So(*f.Users[0].IsAdmin, ShouldBeTrue)
+		So(f.Permissions, ShouldHaveLength, 1)
+		So(f.Permissions[0].Indices, ShouldResemble, []string{"products"})
+	})
+
+	Convey("Entries require their credentials", t, func() {
+		_, err := Read(write("user.json", `{"users": [{"username": "ops"}]}`))
+		So(err, ShouldNotBeNil)
+		_, err = Read(write("permission.json", `{"permissions": [{"password": "key"}]}`))
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Malformed seed files are rejected", t, func() {
+		_, err := Read(write("malformed.json", `{"users": {}}`))
+		So(err, ShouldNotBeNil)
+		_, err = Read(filepath.Join(dir, "missing.json"))
+		So(err, ShouldNotBeNil)
+	})
+}
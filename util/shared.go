@@ -2,6 +2,8 @@ package util
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 
 	es7 "github.com/olivere/elastic/v7"
 	es6 "gopkg.in/olivere/elastic.v6"
@@ -43,3 +45,44 @@ func GetTotalNodes() (int, error) {
 	}
 	return len(response.Nodes), nil
 }
+
+// TotalHits represents the "hits.total" value of a search response.
+type TotalHits struct {
+	Value    int64  `json:"value"`
+	Relation string `json:"relation"`
+}
+
+// UnmarshalJSON decodes "hits.total" which is an integer in es6 and an
+// object with "value" and "relation" keys in es7 and above.
+func (t *TotalHits) UnmarshalJSON(data []byte) error {
+	var value int64
+	if err := json.Unmarshal(data, &value); err == nil {
+		t.Value = value
+		t.Relation = "eq"
+		return nil
+	}
+	type totalHits TotalHits
+	var v totalHits
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("unable to parse hits.total: %v", err)
+	}
+	*t = TotalHits(v)
+	return nil
+}
+
+// ParseTotalHits extracts the "hits.total" from a raw search response
+// irrespective of the es version that served the response.
+func ParseTotalHits(raw []byte) (*TotalHits, error) {
+	var response struct {
+		Hits struct {
+			Total *TotalHits `json:"total"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(raw, &response); err != nil {
+		return nil, err
+	}
+	if response.Hits.Total == nil {
+		return nil, fmt.Errorf("hits.total not found in the search response")
+	}
+	return response.Hits.Total, nil
+}
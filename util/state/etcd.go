@@ -0,0 +1,435 @@
+package state
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// etcdTimeout bounds the requests made to etcd, but the watches.
+const etcdTimeout = 10 * time.Second
+
+// etcdStore keeps the state in etcd, which is shared by all the arc
+// instances. It speaks to the json gateway of the v3 api of etcd: the keys
+// and the values are base64 encoded, the ttls are backed by leases and the
+// channels are keys whose puts are watched by the subscribers.
+type etcdStore struct {
+	endpoints []string
+	username  string
+	password  string
+	prefix    string
+	client    *http.Client
+	// watcher serves the watches, which stream for as long as they last.
+	watcher *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+// NewEtcdStore returns a store backed by the etcd cluster reachable at the
+// endpoints, e.g. "http://localhost:2379", which are tried in order. The
+// credentials are optional. The keys and channels are prefixed with the
+// given prefix.
+func NewEtcdStore(endpoints []string, username, password, prefix string) Store {
+	return &etcdStore{
+		endpoints: endpoints,
+		username:  username,
+		password:  password,
+		prefix:    prefix,
+		client:    &http.Client{Timeout: etcdTimeout},
+		watcher:   &http.Client{},
+	}
+}
+
+// etcdError is the error returned by the gateway.
+type etcdError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *etcdError) Error() string {
+	return fmt.Sprintf("etcd: %s (code %d)", e.Message, e.Code)
+}
+
+// etcdInt is an int64 of the gateway, which encodes them as strings.
+type etcdInt int64
+
+func (i *etcdInt) UnmarshalJSON(b []byte) error {
+	n, err := strconv.ParseInt(strings.Trim(string(b), `"`), 10, 64)
+	*i = etcdInt(n)
+	return err
+}
+
+type etcdKeyValue struct {
+	Key            []byte  `json:"key"`
+	Value          []byte  `json:"value"`
+	CreateRevision etcdInt `json:"create_revision"`
+	ModRevision    etcdInt `json:"mod_revision"`
+}
+
+type etcdPut struct {
+	Key         []byte `json:"key"`
+	Value       []byte `json:"value"`
+	Lease       int64  `json:"lease,omitempty"`
+	IgnoreLease bool   `json:"ignore_lease,omitempty"`
+}
+
+type etcdCompare struct {
+	Key            []byte `json:"key"`
+	Target         string `json:"target"`
+	Result         string `json:"result"`
+	CreateRevision int64  `json:"create_revision"`
+	ModRevision    int64  `json:"mod_revision"`
+}
+
+type etcdRequestOp struct {
+	RequestPut etcdPut `json:"request_put"`
+}
+
+type etcdTxn struct {
+	Compare []etcdCompare   `json:"compare"`
+	Success []etcdRequestOp `json:"success"`
+}
+
+// newTxn puts the value if the revision of the key is the given one, the key
+// must be absent for a zero revision.
+func newTxn(put etcdPut, modRevision int64) etcdTxn {
+	compare := etcdCompare{Key: put.Key, Target: "CREATE", Result: "EQUAL"}
+	if modRevision > 0 {
+		compare = etcdCompare{Key: put.Key, Target: "MOD", Result: "EQUAL", ModRevision: modRevision}
+	}
+	return etcdTxn{Compare: []etcdCompare{compare}, Success: []etcdRequestOp{{RequestPut: put}}}
+}
+
+// etcdWatch is a watch of the key of a channel.
+type etcdWatch struct {
+	body    io.Closer
+	decoder *json.Decoder
+	// revision is the last revision seen by the watch.
+	revision int64
+}
+
+func (s *etcdStore) Backend() string {
+	return BackendEtcd
+}
+
+func (s *etcdStore) Get(ctx context.Context, key string) ([]byte, error) {
+	kv, err := s.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return kv.Value, nil
+}
+
+func (s *etcdStore) get(ctx context.Context, key string) (*etcdKeyValue, error) {
+	var out struct {
+		Kvs []etcdKeyValue `json:"kvs"`
+	}
+	if err := s.call(ctx, "/v3/kv/range", map[string][]byte{"key": []byte(s.prefix + key)}, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Kvs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &out.Kvs[0], nil
+}
+
+func (s *etcdStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	lease, err := s.grant(ctx, ttl)
+	if err != nil {
+		return err
+	}
+	return s.call(ctx, "/v3/kv/put", etcdPut{Key: []byte(s.prefix + key), Value: value, Lease: lease}, nil)
+}
+
+func (s *etcdStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	lease, err := s.grant(ctx, ttl)
+	if err != nil {
+		return false, err
+	}
+	return s.txn(ctx, newTxn(etcdPut{Key: []byte(s.prefix + key), Value: value, Lease: lease}, 0))
+}
+
+func (s *etcdStore) Delete(ctx context.Context, key string) error {
+	return s.call(ctx, "/v3/kv/deleterange", map[string][]byte{"key": []byte(s.prefix + key)}, nil)
+}
+
+// Incr compares and swaps the counter until no other instance changed it in
+// between.
+func (s *etcdStore) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	for {
+		kv, err := s.get(ctx, key)
+		if err != nil && err != ErrNotFound {
+			return 0, err
+		}
+
+		put := etcdPut{Key: []byte(s.prefix + key)}
+		var count, revision int64
+		if kv != nil {
+			if count, err = strconv.ParseInt(string(kv.Value), 10, 64); err != nil {
+				return 0, fmt.Errorf("the value of %s isn't a counter: %v", key, err)
+			}
+			revision = int64(kv.ModRevision)
+			put.IgnoreLease = true
+		} else if put.Lease, err = s.grant(ctx, ttl); err != nil {
+			return 0, err
+		}
+		count += delta
+		put.Value = []byte(strconv.FormatInt(count, 10))
+
+		ok, err := s.txn(ctx, newTxn(put, revision))
+		if err != nil || ok {
+			return count, err
+		}
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// channelKey is the key of the channel, apart from the keys of the state.
+func (s *etcdStore) channelKey(channel string) []byte {
+	return []byte(s.prefix + "channels/" + channel)
+}
+
+func (s *etcdStore) Publish(ctx context.Context, channel string, message []byte) error {
+	return s.call(ctx, "/v3/kv/put", etcdPut{Key: s.channelKey(channel), Value: message}, nil)
+}
+
+// Subscribe watches the key of the channel. The watch is resumed from the
+// last revision seen whenever the connection drops, so that no message is
+// missed in between unless etcd compacted it.
+func (s *etcdStore) Subscribe(channel string, handler func([]byte)) (func(), error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w, err := s.watch(ctx, channel, 0)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go func() {
+		for {
+			w.receive(handler)
+			w.body.Close()
+			for {
+				if ctx.Err() != nil {
+					return
+				}
+				next, err := s.watch(ctx, channel, w.revision+1)
+				if err == nil {
+					w = next
+					break
+				}
+				log.Errorln(logTag, ": unable to watch", channel, ":", err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}()
+	return cancel, nil
+}
+
+// watch opens a watch of the key of the channel from the revision, or from
+// now if it is zero, and waits for its confirmation.
+func (s *etcdStore) watch(ctx context.Context, channel string, revision int64) (*etcdWatch, error) {
+	request := map[string]interface{}{"key": s.channelKey(channel)}
+	if revision > 0 {
+		request["start_revision"] = revision
+	}
+	resp, err := s.do(ctx, s.watcher, "/v3/watch", map[string]interface{}{"create_request": request})
+	if err != nil {
+		return nil, err
+	}
+	var out struct {
+		Result struct {
+			Header struct {
+				Revision etcdInt `json:"revision"`
+			} `json:"header"`
+			Created bool `json:"created"`
+		} `json:"result"`
+		Error *etcdError `json:"error"`
+	}
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(&out); err != nil || !out.Result.Created {
+		resp.Body.Close()
+		if out.Error != nil {
+			return nil, out.Error
+		}
+		return nil, fmt.Errorf("the watch of %s wasn't created: %v", channel, err)
+	}
+	w := &etcdWatch{body: resp.Body, decoder: decoder, revision: int64(out.Result.Header.Revision)}
+	if revision > 0 {
+		// the events from the revision are yet to be received
+		w.revision = revision - 1
+	}
+	return w, nil
+}
+
+// receive invokes the handler for the messages of the watch until it ends.
+func (w *etcdWatch) receive(handler func([]byte)) {
+	for {
+		var out struct {
+			Result struct {
+				Events []struct {
+					Type string       `json:"type"`
+					Kv   etcdKeyValue `json:"kv"`
+				} `json:"events"`
+			} `json:"result"`
+		}
+		if err := w.decoder.Decode(&out); err != nil {
+			return
+		}
+		for _, event := range out.Result.Events {
+			w.revision = int64(event.Kv.ModRevision)
+			if event.Type != "DELETE" {
+				handler(event.Kv.Value)
+			}
+		}
+	}
+}
+
+func (s *etcdStore) Health() error {
+	ctx, cancel := context.WithTimeout(context.Background(), etcdTimeout)
+	defer cancel()
+	return s.call(ctx, "/v3/maintenance/status", struct{}{}, nil)
+}
+
+// grant returns a lease of the ttl, rounded up to the second, or no lease if
+// the ttl is zero.
+func (s *etcdStore) grant(ctx context.Context, ttl time.Duration) (int64, error) {
+	if ttl <= 0 {
+		return 0, nil
+	}
+	seconds := int64((ttl + time.Second - 1) / time.Second)
+	var out struct {
+		ID etcdInt `json:"ID"`
+	}
+	if err := s.call(ctx, "/v3/lease/grant", map[string]int64{"TTL": seconds}, &out); err != nil {
+		return 0, err
+	}
+	return int64(out.ID), nil
+}
+
+func (s *etcdStore) txn(ctx context.Context, txn etcdTxn) (bool, error) {
+	var out struct {
+		Succeeded bool `json:"succeeded"`
+	}
+	err := s.call(ctx, "/v3/kv/txn", txn, &out)
+	return out.Succeeded, err
+}
+
+// call posts the request to the gateway and decodes its response into out.
+func (s *etcdStore) call(ctx context.Context, path string, in, out interface{}) error {
+	resp, err := s.do(ctx, s.client, path, in)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// do posts the request to the first endpoint reachable, authenticating
+// again once if the token expired.
+func (s *etcdStore) do(ctx context.Context, client *http.Client, path string, in interface{}) (*http.Response, error) {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		token, err := s.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := s.post(ctx, client, path, body, token)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusOK {
+			return resp, nil
+		}
+		e := &etcdError{}
+		json.NewDecoder(resp.Body).Decode(e)
+		resp.Body.Close()
+		if e.Message == "" {
+			e.Message = resp.Status
+		}
+		if attempt == 0 && token != "" && strings.Contains(e.Message, "invalid auth token") {
+			s.mu.Lock()
+			s.token = ""
+			s.mu.Unlock()
+			continue
+		}
+		return nil, e
+	}
+}
+
+// authenticate returns the token of the credentials, if any.
+func (s *etcdStore) authenticate(ctx context.Context) (string, error) {
+	if s.username == "" {
+		return "", nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" {
+		return s.token, nil
+	}
+	body, _ := json.Marshal(map[string]string{"name": s.username, "password": s.password})
+	resp, err := s.post(ctx, s.client, "/v3/auth/authenticate", body, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out struct {
+		Token string `json:"token"`
+		etcdError
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK || out.Token == "" {
+		return "", fmt.Errorf("unable to authenticate to etcd as %s: %v", s.username, &out.etcdError)
+	}
+	s.token = out.Token
+	return s.token, nil
+}
+
+// post tries the endpoints in order until one of them answers.
+func (s *etcdStore) post(ctx context.Context, client *http.Client, path string, body []byte, token string) (*http.Response, error) {
+	err := errors.New("no etcd endpoint")
+	for _, endpoint := range s.endpoints {
+		req, e := http.NewRequest(http.MethodPost, strings.TrimSuffix(endpoint, "/")+path, bytes.NewReader(body))
+		if e != nil {
+			return nil, e
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		resp, e := client.Do(req.WithContext(ctx))
+		if e == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, e
+		}
+		err = e
+	}
+	return nil, err
+}
@@ -0,0 +1,302 @@
+package state
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEtcdStore(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeEtcd()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	// the first endpoint is unreachable
+	s := NewEtcdStore([]string{"http://127.0.0.1:1", server.URL}, "arc", "secret", "arc:")
+
+	Convey("The values are stored with a lease of their ttl", t, func() {
+		So(s.Set(ctx, "key", []byte("value"), time.Millisecond), ShouldBeNil)
+		value, err := s.Get(ctx, "key")
+		So(err, ShouldBeNil)
+		So(string(value), ShouldEqual, "value")
+		So(fake.ttl("arc:key"), ShouldEqual, 1)
+
+		So(s.Set(ctx, "key", []byte("forever"), 0), ShouldBeNil)
+		So(fake.ttl("arc:key"), ShouldEqual, 0)
+
+		So(s.Delete(ctx, "key"), ShouldBeNil)
+		_, err = s.Get(ctx, "key")
+		So(err, ShouldEqual, ErrNotFound)
+	})
+
+	Convey("SetNX only stores absent keys", t, func() {
+		ok, err := s.SetNX(ctx, "lock", []byte("a"), time.Minute)
+		So(err, ShouldBeNil)
+		So(ok, ShouldBeTrue)
+		So(fake.ttl("arc:lock"), ShouldEqual, 60)
+		ok, _ = s.SetNX(ctx, "lock", []byte("b"), time.Minute)
+		So(ok, ShouldBeFalse)
+		value, _ := s.Get(ctx, "lock")
+		So(string(value), ShouldEqual, "a")
+	})
+
+	Convey("Incr accumulates the counter and keeps its lease", t, func() {
+		count, err := s.Incr(ctx, "counter", 2, time.Minute)
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 2)
+		count, _ = s.Incr(ctx, "counter", 3, time.Hour)
+		So(count, ShouldEqual, 5)
+		So(fake.ttl("arc:counter"), ShouldEqual, 60)
+	})
+
+	Convey("Incr retries when the counter is changed concurrently", t, func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.Incr(ctx, "concurrent", 1, 0)
+			}()
+		}
+		wg.Wait()
+		value, _ := s.Get(ctx, "concurrent")
+		So(string(value), ShouldEqual, "10")
+	})
+
+	Convey("Published messages reach the subscribers, across reconnections", t, func() {
+		received := make(chan string, 10)
+		unsubscribe, err := s.Subscribe("channel", func(message []byte) {
+			received <- string(message)
+		})
+		So(err, ShouldBeNil)
+
+		So(s.Publish(ctx, "channel", []byte("first")), ShouldBeNil)
+		So(s.Publish(ctx, "other", []byte("ignored")), ShouldBeNil)
+		So(<-received, ShouldEqual, "first")
+
+		fake.dropWatches()
+		So(s.Publish(ctx, "channel", []byte("second")), ShouldBeNil)
+		So(<-received, ShouldEqual, "second")
+
+		unsubscribe()
+		s.Publish(ctx, "channel", []byte("third"))
+		select {
+		case message := <-received:
+			So(message, ShouldBeEmpty)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	Convey("The expired tokens are renewed", t, func() {
+		fake.expireTokens()
+		So(s.Health(), ShouldBeNil)
+		So(fake.authentications(), ShouldEqual, 2)
+	})
+
+	Convey("The channels don't overwrite the keys", t, func() {
+		So(s.Set(ctx, "channel", []byte("value"), 0), ShouldBeNil)
+		So(s.Publish(ctx, "channel", []byte("message")), ShouldBeNil)
+		value, _ := s.Get(ctx, "channel")
+		So(string(value), ShouldEqual, "value")
+	})
+}
+
+// fakeEtcd serves the json gateway of etcd from memory. The leases never
+// expire and the whole history is kept for the watches.
+type fakeEtcd struct {
+	mu       sync.Mutex
+	revision int64
+	kvs      map[string]fakeKeyValue
+	leases   map[int64]int64
+	history  []fakeKeyValue
+	changed  chan struct{}
+	dropped  chan struct{}
+	token    int
+	authn    int
+}
+
+type fakeKeyValue struct {
+	key, value               string
+	createRevision, revision int64
+	lease                    int64
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{
+		kvs:     make(map[string]fakeKeyValue),
+		leases:  make(map[int64]int64),
+		changed: make(chan struct{}),
+		dropped: make(chan struct{}),
+		token:   1,
+	}
+}
+
+func (f *fakeEtcd) ttl(key string) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.leases[f.kvs[key].lease]
+}
+
+func (f *fakeEtcd) dropWatches() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	close(f.dropped)
+	f.dropped = make(chan struct{})
+}
+
+func (f *fakeEtcd) expireTokens() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.token++
+}
+
+func (f *fakeEtcd) authentications() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.authn
+}
+
+func (f *fakeEtcd) fail(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{"error": message, "message": message, "code": code})
+}
+
+func (f *fakeEtcd) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var in struct {
+		Key           []byte
+		Value         []byte
+		Lease         int64
+		IgnoreLease   bool `json:"ignore_lease"`
+		TTL           int64
+		Compare       []etcdCompare
+		Success       []etcdRequestOp
+		CreateRequest struct {
+			Key           []byte
+			StartRevision int64 `json:"start_revision"`
+		} `json:"create_request"`
+	}
+	json.NewDecoder(req.Body).Decode(&in)
+
+	f.mu.Lock()
+	if req.URL.Path == "/v3/auth/authenticate" {
+		f.authn++
+		json.NewEncoder(w).Encode(map[string]string{"token": strconv.Itoa(f.token)})
+		f.mu.Unlock()
+		return
+	}
+	if req.Header.Get("Authorization") != strconv.Itoa(f.token) {
+		f.mu.Unlock()
+		f.fail(w, 16, "etcdserver: invalid auth token")
+		return
+	}
+	if req.URL.Path == "/v3/watch" {
+		f.mu.Unlock()
+		f.watch(w, req, string(in.CreateRequest.Key), in.CreateRequest.StartRevision)
+		return
+	}
+	defer f.mu.Unlock()
+
+	out := map[string]interface{}{}
+	switch req.URL.Path {
+	case "/v3/kv/range":
+		if kv, ok := f.kvs[string(in.Key)]; ok {
+			out["kvs"] = []map[string]interface{}{kv.json()}
+		}
+	case "/v3/kv/put":
+		f.put(etcdPut{Key: in.Key, Value: in.Value, Lease: in.Lease, IgnoreLease: in.IgnoreLease})
+	case "/v3/kv/deleterange":
+		delete(f.kvs, string(in.Key))
+	case "/v3/kv/txn":
+		compare := in.Compare[0]
+		kv := f.kvs[string(compare.Key)]
+		succeeded := compare.Target == "CREATE" && kv.createRevision == compare.CreateRevision ||
+			compare.Target == "MOD" && kv.revision == compare.ModRevision
+		if succeeded {
+			f.put(in.Success[0].RequestPut)
+		}
+		out["succeeded"] = succeeded
+	case "/v3/lease/grant":
+		id := int64(len(f.leases) + 1)
+		f.leases[id] = in.TTL
+		out["ID"] = strconv.FormatInt(id, 10)
+	case "/v3/maintenance/status":
+	default:
+		f.fail(w, 12, "unknown path")
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func (f *fakeEtcd) put(put etcdPut) {
+	f.revision++
+	kv := f.kvs[string(put.Key)]
+	if kv.createRevision == 0 {
+		kv.createRevision = f.revision
+	}
+	if !put.IgnoreLease {
+		kv.lease = put.Lease
+	}
+	kv.key, kv.value, kv.revision = string(put.Key), string(put.Value), f.revision
+	f.kvs[kv.key] = kv
+	f.history = append(f.history, kv)
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// watch streams the puts of the key from the revision, until the watch is
+// canceled or dropped.
+func (f *fakeEtcd) watch(w http.ResponseWriter, req *http.Request, key string, revision int64) {
+	f.mu.Lock()
+	encoder := json.NewEncoder(w)
+	encoder.Encode(map[string]interface{}{"result": map[string]interface{}{
+		"header":  map[string]string{"revision": strconv.FormatInt(f.revision, 10)},
+		"created": true,
+	}})
+	if revision == 0 {
+		revision = f.revision + 1
+	}
+	dropped := f.dropped
+	f.mu.Unlock()
+	w.(http.Flusher).Flush()
+
+	for {
+		f.mu.Lock()
+		var events []map[string]interface{}
+		for _, kv := range f.history {
+			if kv.key == key && kv.revision >= revision {
+				events = append(events, map[string]interface{}{"kv": kv.json()})
+				revision = kv.revision + 1
+			}
+		}
+		changed := f.changed
+		f.mu.Unlock()
+
+		if len(events) > 0 {
+			encoder.Encode(map[string]interface{}{"result": map[string]interface{}{"events": events}})
+			w.(http.Flusher).Flush()
+		}
+		select {
+		case <-changed:
+		case <-dropped:
+			return
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+func (kv fakeKeyValue) json() map[string]interface{} {
+	return map[string]interface{}{
+		"key":             []byte(kv.key),
+		"value":           []byte(kv.value),
+		"create_revision": strconv.FormatInt(kv.createRevision, 10),
+		"mod_revision":    strconv.FormatInt(kv.revision, 10),
+	}
+}
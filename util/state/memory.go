@@ -0,0 +1,150 @@
+package state
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryStore keeps the state in memory of a single arc instance.
+type memoryStore struct {
+	mu          sync.Mutex
+	entries     map[string]entry
+	subscribers map[string]map[int]func([]byte)
+	nextID      int
+}
+
+type entry struct {
+	value   []byte
+	expires time.Time
+}
+
+func (e entry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
+
+// NewMemoryStore returns a store that keeps the state in memory. The expired
+// entries are evicted periodically.
+func NewMemoryStore() Store {
+	s := &memoryStore{
+		entries:     make(map[string]entry),
+		subscribers: make(map[string]map[int]func([]byte)),
+	}
+	go s.evict(time.Minute)
+	return s
+}
+
+func (s *memoryStore) Backend() string {
+	return BackendMemory
+}
+
+func (s *memoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || e.expired(time.Now()) {
+		return nil, ErrNotFound
+	}
+	return e.value, nil
+}
+
+func (s *memoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = newEntry(value, ttl)
+	return nil
+}
+
+func (s *memoryStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[key]; ok && !e.expired(time.Now()) {
+		return false, nil
+	}
+	s.entries[key] = newEntry(value, ttl)
+	return true, nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *memoryStore) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || e.expired(time.Now()) {
+		e = newEntry(nil, ttl)
+	}
+	var count int64
+	if e.value != nil {
+		var err error
+		if count, err = strconv.ParseInt(string(e.value), 10, 64); err != nil {
+			return 0, err
+		}
+	}
+	count += delta
+	e.value = []byte(strconv.FormatInt(count, 10))
+	s.entries[key] = e
+	return count, nil
+}
+
+func (s *memoryStore) Publish(ctx context.Context, channel string, message []byte) error {
+	s.mu.Lock()
+	handlers := make([]func([]byte), 0, len(s.subscribers[channel]))
+	for _, handler := range s.subscribers[channel] {
+		handlers = append(handlers, handler)
+	}
+	s.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+func (s *memoryStore) Subscribe(channel string, handler func([]byte)) (func(), error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[channel] == nil {
+		s.subscribers[channel] = make(map[int]func([]byte))
+	}
+	id := s.nextID
+	s.nextID++
+	s.subscribers[channel][id] = handler
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers[channel], id)
+	}, nil
+}
+
+func (s *memoryStore) Health() error {
+	return nil
+}
+
+func (s *memoryStore) evict(interval time.Duration) {
+	for range time.Tick(interval) {
+		now := time.Now()
+		s.mu.Lock()
+		for key, e := range s.entries {
+			if e.expired(now) {
+				delete(s.entries, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func newEntry(value []byte, ttl time.Duration) entry {
+	e := entry{value: value}
+	if ttl > 0 {
+		e.expires = time.Now().Add(ttl)
+	}
+	return e
+}
@@ -0,0 +1,61 @@
+package state
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Values expire after the ttl", t, func() {
+		s := NewMemoryStore()
+		So(s.Set(ctx, "key", []byte("value"), time.Millisecond), ShouldBeNil)
+		value, err := s.Get(ctx, "key")
+		So(err, ShouldBeNil)
+		So(string(value), ShouldEqual, "value")
+
+		time.Sleep(5 * time.Millisecond)
+		_, err = s.Get(ctx, "key")
+		So(err, ShouldEqual, ErrNotFound)
+	})
+
+	Convey("SetNX only stores absent keys", t, func() {
+		s := NewMemoryStore()
+		ok, _ := s.SetNX(ctx, "lock", []byte("a"), time.Minute)
+		So(ok, ShouldBeTrue)
+		ok, _ = s.SetNX(ctx, "lock", []byte("b"), time.Minute)
+		So(ok, ShouldBeFalse)
+
+		So(s.Delete(ctx, "lock"), ShouldBeNil)
+		ok, _ = s.SetNX(ctx, "lock", []byte("b"), time.Minute)
+		So(ok, ShouldBeTrue)
+	})
+
+	Convey("Incr accumulates the counter", t, func() {
+		s := NewMemoryStore()
+		count, err := s.Incr(ctx, "counter", 2, time.Minute)
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 2)
+		count, _ = s.Incr(ctx, "counter", 3, time.Minute)
+		So(count, ShouldEqual, 5)
+	})
+
+	Convey("Published messages reach the subscribers", t, func() {
+		s := NewMemoryStore()
+		var received []string
+		unsubscribe, err := s.Subscribe("channel", func(message []byte) {
+			received = append(received, string(message))
+		})
+		So(err, ShouldBeNil)
+
+		s.Publish(ctx, "channel", []byte("first"))
+		s.Publish(ctx, "other", []byte("ignored"))
+		unsubscribe()
+		s.Publish(ctx, "channel", []byte("second"))
+		So(received, ShouldResemble, []string{"first"})
+	})
+}
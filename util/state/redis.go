@@ -0,0 +1,86 @@
+package state
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+)
+
+// incrScript increments the counter and sets the ttl only if the key was created.
+var incrScript = redis.NewScript(`
+local count = redis.call("INCRBY", KEYS[1], ARGV[1])
+if count == tonumber(ARGV[1]) and tonumber(ARGV[2]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return count
+`)
+
+// redisStore keeps the state in redis, which is shared by all the arc instances.
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore returns a store backed by the redis client, the keys and
+// channels are prefixed with the given prefix.
+func NewRedisStore(client *redis.Client, prefix string) Store {
+	return &redisStore{client: client, prefix: prefix}
+}
+
+func (s *redisStore) Backend() string {
+	return BackendRedis
+}
+
+func (s *redisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.WithContext(ctx).Get(s.prefix + key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	return value, err
+}
+
+func (s *redisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.WithContext(ctx).Set(s.prefix+key, value, ttl).Err()
+}
+
+func (s *redisStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return s.client.WithContext(ctx).SetNX(s.prefix+key, value, ttl).Result()
+}
+
+func (s *redisStore) Delete(ctx context.Context, key string) error {
+	return s.client.WithContext(ctx).Del(s.prefix + key).Err()
+}
+
+func (s *redisStore) Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error) {
+	return incrScript.Run(s.client.WithContext(ctx), []string{s.prefix + key},
+		delta, int64(ttl/time.Millisecond)).Int64()
+}
+
+func (s *redisStore) Publish(ctx context.Context, channel string, message []byte) error {
+	return s.client.WithContext(ctx).Publish(s.prefix+channel, message).Err()
+}
+
+func (s *redisStore) Subscribe(channel string, handler func([]byte)) (func(), error) {
+	pubsub := s.client.Subscribe(s.prefix + channel)
+	// wait for the confirmation so that no message published after returning is missed
+	if _, err := pubsub.Receive(); err != nil {
+		pubsub.Close()
+		return nil, err
+	}
+	go func() {
+		for msg := range pubsub.Channel() {
+			handler([]byte(msg.Payload))
+		}
+	}()
+	return func() {
+		if err := pubsub.Close(); err != nil {
+			log.Errorln(logTag, ": unable to unsubscribe from", channel, ":", err)
+		}
+	}, nil
+}
+
+func (s *redisStore) Health() error {
+	return s.client.Ping().Err()
+}
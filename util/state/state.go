@@ -0,0 +1,150 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/health"
+)
+
+const (
+	logTag           = "[state]"
+	envBackend       = "STATE_BACKEND"
+	envRedisAddr     = "STATE_REDIS_ADDR"
+	envRedisPassword = "STATE_REDIS_PASSWORD"
+	envRedisDB       = "STATE_REDIS_DB"
+	envKeyPrefix     = "STATE_KEY_PREFIX"
+	envEtcdEndpoints = "STATE_ETCD_ENDPOINTS"
+	envEtcdUsername  = "STATE_ETCD_USERNAME"
+	envEtcdPassword  = "STATE_ETCD_PASSWORD"
+	defaultRedisAddr = "localhost:6379"
+	defaultEtcdAddr  = "http://localhost:2379"
+	defaultKeyPrefix = "arc:"
+)
+
+// Supported state backends.
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+	BackendEtcd   = "etcd"
+)
+
+// ErrNotFound is returned when the key doesn't exist or has expired.
+var ErrNotFound = errors.New("key not found")
+
+// Store is the state shared by the arc instances. The memory store only
+// shares the state within a single instance, hence the instances coordinate
+// only if a distributed backend is configured.
+type Store interface {
+	// Backend returns the name of the backend.
+	Backend() string
+
+	// Get returns the value stored against the key or ErrNotFound.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores the value against the key, the key never expires if the ttl is zero.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// SetNX stores the value only if the key doesn't exist and reports whether it was stored.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
+	// Delete removes the key.
+	Delete(ctx context.Context, key string) error
+
+	// Incr atomically increments the counter stored against the key by delta
+	// and returns the new value. The ttl is only applied when the key is created.
+	Incr(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+
+	// Publish sends the message to the subscribers of the channel on all the instances.
+	Publish(ctx context.Context, channel string, message []byte) error
+
+	// Subscribe invokes the handler for each message published on the channel
+	// until the returned function is called.
+	Subscribe(channel string, handler func(message []byte)) (func(), error)
+
+	// Health returns a non-nil error if the backend is unreachable.
+	Health() error
+}
+
+var (
+	singleton Store
+	once      sync.Once
+)
+
+// Instance returns the store configured via STATE_BACKEND. Arc fails to
+// start if the configured backend is unavailable, since falling back to the
+// memory store would silently stop the instances from coordinating.
+func Instance() Store {
+	once.Do(func() {
+		s, err := newStore(os.Getenv(envBackend))
+		if err != nil {
+			log.Fatal(logTag, ": unable to initialize the state backend: ", err)
+		}
+		log.Println(logTag, ": using", s.Backend(), "backend")
+		health.Register("state."+s.Backend(), s.Health, false)
+		singleton = s
+	})
+	return singleton
+}
+
+// Distributed reports whether the state is shared across the arc instances.
+func Distributed() bool {
+	return Instance().Backend() != BackendMemory
+}
+
+func newStore(backend string) (Store, error) {
+	switch backend {
+	case "", BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendRedis:
+		addr := os.Getenv(envRedisAddr)
+		if addr == "" {
+			addr = defaultRedisAddr
+		}
+		db := 0
+		if value := os.Getenv(envRedisDB); value != "" {
+			var err error
+			if db, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid value for %s: %v", envRedisDB, err)
+			}
+		}
+		client := redis.NewClient(&redis.Options{
+			Addr:     addr,
+			Password: os.Getenv(envRedisPassword),
+			DB:       db,
+		})
+		if err := client.Ping().Err(); err != nil {
+			return nil, fmt.Errorf("unable to connect to redis at %s: %v", addr, err)
+		}
+		return NewRedisStore(client, keyPrefix()), nil
+	case BackendEtcd:
+		endpoints := strings.Split(os.Getenv(envEtcdEndpoints), ",")
+		if endpoints[0] == "" {
+			endpoints = []string{defaultEtcdAddr}
+		}
+		s := NewEtcdStore(endpoints, os.Getenv(envEtcdUsername), os.Getenv(envEtcdPassword), keyPrefix())
+		if err := s.Health(); err != nil {
+			return nil, fmt.Errorf("unable to connect to etcd at %s: %v", strings.Join(endpoints, ","), err)
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf(`invalid value "%s" for %s, must be one of "%s", "%s" or "%s"`,
+			backend, envBackend, BackendMemory, BackendRedis, BackendEtcd)
+	}
+}
+
+func keyPrefix() string {
+	if prefix := os.Getenv(envKeyPrefix); prefix != "" {
+		return prefix
+	}
+	return defaultKeyPrefix
+}
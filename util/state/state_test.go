@@ -0,0 +1,36 @@
+package state
+
+import (
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewStore(t *testing.T) {
+	defer os.Unsetenv(envRedisAddr)
+
+	Convey("The memory backend is the default", t, func() {
+		s, err := newStore("")
+		So(err, ShouldBeNil)
+		So(s.Backend(), ShouldEqual, BackendMemory)
+	})
+
+	Convey("An unreachable redis isn't replaced by the memory backend", t, func() {
+		os.Setenv(envRedisAddr, "127.0.0.1:1")
+		_, err := newStore(BackendRedis)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("An unreachable etcd isn't replaced by the memory backend", t, func() {
+		os.Setenv(envEtcdEndpoints, "http://127.0.0.1:1")
+		defer os.Unsetenv(envEtcdEndpoints)
+		_, err := newStore(BackendEtcd)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("The unsupported backends are rejected", t, func() {
+		_, err := newStore("consul")
+		So(err, ShouldNotBeNil)
+	})
+}
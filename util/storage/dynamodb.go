@@ -0,0 +1,399 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	envAWSRegion        = "AWS_REGION"
+	envAWSDefaultRegion = "AWS_DEFAULT_REGION"
+	envAWSAccessKeyID   = "AWS_ACCESS_KEY_ID"
+	envAWSSecretKey     = "AWS_SECRET_ACCESS_KEY"
+	envAWSSessionToken  = "AWS_SESSION_TOKEN"
+	envDynamoDBEndpoint = "AWS_ENDPOINT_URL_DYNAMODB"
+	// dynamoDBTarget prefixes the operations of the dynamodb api.
+	dynamoDBTarget = "DynamoDB_20120810."
+	// tableActiveTimeout bounds the wait for a created table to be usable.
+	tableActiveTimeout = 2 * time.Minute
+)
+
+// attribute is an attribute value of a dynamodb item, either a string or a
+// number.
+type attribute struct {
+	S string `json:"S,omitempty"`
+	N string `json:"N,omitempty"`
+}
+
+type item map[string]attribute
+
+// dynamoStore keeps the documents in a dynamodb table, whose partition key
+// is the collection of the documents and sort key their id.
+type dynamoStore struct {
+	client *dynamoClient
+	table  string
+}
+
+// openDynamoDB opens the table, created if it doesn't exist. The region and
+// the credentials are those of the standard aws environment variables.
+func openDynamoDB(table string) (*dynamoStore, error) {
+	region := os.Getenv(envAWSRegion)
+	if region == "" {
+		region = os.Getenv(envAWSDefaultRegion)
+	}
+	if region == "" {
+		return nil, fmt.Errorf("%s is required for the %s backend", envAWSRegion, DynamoDB)
+	}
+	creds := credentials{
+		accessKeyID:  os.Getenv(envAWSAccessKeyID),
+		secretKey:    os.Getenv(envAWSSecretKey),
+		sessionToken: os.Getenv(envAWSSessionToken),
+	}
+	if creds.accessKeyID == "" || creds.secretKey == "" {
+		return nil, fmt.Errorf("%s and %s are required for the %s backend", envAWSAccessKeyID, envAWSSecretKey, DynamoDB)
+	}
+	endpoint := os.Getenv(envDynamoDBEndpoint)
+	if endpoint == "" {
+		endpoint = "https://dynamodb." + region + ".amazonaws.com"
+	}
+	s := &dynamoStore{
+		client: &dynamoClient{
+			http:     &http.Client{Timeout: 30 * time.Second},
+			endpoint: endpoint,
+			region:   region,
+			creds:    creds,
+		},
+		table: table,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tableActiveTimeout)
+	defer cancel()
+	if err := s.createTable(ctx); err != nil {
+		return nil, fmt.Errorf("%s: unable to create the table %s: %v", logTag, table, err)
+	}
+	return s, nil
+}
+
+// createTable creates the table if it doesn't exist and waits for it to be
+// active.
+func (s *dynamoStore) createTable(ctx context.Context) error {
+	active, err := s.tableActive(ctx)
+	if err == nil && active {
+		return nil
+	}
+	if e, ok := err.(*dynamoError); ok && e.Type == "ResourceNotFoundException" {
+		err = s.client.do(ctx, "CreateTable", map[string]interface{}{
+			"TableName": s.table,
+			"AttributeDefinitions": []map[string]string{
+				{"AttributeName": "collection", "AttributeType": "S"},
+				{"AttributeName": "id", "AttributeType": "S"},
+			},
+			"KeySchema": []map[string]string{
+				{"AttributeName": "collection", "KeyType": "HASH"},
+				{"AttributeName": "id", "KeyType": "RANGE"},
+			},
+			"BillingMode": "PAY_PER_REQUEST",
+		}, nil)
+		// the table may have been created by another instance meanwhile
+		if e, ok := err.(*dynamoError); ok && e.Type == "ResourceInUseException" {
+			err = nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	for {
+		if active, err := s.tableActive(ctx); err != nil || active {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (s *dynamoStore) tableActive(ctx context.Context) (bool, error) {
+	var out struct {
+		Table struct {
+			TableStatus string
+		}
+	}
+	if err := s.client.do(ctx, "DescribeTable", map[string]string{"TableName": s.table}, &out); err != nil {
+		return false, err
+	}
+	return out.Table.TableStatus == "ACTIVE", nil
+}
+
+func (s *dynamoStore) close() error {
+	return nil
+}
+
+func (s *dynamoStore) key(collection, id string) item {
+	return item{"collection": {S: collection}, "id": {S: id}}
+}
+
+func (s *dynamoStore) get(ctx context.Context, collection, id string) (string, int64, error) {
+	var out struct {
+		Item item
+	}
+	err := s.client.do(ctx, "GetItem", map[string]interface{}{
+		"TableName":      s.table,
+		"Key":            s.key(collection, id),
+		"ConsistentRead": true,
+	}, &out)
+	if err != nil {
+		return "", 0, err
+	}
+	if len(out.Item) == 0 {
+		return "", 0, ErrNotFound
+	}
+	version, err := strconv.ParseInt(out.Item["version"].N, 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	return out.Item["doc"].S, version, nil
+}
+
+func (s *dynamoStore) list(ctx context.Context, collection string) ([]json.RawMessage, error) {
+	docs := []json.RawMessage{}
+	var start item
+	for {
+		in := map[string]interface{}{
+			"TableName":                 s.table,
+			"KeyConditionExpression":    "#c = :c",
+			"ExpressionAttributeNames":  map[string]string{"#c": "collection"},
+			"ExpressionAttributeValues": item{":c": {S: collection}},
+			"ConsistentRead":            true,
+		}
+		if start != nil {
+			in["ExclusiveStartKey"] = start
+		}
+		var out struct {
+			Items            []item
+			LastEvaluatedKey item
+		}
+		if err := s.client.do(ctx, "Query", in, &out); err != nil {
+			return nil, err
+		}
+		for _, i := range out.Items {
+			docs = append(docs, json.RawMessage(i["doc"].S))
+		}
+		if len(out.LastEvaluatedKey) == 0 {
+			return docs, nil
+		}
+		start = out.LastEvaluatedKey
+	}
+}
+
+func (s *dynamoStore) put(ctx context.Context, collection, id, doc string) error {
+	return s.client.do(ctx, "UpdateItem", map[string]interface{}{
+		"TableName":                 s.table,
+		"Key":                       s.key(collection, id),
+		"UpdateExpression":          "SET #d = :d ADD #v :one",
+		"ExpressionAttributeNames":  map[string]string{"#d": "doc", "#v": "version"},
+		"ExpressionAttributeValues": item{":d": {S: doc}, ":one": {N: "1"}},
+	}, nil)
+}
+
+func (s *dynamoStore) replace(ctx context.Context, collection, id, doc string, version int64) (bool, error) {
+	err := s.client.do(ctx, "UpdateItem", map[string]interface{}{
+		"TableName":                s.table,
+		"Key":                      s.key(collection, id),
+		"UpdateExpression":         "SET #d = :d, #v = :next",
+		"ConditionExpression":      "#v = :v",
+		"ExpressionAttributeNames": map[string]string{"#d": "doc", "#v": "version"},
+		"ExpressionAttributeValues": item{
+			":d":    {S: doc},
+			":v":    {N: strconv.FormatInt(version, 10)},
+			":next": {N: strconv.FormatInt(version+1, 10)},
+		},
+	}, nil)
+	if e, ok := err.(*dynamoError); ok && e.Type == "ConditionalCheckFailedException" {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *dynamoStore) delete(ctx context.Context, collection, id string) (bool, error) {
+	var out struct {
+		Attributes item
+	}
+	err := s.client.do(ctx, "DeleteItem", map[string]interface{}{
+		"TableName":    s.table,
+		"Key":          s.key(collection, id),
+		"ReturnValues": "ALL_OLD",
+	}, &out)
+	return len(out.Attributes) > 0, err
+}
+
+// dynamoError is an error returned by the dynamodb api.
+type dynamoError struct {
+	Type    string
+	Message string
+}
+
+func (e *dynamoError) Error() string {
+	return e.Type + ": " + e.Message
+}
+
+// dynamoClient calls the json api of dynamodb.
+type dynamoClient struct {
+	http     *http.Client
+	endpoint string
+	region   string
+	creds    credentials
+}
+
+// do calls the operation with the input and decodes its output into out,
+// unless nil.
+func (c *dynamoClient) do(ctx context.Context, op string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", dynamoDBTarget+op)
+	signV4(req, body, c.creds, c.region, "dynamodb", time.Now())
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &e)
+		// the type is qualified with the namespace of the api, e.g.
+		// com.amazonaws.dynamodb.v20120810#ResourceNotFoundException
+		if i := strings.LastIndex(e.Type, "#"); i >= 0 {
+			e.Type = e.Type[i+1:]
+		}
+		if e.Type == "" {
+			e.Type = resp.Status
+		}
+		return &dynamoError{Type: e.Type, Message: e.Message}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// credentials are the aws credentials the requests are signed with.
+type credentials struct {
+	accessKeyID  string
+	secretKey    string
+	sessionToken string
+}
+
+// signV4 signs the request with the aws signature version 4. The host, the
+// content type and the x-amz headers of the request are signed.
+func signV4(req *http.Request, body []byte, creds credentials, region, service string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	payloadHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+creds.accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalQuery encodes the query params sorted by name, with the spaces
+// encoded as %20.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, awsEscape(key)+"="+awsEscape(value))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func awsEscape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSignV4(t *testing.T) {
+	Convey("The requests are signed as per the aws test suite", t, func() {
+		// the get-vanilla case of the signature version 4 test suite
+		req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+		creds := credentials{accessKeyID: "AKIDEXAMPLE", secretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+		signV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+		So(req.Header.Get("X-Amz-Date"), ShouldEqual, "20150830T123600Z")
+		So(req.Header.Get("Authorization"), ShouldEqual, "AWS4-HMAC-SHA256 "+
+			"Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+			"SignedHeaders=host;x-amz-date, "+
+			"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31")
+	})
+}
+
+func TestDynamoDB(t *testing.T) {
+	fake := newFakeDynamoDB()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	for env, value := range map[string]string{
+		envAWSRegion:        "us-east-1",
+		envAWSAccessKeyID:   "AKID",
+		envAWSSecretKey:     "secret",
+		envDynamoDBEndpoint: server.URL,
+	} {
+		os.Setenv(env, value)
+		defer os.Unsetenv(env)
+	}
+
+	d, err := Open(DynamoDB, "arc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	Convey("The table is created on open", t, func() {
+		So(fake.created, ShouldEqual, 1)
+		_, err := Open(DynamoDB, "arc")
+		So(err, ShouldBeNil)
+		So(fake.created, ShouldEqual, 1)
+	})
+
+	testDocuments(t, d)
+
+	Convey("The requests are signed", t, func() {
+		So(fake.unsigned, ShouldEqual, 0)
+	})
+}
+
+// fakeDynamoDB serves the operations and the expressions of the dynamodb
+// api used by the store from memory, a page of the queries holds a single
+// item.
+type fakeDynamoDB struct {
+	mu       sync.Mutex
+	items    map[string]item
+	created  int
+	unsigned int
+}
+
+func newFakeDynamoDB() *fakeDynamoDB {
+	return &fakeDynamoDB{items: make(map[string]item)}
+}
+
+func (f *fakeDynamoDB) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		f.unsigned++
+	}
+
+	var in struct {
+		Key                       item
+		ExpressionAttributeValues item
+		ExclusiveStartKey         item
+		UpdateExpression          string
+		ConditionExpression       string
+	}
+	json.NewDecoder(req.Body).Decode(&in)
+	key := func(i item) string { return i["collection"].S + "/" + i["id"].S }
+	fail := func(errType string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{
+			"__type":  "com.amazonaws.dynamodb.v20120810#" + errType,
+			"message": errType,
+		})
+	}
+
+	out := map[string]interface{}{}
+	switch strings.TrimPrefix(req.Header.Get("X-Amz-Target"), dynamoDBTarget) {
+	case "DescribeTable":
+		if f.created == 0 {
+			fail("ResourceNotFoundException")
+			return
+		}
+		out["Table"] = map[string]string{"TableStatus": "ACTIVE"}
+	case "CreateTable":
+		f.created++
+	case "GetItem":
+		if i, ok := f.items[key(in.Key)]; ok {
+			out["Item"] = i
+		}
+	case "Query":
+		var keys []string
+		for k := range f.items {
+			if strings.HasPrefix(k, in.ExpressionAttributeValues[":c"].S+"/") &&
+				(in.ExclusiveStartKey == nil || k > key(in.ExclusiveStartKey)) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		items := []item{}
+		if len(keys) > 0 {
+			items = append(items, f.items[keys[0]])
+		}
+		if len(keys) > 1 {
+			out["LastEvaluatedKey"] = item{"collection": items[0]["collection"], "id": items[0]["id"]}
+		}
+		out["Items"] = items
+	case "UpdateItem":
+		k := key(in.Key)
+		existing, ok := f.items[k]
+		values := in.ExpressionAttributeValues
+		switch in.UpdateExpression {
+		case "SET #d = :d ADD #v :one":
+			version := "1"
+			if ok {
+				version = increment(existing["version"].N)
+			}
+			f.items[k] = item{"collection": in.Key["collection"], "id": in.Key["id"], "doc": values[":d"], "version": {N: version}}
+		case "SET #d = :d, #v = :next":
+			if in.ConditionExpression != "#v = :v" || !ok || existing["version"].N != values[":v"].N {
+				fail("ConditionalCheckFailedException")
+				return
+			}
+			f.items[k] = item{"collection": in.Key["collection"], "id": in.Key["id"], "doc": values[":d"], "version": values[":next"]}
+		default:
+			fail("ValidationException")
+			return
+		}
+	case "DeleteItem":
+		if i, ok := f.items[key(in.Key)]; ok {
+			out["Attributes"] = i
+			delete(f.items, key(in.Key))
+		}
+	default:
+		fail("UnknownOperationException")
+		return
+	}
+	json.NewEncoder(w).Encode(out)
+}
+
+func increment(n string) string {
+	var v int
+	json.Unmarshal([]byte(n), &v)
+	b, _ := json.Marshal(v + 1)
+	return string(b)
+}
@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	// the drivers of the supported databases
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// placeholders are the positional parameters of the queries, e.g. $1.
+var placeholders = regexp.MustCompile(`\$\d+`)
+
+// sqlStore keeps the documents in a table of an sql database.
+type sqlStore struct {
+	db      *sql.DB
+	backend string
+}
+
+func openSQL(backend, dsn string) (*sqlStore, error) {
+	driver := "postgres"
+	if backend == SQLite {
+		driver = "sqlite3"
+	}
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if backend == SQLite {
+		// sqlite doesn't allow concurrent writes
+		db.SetMaxOpenConns(1)
+	}
+	s := &sqlStore{db: db, backend: backend}
+	_, err = s.exec(context.Background(), `CREATE TABLE IF NOT EXISTS `+table+` (
+		collection VARCHAR(64) NOT NULL,
+		id VARCHAR(255) NOT NULL,
+		doc TEXT NOT NULL,
+		version BIGINT NOT NULL,
+		PRIMARY KEY (collection, id)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: unable to create the table %s: %v", logTag, table, err)
+	}
+	return s, nil
+}
+
+func (s *sqlStore) close() error {
+	return s.db.Close()
+}
+
+func (s *sqlStore) get(ctx context.Context, collection, id string) (string, int64, error) {
+	var doc string
+	var version int64
+	err := s.queryRow(ctx, `SELECT doc, version FROM `+table+` WHERE collection = $1 AND id = $2`, collection, id).
+		Scan(&doc, &version)
+	if err == sql.ErrNoRows {
+		return "", 0, ErrNotFound
+	}
+	return doc, version, err
+}
+
+func (s *sqlStore) list(ctx context.Context, collection string) ([]json.RawMessage, error) {
+	rows, err := s.db.QueryContext(ctx, s.bind(`SELECT doc FROM `+table+` WHERE collection = $1 ORDER BY id`), collection)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	docs := []json.RawMessage{}
+	for rows.Next() {
+		var doc string
+		if err := rows.Scan(&doc); err != nil {
+			return nil, err
+		}
+		docs = append(docs, json.RawMessage(doc))
+	}
+	return docs, rows.Err()
+}
+
+func (s *sqlStore) put(ctx context.Context, collection, id, doc string) error {
+	_, err := s.exec(ctx, `INSERT INTO `+table+` (collection, id, doc, version) VALUES ($1, $2, $3, 1)
+		ON CONFLICT (collection, id) DO UPDATE SET doc = excluded.doc, version = `+table+`.version + 1`,
+		collection, id, doc)
+	return err
+}
+
+func (s *sqlStore) replace(ctx context.Context, collection, id, doc string, version int64) (bool, error) {
+	result, err := s.exec(ctx, `UPDATE `+table+` SET doc = $1, version = version + 1
+		WHERE collection = $2 AND id = $3 AND version = $4`,
+		doc, collection, id, version)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (s *sqlStore) delete(ctx context.Context, collection, id string) (bool, error) {
+	result, err := s.exec(ctx, `DELETE FROM `+table+` WHERE collection = $1 AND id = $2`, collection, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (s *sqlStore) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.db.ExecContext(ctx, s.bind(query), args...)
+}
+
+func (s *sqlStore) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRowContext(ctx, s.bind(query), args...)
+}
+
+// bind rewrites the placeholders of the query for the database, the queries
+// are written with the placeholders of postgres.
+func (s *sqlStore) bind(query string) string {
+	if s.backend == SQLite {
+		return placeholders.ReplaceAllString(query, "?")
+	}
+	return query
+}
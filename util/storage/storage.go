@@ -0,0 +1,222 @@
+// Package storage keeps the internal documents of arc, i.e. the users and
+// the permissions, in an sql database or in dynamodb rather than in
+// elasticsearch, for the deployments that don't want the metadata of arc to
+// live in the cluster it serves. The documents are stored as json along with
+// their revision, which is handed to the clients as the entity tags of the
+// occ package.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	es7 "github.com/olivere/elastic/v7"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/occ"
+)
+
+const (
+	logTag     = "[storage]"
+	envBackend = "STORAGE_BACKEND"
+	envDSN     = "STORAGE_DSN"
+	table      = "arc_documents"
+)
+
+// The backends the documents can be stored in.
+const (
+	Elasticsearch = "elasticsearch"
+	Postgres      = "postgres"
+	SQLite        = "sqlite"
+	DynamoDB      = "dynamodb"
+)
+
+// The collections of the documents.
+const (
+	Users       = "users"
+	Permissions = "permissions"
+	PublicKeys  = "public_keys"
+)
+
+// ErrNotFound is returned when the document doesn't exist. It is the error
+// elasticsearch responds with, so that the missing documents are handled
+// alike whatever the backend.
+var ErrNotFound = &es7.Error{
+	Status:  http.StatusNotFound,
+	Details: &es7.ErrorDetails{Type: "document_missing_exception", Reason: "document not found"},
+}
+
+var (
+	singleton *Documents
+	initErr   error
+	once      sync.Once
+)
+
+// store is implemented by the backends, which keep the json documents
+// along with their version, incremented on each write.
+type store interface {
+	// get returns the document and its version, ErrNotFound if it doesn't
+	// exist.
+	get(ctx context.Context, collection, id string) (string, int64, error)
+	// list returns the documents of the collection ordered by their id.
+	list(ctx context.Context, collection string) ([]json.RawMessage, error)
+	// put creates or replaces the document.
+	put(ctx context.Context, collection, id, doc string) error
+	// replace replaces the document if it is still at the version, it
+	// reports whether it was replaced.
+	replace(ctx context.Context, collection, id, doc string, version int64) (bool, error)
+	// delete deletes the document, it reports whether the document existed.
+	delete(ctx context.Context, collection, id string) (bool, error)
+	close() error
+}
+
+// Documents is a collection of json documents stored in a database.
+type Documents struct {
+	store store
+}
+
+// Instance returns the documents of the backend selected by STORAGE_BACKEND,
+// or nil if the documents are kept in elasticsearch, the default.
+func Instance() (*Documents, error) {
+	once.Do(func() {
+		backend := os.Getenv(envBackend)
+		if backend == "" || backend == Elasticsearch {
+			return
+		}
+		singleton, initErr = Open(backend, os.Getenv(envDSN))
+		if initErr == nil {
+			log.Println(logTag, ": storing the users and the permissions in", backend)
+		}
+	})
+	return singleton, initErr
+}
+
+// Open opens the database of the backend at the data source name and creates
+// the table of the documents if it doesn't exist.
+func Open(backend, dsn string) (*Documents, error) {
+	if dsn == "" && (backend == Postgres || backend == SQLite || backend == DynamoDB) {
+		return nil, fmt.Errorf("%s is required for the %s backend", envDSN, backend)
+	}
+	var s store
+	var err error
+	switch backend {
+	case Postgres, SQLite:
+		s, err = openSQL(backend, dsn)
+	case DynamoDB:
+		s, err = openDynamoDB(dsn)
+	default:
+		return nil, fmt.Errorf("invalid value for %s: %s, must be one of %s, %s, %s or %s", envBackend, backend, Elasticsearch, Postgres, SQLite, DynamoDB)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Documents{store: s}, nil
+}
+
+// Close closes the database.
+func (d *Documents) Close() error {
+	return d.store.close()
+}
+
+// Get returns the document along with its revision.
+func (d *Documents) Get(ctx context.Context, collection, id string) ([]byte, occ.Version, error) {
+	doc, version, err := d.store.get(ctx, collection, id)
+	if err != nil {
+		return nil, occ.Version{}, err
+	}
+	return []byte(doc), revision(version), nil
+}
+
+// List returns the documents of the collection ordered by their id.
+func (d *Documents) List(ctx context.Context, collection string) ([]json.RawMessage, error) {
+	return d.store.list(ctx, collection)
+}
+
+// Put creates or replaces the document.
+func (d *Documents) Put(ctx context.Context, collection, id string, doc interface{}) error {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return d.store.put(ctx, collection, id, string(raw))
+}
+
+// Update applies the partial document to the document if it is still at the
+// revision, or unconditionally if the revision is nil. The objects of the
+// document are merged with the ones of the partial document, as the partial
+// updates of elasticsearch are. The update response is returned in the form
+// elasticsearch returns it, along with the new revision.
+func (d *Documents) Update(ctx context.Context, collection, id string, patch interface{}, v *occ.Version) ([]byte, occ.Version, error) {
+	var updated occ.Version
+	raw, current, err := d.Get(ctx, collection, id)
+	if err != nil {
+		return nil, updated, err
+	}
+	if v != nil && *v != current {
+		return nil, updated, occ.ErrConflict
+	}
+	var doc, partial map[string]interface{}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, updated, err
+	}
+	// the partial document is normalized to json values
+	rawPatch, err := json.Marshal(patch)
+	if err != nil {
+		return nil, updated, err
+	}
+	if err := json.Unmarshal(rawPatch, &partial); err != nil {
+		return nil, updated, err
+	}
+	merge(doc, partial)
+	if raw, err = json.Marshal(doc); err != nil {
+		return nil, updated, err
+	}
+
+	// the document is only updated if it wasn't updated since it was read
+	replaced, err := d.store.replace(ctx, collection, id, string(raw), current.SeqNo)
+	if err != nil {
+		return nil, updated, err
+	}
+	if !replaced {
+		return nil, updated, occ.ErrConflict
+	}
+	updated = revision(current.SeqNo + 1)
+	response, err := json.Marshal(es7.UpdateResponse{
+		Index:       collection,
+		Id:          id,
+		Version:     updated.SeqNo,
+		Result:      "updated",
+		SeqNo:       updated.SeqNo,
+		PrimaryTerm: updated.PrimaryTerm,
+	})
+	return response, updated, err
+}
+
+// Delete deletes the document, it reports whether the document existed.
+func (d *Documents) Delete(ctx context.Context, collection, id string) (bool, error) {
+	return d.store.delete(ctx, collection, id)
+}
+
+// revision returns the revision of the version of a document, the primary
+// term is always 1 since the documents aren't replicated by arc.
+func revision(version int64) occ.Version {
+	return occ.Version{SeqNo: version, PrimaryTerm: 1}
+}
+
+// merge merges the partial document into the document, recursively for the
+// objects of both.
+func merge(doc, partial map[string]interface{}) {
+	for key, value := range partial {
+		object, ok := value.(map[string]interface{})
+		existing, isObject := doc[key].(map[string]interface{})
+		if ok && isObject {
+			merge(existing, object)
+			continue
+		}
+		doc[key] = value
+	}
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/appbaseio/arc/util"
+	"github.com/appbaseio/arc/util/occ"
+)
+
+func TestDocuments(t *testing.T) {
+	d, err := Open(SQLite, filepath.Join(t.TempDir(), "arc.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	testDocuments(t, d)
+
+	Convey("Only the supported backends are opened", t, func() {
+		_, err := Open("mongodb", "arc")
+		So(err, ShouldNotBeNil)
+		_, err = Open(Postgres, "")
+		So(err, ShouldNotBeNil)
+		_, err = Open(DynamoDB, "")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+// testDocuments runs the cases shared by the backends against the documents.
+func testDocuments(t *testing.T, d *Documents) {
+	ctx := context.Background()
+
+	Convey("The documents are created and replaced", t, func() {
+		So(d.Put(ctx, Users, "foo", map[string]interface{}{"username": "foo", "acls": []string{"search"}}), ShouldBeNil)
+		raw, v, err := d.Get(ctx, Users, "foo")
+		So(err, ShouldBeNil)
+		So(string(raw), ShouldEqual, `{"acls":["search"],"username":"foo"}`)
+		So(v, ShouldResemble, occ.Version{SeqNo: 1, PrimaryTerm: 1})
+
+		So(d.Put(ctx, Users, "foo", map[string]interface{}{"username": "foo"}), ShouldBeNil)
+		_, v, _ = d.Get(ctx, Users, "foo")
+		So(v.SeqNo, ShouldEqual, 2)
+
+		_, _, err = d.Get(ctx, Permissions, "foo")
+		So(err, ShouldEqual, ErrNotFound)
+		So(util.IsNotFound(err), ShouldBeTrue)
+	})
+
+	Convey("The documents are listed by collection", t, func() {
+		So(d.Put(ctx, Users, "bar", map[string]interface{}{"username": "bar"}), ShouldBeNil)
+		So(d.Put(ctx, Permissions, "baz", map[string]interface{}{"username": "baz"}), ShouldBeNil)
+		docs, err := d.List(ctx, Users)
+		So(err, ShouldBeNil)
+		So(docs, ShouldResemble, []json.RawMessage{
+			json.RawMessage(`{"username":"bar"}`),
+			json.RawMessage(`{"username":"foo"}`),
+		})
+	})
+
+	Convey("The partial documents are merged if the revision matches", t, func() {
+		So(d.Put(ctx, Permissions, "abc", map[string]interface{}{"limits": map[string]interface{}{"ip_limit": 1, "docs_limit": 2}}), ShouldBeNil)
+		_, v, _ := d.Get(ctx, Permissions, "abc")
+		_, updated, err := d.Update(ctx, Permissions, "abc", map[string]interface{}{"limits": map[string]interface{}{"ip_limit": 5}, "expired": nil}, &v)
+		So(err, ShouldBeNil)
+		So(updated.SeqNo, ShouldEqual, v.SeqNo+1)
+		raw, _, _ := d.Get(ctx, Permissions, "abc")
+		So(string(raw), ShouldEqual, `{"expired":null,"limits":{"docs_limit":2,"ip_limit":5}}`)
+
+		_, _, err = d.Update(ctx, Permissions, "abc", map[string]interface{}{"expired": true}, &v)
+		So(err, ShouldEqual, occ.ErrConflict)
+		_, _, err = d.Update(ctx, Permissions, "missing", map[string]interface{}{"expired": true}, nil)
+		So(err, ShouldEqual, ErrNotFound)
+	})
+
+	Convey("The deletions report whether the document existed", t, func() {
+		deleted, err := d.Delete(ctx, Users, "bar")
+		So(err, ShouldBeNil)
+		So(deleted, ShouldBeTrue)
+		deleted, _ = d.Delete(ctx, Users, "bar")
+		So(deleted, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,77 @@
+package util
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// TeeResponseWriter forwards the response to the underlying writer as it is
+// written while retaining a copy of up to limit bytes of the body, so that
+// the response can be inspected once served without delaying the client.
+type TeeResponseWriter struct {
+	http.ResponseWriter
+	code        int
+	wroteHeader bool
+	limit       int
+	size        int64
+	body        bytes.Buffer
+}
+
+// NewTeeResponseWriter returns a writer that copies up to limit bytes of the
+// body written to w, a non-positive limit retains no body.
+func NewTeeResponseWriter(w http.ResponseWriter, limit int) *TeeResponseWriter {
+	return &TeeResponseWriter{ResponseWriter: w, code: http.StatusOK, limit: limit}
+}
+
+// WriteHeader records and forwards the status code.
+func (t *TeeResponseWriter) WriteHeader(code int) {
+	if t.wroteHeader {
+		return
+	}
+	t.code = code
+	t.wroteHeader = true
+	t.ResponseWriter.WriteHeader(code)
+}
+
+// Write forwards the bytes to the client and copies them within the limit.
+func (t *TeeResponseWriter) Write(p []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+	if remaining := t.limit - t.body.Len(); remaining > 0 {
+		if len(p) < remaining {
+			remaining = len(p)
+		}
+		t.body.Write(p[:remaining])
+	}
+	n, err := t.ResponseWriter.Write(p)
+	t.size += int64(n)
+	return n, err
+}
+
+// Flush sends the buffered data to the client if supported by the underlying writer.
+func (t *TeeResponseWriter) Flush() {
+	if f, ok := t.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Code returns the status code of the response.
+func (t *TeeResponseWriter) Code() int {
+	return t.code
+}
+
+// Body returns the retained copy of the body.
+func (t *TeeResponseWriter) Body() []byte {
+	return t.body.Bytes()
+}
+
+// Size returns the number of bytes written to the client.
+func (t *TeeResponseWriter) Size() int64 {
+	return t.size
+}
+
+// Truncated reports whether the body exceeded the limit of the copy.
+func (t *TeeResponseWriter) Truncated() bool {
+	return t.size > int64(t.body.Len())
+}
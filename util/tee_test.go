@@ -0,0 +1,35 @@
+package util
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTeeResponseWriter(t *testing.T) {
+	Convey("The response is forwarded while a bounded copy is retained", t, func() {
+		w := httptest.NewRecorder()
+		tee := NewTeeResponseWriter(w, 8)
+		tee.WriteHeader(http.StatusCreated)
+		tee.Write([]byte("hello "))
+		tee.Write([]byte("world"))
+
+		So(w.Code, ShouldEqual, http.StatusCreated)
+		So(w.Body.String(), ShouldEqual, "hello world")
+		So(tee.Code(), ShouldEqual, http.StatusCreated)
+		So(string(tee.Body()), ShouldEqual, "hello wo")
+		So(tee.Size(), ShouldEqual, 11)
+		So(tee.Truncated(), ShouldBeTrue)
+	})
+
+	Convey("The status defaults to ok", t, func() {
+		w := httptest.NewRecorder()
+		tee := NewTeeResponseWriter(w, 64)
+		tee.Write([]byte("{}"))
+		So(tee.Code(), ShouldEqual, http.StatusOK)
+		So(string(tee.Body()), ShouldEqual, "{}")
+		So(tee.Truncated(), ShouldBeFalse)
+	})
+}
@@ -18,7 +18,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	es7 "github.com/olivere/elastic/v7"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/appbaseio/arc/util/chaos"
 )
 
 // Billing is a build time variable
@@ -30,6 +33,9 @@ var HostedBilling string
 // ClusterBilling is a build time variable
 var ClusterBilling string
 
+// Version is a build time variable
+var Version string
+
 // RandStr returns "node" field of a UUID.
 // See: https://tools.ietf.org/html/rfc4122#section-4.1.6
 func RandStr() string {
@@ -37,8 +43,39 @@ func RandStr() string {
 	return tokens[len(tokens)-1]
 }
 
+// RequestIDHeader is the header carrying the unique identifier of the request,
+// which is echoed in the error responses to correlate them with the logs.
+const RequestIDHeader = "X-Request-Id"
+
+// ErrorEnvelope is the body of all the error responses served by arc.
+type ErrorEnvelope struct {
+	Error ErrorBody `json:"error"`
+}
+
+// ErrorBody describes the error, the details are optional.
+type ErrorBody struct {
+	Code      int           `json:"code"`
+	Status    string        `json:"status"`
+	Message   string        `json:"message"`
+	RequestID string        `json:"request_id,omitempty"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail is a single cause of an error, such as an invalid field or the
+// root cause reported by elasticsearch.
+type ErrorDetail struct {
+	Type     string `json:"type,omitempty"`
+	Reason   string `json:"reason"`
+	Location string `json:"location,omitempty"`
+}
+
 // WriteBackMessage writes the given message as a json response to the response writer.
+// Messages with an error status code are written in the error envelope.
 func WriteBackMessage(w http.ResponseWriter, message string, code int) {
+	if code >= http.StatusBadRequest {
+		WriteBackError(w, message, code)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.WriteHeader(code)
@@ -55,17 +92,24 @@ func WriteBackMessage(w http.ResponseWriter, message string, code int) {
 
 // WriteBackError writes the given error message as a json response to the response writer.
 func WriteBackError(w http.ResponseWriter, err string, code int) {
+	WriteBackErrorWithDetails(w, err, code, nil)
+}
+
+// WriteBackErrorWithDetails writes the given error message along with its causes
+// as a json response to the response writer.
+func WriteBackErrorWithDetails(w http.ResponseWriter, err string, code int, details []ErrorDetail) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.WriteHeader(code)
-	msg := map[string]interface{}{
-		"error": map[string]interface{}{
-			"code":    code,
-			"status":  http.StatusText(code),
-			"message": err,
+	json.NewEncoder(w).Encode(ErrorEnvelope{
+		Error: ErrorBody{
+			Code:      code,
+			Status:    http.StatusText(code),
+			Message:   err,
+			RequestID: w.Header().Get(RequestIDHeader),
+			Details:   details,
 		},
-	}
-	json.NewEncoder(w).Encode(msg)
+	})
 }
 
 // WriteBackRaw writes the given json encoded bytes to the response writer.
@@ -76,6 +120,38 @@ func WriteBackRaw(w http.ResponseWriter, raw []byte, code int) {
 	w.Write(raw)
 }
 
+// WriteBackESError writes back the status and reason returned by elasticsearch
+// for the given error if available, otherwise it responds with the message
+// and http.StatusInternalServerError. The root causes are written as the details.
+func WriteBackESError(w http.ResponseWriter, msg string, err error) {
+	if e, ok := err.(*es7.Error); ok && e.Status != 0 {
+		var details []ErrorDetail
+		if e.Details != nil {
+			if e.Details.Reason != "" {
+				msg = e.Details.Reason
+			}
+			for _, cause := range e.Details.RootCause {
+				if cause == nil {
+					continue
+				}
+				details = append(details, ErrorDetail{
+					Type:     cause.Type,
+					Reason:   cause.Reason,
+					Location: cause.Index,
+				})
+			}
+		}
+		WriteBackErrorWithDetails(w, msg, e.Status, details)
+		return
+	}
+	WriteBackError(w, msg, http.StatusInternalServerError)
+}
+
+// NewRequestID returns a unique identifier for a request.
+func NewRequestID() string {
+	return uuid.New().String()
+}
+
 // Contains checks the presence of a string in the given string slice.
 func Contains(slice []string, val string) bool {
 	for _, v := range slice {
@@ -205,7 +281,7 @@ func HTTPClient() *http.Client {
 		}
 		var netClient = &http.Client{
 			Timeout:   time.Minute * 2,
-			Transport: netTransport,
+			Transport: chaos.Transport(netTransport),
 		}
 		client = netClient
 	})
@@ -0,0 +1,57 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	es7 "github.com/olivere/elastic/v7"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWriteBackError(t *testing.T) {
+	Convey("Errors are written in the envelope", t, func() {
+		w := httptest.NewRecorder()
+		w.Header().Set(RequestIDHeader, "abc")
+		WriteBackError(w, "denied", http.StatusForbidden)
+
+		var envelope ErrorEnvelope
+		So(json.Unmarshal(w.Body.Bytes(), &envelope), ShouldBeNil)
+		So(w.Code, ShouldEqual, http.StatusForbidden)
+		So(envelope.Error.Code, ShouldEqual, http.StatusForbidden)
+		So(envelope.Error.Status, ShouldEqual, "Forbidden")
+		So(envelope.Error.Message, ShouldEqual, "denied")
+		So(envelope.Error.RequestID, ShouldEqual, "abc")
+	})
+
+	Convey("Error messages are written in the envelope", t, func() {
+		w := httptest.NewRecorder()
+		WriteBackMessage(w, "slow down", http.StatusTooManyRequests)
+
+		var envelope ErrorEnvelope
+		So(json.Unmarshal(w.Body.Bytes(), &envelope), ShouldBeNil)
+		So(envelope.Error.Message, ShouldEqual, "slow down")
+	})
+
+	Convey("Elasticsearch root causes are written as details", t, func() {
+		w := httptest.NewRecorder()
+		WriteBackESError(w, "unable to search", &es7.Error{
+			Status: http.StatusNotFound,
+			Details: &es7.ErrorDetails{
+				Reason: "no such index [foo]",
+				RootCause: []*es7.ErrorDetails{
+					{Type: "index_not_found_exception", Reason: "no such index [foo]", Index: "foo"},
+				},
+			},
+		})
+
+		var envelope ErrorEnvelope
+		So(json.Unmarshal(w.Body.Bytes(), &envelope), ShouldBeNil)
+		So(w.Code, ShouldEqual, http.StatusNotFound)
+		So(envelope.Error.Message, ShouldEqual, "no such index [foo]")
+		So(envelope.Error.Details, ShouldResemble, []ErrorDetail{
+			{Type: "index_not_found_exception", Reason: "no such index [foo]", Location: "foo"},
+		})
+	})
+}